@@ -2,7 +2,9 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"os"
@@ -15,7 +17,9 @@ import (
 	"github.com/saintparish4/harmonia/config"
 	"github.com/saintparish4/harmonia/database"
 	"github.com/saintparish4/harmonia/internal/domain"
+	"github.com/saintparish4/harmonia/internal/dto"
 	"github.com/saintparish4/harmonia/internal/handlers"
+	"github.com/saintparish4/harmonia/internal/metrics"
 	"github.com/saintparish4/harmonia/internal/middleware"
 	"github.com/saintparish4/harmonia/internal/repository"
 	"github.com/saintparish4/harmonia/internal/service"
@@ -36,10 +40,26 @@ type Dependencies struct {
 	DomainUserRepo           domain.UserRepository
 	DomainPricingRuleRepo    domain.PricingRuleRepository
 	DomainProductRepo        domain.ProductRepository
+	DomainCategoryRepo       domain.CategoryRepository
+	DomainPriceBookRepo      domain.PriceBookRepository
+	DomainWebhookRepo        domain.WebhookRepository
+	DomainIdempotencyRepo    domain.IdempotencyRepository
 	DomainCalculationLogRepo domain.CalculationLogRepository
 
 	// Service
-	PricingEngine *service.PricingEngine
+	PricingEngine         *service.PricingEngine
+	BacktestService       *service.BacktestService
+	RuleResolver          *service.RuleResolver
+	RulePublisher         *service.RulePublisher
+	BundleService         *service.BundleService
+	ProductCatalogService *service.ProductCatalogService
+	ProductCostService    *service.ProductCostService
+	PriceBookService      *service.PriceBookService
+	WebhookService        *service.WebhookService
+	LogRetentionService   *service.LogRetentionService
+	CalculationLogWriter  *service.CalculationLogWriter
+	LogExportService      *service.CalculationLogExportService
+	LogReplayService      *service.CalculationReplayService
 }
 
 // Server represents the HTTP server
@@ -58,7 +78,7 @@ func NewServer(cfg *config.Config, deps *Dependencies) *Server {
 }
 
 // Setup configures the router with all middleware and routes
-func (s *Server) Setup() {
+func (s *Server) Setup() error {
 	// Set Gin mode
 	if s.config.IsProduction() {
 		gin.SetMode(gin.ReleaseMode)
@@ -67,18 +87,33 @@ func (s *Server) Setup() {
 	// Create router
 	s.router = gin.New()
 
+	// Client IPs come from X-Forwarded-For only when a configured proxy sent
+	// it; otherwise any caller could choose the IP that audit logs record and
+	// the rate limiter counts against
+	if err := s.router.SetTrustedProxies(s.config.Security.TrustedProxies); err != nil {
+		return fmt.Errorf("invalid trusted proxies: %w", err)
+	}
+
 	// Apply global middleware
 	s.setupMiddleware()
 
 	// Setup routes
 	s.setupRoutes()
+
+	return nil
 }
 
 // setupMiddleware configures global middleware
 func (s *Server) setupMiddleware() {
-	// Recovery middleware (must be first)
+	// Request metrics, outside recovery so recovered panics count as 500s
+	s.router.Use(middleware.Metrics())
+
+	// Recovery middleware
 	s.router.Use(middleware.Recovery())
 
+	// Request IDs, before logging so every log line carries one
+	s.router.Use(middleware.RequestID())
+
 	// Request logging
 	s.router.Use(middleware.RequestLogger())
 
@@ -100,8 +135,8 @@ func (s *Server) buildCORSConfig() *middleware.CORSConfig {
 	return &middleware.CORSConfig{
 		AllowedOrigins:   []string{s.config.Security.CORSOrigins},
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Origin", "Content-Type", "Authorization", "X-API-Key"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowedHeaders:   []string{"Origin", "Content-Type", "Authorization", "X-API-Key", middleware.IdempotencyKeyHeader, middleware.RequestIDHeader},
+		ExposeHeaders:    []string{"Content-Length", middleware.IdempotentReplayedHeader, middleware.RequestIDHeader},
 		AllowCredentials: false,
 		MaxAge:           3600,
 	}
@@ -111,7 +146,9 @@ func (s *Server) buildCORSConfig() *middleware.CORSConfig {
 func (s *Server) setupRoutes() {
 	// Create handler-specific adapters
 	authMiddleware := middleware.NewAuthMiddleware(&APIKeyValidatorAdapter{repo: s.deps.DomainAPIKeyRepo})
+	idempotency := middleware.NewIdempotency(&IdempotencyStoreAdapter{repo: s.deps.DomainIdempotencyRepo}, s.config.API.IdempotencyKeyTTL, s.config.API.MaxRequestSize)
 	healthHandler := handlers.NewHealthHandler(&DBHealthChecker{db: database.DB})
+	metricsHandler := handlers.NewMetricsHandler(metrics.Default)
 
 	// Create handler-layer adapters
 	keysAPIKeyRepo := &HandlerAPIKeyRepo{domainRepo: s.deps.DomainAPIKeyRepo}
@@ -120,21 +157,49 @@ func (s *Server) setupRoutes() {
 
 	rulesRepo := &HandlerRulesRepo{domainRepo: s.deps.DomainPricingRuleRepo}
 	productsRepo := &HandlerProductsRepo{domainRepo: s.deps.DomainProductRepo}
+	categoriesRepo := &HandlerCategoriesRepo{domainRepo: s.deps.DomainCategoryRepo}
 	logsRepo := &HandlerLogsRepo{domainRepo: s.deps.DomainCalculationLogRepo}
+	analyticsRepo := &HandlerAnalytics{domainRepo: s.deps.DomainCalculationLogRepo}
 
-	pricingEngineHandler := &HandlerPricingEngine{engine: s.deps.PricingEngine}
-	calculationLogger := &HandlerCalculationLogger{domainRepo: s.deps.DomainCalculationLogRepo}
+	pricingEngineHandler := &HandlerPricingEngine{
+		engine:             s.deps.PricingEngine,
+		resolver:           s.deps.RuleResolver,
+		costs:              s.deps.ProductCostService,
+		maxSimulationCells: s.config.API.SimulationMaxCells,
+	}
+	calculationLogger := &HandlerCalculationLogger{writer: s.deps.CalculationLogWriter}
+	backtestRunner := &HandlerBacktestRunner{service: s.deps.BacktestService}
+	rulePublisher := &HandlerRulePublisher{publisher: s.deps.RulePublisher}
+	accountPolicies := &HandlerAccountPolicyStore{domainRepo: s.deps.DomainUserRepo}
+	ruleBundler := &HandlerRuleBundler{service: s.deps.BundleService}
+	productCatalog := &HandlerProductCatalog{service: s.deps.ProductCatalogService}
+	productCosts := &HandlerProductCosts{service: s.deps.ProductCostService}
+	priceBooks := &HandlerPriceBooks{service: s.deps.PriceBookService}
+	webhooks := &HandlerWebhooks{service: s.deps.WebhookService}
 
 	// Initialize handlers
 	keysHandler := handlers.NewKeysHandler(keysAPIKeyRepo, keysUserRepo, keyGenerator)
 	pricingHandler := handlers.NewPricingHandler(pricingEngineHandler, calculationLogger)
-	rulesHandler := handlers.NewRulesHandler(rulesRepo)
-	productsHandler := handlers.NewProductsHandler(productsRepo)
-	logsHandler := handlers.NewLogsHandler(logsRepo)
+	rulesHandler := handlers.NewRulesHandler(rulesRepo, pricingEngineHandler, rulePublisher)
+	productsHandler := handlers.NewProductsHandler(productsRepo, rulesRepo, categoriesRepo, productCosts)
+	categoriesHandler := handlers.NewCategoriesHandler(categoriesRepo, rulesRepo)
+	logExporter := &HandlerLogExporter{service: s.deps.LogExportService}
+	logReplayer := &HandlerLogReplayer{service: s.deps.LogReplayService}
+	logsHandler := handlers.NewLogsHandler(logsRepo, logExporter, logReplayer)
+	analyticsHandler := handlers.NewAnalyticsHandler(analyticsRepo)
+	backtestsHandler := handlers.NewBacktestsHandler(rulesRepo, pricingEngineHandler, backtestRunner)
+	accountHandler := handlers.NewAccountHandler(accountPolicies, rulesRepo)
+	bundlesHandler := handlers.NewBundlesHandler(ruleBundler)
+	catalogHandler := handlers.NewCatalogHandler(productCatalog)
+	priceBooksHandler := handlers.NewPriceBooksHandler(priceBooks)
+	webhooksHandler := handlers.NewWebhooksHandler(webhooks)
 
 	// Health check (public)
 	s.router.GET("/health", healthHandler.Check)
 
+	// Prometheus metrics (protected by the metrics token)
+	s.router.GET("/metrics", middleware.MetricsAuth(s.config.Security.MetricsToken), metricsHandler.Get)
+
 	// Root endpoint
 	s.router.GET("/", rootHandler)
 
@@ -147,22 +212,39 @@ func (s *Server) setupRoutes() {
 
 		// Auth routes (protected by API key)
 		auth := v1.Group("/auth")
-		auth.Use(authMiddleware.Authenticate())
+		auth.Use(authMiddleware.Authenticate(), idempotency.Middleware())
 		{
 			auth.POST("/keys", keysHandler.Create)
 			auth.GET("/keys", keysHandler.List)
 			auth.DELETE("/keys/:id", keysHandler.Revoke)
 		}
 
+		// Account routes (protected)
+		account := v1.Group("/account")
+		account.Use(authMiddleware.Authenticate(), idempotency.Middleware())
+		{
+			account.GET("/policy", accountHandler.GetPolicy)
+			account.PUT("/default-rule", accountHandler.SetDefaultRule)
+		}
+
+		// Admin routes (protected by the admin key)
+		admin := v1.Group("/admin")
+		admin.Use(middleware.AdminAuth(s.config.Security.AdminAPIKey))
+		{
+			admin.PUT("/users/:id/policy", accountHandler.SetPolicy)
+			admin.PUT("/api-keys/:id/approver", keysHandler.SetApprover)
+		}
+
 		// Pricing routes
 		pricing := v1.Group("/pricing")
 		{
 			// List strategies (public or optionally authenticated)
 			pricing.GET("/strategies", authMiddleware.OptionalAuth(), pricingHandler.ListStrategies)
+			pricing.GET("/strategies/:type/schema", authMiddleware.OptionalAuth(), pricingHandler.GetStrategySchema)
 
 			// Protected pricing endpoints
 			pricingAuth := pricing.Group("")
-			pricingAuth.Use(authMiddleware.Authenticate())
+			pricingAuth.Use(authMiddleware.Authenticate(), idempotency.Middleware())
 			{
 				// Price calculation
 				pricingAuth.POST("/calculate", pricingHandler.Calculate)
@@ -170,21 +252,99 @@ func (s *Server) setupRoutes() {
 				// Pricing rules CRUD
 				pricingAuth.GET("/rules", rulesHandler.List)
 				pricingAuth.POST("/rules", rulesHandler.Create)
+				pricingAuth.POST("/rules/validate", rulesHandler.Validate)
+				pricingAuth.GET("/rules/drafts", rulesHandler.ListDrafts)
 				pricingAuth.GET("/rules/:id", rulesHandler.Get)
 				pricingAuth.PUT("/rules/:id", rulesHandler.Update)
 				pricingAuth.DELETE("/rules/:id", rulesHandler.Delete)
+				pricingAuth.POST("/rules/:id/simulate", rulesHandler.Simulate)
+
+				// Rule revision history
+				pricingAuth.GET("/rules/:id/revisions", rulesHandler.ListRevisions)
+				pricingAuth.GET("/rules/:id/revisions/:revision", rulesHandler.GetRevision)
+				pricingAuth.GET("/rules/:id/diff", rulesHandler.Diff)
+				pricingAuth.POST("/rules/:id/rollback", rulesHandler.Rollback)
+
+				// Draft review and publishing
+				pricingAuth.GET("/rules/:id/draft", rulesHandler.GetDraft)
+				pricingAuth.DELETE("/rules/:id/draft", rulesHandler.DiscardDraft)
+				pricingAuth.POST("/rules/:id/draft/approve", rulesHandler.ApproveDraft)
+
+				// Rule bundles for copying rules between accounts
+				pricingAuth.GET("/bundles/export", bundlesHandler.Export)
+				pricingAuth.POST("/bundles/import", bundlesHandler.Import)
+
+				// Backtests against historical calculation logs
+				pricingAuth.POST("/backtests", backtestsHandler.Create)
+				pricingAuth.GET("/backtests/:id", backtestsHandler.Get)
+				pricingAuth.GET("/backtests/:id/events", backtestsHandler.Events)
 			}
 		}
 
 		// Products routes (protected)
 		products := v1.Group("/products")
-		products.Use(authMiddleware.Authenticate())
+		products.Use(authMiddleware.Authenticate(), idempotency.Middleware())
 		{
 			products.GET("", productsHandler.List)
 			products.POST("", productsHandler.Create)
 			products.GET("/:id", productsHandler.Get)
 			products.PUT("/:id", productsHandler.Update)
 			products.DELETE("/:id", productsHandler.Delete)
+
+			// Bulk import and export
+			products.POST("/import", catalogHandler.Import)
+			products.GET("/imports/:id", catalogHandler.GetImport)
+			products.GET("/export", catalogHandler.Export)
+
+			// Scheduled default rules
+			products.GET("/:id/rule-schedule", productsHandler.ListRuleSchedule)
+			products.POST("/:id/rule-schedule", productsHandler.AddRuleSchedule)
+			products.DELETE("/:id/rule-schedule/:assignment_id", productsHandler.DeleteRuleSchedule)
+
+			// Effective-dated cost history
+			products.GET("/:id/costs", productsHandler.ListCosts)
+			products.POST("/:id/costs", productsHandler.AddCost)
+			products.POST("/:id/costs/:cost_id/recompute", productsHandler.RecomputeCost)
+		}
+
+		// Category tree routes (protected)
+		categories := v1.Group("/categories")
+		categories.Use(authMiddleware.Authenticate(), idempotency.Middleware())
+		{
+			categories.GET("", categoriesHandler.List)
+			categories.POST("", categoriesHandler.Create)
+			categories.GET("/:id", categoriesHandler.Get)
+			categories.PUT("/:id", categoriesHandler.Update)
+			categories.DELETE("/:id", categoriesHandler.Delete)
+		}
+
+		// Price book routes (protected)
+		priceBookRoutes := v1.Group("/price-books")
+		priceBookRoutes.Use(authMiddleware.Authenticate(), idempotency.Middleware())
+		{
+			priceBookRoutes.GET("", priceBooksHandler.List)
+			priceBookRoutes.POST("", priceBooksHandler.Create)
+			priceBookRoutes.GET("/:id", priceBooksHandler.Get)
+			priceBookRoutes.PUT("/:id", priceBooksHandler.Update)
+			priceBookRoutes.DELETE("/:id", priceBooksHandler.Delete)
+			priceBookRoutes.POST("/:id/regenerate", priceBooksHandler.Regenerate)
+			priceBookRoutes.GET("/:id/entries", priceBooksHandler.Entries)
+			priceBookRoutes.GET("/:id/export", priceBooksHandler.Export)
+		}
+
+		// Webhook routes (protected)
+		webhookRoutes := v1.Group("/webhooks")
+		webhookRoutes.Use(authMiddleware.Authenticate(), idempotency.Middleware())
+		{
+			webhookRoutes.GET("", webhooksHandler.List)
+			webhookRoutes.POST("", webhooksHandler.Create)
+			webhookRoutes.GET("/:id", webhooksHandler.Get)
+			webhookRoutes.PUT("/:id", webhooksHandler.Update)
+			webhookRoutes.DELETE("/:id", webhooksHandler.Delete)
+			webhookRoutes.POST("/:id/rotate-secret", webhooksHandler.RotateSecret)
+			webhookRoutes.GET("/:id/deliveries", webhooksHandler.Deliveries)
+			webhookRoutes.GET("/:id/deliveries/:delivery_id", webhooksHandler.GetDelivery)
+			webhookRoutes.POST("/:id/deliveries/:delivery_id/replay", webhooksHandler.Replay)
 		}
 
 		// Logs routes (protected)
@@ -192,6 +352,16 @@ func (s *Server) setupRoutes() {
 		logs.Use(authMiddleware.Authenticate())
 		{
 			logs.GET("", logsHandler.List)
+			logs.GET("/export", logsHandler.Export)
+			logs.GET("/:id", logsHandler.Get)
+			logs.POST("/:id/replay", logsHandler.Replay)
+		}
+
+		// Analytics routes (protected)
+		analytics := v1.Group("/analytics")
+		analytics.Use(authMiddleware.Authenticate())
+		{
+			analytics.GET("", analyticsHandler.Get)
 		}
 	}
 
@@ -229,6 +399,15 @@ func (s *Server) Start() error {
 	shutdown := make(chan os.Signal, 1)
 	signal.Notify(shutdown, os.Interrupt, syscall.SIGTERM)
 
+	// Write the logs of the requests that finished, however the server stops
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := s.deps.CalculationLogWriter.Close(ctx); err != nil {
+			log.Printf("Error flushing calculation logs: %v", err)
+		}
+	}()
+
 	// Block until we receive a signal or error
 	select {
 	case err := <-serverErrors:
@@ -283,11 +462,34 @@ func StartServer() {
 	}
 
 	// Initialize dependencies
-	deps := initializeDependencies()
+	deps := initializeDependencies(cfg)
+
+	// Keep price books current and deliver webhooks in the background; work
+	// still running when the server stops is allowed to finish
+	refreshCtx, stopRefresh := context.WithCancel(context.Background())
+	if cfg.API.PriceBookRefreshInterval > 0 {
+		go deps.PriceBookService.Run(refreshCtx, cfg.API.PriceBookRefreshInterval)
+	}
+	if cfg.API.WebhookDispatchInterval > 0 {
+		go deps.WebhookService.Run(refreshCtx, cfg.API.WebhookDispatchInterval)
+	}
+	if cfg.API.LogMaintenanceInterval > 0 {
+		go deps.LogRetentionService.Run(refreshCtx, cfg.API.LogMaintenanceInterval)
+	}
+	go purgeIdempotencyKeys(refreshCtx, deps.DomainIdempotencyRepo)
+	deps.CalculationLogWriter.Start()
+	defer deps.PriceBookService.Wait()
+	defer deps.WebhookService.Wait()
+	defer deps.LogRetentionService.Wait()
+	defer stopRefresh()
+	defer deps.BacktestService.Stop()
+	defer deps.ProductCatalogService.Stop()
 
 	// Create and setup server
 	server := NewServer(cfg, deps)
-	server.Setup()
+	if err := server.Setup(); err != nil {
+		log.Fatalf("Failed to set up server: %v", err)
+	}
 
 	// Start server
 	if err := server.Start(); err != nil {
@@ -295,25 +497,93 @@ func StartServer() {
 	}
 }
 
+// purgeIdempotencyKeys deletes expired idempotency keys every hour until ctx
+// is cancelled. Expired keys are never replayed, so this only reclaims space.
+func purgeIdempotencyKeys(ctx context.Context, repo domain.IdempotencyRepository) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := repo.DeleteExpired(ctx, time.Now()); err != nil {
+				log.Printf("Failed to delete expired idempotency keys: %v", err)
+			}
+		}
+	}
+}
+
 // initializeDependencies creates all repositories and services
-func initializeDependencies() *Dependencies {
+func initializeDependencies(cfg *config.Config) *Dependencies {
 	// Initialize domain repositories
 	domainAPIKeyRepo := repository.NewAPIKeyRepository(database.DB)
 	domainUserRepo := repository.NewUserRepository(database.DB)
 	domainPricingRuleRepo := repository.NewPricingRuleRepository(database.DB)
 	domainProductRepo := repository.NewProductRepository(database.DB)
+	domainCategoryRepo := repository.NewCategoryRepository(database.DB)
 	domainCalculationLogRepo := repository.NewCalculationLogRepository(database.DB)
+	domainBundleRepo := repository.NewBundleRepository(database.DB)
+	domainPriceBookRepo := repository.NewPriceBookRepository(database.DB)
+	domainWebhookRepo := repository.NewWebhookRepository(database.DB)
+	domainIdempotencyRepo := repository.NewIdempotencyRepository(database.DB)
+	domainLogPartitionRepo := repository.NewCalculationLogPartitionRepository(database.DB)
 
 	// Initialize services
 	pricingEngine := service.NewPricingEngine()
+	backtestService := service.NewBacktestService(pricingEngine, domainCalculationLogRepo)
+	ruleResolver := service.NewRuleResolver(domainPricingRuleRepo, domainProductRepo, domainCategoryRepo, domainUserRepo)
+	rulePublisher := service.NewRulePublisher(domainPricingRuleRepo, domainUserRepo, domainAPIKeyRepo)
+	bundleService := service.NewBundleService(pricingEngine, domainPricingRuleRepo, domainProductRepo, domainUserRepo, domainBundleRepo)
+	productCatalogService := service.NewProductCatalogService(domainProductRepo, domainPricingRuleRepo)
+	productCostService := service.NewProductCostService(pricingEngine, ruleResolver, domainProductRepo)
+	priceBookService := service.NewPriceBookService(pricingEngine, ruleResolver, domainProductRepo, domainPriceBookRepo, cfg.API.PriceBookMaxEntries)
+	webhookService := service.NewWebhookService(domainWebhookRepo, cfg.API.WebhookTimeout, cfg.API.WebhookMaxAttempts, cfg.API.WebhookAllowPrivate)
+	logRetentionService := service.NewLogRetentionService(domainLogPartitionRepo, service.LogRetentionConfig{
+		PartitionsAhead: cfg.API.LogPartitionsAhead,
+		RetentionDays:   cfg.API.LogRetentionDays,
+		Mode:            cfg.API.LogRetentionMode,
+		ArchiveDir:      cfg.API.LogArchiveDir,
+	})
+	calculationLogWriter := service.NewCalculationLogWriter(domainCalculationLogRepo, service.CalculationLogWriterConfig{
+		QueueSize:      cfg.API.CalculationLogQueueSize,
+		BatchSize:      cfg.API.CalculationLogBatchSize,
+		FlushInterval:  cfg.API.CalculationLogFlushInterval,
+		EnqueueTimeout: cfg.API.CalculationLogEnqueueTimeout,
+		SpillPath:      cfg.API.CalculationLogSpillPath,
+	})
+
+	// Pool and log queue statistics are read on every scrape
+	metrics.Default.RegisterDBStats(database.DB.Stats)
+	registerCalculationLogMetrics(metrics.Default, calculationLogWriter)
+
+	logExportService := service.NewCalculationLogExportService(domainCalculationLogRepo)
+	logReplayService := service.NewCalculationReplayService(pricingEngine, domainPricingRuleRepo)
 
 	return &Dependencies{
 		DomainAPIKeyRepo:         domainAPIKeyRepo,
 		DomainUserRepo:           domainUserRepo,
 		DomainPricingRuleRepo:    domainPricingRuleRepo,
 		DomainProductRepo:        domainProductRepo,
+		DomainCategoryRepo:       domainCategoryRepo,
+		DomainPriceBookRepo:      domainPriceBookRepo,
+		DomainWebhookRepo:        domainWebhookRepo,
+		DomainIdempotencyRepo:    domainIdempotencyRepo,
 		DomainCalculationLogRepo: domainCalculationLogRepo,
 		PricingEngine:            pricingEngine,
+		BacktestService:          backtestService,
+		RuleResolver:             ruleResolver,
+		RulePublisher:            rulePublisher,
+		BundleService:            bundleService,
+		ProductCatalogService:    productCatalogService,
+		ProductCostService:       productCostService,
+		PriceBookService:         priceBookService,
+		WebhookService:           webhookService,
+		LogRetentionService:      logRetentionService,
+		CalculationLogWriter:     calculationLogWriter,
+		LogExportService:         logExportService,
+		LogReplayService:         logReplayService,
 	}
 }
 
@@ -324,17 +594,17 @@ type APIKeyValidatorAdapter struct {
 	repo domain.APIKeyRepository
 }
 
-func (a *APIKeyValidatorAdapter) ValidateKey(ctx context.Context, keyHash string) (uuid.UUID, bool, error) {
+func (a *APIKeyValidatorAdapter) ValidateKey(ctx context.Context, keyHash string) (uuid.UUID, uuid.UUID, bool, error) {
 	key, err := a.repo.GetByHash(ctx, repository.HashAPIKey(keyHash))
 	if err != nil {
-		return uuid.Nil, false, err
+		return uuid.Nil, uuid.Nil, false, err
 	}
 	if !key.IsActive {
-		return uuid.Nil, false, nil
+		return uuid.Nil, uuid.Nil, false, nil
 	}
 	// Update last used timestamp (fire and forget)
 	go a.repo.UpdateLastUsed(context.Background(), key.ID)
-	return key.UserID, true, nil
+	return key.UserID, key.ID, true, nil
 }
 
 // DBHealthChecker wraps database.DB to implement handlers.HealthChecker
@@ -377,7 +647,11 @@ func (r *HandlerAPIKeyRepo) Create(ctx context.Context, key *handlers.APIKey) er
 		Name:      key.Name,
 		IsActive:  true, // New keys are always active
 	}
-	return r.domainRepo.Create(ctx, domainKey)
+	if err := r.domainRepo.Create(ctx, domainKey); err != nil {
+		return err
+	}
+	key.CreatedAt = domainKey.CreatedAt
+	return nil
 }
 
 func (r *HandlerAPIKeyRepo) GetByUserID(ctx context.Context, userID uuid.UUID) ([]*handlers.APIKey, error) {
@@ -385,22 +659,60 @@ func (r *HandlerAPIKeyRepo) GetByUserID(ctx context.Context, userID uuid.UUID) (
 	if err != nil {
 		return nil, err
 	}
+	return toHandlerAPIKeys(domainKeys), nil
+}
+
+func (r *HandlerAPIKeyRepo) List(ctx context.Context, filter handlers.APIKeyFilter) ([]*handlers.APIKey, error) {
+	domainKeys, err := r.domainRepo.List(ctx, domain.APIKeyFilter{
+		UserID: filter.UserID,
+		Limit:  filter.Limit,
+		After:  toDomainCursor(filter.After),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return toHandlerAPIKeys(domainKeys), nil
+}
+
+func (r *HandlerAPIKeyRepo) Count(ctx context.Context, filter handlers.APIKeyFilter) (int, error) {
+	return r.domainRepo.Count(ctx, domain.APIKeyFilter{UserID: filter.UserID})
+}
+
+func (r *HandlerAPIKeyRepo) Revoke(ctx context.Context, keyID uuid.UUID) error {
+	return r.domainRepo.Revoke(ctx, keyID)
+}
+
+func (r *HandlerAPIKeyRepo) SetCanApprove(ctx context.Context, keyID uuid.UUID, canApprove bool) error {
+	err := r.domainRepo.SetCanApprove(ctx, keyID, canApprove)
+	if errors.Is(err, domain.ErrAPIKeyNotFound) {
+		return fmt.Errorf("%w: %v", handlers.ErrAPIKeyNotFound, err)
+	}
+	return err
+}
+
+func toHandlerAPIKeys(domainKeys []*domain.APIKey) []*handlers.APIKey {
 	handlerKeys := make([]*handlers.APIKey, len(domainKeys))
 	for i, dk := range domainKeys {
 		handlerKeys[i] = &handlers.APIKey{
-			ID:        dk.ID,
-			UserID:    dk.UserID,
-			KeyHash:   dk.KeyHash,
-			KeyPrefix: dk.KeyPrefix,
-			Name:      dk.Name,
-			CreatedAt: dk.CreatedAt.Format(time.RFC3339),
+			ID:         dk.ID,
+			UserID:     dk.UserID,
+			KeyHash:    dk.KeyHash,
+			KeyPrefix:  dk.KeyPrefix,
+			Name:       dk.Name,
+			IsRevoked:  !dk.IsActive,
+			CanApprove: dk.CanApprove,
+			CreatedAt:  dk.CreatedAt,
 		}
 	}
-	return handlerKeys, nil
+	return handlerKeys
 }
 
-func (r *HandlerAPIKeyRepo) Revoke(ctx context.Context, keyID uuid.UUID) error {
-	return r.domainRepo.Revoke(ctx, keyID)
+// toDomainCursor converts a listing position from the handler layer
+func toDomainCursor(cursor *handlers.Cursor) *domain.Cursor {
+	if cursor == nil {
+		return nil
+	}
+	return &domain.Cursor{CreatedAt: cursor.CreatedAt, ID: cursor.ID}
 }
 
 // HandlerUserRepo adapts domain.UserRepository to handlers.UserRepository
@@ -433,14 +745,15 @@ type HandlerRulesRepo struct {
 }
 
 func (r *HandlerRulesRepo) Create(ctx context.Context, rule *handlers.PricingRule) error {
-	domainRule := &domain.PricingRule{
-		ID:           rule.ID,
-		UserID:       rule.UserID,
-		Name:         rule.Name,
-		StrategyType: rule.StrategyType,
-		Config:       rule.Config,
+	domainRule := toDomainRule(rule)
+	if err := r.domainRepo.Create(ctx, domainRule); err != nil {
+		if errors.Is(err, domain.ErrDuplicateExternalKey) {
+			return handlers.ErrDuplicateExternalKey
+		}
+		return err
 	}
-	return r.domainRepo.Create(ctx, domainRule)
+	*rule = *toHandlerRule(domainRule)
+	return nil
 }
 
 func (r *HandlerRulesRepo) GetByID(ctx context.Context, id uuid.UUID) (*handlers.PricingRule, error) {
@@ -448,63 +761,298 @@ func (r *HandlerRulesRepo) GetByID(ctx context.Context, id uuid.UUID) (*handlers
 	if err != nil {
 		return nil, err
 	}
-	return &handlers.PricingRule{
-		ID:           domainRule.ID,
-		UserID:       domainRule.UserID,
-		Name:         domainRule.Name,
-		StrategyType: domainRule.StrategyType,
-		Config:       domainRule.Config,
-	}, nil
+	return toHandlerRule(domainRule), nil
 }
 
-func (r *HandlerRulesRepo) GetByUserID(ctx context.Context, userID uuid.UUID) ([]*handlers.PricingRule, error) {
-	domainRules, err := r.domainRepo.GetByUserID(ctx, userID)
+func (r *HandlerRulesRepo) List(ctx context.Context, filter handlers.RuleFilter) ([]*handlers.PricingRule, error) {
+	domainRules, err := r.domainRepo.List(ctx, toDomainRuleFilter(filter))
 	if err != nil {
 		return nil, err
 	}
 	handlerRules := make([]*handlers.PricingRule, len(domainRules))
 	for i, dr := range domainRules {
-		handlerRules[i] = &handlers.PricingRule{
-			ID:           dr.ID,
-			UserID:       dr.UserID,
-			Name:         dr.Name,
-			StrategyType: dr.StrategyType,
-			Config:       dr.Config,
-		}
+		handlerRules[i] = toHandlerRule(dr)
 	}
 	return handlerRules, nil
 }
 
+func (r *HandlerRulesRepo) Count(ctx context.Context, filter handlers.RuleFilter) (int, error) {
+	return r.domainRepo.Count(ctx, toDomainRuleFilter(filter))
+}
+
 func (r *HandlerRulesRepo) Update(ctx context.Context, rule *handlers.PricingRule) error {
-	domainRule := &domain.PricingRule{
-		ID:           rule.ID,
-		UserID:       rule.UserID,
-		Name:         rule.Name,
-		StrategyType: rule.StrategyType,
-		Config:       rule.Config,
+	domainRule := toDomainRule(rule)
+	if err := r.domainRepo.Update(ctx, domainRule); err != nil {
+		return err
 	}
-	return r.domainRepo.Update(ctx, domainRule)
+	*rule = *toHandlerRule(domainRule)
+	return nil
 }
 
 func (r *HandlerRulesRepo) Delete(ctx context.Context, id uuid.UUID) error {
 	return r.domainRepo.Delete(ctx, id)
 }
 
+func (r *HandlerRulesRepo) ListRevisions(ctx context.Context, ruleID uuid.UUID) ([]*handlers.RuleRevision, error) {
+	domainRevisions, err := r.domainRepo.ListRevisions(ctx, ruleID)
+	if err != nil {
+		return nil, err
+	}
+	revisions := make([]*handlers.RuleRevision, len(domainRevisions))
+	for i, dr := range domainRevisions {
+		revisions[i] = toHandlerRevision(dr)
+	}
+	return revisions, nil
+}
+
+func (r *HandlerRulesRepo) GetRevision(ctx context.Context, ruleID uuid.UUID, revision int) (*handlers.RuleRevision, error) {
+	domainRevision, err := r.domainRepo.GetRevision(ctx, ruleID, revision)
+	if err != nil {
+		return nil, err
+	}
+	return toHandlerRevision(domainRevision), nil
+}
+
+func (r *HandlerRulesRepo) SaveDraft(ctx context.Context, draft *handlers.RuleDraft) error {
+	domainDraft := toDomainDraft(draft)
+	if err := r.domainRepo.SaveDraft(ctx, domainDraft); err != nil {
+		return err
+	}
+	*draft = *toHandlerDraft(domainDraft)
+	return nil
+}
+
+func (r *HandlerRulesRepo) GetDraft(ctx context.Context, ruleID uuid.UUID) (*handlers.RuleDraft, error) {
+	domainDraft, err := r.domainRepo.GetDraft(ctx, ruleID)
+	if errors.Is(err, domain.ErrDraftNotFound) {
+		return nil, handlers.ErrDraftNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return toHandlerDraft(domainDraft), nil
+}
+
+func (r *HandlerRulesRepo) ListDrafts(ctx context.Context, userID uuid.UUID) ([]*handlers.RuleDraft, error) {
+	domainDrafts, err := r.domainRepo.ListDrafts(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	drafts := make([]*handlers.RuleDraft, len(domainDrafts))
+	for i, dd := range domainDrafts {
+		drafts[i] = toHandlerDraft(dd)
+	}
+	return drafts, nil
+}
+
+func (r *HandlerRulesRepo) DiscardDraft(ctx context.Context, ruleID uuid.UUID) error {
+	err := r.domainRepo.DiscardDraft(ctx, ruleID)
+	if errors.Is(err, domain.ErrDraftNotFound) {
+		return handlers.ErrDraftNotFound
+	}
+	return err
+}
+
+func toDomainDraft(draft *handlers.RuleDraft) *domain.RuleDraft {
+	return &domain.RuleDraft{
+		ID:            draft.ID,
+		RuleID:        draft.RuleID,
+		BaseRevision:  draft.BaseRevision,
+		Name:          draft.Name,
+		Description:   draft.Description,
+		StrategyType:  draft.StrategyType,
+		Config:        draft.Config,
+		IsActive:      draft.IsActive,
+		EffectiveFrom: draft.EffectiveFrom,
+		EffectiveTo:   draft.EffectiveTo,
+		AuthorID:      draft.AuthorID,
+		AuthorKeyID:   draft.AuthorKeyID,
+		ChangeNote:    draft.ChangeNote,
+		Status:        draft.Status,
+	}
+}
+
+func toHandlerDraft(draft *domain.RuleDraft) *handlers.RuleDraft {
+	return &handlers.RuleDraft{
+		ID:                draft.ID,
+		RuleID:            draft.RuleID,
+		BaseRevision:      draft.BaseRevision,
+		Name:              draft.Name,
+		Description:       draft.Description,
+		StrategyType:      draft.StrategyType,
+		Config:            draft.Config,
+		IsActive:          draft.IsActive,
+		EffectiveFrom:     draft.EffectiveFrom,
+		EffectiveTo:       draft.EffectiveTo,
+		AuthorID:          draft.AuthorID,
+		AuthorKeyID:       draft.AuthorKeyID,
+		ChangeNote:        draft.ChangeNote,
+		Status:            draft.Status,
+		ApprovedBy:        draft.ApprovedBy,
+		ApprovedByKeyID:   draft.ApprovedByKeyID,
+		ApprovedAt:        draft.ApprovedAt,
+		PublishedRevision: draft.PublishedRevision,
+		CreatedAt:         draft.CreatedAt,
+		UpdatedAt:         draft.UpdatedAt,
+	}
+}
+
+// HandlerRulePublisher adapts service.RulePublisher to handlers.RulePublisher
+type HandlerRulePublisher struct {
+	publisher *service.RulePublisher
+}
+
+func (p *HandlerRulePublisher) Publish(ctx context.Context, ruleID uuid.UUID, draftID *uuid.UUID, approverID uuid.UUID, approverKeyID *uuid.UUID) (*handlers.PricingRule, error) {
+	rule, err := p.publisher.Publish(ctx, ruleID, draftID, domain.DraftApproval{UserID: approverID, KeyID: approverKeyID})
+	switch {
+	case errors.Is(err, domain.ErrSelfApprovalForbidden):
+		return nil, handlers.ErrApprovalRequired
+	case errors.Is(err, domain.ErrDraftNotFound):
+		return nil, handlers.ErrDraftNotFound
+	case err != nil:
+		return nil, err
+	}
+	return toHandlerRule(rule), nil
+}
+
+func (p *HandlerRulePublisher) CheckDelete(ctx context.Context, rule *handlers.PricingRule) error {
+	err := p.publisher.CheckDelete(ctx, toDomainRule(rule))
+	if errors.Is(err, domain.ErrDeleteNeedsApproval) {
+		return fmt.Errorf("%w: %v", handlers.ErrDeleteNeedsApproval, err)
+	}
+	return err
+}
+
+// HandlerAccountPolicyStore adapts domain.UserRepository to handlers.AccountPolicyStore
+type HandlerAccountPolicyStore struct {
+	domainRepo domain.UserRepository
+}
+
+func (s *HandlerAccountPolicyStore) GetPolicy(ctx context.Context, userID uuid.UUID) (*handlers.AccountPolicy, error) {
+	user, err := s.domainRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return &handlers.AccountPolicy{
+		UserID:              user.ID,
+		RequireRuleApproval: user.RequireRuleApproval,
+		DefaultRuleID:       user.DefaultRuleID,
+		LogRetentionDays:    user.LogRetentionDays,
+		UpdatedAt:           user.UpdatedAt,
+	}, nil
+}
+
+func (s *HandlerAccountPolicyStore) SetPolicy(ctx context.Context, policy *handlers.AccountPolicy) error {
+	user, err := s.domainRepo.GetByID(ctx, policy.UserID)
+	if err != nil {
+		return err
+	}
+	user.RequireRuleApproval = policy.RequireRuleApproval
+	user.DefaultRuleID = policy.DefaultRuleID
+	user.LogRetentionDays = policy.LogRetentionDays
+	if err := s.domainRepo.Update(ctx, user); err != nil {
+		return err
+	}
+	policy.UpdatedAt = user.UpdatedAt
+	return nil
+}
+
+func toDomainRule(rule *handlers.PricingRule) *domain.PricingRule {
+	return &domain.PricingRule{
+		ID:             rule.ID,
+		UserID:         rule.UserID,
+		ExternalKey:    rule.ExternalKey,
+		Name:           rule.Name,
+		Description:    rule.Description,
+		StrategyType:   rule.StrategyType,
+		Config:         rule.Config,
+		IsActive:       rule.IsActive,
+		CreatedAt:      rule.CreatedAt,
+		EffectiveFrom:  rule.EffectiveFrom,
+		EffectiveTo:    rule.EffectiveTo,
+		PublishedAt:    rule.PublishedAt,
+		Revision:       rule.Revision,
+		UpdatedBy:      rule.UpdatedBy,
+		UpdatedByKeyID: rule.UpdatedByKeyID,
+		ChangeNote:     rule.ChangeNote,
+	}
+}
+
+func toHandlerRule(rule *domain.PricingRule) *handlers.PricingRule {
+	return &handlers.PricingRule{
+		ID:             rule.ID,
+		UserID:         rule.UserID,
+		ExternalKey:    rule.ExternalKey,
+		Name:           rule.Name,
+		Description:    rule.Description,
+		StrategyType:   rule.StrategyType,
+		Config:         rule.Config,
+		IsActive:       rule.IsActive,
+		CreatedAt:      rule.CreatedAt,
+		UpdatedAt:      rule.UpdatedAt,
+		PublishedAt:    rule.PublishedAt,
+		EffectiveFrom:  rule.EffectiveFrom,
+		EffectiveTo:    rule.EffectiveTo,
+		ScheduleStatus: rule.Window().Status(time.Now()),
+		Revision:       rule.Revision,
+		RevisionID:     rule.RevisionID,
+		UpdatedBy:      rule.UpdatedBy,
+		UpdatedByKeyID: rule.UpdatedByKeyID,
+		ChangeNote:     rule.ChangeNote,
+	}
+}
+
+func toDomainRevision(revision *handlers.RuleRevision) *domain.PricingRuleRevision {
+	return &domain.PricingRuleRevision{
+		ID:            revision.ID,
+		RuleID:        revision.RuleID,
+		Revision:      revision.Revision,
+		Name:          revision.Name,
+		Description:   revision.Description,
+		StrategyType:  revision.StrategyType,
+		Config:        revision.Config,
+		IsActive:      revision.IsActive,
+		EffectiveFrom: revision.EffectiveFrom,
+		EffectiveTo:   revision.EffectiveTo,
+		AuthorID:      revision.AuthorID,
+		AuthorKeyID:   revision.AuthorKeyID,
+		ChangeNote:    revision.ChangeNote,
+		CreatedAt:     revision.CreatedAt,
+	}
+}
+
+func toHandlerRevision(revision *domain.PricingRuleRevision) *handlers.RuleRevision {
+	return &handlers.RuleRevision{
+		ID:            revision.ID,
+		RuleID:        revision.RuleID,
+		Revision:      revision.Revision,
+		Name:          revision.Name,
+		Description:   revision.Description,
+		StrategyType:  revision.StrategyType,
+		Config:        revision.Config,
+		IsActive:      revision.IsActive,
+		EffectiveFrom: revision.EffectiveFrom,
+		EffectiveTo:   revision.EffectiveTo,
+		AuthorID:      revision.AuthorID,
+		AuthorKeyID:   revision.AuthorKeyID,
+		ChangeNote:    revision.ChangeNote,
+		CreatedAt:     revision.CreatedAt,
+	}
+}
+
 // HandlerProductsRepo adapts domain.ProductRepository to handlers.ProductRepository
 type HandlerProductsRepo struct {
 	domainRepo domain.ProductRepository
 }
 
 func (r *HandlerProductsRepo) Create(ctx context.Context, product *handlers.Product) error {
-	domainProduct := &domain.Product{
-		ID:          product.ID,
-		UserID:      product.UserID,
-		SKU:         product.SKU,
-		Name:        product.Name,
-		Description: product.Description,
-		BaseCost:    0, // Handler Product doesn't have base cost
+	domainProduct := toDomainProduct(product)
+	if err := r.domainRepo.Create(ctx, domainProduct); err != nil {
+		return err
 	}
-	return r.domainRepo.Create(ctx, domainProduct)
+	product.CreatedAt = domainProduct.CreatedAt
+	product.UpdatedAt = domainProduct.UpdatedAt
+	return nil
 }
 
 func (r *HandlerProductsRepo) GetByID(ctx context.Context, id uuid.UUID) (*handlers.Product, error) {
@@ -512,13 +1060,7 @@ func (r *HandlerProductsRepo) GetByID(ctx context.Context, id uuid.UUID) (*handl
 	if err != nil {
 		return nil, err
 	}
-	return &handlers.Product{
-		ID:          domainProduct.ID,
-		UserID:      domainProduct.UserID,
-		SKU:         domainProduct.SKU,
-		Name:        domainProduct.Name,
-		Description: domainProduct.Description,
-	}, nil
+	return toHandlerProduct(domainProduct), nil
 }
 
 func (r *HandlerProductsRepo) GetBySKU(ctx context.Context, userID uuid.UUID, sku string) (*handlers.Product, error) {
@@ -526,186 +1068,757 @@ func (r *HandlerProductsRepo) GetBySKU(ctx context.Context, userID uuid.UUID, sk
 	if err != nil {
 		return nil, err
 	}
-	return &handlers.Product{
-		ID:          domainProduct.ID,
-		UserID:      domainProduct.UserID,
-		SKU:         domainProduct.SKU,
-		Name:        domainProduct.Name,
-		Description: domainProduct.Description,
-	}, nil
+	return toHandlerProduct(domainProduct), nil
 }
 
-func (r *HandlerProductsRepo) GetByUserID(ctx context.Context, userID uuid.UUID) ([]*handlers.Product, error) {
-	domainProducts, err := r.domainRepo.GetByUserID(ctx, userID)
+func (r *HandlerProductsRepo) List(ctx context.Context, filter handlers.ProductFilter) ([]*handlers.Product, error) {
+	domainProducts, err := r.domainRepo.List(ctx, toDomainProductFilter(filter))
 	if err != nil {
 		return nil, err
 	}
 	handlerProducts := make([]*handlers.Product, len(domainProducts))
 	for i, dp := range domainProducts {
-		handlerProducts[i] = &handlers.Product{
-			ID:          dp.ID,
-			UserID:      dp.UserID,
-			SKU:         dp.SKU,
-			Name:        dp.Name,
-			Description: dp.Description,
-		}
+		handlerProducts[i] = toHandlerProduct(dp)
 	}
 	return handlerProducts, nil
 }
 
+func (r *HandlerProductsRepo) Count(ctx context.Context, filter handlers.ProductFilter) (int, error) {
+	return r.domainRepo.Count(ctx, toDomainProductFilter(filter))
+}
+
 func (r *HandlerProductsRepo) Update(ctx context.Context, product *handlers.Product) error {
-	domainProduct := &domain.Product{
-		ID:          product.ID,
-		UserID:      product.UserID,
-		SKU:         product.SKU,
-		Name:        product.Name,
-		Description: product.Description,
-		BaseCost:    0, // Handler Product doesn't have base cost
+	domainProduct := toDomainProduct(product)
+	if err := r.domainRepo.Update(ctx, domainProduct); err != nil {
+		return err
 	}
-	return r.domainRepo.Update(ctx, domainProduct)
+	product.UpdatedAt = domainProduct.UpdatedAt
+	return nil
 }
 
 func (r *HandlerProductsRepo) Delete(ctx context.Context, id uuid.UUID) error {
 	return r.domainRepo.Delete(ctx, id)
 }
 
-// HandlerLogsRepo adapts domain.CalculationLogRepository to handlers.CalculationLogRepository
-type HandlerLogsRepo struct {
-	domainRepo domain.CalculationLogRepository
+func (r *HandlerProductsRepo) AddRuleAssignment(ctx context.Context, assignment *handlers.RuleAssignment) error {
+	domainAssignment := &domain.ProductRuleAssignment{
+		ID:            assignment.ID,
+		ProductID:     assignment.ProductID,
+		RuleID:        assignment.RuleID,
+		EffectiveFrom: assignment.EffectiveFrom,
+		EffectiveTo:   assignment.EffectiveTo,
+	}
+	if err := r.domainRepo.AddRuleAssignment(ctx, domainAssignment); err != nil {
+		if errors.Is(err, domain.ErrScheduleOverlap) {
+			return fmt.Errorf("%w: %v", handlers.ErrScheduleOverlap, err)
+		}
+		return err
+	}
+	*assignment = *toHandlerAssignment(domainAssignment)
+	return nil
 }
 
-func (r *HandlerLogsRepo) GetByUserID(ctx context.Context, userID uuid.UUID, limit, offset int, strategyType string, startDate, endDate *time.Time) ([]*handlers.CalculationLog, error) {
-	filter := domain.CalculationLogFilter{
-		UserID:       userID,
-		StrategyType: strategyType,
-		Limit:        limit,
-		Offset:       offset,
+func (r *HandlerProductsRepo) ListRuleAssignments(ctx context.Context, productID uuid.UUID) ([]*handlers.RuleAssignment, error) {
+	domainAssignments, err := r.domainRepo.ListRuleAssignments(ctx, productID)
+	if err != nil {
+		return nil, err
 	}
-	if startDate != nil {
-		filter.From = *startDate
+	assignments := make([]*handlers.RuleAssignment, len(domainAssignments))
+	for i, da := range domainAssignments {
+		assignments[i] = toHandlerAssignment(da)
 	}
-	if endDate != nil {
-		filter.To = *endDate
+	return assignments, nil
+}
+
+func (r *HandlerProductsRepo) DeleteRuleAssignment(ctx context.Context, productID, id uuid.UUID) error {
+	err := r.domainRepo.DeleteRuleAssignment(ctx, productID, id)
+	if errors.Is(err, domain.ErrAssignmentNotFound) {
+		return fmt.Errorf("%w: %v", handlers.ErrAssignmentNotFound, err)
 	}
+	return err
+}
 
-	domainLogs, err := r.domainRepo.List(ctx, filter)
-	if err != nil {
-		return nil, err
+func toDomainProduct(product *handlers.Product) *domain.Product {
+	return &domain.Product{
+		ID:            product.ID,
+		UserID:        product.UserID,
+		SKU:           product.SKU,
+		Name:          product.Name,
+		Description:   product.Description,
+		BaseCost:      product.BaseCost,
+		DefaultRuleID: product.DefaultRuleID,
+		CategoryID:    product.CategoryID,
+		Metadata:      product.Metadata,
+		IsActive:      product.IsActive,
+		CreatedAt:     product.CreatedAt,
+		UpdatedAt:     product.UpdatedAt,
 	}
+}
 
-	handlerLogs := make([]*handlers.CalculationLog, len(domainLogs))
-	for i, dl := range domainLogs {
-		handlerLogs[i] = &handlers.CalculationLog{
-			ID:           dl.ID,
-			UserID:       dl.UserID,
-			StrategyType: dl.StrategyType,
-			Input:        dl.InputData,
-			Output:       dl.OutputData,
-			CreatedAt:    dl.CreatedAt,
-		}
+func toHandlerProduct(product *domain.Product) *handlers.Product {
+	return &handlers.Product{
+		ID:            product.ID,
+		UserID:        product.UserID,
+		SKU:           product.SKU,
+		Name:          product.Name,
+		Description:   product.Description,
+		BaseCost:      product.BaseCost,
+		DefaultRuleID: product.DefaultRuleID,
+		CategoryID:    product.CategoryID,
+		Metadata:      product.Metadata,
+		IsActive:      product.IsActive,
+		CreatedAt:     product.CreatedAt,
+		UpdatedAt:     product.UpdatedAt,
 	}
-	return handlerLogs, nil
 }
 
-func (r *HandlerLogsRepo) Count(ctx context.Context, userID uuid.UUID, strategyType string, startDate, endDate *time.Time) (int, error) {
-	filter := domain.CalculationLogFilter{
-		UserID:       userID,
-		StrategyType: strategyType,
-		Limit:        -1,
+func toDomainRuleFilter(filter handlers.RuleFilter) domain.PricingRuleFilter {
+	active := true
+	return domain.PricingRuleFilter{
+		UserID:   filter.UserID,
+		IsActive: &active,
+		Schedule: filter.Schedule,
+		Limit:    filter.Limit,
+		After:    toDomainCursor(filter.After),
 	}
-	if startDate != nil {
-		filter.From = *startDate
+}
+
+func toDomainProductFilter(filter handlers.ProductFilter) domain.ProductFilter {
+	return domain.ProductFilter{
+		UserID:     filter.UserID,
+		IsActive:   filter.IsActive,
+		Category:   filter.Category,
+		CategoryID: filter.CategoryID,
+		Metadata:   filter.Metadata,
+		Limit:      filter.Limit,
+		After:      toDomainCursor(filter.After),
 	}
-	if endDate != nil {
-		filter.To = *endDate
+}
+
+func toHandlerAssignment(assignment *domain.ProductRuleAssignment) *handlers.RuleAssignment {
+	return &handlers.RuleAssignment{
+		ID:             assignment.ID,
+		ProductID:      assignment.ProductID,
+		RuleID:         assignment.RuleID,
+		EffectiveFrom:  assignment.EffectiveFrom,
+		EffectiveTo:    assignment.EffectiveTo,
+		ScheduleStatus: assignment.Window().Status(time.Now()),
+		CreatedAt:      assignment.CreatedAt,
 	}
+}
 
-	domainLogs, err := r.domainRepo.List(ctx, filter)
-	if err != nil {
-		return 0, err
+// HandlerCategoriesRepo adapts domain.CategoryRepository to handlers.CategoryRepository
+type HandlerCategoriesRepo struct {
+	domainRepo domain.CategoryRepository
+}
+
+func (r *HandlerCategoriesRepo) Create(ctx context.Context, category *handlers.Category) error {
+	domainCategory := toDomainCategory(category)
+	if err := r.domainRepo.Create(ctx, domainCategory); err != nil {
+		return toHandlerCategoryError(err)
 	}
-	return len(domainLogs), nil
+	category.CreatedAt = domainCategory.CreatedAt
+	category.UpdatedAt = domainCategory.UpdatedAt
+	return nil
 }
 
-// HandlerPricingEngine adapts service.PricingEngine to handlers.PricingEngine
-type HandlerPricingEngine struct {
-	engine *service.PricingEngine
+func (r *HandlerCategoriesRepo) GetByID(ctx context.Context, id uuid.UUID) (*handlers.Category, error) {
+	domainCategory, err := r.domainRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return toHandlerCategory(domainCategory), nil
 }
 
-func (e *HandlerPricingEngine) Calculate(ctx context.Context, req *handlers.PricingRequest) (*handlers.PricingResult, error) {
-	// Merge base_price into inputs for the pricing engine
-	inputs := make(map[string]interface{})
+func (r *HandlerCategoriesRepo) ListByUser(ctx context.Context, userID uuid.UUID) ([]*handlers.Category, error) {
+	domainCategories, err := r.domainRepo.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return toHandlerCategories(domainCategories), nil
+}
 
-	// Copy context fields
-	for k, v := range req.Context {
-		inputs[k] = v
+func (r *HandlerCategoriesRepo) Update(ctx context.Context, category *handlers.Category) error {
+	domainCategory := toDomainCategory(category)
+	if err := r.domainRepo.Update(ctx, domainCategory); err != nil {
+		return toHandlerCategoryError(err)
 	}
+	category.UpdatedAt = domainCategory.UpdatedAt
+	return nil
+}
+
+func (r *HandlerCategoriesRepo) Delete(ctx context.Context, id uuid.UUID) error {
+	return toHandlerCategoryError(r.domainRepo.Delete(ctx, id))
+}
 
-	// Add base_price and quantity if not already in context
-	// The cost_plus strategy expects "base_cost" in inputs
-	if _, exists := inputs["base_cost"]; !exists && req.BasePrice > 0 {
-		inputs["base_cost"] = req.BasePrice
+func (r *HandlerCategoriesRepo) Ancestors(ctx context.Context, id uuid.UUID) ([]*handlers.Category, error) {
+	domainCategories, err := r.domainRepo.Ancestors(ctx, id)
+	if err != nil {
+		return nil, err
 	}
-	if _, exists := inputs["base_price"]; !exists && req.BasePrice > 0 {
-		inputs["base_price"] = req.BasePrice
+	return toHandlerCategories(domainCategories), nil
+}
+
+// toHandlerCategoryError translates domain category errors to their handler equivalents
+func toHandlerCategoryError(err error) error {
+	switch {
+	case errors.Is(err, domain.ErrCategoryExists):
+		return fmt.Errorf("%w: %v", handlers.ErrCategoryExists, err)
+	case errors.Is(err, domain.ErrCategoryCycle):
+		return fmt.Errorf("%w: %v", handlers.ErrCategoryCycle, err)
+	case errors.Is(err, domain.ErrCategoryNotEmpty):
+		return fmt.Errorf("%w: %v", handlers.ErrCategoryNotEmpty, err)
 	}
-	if _, exists := inputs["quantity"]; !exists && req.Quantity > 0 {
-		inputs["quantity"] = req.Quantity
+	return err
+}
+
+func toDomainCategory(category *handlers.Category) *domain.Category {
+	return &domain.Category{
+		ID:            category.ID,
+		UserID:        category.UserID,
+		ParentID:      category.ParentID,
+		Name:          category.Name,
+		Description:   category.Description,
+		DefaultRuleID: category.DefaultRuleID,
+		Attributes:    category.Attributes,
+		CreatedAt:     category.CreatedAt,
+		UpdatedAt:     category.UpdatedAt,
 	}
+}
 
-	domainReq := &domain.PricingRequest{
-		Strategy: req.StrategyType,
-		Inputs:   inputs,
+func toHandlerCategories(categories []*domain.Category) []*handlers.Category {
+	handlerCategories := make([]*handlers.Category, len(categories))
+	for i, dc := range categories {
+		handlerCategories[i] = &handlers.Category{
+			ID:            dc.ID,
+			UserID:        dc.UserID,
+			ParentID:      dc.ParentID,
+			Name:          dc.Name,
+			Description:   dc.Description,
+			DefaultRuleID: dc.DefaultRuleID,
+			Attributes:    dc.Attributes,
+			CreatedAt:     dc.CreatedAt,
+			UpdatedAt:     dc.UpdatedAt,
+		}
 	}
+	return handlerCategories
+}
 
-	response, err := e.engine.Calculate(domainReq, inputs)
+func toHandlerCategory(category *domain.Category) *handlers.Category {
+	return toHandlerCategories([]*domain.Category{category})[0]
+}
+
+// HandlerAnalytics adapts domain.CalculationLogRepository to handlers.AnalyticsRepository
+type HandlerAnalytics struct {
+	domainRepo domain.CalculationLogRepository
+}
+
+func (a *HandlerAnalytics) Analytics(ctx context.Context, query handlers.AnalyticsQuery) ([]*handlers.AnalyticsPoint, error) {
+	points, err := a.domainRepo.Analytics(ctx, domain.AnalyticsQuery{
+		UserID:  query.UserID,
+		From:    query.From,
+		To:      query.To,
+		Bucket:  query.Bucket,
+		GroupBy: query.GroupBy,
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	return &handlers.PricingResult{
-		FinalPrice: response.FinalPrice,
-		Breakdown: map[string]interface{}{
-			"strategy":      response.Strategy,
-			"calculated_at": response.CalculatedAt,
-			"breakdown":     response.Breakdown,
-		},
-	}, nil
+	handlerPoints := make([]*handlers.AnalyticsPoint, len(points))
+	for i, point := range points {
+		handlerPoints[i] = &handlers.AnalyticsPoint{
+			BucketStart:        point.BucketStart,
+			Group:              point.Group,
+			Count:              point.Count,
+			Errors:             point.Errors,
+			ErrorRate:          point.ErrorRate(),
+			AvgPrice:           point.AvgPrice,
+			MinPrice:           point.MinPrice,
+			MaxPrice:           point.MaxPrice,
+			P50Price:           point.P50Price,
+			P95Price:           point.P95Price,
+			AvgExecutionTimeMs: point.AvgExecutionTimeMs,
+		}
+	}
+	return handlerPoints, nil
 }
 
-func (e *HandlerPricingEngine) GetAvailableStrategies() []string {
-	return e.engine.ListStrategies()
+// HandlerLogsRepo adapts domain.CalculationLogRepository to handlers.CalculationLogRepository
+type HandlerLogsRepo struct {
+	domainRepo domain.CalculationLogRepository
 }
 
-// HandlerCalculationLogger adapts domain.CalculationLogRepository to handlers.CalculationLogger
-type HandlerCalculationLogger struct {
-	domainRepo domain.CalculationLogRepository
+func (r *HandlerLogsRepo) GetByID(ctx context.Context, id uuid.UUID) (*handlers.CalculationLog, error) {
+	domainLog, err := r.domainRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return toHandlerLog(domainLog), nil
 }
 
-func (l *HandlerCalculationLogger) Log(ctx context.Context, userID uuid.UUID, strategyType string, input, output map[string]interface{}) error {
-	domainLog := &domain.CalculationLog{
-		ID:           uuid.New(),
-		UserID:       userID,
-		StrategyType: strategyType,
-		InputData:    input,
-		OutputData:   output,
-		CreatedAt:    time.Now(),
+func (r *HandlerLogsRepo) List(ctx context.Context, filter handlers.CalculationLogFilter) ([]*handlers.CalculationLog, error) {
+	domainLogs, err := r.domainRepo.List(ctx, toDomainLogFilter(filter))
+	if err != nil {
+		return nil, err
+	}
+
+	handlerLogs := make([]*handlers.CalculationLog, len(domainLogs))
+	for i, dl := range domainLogs {
+		handlerLogs[i] = toHandlerLog(dl)
 	}
-	return l.domainRepo.Create(ctx, domainLog)
+	return handlerLogs, nil
 }
 
-// rootHandler returns API information
-func rootHandler(c *gin.Context) {
-	acceptHeader := c.GetHeader("Accept")
+func (r *HandlerLogsRepo) Count(ctx context.Context, filter handlers.CalculationLogFilter) (int, error) {
+	return r.domainRepo.Count(ctx, toDomainLogFilter(filter))
+}
 
-	// Return HTML if Accept header includes text/html
-	if acceptHeader != "" && (acceptHeader == "text/html" || acceptHeader == "*/*") {
-		c.Header("Content-Type", "text/html; charset=utf-8")
-		c.String(http.StatusOK, `
-<!DOCTYPE html>
-<html>
-<head>
-    <title>Harmonia API</title>
+func toHandlerLog(log *domain.CalculationLog) *handlers.CalculationLog {
+	return &handlers.CalculationLog{
+		ID:              log.ID,
+		UserID:          log.UserID,
+		APIKeyID:        log.APIKeyID,
+		RuleID:          log.RuleID,
+		RuleRevisionID:  log.RuleRevisionID,
+		RuleRevision:    log.RuleRevision,
+		StrategyType:    log.StrategyType,
+		Input:           log.InputData,
+		Output:          log.OutputData,
+		ExecutionTimeMs: log.ExecutionTimeMs,
+		RequestID:       log.RequestID,
+		ClientIP:        log.ClientIP,
+		EngineVersion:   log.EngineVersion,
+		Error:           log.Error,
+		CreatedAt:       log.CreatedAt,
+	}
+}
+
+func toDomainLogFilter(filter handlers.CalculationLogFilter) domain.CalculationLogFilter {
+	domainFilter := domain.CalculationLogFilter{
+		UserID:       filter.UserID,
+		StrategyType: filter.StrategyType,
+		Limit:        filter.Limit,
+		After:        toDomainCursor(filter.After),
+		Data:         toDomainLogDataFilters(filter.Filters),
+	}
+	if filter.StartDate != nil {
+		domainFilter.From = *filter.StartDate
+	}
+	if filter.EndDate != nil {
+		domainFilter.To = *filter.EndDate
+	}
+	return domainFilter
+}
+
+func toDomainLogDataFilters(filters []handlers.LogDataFilter) []domain.LogDataFilter {
+	if len(filters) == 0 {
+		return nil
+	}
+	domainFilters := make([]domain.LogDataFilter, len(filters))
+	for i, f := range filters {
+		domainFilters[i] = domain.LogDataFilter{
+			Column: f.Column,
+			Path:   f.Path,
+			Op:     f.Op,
+			Value:  f.Value,
+		}
+	}
+	return domainFilters
+}
+
+// HandlerLogReplayer adapts service.CalculationReplayService to handlers.CalculationReplayer
+type HandlerLogReplayer struct {
+	service *service.CalculationReplayService
+}
+
+func (r *HandlerLogReplayer) Replay(ctx context.Context, log *handlers.CalculationLog) (*handlers.CalculationReplay, error) {
+	replay, err := r.service.Replay(ctx, &domain.CalculationLog{
+		ID:              log.ID,
+		UserID:          log.UserID,
+		APIKeyID:        log.APIKeyID,
+		RuleID:          log.RuleID,
+		RuleRevisionID:  log.RuleRevisionID,
+		RuleRevision:    log.RuleRevision,
+		StrategyType:    log.StrategyType,
+		InputData:       log.Input,
+		OutputData:      log.Output,
+		ExecutionTimeMs: log.ExecutionTimeMs,
+		RequestID:       log.RequestID,
+		ClientIP:        log.ClientIP,
+		EngineVersion:   log.EngineVersion,
+		Error:           log.Error,
+		CreatedAt:       log.CreatedAt,
+	})
+	if err != nil {
+		if errors.Is(err, domain.ErrReplayUnsupported) {
+			return nil, fmt.Errorf("%w: %v", handlers.ErrReplayUnsupported, err)
+		}
+		return nil, err
+	}
+
+	changes := make([]handlers.JSONChange, len(replay.Changes))
+	for i, dc := range replay.Changes {
+		changes[i] = handlers.JSONChange{Op: dc.Op, Path: dc.Path, From: dc.From, To: dc.To}
+	}
+	return &handlers.CalculationReplay{
+		LogID:                 replay.LogID,
+		RuleID:                replay.RuleID,
+		RuleRevision:          replay.RuleRevision,
+		StrategyType:          replay.StrategyType,
+		RequestedAt:           replay.RequestedAt,
+		OriginalEngineVersion: replay.OriginalEngineVersion,
+		EngineVersion:         replay.EngineVersion,
+		OriginalPrice:         replay.OriginalPrice,
+		ReplayedPrice:         replay.ReplayedPrice,
+		OriginalError:         replay.OriginalError,
+		ReplayedError:         replay.ReplayedError,
+		Drift:                 replay.Drift,
+		Reasons:               replay.Reasons,
+		Changes:               changes,
+		ReplayedAt:            replay.ReplayedAt,
+	}, nil
+}
+
+// HandlerLogExporter adapts service.CalculationLogExportService to handlers.CalculationLogExporter
+type HandlerLogExporter struct {
+	service *service.CalculationLogExportService
+}
+
+func (e *HandlerLogExporter) Export(ctx context.Context, export *handlers.CalculationLogExport, w io.Writer) error {
+	filter := domain.CalculationLogFilter{
+		UserID:        export.UserID,
+		StrategyType:  export.StrategyType,
+		IncludeFailed: export.IncludeFailed,
+		Data:          toDomainLogDataFilters(export.Filters),
+	}
+	if export.StartDate != nil {
+		filter.From = *export.StartDate
+	}
+	if export.EndDate != nil {
+		filter.To = *export.EndDate
+	}
+
+	err := e.service.Export(ctx, filter, export.Format, export.Columns, w)
+	if errors.Is(err, domain.ErrLogExportInvalid) {
+		return fmt.Errorf("%w: %v", handlers.ErrInvalidLogExport, err)
+	}
+	return err
+}
+
+// HandlerPricingEngine adapts service.PricingEngine to handlers.PricingEngine
+type HandlerPricingEngine struct {
+	engine             *service.PricingEngine
+	resolver           *service.RuleResolver
+	costs              *service.ProductCostService
+	maxSimulationCells int
+}
+
+func (e *HandlerPricingEngine) Calculate(ctx context.Context, req *handlers.PricingRequest) (*handlers.PricingResult, error) {
+	// Merge base_price into inputs for the pricing engine
+	inputs := service.BuildInputs(req.Context, req.BasePrice, req.Quantity)
+	result := &handlers.PricingResult{}
+
+	// A product's cost history supplies base_cost unless the context sets it;
+	// unknown products and products without a cost price with the request
+	// values. Any other failure fails the calculation rather than pricing it
+	// from base_price.
+	if _, set := req.Context["base_cost"]; req.ProductSKU != "" && !set {
+		cost, err := e.costs.CostAt(ctx, req.UserID, req.ProductSKU, req.RequestedAt)
+		switch {
+		case err == nil:
+			service.ApplyProductCost(inputs, req.Context, cost.Cost)
+			result.ProductCost = &handlers.AppliedCost{
+				ID:            cost.ID,
+				Cost:          cost.Cost,
+				EffectiveFrom: cost.EffectiveFrom,
+			}
+		case errors.Is(err, domain.ErrProductNotFound), errors.Is(err, domain.ErrNoCostInEffect):
+			// Priced with the request values
+		default:
+			return nil, fmt.Errorf("%w: failed to look up product cost: %v", handlers.ErrPricingUnavailable, err)
+		}
+	}
+
+	domainReq := &domain.PricingRequest{
+		Strategy:    req.StrategyType,
+		ProductSKU:  req.ProductSKU,
+		Inputs:      inputs,
+		RequestedAt: req.RequestedAt,
+	}
+
+	// Inline requests carry their config in the inputs; saved rules supply their own
+	config := inputs
+
+	// A product only selects the rule when no strategy is given inline
+	sku := ""
+	if req.StrategyType == "" {
+		sku = req.ProductSKU
+	}
+
+	resolved, err := e.resolver.Resolve(ctx, req.UserID, req.RuleID, sku, req.RequestedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if resolved != nil {
+		rule := resolved.Rule
+		if req.StrategyType != "" && req.StrategyType != rule.StrategyType {
+			return nil, fmt.Errorf("strategy_type %s does not match rule strategy %s", req.StrategyType, rule.StrategyType)
+		}
+
+		domainReq.Strategy = rule.StrategyType
+		domainReq.RuleID = &rule.ID
+		config = rule.Config
+
+		result.RuleID = &rule.ID
+		result.RuleRevisionID = rule.RevisionID
+		result.RuleRevision = rule.Revision
+		result.RuleSource = &handlers.RuleSource{
+			Level: resolved.Source.Level,
+			ID:    resolved.Source.ID,
+			Name:  resolved.Source.Name,
+		}
+	}
+
+	started := time.Now()
+	response, err := e.engine.Calculate(domainReq, config)
+	if err != nil {
+		return nil, err
+	}
+	result.ExecutionTime = time.Since(started)
+	result.EngineVersion = service.EngineVersion
+
+	result.FinalPrice = response.FinalPrice
+	result.StrategyType = response.Strategy
+	result.Breakdown = map[string]interface{}{
+		"strategy":      response.Strategy,
+		"calculated_at": response.CalculatedAt,
+		"breakdown":     response.Breakdown,
+	}
+	return result, nil
+}
+
+func (e *HandlerPricingEngine) GetAvailableStrategies() []string {
+	return e.engine.ListStrategies()
+}
+
+func (e *HandlerPricingEngine) GetStrategySchemas() []*handlers.StrategySchema {
+	domainSchemas := e.engine.ListSchemas()
+	schemas := make([]*handlers.StrategySchema, len(domainSchemas))
+	for i, ds := range domainSchemas {
+		schemas[i] = &handlers.StrategySchema{
+			Type:         ds.Type,
+			Name:         ds.Name,
+			Description:  ds.Description,
+			ConfigSchema: ds.ConfigSchema,
+			InputSchema:  ds.InputSchema,
+		}
+	}
+	return schemas
+}
+
+func (e *HandlerPricingEngine) GetStrategySchema(strategyType string) (*handlers.StrategySchema, error) {
+	ds, err := e.engine.GetSchema(strategyType)
+	if err != nil {
+		return nil, err
+	}
+	return &handlers.StrategySchema{
+		Type:         ds.Type,
+		Name:         ds.Name,
+		Description:  ds.Description,
+		ConfigSchema: ds.ConfigSchema,
+		InputSchema:  ds.InputSchema,
+	}, nil
+}
+
+func (e *HandlerPricingEngine) ValidateConfig(strategyType string, config map[string]interface{}) []handlers.FieldError {
+	err := e.engine.ValidateConfig(strategyType, config)
+	if err == nil {
+		return nil
+	}
+
+	var ve *domain.ValidationError
+	if !errors.As(err, &ve) {
+		return []handlers.FieldError{{Path: "/strategy_type", Message: err.Error()}}
+	}
+
+	// Strategy paths are relative to the config; report them relative to the request body
+	fieldErrors := make([]handlers.FieldError, len(ve.Errors))
+	for i, fe := range ve.Errors {
+		fieldErrors[i] = handlers.FieldError{Path: "/config" + fe.Path, Message: fe.Message}
+	}
+	return fieldErrors
+}
+
+func (e *HandlerPricingEngine) Simulate(ctx context.Context, rule *handlers.PricingRule, inputs map[string]interface{}, axes []handlers.SimulationAxis) (*handlers.SimulationResult, error) {
+	domainAxes := make([]domain.SimulationAxis, len(axes))
+	for i, axis := range axes {
+		domainAxes[i] = domain.SimulationAxis{Field: axis.Field, Values: axis.Values}
+	}
+
+	ruleID := rule.ID
+	result, err := e.engine.Simulate(&domain.SimulationRequest{
+		Strategy: rule.StrategyType,
+		RuleID:   &ruleID,
+		Config:   rule.Config,
+		Inputs:   inputs,
+		Axes:     domainAxes,
+		MaxCells: e.maxSimulationCells,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	cells := make([]handlers.SimulationCell, len(result.Cells))
+	for i, dc := range result.Cells {
+		cells[i] = handlers.SimulationCell{
+			Index:      dc.Index,
+			Inputs:     dc.Inputs,
+			FinalPrice: dc.FinalPrice,
+			Currency:   dc.Currency,
+			Error:      dc.Error,
+		}
+		if dc.Breakdown != nil {
+			cells[i].Breakdown = dc.Breakdown
+		}
+	}
+	return &handlers.SimulationResult{Shape: result.Shape, Cells: cells}, nil
+}
+
+func (e *HandlerPricingEngine) DiffRevisions(from, to *handlers.RuleRevision) []handlers.JSONChange {
+	fromDoc := toDomainRevision(from).Document()
+	toDoc := toDomainRevision(to).Document()
+
+	domainChanges := service.DiffJSON(fromDoc, toDoc)
+	changes := make([]handlers.JSONChange, len(domainChanges))
+	for i, dc := range domainChanges {
+		changes[i] = handlers.JSONChange{Op: dc.Op, Path: dc.Path, From: dc.From, To: dc.To}
+	}
+	return changes
+}
+
+// HandlerBacktestRunner adapts service.BacktestService to handlers.BacktestRunner
+type HandlerBacktestRunner struct {
+	service *service.BacktestService
+}
+
+func (r *HandlerBacktestRunner) Start(ctx context.Context, req *handlers.BacktestRequest) (*handlers.Backtest, error) {
+	job, err := r.service.Start(ctx, domain.BacktestRequest{
+		UserID:       req.UserID,
+		RuleID:       req.RuleID,
+		StrategyType: req.StrategyType,
+		Config:       req.Config,
+		From:         req.From,
+		To:           req.To,
+	})
+	if errors.Is(err, domain.ErrBacktestBusy) {
+		return nil, fmt.Errorf("%w: %v", handlers.ErrBacktestBusy, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return toHandlerBacktest(job), nil
+}
+
+func (r *HandlerBacktestRunner) Get(ctx context.Context, userID, id uuid.UUID) (*handlers.Backtest, error) {
+	job, err := r.service.Get(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	return toHandlerBacktest(job), nil
+}
+
+func (r *HandlerBacktestRunner) Watch(ctx context.Context, userID, id uuid.UUID) (<-chan *handlers.Backtest, error) {
+	jobs, err := r.service.Watch(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	updates := make(chan *handlers.Backtest)
+	go func() {
+		defer close(updates)
+		for job := range jobs {
+			select {
+			case updates <- toHandlerBacktest(&job):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return updates, nil
+}
+
+func toHandlerBacktest(job *domain.BacktestJob) *handlers.Backtest {
+	backtest := &handlers.Backtest{
+		ID:          job.ID,
+		Status:      string(job.Status),
+		Done:        job.Status.Done(),
+		Processed:   job.Progress.Processed,
+		Total:       job.Progress.Total,
+		Error:       job.Error,
+		CreatedAt:   job.CreatedAt,
+		StartedAt:   job.StartedAt,
+		CompletedAt: job.CompletedAt,
+	}
+	if job.Report != nil {
+		backtest.Report = job.Report
+	}
+	return backtest
+}
+
+// HandlerCalculationLogger adapts service.CalculationLogWriter to handlers.CalculationLogger
+type HandlerCalculationLogger struct {
+	writer *service.CalculationLogWriter
+}
+
+func (l *HandlerCalculationLogger) Log(ctx context.Context, record *handlers.CalculationRecord) error {
+	domainLog := &domain.CalculationLog{
+		ID:              record.ID,
+		UserID:          record.UserID,
+		APIKeyID:        record.APIKeyID,
+		RuleID:          record.RuleID,
+		RuleRevisionID:  record.RuleRevisionID,
+		RuleRevision:    record.RuleRevision,
+		StrategyType:    record.StrategyType,
+		InputData:       record.Input,
+		OutputData:      record.Output,
+		ExecutionTimeMs: record.ExecutionTimeMs,
+		RequestID:       record.RequestID,
+		ClientIP:        record.ClientIP,
+		EngineVersion:   record.EngineVersion,
+		Error:           record.Error,
+		CreatedAt:       record.CreatedAt,
+	}
+	return l.writer.Enqueue(domainLog)
+}
+
+// registerCalculationLogMetrics exports the counters of the calculation log writer
+func registerCalculationLogMetrics(registry *metrics.Registry, writer *service.CalculationLogWriter) {
+	registry.NewGaugeFunc("harmonia_calculation_log_queue_depth", "Calculation logs waiting to be written.",
+		func() float64 { return float64(writer.Stats().Queued) })
+	registry.NewCounterFunc("harmonia_calculation_logs_written_total", "Calculation logs stored, including logs replayed from the spill file.",
+		func() float64 { return float64(writer.Stats().Written) })
+	registry.NewCounterFunc("harmonia_calculation_logs_dropped_total", "Calculation logs dropped because the queue was full.",
+		func() float64 { return float64(writer.Stats().Dropped) })
+	registry.NewCounterFunc("harmonia_calculation_logs_spilled_total", "Calculation logs spilled to disk while the database was unavailable.",
+		func() float64 { return float64(writer.Stats().Spilled) })
+	registry.NewCounterFunc("harmonia_calculation_logs_failed_total", "Calculation logs rejected by the database or lost.",
+		func() float64 { return float64(writer.Stats().Failed) })
+}
+
+// rootHandler returns API information
+func rootHandler(c *gin.Context) {
+	acceptHeader := c.GetHeader("Accept")
+
+	// Return HTML if Accept header includes text/html
+	if acceptHeader != "" && (acceptHeader == "text/html" || acceptHeader == "*/*") {
+		c.Header("Content-Type", "text/html; charset=utf-8")
+		c.String(http.StatusOK, `
+<!DOCTYPE html>
+<html>
+<head>
+    <title>Harmonia API</title>
     <style>
         body { 
             font-family: 'Segoe UI', Tahoma, Geneva, Verdana, sans-serif; 
@@ -788,14 +1901,19 @@ func rootHandler(c *gin.Context) {
 		"version": "1.0.0",
 		"tagline": "Enterprise-grade dynamic pricing for indie developers",
 		"endpoints": gin.H{
-			"health":    "GET /health",
-			"users":     "POST /v1/users",
-			"auth":      "POST /v1/auth/keys",
-			"calculate": "POST /v1/pricing/calculate",
-			"rules":     "GET /v1/pricing/rules",
-			"products":  "GET /v1/products",
-			"logs":      "GET /v1/logs",
-			"docs":      "https://github.com/saintparish4/harmonia",
+			"health":     "GET /health",
+			"users":      "POST /v1/users",
+			"auth":       "POST /v1/auth/keys",
+			"calculate":  "POST /v1/pricing/calculate",
+			"strategies": "GET /v1/pricing/strategies",
+			"rules":      "GET /v1/pricing/rules",
+			"products":   "GET /v1/products",
+			"logs":       "GET /v1/logs",
+			"log_export": "GET /v1/logs/export",
+			"log_replay": "POST /v1/logs/:id/replay",
+			"analytics":  "GET /v1/analytics",
+			"metrics":    "GET /metrics",
+			"docs":       "https://github.com/saintparish4/harmonia",
 		},
 	})
 }
@@ -804,3 +1922,653 @@ func rootHandler(c *gin.Context) {
 func main() {
 	StartServer()
 }
+
+// HandlerRuleBundler adapts service.BundleService to handlers.RuleBundler
+type HandlerRuleBundler struct {
+	service *service.BundleService
+}
+
+func (b *HandlerRuleBundler) Export(ctx context.Context, userID uuid.UUID, includeProducts bool) (*dto.RuleBundle, error) {
+	bundle, err := b.service.Export(ctx, userID, includeProducts)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &dto.RuleBundle{
+		Version:    bundle.Version,
+		ExportedAt: bundle.ExportedAt,
+		Rules:      make([]dto.BundleRule, len(bundle.Rules)),
+	}
+	for i, rule := range bundle.Rules {
+		out.Rules[i] = dto.BundleRule(rule)
+	}
+	for _, product := range bundle.Products {
+		entry := dto.BundleProduct{
+			SKU:         product.SKU,
+			Name:        product.Name,
+			Description: product.Description,
+			BaseCost:    product.BaseCost,
+			Metadata:    product.Metadata,
+			IsActive:    product.IsActive,
+			DefaultRule: product.DefaultRule,
+		}
+		for _, assignment := range product.Schedule {
+			entry.Schedule = append(entry.Schedule, dto.BundleAssignment(assignment))
+		}
+		out.Products = append(out.Products, entry)
+	}
+	return out, nil
+}
+
+func (b *HandlerRuleBundler) Import(ctx context.Context, req *handlers.BundleImport) (*handlers.BundleImportResult, error) {
+	bundle := &domain.Bundle{
+		Version:    req.Bundle.Version,
+		ExportedAt: req.Bundle.ExportedAt,
+		Rules:      make([]domain.BundleRule, len(req.Bundle.Rules)),
+	}
+	for i, rule := range req.Bundle.Rules {
+		bundle.Rules[i] = domain.BundleRule(rule)
+	}
+	for _, product := range req.Bundle.Products {
+		entry := domain.BundleProduct{
+			SKU:         product.SKU,
+			Name:        product.Name,
+			Description: product.Description,
+			BaseCost:    product.BaseCost,
+			Metadata:    product.Metadata,
+			IsActive:    product.IsActive,
+			DefaultRule: product.DefaultRule,
+		}
+		for _, assignment := range product.Schedule {
+			entry.Schedule = append(entry.Schedule, domain.BundleAssignment(assignment))
+		}
+		bundle.Products = append(bundle.Products, entry)
+	}
+
+	report, err := b.service.Import(ctx, domain.ImportRequest{
+		Bundle: bundle,
+		Options: domain.ImportOptions{
+			DryRun:     req.DryRun,
+			MatchBy:    req.MatchBy,
+			Mode:       req.Mode,
+			Publish:    req.Publish,
+			ChangeNote: req.ChangeNote,
+		},
+		Approval: domain.DraftApproval{UserID: req.UserID, KeyID: req.KeyID},
+	})
+	switch {
+	case errors.Is(err, domain.ErrUnsupportedBundleVersion), errors.Is(err, domain.ErrInvalidImportOptions):
+		return nil, fmt.Errorf("%w: %v", handlers.ErrInvalidBundle, err)
+	case errors.Is(err, domain.ErrSelfApprovalForbidden):
+		return nil, handlers.ErrApprovalRequired
+	case errors.Is(err, domain.ErrDuplicateExternalKey):
+		return nil, fmt.Errorf("%w: %v", handlers.ErrDuplicateExternalKey, err)
+	case err != nil:
+		return nil, err
+	}
+
+	return &handlers.BundleImportResult{
+		Rejected:   report.Rejected(),
+		Conflicted: len(report.Conflicts) > 0,
+		Report:     report,
+	}, nil
+}
+
+// HandlerProductCosts adapts service.ProductCostService to handlers.ProductCosts
+type HandlerProductCosts struct {
+	service *service.ProductCostService
+}
+
+func (p *HandlerProductCosts) Timeline(ctx context.Context, productID uuid.UUID) ([]*handlers.ProductCost, error) {
+	timeline, err := p.service.Timeline(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	costs := make([]*handlers.ProductCost, len(timeline))
+	for i, period := range timeline {
+		costs[i] = toHandlerCostPeriod(period)
+	}
+	return costs, nil
+}
+
+func (p *HandlerProductCosts) AddCost(ctx context.Context, cost *handlers.ProductCost) error {
+	domainCost := &domain.ProductCost{
+		ProductID:     cost.ProductID,
+		Cost:          cost.Cost,
+		EffectiveFrom: cost.EffectiveFrom,
+		Source:        cost.Source,
+		Note:          cost.Note,
+		CreatedBy:     cost.CreatedBy,
+	}
+	if err := p.service.AddCost(ctx, domainCost); err != nil {
+		return toHandlerCostError(err)
+	}
+
+	// Return the entry as it sits in the timeline, with its window and change
+	timeline, err := p.service.Timeline(ctx, cost.ProductID)
+	if err != nil {
+		return err
+	}
+	for _, period := range timeline {
+		if period.ID == domainCost.ID {
+			*cost = *toHandlerCostPeriod(period)
+			break
+		}
+	}
+	return nil
+}
+
+func (p *HandlerProductCosts) Recompute(ctx context.Context, userID, productID, costID uuid.UUID, quantity int, context map[string]interface{}) (*handlers.CostRecomputeReport, error) {
+	report, err := p.service.Recompute(ctx, userID, productID, costID, quantity, context)
+	if err != nil {
+		return nil, toHandlerCostError(err)
+	}
+
+	result := &handlers.CostRecomputeReport{
+		ProductID:   report.ProductID,
+		SKU:         report.SKU,
+		CostID:      report.CostID,
+		EffectiveAt: report.EffectiveAt,
+		RuleID:      report.RuleID,
+		Before:      toHandlerPriceAtCost(report.Before),
+		After:       toHandlerPriceAtCost(report.After),
+		PriceChange: report.PriceChange,
+		Error:       report.Error,
+	}
+	if report.RuleSource != nil {
+		result.RuleSource = &handlers.RuleSource{
+			Level: report.RuleSource.Level,
+			ID:    report.RuleSource.ID,
+			Name:  report.RuleSource.Name,
+		}
+	}
+	return result, nil
+}
+
+func toHandlerCostPeriod(period *domain.CostPeriod) *handlers.ProductCost {
+	return &handlers.ProductCost{
+		ID:             period.ID,
+		ProductID:      period.ProductID,
+		Cost:           period.Cost,
+		EffectiveFrom:  period.EffectiveFrom,
+		EffectiveTo:    period.EffectiveTo,
+		Status:         period.Status,
+		Source:         period.Source,
+		Note:           period.Note,
+		Change:         period.Change,
+		ChangePercent:  period.ChangePercent,
+		PreviousCostID: period.PreviousCostID,
+		CreatedBy:      period.CreatedBy,
+		CreatedAt:      period.CreatedAt,
+	}
+}
+
+func toHandlerPriceAtCost(price *domain.PriceAtCost) *handlers.PriceAtCost {
+	if price == nil {
+		return nil
+	}
+	return &handlers.PriceAtCost{
+		Cost:          price.Cost,
+		FinalPrice:    price.FinalPrice,
+		Margin:        price.Margin,
+		MarginPercent: price.MarginPercent,
+	}
+}
+
+// toHandlerCostError maps cost history errors to their handler equivalents
+func toHandlerCostError(err error) error {
+	switch {
+	case errors.Is(err, domain.ErrCostExists):
+		return fmt.Errorf("%w: %v", handlers.ErrCostExists, err)
+	case errors.Is(err, domain.ErrCostNotFound):
+		return fmt.Errorf("%w: %v", handlers.ErrCostNotFound, err)
+	case errors.Is(err, domain.ErrInvalidCost):
+		return fmt.Errorf("%w: %v", handlers.ErrInvalidCost, err)
+	}
+	return err
+}
+
+// HandlerProductCatalog adapts service.ProductCatalogService to handlers.ProductCatalog
+type HandlerProductCatalog struct {
+	service *service.ProductCatalogService
+}
+
+func (p *HandlerProductCatalog) StartImport(ctx context.Context, req *handlers.ProductImportRequest) (*handlers.ProductImport, error) {
+	job, err := p.service.StartImport(ctx, domain.ProductImportRequest{
+		UserID:  req.UserID,
+		Format:  req.Format,
+		Mapping: req.Mapping,
+		Data:    req.Data,
+	})
+	if errors.Is(err, domain.ErrProductImportInvalid) {
+		return nil, fmt.Errorf("%w: %v", handlers.ErrInvalidProductFile, err)
+	}
+	if errors.Is(err, domain.ErrProductImportBusy) {
+		return nil, fmt.Errorf("%w: %v", handlers.ErrProductImportBusy, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return toHandlerProductImport(job), nil
+}
+
+func (p *HandlerProductCatalog) GetImport(ctx context.Context, userID, id uuid.UUID) (*handlers.ProductImport, error) {
+	job, err := p.service.GetImport(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	return toHandlerProductImport(job), nil
+}
+
+func (p *HandlerProductCatalog) Export(ctx context.Context, userID uuid.UUID, format string, w io.Writer) error {
+	return p.service.Export(ctx, userID, format, w)
+}
+
+func toHandlerProductImport(job *domain.ProductImportJob) *handlers.ProductImport {
+	return &handlers.ProductImport{
+		ID:          job.ID,
+		Format:      job.Format,
+		Status:      string(job.Status),
+		Done:        job.Status.Done(),
+		Processed:   job.Progress.Processed,
+		Total:       job.Progress.Total,
+		Result:      job.Result,
+		Error:       job.Error,
+		CreatedAt:   job.CreatedAt,
+		StartedAt:   job.StartedAt,
+		CompletedAt: job.CompletedAt,
+		Volatile:    true, // Import jobs are kept in the service's memory
+	}
+}
+
+// HandlerPriceBooks adapts service.PriceBookService to handlers.PriceBooks
+type HandlerPriceBooks struct {
+	service *service.PriceBookService
+}
+
+func (p *HandlerPriceBooks) Create(ctx context.Context, book *handlers.PriceBook) error {
+	domainBook := toDomainPriceBook(book)
+	if err := p.service.Create(ctx, domainBook); err != nil {
+		return toHandlerPriceBookError(err)
+	}
+	*book = *toHandlerPriceBook(domainBook)
+	return nil
+}
+
+func (p *HandlerPriceBooks) Get(ctx context.Context, id uuid.UUID) (*handlers.PriceBook, error) {
+	book, err := p.service.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return toHandlerPriceBook(book), nil
+}
+
+func (p *HandlerPriceBooks) List(ctx context.Context, userID uuid.UUID) ([]*handlers.PriceBook, error) {
+	books, err := p.service.List(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	handlerBooks := make([]*handlers.PriceBook, len(books))
+	for i, book := range books {
+		handlerBooks[i] = toHandlerPriceBook(book)
+	}
+	return handlerBooks, nil
+}
+
+func (p *HandlerPriceBooks) Update(ctx context.Context, book *handlers.PriceBook) error {
+	domainBook := toDomainPriceBook(book)
+	if err := p.service.Update(ctx, domainBook); err != nil {
+		return toHandlerPriceBookError(err)
+	}
+	*book = *toHandlerPriceBook(domainBook)
+	return nil
+}
+
+func (p *HandlerPriceBooks) Delete(ctx context.Context, id uuid.UUID) error {
+	return p.service.Delete(ctx, id)
+}
+
+func (p *HandlerPriceBooks) Regenerate(ctx context.Context, id uuid.UUID) (*handlers.PriceBook, error) {
+	book, err := p.service.Regenerate(ctx, id)
+	if err != nil {
+		return nil, toHandlerPriceBookError(err)
+	}
+	return toHandlerPriceBook(book), nil
+}
+
+func (p *HandlerPriceBooks) Entries(ctx context.Context, filter handlers.PriceBookEntryFilter) ([]*handlers.PriceBookEntry, int, error) {
+	entries, total, err := p.service.Entries(ctx, domain.PriceBookEntryFilter{
+		PriceBookID: filter.PriceBookID,
+		SKU:         filter.SKU,
+		Location:    filter.Location,
+		Currency:    filter.Currency,
+		Channel:     filter.Channel,
+		Limit:       filter.Limit,
+		Offset:      filter.Offset,
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	handlerEntries := make([]*handlers.PriceBookEntry, len(entries))
+	for i, entry := range entries {
+		handlerEntries[i] = &handlers.PriceBookEntry{
+			ProductID:      entry.ProductID,
+			SKU:            entry.SKU,
+			Location:       entry.Location,
+			Currency:       entry.Currency,
+			Channel:        entry.Channel,
+			FinalPrice:     entry.FinalPrice,
+			PriceCurrency:  entry.PriceCurrency,
+			BaseCost:       entry.BaseCost,
+			StrategyType:   entry.StrategyType,
+			RuleID:         entry.RuleID,
+			RuleRevisionID: entry.RuleRevisionID,
+			RuleRevision:   entry.RuleRevision,
+			RuleSource:     entry.RuleSource,
+			Error:          entry.Error,
+			GeneratedAt:    entry.GeneratedAt,
+		}
+	}
+	return handlerEntries, total, nil
+}
+
+func (p *HandlerPriceBooks) Export(ctx context.Context, id uuid.UUID, format string, w io.Writer) error {
+	book, err := p.service.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	return p.service.Export(ctx, book, format, w)
+}
+
+// toHandlerPriceBookError maps price book errors to their handler equivalents
+func toHandlerPriceBookError(err error) error {
+	switch {
+	case errors.Is(err, domain.ErrPriceBookExists):
+		return fmt.Errorf("%w: %v", handlers.ErrPriceBookExists, err)
+	case errors.Is(err, domain.ErrPriceBookBusy):
+		return fmt.Errorf("%w: %v", handlers.ErrPriceBookBusy, err)
+	case errors.Is(err, domain.ErrInvalidPriceBook), errors.Is(err, domain.ErrPriceBookTooLarge):
+		return fmt.Errorf("%w: %v", handlers.ErrInvalidPriceBook, err)
+	}
+	return err
+}
+
+func toDomainPriceBook(book *handlers.PriceBook) *domain.PriceBook {
+	revisions := make([]domain.PriceBookRevision, len(book.RuleRevisions))
+	for i, revision := range book.RuleRevisions {
+		revisions[i] = domain.PriceBookRevision{
+			RuleID:     revision.RuleID,
+			RevisionID: revision.RevisionID,
+			Revision:   revision.Revision,
+			Entries:    revision.Entries,
+		}
+	}
+
+	return &domain.PriceBook{
+		ID:          book.ID,
+		UserID:      book.UserID,
+		Name:        book.Name,
+		Description: book.Description,
+		Dimensions: domain.PriceBookDimensions{
+			Locations:  book.Locations,
+			Currencies: book.Currencies,
+			Channels:   book.Channels,
+		},
+		Quantity:       book.Quantity,
+		Context:        book.Context,
+		AutoRegenerate: book.AutoRegenerate,
+		Version:        book.Version,
+		Status:         book.Status,
+		Generation:     book.Generation,
+		GeneratedAt:    book.GeneratedAt,
+		StartedAt:      book.StartedAt,
+		RuleRevisions:  revisions,
+		EntryCount:     book.EntryCount,
+		ErrorCount:     book.ErrorCount,
+		LastError:      book.LastError,
+		CreatedAt:      book.CreatedAt,
+		UpdatedAt:      book.UpdatedAt,
+	}
+}
+
+func toHandlerPriceBook(book *domain.PriceBook) *handlers.PriceBook {
+	revisions := make([]handlers.PriceBookRevision, len(book.RuleRevisions))
+	for i, revision := range book.RuleRevisions {
+		revisions[i] = handlers.PriceBookRevision{
+			RuleID:     revision.RuleID,
+			RevisionID: revision.RevisionID,
+			Revision:   revision.Revision,
+			Entries:    revision.Entries,
+		}
+	}
+
+	return &handlers.PriceBook{
+		ID:             book.ID,
+		UserID:         book.UserID,
+		Name:           book.Name,
+		Description:    book.Description,
+		Locations:      book.Dimensions.Locations,
+		Currencies:     book.Dimensions.Currencies,
+		Channels:       book.Dimensions.Channels,
+		Quantity:       book.Quantity,
+		Context:        book.Context,
+		AutoRegenerate: book.AutoRegenerate,
+		Version:        book.Version,
+		Status:         book.Status,
+		Generation:     book.Generation,
+		GeneratedAt:    book.GeneratedAt,
+		StartedAt:      book.StartedAt,
+		RuleRevisions:  revisions,
+		EntryCount:     book.EntryCount,
+		ErrorCount:     book.ErrorCount,
+		LastError:      book.LastError,
+		CreatedAt:      book.CreatedAt,
+		UpdatedAt:      book.UpdatedAt,
+	}
+}
+
+// HandlerWebhooks adapts service.WebhookService to handlers.Webhooks
+type HandlerWebhooks struct {
+	service *service.WebhookService
+}
+
+func (w *HandlerWebhooks) CreateEndpoint(ctx context.Context, endpoint *handlers.WebhookEndpoint) error {
+	domainEndpoint := toDomainWebhookEndpoint(endpoint)
+	if err := w.service.CreateEndpoint(ctx, domainEndpoint); err != nil {
+		return toHandlerWebhookError(err)
+	}
+	*endpoint = *toHandlerWebhookEndpoint(domainEndpoint)
+	return nil
+}
+
+func (w *HandlerWebhooks) GetEndpoint(ctx context.Context, id uuid.UUID) (*handlers.WebhookEndpoint, error) {
+	endpoint, err := w.service.GetEndpoint(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return toHandlerWebhookEndpoint(endpoint), nil
+}
+
+func (w *HandlerWebhooks) ListEndpoints(ctx context.Context, userID uuid.UUID) ([]*handlers.WebhookEndpoint, error) {
+	endpoints, err := w.service.ListEndpoints(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	handlerEndpoints := make([]*handlers.WebhookEndpoint, len(endpoints))
+	for i, endpoint := range endpoints {
+		handlerEndpoints[i] = toHandlerWebhookEndpoint(endpoint)
+	}
+	return handlerEndpoints, nil
+}
+
+func (w *HandlerWebhooks) UpdateEndpoint(ctx context.Context, endpoint *handlers.WebhookEndpoint) error {
+	domainEndpoint := toDomainWebhookEndpoint(endpoint)
+	if err := w.service.UpdateEndpoint(ctx, domainEndpoint); err != nil {
+		return toHandlerWebhookError(err)
+	}
+	*endpoint = *toHandlerWebhookEndpoint(domainEndpoint)
+	return nil
+}
+
+func (w *HandlerWebhooks) RotateSecret(ctx context.Context, endpoint *handlers.WebhookEndpoint) error {
+	domainEndpoint := toDomainWebhookEndpoint(endpoint)
+	if err := w.service.RotateSecret(ctx, domainEndpoint); err != nil {
+		return err
+	}
+	*endpoint = *toHandlerWebhookEndpoint(domainEndpoint)
+	return nil
+}
+
+func (w *HandlerWebhooks) DeleteEndpoint(ctx context.Context, id uuid.UUID) error {
+	return w.service.DeleteEndpoint(ctx, id)
+}
+
+func (w *HandlerWebhooks) Deliveries(ctx context.Context, filter handlers.WebhookDeliveryFilter) ([]*handlers.WebhookDelivery, int, error) {
+	deliveries, total, err := w.service.Deliveries(ctx, domain.WebhookDeliveryFilter{
+		EndpointID: filter.EndpointID,
+		Status:     filter.Status,
+		EventType:  filter.EventType,
+		Limit:      filter.Limit,
+		Offset:     filter.Offset,
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	handlerDeliveries := make([]*handlers.WebhookDelivery, len(deliveries))
+	for i, delivery := range deliveries {
+		handlerDeliveries[i] = toHandlerWebhookDelivery(delivery)
+	}
+	return handlerDeliveries, total, nil
+}
+
+func (w *HandlerWebhooks) Delivery(ctx context.Context, id uuid.UUID) (*handlers.WebhookDelivery, []*handlers.WebhookAttempt, error) {
+	delivery, attempts, err := w.service.Delivery(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	handlerAttempts := make([]*handlers.WebhookAttempt, len(attempts))
+	for i, attempt := range attempts {
+		handlerAttempts[i] = &handlers.WebhookAttempt{
+			Attempt:        attempt.Attempt,
+			AttemptedAt:    attempt.AttemptedAt,
+			DurationMs:     attempt.DurationMs,
+			ResponseStatus: attempt.ResponseStatus,
+			ResponseBody:   attempt.ResponseBody,
+			Error:          attempt.Error,
+		}
+	}
+	return toHandlerWebhookDelivery(delivery), handlerAttempts, nil
+}
+
+func (w *HandlerWebhooks) Replay(ctx context.Context, deliveryID uuid.UUID) (*handlers.WebhookDelivery, error) {
+	delivery, err := w.service.Replay(ctx, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	return toHandlerWebhookDelivery(delivery), nil
+}
+
+// toHandlerWebhookError maps webhook errors to their handler equivalents
+func toHandlerWebhookError(err error) error {
+	if errors.Is(err, domain.ErrInvalidWebhook) {
+		return fmt.Errorf("%w: %v", handlers.ErrInvalidWebhook, err)
+	}
+	return err
+}
+
+func toDomainWebhookEndpoint(endpoint *handlers.WebhookEndpoint) *domain.WebhookEndpoint {
+	return &domain.WebhookEndpoint{
+		ID:          endpoint.ID,
+		UserID:      endpoint.UserID,
+		URL:         endpoint.URL,
+		Description: endpoint.Description,
+		Secret:      endpoint.Secret,
+		EventTypes:  endpoint.EventTypes,
+		IsActive:    endpoint.IsActive,
+		CreatedAt:   endpoint.CreatedAt,
+		UpdatedAt:   endpoint.UpdatedAt,
+	}
+}
+
+func toHandlerWebhookEndpoint(endpoint *domain.WebhookEndpoint) *handlers.WebhookEndpoint {
+	return &handlers.WebhookEndpoint{
+		ID:          endpoint.ID,
+		UserID:      endpoint.UserID,
+		URL:         endpoint.URL,
+		Description: endpoint.Description,
+		Secret:      endpoint.Secret,
+		EventTypes:  endpoint.EventTypes,
+		IsActive:    endpoint.IsActive,
+		CreatedAt:   endpoint.CreatedAt,
+		UpdatedAt:   endpoint.UpdatedAt,
+	}
+}
+
+func toHandlerWebhookDelivery(delivery *domain.WebhookDelivery) *handlers.WebhookDelivery {
+	handlerDelivery := &handlers.WebhookDelivery{
+		ID:             delivery.ID,
+		EndpointID:     delivery.EndpointID,
+		Status:         delivery.Status,
+		Attempts:       delivery.Attempts,
+		NextAttemptAt:  delivery.NextAttemptAt,
+		LastAttemptAt:  delivery.LastAttemptAt,
+		ResponseStatus: delivery.ResponseStatus,
+		LastError:      delivery.LastError,
+		ReplayOf:       delivery.ReplayOf,
+		CreatedAt:      delivery.CreatedAt,
+		UpdatedAt:      delivery.UpdatedAt,
+	}
+	if delivery.Event != nil {
+		handlerDelivery.EventID = delivery.Event.ID
+		handlerDelivery.EventType = delivery.Event.Type
+		handlerDelivery.Payload = delivery.Event.Payload
+	}
+	return handlerDelivery
+}
+
+// IdempotencyStoreAdapter adapts domain.IdempotencyRepository for the idempotency middleware
+type IdempotencyStoreAdapter struct {
+	repo domain.IdempotencyRepository
+}
+
+func (a *IdempotencyStoreAdapter) Reserve(ctx context.Context, record *middleware.IdempotencyRecord, now, staleBefore time.Time) (*middleware.IdempotencyRecord, bool, error) {
+	existing, reserved, err := a.repo.Reserve(ctx, toDomainIdempotencyRecord(record), now, staleBefore)
+	if err != nil || reserved {
+		return nil, reserved, err
+	}
+	return &middleware.IdempotencyRecord{
+		UserID:      existing.UserID,
+		Key:         existing.Key,
+		Fingerprint: existing.Fingerprint,
+		Status:      existing.Status,
+		Headers:     existing.Headers,
+		Body:        existing.Body,
+		CreatedAt:   existing.CreatedAt,
+		CompletedAt: existing.CompletedAt,
+		ExpiresAt:   existing.ExpiresAt,
+	}, false, nil
+}
+
+func (a *IdempotencyStoreAdapter) Complete(ctx context.Context, record *middleware.IdempotencyRecord) error {
+	return a.repo.Complete(ctx, toDomainIdempotencyRecord(record))
+}
+
+func (a *IdempotencyStoreAdapter) Release(ctx context.Context, record *middleware.IdempotencyRecord) error {
+	return a.repo.Release(ctx, toDomainIdempotencyRecord(record))
+}
+
+func toDomainIdempotencyRecord(record *middleware.IdempotencyRecord) *domain.IdempotencyRecord {
+	return &domain.IdempotencyRecord{
+		UserID:      record.UserID,
+		Key:         record.Key,
+		Fingerprint: record.Fingerprint,
+		Token:       record.Token,
+		Status:      record.Status,
+		Headers:     record.Headers,
+		Body:        record.Body,
+		CreatedAt:   record.CreatedAt,
+		CompletedAt: record.CompletedAt,
+		ExpiresAt:   record.ExpiresAt,
+	}
+}
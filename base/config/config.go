@@ -2,8 +2,10 @@ package config
 
 import (
 	"fmt"
+	"net"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
@@ -37,17 +39,46 @@ type DatabaseConfig struct {
 }
 
 type APIConfig struct {
-	Version               string
-	RateLimitRequests     int
+	Version                string
+	RateLimitRequests      int
 	RateLimitWindowSeconds int
+	SimulationMaxCells     int // Maximum grid size for rule simulations
+
+	PriceBookRefreshInterval time.Duration // How often price books are checked for changes; 0 disables
+	PriceBookMaxEntries      int           // Maximum entries in a single price book
+
+	WebhookDispatchInterval time.Duration // How often the event outbox and due deliveries are processed; 0 disables
+	WebhookMaxAttempts      int           // Attempts before a delivery is marked failed
+	WebhookTimeout          time.Duration // Timeout of each delivery request
+	WebhookAllowPrivate     bool          // Allow endpoints on loopback and private networks; for development only
+
+	IdempotencyKeyTTL time.Duration // How long responses to Idempotency-Key requests are replayed
+	MaxRequestSize    int64         // Largest request body, in bytes, buffered for an Idempotency-Key
+
+	CalculationLogQueueSize      int           // Calculation logs waiting to be written before new ones are dropped
+	CalculationLogBatchSize      int           // Calculation logs written per batch
+	CalculationLogFlushInterval  time.Duration // How long a partial batch waits before it is written
+	CalculationLogEnqueueTimeout time.Duration // How long a request waits for room in a full queue
+	CalculationLogSpillPath      string        // File for logs that could not be written; empty disables
+
+	LogMaintenanceInterval time.Duration // How often log partitions are created and retention applied; 0 disables
+	LogPartitionsAhead     int           // Future monthly log partitions kept ready
+	LogRetentionDays       int           // Days logs are kept for accounts without their own retention; 0 keeps them forever
+	LogRetentionMode       string        // drop or detach expired log partitions
+	LogArchiveDir          string        // Directory expired log partitions are archived to; empty disables
 }
 
 type SecurityConfig struct {
 	JWTSecret    string
 	AdminAPIKey  string
+	MetricsToken string // Bearer token for /metrics; empty disables the endpoint
 	CORSOrigins  string
 	CORSMethods  string
 	CORSHeaders  string
+
+	// Proxy addresses or CIDRs whose X-Forwarded-For header is believed when
+	// working out client IPs; empty trusts no proxy
+	TrustedProxies []string
 }
 
 type LoggingConfig struct {
@@ -78,16 +109,43 @@ func Load() (*Config, error) {
 			ConnMaxLifetime: getEnvAsDuration("DB_CONN_MAX_LIFETIME", 5*time.Minute),
 		},
 		API: APIConfig{
-			Version:               getEnv("API_VERSION", "v1"),
-			RateLimitRequests:     getEnvAsInt("RATE_LIMIT_REQUESTS", 100),
+			Version:                getEnv("API_VERSION", "v1"),
+			RateLimitRequests:      getEnvAsInt("RATE_LIMIT_REQUESTS", 100),
 			RateLimitWindowSeconds: getEnvAsInt("RATE_LIMIT_WINDOW", 3600),
+			SimulationMaxCells:     getEnvAsInt("SIMULATION_MAX_CELLS", 1000),
+
+			PriceBookRefreshInterval: getEnvAsDuration("PRICE_BOOK_REFRESH_INTERVAL", time.Minute),
+			PriceBookMaxEntries:      getEnvAsInt("PRICE_BOOK_MAX_ENTRIES", 200000),
+
+			WebhookDispatchInterval: getEnvAsDuration("WEBHOOK_DISPATCH_INTERVAL", 5*time.Second),
+			WebhookMaxAttempts:      getEnvAsInt("WEBHOOK_MAX_ATTEMPTS", 8),
+			WebhookTimeout:          getEnvAsDuration("WEBHOOK_TIMEOUT", 10*time.Second),
+			WebhookAllowPrivate:     getEnvAsBool("WEBHOOK_ALLOW_PRIVATE_NETWORKS", false),
+
+			IdempotencyKeyTTL: getEnvAsDuration("IDEMPOTENCY_KEY_TTL", 24*time.Hour),
+			MaxRequestSize:    int64(getEnvAsInt("MAX_REQUEST_SIZE", 50<<20)), // the largest upload, a product file
+
+			CalculationLogQueueSize:      getEnvAsInt("CALC_LOG_QUEUE_SIZE", 10000),
+			CalculationLogBatchSize:      getEnvAsInt("CALC_LOG_BATCH_SIZE", 500),
+			CalculationLogFlushInterval:  getEnvAsDuration("CALC_LOG_FLUSH_INTERVAL", time.Second),
+			CalculationLogEnqueueTimeout: getEnvAsDuration("CALC_LOG_ENQUEUE_TIMEOUT", 10*time.Millisecond),
+			CalculationLogSpillPath:      getEnv("CALC_LOG_SPILL_PATH", ""),
+
+			LogMaintenanceInterval: getEnvAsDuration("LOG_MAINTENANCE_INTERVAL", time.Hour),
+			LogPartitionsAhead:     getEnvAsInt("LOG_PARTITIONS_AHEAD", 3),
+			LogRetentionDays:       getEnvAsInt("LOG_RETENTION_DAYS", 0),
+			LogRetentionMode:       getEnv("LOG_RETENTION_MODE", "drop"),
+			LogArchiveDir:          getEnv("LOG_ARCHIVE_DIR", ""),
 		},
 		Security: SecurityConfig{
-			JWTSecret:   getEnv("JWT_SECRET", ""),
-			AdminAPIKey: getEnv("ADMIN_API_KEY", ""),
-			CORSOrigins: getEnv("CORS_ALLOWED_ORIGINS", "*"),
-			CORSMethods: getEnv("CORS_ALLOWED_METHODS", "GET,POST,PUT,DELETE,OPTIONS"),
-			CORSHeaders: getEnv("CORS_ALLOWED_HEADERS", "Content-Type,Authorization"),
+			JWTSecret:    getEnv("JWT_SECRET", ""),
+			AdminAPIKey:  getEnv("ADMIN_API_KEY", ""),
+			MetricsToken: getEnv("METRICS_TOKEN", ""),
+			CORSOrigins:  getEnv("CORS_ALLOWED_ORIGINS", "*"),
+			CORSMethods:  getEnv("CORS_ALLOWED_METHODS", "GET,POST,PUT,DELETE,OPTIONS"),
+			CORSHeaders:  getEnv("CORS_ALLOWED_HEADERS", "Content-Type,Authorization"),
+
+			TrustedProxies: getEnvAsList("TRUSTED_PROXIES"),
 		},
 		Logging: LoggingConfig{
 			Level:  getEnv("LOG_LEVEL", "info"),
@@ -110,6 +168,18 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("database configuration missing: set DATABASE_URL or DB_HOST")
 	}
 
+	if c.API.LogRetentionMode != "drop" && c.API.LogRetentionMode != "detach" {
+		return fmt.Errorf("LOG_RETENTION_MODE must be drop or detach")
+	}
+
+	for _, proxy := range c.Security.TrustedProxies {
+		if net.ParseIP(proxy) == nil {
+			if _, _, err := net.ParseCIDR(proxy); err != nil {
+				return fmt.Errorf("TRUSTED_PROXIES entry %q is not an IP address or CIDR", proxy)
+			}
+		}
+	}
+
 	// Security keys required in production
 	if c.Server.Environment == "production" {
 		if c.Security.JWTSecret == "" {
@@ -186,4 +256,30 @@ func getEnvAsDuration(key string, defaultValue time.Duration) time.Duration {
 	}
 
 	return value
-}
\ No newline at end of file
+}
+
+func getEnvAsBool(key string, defaultValue bool) bool {
+	valueStr := os.Getenv(key)
+	if valueStr == "" {
+		return defaultValue
+	}
+
+	value, err := strconv.ParseBool(valueStr)
+	if err != nil {
+		return defaultValue
+	}
+
+	return value
+}
+
+// getEnvAsList splits a comma-separated variable, skipping empty entries;
+// nil when unset
+func getEnvAsList(key string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
@@ -0,0 +1,14 @@
+-- 006_formula_strategy.down.sql
+-- Revert to the original strategy types
+
+DELETE FROM pricing_rules WHERE strategy_type = 'formula';
+
+ALTER TABLE pricing_rules
+DROP CONSTRAINT IF EXISTS chk_strategy_type;
+
+ALTER TABLE pricing_rules
+ADD CONSTRAINT chk_strategy_type CHECK (
+    strategy_type IN ('cost_plus', 'geographic', 'time_based', 'rule_based')
+);
+
+COMMENT ON COLUMN pricing_rules.strategy_type IS 'Pricing strategy: cost_plus, geographic, time_based, rule_based';
@@ -0,0 +1,12 @@
+-- 006_formula_strategy.up.sql
+-- Allow the formula strategy type on pricing rules
+
+ALTER TABLE pricing_rules
+DROP CONSTRAINT IF EXISTS chk_strategy_type;
+
+ALTER TABLE pricing_rules
+ADD CONSTRAINT chk_strategy_type CHECK (
+    strategy_type IN ('cost_plus', 'geographic', 'time_based', 'rule_based', 'formula')
+);
+
+COMMENT ON COLUMN pricing_rules.strategy_type IS 'Pricing strategy: cost_plus, geographic, time_based, rule_based, formula';
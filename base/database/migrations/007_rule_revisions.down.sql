@@ -0,0 +1,11 @@
+-- 007_rule_revisions.down.sql
+-- Drop pricing rule revision history
+
+DROP INDEX IF EXISTS idx_calc_logs_rule_revision;
+ALTER TABLE calculation_logs DROP COLUMN IF EXISTS rule_revision_id;
+
+ALTER TABLE pricing_rules DROP COLUMN IF EXISTS current_revision;
+
+DROP TRIGGER IF EXISTS pricing_rule_revisions_immutable ON pricing_rule_revisions;
+DROP FUNCTION IF EXISTS prevent_rule_revision_update();
+DROP TABLE IF EXISTS pricing_rule_revisions;
@@ -0,0 +1,54 @@
+-- 007_rule_revisions.up.sql
+-- Store every pricing rule change as an immutable revision
+
+CREATE TABLE pricing_rule_revisions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    rule_id UUID NOT NULL REFERENCES pricing_rules(id) ON DELETE CASCADE,
+    revision INTEGER NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    strategy_type VARCHAR(50) NOT NULL,
+    config JSONB NOT NULL,
+    is_active BOOLEAN NOT NULL,
+    author_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    change_note TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT chk_revision_positive CHECK (revision > 0),
+    UNIQUE(rule_id, revision)
+);
+
+CREATE INDEX idx_rule_revisions_rule ON pricing_rule_revisions(rule_id, revision DESC);
+
+-- Revisions are append-only
+CREATE OR REPLACE FUNCTION prevent_rule_revision_update()
+RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'pricing rule revisions are immutable';
+END;
+$$ language 'plpgsql';
+
+CREATE TRIGGER pricing_rule_revisions_immutable BEFORE UPDATE ON pricing_rule_revisions
+    FOR EACH ROW EXECUTE FUNCTION prevent_rule_revision_update();
+
+-- Track the revision a rule currently serves
+ALTER TABLE pricing_rules ADD COLUMN current_revision INTEGER NOT NULL DEFAULT 1;
+
+-- Existing rules start at revision 1
+INSERT INTO pricing_rule_revisions (
+    rule_id, revision, name, description, strategy_type, config, is_active, author_id, change_note, created_at
+)
+SELECT id, 1, name, description, strategy_type, config, COALESCE(is_active, true), user_id, 'Initial revision', updated_at
+FROM pricing_rules;
+
+-- Calculations reference the exact revision used
+ALTER TABLE calculation_logs
+ADD COLUMN rule_revision_id UUID REFERENCES pricing_rule_revisions(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_calc_logs_rule_revision ON calculation_logs(rule_revision_id);
+
+-- Comments
+COMMENT ON TABLE pricing_rule_revisions IS 'Immutable history of pricing rule changes';
+COMMENT ON COLUMN pricing_rule_revisions.author_id IS 'User who made the change';
+COMMENT ON COLUMN pricing_rules.current_revision IS 'Revision number of the live rule';
+COMMENT ON COLUMN calculation_logs.rule_revision_id IS 'Rule revision used for the calculation';
@@ -0,0 +1,12 @@
+-- 008_rule_schedules.down.sql
+-- Drop rule and product assignment schedules
+
+DROP TABLE IF EXISTS product_rule_assignments;
+
+ALTER TABLE pricing_rule_revisions DROP COLUMN IF EXISTS effective_to;
+ALTER TABLE pricing_rule_revisions DROP COLUMN IF EXISTS effective_from;
+
+DROP INDEX IF EXISTS idx_pricing_rules_effective;
+ALTER TABLE pricing_rules DROP CONSTRAINT IF EXISTS chk_rule_effective_window;
+ALTER TABLE pricing_rules DROP COLUMN IF EXISTS effective_to;
+ALTER TABLE pricing_rules DROP COLUMN IF EXISTS effective_from;
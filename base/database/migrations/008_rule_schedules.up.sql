@@ -0,0 +1,37 @@
+-- 008_rule_schedules.up.sql
+-- Effective windows for pricing rules and product rule assignments
+
+-- Rules apply from effective_from (inclusive) until effective_to (exclusive); NULL is unbounded
+ALTER TABLE pricing_rules ADD COLUMN effective_from TIMESTAMP;
+ALTER TABLE pricing_rules ADD COLUMN effective_to TIMESTAMP;
+ALTER TABLE pricing_rules ADD CONSTRAINT chk_rule_effective_window
+    CHECK (effective_from IS NULL OR effective_to IS NULL OR effective_from < effective_to);
+
+CREATE INDEX idx_pricing_rules_effective ON pricing_rules(user_id, effective_from, effective_to);
+
+-- Revisions capture schedule changes too
+ALTER TABLE pricing_rule_revisions ADD COLUMN effective_from TIMESTAMP;
+ALTER TABLE pricing_rule_revisions ADD COLUMN effective_to TIMESTAMP;
+
+-- Scheduled default rules for products
+CREATE TABLE product_rule_assignments (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    product_id UUID NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    rule_id UUID NOT NULL REFERENCES pricing_rules(id) ON DELETE CASCADE,
+    effective_from TIMESTAMP,
+    effective_to TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT chk_assignment_effective_window
+        CHECK (effective_from IS NULL OR effective_to IS NULL OR effective_from < effective_to)
+);
+
+CREATE INDEX idx_product_rule_assignments_product ON product_rule_assignments(product_id, effective_from);
+CREATE INDEX idx_product_rule_assignments_rule ON product_rule_assignments(rule_id);
+
+-- Comments
+COMMENT ON COLUMN pricing_rules.effective_from IS 'Start of the window the rule applies in (inclusive, NULL = always)';
+COMMENT ON COLUMN pricing_rules.effective_to IS 'End of the window the rule applies in (exclusive, NULL = open-ended)';
+COMMENT ON TABLE product_rule_assignments IS 'Scheduled default pricing rules; windows for a product never overlap';
+COMMENT ON COLUMN product_rule_assignments.effective_from IS 'Start of the assignment (inclusive, NULL = always)';
+COMMENT ON COLUMN product_rule_assignments.effective_to IS 'End of the assignment (exclusive, NULL = open-ended)';
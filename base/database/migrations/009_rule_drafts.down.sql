@@ -0,0 +1,6 @@
+-- 009_rule_drafts.down.sql
+-- Drop the draft/publish workflow
+
+DROP TABLE IF EXISTS pricing_rule_drafts;
+ALTER TABLE pricing_rules DROP COLUMN IF EXISTS published_at;
+ALTER TABLE users DROP COLUMN IF EXISTS require_rule_approval;
@@ -0,0 +1,45 @@
+-- 009_rule_drafts.up.sql
+-- Draft/publish workflow for pricing rules
+
+-- Account policy: publishing needs approval from a different API key holder
+ALTER TABLE users ADD COLUMN require_rule_approval BOOLEAN NOT NULL DEFAULT false;
+
+-- pricing_rules holds the published version; unpublished rules have no revisions yet
+ALTER TABLE pricing_rules ADD COLUMN published_at TIMESTAMP;
+UPDATE pricing_rules SET published_at = updated_at;
+
+-- Pending edits, one per rule, kept after publishing for the approval trail
+CREATE TABLE pricing_rule_drafts (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    rule_id UUID NOT NULL REFERENCES pricing_rules(id) ON DELETE CASCADE,
+    base_revision INTEGER NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    strategy_type VARCHAR(50) NOT NULL,
+    config JSONB NOT NULL,
+    is_active BOOLEAN NOT NULL,
+    effective_from TIMESTAMP,
+    effective_to TIMESTAMP,
+    author_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    author_key_id UUID REFERENCES api_keys(id) ON DELETE SET NULL,
+    change_note TEXT,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    approved_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    approved_by_key_id UUID REFERENCES api_keys(id) ON DELETE SET NULL,
+    approved_at TIMESTAMP,
+    published_revision INTEGER,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT chk_draft_status CHECK (status IN ('pending', 'published', 'discarded'))
+);
+
+CREATE UNIQUE INDEX idx_rule_drafts_pending ON pricing_rule_drafts(rule_id) WHERE status = 'pending';
+CREATE INDEX idx_rule_drafts_status ON pricing_rule_drafts(status, updated_at DESC);
+
+-- Comments
+COMMENT ON COLUMN users.require_rule_approval IS 'Rule drafts must be approved by a different API key than their author';
+COMMENT ON COLUMN pricing_rules.published_at IS 'When the rule was first published; NULL rules are never used for calculations';
+COMMENT ON TABLE pricing_rule_drafts IS 'Unpublished pricing rule edits and their approvals';
+COMMENT ON COLUMN pricing_rule_drafts.base_revision IS 'Published revision the draft was edited from (0 for new rules)';
+COMMENT ON COLUMN pricing_rule_drafts.published_revision IS 'Revision created when the draft was published';
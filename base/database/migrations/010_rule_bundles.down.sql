@@ -0,0 +1,5 @@
+-- 010_rule_bundles.down.sql
+-- Drop rule external keys
+
+DROP INDEX IF EXISTS idx_pricing_rules_external_key;
+ALTER TABLE pricing_rules DROP COLUMN IF EXISTS external_key;
@@ -0,0 +1,10 @@
+-- 010_rule_bundles.up.sql
+-- Stable external keys for matching rules across accounts in bundle imports
+
+ALTER TABLE pricing_rules ADD COLUMN external_key VARCHAR(255);
+
+CREATE UNIQUE INDEX idx_pricing_rules_external_key ON pricing_rules(user_id, external_key)
+    WHERE external_key IS NOT NULL AND deleted_at IS NULL;
+
+-- Comments
+COMMENT ON COLUMN pricing_rules.external_key IS 'Caller-chosen identifier used to match rules when importing bundles';
@@ -0,0 +1,7 @@
+-- 011_product_categories.down.sql
+-- Drop the product category tree
+
+ALTER TABLE users DROP COLUMN IF EXISTS default_rule_id;
+ALTER TABLE products DROP COLUMN IF EXISTS category_id;
+DROP TRIGGER IF EXISTS update_product_categories_updated_at ON product_categories;
+DROP TABLE IF EXISTS product_categories;
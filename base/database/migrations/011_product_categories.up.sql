@@ -0,0 +1,41 @@
+-- 011_product_categories.up.sql
+-- Category tree for products, with inherited default rules and attributes
+
+CREATE TABLE product_categories (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    parent_id UUID REFERENCES product_categories(id) ON DELETE RESTRICT,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    default_rule_id UUID REFERENCES pricing_rules(id) ON DELETE SET NULL,
+    attributes JSONB DEFAULT '{}'::jsonb,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT chk_category_parent CHECK (parent_id IS NULL OR parent_id <> id)
+);
+
+-- Sibling names are unique; top-level categories share the nil parent
+CREATE UNIQUE INDEX idx_product_categories_name ON product_categories(
+    user_id, COALESCE(parent_id, '00000000-0000-0000-0000-000000000000'::uuid), name
+);
+CREATE INDEX idx_product_categories_parent ON product_categories(parent_id);
+CREATE INDEX idx_product_categories_rule ON product_categories(default_rule_id);
+
+-- Updated_at trigger
+CREATE TRIGGER update_product_categories_updated_at BEFORE UPDATE ON product_categories
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- Products belong to at most one category
+ALTER TABLE products ADD COLUMN category_id UUID REFERENCES product_categories(id) ON DELETE SET NULL;
+CREATE INDEX idx_products_category ON products(category_id);
+
+-- Account-wide fallback rule
+ALTER TABLE users ADD COLUMN default_rule_id UUID REFERENCES pricing_rules(id) ON DELETE SET NULL;
+
+-- Comments
+COMMENT ON TABLE product_categories IS 'Per-account product category tree';
+COMMENT ON COLUMN product_categories.default_rule_id IS 'Default pricing rule for products in this category and its subcategories';
+COMMENT ON COLUMN product_categories.attributes IS 'Default product attributes; nearer categories and product metadata override them';
+COMMENT ON COLUMN products.category_id IS 'Category whose default rule applies when the product sets none';
+COMMENT ON COLUMN users.default_rule_id IS 'Pricing rule for products whose SKU and categories set none';
@@ -0,0 +1,5 @@
+-- 012_product_costs.down.sql
+-- Drop product cost history
+
+DROP TABLE IF EXISTS product_costs;
+COMMENT ON COLUMN products.base_cost IS 'Base cost for cost-plus calculations';
@@ -0,0 +1,29 @@
+-- 012_product_costs.up.sql
+-- Effective-dated product cost history
+
+CREATE TABLE product_costs (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    product_id UUID NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    cost DECIMAL(10,2) NOT NULL,
+    effective_from TIMESTAMP NOT NULL,
+    source VARCHAR(20) NOT NULL DEFAULT 'manual',
+    note TEXT,
+    created_by UUID REFERENCES api_keys(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT chk_product_cost CHECK (cost >= 0),
+    CONSTRAINT chk_product_cost_source CHECK (source IN ('manual', 'supplier', 'import', 'adjustment')),
+    CONSTRAINT uq_product_costs_effective UNIQUE (product_id, effective_from)
+);
+
+-- Existing base costs start each product's history
+INSERT INTO product_costs (product_id, cost, effective_from, source, created_at)
+SELECT id, base_cost, created_at, 'manual', created_at
+FROM products
+WHERE base_cost IS NOT NULL;
+
+-- Comments
+COMMENT ON TABLE product_costs IS 'Unit cost history; each entry applies until the next one for the product';
+COMMENT ON COLUMN product_costs.effective_from IS 'Start of the cost (inclusive); calculations use the latest entry at or before requested_at';
+COMMENT ON COLUMN product_costs.source IS 'Where the cost came from: manual, supplier, import or adjustment';
+COMMENT ON COLUMN products.base_cost IS 'Cost in effect when the product or its cost history was last written';
@@ -0,0 +1,6 @@
+-- 013_price_books.down.sql
+-- Drop price books
+
+DROP TABLE IF EXISTS price_book_entries;
+DROP TRIGGER IF EXISTS update_price_books_updated_at ON price_books;
+DROP TABLE IF EXISTS price_books;
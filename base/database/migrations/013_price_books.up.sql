@@ -0,0 +1,68 @@
+-- 013_price_books.up.sql
+-- Materialized prices of every product per location, currency and channel
+
+CREATE TABLE price_books (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    dimensions JSONB NOT NULL DEFAULT '{}'::jsonb,
+    quantity INTEGER NOT NULL DEFAULT 1,
+    context JSONB NOT NULL DEFAULT '{}'::jsonb,
+    auto_regenerate BOOLEAN NOT NULL DEFAULT true,
+    definition_version INTEGER NOT NULL DEFAULT 1,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    generation INTEGER NOT NULL DEFAULT 0,
+    generated_at TIMESTAMP,
+    started_at TIMESTAMP,
+    rule_revisions JSONB NOT NULL DEFAULT '[]'::jsonb,
+    entry_count INTEGER NOT NULL DEFAULT 0,
+    error_count INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    dependency_hash VARCHAR(32),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT uq_price_books_name UNIQUE (user_id, name),
+    CONSTRAINT chk_price_book_quantity CHECK (quantity > 0),
+    CONSTRAINT chk_price_book_status CHECK (status IN ('pending', 'generating', 'ready', 'failed'))
+);
+
+CREATE INDEX idx_price_books_auto ON price_books(auto_regenerate) WHERE auto_regenerate = true;
+
+-- Updated_at trigger
+CREATE TRIGGER update_price_books_updated_at BEFORE UPDATE ON price_books
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- One row per product and cell; blank dimension values price without that input
+CREATE TABLE price_book_entries (
+    price_book_id UUID NOT NULL REFERENCES price_books(id) ON DELETE CASCADE,
+    product_id UUID NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    sku VARCHAR(100) NOT NULL,
+    location VARCHAR(100) NOT NULL DEFAULT '',
+    currency VARCHAR(10) NOT NULL DEFAULT '',
+    channel VARCHAR(100) NOT NULL DEFAULT '',
+    final_price DECIMAL(12,2),
+    price_currency VARCHAR(10),
+    base_cost DECIMAL(10,2) NOT NULL DEFAULT 0,
+    strategy_type VARCHAR(50),
+    rule_id UUID,
+    rule_revision_id UUID,
+    rule_revision INTEGER,
+    rule_source VARCHAR(20),
+    error TEXT,
+    generated_at TIMESTAMP NOT NULL,
+
+    PRIMARY KEY (price_book_id, sku, location, currency, channel)
+);
+
+CREATE INDEX idx_price_book_entries_product ON price_book_entries(price_book_id, product_id);
+
+-- Comments
+COMMENT ON TABLE price_books IS 'Named snapshots of product prices across locations, currencies and channels';
+COMMENT ON COLUMN price_books.definition_version IS 'Incremented when the dimensions or inputs change; a generation of an older version leaves the book pending';
+COMMENT ON COLUMN price_books.generated_at IS 'Rules and products as of this time are reflected in the entries';
+COMMENT ON COLUMN price_books.started_at IS 'Start of the running or last generation; stale claims can be taken over';
+COMMENT ON COLUMN price_books.rule_revisions IS 'Rule revisions the entries were priced with and how many entries each priced';
+COMMENT ON COLUMN price_books.dependency_hash IS 'Fingerprint of rules, schedules, categories and the account default at generated_at';
+COMMENT ON TABLE price_book_entries IS 'Price of one product at one location, currency and channel of a price book';
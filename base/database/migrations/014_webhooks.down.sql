@@ -0,0 +1,9 @@
+-- 014_webhooks.down.sql
+-- Drop webhooks
+
+DROP TABLE IF EXISTS webhook_delivery_attempts;
+DROP TRIGGER IF EXISTS update_webhook_deliveries_updated_at ON webhook_deliveries;
+DROP TABLE IF EXISTS webhook_deliveries;
+DROP TABLE IF EXISTS webhook_events;
+DROP TRIGGER IF EXISTS update_webhook_endpoints_updated_at ON webhook_endpoints;
+DROP TABLE IF EXISTS webhook_endpoints;
@@ -0,0 +1,4 @@
+-- 015_idempotency_keys.down.sql
+-- Drop idempotency keys
+
+DROP TABLE IF EXISTS idempotency_keys;
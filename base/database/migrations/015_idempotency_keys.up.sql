@@ -0,0 +1,21 @@
+-- 015_idempotency_keys.up.sql
+-- Responses stored per user and Idempotency-Key so retried requests are replayed
+
+CREATE TABLE idempotency_keys (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    idempotency_key VARCHAR(255) NOT NULL,
+    fingerprint VARCHAR(64) NOT NULL,
+    response_status INTEGER,
+    response_headers JSONB NOT NULL DEFAULT '{}'::jsonb,
+    response_body BYTEA,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    completed_at TIMESTAMP,
+    expires_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (user_id, idempotency_key)
+);
+
+CREATE INDEX idx_idempotency_keys_expires_at ON idempotency_keys(expires_at);
+
+COMMENT ON TABLE idempotency_keys IS 'Requests made with an Idempotency-Key and the responses replayed to their retries';
+COMMENT ON COLUMN idempotency_keys.fingerprint IS 'SHA-256 of the method, path and body; a reused key with a different fingerprint is rejected';
+COMMENT ON COLUMN idempotency_keys.completed_at IS 'NULL while the first request is still in flight';
@@ -0,0 +1,28 @@
+-- 016_calculation_audit.down.sql
+-- Remove calculation audit columns
+
+DROP VIEW IF EXISTS calculation_analytics;
+
+DROP INDEX IF EXISTS idx_calc_logs_request_id;
+
+ALTER TABLE calculation_logs
+    DROP COLUMN IF EXISTS engine_version,
+    DROP COLUMN IF EXISTS client_ip,
+    DROP COLUMN IF EXISTS request_id,
+    DROP COLUMN IF EXISTS rule_revision;
+
+ALTER TABLE calculation_logs ALTER COLUMN execution_time_ms TYPE INTEGER USING ROUND(execution_time_ms);
+
+CREATE VIEW calculation_analytics AS
+SELECT 
+    user_id,
+    strategy_type,
+    COUNT(*) as total_calculations,
+    AVG(execution_time_ms) as avg_execution_time_ms,
+    MIN((output_data->>'final_price')::numeric) as min_price,
+    MAX((output_data->>'final_price')::numeric) as max_price,
+    AVG((output_data->>'final_price')::numeric) as avg_price,
+    DATE_TRUNC('day', created_at) as calculation_date
+FROM calculation_logs
+WHERE created_at >= CURRENT_TIMESTAMP - INTERVAL '30 days'
+GROUP BY user_id, strategy_type, DATE_TRUNC('day', created_at);
@@ -0,0 +1,38 @@
+-- 016_calculation_audit.up.sql
+-- Record the request, client, rule revision and engine behind every calculation
+
+-- The analytics view depends on execution_time_ms, so it is rebuilt around the type change
+DROP VIEW IF EXISTS calculation_analytics;
+
+-- Calculations usually take well under a millisecond
+ALTER TABLE calculation_logs ALTER COLUMN execution_time_ms TYPE NUMERIC(12, 3);
+
+ALTER TABLE calculation_logs
+    ADD COLUMN rule_revision INTEGER,
+    ADD COLUMN request_id VARCHAR(100),
+    ADD COLUMN client_ip VARCHAR(45),
+    ADD COLUMN engine_version VARCHAR(20);
+
+CREATE INDEX idx_calc_logs_request_id ON calculation_logs(request_id);
+
+CREATE VIEW calculation_analytics AS
+SELECT 
+    user_id,
+    strategy_type,
+    COUNT(*) as total_calculations,
+    AVG(execution_time_ms) as avg_execution_time_ms,
+    MIN((output_data->>'final_price')::numeric) as min_price,
+    MAX((output_data->>'final_price')::numeric) as max_price,
+    AVG((output_data->>'final_price')::numeric) as avg_price,
+    DATE_TRUNC('day', created_at) as calculation_date
+FROM calculation_logs
+WHERE created_at >= CURRENT_TIMESTAMP - INTERVAL '30 days'
+GROUP BY user_id, strategy_type, DATE_TRUNC('day', created_at);
+
+-- Comments
+COMMENT ON VIEW calculation_analytics IS 'Last 30 days of calculation statistics by user and strategy';
+COMMENT ON COLUMN calculation_logs.execution_time_ms IS 'Pricing engine execution time in milliseconds';
+COMMENT ON COLUMN calculation_logs.rule_revision IS 'Revision number of the rule used for the calculation';
+COMMENT ON COLUMN calculation_logs.request_id IS 'X-Request-ID of the request that made the calculation';
+COMMENT ON COLUMN calculation_logs.client_ip IS 'IP address of the client that made the calculation';
+COMMENT ON COLUMN calculation_logs.engine_version IS 'Pricing engine version that made the calculation';
@@ -0,0 +1,72 @@
+-- 017_partition_calculation_logs.down.sql
+-- Move calculation logs back to a single table
+
+ALTER TABLE users DROP CONSTRAINT IF EXISTS chk_log_retention_days;
+ALTER TABLE users DROP COLUMN IF EXISTS log_retention_days;
+
+DROP VIEW IF EXISTS calculation_analytics;
+
+ALTER TABLE calculation_logs RENAME TO calculation_logs_partitioned;
+ALTER INDEX calculation_logs_pkey RENAME TO calculation_logs_partitioned_pkey;
+
+DROP INDEX IF EXISTS idx_calc_logs_user_created;
+DROP INDEX IF EXISTS idx_calc_logs_api_key_created;
+DROP INDEX IF EXISTS idx_calc_logs_rule;
+DROP INDEX IF EXISTS idx_calc_logs_rule_revision;
+DROP INDEX IF EXISTS idx_calc_logs_strategy;
+DROP INDEX IF EXISTS idx_calc_logs_created;
+DROP INDEX IF EXISTS idx_calc_logs_request_id;
+DROP INDEX IF EXISTS idx_calc_logs_input;
+DROP INDEX IF EXISTS idx_calc_logs_output;
+
+CREATE TABLE calculation_logs (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    api_key_id UUID REFERENCES api_keys(id) ON DELETE SET NULL,
+    rule_id UUID REFERENCES pricing_rules(id) ON DELETE SET NULL,
+    rule_revision_id UUID REFERENCES pricing_rule_revisions(id) ON DELETE SET NULL,
+    rule_revision INTEGER,
+    strategy_type VARCHAR(50) NOT NULL,
+    input_data JSONB NOT NULL,
+    output_data JSONB NOT NULL,
+    execution_time_ms NUMERIC(12, 3),
+    request_id VARCHAR(100),
+    client_ip VARCHAR(45),
+    engine_version VARCHAR(20),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT chk_execution_time CHECK (execution_time_ms >= 0)
+);
+
+INSERT INTO calculation_logs
+SELECT
+    id, user_id, api_key_id, rule_id, rule_revision_id, rule_revision, strategy_type,
+    input_data, output_data, execution_time_ms, request_id, client_ip, engine_version, created_at
+FROM calculation_logs_partitioned;
+
+DROP TABLE calculation_logs_partitioned;
+DROP FUNCTION IF EXISTS create_calculation_log_partition(DATE);
+
+CREATE INDEX idx_calc_logs_user_created ON calculation_logs(user_id, created_at DESC);
+CREATE INDEX idx_calc_logs_api_key_created ON calculation_logs(api_key_id, created_at DESC);
+CREATE INDEX idx_calc_logs_rule ON calculation_logs(rule_id);
+CREATE INDEX idx_calc_logs_rule_revision ON calculation_logs(rule_revision_id);
+CREATE INDEX idx_calc_logs_strategy ON calculation_logs(strategy_type);
+CREATE INDEX idx_calc_logs_created ON calculation_logs(created_at DESC);
+CREATE INDEX idx_calc_logs_request_id ON calculation_logs(request_id);
+CREATE INDEX idx_calc_logs_input ON calculation_logs USING GIN (input_data);
+CREATE INDEX idx_calc_logs_output ON calculation_logs USING GIN (output_data);
+
+CREATE VIEW calculation_analytics AS
+SELECT 
+    user_id,
+    strategy_type,
+    COUNT(*) as total_calculations,
+    AVG(execution_time_ms) as avg_execution_time_ms,
+    MIN((output_data->>'final_price')::numeric) as min_price,
+    MAX((output_data->>'final_price')::numeric) as max_price,
+    AVG((output_data->>'final_price')::numeric) as avg_price,
+    DATE_TRUNC('day', created_at) as calculation_date
+FROM calculation_logs
+WHERE created_at >= CURRENT_TIMESTAMP - INTERVAL '30 days'
+GROUP BY user_id, strategy_type, DATE_TRUNC('day', created_at);
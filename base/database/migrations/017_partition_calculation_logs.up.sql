@@ -0,0 +1,122 @@
+-- 017_partition_calculation_logs.up.sql
+-- Partition calculation_logs by month so expired months can be dropped whole
+
+DROP VIEW IF EXISTS calculation_analytics;
+
+ALTER TABLE calculation_logs RENAME TO calculation_logs_unpartitioned;
+ALTER INDEX calculation_logs_pkey RENAME TO calculation_logs_unpartitioned_pkey;
+
+-- The partition key has to be part of the primary key
+CREATE TABLE calculation_logs (
+    id UUID NOT NULL DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    api_key_id UUID REFERENCES api_keys(id) ON DELETE SET NULL,
+    rule_id UUID REFERENCES pricing_rules(id) ON DELETE SET NULL,
+    rule_revision_id UUID REFERENCES pricing_rule_revisions(id) ON DELETE SET NULL,
+    rule_revision INTEGER,
+    strategy_type VARCHAR(50) NOT NULL,
+    input_data JSONB NOT NULL,
+    output_data JSONB NOT NULL,
+    execution_time_ms NUMERIC(12, 3),
+    request_id VARCHAR(100),
+    client_ip VARCHAR(45),
+    engine_version VARCHAR(20),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    PRIMARY KEY (id, created_at),
+    CONSTRAINT chk_execution_time CHECK (execution_time_ms >= 0)
+) PARTITION BY RANGE (created_at);
+
+-- Creates the partition holding a month, named calculation_logs_yYYYYmMM.
+-- Returns false if it already exists.
+CREATE OR REPLACE FUNCTION create_calculation_log_partition(month DATE)
+RETURNS BOOLEAN AS $$
+DECLARE
+    start_date DATE := DATE_TRUNC('month', month)::DATE;
+    partition_name TEXT := 'calculation_logs_' || TO_CHAR(start_date, '"y"YYYY"m"MM');
+BEGIN
+    IF TO_REGCLASS(partition_name) IS NOT NULL THEN
+        RETURN false;
+    END IF;
+
+    EXECUTE FORMAT(
+        'CREATE TABLE %I PARTITION OF calculation_logs FOR VALUES FROM (%L) TO (%L)',
+        partition_name,
+        start_date,
+        (start_date + INTERVAL '1 month')::DATE
+    );
+    RETURN true;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Partitions for every month with logs, through three months ahead
+DO $$
+DECLARE
+    partition_month DATE;
+BEGIN
+    partition_month := DATE_TRUNC('month', COALESCE(
+        (SELECT MIN(created_at) FROM calculation_logs_unpartitioned),
+        CURRENT_TIMESTAMP
+    ))::DATE;
+
+    WHILE partition_month <= DATE_TRUNC('month', CURRENT_TIMESTAMP + INTERVAL '3 months') LOOP
+        PERFORM create_calculation_log_partition(partition_month);
+        partition_month := (partition_month + INTERVAL '1 month')::DATE;
+    END LOOP;
+END;
+$$;
+
+INSERT INTO calculation_logs (
+    id, user_id, api_key_id, rule_id, rule_revision_id, rule_revision, strategy_type,
+    input_data, output_data, execution_time_ms, request_id, client_ip, engine_version, created_at
+)
+SELECT
+    id, user_id, api_key_id, rule_id, rule_revision_id, rule_revision, strategy_type,
+    input_data, output_data, execution_time_ms, request_id, client_ip, engine_version,
+    COALESCE(created_at, CURRENT_TIMESTAMP)
+FROM calculation_logs_unpartitioned;
+
+DROP TABLE calculation_logs_unpartitioned;
+
+-- Indexes for calculation_logs, created on every partition
+CREATE INDEX idx_calc_logs_user_created ON calculation_logs(user_id, created_at DESC);
+CREATE INDEX idx_calc_logs_api_key_created ON calculation_logs(api_key_id, created_at DESC);
+CREATE INDEX idx_calc_logs_rule ON calculation_logs(rule_id);
+CREATE INDEX idx_calc_logs_rule_revision ON calculation_logs(rule_revision_id);
+CREATE INDEX idx_calc_logs_strategy ON calculation_logs(strategy_type);
+CREATE INDEX idx_calc_logs_created ON calculation_logs(created_at DESC);
+CREATE INDEX idx_calc_logs_request_id ON calculation_logs(request_id);
+CREATE INDEX idx_calc_logs_input ON calculation_logs USING GIN (input_data);
+CREATE INDEX idx_calc_logs_output ON calculation_logs USING GIN (output_data);
+
+CREATE VIEW calculation_analytics AS
+SELECT 
+    user_id,
+    strategy_type,
+    COUNT(*) as total_calculations,
+    AVG(execution_time_ms) as avg_execution_time_ms,
+    MIN((output_data->>'final_price')::numeric) as min_price,
+    MAX((output_data->>'final_price')::numeric) as max_price,
+    AVG((output_data->>'final_price')::numeric) as avg_price,
+    DATE_TRUNC('day', created_at) as calculation_date
+FROM calculation_logs
+WHERE created_at >= CURRENT_TIMESTAMP - INTERVAL '30 days'
+GROUP BY user_id, strategy_type, DATE_TRUNC('day', created_at);
+
+-- Account policy: how long the account's calculation logs are kept
+ALTER TABLE users ADD COLUMN log_retention_days INTEGER;
+ALTER TABLE users ADD CONSTRAINT chk_log_retention_days CHECK (log_retention_days > 0);
+
+-- Comments
+COMMENT ON TABLE calculation_logs IS 'Audit trail of all price calculations, partitioned by month';
+COMMENT ON COLUMN calculation_logs.input_data IS 'Input parameters sent to pricing engine';
+COMMENT ON COLUMN calculation_logs.output_data IS 'Calculated price with full breakdown';
+COMMENT ON COLUMN calculation_logs.rule_revision_id IS 'Rule revision used for the calculation';
+COMMENT ON COLUMN calculation_logs.execution_time_ms IS 'Pricing engine execution time in milliseconds';
+COMMENT ON COLUMN calculation_logs.rule_revision IS 'Revision number of the rule used for the calculation';
+COMMENT ON COLUMN calculation_logs.request_id IS 'X-Request-ID of the request that made the calculation';
+COMMENT ON COLUMN calculation_logs.client_ip IS 'IP address of the client that made the calculation';
+COMMENT ON COLUMN calculation_logs.engine_version IS 'Pricing engine version that made the calculation';
+COMMENT ON VIEW calculation_analytics IS 'Last 30 days of calculation statistics by user and strategy';
+COMMENT ON FUNCTION create_calculation_log_partition(DATE) IS 'Creates the calculation_logs partition for a month';
+COMMENT ON COLUMN users.log_retention_days IS 'Days calculation logs are kept; NULL uses the server default';
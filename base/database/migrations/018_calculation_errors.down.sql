@@ -0,0 +1,21 @@
+-- 018_calculation_errors.down.sql
+-- Remove failed calculations and restore the analytics view
+
+DELETE FROM calculation_logs WHERE error IS NOT NULL;
+ALTER TABLE calculation_logs DROP COLUMN IF EXISTS error;
+
+CREATE VIEW calculation_analytics AS
+SELECT 
+    user_id,
+    strategy_type,
+    COUNT(*) as total_calculations,
+    AVG(execution_time_ms) as avg_execution_time_ms,
+    MIN((output_data->>'final_price')::numeric) as min_price,
+    MAX((output_data->>'final_price')::numeric) as max_price,
+    AVG((output_data->>'final_price')::numeric) as avg_price,
+    DATE_TRUNC('day', created_at) as calculation_date
+FROM calculation_logs
+WHERE created_at >= CURRENT_TIMESTAMP - INTERVAL '30 days'
+GROUP BY user_id, strategy_type, DATE_TRUNC('day', created_at);
+
+COMMENT ON VIEW calculation_analytics IS 'Last 30 days of calculation statistics by user and strategy';
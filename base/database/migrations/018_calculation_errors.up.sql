@@ -0,0 +1,10 @@
+-- 018_calculation_errors.up.sql
+-- Log failed calculations so analytics can report error rates
+
+ALTER TABLE calculation_logs ADD COLUMN error TEXT;
+
+-- Superseded by GET /v1/analytics, which takes any range and grouping
+DROP VIEW IF EXISTS calculation_analytics;
+
+-- Comments
+COMMENT ON COLUMN calculation_logs.error IS 'Why the calculation failed; NULL for successful calculations';
@@ -0,0 +1,9 @@
+-- 019_keyset_pagination.down.sql
+-- Restore the indexes from before keyset pagination
+
+DROP INDEX IF EXISTS idx_calc_logs_user_created;
+CREATE INDEX idx_calc_logs_user_created ON calculation_logs(user_id, created_at DESC);
+
+DROP INDEX IF EXISTS idx_products_user_created;
+DROP INDEX IF EXISTS idx_pricing_rules_user_created;
+DROP INDEX IF EXISTS idx_api_keys_user_created;
@@ -0,0 +1,10 @@
+-- 019_keyset_pagination.up.sql
+-- Serve listings paginated by (created_at, id), newest first, from an index
+
+CREATE INDEX idx_api_keys_user_created ON api_keys(user_id, created_at DESC, id DESC);
+CREATE INDEX idx_pricing_rules_user_created ON pricing_rules(user_id, created_at DESC, id DESC) WHERE deleted_at IS NULL;
+CREATE INDEX idx_products_user_created ON products(user_id, created_at DESC, id DESC);
+
+-- Created on every partition
+DROP INDEX IF EXISTS idx_calc_logs_user_created;
+CREATE INDEX idx_calc_logs_user_created ON calculation_logs(user_id, created_at DESC, id DESC);
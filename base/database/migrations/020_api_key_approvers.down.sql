@@ -0,0 +1,3 @@
+-- 020_api_key_approvers.down.sql
+
+ALTER TABLE api_keys DROP COLUMN IF EXISTS can_approve;
@@ -0,0 +1,5 @@
+-- 020_api_key_approvers.up.sql
+-- Approver keys may publish drafts under the approval policy. Only an
+-- administrator can grant the flag, so minting a key does not confer it.
+
+ALTER TABLE api_keys ADD COLUMN can_approve BOOLEAN NOT NULL DEFAULT false;
@@ -0,0 +1,3 @@
+-- 021_revision_author_keys.down.sql
+
+ALTER TABLE pricing_rule_revisions DROP COLUMN IF EXISTS author_key_id;
@@ -0,0 +1,15 @@
+-- 021_revision_author_keys.up.sql
+-- Record the API key that authored each rule revision, alongside its user
+
+ALTER TABLE pricing_rule_revisions ADD COLUMN author_key_id UUID REFERENCES api_keys(id) ON DELETE SET NULL;
+
+-- Revisions published from drafts were authored by the draft's key. Revisions
+-- are immutable (007), so the trigger is lifted for the backfill only.
+ALTER TABLE pricing_rule_revisions DISABLE TRIGGER pricing_rule_revisions_immutable;
+
+UPDATE pricing_rule_revisions rv
+SET author_key_id = d.author_key_id
+FROM pricing_rule_drafts d
+WHERE d.rule_id = rv.rule_id AND d.published_revision = rv.revision AND d.status = 'published';
+
+ALTER TABLE pricing_rule_revisions ENABLE TRIGGER pricing_rule_revisions_immutable;
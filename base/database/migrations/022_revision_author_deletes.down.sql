@@ -0,0 +1,11 @@
+-- 022_revision_author_deletes.down.sql
+
+CREATE OR REPLACE FUNCTION prevent_rule_revision_update()
+RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'pricing rule revisions are immutable';
+END;
+$$ language 'plpgsql';
+
+COMMENT ON COLUMN pricing_rule_revisions.author_id IS 'User who made the change';
+COMMENT ON COLUMN pricing_rule_revisions.author_key_id IS NULL;
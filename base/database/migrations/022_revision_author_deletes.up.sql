@@ -0,0 +1,21 @@
+-- 022_revision_author_deletes.up.sql
+-- Let deleting a user or API key clear its authorship of rule revisions.
+-- ON DELETE SET NULL on author_id and author_key_id is applied as an UPDATE,
+-- which the immutability trigger from 007 rejected, so deleting any user or
+-- key that had authored a revision failed.
+
+CREATE OR REPLACE FUNCTION prevent_rule_revision_update()
+RETURNS TRIGGER AS $$
+BEGIN
+    -- Clearing the author references is the only change allowed
+    IF (NEW.author_id IS NULL OR NEW.author_id = OLD.author_id)
+        AND (NEW.author_key_id IS NULL OR NEW.author_key_id = OLD.author_key_id)
+        AND to_jsonb(NEW) - 'author_id' - 'author_key_id' = to_jsonb(OLD) - 'author_id' - 'author_key_id' THEN
+        RETURN NEW;
+    END IF;
+    RAISE EXCEPTION 'pricing rule revisions are immutable';
+END;
+$$ language 'plpgsql';
+
+COMMENT ON COLUMN pricing_rule_revisions.author_id IS 'User who made the change; cleared when the user is deleted';
+COMMENT ON COLUMN pricing_rule_revisions.author_key_id IS 'API key that made the change; cleared when the key is deleted';
@@ -0,0 +1,3 @@
+-- 023_idempotency_reservation_tokens.down.sql
+
+ALTER TABLE idempotency_keys DROP COLUMN IF EXISTS reservation_token;
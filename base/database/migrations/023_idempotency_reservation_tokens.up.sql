@@ -0,0 +1,7 @@
+-- 023_idempotency_reservation_tokens.up.sql
+-- Identify each reservation of an idempotency key, so a request whose key
+-- was taken over as stale cannot complete or release the new reservation
+
+ALTER TABLE idempotency_keys ADD COLUMN reservation_token UUID;
+
+COMMENT ON COLUMN idempotency_keys.reservation_token IS 'Set by each reservation; only its holder completes or releases the key';
@@ -0,0 +1,20 @@
+// Package csvsafe neutralises CSV cells that spreadsheets would run as formulas
+package csvsafe
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Cell prefixes values that spreadsheets would run as formulas with a quote,
+// so they are shown as text. Numbers, including negative ones, are left
+// unchanged.
+func Cell(value string) string {
+	if value == "" || !strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return value
+	}
+	if _, err := strconv.ParseFloat(value, 64); err == nil {
+		return value
+	}
+	return "'" + value
+}
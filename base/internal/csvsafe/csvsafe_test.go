@@ -0,0 +1,27 @@
+package csvsafe
+
+import "testing"
+
+func TestCell(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"", ""},
+		{"WIDGET-1", "WIDGET-1"},
+		{"=HYPERLINK(\"http://x\")", "'=HYPERLINK(\"http://x\")"},
+		{"+1+cmd", "'+1+cmd"},
+		{"-2+3", "'-2+3"},
+		{"@SUM(A1)", "'@SUM(A1)"},
+		{"\tvalue", "'\tvalue"},
+		{"\rvalue", "'\rvalue"},
+		{"-12.5", "-12.5"},
+		{"+3", "+3"},
+	}
+
+	for _, tt := range tests {
+		if got := Cell(tt.value); got != tt.want {
+			t.Errorf("Cell(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Analytics bucket sizes
+const (
+	AnalyticsBucketHour = "hour"
+	AnalyticsBucketDay  = "day"
+	AnalyticsBucketWeek = "week" // weeks start on Monday
+)
+
+// Analytics groupings
+const (
+	AnalyticsGroupStrategy   = "strategy"
+	AnalyticsGroupRule       = "rule"
+	AnalyticsGroupAPIKey     = "api_key"
+	AnalyticsGroupLocation   = "location"
+	AnalyticsGroupProductSKU = "product_sku"
+)
+
+// AnalyticsQuery selects an account's calculations for analytics
+type AnalyticsQuery struct {
+	UserID  uuid.UUID
+	From    time.Time // inclusive
+	To      time.Time // exclusive
+	Bucket  string
+	GroupBy string // empty for a single series
+}
+
+// AnalyticsPoint aggregates the calculations of one bucket and group. Price
+// statistics cover successful calculations and are nil when there are none.
+type AnalyticsPoint struct {
+	BucketStart        time.Time
+	Group              *string // nil when ungrouped or the logs have no value
+	Count              int
+	Errors             int
+	AvgPrice           *float64
+	MinPrice           *float64
+	MaxPrice           *float64
+	P50Price           *float64
+	P95Price           *float64
+	AvgExecutionTimeMs float64
+}
+
+// ErrorRate is the fraction of calculations that failed
+func (p *AnalyticsPoint) ErrorRate() float64 {
+	if p.Count == 0 {
+		return 0
+	}
+	return float64(p.Errors) / float64(p.Count)
+}
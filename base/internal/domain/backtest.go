@@ -0,0 +1,105 @@
+package domain
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// BacktestStatus is the lifecycle state of a backtest job
+type BacktestStatus string
+
+const (
+	BacktestStatusPending   BacktestStatus = "pending"
+	BacktestStatusRunning   BacktestStatus = "running"
+	BacktestStatusCompleted BacktestStatus = "completed"
+	BacktestStatusFailed    BacktestStatus = "failed"
+)
+
+// Done reports whether the job has reached a terminal state
+func (s BacktestStatus) Done() bool {
+	return s == BacktestStatusCompleted || s == BacktestStatusFailed
+}
+
+// Backtest errors
+var (
+	ErrBacktestInvalid  = errors.New("backtest request is invalid")
+	ErrBacktestNotFound = errors.New("backtest not found")
+	ErrBacktestBusy     = errors.New("too many backtests are running")
+)
+
+// BacktestRequest replays historical calculations with a proposed config
+type BacktestRequest struct {
+	UserID       uuid.UUID              `json:"user_id"`
+	RuleID       *uuid.UUID             `json:"rule_id,omitempty"` // Replay logs produced by this rule
+	StrategyType string                 `json:"strategy_type"`     // Strategy of the proposed config
+	Config       map[string]interface{} `json:"config"`
+	From         time.Time              `json:"from"`
+	To           time.Time              `json:"to"`
+}
+
+// BacktestProgress tracks how many logs have been replayed
+type BacktestProgress struct {
+	Processed int `json:"processed"`
+	Total     int `json:"total"`
+}
+
+// BacktestChange is a single replayed calculation whose price changed
+type BacktestChange struct {
+	LogID         uuid.UUID `json:"log_id"`
+	OldPrice      float64   `json:"old_price"`
+	NewPrice      float64   `json:"new_price"`
+	Change        float64   `json:"change"`
+	ChangePercent *float64  `json:"change_percent,omitempty"` // Nil when the old price was zero
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// BacktestError is a replayed calculation that fails with the proposed config
+type BacktestError struct {
+	LogID     uuid.UUID `json:"log_id"`
+	Error     string    `json:"error"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// BacktestBucket counts price changes within a percent range
+type BacktestBucket struct {
+	Label string   `json:"label"`
+	Min   *float64 `json:"min_percent,omitempty"` // Inclusive; nil is unbounded
+	Max   *float64 `json:"max_percent,omitempty"` // Exclusive; nil is unbounded
+	Count int      `json:"count"`
+}
+
+// BacktestReport summarises how a proposed config would have changed prices
+type BacktestReport struct {
+	Replayed  int `json:"replayed"`
+	Unchanged int `json:"unchanged"`
+	Changed   int `json:"changed"`
+	Increased int `json:"increased"`
+	Decreased int `json:"decreased"`
+	NewErrors int `json:"new_errors"`
+	Skipped   int `json:"skipped"` // Logs without a recorded price or usable inputs
+
+	MeanChange        float64 `json:"mean_change"`
+	MeanChangePercent float64 `json:"mean_change_percent"`
+	OldRevenue        float64 `json:"old_revenue"`
+	NewRevenue        float64 `json:"new_revenue"`
+
+	Distribution     []BacktestBucket `json:"distribution"`
+	LargestIncreases []BacktestChange `json:"largest_increases"`
+	LargestDecreases []BacktestChange `json:"largest_decreases"`
+	Errors           []BacktestError  `json:"errors"` // First errors encountered
+}
+
+// BacktestJob is a backtest that may run in the background
+type BacktestJob struct {
+	ID          uuid.UUID        `json:"id"`
+	Request     BacktestRequest  `json:"request"`
+	Status      BacktestStatus   `json:"status"`
+	Progress    BacktestProgress `json:"progress"`
+	Report      *BacktestReport  `json:"report,omitempty"`
+	Error       string           `json:"error,omitempty"`
+	CreatedAt   time.Time        `json:"created_at"`
+	StartedAt   *time.Time       `json:"started_at,omitempty"`
+	CompletedAt *time.Time       `json:"completed_at,omitempty"`
+}
@@ -0,0 +1,156 @@
+package domain
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// BundleVersion is the bundle format written by exports and accepted by imports
+const BundleVersion = 1
+
+// How imported rules are matched to existing ones
+const (
+	BundleMatchKey  = "key"  // external key, or the rule ID of an export from the same account
+	BundleMatchName = "name" // rule name; several rules with the name is a conflict
+)
+
+// Import modes
+const (
+	ImportModeCreate = "create" // existing rules and products are conflicts
+	ImportModeUpsert = "upsert" // existing rules and products are updated
+)
+
+// Planned import actions
+const (
+	ImportActionCreate    = "create"
+	ImportActionUpdate    = "update"
+	ImportActionUnchanged = "unchanged"
+)
+
+// Import conflict kinds
+const (
+	ImportConflictExists       = "exists"
+	ImportConflictAmbiguous    = "ambiguous"
+	ImportConflictDuplicate    = "duplicate"
+	ImportConflictPendingDraft = "pending_draft"
+)
+
+// Bundle errors
+var (
+	ErrUnsupportedBundleVersion = errors.New("unsupported bundle version")
+	ErrInvalidImportOptions     = errors.New("invalid import options")
+	ErrDuplicateExternalKey     = errors.New("external key is already used by another rule")
+)
+
+// Bundle is a portable set of rules and, optionally, the products using them
+type Bundle struct {
+	Version    int             `json:"version"`
+	ExportedAt time.Time       `json:"exported_at"`
+	Rules      []BundleRule    `json:"rules"`
+	Products   []BundleProduct `json:"products,omitempty"`
+}
+
+// BundleRule is a rule's published content, identified by Key within the bundle
+type BundleRule struct {
+	Key           string                 `json:"key"`
+	Name          string                 `json:"name"`
+	Description   string                 `json:"description,omitempty"`
+	StrategyType  string                 `json:"strategy_type"`
+	Config        map[string]interface{} `json:"config"`
+	IsActive      bool                   `json:"is_active"`
+	EffectiveFrom *time.Time             `json:"effective_from,omitempty"`
+	EffectiveTo   *time.Time             `json:"effective_to,omitempty"`
+}
+
+// BundleProduct is a product with its rule links, which refer to bundle rule keys
+type BundleProduct struct {
+	SKU         string                 `json:"sku"`
+	Name        string                 `json:"name"`
+	Description string                 `json:"description,omitempty"`
+	BaseCost    float64                `json:"base_cost"`
+	Metadata    map[string]interface{} `json:"metadata,omitempty"`
+	IsActive    bool                   `json:"is_active"`
+	DefaultRule string                 `json:"default_rule,omitempty"`
+	Schedule    []BundleAssignment     `json:"schedule,omitempty"`
+}
+
+// BundleAssignment schedules a bundle rule as a product's default
+type BundleAssignment struct {
+	Rule          string     `json:"rule"`
+	EffectiveFrom *time.Time `json:"effective_from,omitempty"`
+	EffectiveTo   *time.Time `json:"effective_to,omitempty"`
+}
+
+// ImportOptions controls how a bundle is applied
+type ImportOptions struct {
+	DryRun     bool
+	MatchBy    string
+	Mode       string
+	Publish    bool // publish imported rules instead of leaving them as drafts
+	ChangeNote string
+}
+
+// ImportRequest is a bundle to import into a user's account
+type ImportRequest struct {
+	Bundle   *Bundle
+	Options  ImportOptions
+	Approval DraftApproval // the importing user and key, recorded as draft author
+}
+
+// ImportItem is the planned action for one rule or product in a bundle
+type ImportItem struct {
+	Path   string     `json:"path"` // JSON pointer into the bundle, e.g. /rules/0
+	Kind   string     `json:"kind"` // "rule" or "product"
+	Key    string     `json:"key"`  // rule key or product SKU
+	Action string     `json:"action"`
+	ID     *uuid.UUID `json:"id,omitempty"` // existing or newly assigned ID
+}
+
+// ImportConflict is a bundle entry that cannot be applied as requested
+type ImportConflict struct {
+	Path       string     `json:"path"`
+	Kind       string     `json:"kind"`
+	Message    string     `json:"message"`
+	ExistingID *uuid.UUID `json:"existing_id,omitempty"`
+}
+
+// ImportReport describes what an import did, or would do on a dry run.
+// Nothing is applied when there are conflicts or errors.
+type ImportReport struct {
+	DryRun    bool             `json:"dry_run"`
+	Applied   bool             `json:"applied"`
+	Published bool             `json:"published"`
+	Items     []ImportItem     `json:"items"`
+	Conflicts []ImportConflict `json:"conflicts"`
+	Errors    []FieldError     `json:"errors"` // paths are JSON pointers into the bundle
+	Summary   map[string]int   `json:"summary"`
+}
+
+// Rejected reports whether the import cannot be applied
+func (r *ImportReport) Rejected() bool {
+	return len(r.Conflicts) > 0 || len(r.Errors) > 0
+}
+
+// ImportPlan is a validated import, applied in a single transaction
+type ImportPlan struct {
+	UserID   uuid.UUID
+	Approval DraftApproval
+	Publish  bool
+	Rules    []*PlannedRule
+	Products []*PlannedProduct
+}
+
+// PlannedRule is the imported content of a rule; Rule.ID is set for new rules too
+type PlannedRule struct {
+	Action string
+	Rule   *PricingRule
+}
+
+// PlannedProduct is an imported product and the schedule that replaces its existing one
+type PlannedProduct struct {
+	Action   string
+	Product  *Product
+	Schedule []*ProductRuleAssignment
+}
@@ -0,0 +1,51 @@
+package domain
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Where a resolved pricing rule came from, most specific first
+const (
+	RuleSourceRequest  = "request"  // rule_id given with the calculation
+	RuleSourceSchedule = "schedule" // the product's scheduled assignment
+	RuleSourceProduct  = "product"  // the product's default rule
+	RuleSourceCategory = "category" // the default rule of the product's category or an ancestor
+	RuleSourceAccount  = "account"  // the account's default rule
+)
+
+// Category errors
+var (
+	ErrCategoryExists   = errors.New("a category with this name already exists under the parent")
+	ErrCategoryCycle    = errors.New("category cannot be moved under itself or its descendants")
+	ErrCategoryNotEmpty = errors.New("category still has subcategories or active products")
+)
+
+// Category groups products in a per-account tree. Its default rule and
+// attributes apply to every product below it that does not set its own.
+type Category struct {
+	ID            uuid.UUID              `json:"id"`
+	UserID        uuid.UUID              `json:"user_id"`
+	ParentID      *uuid.UUID             `json:"parent_id,omitempty"` // nil for top-level categories
+	Name          string                 `json:"name"`
+	Description   string                 `json:"description"`
+	DefaultRuleID *uuid.UUID             `json:"default_rule_id,omitempty"`
+	Attributes    map[string]interface{} `json:"attributes"` // defaults for product metadata
+	CreatedAt     time.Time              `json:"created_at"`
+	UpdatedAt     time.Time              `json:"updated_at"`
+}
+
+// RuleSource identifies where a resolved rule was configured
+type RuleSource struct {
+	Level string    `json:"level"` // one of the RuleSource constants
+	ID    uuid.UUID `json:"id"`    // the rule, assignment, product, category or user that supplied it
+	Name  string    `json:"name,omitempty"`
+}
+
+// ResolvedRule is the rule a calculation prices with and where it came from
+type ResolvedRule struct {
+	Rule   *PricingRule `json:"rule"`
+	Source RuleSource   `json:"source"`
+}
@@ -0,0 +1,123 @@
+package domain
+
+import (
+	"errors"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Where a product cost came from
+const (
+	CostSourceManual     = "manual"     // set through the products API
+	CostSourceSupplier   = "supplier"   // a supplier price change
+	CostSourceImport     = "import"     // a product file or bundle import
+	CostSourceAdjustment = "adjustment" // a correction to an earlier cost
+)
+
+// CostSources lists the accepted cost sources
+var CostSources = []string{CostSourceManual, CostSourceSupplier, CostSourceImport, CostSourceAdjustment}
+
+// Cost history errors
+var (
+	ErrCostExists     = errors.New("product already has a cost effective at this time")
+	ErrCostNotFound   = errors.New("product cost not found")
+	ErrNoCostInEffect = errors.New("no product cost in effect")
+	ErrInvalidCost    = errors.New("invalid product cost")
+)
+
+// ProductCost is a product's unit cost from EffectiveFrom until the next entry
+type ProductCost struct {
+	ID            uuid.UUID  `json:"id"`
+	ProductID     uuid.UUID  `json:"product_id"`
+	Cost          float64    `json:"cost"`
+	EffectiveFrom time.Time  `json:"effective_from"`
+	Source        string     `json:"source"`
+	Note          string     `json:"note,omitempty"`
+	CreatedBy     *uuid.UUID `json:"created_by,omitempty"` // API key that recorded it
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// CostPeriod is a cost history entry with the window it applies in and the
+// change from the entry before it
+type CostPeriod struct {
+	ProductCost
+	EffectiveTo    *time.Time `json:"effective_to,omitempty"` // the next entry's start
+	Status         string     `json:"status"`                 // active, upcoming or expired
+	Change         *float64   `json:"change,omitempty"`
+	ChangePercent  *float64   `json:"change_percent,omitempty"`
+	PreviousCostID *uuid.UUID `json:"previous_cost_id,omitempty"`
+}
+
+// Window returns the period as an effective window
+func (p *CostPeriod) Window() EffectiveWindow {
+	return EffectiveWindow{From: &p.EffectiveFrom, To: p.EffectiveTo}
+}
+
+// CostTimeline returns a product's cost history as consecutive periods,
+// earliest first; costs must be sorted by EffectiveFrom
+func CostTimeline(costs []*ProductCost, now time.Time) []*CostPeriod {
+	periods := make([]*CostPeriod, len(costs))
+	for i, cost := range costs {
+		period := &CostPeriod{ProductCost: *cost}
+		if i+1 < len(costs) {
+			period.EffectiveTo = &costs[i+1].EffectiveFrom
+		}
+		if i > 0 {
+			previous := costs[i-1]
+			change := RoundChange(cost.Cost - previous.Cost)
+			period.Change = &change
+			period.PreviousCostID = &previous.ID
+			if previous.Cost != 0 {
+				percent := RoundChange(change / previous.Cost * 100)
+				period.ChangePercent = &percent
+			}
+		}
+		period.Status = period.Window().Status(now)
+		periods[i] = period
+	}
+	return periods
+}
+
+// RoundChange rounds a difference to 2 decimal places, half away from zero.
+// Unlike RoundToTwoDecimals it is exact for negative values.
+func RoundChange(val float64) float64 {
+	return math.Round(val*100) / 100
+}
+
+// PriceAtCost is the price of a product at one unit cost
+type PriceAtCost struct {
+	Cost          float64 `json:"cost"`
+	FinalPrice    float64 `json:"final_price"`
+	Margin        float64 `json:"margin"`
+	MarginPercent float64 `json:"margin_percent"`
+}
+
+// NewPriceAtCost derives the margin of a price over a unit cost
+func NewPriceAtCost(cost, finalPrice float64) *PriceAtCost {
+	price := &PriceAtCost{
+		Cost:       cost,
+		FinalPrice: finalPrice,
+		Margin:     RoundChange(finalPrice - cost),
+	}
+	if finalPrice != 0 {
+		price.MarginPercent = RoundChange((finalPrice - cost) / finalPrice * 100)
+	}
+	return price
+}
+
+// CostRecomputeReport compares a product's price just before and after a
+// cost change took effect, using the rule in effect at that time
+type CostRecomputeReport struct {
+	ProductID   uuid.UUID    `json:"product_id"`
+	SKU         string       `json:"sku"`
+	CostID      uuid.UUID    `json:"cost_id"`
+	EffectiveAt time.Time    `json:"effective_at"`
+	RuleID      *uuid.UUID   `json:"rule_id,omitempty"`
+	RuleSource  *RuleSource  `json:"rule_source,omitempty"`
+	Before      *PriceAtCost `json:"before,omitempty"` // nil for a product's first cost
+	After       *PriceAtCost `json:"after,omitempty"`
+	PriceChange *float64     `json:"price_change,omitempty"`
+	Error       string       `json:"error,omitempty"` // why the price could not be computed
+}
@@ -0,0 +1,77 @@
+package domain
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Rule draft statuses
+const (
+	DraftStatusPending   = "pending"
+	DraftStatusPublished = "published"
+	DraftStatusDiscarded = "discarded"
+)
+
+// Draft workflow errors
+var (
+	ErrDraftNotFound         = errors.New("no pending draft")
+	ErrRuleNotPublished      = errors.New("pricing rule has not been published")
+	ErrSelfApprovalForbidden = errors.New("draft must be approved by an approver key other than its author's")
+	ErrDeleteNeedsApproval   = errors.New("published rules of accounts that require approval are taken offline through an approved draft")
+)
+
+// RuleDraft is an unpublished edit of a pricing rule
+type RuleDraft struct {
+	ID            uuid.UUID              `json:"id"`
+	RuleID        uuid.UUID              `json:"rule_id"`
+	BaseRevision  int                    `json:"base_revision"` // 0 for rules that were never published
+	Name          string                 `json:"name"`
+	Description   string                 `json:"description"`
+	StrategyType  string                 `json:"strategy_type"`
+	Config        map[string]interface{} `json:"config"`
+	IsActive      bool                   `json:"is_active"`
+	EffectiveFrom *time.Time             `json:"effective_from,omitempty"`
+	EffectiveTo   *time.Time             `json:"effective_to,omitempty"`
+	AuthorID      *uuid.UUID             `json:"author_id,omitempty"`
+	AuthorKeyID   *uuid.UUID             `json:"author_key_id,omitempty"`
+	ChangeNote    string                 `json:"change_note,omitempty"`
+	Status        string                 `json:"status"`
+
+	// Set when the draft is published
+	ApprovedBy        *uuid.UUID `json:"approved_by,omitempty"`
+	ApprovedByKeyID   *uuid.UUID `json:"approved_by_key_id,omitempty"`
+	ApprovedAt        *time.Time `json:"approved_at,omitempty"`
+	PublishedRevision int        `json:"published_revision,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// DraftApproval identifies who approved a draft for publishing
+type DraftApproval struct {
+	UserID uuid.UUID
+	KeyID  *uuid.UUID
+
+	// CanApprove reports whether an administrator granted KeyID approval
+	// rights; it is read from the key, never taken from the caller
+	CanApprove bool
+}
+
+// CheckApproval enforces the account policy: when approval is required the
+// approver must hold an approver key other than the one that authored the
+// draft. Any key can mint more keys for its account, so a differing key alone
+// does not prove a different approver.
+func (d *RuleDraft) CheckApproval(approval DraftApproval, requireApproval bool) error {
+	if !requireApproval {
+		return nil
+	}
+	if approval.KeyID == nil || !approval.CanApprove {
+		return ErrSelfApprovalForbidden
+	}
+	if d.AuthorKeyID == nil || *approval.KeyID == *d.AuthorKeyID {
+		return ErrSelfApprovalForbidden
+	}
+	return nil
+}
@@ -0,0 +1,22 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// IdempotencyRecord is a request made with an Idempotency-Key and, once it
+// has finished, the response replayed to its retries
+type IdempotencyRecord struct {
+	UserID      uuid.UUID
+	Key         string
+	Fingerprint string    // SHA-256 of the method, path and body
+	Token       uuid.UUID // Identifies the reservation; only its holder completes or releases it
+	Status      int       // 0 while the request is in flight
+	Headers     map[string]string
+	Body        []byte
+	CreatedAt   time.Time
+	CompletedAt *time.Time
+	ExpiresAt   time.Time
+}
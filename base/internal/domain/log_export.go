@@ -0,0 +1,60 @@
+package domain
+
+import (
+	"errors"
+)
+
+// Calculation log export formats
+const (
+	LogExportCSV    = "csv"
+	LogExportNDJSON = "ndjson" // one JSON object per line
+)
+
+// Calculation log export columns. Columns named input.<path> or output.<path>
+// hold one value of the calculation's input or output, such as
+// input.context.location or output.breakdown.0.amount.
+const (
+	LogColumnID             = "id"
+	LogColumnCreatedAt      = "created_at"
+	LogColumnUserID         = "user_id"
+	LogColumnAPIKeyID       = "api_key_id"
+	LogColumnRuleID         = "rule_id"
+	LogColumnRuleRevisionID = "rule_revision_id"
+	LogColumnRuleRevision   = "rule_revision"
+	LogColumnStrategyType   = "strategy_type"
+	LogColumnExecutionTime  = "execution_time_ms"
+	LogColumnRequestID      = "request_id"
+	LogColumnClientIP       = "client_ip"
+	LogColumnEngineVersion  = "engine_version"
+	LogColumnError          = "error"
+	LogColumnInputData      = "input_data"  // the whole input as JSON
+	LogColumnOutputData     = "output_data" // the whole output as JSON
+)
+
+// LogColumns lists the columns exported when none are selected, in order
+var LogColumns = []string{
+	LogColumnID,
+	LogColumnCreatedAt,
+	LogColumnUserID,
+	LogColumnAPIKeyID,
+	LogColumnRuleID,
+	LogColumnRuleRevisionID,
+	LogColumnRuleRevision,
+	LogColumnStrategyType,
+	LogColumnExecutionTime,
+	LogColumnRequestID,
+	LogColumnClientIP,
+	LogColumnEngineVersion,
+	LogColumnError,
+	LogColumnInputData,
+	LogColumnOutputData,
+}
+
+// Prefixes of the columns holding one value of a calculation's input or output
+const (
+	LogInputPrefix  = "input."
+	LogOutputPrefix = "output."
+)
+
+// ErrLogExportInvalid is returned when an export's format or columns are unusable
+var ErrLogExportInvalid = errors.New("calculation log export is invalid")
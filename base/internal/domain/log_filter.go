@@ -0,0 +1,63 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Operators of calculation log input and output filters
+const (
+	LogFilterEq       = "eq"       // the value equals a JSON scalar
+	LogFilterGt       = "gt"       // the value is a number above
+	LogFilterGte      = "gte"      // the value is a number at or above
+	LogFilterLt       = "lt"       // the value is a number below
+	LogFilterLte      = "lte"      // the value is a number at or below
+	LogFilterExists   = "exists"   // the path exists
+	LogFilterContains = "contains" // the array holds the elements, or the object holds the keys and values
+)
+
+// LogDataFilter matches calculation logs by one value of their input or output
+type LogDataFilter struct {
+	Column string   // LogColumnInputData or LogColumnOutputData
+	Path   []string // object keys leading to the value
+	Op     string
+	Value  interface{} // a decoded JSON value; float64 for range operators; unused by exists
+}
+
+// ErrLogFilterInvalid is returned when a calculation log filter cannot be applied
+var ErrLogFilterInvalid = errors.New("calculation log filter is invalid")
+
+// Validate checks that the filter names a column, a path and a value its
+// operator can compare
+func (f LogDataFilter) Validate() error {
+	if f.Column != LogColumnInputData && f.Column != LogColumnOutputData {
+		return fmt.Errorf("%w: cannot filter on %q", ErrLogFilterInvalid, f.Column)
+	}
+	if len(f.Path) == 0 {
+		return fmt.Errorf("%w: a path is required", ErrLogFilterInvalid)
+	}
+	for _, key := range f.Path {
+		if key == "" {
+			return fmt.Errorf("%w: path keys cannot be empty", ErrLogFilterInvalid)
+		}
+	}
+
+	switch f.Op {
+	case LogFilterEq:
+		switch f.Value.(type) {
+		case map[string]interface{}, []interface{}:
+			return fmt.Errorf("%w: eq compares a single value; use contains for objects and arrays", ErrLogFilterInvalid)
+		}
+	case LogFilterGt, LogFilterGte, LogFilterLt, LogFilterLte:
+		n, ok := f.Value.(float64)
+		if !ok || math.IsNaN(n) || math.IsInf(n, 0) {
+			return fmt.Errorf("%w: %s compares a number", ErrLogFilterInvalid, f.Op)
+		}
+	case LogFilterExists, LogFilterContains:
+	default:
+		return fmt.Errorf("%w: unknown operator %q", ErrLogFilterInvalid, f.Op)
+	}
+
+	return nil
+}
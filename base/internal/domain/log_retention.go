@@ -0,0 +1,19 @@
+package domain
+
+import (
+	"time"
+)
+
+// What happens to a calculation log partition once every account's
+// retention has passed
+const (
+	LogRetentionDrop   = "drop"   // the partition is deleted
+	LogRetentionDetach = "detach" // the partition is kept as a standalone table
+)
+
+// CalculationLogPartition is a monthly partition of the calculation logs
+type CalculationLogPartition struct {
+	Name string
+	From time.Time // first instant of the month
+	To   time.Time // first instant of the next month, exclusive
+}
@@ -0,0 +1,14 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Cursor is a position in a listing ordered by (created_at, id), newest
+// first. A listing given a cursor continues after the item it names.
+type Cursor struct {
+	CreatedAt time.Time
+	ID        uuid.UUID
+}
@@ -0,0 +1,156 @@
+package domain
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Price book generation states
+const (
+	PriceBookPending    = "pending"    // created or redefined, not generated yet
+	PriceBookGenerating = "generating" // a generation is running; the previous entries are still served
+	PriceBookReady      = "ready"
+	PriceBookFailed     = "failed" // the last generation failed; the previous entries are still served
+)
+
+// Price book export formats
+const (
+	PriceBookFormatCSV  = "csv"
+	PriceBookFormatJSON = "json"
+)
+
+// PriceBookColumns are the columns of a price book CSV export, in order
+var PriceBookColumns = []string{
+	"sku", "location", "currency", "channel", "final_price", "price_currency",
+	"base_cost", "strategy_type", "rule_id", "rule_revision", "rule_source", "error",
+}
+
+// Price book errors
+var (
+	ErrPriceBookExists   = errors.New("a price book with this name already exists")
+	ErrPriceBookNotFound = errors.New("price book not found")
+	ErrInvalidPriceBook  = errors.New("invalid price book")
+	ErrPriceBookTooLarge = errors.New("price book exceeds the entry limit")
+	ErrPriceBookBusy     = errors.New("price book is already being generated")
+)
+
+// PriceBookDimensions are the values every product is priced at. Each
+// location, currency and channel is passed to the strategy as the input of
+// the same name; an empty list prices without that input.
+type PriceBookDimensions struct {
+	Locations  []string `json:"locations"`
+	Currencies []string `json:"currencies"`
+	Channels   []string `json:"channels"`
+}
+
+// PriceBookCell is one combination of dimension values
+type PriceBookCell struct {
+	Location string `json:"location,omitempty"`
+	Currency string `json:"currency,omitempty"`
+	Channel  string `json:"channel,omitempty"`
+}
+
+// Cells returns every combination of the dimension values, locations varying slowest
+func (d PriceBookDimensions) Cells() []PriceBookCell {
+	orEmpty := func(values []string) []string {
+		if len(values) == 0 {
+			return []string{""}
+		}
+		return values
+	}
+
+	var cells []PriceBookCell
+	for _, location := range orEmpty(d.Locations) {
+		for _, currency := range orEmpty(d.Currencies) {
+			for _, channel := range orEmpty(d.Channels) {
+				cells = append(cells, PriceBookCell{Location: location, Currency: currency, Channel: channel})
+			}
+		}
+	}
+	return cells
+}
+
+// PriceBook is a named snapshot of every active product of an account priced
+// at each cell of its dimensions
+type PriceBook struct {
+	ID             uuid.UUID              `json:"id"`
+	UserID         uuid.UUID              `json:"user_id"`
+	Name           string                 `json:"name"`
+	Description    string                 `json:"description"`
+	Dimensions     PriceBookDimensions    `json:"dimensions"`
+	Quantity       int                    `json:"quantity"`
+	Context        map[string]interface{} `json:"context"` // extra inputs for every calculation
+	AutoRegenerate bool                   `json:"auto_regenerate"`
+	Version        int                    `json:"version"` // incremented when the definition changes
+
+	Status         string              `json:"status"`
+	Generation     int                 `json:"generation"`             // incremented by every completed generation
+	GeneratedAt    *time.Time          `json:"generated_at,omitempty"` // the entries reflect rules and products as of this time
+	StartedAt      *time.Time          `json:"started_at,omitempty"`   // start of the running or last generation
+	RuleRevisions  []PriceBookRevision `json:"rule_revisions"`
+	EntryCount     int                 `json:"entry_count"`
+	ErrorCount     int                 `json:"error_count"` // entries that could not be priced
+	LastError      string              `json:"last_error,omitempty"`
+	DependencyHash string              `json:"-"` // fingerprint of the rule configuration at GeneratedAt
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// PriceBookRevision is a rule revision the entries of a price book were priced with
+type PriceBookRevision struct {
+	RuleID     uuid.UUID  `json:"rule_id"`
+	RevisionID *uuid.UUID `json:"revision_id,omitempty"`
+	Revision   int        `json:"revision"`
+	Entries    int        `json:"entries"`
+}
+
+// PriceBookEntry is the price of one product at one cell of a price book
+type PriceBookEntry struct {
+	PriceBookID uuid.UUID `json:"price_book_id"`
+	ProductID   uuid.UUID `json:"product_id"`
+	SKU         string    `json:"sku"`
+	PriceBookCell
+	FinalPrice     *float64   `json:"final_price,omitempty"` // nil when the entry could not be priced
+	PriceCurrency  string     `json:"price_currency,omitempty"`
+	BaseCost       float64    `json:"base_cost"`
+	StrategyType   string     `json:"strategy_type,omitempty"`
+	RuleID         *uuid.UUID `json:"rule_id,omitempty"`
+	RuleRevisionID *uuid.UUID `json:"rule_revision_id,omitempty"`
+	RuleRevision   int        `json:"rule_revision,omitempty"`
+	RuleSource     string     `json:"rule_source,omitempty"`
+	Error          string     `json:"error,omitempty"`
+	GeneratedAt    time.Time  `json:"generated_at"`
+}
+
+// PriceBookEntryFilter selects entries of a price book; empty fields match all
+type PriceBookEntryFilter struct {
+	PriceBookID uuid.UUID
+	SKU         string
+	Location    *string
+	Currency    *string
+	Channel     *string
+	Limit       int
+	Offset      int
+}
+
+// PriceBookGeneration is the result of pricing a price book. A full
+// generation replaces every entry; an incremental one replaces the entries of
+// ProductIDs only.
+type PriceBookGeneration struct {
+	PriceBookID    uuid.UUID
+	Version        int // definition version the entries were priced with
+	Full           bool
+	ProductIDs     []uuid.UUID
+	Entries        []*PriceBookEntry
+	StartedAt      time.Time
+	DependencyHash string
+}
+
+// PriceBookChanges is what changed since a price book was generated
+type PriceBookChanges struct {
+	Full       bool        // rules, schedules, categories or the account default changed
+	ProductIDs []uuid.UUID // products or product costs that changed
+}
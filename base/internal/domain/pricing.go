@@ -13,6 +13,7 @@ const (
 	StrategyTypeGeographic = "geographic"
 	StrategyTypeTimeBased  = "time_based"
 	StrategyTypeRuleBased  = "rule_based"
+	StrategyTypeFormula    = "formula"
 )
 
 // Pricing Strategy defines the interface all pricing strategies must implement
@@ -25,6 +26,18 @@ type PricingStrategy interface {
 
 	// Name returns the strategy type identifier
 	Name() string
+
+	// Schema describes the strategy's config and inputs as JSON Schema
+	Schema() StrategySchema
+}
+
+// StrategySchema describes a strategy for clients building rule editors
+type StrategySchema struct {
+	Type         string                 `json:"type"`
+	Name         string                 `json:"name"`
+	Description  string                 `json:"description"`
+	ConfigSchema map[string]interface{} `json:"config_schema"`
+	InputSchema  map[string]interface{} `json:"input_schema"`
 }
 
 // PricingRequest contains all inputs for price calculation
@@ -93,6 +106,76 @@ type PricingRule struct {
 	CreatedAt    time.Time              `json:"created_at"`
 	UpdatedAt    time.Time              `json:"updated_at"`
 	DeletedAt    *time.Time             `json:"deleted_at,omitempty"`
+
+	// Caller-chosen identifier, unique per user, for matching rules across accounts
+	ExternalKey string `json:"external_key,omitempty"`
+
+	// Published content is served to calculations; nil until the first publish
+	PublishedAt *time.Time `json:"published_at,omitempty"`
+
+	// Window the rule applies in; nil bounds are unbounded
+	EffectiveFrom *time.Time `json:"effective_from,omitempty"`
+	EffectiveTo   *time.Time `json:"effective_to,omitempty"`
+
+	// Current revision; Create and Update record a new one from UpdatedBy,
+	// UpdatedByKeyID and ChangeNote
+	Revision       int        `json:"revision"`
+	RevisionID     *uuid.UUID `json:"revision_id,omitempty"`
+	UpdatedBy      *uuid.UUID `json:"updated_by,omitempty"`
+	UpdatedByKeyID *uuid.UUID `json:"updated_by_key_id,omitempty"`
+	ChangeNote     string     `json:"change_note,omitempty"`
+}
+
+// Window returns the rule's effective window
+func (r *PricingRule) Window() EffectiveWindow {
+	return EffectiveWindow{From: r.EffectiveFrom, To: r.EffectiveTo}
+}
+
+// PricingRuleRevision is an immutable snapshot of a pricing rule
+type PricingRuleRevision struct {
+	ID            uuid.UUID              `json:"id"`
+	RuleID        uuid.UUID              `json:"rule_id"`
+	Revision      int                    `json:"revision"`
+	Name          string                 `json:"name"`
+	Description   string                 `json:"description"`
+	StrategyType  string                 `json:"strategy_type"`
+	Config        map[string]interface{} `json:"config"`
+	IsActive      bool                   `json:"is_active"`
+	EffectiveFrom *time.Time             `json:"effective_from,omitempty"`
+	EffectiveTo   *time.Time             `json:"effective_to,omitempty"`
+	AuthorID      *uuid.UUID             `json:"author_id,omitempty"`
+	AuthorKeyID   *uuid.UUID             `json:"author_key_id,omitempty"`
+	ChangeNote    string                 `json:"change_note,omitempty"`
+	CreatedAt     time.Time              `json:"created_at"`
+}
+
+// Document returns the revised fields as a JSON document for diffing
+func (r *PricingRuleRevision) Document() map[string]interface{} {
+	return map[string]interface{}{
+		"name":           r.Name,
+		"description":    r.Description,
+		"strategy_type":  r.StrategyType,
+		"config":         r.Config,
+		"is_active":      r.IsActive,
+		"effective_from": formatScheduleBound(r.EffectiveFrom),
+		"effective_to":   formatScheduleBound(r.EffectiveTo),
+	}
+}
+
+// formatScheduleBound renders a window bound as a JSON value (nil when unbounded)
+func formatScheduleBound(t *time.Time) interface{} {
+	if t == nil {
+		return nil
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// JSONChange is one difference between two JSON documents
+type JSONChange struct {
+	Op   string      `json:"op"`   // "add", "remove" or "replace"
+	Path string      `json:"path"` // JSON pointer (RFC 6901)
+	From interface{} `json:"from,omitempty"`
+	To   interface{} `json:"to,omitempty"`
 }
 
 // Product represents a SKU in the catalog
@@ -104,6 +187,7 @@ type Product struct {
 	Description   string                 `json:"description"`
 	BaseCost      float64                `json:"base_cost"`
 	DefaultRuleID *uuid.UUID             `json:"default_rule_id,omitempty"`
+	CategoryID    *uuid.UUID             `json:"category_id,omitempty"`
 	Metadata      map[string]interface{} `json:"metadata"`
 	IsActive      bool                   `json:"is_active"`
 	CreatedAt     time.Time              `json:"created_at"`
@@ -116,13 +200,23 @@ type CalculationLog struct {
 	UserID          uuid.UUID              `json:"user_id"`
 	APIKeyID        *uuid.UUID             `json:"api_key_id,omitempty"`
 	RuleID          *uuid.UUID             `json:"rule_id,omitempty"`
+	RuleRevisionID  *uuid.UUID             `json:"rule_revision_id,omitempty"`
+	RuleRevision    int                    `json:"rule_revision,omitempty"`
 	StrategyType    string                 `json:"strategy_type"`
 	InputData       map[string]interface{} `json:"input_data"`
 	OutputData      map[string]interface{} `json:"output_data"`
-	ExecutionTimeMs int                    `json:"execution_time_ms"`
+	ExecutionTimeMs float64                `json:"execution_time_ms"` // pricing engine time, fractional
+	RequestID       string                 `json:"request_id,omitempty"`
+	ClientIP        string                 `json:"client_ip,omitempty"`
+	EngineVersion   string                 `json:"engine_version,omitempty"`
+	Error           string                 `json:"error,omitempty"` // set when the calculation failed
 	CreatedAt       time.Time              `json:"created_at"`
 }
 
+// ErrCalculationLogQueueFull is returned when a calculation log is dropped
+// because the writer cannot keep up
+var ErrCalculationLogQueueFull = errors.New("calculation log queue is full")
+
 // Common validation errors
 var (
 	ErrInvalidStrategy      = errors.New("invalid pricing strategy")
@@ -141,6 +235,7 @@ func ValidateStrategy(strategy string) error {
 		StrategyTypeGeographic: true,
 		StrategyTypeRuleBased:  true,
 		StrategyTypeTimeBased:  true,
+		StrategyTypeFormula:    true,
 	}
 
 	if !validStrategies[strategy] {
@@ -0,0 +1,111 @@
+package domain
+
+import (
+	"errors"
+	"io"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Product file formats for bulk import and export
+const (
+	ProductFormatCSV    = "csv"
+	ProductFormatNDJSON = "ndjson" // one JSON object per line
+)
+
+// Product file columns. Columns named metadata.<key> set a single metadata key.
+const (
+	ProductColumnSKU         = "sku"
+	ProductColumnName        = "name"
+	ProductColumnDescription = "description"
+	ProductColumnBaseCost    = "base_cost"
+	ProductColumnDefaultRule = "default_rule" // rule external key or ID; empty clears it
+	ProductColumnIsActive    = "is_active"
+	ProductColumnMetadata    = "metadata" // JSON object replacing the product's metadata
+)
+
+// ProductColumns lists the columns written by exports, in order
+var ProductColumns = []string{
+	ProductColumnSKU,
+	ProductColumnName,
+	ProductColumnDescription,
+	ProductColumnBaseCost,
+	ProductColumnDefaultRule,
+	ProductColumnIsActive,
+	ProductColumnMetadata,
+}
+
+// ProductMetadataPrefix starts the name of a column that sets one metadata key
+const ProductMetadataPrefix = "metadata."
+
+// ProductColumnIgnored maps a source column to nothing
+const ProductColumnIgnored = "-"
+
+// ProductImportStatus is the lifecycle state of a product import job
+type ProductImportStatus string
+
+const (
+	ProductImportPending   ProductImportStatus = "pending"
+	ProductImportRunning   ProductImportStatus = "running"
+	ProductImportCompleted ProductImportStatus = "completed"
+	ProductImportFailed    ProductImportStatus = "failed"
+)
+
+// Done reports whether the job has reached a terminal state
+func (s ProductImportStatus) Done() bool {
+	return s == ProductImportCompleted || s == ProductImportFailed
+}
+
+// Product import errors
+var (
+	ErrProductImportInvalid  = errors.New("product import is invalid")
+	ErrProductImportNotFound = errors.New("product import not found")
+	ErrProductImportBusy     = errors.New("too many product imports are running")
+)
+
+// ProductImportRequest is an uploaded product file to upsert by SKU
+type ProductImportRequest struct {
+	UserID uuid.UUID
+	Format string
+	// Mapping renames source columns (or NDJSON keys) to product columns;
+	// unmapped columns must already be named after one
+	Mapping map[string]string
+	Data    io.Reader
+}
+
+// ProductRowError is a row of an import file that was not applied
+type ProductRowError struct {
+	Line    int    `json:"line"` // 1-based line of the row in the file
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+// ProductImportProgress tracks how many rows have been processed
+type ProductImportProgress struct {
+	Processed int `json:"processed"`
+	Total     int `json:"total"`
+}
+
+// ProductImportResult counts what an import did with each row
+type ProductImportResult struct {
+	Created         int               `json:"created"`
+	Updated         int               `json:"updated"`
+	Failed          int               `json:"failed"`
+	Errors          []ProductRowError `json:"errors"`
+	ErrorsTruncated bool              `json:"errors_truncated"` // more rows failed than are listed
+}
+
+// ProductImportJob is a product import running in the background
+type ProductImportJob struct {
+	ID          uuid.UUID             `json:"id"`
+	UserID      uuid.UUID             `json:"user_id"`
+	Format      string                `json:"format"`
+	Status      ProductImportStatus   `json:"status"`
+	Progress    ProductImportProgress `json:"progress"`
+	Result      ProductImportResult   `json:"result"`
+	Error       string                `json:"error,omitempty"`
+	CreatedAt   time.Time             `json:"created_at"`
+	StartedAt   *time.Time            `json:"started_at,omitempty"`
+	CompletedAt *time.Time            `json:"completed_at,omitempty"`
+}
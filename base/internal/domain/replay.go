@@ -0,0 +1,43 @@
+package domain
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Reasons a replayed calculation drifted from its log
+const (
+	ReplayDriftRuleChanged   = "rule_changed"   // the logged rule revision is unknown, and the rule changed since
+	ReplayDriftEngineChanged = "engine_changed" // the engine version differs from the logged one
+	ReplayDriftUnexplained   = "unexplained"    // neither the rule nor the engine changed
+)
+
+// ErrReplayUnsupported is returned when a calculation log lacks the data to replay it
+var ErrReplayUnsupported = errors.New("calculation log cannot be replayed")
+
+// CalculationReplay is the outcome of re-running a logged calculation with
+// the rule revision and evaluation time it originally used
+type CalculationReplay struct {
+	LogID        uuid.UUID  `json:"log_id"`
+	RuleID       *uuid.UUID `json:"rule_id,omitempty"`
+	RuleRevision int        `json:"rule_revision,omitempty"` // Revision replayed; 0 for inline configs
+	StrategyType string     `json:"strategy_type"`
+	RequestedAt  time.Time  `json:"requested_at"` // Evaluation time of both runs
+
+	OriginalEngineVersion string `json:"original_engine_version,omitempty"`
+	EngineVersion         string `json:"engine_version"`
+
+	OriginalPrice *float64 `json:"original_price,omitempty"`
+	ReplayedPrice *float64 `json:"replayed_price,omitempty"`
+	OriginalError string   `json:"original_error,omitempty"`
+	ReplayedError string   `json:"replayed_error,omitempty"`
+
+	// Drift is set when the replay's price, breakdown or outcome differs;
+	// Changes lists the differences and Reasons their likely causes
+	Drift      bool         `json:"drift"`
+	Reasons    []string     `json:"reasons"`
+	Changes    []JSONChange `json:"changes"`
+	ReplayedAt time.Time    `json:"replayed_at"`
+}
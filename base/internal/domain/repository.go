@@ -2,6 +2,7 @@ package domain
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"github.com/google/uuid"
@@ -26,6 +27,30 @@ type PricingRuleRepository interface {
 
 	// List retrieves pricing rules with optional filters
 	List(ctx context.Context, filter PricingRuleFilter) ([]*PricingRule, error)
+
+	// Count returns the number of pricing rules matching the filters (ignores Limit/Offset/After)
+	Count(ctx context.Context, filter PricingRuleFilter) (int, error)
+
+	// ListRevisions retrieves every revision of a rule, newest first
+	ListRevisions(ctx context.Context, ruleID uuid.UUID) ([]*PricingRuleRevision, error)
+
+	// GetRevision retrieves a single revision of a rule
+	GetRevision(ctx context.Context, ruleID uuid.UUID, revision int) (*PricingRuleRevision, error)
+
+	// SaveDraft creates or replaces the pending draft of a rule, assigning it a new ID
+	SaveDraft(ctx context.Context, draft *RuleDraft) error
+
+	// GetDraft retrieves the pending draft of a rule, or ErrDraftNotFound
+	GetDraft(ctx context.Context, ruleID uuid.UUID) (*RuleDraft, error)
+
+	// ListDrafts retrieves a user's pending drafts, most recently edited first
+	ListDrafts(ctx context.Context, userID uuid.UUID) ([]*RuleDraft, error)
+
+	// DiscardDraft abandons the pending draft of a rule
+	DiscardDraft(ctx context.Context, ruleID uuid.UUID) error
+
+	// PublishDraft applies a pending draft as the rule's next revision and records the approval
+	PublishDraft(ctx context.Context, draftID uuid.UUID, approval DraftApproval) (*PricingRule, error)
 }
 
 // ProductRepository defines operations for products
@@ -50,6 +75,154 @@ type ProductRepository interface {
 
 	// List retrieves products with optional filters
 	List(ctx context.Context, filter ProductFilter) ([]*Product, error)
+
+	// Count returns the number of products matching the filters (ignores Limit/Offset/After)
+	Count(ctx context.Context, filter ProductFilter) (int, error)
+
+	// GetBySKUs retrieves a user's products with any of the given SKUs
+	GetBySKUs(ctx context.Context, userID uuid.UUID, skus []string) ([]*Product, error)
+
+	// UpsertBatch creates or updates products by (user_id, sku) in one transaction
+	UpsertBatch(ctx context.Context, products []*Product) error
+
+	// AddRuleAssignment schedules a default rule, rejecting overlaps with ErrScheduleOverlap
+	AddRuleAssignment(ctx context.Context, assignment *ProductRuleAssignment) error
+
+	// ListRuleAssignments retrieves a product's scheduled rules, earliest first
+	ListRuleAssignments(ctx context.Context, productID uuid.UUID) ([]*ProductRuleAssignment, error)
+
+	// DeleteRuleAssignment removes a scheduled rule from a product
+	DeleteRuleAssignment(ctx context.Context, productID, id uuid.UUID) error
+
+	// AddCost records a cost, rejecting a second entry at the same time with ErrCostExists
+	AddCost(ctx context.Context, cost *ProductCost) error
+
+	// ListCosts retrieves a product's cost history, earliest first
+	ListCosts(ctx context.Context, productID uuid.UUID) ([]*ProductCost, error)
+
+	// CostAt retrieves the cost in effect at a point in time, or ErrNoCostInEffect
+	CostAt(ctx context.Context, productID uuid.UUID, at time.Time) (*ProductCost, error)
+}
+
+// CategoryRepository defines operations for product categories
+type CategoryRepository interface {
+	// Create creates a category, returning ErrCategoryExists if a sibling has the name
+	Create(ctx context.Context, category *Category) error
+
+	// GetByID retrieves a category by ID
+	GetByID(ctx context.Context, id uuid.UUID) (*Category, error)
+
+	// ListByUser retrieves all of a user's categories, ordered by name
+	ListByUser(ctx context.Context, userID uuid.UUID) ([]*Category, error)
+
+	// Update updates a category, returning ErrCategoryCycle if it would become its own ancestor
+	Update(ctx context.Context, category *Category) error
+
+	// Delete deletes a category, returning ErrCategoryNotEmpty if it has children or active products
+	Delete(ctx context.Context, id uuid.UUID) error
+
+	// Ancestors retrieves a category followed by its ancestors, nearest first
+	Ancestors(ctx context.Context, id uuid.UUID) ([]*Category, error)
+}
+
+// BundleRepository applies rule bundle imports
+type BundleRepository interface {
+	// ApplyImport writes every planned rule and product in one transaction
+	ApplyImport(ctx context.Context, plan *ImportPlan) error
+}
+
+// PriceBookRepository defines operations for price books and their entries
+type PriceBookRepository interface {
+	// Create creates a price book, returning ErrPriceBookExists if the user has one with the name
+	Create(ctx context.Context, book *PriceBook) error
+
+	// GetByID retrieves a price book by ID
+	GetByID(ctx context.Context, id uuid.UUID) (*PriceBook, error)
+
+	// ListByUser retrieves all of a user's price books, ordered by name
+	ListByUser(ctx context.Context, userID uuid.UUID) ([]*PriceBook, error)
+
+	// ListAutoRegenerate retrieves every price book that regenerates on changes
+	ListAutoRegenerate(ctx context.Context) ([]*PriceBook, error)
+
+	// Update updates a price book's definition and marks it pending
+	Update(ctx context.Context, book *PriceBook) error
+
+	// Delete deletes a price book and its entries
+	Delete(ctx context.Context, id uuid.UUID) error
+
+	// Claim marks a price book as generating and returns the database time it
+	// started at. Returns ErrPriceBookBusy if a recent generation is running.
+	Claim(ctx context.Context, id uuid.UUID) (time.Time, error)
+
+	// Complete stores a generation's entries and marks the price book ready
+	Complete(ctx context.Context, generation *PriceBookGeneration) error
+
+	// Fail records why a generation failed and the fingerprint it ran with,
+	// keeping the previous entries
+	Fail(ctx context.Context, id uuid.UUID, message, dependencyHash string) error
+
+	// DependencyHash fingerprints the rules, schedules, categories and account
+	// default that resolve the user's rules
+	DependencyHash(ctx context.Context, userID uuid.UUID) (string, error)
+
+	// Changes reports what changed for the user's products since the given
+	// time, including rule windows and costs that took effect before now
+	Changes(ctx context.Context, userID uuid.UUID, since, now time.Time) (*PriceBookChanges, error)
+
+	// ListEntries retrieves entries ordered by SKU and cell
+	ListEntries(ctx context.Context, filter PriceBookEntryFilter) ([]*PriceBookEntry, error)
+
+	// CountEntries returns the number of entries matching the filter
+	CountEntries(ctx context.Context, filter PriceBookEntryFilter) (int, error)
+
+	// EachEntry calls fn for every entry of a price book in one consistent read
+	EachEntry(ctx context.Context, priceBookID uuid.UUID, fn func(*PriceBookEntry) error) error
+}
+
+// WebhookRepository defines operations for webhook endpoints, the event
+// outbox and deliveries
+type WebhookRepository interface {
+	// CreateEndpoint creates a webhook endpoint
+	CreateEndpoint(ctx context.Context, endpoint *WebhookEndpoint) error
+
+	// GetEndpoint retrieves a webhook endpoint by ID
+	GetEndpoint(ctx context.Context, id uuid.UUID) (*WebhookEndpoint, error)
+
+	// ListEndpoints retrieves a user's webhook endpoints, oldest first
+	ListEndpoints(ctx context.Context, userID uuid.UUID) ([]*WebhookEndpoint, error)
+
+	// UpdateEndpoint updates a webhook endpoint
+	UpdateEndpoint(ctx context.Context, endpoint *WebhookEndpoint) error
+
+	// DeleteEndpoint deletes a webhook endpoint and its deliveries
+	DeleteEndpoint(ctx context.Context, id uuid.UUID) error
+
+	// DispatchEvents creates a delivery for each subscribed endpoint of up to
+	// limit undispatched events, returning how many events were dispatched
+	DispatchEvents(ctx context.Context, limit int, now time.Time) (int, error)
+
+	// ClaimDeliveries retrieves up to limit pending deliveries due at now,
+	// pushing their next attempt to leaseUntil so no other worker sends them
+	ClaimDeliveries(ctx context.Context, limit int, now, leaseUntil time.Time) ([]*WebhookDelivery, error)
+
+	// RecordAttempt stores an attempt and the delivery's resulting state
+	RecordAttempt(ctx context.Context, delivery *WebhookDelivery, attempt *WebhookAttempt) error
+
+	// GetDelivery retrieves a delivery with its event
+	GetDelivery(ctx context.Context, id uuid.UUID) (*WebhookDelivery, error)
+
+	// ListDeliveries retrieves deliveries with their events, newest first
+	ListDeliveries(ctx context.Context, filter WebhookDeliveryFilter) ([]*WebhookDelivery, error)
+
+	// CountDeliveries returns the number of deliveries matching the filter
+	CountDeliveries(ctx context.Context, filter WebhookDeliveryFilter) (int, error)
+
+	// ListAttempts retrieves a delivery's attempts, earliest first
+	ListAttempts(ctx context.Context, deliveryID uuid.UUID) ([]*WebhookAttempt, error)
+
+	// Replay creates a pending delivery of the same event to the same endpoint
+	Replay(ctx context.Context, deliveryID uuid.UUID, now time.Time) (*WebhookDelivery, error)
 }
 
 // CalculationLogRepository defines operations for calculation logs
@@ -57,6 +230,11 @@ type CalculationLogRepository interface {
 	// Create creates a new calculation log entry
 	Create(ctx context.Context, log *CalculationLog) error
 
+	// CreateBatch writes many log entries at once and returns how many were
+	// written. Entries rejected by a constraint, such as an ID that already
+	// exists, are skipped; an error means the batch should be retried.
+	CreateBatch(ctx context.Context, logs []*CalculationLog) (int, error)
+
 	// GetByID retrieves a calculation log by ID
 	GetByID(ctx context.Context, id uuid.UUID) (*CalculationLog, error)
 
@@ -66,8 +244,19 @@ type CalculationLogRepository interface {
 	// List retrieves calculation logs with filters
 	List(ctx context.Context, filter CalculationLogFilter) ([]*CalculationLog, error)
 
+	// Count returns the number of calculation logs matching the filters (ignores Limit/Offset/After)
+	Count(ctx context.Context, filter CalculationLogFilter) (int, error)
+
+	// Export calls fn with every calculation log matching the filters, oldest
+	// first, ignoring Limit/Offset. Logs are read through a server-side cursor.
+	Export(ctx context.Context, filter CalculationLogFilter, fn func(*CalculationLog) error) error
+
 	// GetStats retrieves calculation statistics
 	GetStats(ctx context.Context, userID uuid.UUID, from, to time.Time) (*CalculationStats, error)
+
+	// Analytics aggregates an account's calculations per time bucket and group,
+	// ordered by bucket then group
+	Analytics(ctx context.Context, query AnalyticsQuery) ([]*AnalyticsPoint, error)
 }
 
 // APIKeyRepository defines operations for API keys
@@ -78,15 +267,27 @@ type APIKeyRepository interface {
 	// GetByHash retrieves an API key by its hash
 	GetByHash(ctx context.Context, hash string) (*APIKey, error)
 
+	// GetByID retrieves an API key by ID, including revoked keys
+	GetByID(ctx context.Context, id uuid.UUID) (*APIKey, error)
+
 	// GetByUserID retrieves all API keys for a user
 	GetByUserID(ctx context.Context, userID uuid.UUID) ([]*APIKey, error)
 
+	// List retrieves API keys newest first, continuing after filter.After
+	List(ctx context.Context, filter APIKeyFilter) ([]*APIKey, error)
+
+	// Count returns the number of API keys matching the filter (ignores Limit/After)
+	Count(ctx context.Context, filter APIKeyFilter) (int, error)
+
 	// UpdateLastUsed updates the last_used_at timestamp
 	UpdateLastUsed(ctx context.Context, id uuid.UUID) error
 
 	// Revoke revokes an API key (soft delete)
 	Revoke(ctx context.Context, id uuid.UUID) error
 
+	// SetCanApprove grants or withdraws an active key's right to approve drafts
+	SetCanApprove(ctx context.Context, id uuid.UUID, canApprove bool) error
+
 	// Delete permanently deletes an API key
 	Delete(ctx context.Context, id uuid.UUID) error
 }
@@ -116,16 +317,29 @@ type PricingRuleFilter struct {
 	UserID       uuid.UUID
 	StrategyType string
 	IsActive     *bool
+	Schedule     string // ScheduleStatus* of the rule's effective window now
 	Limit        int
 	Offset       int
+	After        *Cursor
 }
 
 // ProductFilter defines filters for products
 type ProductFilter struct {
-	UserID   uuid.UUID
-	IsActive *bool
-	Limit    int
-	Offset   int
+	UserID     uuid.UUID
+	IsActive   *bool
+	Category   string            // metadata "category" value
+	CategoryID *uuid.UUID        // category tree node; products in its subcategories match too
+	Metadata   map[string]string // each key must hold the value, as a string or the number or boolean it spells
+	Limit      int
+	Offset     int
+	After      *Cursor
+}
+
+// APIKeyFilter defines filters for API keys
+type APIKeyFilter struct {
+	UserID uuid.UUID
+	Limit  int
+	After  *Cursor
 }
 
 // CalculationLogFilter defines filters for calculation logs
@@ -133,11 +347,19 @@ type CalculationLogFilter struct {
 	UserID       uuid.UUID
 	APIKeyID     *uuid.UUID
 	RuleID       *uuid.UUID
+	RevisionID   *uuid.UUID
 	StrategyType string
 	From         time.Time
 	To           time.Time
 	Limit        int
 	Offset       int
+	After        *Cursor
+
+	// Failed calculations are left out unless IncludeFailed is set
+	IncludeFailed bool
+
+	// Logs must match every input and output filter
+	Data []LogDataFilter
 }
 
 // Additional domain models
@@ -147,6 +369,16 @@ type User struct {
 	ID        uuid.UUID `json:"id"`
 	Email     string    `json:"email"`
 	CreatedAt time.Time `json:"created_at"`
+
+	// Account policy: rule drafts need approval from a different API key
+	RequireRuleApproval bool `json:"require_rule_approval"`
+
+	// Rule for products whose SKU and categories set none
+	DefaultRuleID *uuid.UUID `json:"default_rule_id,omitempty"`
+
+	// Days calculation logs are kept; nil uses the server default
+	LogRetentionDays *int `json:"log_retention_days,omitempty"`
+
 	UpdatedAt time.Time `json:"updated_at"`
 }
 
@@ -161,8 +393,17 @@ type APIKey struct {
 	IsActive   bool       `json:"is_active"`
 	CreatedAt  time.Time  `json:"created_at"`
 	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+
+	// Set only by an administrator; approves drafts under the approval policy
+	CanApprove bool `json:"can_approve"`
 }
 
+// ErrAPIKeyNotFound is returned when an API key does not exist or is revoked
+var ErrAPIKeyNotFound = errors.New("API key not found")
+
+// ErrProductNotFound is returned when a product does not exist or is deleted
+var ErrProductNotFound = errors.New("product not found")
+
 // CalculationStats represents aggregated calculation statistics
 type CalculationStats struct {
 	TotalCalculations int            `json:"total_calculations"`
@@ -173,3 +414,54 @@ type CalculationStats struct {
 	AvgPrice          float64        `json:"avg_price"`
 	Period            string         `json:"period"`
 }
+
+// CalculationLogPartitionRepository defines operations for the monthly
+// partitions of the calculation logs and their retention
+type CalculationLogPartitionRepository interface {
+	// CreatePartition creates the partition holding month, returning false if
+	// it already exists
+	CreatePartition(ctx context.Context, month time.Time) (bool, error)
+
+	// ListPartitions lists the attached partitions, oldest first
+	ListPartitions(ctx context.Context) ([]*CalculationLogPartition, error)
+
+	// ExportPartition calls fn with every log in a partition, oldest first
+	ExportPartition(ctx context.Context, name string, fn func(*CalculationLog) error) error
+
+	// DropPartition deletes a partition and its logs
+	DropPartition(ctx context.Context, name string) error
+
+	// DetachPartition removes a partition from the calculation logs, keeping
+	// it as a standalone table
+	DetachPartition(ctx context.Context, name string) error
+
+	// AccountRetention returns the retention in days of the accounts that set
+	// their own
+	AccountRetention(ctx context.Context) (map[uuid.UUID]int, error)
+
+	// DeleteUserLogsBefore deletes an account's logs created before a time
+	DeleteUserLogsBefore(ctx context.Context, userID uuid.UUID, before time.Time) (int64, error)
+
+	// DeleteLogsBefore deletes logs created before a time, except those of
+	// the given accounts
+	DeleteLogsBefore(ctx context.Context, before time.Time, except []uuid.UUID) (int64, error)
+}
+
+// IdempotencyRepository defines operations for stored Idempotency-Key responses
+type IdempotencyRepository interface {
+	// Reserve stores an in-flight record unless the key is already held.
+	// Expired records and in-flight records created before staleBefore are
+	// taken over. Returns the existing record and false if the key is held.
+	Reserve(ctx context.Context, record *IdempotencyRecord, now, staleBefore time.Time) (*IdempotencyRecord, bool, error)
+
+	// Complete stores the response of a reserved record, unless its
+	// reservation (record.Token) was taken over
+	Complete(ctx context.Context, record *IdempotencyRecord) error
+
+	// Release deletes an in-flight record so the request can be retried,
+	// unless its reservation (record.Token) was taken over
+	Release(ctx context.Context, record *IdempotencyRecord) error
+
+	// DeleteExpired deletes records that expired before now
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+}
@@ -0,0 +1,81 @@
+package domain
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Schedule statuses of a rule or assignment relative to a point in time
+const (
+	ScheduleStatusActive   = "active"
+	ScheduleStatusUpcoming = "upcoming"
+	ScheduleStatusExpired  = "expired"
+)
+
+// Schedule errors
+var (
+	ErrInvalidSchedule    = errors.New("effective_from must be before effective_to")
+	ErrScheduleOverlap    = errors.New("schedule overlaps an existing assignment")
+	ErrAssignmentNotFound = errors.New("rule assignment not found")
+	ErrRuleNotInEffect    = errors.New("pricing rule is not in effect")
+	ErrNoRuleInEffect     = errors.New("no pricing rule in effect")
+)
+
+// EffectiveWindow is a half-open time range [From, To); a nil bound is unbounded
+type EffectiveWindow struct {
+	From *time.Time `json:"effective_from,omitempty"`
+	To   *time.Time `json:"effective_to,omitempty"`
+}
+
+// Validate checks that the window is not empty
+func (w EffectiveWindow) Validate() error {
+	if w.From != nil && w.To != nil && !w.From.Before(*w.To) {
+		return ErrInvalidSchedule
+	}
+	return nil
+}
+
+// Contains reports whether at falls inside the window
+func (w EffectiveWindow) Contains(at time.Time) bool {
+	return w.Status(at) == ScheduleStatusActive
+}
+
+// Status reports whether the window is active, upcoming or expired at a point in time
+func (w EffectiveWindow) Status(at time.Time) string {
+	if w.From != nil && at.Before(*w.From) {
+		return ScheduleStatusUpcoming
+	}
+	if w.To != nil && !at.Before(*w.To) {
+		return ScheduleStatusExpired
+	}
+	return ScheduleStatusActive
+}
+
+// Overlaps reports whether two windows share any instant
+func (w EffectiveWindow) Overlaps(other EffectiveWindow) bool {
+	// Each window must start before the other one ends
+	if w.From != nil && other.To != nil && !w.From.Before(*other.To) {
+		return false
+	}
+	if other.From != nil && w.To != nil && !other.From.Before(*w.To) {
+		return false
+	}
+	return true
+}
+
+// ProductRuleAssignment schedules a pricing rule as a product's default
+type ProductRuleAssignment struct {
+	ID            uuid.UUID  `json:"id"`
+	ProductID     uuid.UUID  `json:"product_id"`
+	RuleID        uuid.UUID  `json:"rule_id"`
+	EffectiveFrom *time.Time `json:"effective_from,omitempty"`
+	EffectiveTo   *time.Time `json:"effective_to,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// Window returns the assignment's effective window
+func (a *ProductRuleAssignment) Window() EffectiveWindow {
+	return EffectiveWindow{From: a.EffectiveFrom, To: a.EffectiveTo}
+}
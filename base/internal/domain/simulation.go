@@ -0,0 +1,57 @@
+package domain
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DefaultMaxSimulationCells caps the input grid when no limit is configured
+const DefaultMaxSimulationCells = 1000
+
+// Simulation errors
+var (
+	ErrSimulationInvalid  = errors.New("simulation request is invalid")
+	ErrSimulationTooLarge = errors.New("simulation exceeds maximum cell count")
+)
+
+// SimulationAxis varies one input field across a list of values
+type SimulationAxis struct {
+	Field  string        `json:"field"`
+	Values []interface{} `json:"values"`
+}
+
+// SimulationRequest runs a strategy over the Cartesian product of its axes
+type SimulationRequest struct {
+	Strategy string                 `json:"strategy"`
+	RuleID   *uuid.UUID             `json:"rule_id,omitempty"`
+	Config   map[string]interface{} `json:"config"`
+
+	// Inputs shared by every cell; axis values override them
+	Inputs map[string]interface{} `json:"inputs,omitempty"`
+	Axes   []SimulationAxis       `json:"axes"`
+
+	// MaxCells limits the grid size (DefaultMaxSimulationCells if zero)
+	MaxCells    int       `json:"-"`
+	RequestedAt time.Time `json:"requested_at,omitempty"`
+}
+
+// SimulationCell is the result of one point in the input grid
+type SimulationCell struct {
+	Index      []int                  `json:"index"` // Position along each axis
+	Inputs     map[string]interface{} `json:"inputs"`
+	FinalPrice float64                `json:"final_price"`
+	Currency   string                 `json:"currency,omitempty"`
+	Breakdown  *PriceBreakdown        `json:"breakdown,omitempty"`
+	Error      string                 `json:"error,omitempty"`
+}
+
+// SimulationResult is the price matrix produced by a simulation
+type SimulationResult struct {
+	Strategy string           `json:"strategy"`
+	RuleID   *uuid.UUID       `json:"rule_id,omitempty"`
+	Axes     []SimulationAxis `json:"axes"`
+	Shape    []int            `json:"shape"` // Number of values along each axis
+	Cells    []SimulationCell `json:"cells"` // Row-major, last axis varies fastest
+}
@@ -0,0 +1,92 @@
+package domain
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// FieldError describes a single invalid value in a configuration
+type FieldError struct {
+	Path    string `json:"path"`    // JSON pointer (RFC 6901), e.g. /time_windows/0/multiplier
+	Message string `json:"message"` // Human-readable explanation
+}
+
+// ValidationError collects field-level errors for a strategy configuration
+type ValidationError struct {
+	Errors []FieldError `json:"errors"`
+}
+
+// Add records an error at the given JSON pointer path
+func (e *ValidationError) Add(path, format string, args ...interface{}) {
+	e.Errors = append(e.Errors, FieldError{
+		Path:    path,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// Merge records every error from other, prefixing its paths
+func (e *ValidationError) Merge(prefix string, other *ValidationError) {
+	if other == nil {
+		return
+	}
+	for _, fe := range other.Errors {
+		e.Errors = append(e.Errors, FieldError{Path: prefix + fe.Path, Message: fe.Message})
+	}
+}
+
+// HasErrors reports whether any errors were recorded
+func (e *ValidationError) HasErrors() bool {
+	return e != nil && len(e.Errors) > 0
+}
+
+// Err returns the collected errors as an error, or nil if there are none
+func (e *ValidationError) Err() error {
+	if !e.HasErrors() {
+		return nil
+	}
+	return e
+}
+
+// Error implements the error interface
+func (e *ValidationError) Error() string {
+	parts := make([]string, len(e.Errors))
+	for i, fe := range e.Errors {
+		if fe.Path == "" {
+			parts[i] = fe.Message
+		} else {
+			parts[i] = fe.Path + ": " + fe.Message
+		}
+	}
+	return ErrConfigurationInvalid.Error() + ": " + strings.Join(parts, "; ")
+}
+
+// Unwrap allows errors.Is(err, ErrConfigurationInvalid)
+func (e *ValidationError) Unwrap() error {
+	return ErrConfigurationInvalid
+}
+
+// NewValidationError creates a validation error with a single field error
+func NewValidationError(path, format string, args ...interface{}) *ValidationError {
+	ve := &ValidationError{}
+	ve.Add(path, format, args...)
+	return ve
+}
+
+// JSONPointer builds an RFC 6901 JSON pointer from object keys and array indexes
+func JSONPointer(tokens ...interface{}) string {
+	var sb strings.Builder
+	for _, token := range tokens {
+		sb.WriteByte('/')
+		switch t := token.(type) {
+		case int:
+			sb.WriteString(strconv.Itoa(t))
+		case string:
+			escaped := strings.ReplaceAll(t, "~", "~0")
+			sb.WriteString(strings.ReplaceAll(escaped, "/", "~1"))
+		default:
+			sb.WriteString(fmt.Sprint(t))
+		}
+	}
+	return sb.String()
+}
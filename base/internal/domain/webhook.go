@@ -0,0 +1,178 @@
+package domain
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Webhook event types. There is no quote.redeemed event: the API has no
+// quotes to redeem, so nothing could produce one.
+const (
+	EventRuleCreated          = "rule.created"
+	EventRuleUpdated          = "rule.updated" // a new revision was published
+	EventRuleDeleted          = "rule.deleted"
+	EventProductCostChanged   = "product.cost_changed"
+	EventPriceBookRegenerated = "price_book.regenerated"
+)
+
+// WebhookEventTypes lists every event type an endpoint can subscribe to
+var WebhookEventTypes = []string{
+	EventRuleCreated,
+	EventRuleUpdated,
+	EventRuleDeleted,
+	EventProductCostChanged,
+	EventPriceBookRegenerated,
+}
+
+// Webhook delivery states
+const (
+	WebhookDeliveryPending   = "pending" // waiting for its first attempt or a retry
+	WebhookDeliverySucceeded = "succeeded"
+	WebhookDeliveryFailed    = "failed" // every attempt failed; replay it to try again
+)
+
+// Webhook errors
+var (
+	ErrWebhookNotFound         = errors.New("webhook endpoint not found")
+	ErrWebhookDeliveryNotFound = errors.New("webhook delivery not found")
+	ErrInvalidWebhook          = errors.New("invalid webhook endpoint")
+)
+
+// WebhookEndpoint is a URL that receives a user's events
+type WebhookEndpoint struct {
+	ID          uuid.UUID `json:"id"`
+	UserID      uuid.UUID `json:"user_id"`
+	URL         string    `json:"url"`
+	Description string    `json:"description"`
+	Secret      string    `json:"-"`           // HMAC signing key
+	EventTypes  []string  `json:"event_types"` // empty subscribes to every type
+	IsActive    bool      `json:"is_active"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// Subscribes reports whether the endpoint receives events of the given type
+func (e *WebhookEndpoint) Subscribes(eventType string) bool {
+	if len(e.EventTypes) == 0 {
+		return true
+	}
+	for _, t := range e.EventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// IsWebhookEventType reports whether eventType is a known event type
+func IsWebhookEventType(eventType string) bool {
+	for _, t := range WebhookEventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookEvent is a change written to the outbox with the change itself, so
+// it is delivered exactly when the change commits
+type WebhookEvent struct {
+	ID           uuid.UUID              `json:"id"`
+	UserID       uuid.UUID              `json:"user_id"`
+	Type         string                 `json:"type"`
+	Payload      map[string]interface{} `json:"data"`
+	CreatedAt    time.Time              `json:"created_at"`
+	DispatchedAt *time.Time             `json:"dispatched_at,omitempty"`
+}
+
+// NewWebhookEvent creates an event of the given type for a user
+func NewWebhookEvent(userID uuid.UUID, eventType string, payload map[string]interface{}) *WebhookEvent {
+	return &WebhookEvent{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Type:      eventType,
+		Payload:   payload,
+		CreatedAt: time.Now(),
+	}
+}
+
+// RuleEvent describes a change to a pricing rule
+func RuleEvent(eventType string, rule *PricingRule) *WebhookEvent {
+	return NewWebhookEvent(rule.UserID, eventType, map[string]interface{}{
+		"rule_id":        rule.ID,
+		"name":           rule.Name,
+		"strategy_type":  rule.StrategyType,
+		"revision":       rule.Revision,
+		"is_active":      rule.IsActive,
+		"effective_from": rule.EffectiveFrom,
+		"effective_to":   rule.EffectiveTo,
+	})
+}
+
+// CostChangedEvent describes a cost recorded in a product's cost history
+func CostChangedEvent(userID uuid.UUID, sku string, cost *ProductCost) *WebhookEvent {
+	return NewWebhookEvent(userID, EventProductCostChanged, map[string]interface{}{
+		"product_id":     cost.ProductID,
+		"sku":            sku,
+		"cost_id":        cost.ID,
+		"cost":           cost.Cost,
+		"effective_from": cost.EffectiveFrom,
+		"source":         cost.Source,
+	})
+}
+
+// PriceBookRegeneratedEvent describes a completed price book generation
+func PriceBookRegeneratedEvent(book *PriceBook, full bool) *WebhookEvent {
+	return NewWebhookEvent(book.UserID, EventPriceBookRegenerated, map[string]interface{}{
+		"price_book_id": book.ID,
+		"name":          book.Name,
+		"generation":    book.Generation,
+		"generated_at":  book.GeneratedAt,
+		"full":          full,
+		"entry_count":   book.EntryCount,
+		"error_count":   book.ErrorCount,
+	})
+}
+
+// WebhookDelivery is one event sent to one endpoint, with its retry state
+type WebhookDelivery struct {
+	ID             uuid.UUID     `json:"id"`
+	EndpointID     uuid.UUID     `json:"endpoint_id"`
+	Event          *WebhookEvent `json:"event"`
+	Status         string        `json:"status"`
+	Attempts       int           `json:"attempts"`
+	NextAttemptAt  *time.Time    `json:"next_attempt_at,omitempty"`
+	LastAttemptAt  *time.Time    `json:"last_attempt_at,omitempty"`
+	ResponseStatus *int          `json:"response_status,omitempty"`
+	LastError      string        `json:"last_error,omitempty"`
+	ReplayOf       *uuid.UUID    `json:"replay_of,omitempty"` // the delivery this one replays
+	CreatedAt      time.Time     `json:"created_at"`
+	UpdatedAt      time.Time     `json:"updated_at"`
+
+	// Set on claimed deliveries for sending
+	URL    string `json:"-"`
+	Secret string `json:"-"`
+}
+
+// WebhookAttempt is one request sent for a delivery
+type WebhookAttempt struct {
+	ID             uuid.UUID `json:"id"`
+	DeliveryID     uuid.UUID `json:"delivery_id"`
+	Attempt        int       `json:"attempt"`
+	AttemptedAt    time.Time `json:"attempted_at"`
+	DurationMs     int       `json:"duration_ms"`
+	ResponseStatus *int      `json:"response_status,omitempty"`
+	ResponseBody   string    `json:"response_body,omitempty"` // truncated
+	Error          string    `json:"error,omitempty"`
+}
+
+// WebhookDeliveryFilter selects deliveries of an endpoint, newest first
+type WebhookDeliveryFilter struct {
+	EndpointID uuid.UUID
+	Status     string
+	EventType  string
+	Limit      int
+	Offset     int
+}
@@ -40,13 +40,25 @@ type CreateKeyResponse struct {
 	Warning   string    `json:"warning"`
 }
 
+// SetKeyApproverRequest grants or withdraws a key's right to approve rule drafts
+type SetKeyApproverRequest struct {
+	CanApprove *bool `json:"can_approve" binding:"required"`
+}
+
+// SetKeyApproverResponse reports a key's approval rights
+type SetKeyApproverResponse struct {
+	ID         uuid.UUID `json:"id"`
+	CanApprove bool      `json:"can_approve"`
+}
+
 // APIKeyResponse represents an API key (without the raw key)
 type APIKeyResponse struct {
-	ID        uuid.UUID  `json:"id"`
-	KeyPrefix string     `json:"key_prefix"`
-	Name      string     `json:"name"`
-	IsLive    bool       `json:"is_live"`
-	IsRevoked bool       `json:"is_revoked"`
-	LastUsed  *time.Time `json:"last_used,omitempty"`
-	CreatedAt time.Time  `json:"created_at"`
+	ID         uuid.UUID  `json:"id"`
+	KeyPrefix  string     `json:"key_prefix"`
+	Name       string     `json:"name"`
+	IsLive     bool       `json:"is_live"`
+	IsRevoked  bool       `json:"is_revoked"`
+	CanApprove bool       `json:"can_approve"` // Granted by an administrator
+	LastUsed   *time.Time `json:"last_used,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
 }
@@ -1,6 +1,7 @@
 package dto
 
 import (
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
@@ -10,19 +11,42 @@ import (
 
 // CalculatePriceRequest represents a pricing calculation request
 type CalculatePriceRequest struct {
-	StrategyType string                 `json:"strategy_type" binding:"required"`
+	RuleID       *uuid.UUID             `json:"rule_id,omitempty"` // Use a saved rule's strategy and config
+	StrategyType string                 `json:"strategy_type" binding:"required_without_all=RuleID ProductSKU"`
 	BasePrice    float64                `json:"base_price" binding:"required,gt=0"`
 	Quantity     int                    `json:"quantity" binding:"required,gt=0"`
 	Context      map[string]interface{} `json:"context,omitempty"`
-	ProductSKU   string                 `json:"product_sku,omitempty"`
+	ProductSKU   string                 `json:"product_sku,omitempty"`  // Without strategy_type, prices with the product's scheduled rule; base_cost defaults to its cost at requested_at
+	RequestedAt  *time.Time             `json:"requested_at,omitempty"` // Selects rules in effect at this time, defaults to now
 }
 
 // CalculatePriceResponse represents the pricing calculation result
 type CalculatePriceResponse struct {
-	FinalPrice   float64                `json:"final_price"`
-	StrategyType string                 `json:"strategy_type"`
-	Breakdown    map[string]interface{} `json:"breakdown"`
-	CalculatedAt time.Time              `json:"calculated_at"`
+	FinalPrice     float64                `json:"final_price"`
+	StrategyType   string                 `json:"strategy_type"`
+	RuleID         *uuid.UUID             `json:"rule_id,omitempty"`
+	RuleRevisionID *uuid.UUID             `json:"rule_revision_id,omitempty"`
+	RuleRevision   int                    `json:"rule_revision,omitempty"`
+	RuleSource     *RuleSourceResponse    `json:"rule_source,omitempty"`  // Where a saved rule came from
+	ProductCost    *AppliedCostResponse   `json:"product_cost,omitempty"` // The cost history entry used as base_cost
+	Breakdown      map[string]interface{} `json:"breakdown"`
+	RequestedAt    time.Time              `json:"requested_at"`
+	CalculatedAt   time.Time              `json:"calculated_at"`
+	LogID          uuid.UUID              `json:"log_id"` // The calculation log entry of this request
+}
+
+// RuleSourceResponse identifies where the rule of a calculation was configured
+type RuleSourceResponse struct {
+	Level string    `json:"level"` // request, schedule, product, category or account
+	ID    uuid.UUID `json:"id"`    // the rule, assignment, product, category or account that supplied it
+	Name  string    `json:"name,omitempty"`
+}
+
+// AppliedCostResponse identifies the product cost a calculation priced with
+type AppliedCostResponse struct {
+	ID            uuid.UUID `json:"id"`
+	Cost          float64   `json:"cost"`
+	EffectiveFrom time.Time `json:"effective_from"`
 }
 
 // --- Pricing Strategy DTOs ---
@@ -33,88 +57,744 @@ type PricingStrategyResponse struct {
 	Name           string   `json:"name"`
 	Description    string   `json:"description"`
 	RequiredFields []string `json:"required_fields"`
+	RequiredConfig []string `json:"required_config"`
+	SchemaURL      string   `json:"schema_url"`
+}
+
+// StrategySchemaResponse represents the JSON Schemas of a pricing strategy
+type StrategySchemaResponse struct {
+	Type         string                 `json:"type"`
+	Name         string                 `json:"name"`
+	Description  string                 `json:"description"`
+	ConfigSchema map[string]interface{} `json:"config_schema"`
+	InputSchema  map[string]interface{} `json:"input_schema"`
 }
 
 // --- Pricing Rule DTOs ---
 
 // CreatePricingRuleRequest represents a request to create a pricing rule
 type CreatePricingRuleRequest struct {
-	Name         string                 `json:"name" binding:"required"`
-	StrategyType string                 `json:"strategy_type" binding:"required"`
-	Config       map[string]interface{} `json:"config" binding:"required"`
-	IsActive     bool                   `json:"is_active"`
+	Name          string                 `json:"name" binding:"required"`
+	ExternalKey   string                 `json:"external_key,omitempty"` // Unique per account; matches rules in bundle imports
+	StrategyType  string                 `json:"strategy_type" binding:"required"`
+	Config        map[string]interface{} `json:"config" binding:"required"`
+	IsActive      bool                   `json:"is_active"`
+	EffectiveFrom *time.Time             `json:"effective_from,omitempty"` // Inclusive, unbounded when omitted
+	EffectiveTo   *time.Time             `json:"effective_to,omitempty"`   // Exclusive, open-ended when omitted
+	ChangeNote    string                 `json:"change_note,omitempty"`
 }
 
 // UpdatePricingRuleRequest represents a request to update a pricing rule
 type UpdatePricingRuleRequest struct {
-	Name         *string                `json:"name,omitempty"`
-	StrategyType *string                `json:"strategy_type,omitempty"`
-	Config       map[string]interface{} `json:"config,omitempty"`
-	IsActive     *bool                  `json:"is_active,omitempty"`
+	Name          *string                `json:"name,omitempty"`
+	StrategyType  *string                `json:"strategy_type,omitempty"`
+	Config        map[string]interface{} `json:"config,omitempty"`
+	IsActive      *bool                  `json:"is_active,omitempty"`
+	EffectiveFrom OptionalTime           `json:"effective_from"`        // null clears the bound
+	EffectiveTo   OptionalTime           `json:"effective_to"`          // null clears the bound
+	ChangeNote    string                 `json:"change_note,omitempty"` // Recorded on the revision the draft is published as
+}
+
+// OptionalTime distinguishes an omitted time from an explicit null in update requests
+type OptionalTime struct {
+	Set   bool
+	Value *time.Time
+}
+
+// UnmarshalJSON records that the field was present, leaving Value nil for null
+func (o *OptionalTime) UnmarshalJSON(data []byte) error {
+	o.Set = true
+	if string(data) == "null" {
+		o.Value = nil
+		return nil
+	}
+
+	var t time.Time
+	if err := json.Unmarshal(data, &t); err != nil {
+		return err
+	}
+	o.Value = &t
+	return nil
+}
+
+// OptionalUUID distinguishes an omitted ID from an explicit null in update requests
+type OptionalUUID struct {
+	Set   bool
+	Value *uuid.UUID
+}
+
+// UnmarshalJSON records that the field was present, leaving Value nil for null
+func (o *OptionalUUID) UnmarshalJSON(data []byte) error {
+	o.Set = true
+	if string(data) == "null" {
+		o.Value = nil
+		return nil
+	}
+
+	var id uuid.UUID
+	if err := json.Unmarshal(data, &id); err != nil {
+		return err
+	}
+	o.Value = &id
+	return nil
+}
+
+// OptionalInt distinguishes an omitted number from an explicit null in update requests
+type OptionalInt struct {
+	Set   bool
+	Value *int
+}
+
+// UnmarshalJSON records that the field was present, leaving Value nil for null
+func (o *OptionalInt) UnmarshalJSON(data []byte) error {
+	o.Set = true
+	if string(data) == "null" {
+		o.Value = nil
+		return nil
+	}
+
+	var n int
+	if err := json.Unmarshal(data, &n); err != nil {
+		return err
+	}
+	o.Value = &n
+	return nil
+}
+
+// RollbackPricingRuleRequest represents a request to restore an earlier revision
+type RollbackPricingRuleRequest struct {
+	Revision   int    `json:"revision" binding:"required,min=1"`
+	ChangeNote string `json:"change_note,omitempty"`
+}
+
+// ApproveRuleDraftRequest represents a request to publish a rule's pending draft
+type ApproveRuleDraftRequest struct {
+	DraftID *uuid.UUID `json:"draft_id,omitempty"` // Rejects the approval if the draft was edited since it was reviewed
+}
+
+// ValidatePricingRuleRequest represents a draft rule config to check without saving
+type ValidatePricingRuleRequest struct {
+	StrategyType string                 `json:"strategy_type" binding:"required"`
+	Config       map[string]interface{} `json:"config" binding:"required"`
+}
+
+// ValidatePricingRuleResponse reports that a draft rule config is valid
+type ValidatePricingRuleResponse struct {
+	Valid bool `json:"valid"`
+}
+
+// SimulationAxis is one input field and the values to try for it
+type SimulationAxis struct {
+	Field  string        `json:"field" binding:"required"`
+	Values []interface{} `json:"values" binding:"required,min=1"`
+}
+
+// SimulateRuleRequest represents a request to run a rule over an input grid
+type SimulateRuleRequest struct {
+	Inputs map[string]interface{} `json:"inputs,omitempty"` // Shared by every cell
+	Axes   []SimulationAxis       `json:"axes" binding:"required,min=1,dive"`
+}
+
+// SimulationCellResponse represents one point of a simulation grid
+type SimulationCellResponse struct {
+	Index      []int                  `json:"index"`
+	Inputs     map[string]interface{} `json:"inputs"`
+	FinalPrice float64                `json:"final_price"`
+	Currency   string                 `json:"currency,omitempty"`
+	Breakdown  interface{}            `json:"breakdown,omitempty"`
+	Error      string                 `json:"error,omitempty"`
+}
+
+// SimulateRuleResponse represents the price matrix of a rule simulation
+type SimulateRuleResponse struct {
+	RuleID       uuid.UUID                `json:"rule_id"`
+	StrategyType string                   `json:"strategy_type"`
+	Axes         []SimulationAxis         `json:"axes"`
+	Shape        []int                    `json:"shape"`
+	CellCount    int                      `json:"cell_count"`
+	Cells        []SimulationCellResponse `json:"cells"`
+}
+
+// CreateBacktestRequest represents a request to replay logs with a proposed config
+type CreateBacktestRequest struct {
+	RuleID       *uuid.UUID             `json:"rule_id,omitempty"`       // Replay logs produced by this rule
+	StrategyType string                 `json:"strategy_type,omitempty"` // Defaults to the rule's strategy
+	Config       map[string]interface{} `json:"config" binding:"required"`
+	StartDate    string                 `json:"start_date,omitempty"` // ISO 8601 format
+	EndDate      string                 `json:"end_date,omitempty"`   // ISO 8601 format, defaults to now
+}
+
+// BacktestResponse represents a backtest job and, once finished, its report
+type BacktestResponse struct {
+	ID          uuid.UUID   `json:"id"`
+	Status      string      `json:"status"`
+	Processed   int         `json:"processed"`
+	Total       int         `json:"total"`
+	Report      interface{} `json:"report,omitempty"`
+	Error       string      `json:"error,omitempty"`
+	EventsURL   string      `json:"events_url"`
+	CreatedAt   time.Time   `json:"created_at"`
+	StartedAt   *time.Time  `json:"started_at,omitempty"`
+	CompletedAt *time.Time  `json:"completed_at,omitempty"`
+}
+
+// RulesQueryParams represents query parameters for listing pricing rules
+type RulesQueryParams struct {
+	PageParams
+	Schedule string `form:"schedule"` // active, upcoming or expired
 }
 
 // PricingRuleResponse represents a pricing rule
 type PricingRuleResponse struct {
-	ID           uuid.UUID              `json:"id"`
-	UserID       uuid.UUID              `json:"user_id"`
-	Name         string                 `json:"name"`
-	StrategyType string                 `json:"strategy_type"`
-	Config       map[string]interface{} `json:"config"`
-	IsActive     bool                   `json:"is_active"`
-	CreatedAt    time.Time              `json:"created_at"`
-	UpdatedAt    time.Time              `json:"updated_at"`
+	ID             uuid.UUID              `json:"id"`
+	UserID         uuid.UUID              `json:"user_id"`
+	ExternalKey    string                 `json:"external_key,omitempty"`
+	Name           string                 `json:"name"`
+	Description    string                 `json:"description,omitempty"`
+	StrategyType   string                 `json:"strategy_type"`
+	Config         map[string]interface{} `json:"config"`
+	IsActive       bool                   `json:"is_active"`
+	Published      bool                   `json:"published"`
+	PublishedAt    *time.Time             `json:"published_at,omitempty"`
+	EffectiveFrom  *time.Time             `json:"effective_from,omitempty"`
+	EffectiveTo    *time.Time             `json:"effective_to,omitempty"`
+	ScheduleStatus string                 `json:"schedule_status"` // active, upcoming or expired
+	Revision       int                    `json:"revision"`
+	RevisionID     *uuid.UUID             `json:"revision_id,omitempty"`
+	ChangeNote     string                 `json:"change_note,omitempty"`
+	Draft          *RuleDraftResponse     `json:"draft,omitempty"`    // Pending edits awaiting approval
+	Approval       *RuleApprovalResponse  `json:"approval,omitempty"` // Who published the revision; set by draft approval
+	CreatedAt      time.Time              `json:"created_at"`
+	UpdatedAt      time.Time              `json:"updated_at"`
+}
+
+// RuleApprovalResponse identifies the caller who approved a draft
+type RuleApprovalResponse struct {
+	ApprovedBy      uuid.UUID  `json:"approved_by"`
+	ApprovedByKeyID *uuid.UUID `json:"approved_by_key_id,omitempty"`
+}
+
+// RuleDraftResponse represents unpublished edits of a pricing rule
+type RuleDraftResponse struct {
+	ID                uuid.UUID              `json:"id"`
+	RuleID            uuid.UUID              `json:"rule_id"`
+	BaseRevision      int                    `json:"base_revision"`
+	Name              string                 `json:"name"`
+	Description       string                 `json:"description,omitempty"`
+	StrategyType      string                 `json:"strategy_type"`
+	Config            map[string]interface{} `json:"config"`
+	IsActive          bool                   `json:"is_active"`
+	EffectiveFrom     *time.Time             `json:"effective_from,omitempty"`
+	EffectiveTo       *time.Time             `json:"effective_to,omitempty"`
+	AuthorID          *uuid.UUID             `json:"author_id,omitempty"`
+	AuthorKeyID       *uuid.UUID             `json:"author_key_id,omitempty"`
+	ChangeNote        string                 `json:"change_note,omitempty"`
+	Status            string                 `json:"status"`
+	ApprovedBy        *uuid.UUID             `json:"approved_by,omitempty"`
+	ApprovedByKeyID   *uuid.UUID             `json:"approved_by_key_id,omitempty"`
+	ApprovedAt        *time.Time             `json:"approved_at,omitempty"`
+	PublishedRevision int                    `json:"published_revision,omitempty"`
+	Changes           []JSONChangeResponse   `json:"changes,omitempty"` // Against the published rule
+	CreatedAt         time.Time              `json:"created_at"`
+	UpdatedAt         time.Time              `json:"updated_at"`
+}
+
+// RuleRevisionResponse represents an immutable pricing rule revision
+type RuleRevisionResponse struct {
+	ID            uuid.UUID              `json:"id"`
+	RuleID        uuid.UUID              `json:"rule_id"`
+	Revision      int                    `json:"revision"`
+	Name          string                 `json:"name"`
+	Description   string                 `json:"description,omitempty"`
+	StrategyType  string                 `json:"strategy_type"`
+	Config        map[string]interface{} `json:"config"`
+	IsActive      bool                   `json:"is_active"`
+	EffectiveFrom *time.Time             `json:"effective_from,omitempty"`
+	EffectiveTo   *time.Time             `json:"effective_to,omitempty"`
+	AuthorID      *uuid.UUID             `json:"author_id,omitempty"`
+	AuthorKeyID   *uuid.UUID             `json:"author_key_id,omitempty"`
+	ChangeNote    string                 `json:"change_note,omitempty"`
+	CreatedAt     time.Time              `json:"created_at"`
+}
+
+// JSONChangeResponse represents one structural difference between revisions
+type JSONChangeResponse struct {
+	Op   string      `json:"op"`
+	Path string      `json:"path"`
+	From interface{} `json:"from,omitempty"`
+	To   interface{} `json:"to,omitempty"`
+}
+
+// RuleDiffResponse represents the changes between two rule revisions
+type RuleDiffResponse struct {
+	RuleID  uuid.UUID            `json:"rule_id"`
+	From    int                  `json:"from"`
+	To      int                  `json:"to"`
+	Changes []JSONChangeResponse `json:"changes"`
+}
+
+// --- Bundle DTOs ---
+
+// RuleBundle is the portable JSON/YAML file produced by exports and read by imports
+type RuleBundle struct {
+	Version    int             `json:"version"`
+	ExportedAt time.Time       `json:"exported_at"`
+	Rules      []BundleRule    `json:"rules"`
+	Products   []BundleProduct `json:"products,omitempty"`
+}
+
+// BundleRule is a rule in a bundle; products refer to it by Key
+type BundleRule struct {
+	Key           string                 `json:"key"`
+	Name          string                 `json:"name"`
+	Description   string                 `json:"description,omitempty"`
+	StrategyType  string                 `json:"strategy_type"`
+	Config        map[string]interface{} `json:"config"`
+	IsActive      bool                   `json:"is_active"`
+	EffectiveFrom *time.Time             `json:"effective_from,omitempty"`
+	EffectiveTo   *time.Time             `json:"effective_to,omitempty"`
+}
+
+// BundleProduct is a product in a bundle with links to bundle rules
+type BundleProduct struct {
+	SKU         string                 `json:"sku"`
+	Name        string                 `json:"name"`
+	Description string                 `json:"description,omitempty"`
+	BaseCost    float64                `json:"base_cost"`
+	Metadata    map[string]interface{} `json:"metadata,omitempty"`
+	IsActive    bool                   `json:"is_active"`
+	DefaultRule string                 `json:"default_rule,omitempty"`
+	Schedule    []BundleAssignment     `json:"schedule,omitempty"`
+}
+
+// BundleAssignment schedules a bundle rule as a product's default
+type BundleAssignment struct {
+	Rule          string     `json:"rule"`
+	EffectiveFrom *time.Time `json:"effective_from,omitempty"`
+	EffectiveTo   *time.Time `json:"effective_to,omitempty"`
+}
+
+// ImportBundleQuery holds the options of a bundle import
+type ImportBundleQuery struct {
+	Format     string `form:"format"`   // json or yaml; defaults from Content-Type
+	DryRun     bool   `form:"dry_run"`  // report without applying
+	MatchBy    string `form:"match_by"` // key (default) or name
+	Mode       string `form:"mode"`     // create (default) or upsert
+	Publish    bool   `form:"publish"`  // publish instead of leaving drafts; refused under approval policy
+	ChangeNote string `form:"change_note"`
+}
+
+// --- Account DTOs ---
+
+// AccountPolicyResponse represents an account's rule publishing policy
+type AccountPolicyResponse struct {
+	UserID              uuid.UUID  `json:"user_id"`
+	RequireRuleApproval bool       `json:"require_rule_approval"`
+	DefaultRuleID       *uuid.UUID `json:"default_rule_id,omitempty"`
+	LogRetentionDays    *int       `json:"log_retention_days,omitempty"` // Omitted when the server default applies
+	UpdatedAt           time.Time  `json:"updated_at"`
+}
+
+// SetDefaultRuleRequest sets the rule for products whose SKU and categories set none
+type SetDefaultRuleRequest struct {
+	RuleID OptionalUUID `json:"rule_id"` // null clears the account default
+}
+
+// UpdateAccountPolicyRequest represents an administrator change to an account's
+// policy; omitted fields are left unchanged
+type UpdateAccountPolicyRequest struct {
+	RequireRuleApproval *bool       `json:"require_rule_approval"`
+	LogRetentionDays    OptionalInt `json:"log_retention_days"` // null restores the server default
 }
 
 // --- Product DTOs ---
 
 // CreateProductRequest represents a request to create a product
 type CreateProductRequest struct {
-	SKU         string  `json:"sku" binding:"required"`
-	Name        string  `json:"name" binding:"required"`
-	Description string  `json:"description,omitempty"`
-	BaseCost    float64 `json:"base_cost" binding:"required,gte=0"`
+	SKU           string                 `json:"sku" binding:"required"`
+	Name          string                 `json:"name" binding:"required"`
+	Description   string                 `json:"description,omitempty"`
+	BaseCost      *float64               `json:"base_cost" binding:"required,gte=0"`
+	DefaultRuleID *uuid.UUID             `json:"default_rule_id,omitempty"`
+	CategoryID    *uuid.UUID             `json:"category_id,omitempty"`
+	Metadata      map[string]interface{} `json:"metadata,omitempty"`
 }
 
 // UpdateProductRequest represents a request to update a product
 type UpdateProductRequest struct {
-	Name        *string  `json:"name,omitempty"`
-	Description *string  `json:"description,omitempty"`
-	BaseCost    *float64 `json:"base_cost,omitempty"`
-	IsActive    *bool    `json:"is_active,omitempty"`
+	Name          *string                `json:"name,omitempty"`
+	Description   *string                `json:"description,omitempty"`
+	BaseCost      *float64               `json:"base_cost,omitempty" binding:"omitempty,gte=0"`
+	DefaultRuleID OptionalUUID           `json:"default_rule_id"`    // null clears the default rule
+	CategoryID    OptionalUUID           `json:"category_id"`        // null removes the product from its category
+	Metadata      map[string]interface{} `json:"metadata,omitempty"` // Replaces the existing metadata
+	IsActive      *bool                  `json:"is_active,omitempty"`
+}
+
+// ProductsQueryParams represents query parameters for listing products.
+// Metadata filters are given as metadata[key]=value.
+type ProductsQueryParams struct {
+	PageParams
+	IsActive   *bool  `form:"is_active"`   // Defaults to active products
+	Category   string `form:"category"`    // metadata "category" value
+	CategoryID string `form:"category_id"` // category tree node, including subcategories
 }
 
 // ProductResponse represents a product
 type ProductResponse struct {
+	ID            uuid.UUID              `json:"id"`
+	UserID        uuid.UUID              `json:"user_id"`
+	SKU           string                 `json:"sku"`
+	Name          string                 `json:"name"`
+	Description   string                 `json:"description,omitempty"`
+	BaseCost      float64                `json:"base_cost"`
+	DefaultRuleID *uuid.UUID             `json:"default_rule_id,omitempty"`
+	CategoryID    *uuid.UUID             `json:"category_id,omitempty"`
+	Metadata      map[string]interface{} `json:"metadata"`
+	Attributes    map[string]interface{} `json:"attributes"` // metadata over the category tree's attribute defaults
+	IsActive      bool                   `json:"is_active"`
+	CreatedAt     time.Time              `json:"created_at"`
+	UpdatedAt     time.Time              `json:"updated_at"`
+}
+
+// ProductImportResponse represents a product import job and its row results so far
+type ProductImportResponse struct {
+	ID          uuid.UUID   `json:"id"`
+	Format      string      `json:"format"`
+	Status      string      `json:"status"`
+	Processed   int         `json:"processed"`
+	Total       int         `json:"total"` // known once the file is parsed
+	Result      interface{} `json:"result"`
+	Error       string      `json:"error,omitempty"`
+	CreatedAt   time.Time   `json:"created_at"`
+	StartedAt   *time.Time  `json:"started_at,omitempty"`
+	CompletedAt *time.Time  `json:"completed_at,omitempty"`
+	Volatile    bool        `json:"volatile"` // The job is lost when the server restarts, and an hour after it completes
+}
+
+// CreateRuleAssignmentRequest represents a request to schedule a product's default rule
+type CreateRuleAssignmentRequest struct {
+	RuleID        uuid.UUID  `json:"rule_id" binding:"required"`
+	EffectiveFrom *time.Time `json:"effective_from,omitempty"` // Inclusive, unbounded when omitted
+	EffectiveTo   *time.Time `json:"effective_to,omitempty"`   // Exclusive, open-ended when omitted
+}
+
+// RuleAssignmentResponse represents a scheduled default rule of a product
+type RuleAssignmentResponse struct {
+	ID             uuid.UUID  `json:"id"`
+	ProductID      uuid.UUID  `json:"product_id"`
+	RuleID         uuid.UUID  `json:"rule_id"`
+	EffectiveFrom  *time.Time `json:"effective_from,omitempty"`
+	EffectiveTo    *time.Time `json:"effective_to,omitempty"`
+	ScheduleStatus string     `json:"schedule_status"` // active, upcoming or expired
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// CreateProductCostRequest represents a request to record a product cost
+type CreateProductCostRequest struct {
+	Cost          *float64               `json:"cost" binding:"required,gte=0"`
+	EffectiveFrom *time.Time             `json:"effective_from,omitempty"` // Defaults to now
+	Source        string                 `json:"source,omitempty" binding:"omitempty,oneof=manual supplier import adjustment"`
+	Note          string                 `json:"note,omitempty" binding:"max=1000"`
+	Recompute     *RecomputePriceRequest `json:"recompute,omitempty"` // Report the price change this cost causes
+}
+
+// RecomputePriceRequest holds the inputs a cost change is priced with
+type RecomputePriceRequest struct {
+	Quantity int                    `json:"quantity,omitempty" binding:"gte=0"` // Defaults to 1
+	Context  map[string]interface{} `json:"context,omitempty"`
+}
+
+// ProductCostResponse represents an entry of a product's cost timeline
+type ProductCostResponse struct {
+	ID             uuid.UUID              `json:"id"`
+	ProductID      uuid.UUID              `json:"product_id"`
+	Cost           float64                `json:"cost"`
+	EffectiveFrom  time.Time              `json:"effective_from"`
+	EffectiveTo    *time.Time             `json:"effective_to,omitempty"` // Start of the next entry
+	Status         string                 `json:"status"`                 // active, upcoming or expired
+	Source         string                 `json:"source"`
+	Note           string                 `json:"note,omitempty"`
+	Change         *float64               `json:"change,omitempty"` // From the previous entry
+	ChangePercent  *float64               `json:"change_percent,omitempty"`
+	PreviousCostID *uuid.UUID             `json:"previous_cost_id,omitempty"`
+	CreatedBy      *uuid.UUID             `json:"created_by,omitempty"` // API key that recorded it
+	CreatedAt      time.Time              `json:"created_at"`
+	Recompute      *CostRecomputeResponse `json:"recompute,omitempty"`
+}
+
+// PriceAtCostResponse represents a product's price at one unit cost
+type PriceAtCostResponse struct {
+	Cost          float64 `json:"cost"`
+	FinalPrice    float64 `json:"final_price"`
+	Margin        float64 `json:"margin"`
+	MarginPercent float64 `json:"margin_percent"`
+}
+
+// CostRecomputeResponse compares a product's price before and after a cost change
+type CostRecomputeResponse struct {
+	ProductID   uuid.UUID            `json:"product_id"`
+	SKU         string               `json:"sku"`
+	CostID      uuid.UUID            `json:"cost_id"`
+	EffectiveAt time.Time            `json:"effective_at"`
+	RuleID      *uuid.UUID           `json:"rule_id,omitempty"`
+	RuleSource  *RuleSourceResponse  `json:"rule_source,omitempty"`
+	Before      *PriceAtCostResponse `json:"before,omitempty"` // Omitted for a product's first cost
+	After       *PriceAtCostResponse `json:"after,omitempty"`
+	PriceChange *float64             `json:"price_change,omitempty"`
+	Error       string               `json:"error,omitempty"` // Why the price could not be computed
+}
+
+// --- Category DTOs ---
+
+// CreateCategoryRequest represents a request to create a product category
+type CreateCategoryRequest struct {
+	Name          string                 `json:"name" binding:"required,max=255"`
+	Description   string                 `json:"description,omitempty"`
+	ParentID      *uuid.UUID             `json:"parent_id,omitempty"` // Omit for a top-level category
+	DefaultRuleID *uuid.UUID             `json:"default_rule_id,omitempty"`
+	Attributes    map[string]interface{} `json:"attributes,omitempty"`
+}
+
+// UpdateCategoryRequest represents a request to update or move a product category
+type UpdateCategoryRequest struct {
+	Name          *string                `json:"name,omitempty" binding:"omitempty,min=1,max=255"`
+	Description   *string                `json:"description,omitempty"`
+	ParentID      OptionalUUID           `json:"parent_id"`            // null moves the category to the top level
+	DefaultRuleID OptionalUUID           `json:"default_rule_id"`      // null clears the default rule
+	Attributes    map[string]interface{} `json:"attributes,omitempty"` // Replaces the existing attributes
+}
+
+// CategoryResponse represents a product category and the values it inherits
+type CategoryResponse struct {
+	ID                     uuid.UUID              `json:"id"`
+	ParentID               *uuid.UUID             `json:"parent_id,omitempty"`
+	Name                   string                 `json:"name"`
+	Description            string                 `json:"description,omitempty"`
+	Path                   []string               `json:"path"` // names from the top-level category down
+	DefaultRuleID          *uuid.UUID             `json:"default_rule_id,omitempty"`
+	Attributes             map[string]interface{} `json:"attributes"`
+	EffectiveDefaultRuleID *uuid.UUID             `json:"effective_default_rule_id,omitempty"` // own or nearest ancestor's
+	DefaultRuleCategoryID  *uuid.UUID             `json:"default_rule_category_id,omitempty"`  // category that set it
+	EffectiveAttributes    map[string]interface{} `json:"effective_attributes"`
+	CreatedAt              time.Time              `json:"created_at"`
+	UpdatedAt              time.Time              `json:"updated_at"`
+}
+
+// --- Price Book DTOs ---
+
+// PriceBookDimensions lists the values every product of a price book is priced at
+type PriceBookDimensions struct {
+	Locations  []string `json:"locations,omitempty" binding:"omitempty,dive,min=1,max=100"`
+	Currencies []string `json:"currencies,omitempty" binding:"omitempty,dive,min=1,max=10"`
+	Channels   []string `json:"channels,omitempty" binding:"omitempty,dive,min=1,max=100"`
+}
+
+// CreatePriceBookRequest represents a request to create a price book
+type CreatePriceBookRequest struct {
+	Name           string                 `json:"name" binding:"required,max=255"`
+	Description    string                 `json:"description,omitempty"`
+	Dimensions     PriceBookDimensions    `json:"dimensions"`
+	Quantity       int                    `json:"quantity,omitempty" binding:"omitempty,gte=1"` // Defaults to 1
+	Context        map[string]interface{} `json:"context,omitempty"`                            // Extra inputs for every calculation
+	AutoRegenerate *bool                  `json:"auto_regenerate,omitempty"`                    // Defaults to true
+}
+
+// UpdatePriceBookRequest represents a request to redefine a price book
+type UpdatePriceBookRequest struct {
+	Name           *string                `json:"name,omitempty" binding:"omitempty,min=1,max=255"`
+	Description    *string                `json:"description,omitempty"`
+	Dimensions     *PriceBookDimensions   `json:"dimensions,omitempty"`
+	Quantity       *int                   `json:"quantity,omitempty" binding:"omitempty,gte=1"`
+	Context        map[string]interface{} `json:"context,omitempty"` // Replaces the existing context
+	AutoRegenerate *bool                  `json:"auto_regenerate,omitempty"`
+}
+
+// PriceBookRevisionResponse represents a rule revision a price book was priced with
+type PriceBookRevisionResponse struct {
+	RuleID     uuid.UUID  `json:"rule_id"`
+	RevisionID *uuid.UUID `json:"revision_id,omitempty"`
+	Revision   int        `json:"revision"`
+	Entries    int        `json:"entries"`
+}
+
+// PriceBookResponse represents a price book and the state of its generation
+type PriceBookResponse struct {
+	ID             uuid.UUID                   `json:"id"`
+	Name           string                      `json:"name"`
+	Description    string                      `json:"description,omitempty"`
+	Dimensions     PriceBookDimensions         `json:"dimensions"`
+	Quantity       int                         `json:"quantity"`
+	Context        map[string]interface{}      `json:"context"`
+	AutoRegenerate bool                        `json:"auto_regenerate"`
+	Version        int                         `json:"version"`
+	Status         string                      `json:"status"` // pending, generating, ready or failed
+	Generation     int                         `json:"generation"`
+	GeneratedAt    *time.Time                  `json:"generated_at,omitempty"`
+	StartedAt      *time.Time                  `json:"started_at,omitempty"`
+	RuleRevisions  []PriceBookRevisionResponse `json:"rule_revisions"`
+	EntryCount     int                         `json:"entry_count"`
+	ErrorCount     int                         `json:"error_count"`
+	LastError      string                      `json:"last_error,omitempty"`
+	CreatedAt      time.Time                   `json:"created_at"`
+	UpdatedAt      time.Time                   `json:"updated_at"`
+}
+
+// PriceBookEntriesQueryParams represents query parameters for reading price book entries
+type PriceBookEntriesQueryParams struct {
+	Limit    int     `form:"limit" binding:"omitempty,min=1,max=100"`
+	Offset   int     `form:"offset" binding:"omitempty,min=0"`
+	SKU      string  `form:"sku"`
+	Location *string `form:"location"`
+	Currency *string `form:"currency"`
+	Channel  *string `form:"channel"`
+}
+
+// PriceBookEntryResponse represents the price of one product at one cell of a price book
+type PriceBookEntryResponse struct {
+	ProductID      uuid.UUID  `json:"product_id"`
+	SKU            string     `json:"sku"`
+	Location       string     `json:"location,omitempty"`
+	Currency       string     `json:"currency,omitempty"`
+	Channel        string     `json:"channel,omitempty"`
+	FinalPrice     *float64   `json:"final_price,omitempty"`
+	PriceCurrency  string     `json:"price_currency,omitempty"`
+	BaseCost       float64    `json:"base_cost"`
+	StrategyType   string     `json:"strategy_type,omitempty"`
+	RuleID         *uuid.UUID `json:"rule_id,omitempty"`
+	RuleRevisionID *uuid.UUID `json:"rule_revision_id,omitempty"`
+	RuleRevision   int        `json:"rule_revision,omitempty"`
+	RuleSource     string     `json:"rule_source,omitempty"`
+	Error          string     `json:"error,omitempty"` // Why the product could not be priced
+	GeneratedAt    time.Time  `json:"generated_at"`
+}
+
+// --- Webhook DTOs ---
+
+// CreateWebhookRequest represents a request to register a webhook endpoint
+type CreateWebhookRequest struct {
+	URL         string   `json:"url" binding:"required,url,max=2048"`
+	Description string   `json:"description,omitempty" binding:"max=255"`
+	EventTypes  []string `json:"event_types,omitempty"` // Empty subscribes to every event type
+	IsActive    *bool    `json:"is_active,omitempty"`   // Defaults to true
+}
+
+// UpdateWebhookRequest represents a request to update a webhook endpoint
+type UpdateWebhookRequest struct {
+	URL         *string  `json:"url,omitempty" binding:"omitempty,url,max=2048"`
+	Description *string  `json:"description,omitempty" binding:"omitempty,max=255"`
+	EventTypes  []string `json:"event_types,omitempty"` // Replaces the existing subscriptions
+	IsActive    *bool    `json:"is_active,omitempty"`
+}
+
+// WebhookEndpointResponse represents a webhook endpoint
+type WebhookEndpointResponse struct {
 	ID          uuid.UUID `json:"id"`
-	UserID      uuid.UUID `json:"user_id"`
-	SKU         string    `json:"sku"`
-	Name        string    `json:"name"`
+	URL         string    `json:"url"`
 	Description string    `json:"description,omitempty"`
-	BaseCost    float64   `json:"base_cost"`
+	EventTypes  []string  `json:"event_types"`
 	IsActive    bool      `json:"is_active"`
+	Secret      string    `json:"secret,omitempty"` // Only returned when created or rotated
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
 }
 
+// WebhookDeliveriesQueryParams represents query parameters for listing deliveries
+type WebhookDeliveriesQueryParams struct {
+	Limit     int    `form:"limit" binding:"omitempty,min=1,max=100"`
+	Offset    int    `form:"offset" binding:"omitempty,min=0"`
+	Status    string `form:"status" binding:"omitempty,oneof=pending succeeded failed"`
+	EventType string `form:"event_type"`
+}
+
+// WebhookDeliveryResponse represents one event sent to an endpoint
+type WebhookDeliveryResponse struct {
+	ID             uuid.UUID                `json:"id"`
+	EndpointID     uuid.UUID                `json:"endpoint_id"`
+	EventID        uuid.UUID                `json:"event_id"`
+	EventType      string                   `json:"event_type"`
+	Payload        map[string]interface{}   `json:"payload"`
+	Status         string                   `json:"status"` // pending, succeeded or failed
+	Attempts       int                      `json:"attempts"`
+	NextAttemptAt  *time.Time               `json:"next_attempt_at,omitempty"`
+	LastAttemptAt  *time.Time               `json:"last_attempt_at,omitempty"`
+	ResponseStatus *int                     `json:"response_status,omitempty"`
+	LastError      string                   `json:"last_error,omitempty"`
+	ReplayOf       *uuid.UUID               `json:"replay_of,omitempty"`
+	AttemptLog     []WebhookAttemptResponse `json:"attempt_log,omitempty"` // Only returned for a single delivery
+	CreatedAt      time.Time                `json:"created_at"`
+	UpdatedAt      time.Time                `json:"updated_at"`
+}
+
+// WebhookAttemptResponse represents one request sent for a delivery
+type WebhookAttemptResponse struct {
+	Attempt        int       `json:"attempt"`
+	AttemptedAt    time.Time `json:"attempted_at"`
+	DurationMs     int       `json:"duration_ms"`
+	ResponseStatus *int      `json:"response_status,omitempty"`
+	ResponseBody   string    `json:"response_body,omitempty"` // Truncated
+	Error          string    `json:"error,omitempty"`
+}
+
 // --- Calculation Log DTOs ---
 
 // CalculationLogResponse represents a calculation log entry
 type CalculationLogResponse struct {
-	ID           uuid.UUID              `json:"id"`
-	UserID       uuid.UUID              `json:"user_id"`
-	StrategyType string                 `json:"strategy_type"`
-	Input        map[string]interface{} `json:"input"`
-	Output       map[string]interface{} `json:"output"`
-	CreatedAt    time.Time              `json:"created_at"`
+	ID              uuid.UUID              `json:"id"`
+	UserID          uuid.UUID              `json:"user_id"`
+	APIKeyID        *uuid.UUID             `json:"api_key_id,omitempty"`
+	RuleID          *uuid.UUID             `json:"rule_id,omitempty"`
+	RuleRevisionID  *uuid.UUID             `json:"rule_revision_id,omitempty"`
+	RuleRevision    int                    `json:"rule_revision,omitempty"`
+	StrategyType    string                 `json:"strategy_type"`
+	Input           map[string]interface{} `json:"input"`
+	Output          map[string]interface{} `json:"output"`
+	ExecutionTimeMs float64                `json:"execution_time_ms"`
+	RequestID       string                 `json:"request_id,omitempty"`
+	ClientIP        string                 `json:"client_ip,omitempty"`
+	EngineVersion   string                 `json:"engine_version,omitempty"`
+	Error           string                 `json:"error,omitempty"`
+	CreatedAt       time.Time              `json:"created_at"`
+}
+
+// LogReplayResponse represents a logged calculation re-run and compared with its output
+type LogReplayResponse struct {
+	LogID                 uuid.UUID              `json:"log_id"`
+	RuleID                *uuid.UUID             `json:"rule_id,omitempty"`
+	RuleRevision          int                    `json:"rule_revision,omitempty"`
+	StrategyType          string                 `json:"strategy_type"`
+	RequestedAt           time.Time              `json:"requested_at"`
+	OriginalEngineVersion string                 `json:"original_engine_version,omitempty"`
+	EngineVersion         string                 `json:"engine_version"`
+	OriginalPrice         *float64               `json:"original_price,omitempty"`
+	ReplayedPrice         *float64               `json:"replayed_price,omitempty"`
+	OriginalError         string                 `json:"original_error,omitempty"`
+	ReplayedError         string                 `json:"replayed_error,omitempty"`
+	Drift                 bool                   `json:"drift"`
+	Reasons               []string               `json:"reasons"`
+	Changes               []JSONChangeResponse   `json:"changes"` // From the logged output to the replayed one
+	OriginalOutput        map[string]interface{} `json:"original_output"`
+	ReplayedAt            time.Time              `json:"replayed_at"`
 }
 
 // LogsQueryParams represents query parameters for fetching logs
 type LogsQueryParams struct {
-	Limit        int    `form:"limit" binding:"omitempty,min=1,max=100"`
-	Offset       int    `form:"offset" binding:"omitempty,min=0"`
-	StrategyType string `form:"strategy_type"`
-	StartDate    string `form:"start_date"` // ISO 8601 format
-	EndDate      string `form:"end_date"`   // ISO 8601 format
+	PageParams
+	StrategyType string   `form:"strategy_type"`
+	StartDate    string   `form:"start_date"` // ISO 8601 format
+	EndDate      string   `form:"end_date"`   // ISO 8601 format
+	Filters      []string `form:"filter"`     // <input|output>.<path>:<op>[:<value>]
+}
+
+// LogExportQueryParams represents query parameters for exporting logs
+type LogExportQueryParams struct {
+	Format        string   `form:"format"`  // csv (default) or ndjson
+	Columns       string   `form:"columns"` // comma-separated; input.<path> and output.<path> flatten JSON values
+	StrategyType  string   `form:"strategy_type"`
+	StartDate     string   `form:"start_date"` // ISO 8601 format
+	EndDate       string   `form:"end_date"`   // ISO 8601 format
+	IncludeFailed bool     `form:"include_failed"`
+	Filters       []string `form:"filter"` // as for LogsQueryParams
 }
 
 // PaginatedResponse wraps paginated results
@@ -125,3 +805,54 @@ type PaginatedResponse struct {
 	Offset  int         `json:"offset"`
 	HasMore bool        `json:"has_more"`
 }
+
+// PageParams represents query parameters for listings paginated by cursor
+type PageParams struct {
+	Limit        int    `form:"limit" binding:"omitempty,min=1,max=100"`
+	Cursor       string `form:"cursor"`        // next_cursor of the previous page
+	IncludeTotal bool   `form:"include_total"` // count every match; costs a query
+}
+
+// CursorPageResponse wraps a page of results ordered newest first
+type CursorPageResponse struct {
+	Data       interface{} `json:"data"`
+	Limit      int         `json:"limit"`
+	HasMore    bool        `json:"has_more"`
+	NextCursor *string     `json:"next_cursor"`     // null on the last page
+	Total      *int        `json:"total,omitempty"` // only with include_total=true
+}
+
+// --- Analytics DTOs ---
+
+// AnalyticsQueryParams represents query parameters for calculation analytics
+type AnalyticsQueryParams struct {
+	From    string `form:"from"` // RFC3339; defaults to a range suiting the bucket
+	To      string `form:"to"`   // RFC3339, exclusive; defaults to now
+	Bucket  string `form:"bucket" binding:"omitempty,oneof=hour day week"`
+	GroupBy string `form:"group_by" binding:"omitempty,oneof=strategy rule api_key location product_sku"`
+}
+
+// AnalyticsResponse represents calculation statistics per time bucket
+type AnalyticsResponse struct {
+	From    time.Time                `json:"from"`
+	To      time.Time                `json:"to"`
+	Bucket  string                   `json:"bucket"`
+	GroupBy string                   `json:"group_by,omitempty"`
+	Points  []AnalyticsPointResponse `json:"points"`
+}
+
+// AnalyticsPointResponse represents the calculations of one bucket and group.
+// Prices cover successful calculations and are null when there are none.
+type AnalyticsPointResponse struct {
+	BucketStart        time.Time `json:"bucket_start"`
+	Group              *string   `json:"group,omitempty"` // omitted when the logs have no value for the grouping
+	Count              int       `json:"count"`
+	Errors             int       `json:"errors"`
+	ErrorRate          float64   `json:"error_rate"`
+	AvgPrice           *float64  `json:"avg_price"`
+	MinPrice           *float64  `json:"min_price"`
+	MaxPrice           *float64  `json:"max_price"`
+	P50Price           *float64  `json:"p50_price"`
+	P95Price           *float64  `json:"p95_price"`
+	AvgExecutionTimeMs float64   `json:"avg_execution_time_ms"`
+}
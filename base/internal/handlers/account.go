@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/saintparish4/harmonia/internal/dto"
+)
+
+// AccountPolicy represents an account's rule publishing policy, default rule
+// and log retention
+type AccountPolicy struct {
+	UserID              uuid.UUID
+	RequireRuleApproval bool
+	DefaultRuleID       *uuid.UUID // Applies when neither the SKU nor its categories set a rule
+	LogRetentionDays    *int       // Days calculation logs are kept; nil uses the server default
+	UpdatedAt           time.Time
+}
+
+// maxLogRetentionDays bounds an account's log retention at about a century
+const maxLogRetentionDays = 36500
+
+// AccountPolicyStore defines operations for account policies
+type AccountPolicyStore interface {
+	GetPolicy(ctx context.Context, userID uuid.UUID) (*AccountPolicy, error)
+	SetPolicy(ctx context.Context, policy *AccountPolicy) error
+}
+
+// AccountHandler handles account policy endpoints
+type AccountHandler struct {
+	store AccountPolicyStore
+	rules PricingRuleRepository
+}
+
+// NewAccountHandler creates a new account handler
+func NewAccountHandler(store AccountPolicyStore, rules PricingRuleRepository) *AccountHandler {
+	return &AccountHandler{store: store, rules: rules}
+}
+
+// GetPolicy handles GET /v1/account/policy
+func (h *AccountHandler) GetPolicy(c *gin.Context) {
+	// Get user ID from context
+	userID := MustGetUserID(c)
+	if userID == uuid.Nil {
+		return
+	}
+
+	policy, err := h.store.GetPolicy(c.Request.Context(), userID)
+	if err != nil {
+		NotFound(c, "Account not found")
+		return
+	}
+
+	Success(c, policyResponse(policy))
+}
+
+// SetDefaultRule handles PUT /v1/account/default-rule
+// The account default is the last step of rule resolution, after the SKU and
+// its categories; a null rule_id clears it.
+func (h *AccountHandler) SetDefaultRule(c *gin.Context) {
+	// Get user ID from context
+	userID := MustGetUserID(c)
+	if userID == uuid.Nil {
+		return
+	}
+
+	// Bind request
+	var req dto.SetDefaultRuleRequest
+	if !BindJSON(c, &req) {
+		return
+	}
+	if !req.RuleID.Set {
+		BadRequestWithDetails(c, "Invalid default rule", map[string]string{
+			"/rule_id": "required; use null to clear the account default",
+		})
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	if req.RuleID.Value != nil && !checkOwnedRule(c, h.rules, userID, *req.RuleID.Value, "/rule_id") {
+		return
+	}
+
+	policy, err := h.store.GetPolicy(ctx, userID)
+	if err != nil {
+		NotFound(c, "Account not found")
+		return
+	}
+
+	policy.DefaultRuleID = req.RuleID.Value
+	if err := h.store.SetPolicy(ctx, policy); err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	Success(c, policyResponse(policy))
+}
+
+// SetPolicy handles PUT /v1/admin/users/:id/policy
+// Only administrators can change whether an account requires rule approval
+// and how long its calculation logs are kept.
+func (h *AccountHandler) SetPolicy(c *gin.Context) {
+	// Validate user ID
+	userID, err := ValidateUUID(c, "id")
+	if err != nil {
+		BadRequest(c, "Invalid user ID")
+		return
+	}
+
+	// Bind request
+	var req dto.UpdateAccountPolicyRequest
+	if !BindJSON(c, &req) {
+		return
+	}
+	if req.RequireRuleApproval == nil && !req.LogRetentionDays.Set {
+		BadRequest(c, "Set require_rule_approval or log_retention_days")
+		return
+	}
+	if req.LogRetentionDays.Value != nil && (*req.LogRetentionDays.Value < 1 || *req.LogRetentionDays.Value > maxLogRetentionDays) {
+		BadRequestWithDetails(c, "Invalid account policy", map[string]string{
+			"/log_retention_days": fmt.Sprintf("must be between 1 and %d, or null for the server default", maxLogRetentionDays),
+		})
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	policy, err := h.store.GetPolicy(ctx, userID)
+	if err != nil {
+		NotFound(c, "Account not found")
+		return
+	}
+
+	if req.RequireRuleApproval != nil {
+		policy.RequireRuleApproval = *req.RequireRuleApproval
+	}
+	if req.LogRetentionDays.Set {
+		policy.LogRetentionDays = req.LogRetentionDays.Value
+	}
+	if err := h.store.SetPolicy(ctx, policy); err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	Success(c, policyResponse(policy))
+}
+
+// policyResponse converts an account policy to its response DTO
+func policyResponse(policy *AccountPolicy) dto.AccountPolicyResponse {
+	return dto.AccountPolicyResponse{
+		UserID:              policy.UserID,
+		RequireRuleApproval: policy.RequireRuleApproval,
+		DefaultRuleID:       policy.DefaultRuleID,
+		LogRetentionDays:    policy.LogRetentionDays,
+		UpdatedAt:           policy.UpdatedAt,
+	}
+}
@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/saintparish4/harmonia/internal/dto"
+)
+
+// maxAnalyticsBuckets bounds the time buckets of one analytics query
+const maxAnalyticsBuckets = 1000
+
+// analyticsBuckets are the supported bucket sizes and the range shown when
+// from is omitted
+var analyticsBuckets = map[string]struct {
+	size         time.Duration
+	defaultRange time.Duration
+}{
+	"hour": {size: time.Hour, defaultRange: 24 * time.Hour},
+	"day":  {size: 24 * time.Hour, defaultRange: 30 * 24 * time.Hour},
+	"week": {size: 7 * 24 * time.Hour, defaultRange: 12 * 7 * 24 * time.Hour},
+}
+
+// AnalyticsQuery selects an account's calculations for analytics
+type AnalyticsQuery struct {
+	UserID  uuid.UUID
+	From    time.Time
+	To      time.Time
+	Bucket  string
+	GroupBy string
+}
+
+// AnalyticsPoint represents the calculations of one time bucket and group
+type AnalyticsPoint struct {
+	BucketStart        time.Time
+	Group              *string
+	Count              int
+	Errors             int
+	ErrorRate          float64
+	AvgPrice           *float64
+	MinPrice           *float64
+	MaxPrice           *float64
+	P50Price           *float64
+	P95Price           *float64
+	AvgExecutionTimeMs float64
+}
+
+// AnalyticsRepository defines operations for calculation analytics
+type AnalyticsRepository interface {
+	Analytics(ctx context.Context, query AnalyticsQuery) ([]*AnalyticsPoint, error)
+}
+
+// AnalyticsHandler handles calculation analytics endpoints
+type AnalyticsHandler struct {
+	repo AnalyticsRepository
+}
+
+// NewAnalyticsHandler creates a new analytics handler
+func NewAnalyticsHandler(repo AnalyticsRepository) *AnalyticsHandler {
+	return &AnalyticsHandler{repo: repo}
+}
+
+// Get handles GET /v1/analytics
+// Calculations from from up to to are counted per hour, day or week, and
+// optionally per strategy, rule, API key, location or product SKU.
+func (h *AnalyticsHandler) Get(c *gin.Context) {
+	// Get user ID from context
+	userID := MustGetUserID(c)
+	if userID == uuid.Nil {
+		return
+	}
+
+	// Bind query parameters
+	var params dto.AnalyticsQueryParams
+	if err := c.ShouldBindQuery(&params); err != nil {
+		BadRequest(c, err.Error())
+		return
+	}
+	if params.Bucket == "" {
+		params.Bucket = "day"
+	}
+	bucket := analyticsBuckets[params.Bucket]
+
+	to := time.Now().UTC()
+	if params.To != "" {
+		t, err := time.Parse(time.RFC3339, params.To)
+		if err != nil {
+			BadRequest(c, "Invalid to format. Use ISO 8601 (RFC3339)")
+			return
+		}
+		to = t.UTC()
+	}
+
+	from := to.Add(-bucket.defaultRange)
+	if params.From != "" {
+		t, err := time.Parse(time.RFC3339, params.From)
+		if err != nil {
+			BadRequest(c, "Invalid from format. Use ISO 8601 (RFC3339)")
+			return
+		}
+		from = t.UTC()
+	}
+
+	if !from.Before(to) {
+		BadRequest(c, "from must be before to")
+		return
+	}
+	if to.Sub(from) > bucket.size*maxAnalyticsBuckets {
+		BadRequest(c, fmt.Sprintf("The range covers more than %d %s buckets; use a larger bucket or a shorter range", maxAnalyticsBuckets, params.Bucket))
+		return
+	}
+
+	points, err := h.repo.Analytics(c.Request.Context(), AnalyticsQuery{
+		UserID:  userID,
+		From:    from,
+		To:      to,
+		Bucket:  params.Bucket,
+		GroupBy: params.GroupBy,
+	})
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	response := dto.AnalyticsResponse{
+		From:    from,
+		To:      to,
+		Bucket:  params.Bucket,
+		GroupBy: params.GroupBy,
+		Points:  make([]dto.AnalyticsPointResponse, len(points)),
+	}
+	for i, point := range points {
+		response.Points[i] = dto.AnalyticsPointResponse{
+			BucketStart:        point.BucketStart,
+			Group:              point.Group,
+			Count:              point.Count,
+			Errors:             point.Errors,
+			ErrorRate:          point.ErrorRate,
+			AvgPrice:           point.AvgPrice,
+			MinPrice:           point.MinPrice,
+			MaxPrice:           point.MaxPrice,
+			P50Price:           point.P50Price,
+			P95Price:           point.P95Price,
+			AvgExecutionTimeMs: point.AvgExecutionTimeMs,
+		}
+	}
+
+	Success(c, response)
+}
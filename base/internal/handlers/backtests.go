@@ -0,0 +1,254 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/saintparish4/harmonia/internal/dto"
+)
+
+const (
+	// backtestHeartbeat keeps idle event streams open through proxies
+	backtestHeartbeat = 15 * time.Second
+
+	// backtestWriteTimeout replaces the server write timeout for each streamed event
+	backtestWriteTimeout = 30 * time.Second
+)
+
+// ErrBacktestBusy is returned by BacktestRunner.Start when too many backtests are running
+var ErrBacktestBusy = errors.New("too many backtests are running")
+
+// BacktestRequest represents a proposed config to replay against logged calculations
+type BacktestRequest struct {
+	UserID       uuid.UUID
+	RuleID       *uuid.UUID
+	StrategyType string
+	Config       map[string]interface{}
+	From         time.Time
+	To           time.Time
+}
+
+// Backtest represents a backtest job
+type Backtest struct {
+	ID          uuid.UUID
+	Status      string
+	Done        bool
+	Processed   int
+	Total       int
+	Report      interface{}
+	Error       string
+	CreatedAt   time.Time
+	StartedAt   *time.Time
+	CompletedAt *time.Time
+}
+
+// BacktestRunner runs backtests, in the background for large ranges
+type BacktestRunner interface {
+	Start(ctx context.Context, req *BacktestRequest) (*Backtest, error)
+	Get(ctx context.Context, userID, id uuid.UUID) (*Backtest, error)
+	// Watch streams job snapshots until the job finishes or ctx ends
+	Watch(ctx context.Context, userID, id uuid.UUID) (<-chan *Backtest, error)
+}
+
+// BacktestsHandler handles backtest endpoints
+type BacktestsHandler struct {
+	rules     PricingRuleRepository
+	validator ConfigValidator
+	runner    BacktestRunner
+}
+
+// NewBacktestsHandler creates a new backtests handler
+func NewBacktestsHandler(rules PricingRuleRepository, validator ConfigValidator, runner BacktestRunner) *BacktestsHandler {
+	return &BacktestsHandler{
+		rules:     rules,
+		validator: validator,
+		runner:    runner,
+	}
+}
+
+// Create handles POST /v1/pricing/backtests
+func (h *BacktestsHandler) Create(c *gin.Context) {
+	// Get user ID from context
+	userID := MustGetUserID(c)
+	if userID == uuid.Nil {
+		return
+	}
+
+	// Bind request
+	var req dto.CreateBacktestRequest
+	if !BindJSON(c, &req) {
+		return
+	}
+
+	if req.RuleID == nil && req.StrategyType == "" {
+		BadRequest(c, "Either rule_id or strategy_type is required")
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	strategyType := req.StrategyType
+	if req.RuleID != nil {
+		rule, err := h.rules.GetByID(ctx, *req.RuleID)
+		if err != nil {
+			NotFound(c, "Pricing rule not found")
+			return
+		}
+
+		// Verify ownership
+		if rule.UserID != userID {
+			Forbidden(c, "Access denied")
+			return
+		}
+
+		if strategyType == "" {
+			strategyType = rule.StrategyType
+		}
+	}
+
+	// Validate the proposed config
+	if !checkConfig(c, h.validator, strategyType, req.Config) {
+		return
+	}
+
+	// Parse date range if provided
+	backtestReq := &BacktestRequest{
+		UserID:       userID,
+		RuleID:       req.RuleID,
+		StrategyType: strategyType,
+		Config:       req.Config,
+	}
+	if req.StartDate != "" {
+		t, err := time.Parse(time.RFC3339, req.StartDate)
+		if err != nil {
+			BadRequest(c, "Invalid start_date format. Use ISO 8601 (RFC3339)")
+			return
+		}
+		backtestReq.From = t
+	}
+	if req.EndDate != "" {
+		t, err := time.Parse(time.RFC3339, req.EndDate)
+		if err != nil {
+			BadRequest(c, "Invalid end_date format. Use ISO 8601 (RFC3339)")
+			return
+		}
+		backtestReq.To = t
+	}
+
+	backtest, err := h.runner.Start(ctx, backtestReq)
+	if errors.Is(err, ErrBacktestBusy) {
+		TooManyRequests(c, "Too many backtests are running; try again later")
+		return
+	}
+	if err != nil {
+		BadRequest(c, err.Error())
+		return
+	}
+
+	response := backtestResponse(backtest)
+	if !backtest.Done {
+		// Large ranges keep running; poll the job or follow its events
+		c.Header("Location", "/v1/pricing/backtests/"+backtest.ID.String())
+		Accepted(c, response)
+		return
+	}
+
+	Success(c, response)
+}
+
+// Get handles GET /v1/pricing/backtests/:id
+func (h *BacktestsHandler) Get(c *gin.Context) {
+	// Get user ID from context
+	userID := MustGetUserID(c)
+	if userID == uuid.Nil {
+		return
+	}
+
+	// Validate backtest ID
+	backtestID, err := ValidateUUID(c, "id")
+	if err != nil {
+		BadRequest(c, "Invalid backtest ID")
+		return
+	}
+
+	backtest, err := h.runner.Get(c.Request.Context(), userID, backtestID)
+	if err != nil {
+		NotFound(c, "Backtest not found")
+		return
+	}
+
+	Success(c, backtestResponse(backtest))
+}
+
+// Events handles GET /v1/pricing/backtests/:id/events as a Server-Sent Events stream.
+// Each event is named after the job status; the stream ends once the job finishes.
+func (h *BacktestsHandler) Events(c *gin.Context) {
+	// Get user ID from context
+	userID := MustGetUserID(c)
+	if userID == uuid.Nil {
+		return
+	}
+
+	// Validate backtest ID
+	backtestID, err := ValidateUUID(c, "id")
+	if err != nil {
+		BadRequest(c, "Invalid backtest ID")
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	updates, err := h.runner.Watch(ctx, userID, backtestID)
+	if err != nil {
+		NotFound(c, "Backtest not found")
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+
+	// Progress can outlast the server's write timeout, so extend it per event
+	controller := http.NewResponseController(c.Writer)
+	heartbeat := time.NewTicker(backtestHeartbeat)
+	defer heartbeat.Stop()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case backtest, ok := <-updates:
+			if !ok {
+				return false
+			}
+			_ = controller.SetWriteDeadline(time.Now().Add(backtestWriteTimeout))
+			c.SSEvent(backtest.Status, backtestResponse(backtest))
+			return !backtest.Done
+		case <-heartbeat.C:
+			_ = controller.SetWriteDeadline(time.Now().Add(backtestWriteTimeout))
+			c.SSEvent("heartbeat", gin.H{"time": time.Now().UTC()})
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	})
+}
+
+// backtestResponse converts a backtest job to its response DTO
+func backtestResponse(backtest *Backtest) dto.BacktestResponse {
+	return dto.BacktestResponse{
+		ID:          backtest.ID,
+		Status:      backtest.Status,
+		Processed:   backtest.Processed,
+		Total:       backtest.Total,
+		Report:      backtest.Report,
+		Error:       backtest.Error,
+		EventsURL:   "/v1/pricing/backtests/" + backtest.ID.String() + "/events",
+		CreatedAt:   backtest.CreatedAt,
+		StartedAt:   backtest.StartedAt,
+		CompletedAt: backtest.CompletedAt,
+	}
+}
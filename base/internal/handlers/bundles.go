@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/google/uuid"
+	"github.com/saintparish4/harmonia/internal/dto"
+)
+
+// maxBundleSize limits the size of an uploaded bundle
+const maxBundleSize = 10 << 20 // 10 MB
+
+// BundleImport represents a bundle to import and its options
+type BundleImport struct {
+	UserID     uuid.UUID
+	KeyID      *uuid.UUID
+	Bundle     *dto.RuleBundle
+	DryRun     bool
+	MatchBy    string
+	Mode       string
+	Publish    bool
+	ChangeNote string
+}
+
+// BundleImportResult represents the outcome of an import
+type BundleImportResult struct {
+	Rejected   bool // conflicts or errors; nothing was applied
+	Conflicted bool
+	Report     interface{}
+}
+
+// Bundle errors returned by bundlers
+var (
+	ErrInvalidBundle        = errors.New("invalid bundle")
+	ErrDuplicateExternalKey = errors.New("external key is already used by another rule")
+)
+
+// RuleBundler exports and imports rule bundles
+type RuleBundler interface {
+	Export(ctx context.Context, userID uuid.UUID, includeProducts bool) (*dto.RuleBundle, error)
+	// Import applies a bundle in one transaction unless it is a dry run or is rejected
+	Import(ctx context.Context, req *BundleImport) (*BundleImportResult, error)
+}
+
+// BundlesHandler handles rule bundle endpoints
+type BundlesHandler struct {
+	bundler RuleBundler
+}
+
+// NewBundlesHandler creates a new bundles handler
+func NewBundlesHandler(bundler RuleBundler) *BundlesHandler {
+	return &BundlesHandler{bundler: bundler}
+}
+
+// Export handles GET /v1/pricing/bundles/export
+// ?format=json|yaml selects the file format; ?include_products=true adds products and their rule links.
+func (h *BundlesHandler) Export(c *gin.Context) {
+	// Get user ID from context
+	userID := MustGetUserID(c)
+	if userID == uuid.Nil {
+		return
+	}
+
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "yaml" {
+		BadRequest(c, "Invalid format. Must be one of: json, yaml")
+		return
+	}
+
+	bundle, err := h.bundler.Export(c.Request.Context(), userID, c.Query("include_products") == "true")
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	filename := fmt.Sprintf("rules-%s.%s", bundle.ExportedAt.Format("20060102-150405"), format)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", filename))
+
+	if format == "yaml" {
+		c.YAML(http.StatusOK, bundle)
+		return
+	}
+	c.JSON(http.StatusOK, bundle)
+}
+
+// Import handles POST /v1/pricing/bundles/import
+// The body is a bundle file, read as YAML when ?format=yaml or the Content-Type
+// is YAML. Rules are imported as drafts unless ?publish=true. Nothing is applied
+// on a dry run or when any entry conflicts or fails validation.
+func (h *BundlesHandler) Import(c *gin.Context) {
+	// Get user ID from context
+	userID := MustGetUserID(c)
+	if userID == uuid.Nil {
+		return
+	}
+
+	var query dto.ImportBundleQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		BadRequest(c, err.Error())
+		return
+	}
+
+	format := query.Format
+	if format == "" {
+		format = "json"
+		if strings.Contains(c.ContentType(), "yaml") {
+			format = "yaml"
+		}
+	}
+	if format != "json" && format != "yaml" {
+		BadRequest(c, "Invalid format. Must be one of: json, yaml")
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxBundleSize+1))
+	if err != nil {
+		BadRequest(c, "Failed to read bundle")
+		return
+	}
+	if len(body) > maxBundleSize {
+		BadRequest(c, "Bundle exceeds the 10 MB limit")
+		return
+	}
+
+	var bundle dto.RuleBundle
+	var decoder binding.BindingBody = binding.JSON
+	if format == "yaml" {
+		decoder = binding.YAML
+	}
+	if err := decoder.BindBody(body, &bundle); err != nil {
+		BadRequest(c, "Invalid bundle: "+err.Error())
+		return
+	}
+
+	result, err := h.bundler.Import(c.Request.Context(), &BundleImport{
+		UserID:     userID,
+		KeyID:      GetAPIKeyID(c),
+		Bundle:     &bundle,
+		DryRun:     query.DryRun,
+		MatchBy:    query.MatchBy,
+		Mode:       query.Mode,
+		Publish:    query.Publish,
+		ChangeNote: query.ChangeNote,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrInvalidBundle):
+			BadRequest(c, err.Error())
+		case errors.Is(err, ErrApprovalRequired):
+			Forbidden(c, "This account requires rule drafts to be approved; import without publish=true")
+		case errors.Is(err, ErrDuplicateExternalKey):
+			Conflict(c, err.Error())
+		default:
+			HandleError(c, err)
+		}
+		return
+	}
+
+	if result.Rejected {
+		status := http.StatusUnprocessableEntity
+		if result.Conflicted {
+			status = http.StatusConflict
+		}
+		c.JSON(status, SuccessResponse{
+			Success: false,
+			Message: "Import rejected; nothing was applied",
+			Data:    result.Report,
+		})
+		return
+	}
+
+	Success(c, result.Report)
+}
@@ -0,0 +1,227 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/saintparish4/harmonia/internal/dto"
+)
+
+const (
+	// productUploadTimeout replaces the server read timeout for product file uploads
+	productUploadTimeout = 2 * time.Minute
+
+	// productExportWriteTimeout replaces the server write timeout for each streamed chunk
+	productExportWriteTimeout = 30 * time.Second
+)
+
+// Product file content types
+var productContentTypes = map[string]string{
+	"csv":    "text/csv; charset=utf-8",
+	"ndjson": "application/x-ndjson",
+}
+
+// ProductImportRequest represents an uploaded product file
+type ProductImportRequest struct {
+	UserID  uuid.UUID
+	Format  string
+	Mapping map[string]string
+	Data    io.Reader
+}
+
+// ProductImport represents a product import job
+type ProductImport struct {
+	ID          uuid.UUID
+	Format      string
+	Status      string
+	Done        bool
+	Processed   int
+	Total       int
+	Result      interface{}
+	Error       string
+	CreatedAt   time.Time
+	StartedAt   *time.Time
+	CompletedAt *time.Time
+	Volatile    bool // Lost when the server restarts
+}
+
+// Product catalog errors
+var (
+	// ErrInvalidProductFile is returned when an upload's format or columns are unusable
+	ErrInvalidProductFile = errors.New("invalid product file")
+	// ErrProductImportBusy is returned when too many imports are running
+	ErrProductImportBusy = errors.New("too many product imports are running")
+)
+
+// ProductCatalog imports and exports product files
+type ProductCatalog interface {
+	// StartImport reads the file, checks its columns and upserts its rows in
+	// the background
+	StartImport(ctx context.Context, req *ProductImportRequest) (*ProductImport, error)
+	GetImport(ctx context.Context, userID, id uuid.UUID) (*ProductImport, error)
+	// Export writes every product of the user to w in the given format
+	Export(ctx context.Context, userID uuid.UUID, format string, w io.Writer) error
+}
+
+// CatalogHandler handles bulk product import and export endpoints
+type CatalogHandler struct {
+	catalog ProductCatalog
+}
+
+// NewCatalogHandler creates a new catalog handler
+func NewCatalogHandler(catalog ProductCatalog) *CatalogHandler {
+	return &CatalogHandler{catalog: catalog}
+}
+
+// Import handles POST /v1/products/import
+// The body is a CSV file with a header row, or NDJSON with one product per line,
+// chosen by ?format=csv|ndjson or the Content-Type. ?mapping[<column>]=<field>
+// renames source columns; rows are upserted by SKU in the background.
+func (h *CatalogHandler) Import(c *gin.Context) {
+	// Get user ID from context
+	userID := MustGetUserID(c)
+	if userID == uuid.Nil {
+		return
+	}
+
+	format := c.Query("format")
+	if format == "" {
+		format = "csv"
+		if strings.Contains(c.ContentType(), "ndjson") {
+			format = "ndjson"
+		}
+	}
+	if _, ok := productContentTypes[format]; !ok {
+		BadRequest(c, "Invalid format. Must be one of: csv, ndjson")
+		return
+	}
+
+	// Large catalogs can take longer to upload than the server read timeout
+	_ = http.NewResponseController(c.Writer).SetReadDeadline(time.Now().Add(productUploadTimeout))
+
+	productImport, err := h.catalog.StartImport(c.Request.Context(), &ProductImportRequest{
+		UserID:  userID,
+		Format:  format,
+		Mapping: c.QueryMap("mapping"),
+		Data:    c.Request.Body,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrInvalidProductFile):
+			BadRequest(c, err.Error())
+		case errors.Is(err, ErrProductImportBusy):
+			TooManyRequests(c, "Too many product imports are running; try again later")
+		default:
+			HandleError(c, err)
+		}
+		return
+	}
+
+	c.Header("Location", "/v1/products/imports/"+productImport.ID.String())
+	Accepted(c, productImportResponse(productImport))
+}
+
+// GetImport handles GET /v1/products/imports/:id
+func (h *CatalogHandler) GetImport(c *gin.Context) {
+	// Get user ID from context
+	userID := MustGetUserID(c)
+	if userID == uuid.Nil {
+		return
+	}
+
+	// Validate import ID
+	importID, err := ValidateUUID(c, "id")
+	if err != nil {
+		BadRequest(c, "Invalid import ID")
+		return
+	}
+
+	productImport, err := h.catalog.GetImport(c.Request.Context(), userID, importID)
+	if err != nil {
+		NotFound(c, "Product import not found")
+		return
+	}
+
+	Success(c, productImportResponse(productImport))
+}
+
+// Export handles GET /v1/products/export
+// ?format=csv|ndjson selects the file format; the file can be imported unchanged.
+func (h *CatalogHandler) Export(c *gin.Context) {
+	// Get user ID from context
+	userID := MustGetUserID(c)
+	if userID == uuid.Nil {
+		return
+	}
+
+	format := c.DefaultQuery("format", "csv")
+	contentType, ok := productContentTypes[format]
+	if !ok {
+		BadRequest(c, "Invalid format. Must be one of: csv, ndjson")
+		return
+	}
+
+	w := &streamWriter{
+		c:          c,
+		controller: http.NewResponseController(c.Writer),
+		header: func() {
+			filename := fmt.Sprintf("products-%s.%s", time.Now().UTC().Format("20060102-150405"), format)
+			c.Header("Content-Type", contentType)
+			c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", filename))
+			c.Status(http.StatusOK)
+		},
+	}
+
+	if err := h.catalog.Export(c.Request.Context(), userID, format, w); err != nil {
+		if !w.started {
+			HandleError(c, err)
+			return
+		}
+		// The status is already sent; the truncated body is all the client sees
+		_ = c.Error(err)
+	}
+}
+
+// streamWriter writes a streamed download, sending headers on the first write
+// and extending the write deadline for each chunk
+type streamWriter struct {
+	c          *gin.Context
+	controller *http.ResponseController
+	header     func()
+	started    bool
+}
+
+func (w *streamWriter) Write(p []byte) (int, error) {
+	if !w.started {
+		w.header()
+		w.started = true
+	}
+	_ = w.controller.SetWriteDeadline(time.Now().Add(productExportWriteTimeout))
+	n, err := w.c.Writer.Write(p)
+	w.c.Writer.Flush()
+	return n, err
+}
+
+// productImportResponse converts a product import job to its response DTO
+func productImportResponse(productImport *ProductImport) dto.ProductImportResponse {
+	return dto.ProductImportResponse{
+		ID:          productImport.ID,
+		Format:      productImport.Format,
+		Status:      productImport.Status,
+		Processed:   productImport.Processed,
+		Total:       productImport.Total,
+		Result:      productImport.Result,
+		Error:       productImport.Error,
+		CreatedAt:   productImport.CreatedAt,
+		StartedAt:   productImport.StartedAt,
+		CompletedAt: productImport.CompletedAt,
+		Volatile:    productImport.Volatile,
+	}
+}
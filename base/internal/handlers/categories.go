@@ -0,0 +1,350 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/saintparish4/harmonia/internal/dto"
+)
+
+// Category represents a node of a user's product category tree
+type Category struct {
+	ID            uuid.UUID
+	UserID        uuid.UUID
+	ParentID      *uuid.UUID
+	Name          string
+	Description   string
+	DefaultRuleID *uuid.UUID
+	Attributes    map[string]interface{}
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+// Category errors
+var (
+	ErrCategoryExists   = errors.New("a category with this name already exists under the parent")
+	ErrCategoryCycle    = errors.New("category cannot be moved under itself or its descendants")
+	ErrCategoryNotEmpty = errors.New("category still has subcategories or active products")
+)
+
+// CategoryRepository defines operations for product categories
+type CategoryRepository interface {
+	Create(ctx context.Context, category *Category) error
+	GetByID(ctx context.Context, id uuid.UUID) (*Category, error)
+	ListByUser(ctx context.Context, userID uuid.UUID) ([]*Category, error)
+	Update(ctx context.Context, category *Category) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	// Ancestors returns the category followed by its ancestors, nearest first
+	Ancestors(ctx context.Context, id uuid.UUID) ([]*Category, error)
+}
+
+// CategoriesHandler handles product category endpoints
+type CategoriesHandler struct {
+	repo  CategoryRepository
+	rules PricingRuleRepository
+}
+
+// NewCategoriesHandler creates a new categories handler
+func NewCategoriesHandler(repo CategoryRepository, rules PricingRuleRepository) *CategoriesHandler {
+	return &CategoriesHandler{repo: repo, rules: rules}
+}
+
+// List handles GET /v1/categories
+// Returns every category of the user, each with the values it inherits.
+func (h *CategoriesHandler) List(c *gin.Context) {
+	// Get user ID from context
+	userID := MustGetUserID(c)
+	if userID == uuid.Nil {
+		return
+	}
+
+	categories, err := h.repo.ListByUser(c.Request.Context(), userID)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	tree := newCategoryTree(categories)
+	response := make([]dto.CategoryResponse, len(categories))
+	for i, category := range categories {
+		response[i] = categoryResponse(tree.chain(category.ID))
+	}
+
+	Success(c, response)
+}
+
+// Create handles POST /v1/categories
+func (h *CategoriesHandler) Create(c *gin.Context) {
+	// Get user ID from context
+	userID := MustGetUserID(c)
+	if userID == uuid.Nil {
+		return
+	}
+
+	// Bind request
+	var req dto.CreateCategoryRequest
+	if !BindJSON(c, &req) {
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	var parents []*Category
+	if req.ParentID != nil {
+		var ok bool
+		if parents, ok = h.ownedAncestors(c, userID, *req.ParentID); !ok {
+			return
+		}
+	}
+	if req.DefaultRuleID != nil && !checkOwnedRule(c, h.rules, userID, *req.DefaultRuleID, "/default_rule_id") {
+		return
+	}
+
+	attributes := req.Attributes
+	if attributes == nil {
+		attributes = map[string]interface{}{}
+	}
+
+	category := &Category{
+		ID:            uuid.New(),
+		UserID:        userID,
+		ParentID:      req.ParentID,
+		Name:          req.Name,
+		Description:   req.Description,
+		DefaultRuleID: req.DefaultRuleID,
+		Attributes:    attributes,
+	}
+
+	if err := h.repo.Create(ctx, category); err != nil {
+		if errors.Is(err, ErrCategoryExists) {
+			Conflict(c, "A category with this name already exists under the parent")
+			return
+		}
+		HandleError(c, err)
+		return
+	}
+
+	Created(c, categoryResponse(append([]*Category{category}, parents...)))
+}
+
+// Get handles GET /v1/categories/:id
+func (h *CategoriesHandler) Get(c *gin.Context) {
+	category, ok := h.ownedCategory(c)
+	if !ok {
+		return
+	}
+
+	chain, err := h.repo.Ancestors(c.Request.Context(), category.ID)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	Success(c, categoryResponse(chain))
+}
+
+// Update handles PUT /v1/categories/:id
+// Setting parent_id moves the category with its subcategories and products;
+// the next calculation for those products resolves through the new parents.
+func (h *CategoriesHandler) Update(c *gin.Context) {
+	category, ok := h.ownedCategory(c)
+	if !ok {
+		return
+	}
+
+	// Bind request
+	var req dto.UpdateCategoryRequest
+	if !BindJSON(c, &req) {
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	// Update fields
+	if req.Name != nil {
+		category.Name = *req.Name
+	}
+	if req.Description != nil {
+		category.Description = *req.Description
+	}
+	if req.ParentID.Set {
+		if req.ParentID.Value != nil {
+			if _, ok := h.ownedAncestors(c, category.UserID, *req.ParentID.Value); !ok {
+				return
+			}
+		}
+		category.ParentID = req.ParentID.Value
+	}
+	if req.DefaultRuleID.Set {
+		if req.DefaultRuleID.Value != nil && !checkOwnedRule(c, h.rules, category.UserID, *req.DefaultRuleID.Value, "/default_rule_id") {
+			return
+		}
+		category.DefaultRuleID = req.DefaultRuleID.Value
+	}
+	if req.Attributes != nil {
+		category.Attributes = req.Attributes
+	}
+
+	// Save updates
+	if err := h.repo.Update(ctx, category); err != nil {
+		switch {
+		case errors.Is(err, ErrCategoryCycle):
+			BadRequestWithDetails(c, "Invalid category parent", map[string]string{
+				"/parent_id": "category cannot be moved under itself or its descendants",
+			})
+		case errors.Is(err, ErrCategoryExists):
+			Conflict(c, "A category with this name already exists under the parent")
+		default:
+			HandleError(c, err)
+		}
+		return
+	}
+
+	chain, err := h.repo.Ancestors(ctx, category.ID)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	Success(c, categoryResponse(chain))
+}
+
+// Delete handles DELETE /v1/categories/:id
+// Only categories without subcategories or active products can be deleted.
+func (h *CategoriesHandler) Delete(c *gin.Context) {
+	category, ok := h.ownedCategory(c)
+	if !ok {
+		return
+	}
+
+	if err := h.repo.Delete(c.Request.Context(), category.ID); err != nil {
+		if errors.Is(err, ErrCategoryNotEmpty) {
+			Conflict(c, "Category still has subcategories or active products")
+			return
+		}
+		HandleError(c, err)
+		return
+	}
+
+	NoContent(c)
+}
+
+// ownedCategory loads the category named by the :id parameter and verifies
+// the caller owns it. Returns false if a response was written.
+func (h *CategoriesHandler) ownedCategory(c *gin.Context) (*Category, bool) {
+	// Get user ID from context
+	userID := MustGetUserID(c)
+	if userID == uuid.Nil {
+		return nil, false
+	}
+
+	// Validate category ID
+	categoryID, err := ValidateUUID(c, "id")
+	if err != nil {
+		BadRequest(c, "Invalid category ID")
+		return nil, false
+	}
+
+	category, err := h.repo.GetByID(c.Request.Context(), categoryID)
+	if err != nil {
+		NotFound(c, "Category not found")
+		return nil, false
+	}
+
+	// Verify ownership
+	if category.UserID != userID {
+		Forbidden(c, "Access denied")
+		return nil, false
+	}
+
+	return category, true
+}
+
+// ownedAncestors loads a parent category and its ancestors, verifying the
+// user owns it. Returns false if a response was written.
+func (h *CategoriesHandler) ownedAncestors(c *gin.Context, userID, parentID uuid.UUID) ([]*Category, bool) {
+	chain, err := h.repo.Ancestors(c.Request.Context(), parentID)
+	if err != nil || chain[0].UserID != userID {
+		BadRequestWithDetails(c, "Invalid category parent", map[string]string{
+			"/parent_id": "category not found",
+		})
+		return nil, false
+	}
+	return chain, true
+}
+
+// categoryTree indexes a user's categories to build ancestor chains in memory
+type categoryTree map[uuid.UUID]*Category
+
+func newCategoryTree(categories []*Category) categoryTree {
+	tree := make(categoryTree, len(categories))
+	for _, category := range categories {
+		tree[category.ID] = category
+	}
+	return tree
+}
+
+// chain returns a category followed by its ancestors, nearest first
+func (t categoryTree) chain(id uuid.UUID) []*Category {
+	var chain []*Category
+	for category := t[id]; category != nil && len(chain) <= len(t); {
+		chain = append(chain, category)
+		if category.ParentID == nil {
+			break
+		}
+		category = t[*category.ParentID]
+	}
+	return chain
+}
+
+// effectiveAttributes merges the attributes of a category chain, nearest
+// category first, under the given metadata. Nearer values win.
+func effectiveAttributes(chain []*Category, metadata map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{})
+	for i := len(chain) - 1; i >= 0; i-- {
+		for k, v := range chain[i].Attributes {
+			merged[k] = v
+		}
+	}
+	for k, v := range metadata {
+		merged[k] = v
+	}
+	return merged
+}
+
+// categoryResponse converts a category chain, nearest first, to the response
+// DTO of its first category
+func categoryResponse(chain []*Category) dto.CategoryResponse {
+	category := chain[0]
+
+	attributes := category.Attributes
+	if attributes == nil {
+		attributes = map[string]interface{}{}
+	}
+
+	response := dto.CategoryResponse{
+		ID:                  category.ID,
+		ParentID:            category.ParentID,
+		Name:                category.Name,
+		Description:         category.Description,
+		Path:                make([]string, len(chain)),
+		DefaultRuleID:       category.DefaultRuleID,
+		Attributes:          attributes,
+		EffectiveAttributes: effectiveAttributes(chain, nil),
+		CreatedAt:           category.CreatedAt,
+		UpdatedAt:           category.UpdatedAt,
+	}
+
+	for i, ancestor := range chain {
+		response.Path[len(chain)-1-i] = ancestor.Name
+		if response.EffectiveDefaultRuleID == nil && ancestor.DefaultRuleID != nil {
+			response.EffectiveDefaultRuleID = ancestor.DefaultRuleID
+			response.DefaultRuleCategoryID = &chain[i].ID
+		}
+	}
+
+	return response
+}
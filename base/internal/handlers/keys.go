@@ -2,6 +2,8 @@ package handlers
 
 import (
 	"context"
+	"errors"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -10,14 +12,25 @@ import (
 
 // APIKey represents an API key domain model
 type APIKey struct {
-	ID        uuid.UUID
-	UserID    uuid.UUID
-	KeyHash   string
-	KeyPrefix string
-	Name      string
-	IsLive    bool
-	IsRevoked bool
-	CreatedAt string
+	ID         uuid.UUID
+	UserID     uuid.UUID
+	KeyHash    string
+	KeyPrefix  string
+	Name       string
+	IsLive     bool
+	IsRevoked  bool
+	CanApprove bool // Granted by an administrator; approves drafts under the approval policy
+	CreatedAt  time.Time
+}
+
+// ErrAPIKeyNotFound is returned when an API key does not exist or is revoked
+var ErrAPIKeyNotFound = errors.New("API key not found")
+
+// APIKeyFilter selects a user's API keys
+type APIKeyFilter struct {
+	UserID uuid.UUID
+	Limit  int
+	After  *Cursor
 }
 
 // User represents a user domain model
@@ -30,7 +43,13 @@ type User struct {
 type APIKeyRepository interface {
 	Create(ctx context.Context, key *APIKey) error
 	GetByUserID(ctx context.Context, userID uuid.UUID) ([]*APIKey, error)
+	// List retrieves keys newest first, continuing after filter.After
+	List(ctx context.Context, filter APIKeyFilter) ([]*APIKey, error)
+	// Count returns the number of keys matching the filter, ignoring Limit and After
+	Count(ctx context.Context, filter APIKeyFilter) (int, error)
 	Revoke(ctx context.Context, keyID uuid.UUID) error
+	// SetCanApprove grants or withdraws an active key's approval rights
+	SetCanApprove(ctx context.Context, keyID uuid.UUID, canApprove bool) error
 }
 
 // UserRepository defines operations for user management
@@ -106,7 +125,7 @@ func (h *KeysHandler) Create(c *gin.Context) {
 		KeyPrefix: keyPrefix,
 		Name:      req.Name,
 		IsLive:    req.IsLive,
-		CreatedAt: apiKey.CreatedAt,
+		CreatedAt: apiKey.CreatedAt.Format(time.RFC3339),
 		Warning:   "Save this key securely. You won't be able to see it again!",
 	}
 
@@ -114,6 +133,8 @@ func (h *KeysHandler) Create(c *gin.Context) {
 }
 
 // List handles GET /v1/auth/keys
+// Keys are listed newest first, a page at a time; pass next_cursor as ?cursor
+// for the next page, and ?include_total=true to count every key.
 func (h *KeysHandler) List(c *gin.Context) {
 	// Get user ID from context
 	userID := MustGetUserID(c)
@@ -121,29 +142,64 @@ func (h *KeysHandler) List(c *gin.Context) {
 		return
 	}
 
+	// Bind query parameters
+	var params dto.PageParams
+	if err := c.ShouldBindQuery(&params); err != nil {
+		BadRequest(c, err.Error())
+		return
+	}
+
+	page, ok := parsePage(c, params)
+	if !ok {
+		return
+	}
+
 	ctx := c.Request.Context()
+	filter := APIKeyFilter{
+		UserID: userID,
+		Limit:  page.fetchLimit(),
+		After:  page.after,
+	}
 
-	// Get all keys for user
-	keys, err := h.repo.GetByUserID(ctx, userID)
+	keys, err := h.repo.List(ctx, filter)
 	if err != nil {
 		HandleError(c, err)
 		return
 	}
+	fetched := len(keys)
+	if fetched > page.limit {
+		keys = keys[:page.limit]
+	}
+
+	// Get total count if asked for
+	var total *int
+	if page.includeTotal {
+		count, err := h.repo.Count(ctx, filter)
+		if err != nil {
+			HandleError(c, err)
+			return
+		}
+		total = &count
+	}
 
 	// Convert to response DTOs
+	var last Cursor
 	response := make([]dto.APIKeyResponse, len(keys))
 	for i, key := range keys {
+		last = Cursor{CreatedAt: key.CreatedAt, ID: key.ID}
 		response[i] = dto.APIKeyResponse{
-			ID:        key.ID,
-			KeyPrefix: key.KeyPrefix,
-			Name:      key.Name,
-			IsLive:    key.IsLive,
-			IsRevoked: key.IsRevoked,
+			ID:         key.ID,
+			KeyPrefix:  key.KeyPrefix,
+			Name:       key.Name,
+			IsLive:     key.IsLive,
+			IsRevoked:  key.IsRevoked,
+			CanApprove: key.CanApprove,
+			CreatedAt:  key.CreatedAt,
 			// Note: Not including LastUsed for now - can be added later
 		}
 	}
 
-	Success(c, response)
+	Success(c, page.response(response, fetched, last, total))
 }
 
 // Revoke handles DELETE /v1/auth/keys/:id
@@ -193,6 +249,35 @@ func (h *KeysHandler) Revoke(c *gin.Context) {
 	SuccessWithMessage(c, "API key revoked successfully", nil)
 }
 
+// SetApprover handles PUT /v1/admin/api-keys/:id/approver
+// Only administrators can let a key approve rule drafts, so an account holder
+// cannot mint a key that approves their own edits.
+func (h *KeysHandler) SetApprover(c *gin.Context) {
+	// Validate key ID
+	keyID, err := ValidateUUID(c, "id")
+	if err != nil {
+		BadRequest(c, "Invalid key ID")
+		return
+	}
+
+	// Bind request
+	var req dto.SetKeyApproverRequest
+	if !BindJSON(c, &req) {
+		return
+	}
+
+	if err := h.repo.SetCanApprove(c.Request.Context(), keyID, *req.CanApprove); err != nil {
+		if errors.Is(err, ErrAPIKeyNotFound) {
+			NotFound(c, "API key not found")
+			return
+		}
+		HandleError(c, err)
+		return
+	}
+
+	Success(c, dto.SetKeyApproverResponse{ID: keyID, CanApprove: *req.CanApprove})
+}
+
 // CreateUser handles POST /v1/users (for demo/testing purposes)
 func (h *KeysHandler) CreateUser(c *gin.Context) {
 	var req dto.CreateUserRequest
@@ -2,6 +2,14 @@ package handlers
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -11,31 +19,140 @@ import (
 
 // CalculationLog represents a calculation log entry
 type CalculationLog struct {
-	ID           uuid.UUID
+	ID              uuid.UUID
+	UserID          uuid.UUID
+	APIKeyID        *uuid.UUID
+	RuleID          *uuid.UUID
+	RuleRevisionID  *uuid.UUID
+	RuleRevision    int
+	StrategyType    string
+	Input           map[string]interface{}
+	Output          map[string]interface{}
+	ExecutionTimeMs float64
+	RequestID       string
+	ClientIP        string
+	EngineVersion   string
+	Error           string // set when the calculation failed
+	CreatedAt       time.Time
+}
+
+// maxLogFilters limits the input and output filters of one request
+const maxLogFilters = 10
+
+// LogDataFilter matches calculation logs by one value of their input or output
+type LogDataFilter struct {
+	Column string   // input_data or output_data
+	Path   []string // object keys leading to the value
+	Op     string
+	Value  interface{}
+}
+
+// logFilterOperators are the operators of input and output filters, and
+// whether they take a value
+var logFilterOperators = map[string]bool{
+	"eq":       true,
+	"gt":       true,
+	"gte":      true,
+	"lt":       true,
+	"lte":      true,
+	"exists":   false,
+	"contains": true,
+}
+
+// CalculationLogFilter selects a user's calculation logs
+type CalculationLogFilter struct {
 	UserID       uuid.UUID
 	StrategyType string
-	Input        map[string]interface{}
-	Output       map[string]interface{}
-	CreatedAt    time.Time
+	StartDate    *time.Time
+	EndDate      *time.Time
+	Filters      []LogDataFilter
+	Limit        int
+	After        *Cursor
 }
 
 // CalculationLogRepository defines operations for calculation logs
 type CalculationLogRepository interface {
-	GetByUserID(ctx context.Context, userID uuid.UUID, limit, offset int, strategyType string, startDate, endDate *time.Time) ([]*CalculationLog, error)
-	Count(ctx context.Context, userID uuid.UUID, strategyType string, startDate, endDate *time.Time) (int, error)
+	GetByID(ctx context.Context, id uuid.UUID) (*CalculationLog, error)
+	// List retrieves logs newest first, continuing after filter.After
+	List(ctx context.Context, filter CalculationLogFilter) ([]*CalculationLog, error)
+	// Count returns the number of logs matching the filter, ignoring Limit and After
+	Count(ctx context.Context, filter CalculationLogFilter) (int, error)
+}
+
+// Calculation log export content types
+var logExportContentTypes = map[string]string{
+	"csv":    "text/csv; charset=utf-8",
+	"ndjson": "application/x-ndjson",
+}
+
+// CalculationLogExport selects the logs and columns of an export
+type CalculationLogExport struct {
+	UserID        uuid.UUID
+	StrategyType  string
+	StartDate     *time.Time
+	EndDate       *time.Time
+	IncludeFailed bool
+	Filters       []LogDataFilter
+	Format        string
+	Columns       []string // empty exports the default columns
+}
+
+// ErrInvalidLogExport is returned when an export's format or columns are unusable
+var ErrInvalidLogExport = errors.New("invalid calculation log export")
+
+// CalculationLogExporter streams calculation logs as files
+type CalculationLogExporter interface {
+	// Export writes every log matching the export to w, oldest first.
+	// Nothing is written when the export is invalid.
+	Export(ctx context.Context, export *CalculationLogExport, w io.Writer) error
+}
+
+// CalculationReplay represents a logged calculation re-run with the rule
+// revision and evaluation time it originally used
+type CalculationReplay struct {
+	LogID                 uuid.UUID
+	RuleID                *uuid.UUID
+	RuleRevision          int
+	StrategyType          string
+	RequestedAt           time.Time
+	OriginalEngineVersion string
+	EngineVersion         string
+	OriginalPrice         *float64
+	ReplayedPrice         *float64
+	OriginalError         string
+	ReplayedError         string
+	Drift                 bool
+	Reasons               []string // rule_changed, engine_changed or unexplained
+	Changes               []JSONChange
+	ReplayedAt            time.Time
+}
+
+// ErrReplayUnsupported is returned when a log lacks the data to replay it
+var ErrReplayUnsupported = errors.New("calculation log cannot be replayed")
+
+// CalculationReplayer re-runs logged calculations
+type CalculationReplayer interface {
+	Replay(ctx context.Context, log *CalculationLog) (*CalculationReplay, error)
 }
 
 // LogsHandler handles calculation log endpoints
 type LogsHandler struct {
-	repo CalculationLogRepository
+	repo     CalculationLogRepository
+	exporter CalculationLogExporter
+	replayer CalculationReplayer
 }
 
 // NewLogsHandler creates a new logs handler
-func NewLogsHandler(repo CalculationLogRepository) *LogsHandler {
-	return &LogsHandler{repo: repo}
+func NewLogsHandler(repo CalculationLogRepository, exporter CalculationLogExporter, replayer CalculationReplayer) *LogsHandler {
+	return &LogsHandler{repo: repo, exporter: exporter, replayer: replayer}
 }
 
 // List handles GET /v1/logs
+// Logs are listed newest first, a page at a time; pass next_cursor as ?cursor
+// for the next page, and ?include_total=true to count every match.
+// Repeated filter=<input|output>.<path>:<op>[:<value>] parameters match
+// values of the calculation's input or output, such as
+// filter=input.context.location:eq:US-CA&filter=output.final_price:gt:500.
 func (h *LogsHandler) List(c *gin.Context) {
 	// Get user ID from context
 	userID := MustGetUserID(c)
@@ -50,70 +167,354 @@ func (h *LogsHandler) List(c *gin.Context) {
 		return
 	}
 
-	// Set defaults
-	if params.Limit == 0 {
-		params.Limit = 20
-	}
-	if params.Limit > 100 {
-		params.Limit = 100
+	page, ok := parsePage(c, params.PageParams)
+	if !ok {
+		return
 	}
 
 	// Parse date filters if provided
-	var startDate, endDate *time.Time
-	if params.StartDate != "" {
-		t, err := time.Parse(time.RFC3339, params.StartDate)
-		if err != nil {
-			BadRequest(c, "Invalid start_date format. Use ISO 8601 (RFC3339)")
-			return
-		}
-		startDate = &t
+	startDate, endDate, ok := parseLogDates(c, params.StartDate, params.EndDate)
+	if !ok {
+		return
 	}
-	if params.EndDate != "" {
-		t, err := time.Parse(time.RFC3339, params.EndDate)
-		if err != nil {
-			BadRequest(c, "Invalid end_date format. Use ISO 8601 (RFC3339)")
-			return
-		}
-		endDate = &t
+
+	filters, ok := parseLogFilters(c, params.Filters)
+	if !ok {
+		return
 	}
 
 	ctx := c.Request.Context()
+	filter := CalculationLogFilter{
+		UserID:       userID,
+		StrategyType: params.StrategyType,
+		StartDate:    startDate,
+		EndDate:      endDate,
+		Filters:      filters,
+		Limit:        page.fetchLimit(),
+		After:        page.after,
+	}
 
 	// Get logs
-	logs, err := h.repo.GetByUserID(ctx, userID, params.Limit, params.Offset, params.StrategyType, startDate, endDate)
+	logs, err := h.repo.List(ctx, filter)
 	if err != nil {
 		HandleError(c, err)
 		return
 	}
+	fetched := len(logs)
+	if fetched > page.limit {
+		logs = logs[:page.limit]
+	}
+
+	// Get total count if asked for
+	var total *int
+	if page.includeTotal {
+		count, err := h.repo.Count(ctx, filter)
+		if err != nil {
+			HandleError(c, err)
+			return
+		}
+		total = &count
+	}
+
+	// Convert to response DTOs
+	var last Cursor
+	logResponses := make([]dto.CalculationLogResponse, len(logs))
+	for i, log := range logs {
+		last = Cursor{CreatedAt: log.CreatedAt, ID: log.ID}
+		logResponses[i] = calculationLogResponse(log)
+	}
+
+	Success(c, page.response(logResponses, fetched, last, total))
+}
+
+// Get handles GET /v1/logs/:id
+func (h *LogsHandler) Get(c *gin.Context) {
+	log, ok := h.ownedLog(c)
+	if !ok {
+		return
+	}
 
-	// Get total count
-	total, err := h.repo.Count(ctx, userID, params.StrategyType, startDate, endDate)
+	Success(c, calculationLogResponse(log))
+}
+
+// Replay handles POST /v1/logs/:id/replay
+// The logged input is priced again with the rule revision and evaluation time
+// the calculation originally used, and the result compared with the logged
+// output. Any drift is listed with its likely reasons.
+func (h *LogsHandler) Replay(c *gin.Context) {
+	log, ok := h.ownedLog(c)
+	if !ok {
+		return
+	}
+
+	replay, err := h.replayer.Replay(c.Request.Context(), log)
 	if err != nil {
+		if errors.Is(err, ErrReplayUnsupported) {
+			BadRequest(c, err.Error())
+			return
+		}
 		HandleError(c, err)
 		return
 	}
 
-	// Convert to response DTOs
-	logResponses := make([]dto.CalculationLogResponse, len(logs))
-	for i, log := range logs {
-		logResponses[i] = dto.CalculationLogResponse{
-			ID:           log.ID,
-			UserID:       log.UserID,
-			StrategyType: log.StrategyType,
-			Input:        log.Input,
-			Output:       log.Output,
-			CreatedAt:    log.CreatedAt,
+	changes := make([]dto.JSONChangeResponse, len(replay.Changes))
+	for i, change := range replay.Changes {
+		changes[i] = dto.JSONChangeResponse{
+			Op:   change.Op,
+			Path: change.Path,
+			From: change.From,
+			To:   change.To,
 		}
 	}
 
-	// Create paginated response
-	response := dto.PaginatedResponse{
-		Data:    logResponses,
-		Total:   total,
-		Limit:   params.Limit,
-		Offset:  params.Offset,
-		HasMore: params.Offset+params.Limit < total,
+	Success(c, dto.LogReplayResponse{
+		LogID:                 replay.LogID,
+		RuleID:                replay.RuleID,
+		RuleRevision:          replay.RuleRevision,
+		StrategyType:          replay.StrategyType,
+		RequestedAt:           replay.RequestedAt,
+		OriginalEngineVersion: replay.OriginalEngineVersion,
+		EngineVersion:         replay.EngineVersion,
+		OriginalPrice:         replay.OriginalPrice,
+		ReplayedPrice:         replay.ReplayedPrice,
+		OriginalError:         replay.OriginalError,
+		ReplayedError:         replay.ReplayedError,
+		Drift:                 replay.Drift,
+		Reasons:               replay.Reasons,
+		Changes:               changes,
+		OriginalOutput:        log.Output,
+		ReplayedAt:            replay.ReplayedAt,
+	})
+}
+
+// ownedLog loads the calculation log named by the :id parameter and verifies
+// the caller owns it. Returns false if a response was written.
+func (h *LogsHandler) ownedLog(c *gin.Context) (*CalculationLog, bool) {
+	// Get user ID from context
+	userID := MustGetUserID(c)
+	if userID == uuid.Nil {
+		return nil, false
+	}
+
+	// Validate log ID
+	logID, err := ValidateUUID(c, "id")
+	if err != nil {
+		BadRequest(c, "Invalid log ID")
+		return nil, false
+	}
+
+	log, err := h.repo.GetByID(c.Request.Context(), logID)
+	if err != nil {
+		NotFound(c, "Calculation log not found")
+		return nil, false
+	}
+
+	// Verify ownership
+	if log.UserID != userID {
+		Forbidden(c, "Access denied")
+		return nil, false
+	}
+
+	return log, true
+}
+
+// calculationLogResponse converts a calculation log to its response DTO
+func calculationLogResponse(log *CalculationLog) dto.CalculationLogResponse {
+	return dto.CalculationLogResponse{
+		ID:              log.ID,
+		UserID:          log.UserID,
+		APIKeyID:        log.APIKeyID,
+		RuleID:          log.RuleID,
+		RuleRevisionID:  log.RuleRevisionID,
+		RuleRevision:    log.RuleRevision,
+		StrategyType:    log.StrategyType,
+		Input:           log.Input,
+		Output:          log.Output,
+		ExecutionTimeMs: log.ExecutionTimeMs,
+		RequestID:       log.RequestID,
+		ClientIP:        log.ClientIP,
+		EngineVersion:   log.EngineVersion,
+		Error:           log.Error,
+		CreatedAt:       log.CreatedAt,
+	}
+}
+
+// Export handles GET /v1/logs/export
+// Every log matching the filters is streamed, oldest first, as CSV or NDJSON.
+// ?columns=id,created_at,input.product_id,output.final_price selects the
+// columns; input.<path> and output.<path> columns flatten one value of the
+// calculation's input or output. Logs are filtered as in List.
+func (h *LogsHandler) Export(c *gin.Context) {
+	// Get user ID from context
+	userID := MustGetUserID(c)
+	if userID == uuid.Nil {
+		return
+	}
+
+	// Bind query parameters
+	var params dto.LogExportQueryParams
+	if err := c.ShouldBindQuery(&params); err != nil {
+		BadRequest(c, err.Error())
+		return
+	}
+
+	format := params.Format
+	if format == "" {
+		format = "csv"
+	}
+	contentType, ok := logExportContentTypes[format]
+	if !ok {
+		BadRequest(c, "Invalid format. Must be one of: csv, ndjson")
+		return
+	}
+
+	startDate, endDate, ok := parseLogDates(c, params.StartDate, params.EndDate)
+	if !ok {
+		return
+	}
+
+	filters, ok := parseLogFilters(c, params.Filters)
+	if !ok {
+		return
+	}
+
+	var columns []string
+	if params.Columns != "" {
+		for _, column := range strings.Split(params.Columns, ",") {
+			columns = append(columns, strings.TrimSpace(column))
+		}
+	}
+
+	w := &streamWriter{
+		c:          c,
+		controller: http.NewResponseController(c.Writer),
+		header: func() {
+			filename := fmt.Sprintf("calculation-logs-%s.%s", time.Now().UTC().Format("20060102-150405"), format)
+			c.Header("Content-Type", contentType)
+			c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", filename))
+			c.Status(http.StatusOK)
+		},
+	}
+
+	err := h.exporter.Export(c.Request.Context(), &CalculationLogExport{
+		UserID:        userID,
+		StrategyType:  params.StrategyType,
+		StartDate:     startDate,
+		EndDate:       endDate,
+		IncludeFailed: params.IncludeFailed,
+		Filters:       filters,
+		Format:        format,
+		Columns:       columns,
+	}, w)
+	if err != nil {
+		if !w.started {
+			if errors.Is(err, ErrInvalidLogExport) {
+				BadRequest(c, err.Error())
+				return
+			}
+			HandleError(c, err)
+			return
+		}
+		// The status is already sent; the truncated body is all the client sees
+		_ = c.Error(err)
+	}
+}
+
+// parseLogDates parses the optional start_date and end_date filters.
+// Returns false if a response was written.
+func parseLogDates(c *gin.Context, start, end string) (*time.Time, *time.Time, bool) {
+	var startDate, endDate *time.Time
+	if start != "" {
+		t, err := time.Parse(time.RFC3339, start)
+		if err != nil {
+			BadRequest(c, "Invalid start_date format. Use ISO 8601 (RFC3339)")
+			return nil, nil, false
+		}
+		startDate = &t
+	}
+	if end != "" {
+		t, err := time.Parse(time.RFC3339, end)
+		if err != nil {
+			BadRequest(c, "Invalid end_date format. Use ISO 8601 (RFC3339)")
+			return nil, nil, false
+		}
+		endDate = &t
+	}
+	return startDate, endDate, true
+}
+
+// parseLogFilters parses filter parameters of the form
+// <input|output>.<path>:<op>[:<value>]. Returns false if a response was written.
+func parseLogFilters(c *gin.Context, params []string) ([]LogDataFilter, bool) {
+	if len(params) > maxLogFilters {
+		BadRequest(c, fmt.Sprintf("At most %d filters are allowed", maxLogFilters))
+		return nil, false
+	}
+
+	filters := make([]LogDataFilter, 0, len(params))
+	for _, param := range params {
+		filter, err := parseLogFilter(param)
+		if err != nil {
+			BadRequest(c, fmt.Sprintf("Invalid filter %q: %v", param, err))
+			return nil, false
+		}
+		filters = append(filters, filter)
+	}
+	return filters, true
+}
+
+// parseLogFilter parses one filter parameter. Values are JSON; values that
+// are not valid JSON are strings, so input.region:eq:US-CA needs no quotes.
+func parseLogFilter(param string) (LogDataFilter, error) {
+	field, rest, _ := strings.Cut(param, ":")
+	op, value, hasValue := strings.Cut(rest, ":")
+
+	filter := LogDataFilter{Op: op}
+	if path, ok := strings.CutPrefix(field, "input."); ok {
+		filter.Column = "input_data"
+		filter.Path = strings.Split(path, ".")
+	} else if path, ok := strings.CutPrefix(field, "output."); ok {
+		filter.Column = "output_data"
+		filter.Path = strings.Split(path, ".")
+	} else {
+		return filter, errors.New("the field must start with input. or output.")
+	}
+	for _, key := range filter.Path {
+		if key == "" {
+			return filter, errors.New("path keys cannot be empty")
+		}
+	}
+
+	takesValue, ok := logFilterOperators[op]
+	if !ok {
+		return filter, errors.New("the operator must be one of: eq, gt, gte, lt, lte, exists, contains")
+	}
+	if takesValue && !hasValue {
+		return filter, fmt.Errorf("%s needs a value", op)
+	}
+	if !takesValue && hasValue {
+		return filter, fmt.Errorf("%s takes no value", op)
+	}
+
+	switch op {
+	case "exists":
+	case "gt", "gte", "lt", "lte":
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil || math.IsNaN(n) || math.IsInf(n, 0) {
+			return filter, fmt.Errorf("%s compares a number", op)
+		}
+		filter.Value = n
+	default:
+		if err := json.Unmarshal([]byte(value), &filter.Value); err != nil {
+			filter.Value = value
+		}
+		if op == "eq" {
+			switch filter.Value.(type) {
+			case map[string]interface{}, []interface{}:
+				return filter, errors.New("eq compares a single value; use contains for objects and arrays")
+			}
+		}
 	}
 
-	Success(c, response)
+	return filter, nil
 }
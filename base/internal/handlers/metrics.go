@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MetricsWriter writes metrics in the Prometheus text exposition format
+type MetricsWriter interface {
+	WriteText(w io.Writer) error
+}
+
+// MetricsHandler handles the metrics endpoint
+type MetricsHandler struct {
+	metrics MetricsWriter
+}
+
+// NewMetricsHandler creates a new metrics handler
+func NewMetricsHandler(metrics MetricsWriter) *MetricsHandler {
+	return &MetricsHandler{metrics: metrics}
+}
+
+// Get handles GET /metrics
+func (h *MetricsHandler) Get(c *gin.Context) {
+	c.Header("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	c.Status(http.StatusOK)
+
+	if err := h.metrics.WriteText(c.Writer); err != nil {
+		// The status is already sent; the truncated body is all the client sees
+		_ = c.Error(err)
+	}
+}
@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/saintparish4/harmonia/internal/dto"
+)
+
+// defaultPageLimit is the page size of listings when ?limit is omitted
+const defaultPageLimit = 20
+
+// Cursor is a position in a listing ordered by (created_at, id), newest
+// first. A listing given a cursor continues after the item it names.
+type Cursor struct {
+	CreatedAt time.Time
+	ID        uuid.UUID
+}
+
+// pageRequest is a parsed request for one page of a listing
+type pageRequest struct {
+	limit        int
+	after        *Cursor
+	includeTotal bool
+}
+
+// parsePage reads the limit, cursor and include_total parameters. Returns
+// false if a response was written.
+func parsePage(c *gin.Context, params dto.PageParams) (pageRequest, bool) {
+	page := pageRequest{
+		limit:        params.Limit,
+		includeTotal: params.IncludeTotal,
+	}
+	if page.limit == 0 {
+		page.limit = defaultPageLimit
+	}
+
+	if params.Cursor != "" {
+		cursor, ok := decodeCursor(params.Cursor)
+		if !ok {
+			BadRequest(c, "Invalid cursor. Pass the next_cursor of the previous page")
+			return page, false
+		}
+		page.after = cursor
+	}
+
+	return page, true
+}
+
+// fetchLimit is the number of items to fetch: one more than the page holds,
+// which tells whether another page follows
+func (p pageRequest) fetchLimit() int {
+	return p.limit + 1
+}
+
+// response wraps a page of data. fetched is the number of items fetched with
+// fetchLimit, and last is the position of the last item on the page.
+func (p pageRequest) response(data interface{}, fetched int, last Cursor, total *int) dto.CursorPageResponse {
+	response := dto.CursorPageResponse{
+		Data:    data,
+		Limit:   p.limit,
+		HasMore: fetched > p.limit,
+		Total:   total,
+	}
+	if response.HasMore {
+		next := encodeCursor(last)
+		response.NextCursor = &next
+	}
+	return response
+}
+
+// encodeCursor returns the opaque token naming a position
+func encodeCursor(cursor Cursor) string {
+	position := cursor.CreatedAt.UTC().Format(time.RFC3339Nano) + "," + cursor.ID.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(position))
+}
+
+// decodeCursor parses a token made by encodeCursor
+func decodeCursor(token string) (*Cursor, bool) {
+	position, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, false
+	}
+
+	createdAt, id, ok := strings.Cut(string(position), ",")
+	if !ok {
+		return nil, false
+	}
+
+	cursor := &Cursor{}
+	if cursor.CreatedAt, err = time.Parse(time.RFC3339Nano, createdAt); err != nil {
+		return nil, false
+	}
+	if cursor.ID, err = uuid.Parse(id); err != nil {
+		return nil, false
+	}
+
+	return cursor, true
+}
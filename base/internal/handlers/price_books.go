@@ -0,0 +1,494 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/saintparish4/harmonia/internal/dto"
+)
+
+// Price book content types
+var priceBookContentTypes = map[string]string{
+	"csv":  "text/csv; charset=utf-8",
+	"json": "application/json; charset=utf-8",
+}
+
+// PriceBook represents a named snapshot of a user's products priced at each
+// combination of locations, currencies and channels
+type PriceBook struct {
+	ID             uuid.UUID
+	UserID         uuid.UUID
+	Name           string
+	Description    string
+	Locations      []string
+	Currencies     []string
+	Channels       []string
+	Quantity       int
+	Context        map[string]interface{}
+	AutoRegenerate bool
+	Version        int
+
+	Status        string
+	Generation    int
+	GeneratedAt   *time.Time
+	StartedAt     *time.Time
+	RuleRevisions []PriceBookRevision
+	EntryCount    int
+	ErrorCount    int
+	LastError     string
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+// PriceBookRevision represents a rule revision a price book was priced with
+type PriceBookRevision struct {
+	RuleID     uuid.UUID
+	RevisionID *uuid.UUID
+	Revision   int
+	Entries    int
+}
+
+// PriceBookEntry represents the price of one product at one cell of a price book
+type PriceBookEntry struct {
+	ProductID      uuid.UUID
+	SKU            string
+	Location       string
+	Currency       string
+	Channel        string
+	FinalPrice     *float64
+	PriceCurrency  string
+	BaseCost       float64
+	StrategyType   string
+	RuleID         *uuid.UUID
+	RuleRevisionID *uuid.UUID
+	RuleRevision   int
+	RuleSource     string
+	Error          string
+	GeneratedAt    time.Time
+}
+
+// PriceBookEntryFilter selects entries of a price book
+type PriceBookEntryFilter struct {
+	PriceBookID uuid.UUID
+	SKU         string
+	Location    *string
+	Currency    *string
+	Channel     *string
+	Limit       int
+	Offset      int
+}
+
+// Price book errors
+var (
+	ErrPriceBookExists  = errors.New("a price book with this name already exists")
+	ErrInvalidPriceBook = errors.New("invalid price book")
+	ErrPriceBookBusy    = errors.New("price book is already being generated")
+)
+
+// PriceBooks manages price books and serves their entries
+type PriceBooks interface {
+	// Create stores a price book and generates it in the background
+	Create(ctx context.Context, book *PriceBook) error
+	Get(ctx context.Context, id uuid.UUID) (*PriceBook, error)
+	List(ctx context.Context, userID uuid.UUID) ([]*PriceBook, error)
+	// Update redefines a price book and regenerates it in the background
+	Update(ctx context.Context, book *PriceBook) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	// Regenerate starts a full generation, or returns ErrPriceBookBusy
+	Regenerate(ctx context.Context, id uuid.UUID) (*PriceBook, error)
+	// Entries returns a page of entries and the total matching the filter
+	Entries(ctx context.Context, filter PriceBookEntryFilter) ([]*PriceBookEntry, int, error)
+	// Export writes every entry of a price book to w in the given format
+	Export(ctx context.Context, id uuid.UUID, format string, w io.Writer) error
+}
+
+// PriceBooksHandler handles price book endpoints
+type PriceBooksHandler struct {
+	books PriceBooks
+}
+
+// NewPriceBooksHandler creates a new price books handler
+func NewPriceBooksHandler(books PriceBooks) *PriceBooksHandler {
+	return &PriceBooksHandler{books: books}
+}
+
+// List handles GET /v1/price-books
+func (h *PriceBooksHandler) List(c *gin.Context) {
+	// Get user ID from context
+	userID := MustGetUserID(c)
+	if userID == uuid.Nil {
+		return
+	}
+
+	books, err := h.books.List(c.Request.Context(), userID)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	response := make([]dto.PriceBookResponse, len(books))
+	for i, book := range books {
+		response[i] = priceBookResponse(book)
+	}
+
+	Success(c, response)
+}
+
+// Create handles POST /v1/price-books
+// The book is generated in the background; poll it until its status is ready.
+func (h *PriceBooksHandler) Create(c *gin.Context) {
+	// Get user ID from context
+	userID := MustGetUserID(c)
+	if userID == uuid.Nil {
+		return
+	}
+
+	// Bind request
+	var req dto.CreatePriceBookRequest
+	if !BindJSON(c, &req) {
+		return
+	}
+
+	context := req.Context
+	if context == nil {
+		context = map[string]interface{}{}
+	}
+	autoRegenerate := true
+	if req.AutoRegenerate != nil {
+		autoRegenerate = *req.AutoRegenerate
+	}
+
+	book := &PriceBook{
+		ID:             uuid.New(),
+		UserID:         userID,
+		Name:           req.Name,
+		Description:    req.Description,
+		Locations:      req.Dimensions.Locations,
+		Currencies:     req.Dimensions.Currencies,
+		Channels:       req.Dimensions.Channels,
+		Quantity:       req.Quantity,
+		Context:        context,
+		AutoRegenerate: autoRegenerate,
+	}
+
+	if err := h.books.Create(c.Request.Context(), book); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.Header("Location", "/v1/price-books/"+book.ID.String())
+	Created(c, priceBookResponse(book))
+}
+
+// Get handles GET /v1/price-books/:id
+func (h *PriceBooksHandler) Get(c *gin.Context) {
+	book, ok := h.ownedPriceBook(c)
+	if !ok {
+		return
+	}
+
+	Success(c, priceBookResponse(book))
+}
+
+// Update handles PUT /v1/price-books/:id
+// The previous entries are served until the redefined book is generated.
+func (h *PriceBooksHandler) Update(c *gin.Context) {
+	book, ok := h.ownedPriceBook(c)
+	if !ok {
+		return
+	}
+
+	// Bind request
+	var req dto.UpdatePriceBookRequest
+	if !BindJSON(c, &req) {
+		return
+	}
+
+	// Update fields
+	if req.Name != nil {
+		book.Name = *req.Name
+	}
+	if req.Description != nil {
+		book.Description = *req.Description
+	}
+	if req.Dimensions != nil {
+		book.Locations = req.Dimensions.Locations
+		book.Currencies = req.Dimensions.Currencies
+		book.Channels = req.Dimensions.Channels
+	}
+	if req.Quantity != nil {
+		book.Quantity = *req.Quantity
+	}
+	if req.Context != nil {
+		book.Context = req.Context
+	}
+	if req.AutoRegenerate != nil {
+		book.AutoRegenerate = *req.AutoRegenerate
+	}
+
+	// Save updates
+	if err := h.books.Update(c.Request.Context(), book); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	Success(c, priceBookResponse(book))
+}
+
+// Delete handles DELETE /v1/price-books/:id
+func (h *PriceBooksHandler) Delete(c *gin.Context) {
+	book, ok := h.ownedPriceBook(c)
+	if !ok {
+		return
+	}
+
+	if err := h.books.Delete(c.Request.Context(), book.ID); err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	NoContent(c)
+}
+
+// Regenerate handles POST /v1/price-books/:id/regenerate
+// Starts a full generation in the background, including for books that do
+// not regenerate automatically.
+func (h *PriceBooksHandler) Regenerate(c *gin.Context) {
+	book, ok := h.ownedPriceBook(c)
+	if !ok {
+		return
+	}
+
+	book, err := h.books.Regenerate(c.Request.Context(), book.ID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	Accepted(c, priceBookResponse(book))
+}
+
+// Entries handles GET /v1/price-books/:id/entries
+// Serves the materialized prices, filtered by ?sku=, ?location=, ?currency=
+// and ?channel=, with limit/offset pagination. The ETag changes with every
+// generation, so clients can poll with If-None-Match.
+func (h *PriceBooksHandler) Entries(c *gin.Context) {
+	book, ok := h.ownedPriceBook(c)
+	if !ok {
+		return
+	}
+
+	// Bind query parameters
+	var params dto.PriceBookEntriesQueryParams
+	if err := c.ShouldBindQuery(&params); err != nil {
+		BadRequest(c, err.Error())
+		return
+	}
+
+	// Set defaults
+	if params.Limit == 0 {
+		params.Limit = 20
+	}
+
+	etag := priceBookETag(book)
+	setPriceBookHeaders(c, book)
+	c.Header("ETag", etag)
+	if c.GetHeader("If-None-Match") == etag {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	entries, total, err := h.books.Entries(c.Request.Context(), PriceBookEntryFilter{
+		PriceBookID: book.ID,
+		SKU:         params.SKU,
+		Location:    params.Location,
+		Currency:    params.Currency,
+		Channel:     params.Channel,
+		Limit:       params.Limit,
+		Offset:      params.Offset,
+	})
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	entryResponses := make([]dto.PriceBookEntryResponse, len(entries))
+	for i, entry := range entries {
+		entryResponses[i] = priceBookEntryResponse(entry)
+	}
+
+	Success(c, dto.PaginatedResponse{
+		Data:    entryResponses,
+		Total:   total,
+		Limit:   params.Limit,
+		Offset:  params.Offset,
+		HasMore: params.Offset+params.Limit < total,
+	})
+}
+
+// Export handles GET /v1/price-books/:id/export
+// ?format=csv|json selects the file format. JSON files carry the book's
+// generation time and rule revisions alongside the entries.
+func (h *PriceBooksHandler) Export(c *gin.Context) {
+	book, ok := h.ownedPriceBook(c)
+	if !ok {
+		return
+	}
+
+	format := c.DefaultQuery("format", "csv")
+	contentType, ok := priceBookContentTypes[format]
+	if !ok {
+		BadRequest(c, "Invalid format. Must be one of: csv, json")
+		return
+	}
+
+	w := &streamWriter{
+		c:          c,
+		controller: http.NewResponseController(c.Writer),
+		header: func() {
+			filename := fmt.Sprintf("price-book-%s-%d.%s", book.ID, book.Generation, format)
+			setPriceBookHeaders(c, book)
+			c.Header("Content-Type", contentType)
+			c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", filename))
+			c.Status(http.StatusOK)
+		},
+	}
+
+	if err := h.books.Export(c.Request.Context(), book.ID, format, w); err != nil {
+		if !w.started {
+			HandleError(c, err)
+			return
+		}
+		// The status is already sent; the truncated body is all the client sees
+		_ = c.Error(err)
+	}
+}
+
+// ownedPriceBook loads the price book named by the :id parameter and verifies
+// the caller owns it. Returns false if a response was written.
+func (h *PriceBooksHandler) ownedPriceBook(c *gin.Context) (*PriceBook, bool) {
+	// Get user ID from context
+	userID := MustGetUserID(c)
+	if userID == uuid.Nil {
+		return nil, false
+	}
+
+	// Validate price book ID
+	bookID, err := ValidateUUID(c, "id")
+	if err != nil {
+		BadRequest(c, "Invalid price book ID")
+		return nil, false
+	}
+
+	book, err := h.books.Get(c.Request.Context(), bookID)
+	if err != nil {
+		NotFound(c, "Price book not found")
+		return nil, false
+	}
+
+	// Verify ownership
+	if book.UserID != userID {
+		Forbidden(c, "Access denied")
+		return nil, false
+	}
+
+	return book, true
+}
+
+// handleError writes the response for a price book error
+func (h *PriceBooksHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrPriceBookExists):
+		Conflict(c, "A price book with this name already exists")
+	case errors.Is(err, ErrPriceBookBusy):
+		Conflict(c, "Price book is already being generated")
+	case errors.Is(err, ErrInvalidPriceBook):
+		BadRequest(c, err.Error())
+	default:
+		HandleError(c, err)
+	}
+}
+
+// priceBookETag identifies the entries of a price book's current generation
+func priceBookETag(book *PriceBook) string {
+	return fmt.Sprintf(`"%s-%d"`, book.ID, book.Generation)
+}
+
+// setPriceBookHeaders describes the generation a response was served from
+func setPriceBookHeaders(c *gin.Context, book *PriceBook) {
+	c.Header("X-Price-Book-Generation", strconv.Itoa(book.Generation))
+	if book.GeneratedAt != nil {
+		c.Header("X-Price-Book-Generated-At", book.GeneratedAt.UTC().Format(time.RFC3339))
+	}
+}
+
+// priceBookResponse converts a price book to its response DTO
+func priceBookResponse(book *PriceBook) dto.PriceBookResponse {
+	revisions := make([]dto.PriceBookRevisionResponse, len(book.RuleRevisions))
+	for i, revision := range book.RuleRevisions {
+		revisions[i] = dto.PriceBookRevisionResponse{
+			RuleID:     revision.RuleID,
+			RevisionID: revision.RevisionID,
+			Revision:   revision.Revision,
+			Entries:    revision.Entries,
+		}
+	}
+
+	context := book.Context
+	if context == nil {
+		context = map[string]interface{}{}
+	}
+
+	return dto.PriceBookResponse{
+		ID:          book.ID,
+		Name:        book.Name,
+		Description: book.Description,
+		Dimensions: dto.PriceBookDimensions{
+			Locations:  book.Locations,
+			Currencies: book.Currencies,
+			Channels:   book.Channels,
+		},
+		Quantity:       book.Quantity,
+		Context:        context,
+		AutoRegenerate: book.AutoRegenerate,
+		Version:        book.Version,
+		Status:         book.Status,
+		Generation:     book.Generation,
+		GeneratedAt:    book.GeneratedAt,
+		StartedAt:      book.StartedAt,
+		RuleRevisions:  revisions,
+		EntryCount:     book.EntryCount,
+		ErrorCount:     book.ErrorCount,
+		LastError:      book.LastError,
+		CreatedAt:      book.CreatedAt,
+		UpdatedAt:      book.UpdatedAt,
+	}
+}
+
+// priceBookEntryResponse converts a price book entry to its response DTO
+func priceBookEntryResponse(entry *PriceBookEntry) dto.PriceBookEntryResponse {
+	return dto.PriceBookEntryResponse{
+		ProductID:      entry.ProductID,
+		SKU:            entry.SKU,
+		Location:       entry.Location,
+		Currency:       entry.Currency,
+		Channel:        entry.Channel,
+		FinalPrice:     entry.FinalPrice,
+		PriceCurrency:  entry.PriceCurrency,
+		BaseCost:       entry.BaseCost,
+		StrategyType:   entry.StrategyType,
+		RuleID:         entry.RuleID,
+		RuleRevisionID: entry.RuleRevisionID,
+		RuleRevision:   entry.RuleRevision,
+		RuleSource:     entry.RuleSource,
+		Error:          entry.Error,
+		GeneratedAt:    entry.GeneratedAt,
+	}
+}
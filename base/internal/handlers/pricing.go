@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -9,29 +10,90 @@ import (
 	"github.com/saintparish4/harmonia/internal/dto"
 )
 
+// ErrPricingUnavailable is returned by Calculate when data the price depends
+// on could not be read; unlike invalid requests it is a server error
+var ErrPricingUnavailable = errors.New("pricing data unavailable")
+
 // PricingRequest represents a pricing calculation request
 type PricingRequest struct {
+	UserID       uuid.UUID
+	RuleID       *uuid.UUID // Price with a saved rule instead of inline config
+	ProductSKU   string     // Without a strategy or rule, price with the product's scheduled rule
 	StrategyType string
 	BasePrice    float64
 	Quantity     int
 	Context      map[string]interface{}
+	RequestedAt  time.Time // Rules must be in effect at this time
 }
 
 // PricingResult represents a pricing calculation result
 type PricingResult struct {
-	FinalPrice float64
-	Breakdown  map[string]interface{}
+	FinalPrice     float64
+	StrategyType   string
+	RuleID         *uuid.UUID
+	RuleRevisionID *uuid.UUID
+	RuleRevision   int
+	RuleSource     *RuleSource  // Set when a saved rule priced the request
+	ProductCost    *AppliedCost // Set when the product's cost history supplied base_cost
+	Breakdown      map[string]interface{}
+	ExecutionTime  time.Duration // Time spent in the pricing engine
+	EngineVersion  string
+}
+
+// AppliedCost identifies the product cost a calculation priced with
+type AppliedCost struct {
+	ID            uuid.UUID
+	Cost          float64
+	EffectiveFrom time.Time
+}
+
+// RuleSource identifies where the rule of a calculation was configured
+type RuleSource struct {
+	Level string // request, schedule, product, category or account
+	ID    uuid.UUID
+	Name  string
+}
+
+// StrategySchema describes a pricing strategy's config and inputs as JSON Schema
+type StrategySchema struct {
+	Type         string
+	Name         string
+	Description  string
+	ConfigSchema map[string]interface{}
+	InputSchema  map[string]interface{}
 }
 
 // PricingEngine defines interface for pricing calculations
 type PricingEngine interface {
 	Calculate(ctx context.Context, req *PricingRequest) (*PricingResult, error)
 	GetAvailableStrategies() []string
+	GetStrategySchemas() []*StrategySchema
+	GetStrategySchema(strategyType string) (*StrategySchema, error)
 }
 
-// CalculationLogger defines interface for logging calculations
+// CalculationRecord represents a calculation to write to the audit log
+type CalculationRecord struct {
+	ID              uuid.UUID
+	UserID          uuid.UUID
+	APIKeyID        *uuid.UUID
+	RuleID          *uuid.UUID
+	RuleRevisionID  *uuid.UUID
+	RuleRevision    int
+	StrategyType    string
+	Input           map[string]interface{}
+	Output          map[string]interface{}
+	ExecutionTimeMs float64
+	RequestID       string
+	ClientIP        string
+	EngineVersion   string
+	Error           string // set when the calculation failed
+	CreatedAt       time.Time
+}
+
+// CalculationLogger defines interface for logging calculations. Log must not
+// wait on storage, since it runs on the request path.
 type CalculationLogger interface {
-	Log(ctx context.Context, userID uuid.UUID, strategyType string, input, output map[string]interface{}) error
+	Log(ctx context.Context, record *CalculationRecord) error
 }
 
 // PricingHandler handles pricing-related endpoints
@@ -50,36 +112,42 @@ func NewPricingHandler(engine PricingEngine, logger CalculationLogger) *PricingH
 
 // ListStrategies handles GET /v1/pricing/strategies
 func (h *PricingHandler) ListStrategies(c *gin.Context) {
-	strategies := []dto.PricingStrategyResponse{
-		{
-			Type:           "cost_plus",
-			Name:           "Cost-Plus Pricing",
-			Description:    "Adds a fixed markup or percentage to the base cost",
-			RequiredFields: []string{"base_price", "markup_type", "markup_value"},
-		},
-		{
-			Type:           "geographic",
-			Name:           "Geographic Pricing",
-			Description:    "Applies regional multipliers based on location",
-			RequiredFields: []string{"base_price", "region_code"},
-		},
-		{
-			Type:           "time_based",
-			Name:           "Time-Based Surge Pricing",
-			Description:    "Adjusts price based on time windows and demand",
-			RequiredFields: []string{"base_price"},
-		},
-		{
-			Type:           "rule_based",
-			Name:           "Rule-Based Pricing",
-			Description:    "Applies custom conditional rules to determine price",
-			RequiredFields: []string{"base_price", "context"},
-		},
+	schemas := h.engine.GetStrategySchemas()
+
+	strategies := make([]dto.PricingStrategyResponse, len(schemas))
+	for i, schema := range schemas {
+		strategies[i] = dto.PricingStrategyResponse{
+			Type:           schema.Type,
+			Name:           schema.Name,
+			Description:    schema.Description,
+			RequiredFields: requiredFields(schema.InputSchema),
+			RequiredConfig: requiredFields(schema.ConfigSchema),
+			SchemaURL:      "/v1/pricing/strategies/" + schema.Type + "/schema",
+		}
 	}
 
 	Success(c, strategies)
 }
 
+// GetStrategySchema handles GET /v1/pricing/strategies/:type/schema
+func (h *PricingHandler) GetStrategySchema(c *gin.Context) {
+	schema, err := h.engine.GetStrategySchema(c.Param("type"))
+	if err != nil {
+		NotFound(c, "Pricing strategy not found")
+		return
+	}
+
+	response := dto.StrategySchemaResponse{
+		Type:         schema.Type,
+		Name:         schema.Name,
+		Description:  schema.Description,
+		ConfigSchema: schema.ConfigSchema,
+		InputSchema:  schema.InputSchema,
+	}
+
+	Success(c, response)
+}
+
 // Calculate handles POST /v1/pricing/calculate
 func (h *PricingHandler) Calculate(c *gin.Context) {
 	// Get user ID from context
@@ -100,60 +168,178 @@ func (h *PricingHandler) Calculate(c *gin.Context) {
 		"geographic": true,
 		"time_based": true,
 		"rule_based": true,
+		"formula":    true,
 	}
 
-	if !validStrategies[req.StrategyType] {
-		BadRequest(c, "Invalid strategy type. Must be one of: cost_plus, geographic, time_based, rule_based")
+	// A saved rule supplies its own strategy
+	if req.StrategyType != "" && !validStrategies[req.StrategyType] {
+		BadRequest(c, "Invalid strategy type. Must be one of: cost_plus, geographic, time_based, rule_based, formula")
 		return
 	}
 
 	ctx := c.Request.Context()
 
+	requestedAt := time.Now().UTC()
+	if req.RequestedAt != nil {
+		requestedAt = req.RequestedAt.UTC()
+	}
+
 	// Prepare pricing request
 	pricingReq := &PricingRequest{
+		UserID:       userID,
+		RuleID:       req.RuleID,
+		ProductSKU:   req.ProductSKU,
 		StrategyType: req.StrategyType,
 		BasePrice:    req.BasePrice,
 		Quantity:     req.Quantity,
 		Context:      req.Context,
+		RequestedAt:  requestedAt,
 	}
 
 	// Calculate price
 	result, err := h.engine.Calculate(ctx, pricingReq)
 	if err != nil {
+		h.logFailure(c, userID, &req, requestedAt, err)
+		if errors.Is(err, ErrPricingUnavailable) {
+			HandleError(c, err)
+			return
+		}
 		BadRequest(c, err.Error())
 		return
 	}
 
 	// Log calculation
-	inputData := map[string]interface{}{
-		"strategy_type": req.StrategyType,
-		"base_price":    req.BasePrice,
-		"quantity":      req.Quantity,
-		"context":       req.Context,
-		"product_sku":   req.ProductSKU,
-	}
+	inputData := calculationInput(&req, requestedAt)
+	inputData["strategy_type"] = result.StrategyType
 
 	outputData := map[string]interface{}{
 		"final_price": result.FinalPrice,
 		"breakdown":   result.Breakdown,
 	}
 
-	go func() {
-		// Log asynchronously to not block response
-		bgCtx := context.Background()
-		if err := h.logger.Log(bgCtx, userID, req.StrategyType, inputData, outputData); err != nil {
-			// Log error but don't fail the request
-			// In production, you'd want proper logging here
-		}
-	}()
+	if result.RuleID != nil {
+		inputData["rule_id"] = result.RuleID.String()
+	}
+	if result.RuleSource != nil {
+		inputData["rule_source"] = result.RuleSource.Level
+	}
+	if result.ProductCost != nil {
+		inputData["product_cost"] = result.ProductCost.Cost
+		inputData["product_cost_id"] = result.ProductCost.ID.String()
+	}
+
+	record := &CalculationRecord{
+		ID:              uuid.New(),
+		UserID:          userID,
+		APIKeyID:        GetAPIKeyID(c),
+		RuleID:          result.RuleID,
+		RuleRevisionID:  result.RuleRevisionID,
+		RuleRevision:    result.RuleRevision,
+		StrategyType:    result.StrategyType,
+		Input:           inputData,
+		Output:          outputData,
+		ExecutionTimeMs: float64(result.ExecutionTime.Microseconds()) / 1000,
+		RequestID:       GetRequestID(c),
+		ClientIP:        c.ClientIP(),
+		EngineVersion:   result.EngineVersion,
+		CreatedAt:       time.Now(),
+	}
+
+	// The log is queued and written in the background; a log dropped under
+	// load doesn't fail the request
+	if err := h.logger.Log(c.Request.Context(), record); err != nil {
+		_ = c.Error(err)
+	}
 
 	// Return response
 	response := dto.CalculatePriceResponse{
-		FinalPrice:   result.FinalPrice,
-		StrategyType: req.StrategyType,
-		Breakdown:    result.Breakdown,
-		CalculatedAt: time.Now().UTC(),
+		FinalPrice:     result.FinalPrice,
+		StrategyType:   result.StrategyType,
+		RuleID:         result.RuleID,
+		RuleRevisionID: result.RuleRevisionID,
+		RuleRevision:   result.RuleRevision,
+		RuleSource:     ruleSourceResponse(result.RuleSource),
+		ProductCost:    appliedCostResponse(result.ProductCost),
+		Breakdown:      result.Breakdown,
+		RequestedAt:    requestedAt,
+		CalculatedAt:   time.Now().UTC(),
+		LogID:          record.ID,
 	}
 
 	Success(c, response)
 }
+
+// logFailure logs a calculation the engine rejected, so analytics can report
+// error rates. The requested rule is only recorded in the input, since it
+// may not exist.
+func (h *PricingHandler) logFailure(c *gin.Context, userID uuid.UUID, req *dto.CalculatePriceRequest, requestedAt time.Time, calcErr error) {
+	inputData := calculationInput(req, requestedAt)
+	inputData["strategy_type"] = req.StrategyType
+	if req.RuleID != nil {
+		inputData["rule_id"] = req.RuleID.String()
+	}
+
+	record := &CalculationRecord{
+		ID:           uuid.New(),
+		UserID:       userID,
+		APIKeyID:     GetAPIKeyID(c),
+		StrategyType: req.StrategyType,
+		Input:        inputData,
+		Output:       map[string]interface{}{},
+		RequestID:    GetRequestID(c),
+		ClientIP:     c.ClientIP(),
+		Error:        calcErr.Error(),
+		CreatedAt:    time.Now(),
+	}
+
+	if err := h.logger.Log(c.Request.Context(), record); err != nil {
+		_ = c.Error(err)
+	}
+}
+
+// calculationInput is the logged input of a calculation request
+func calculationInput(req *dto.CalculatePriceRequest, requestedAt time.Time) map[string]interface{} {
+	inputData := map[string]interface{}{
+		"base_price":  req.BasePrice,
+		"quantity":    req.Quantity,
+		"context":     req.Context,
+		"product_sku": req.ProductSKU,
+	}
+	if req.RequestedAt != nil {
+		inputData["requested_at"] = requestedAt.Format(time.RFC3339)
+	}
+	return inputData
+}
+
+// ruleSourceResponse converts a rule source to its response DTO
+func ruleSourceResponse(source *RuleSource) *dto.RuleSourceResponse {
+	if source == nil {
+		return nil
+	}
+	return &dto.RuleSourceResponse{
+		Level: source.Level,
+		ID:    source.ID,
+		Name:  source.Name,
+	}
+}
+
+// appliedCostResponse converts an applied product cost to its response DTO
+func appliedCostResponse(cost *AppliedCost) *dto.AppliedCostResponse {
+	if cost == nil {
+		return nil
+	}
+	return &dto.AppliedCostResponse{
+		ID:            cost.ID,
+		Cost:          cost.Cost,
+		EffectiveFrom: cost.EffectiveFrom,
+	}
+}
+
+// requiredFields returns the required property names of a JSON Schema object
+func requiredFields(schema map[string]interface{}) []string {
+	required, _ := schema["required"].([]string)
+	if required == nil {
+		return []string{}
+	}
+	return required
+}
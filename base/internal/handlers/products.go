@@ -2,6 +2,8 @@ package handlers
 
 import (
 	"context"
+	"errors"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -10,13 +12,103 @@ import (
 
 // Product represents a product domain model
 type Product struct {
-	ID          uuid.UUID
-	UserID      uuid.UUID
+	ID            uuid.UUID
+	UserID        uuid.UUID
+	SKU           string
+	Name          string
+	Description   string
+	BaseCost      float64
+	DefaultRuleID *uuid.UUID
+	CategoryID    *uuid.UUID
+	Metadata      map[string]interface{}
+	IsActive      bool
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+// ProductFilter selects a page of a user's products
+type ProductFilter struct {
+	UserID     uuid.UUID
+	IsActive   *bool
+	Category   string
+	CategoryID *uuid.UUID // Includes subcategories
+	Metadata   map[string]string
+	Limit      int
+	After      *Cursor
+}
+
+// RuleAssignment schedules a pricing rule as a product's default
+type RuleAssignment struct {
+	ID             uuid.UUID
+	ProductID      uuid.UUID
+	RuleID         uuid.UUID
+	EffectiveFrom  *time.Time
+	EffectiveTo    *time.Time
+	ScheduleStatus string // Relative to now
+	CreatedAt      time.Time
+}
+
+// Rule assignment errors
+var (
+	// ErrScheduleOverlap is returned when a rule assignment overlaps an existing one
+	ErrScheduleOverlap = errors.New("schedule overlaps an existing assignment")
+	// ErrAssignmentNotFound is returned when a product has no such rule assignment
+	ErrAssignmentNotFound = errors.New("rule assignment not found")
+)
+
+// ProductCost represents an entry of a product's cost history. The window,
+// status and change fields are set on timeline entries.
+type ProductCost struct {
+	ID             uuid.UUID
+	ProductID      uuid.UUID
+	Cost           float64
+	EffectiveFrom  time.Time
+	EffectiveTo    *time.Time
+	Status         string // Relative to now
+	Source         string
+	Note           string
+	Change         *float64
+	ChangePercent  *float64
+	PreviousCostID *uuid.UUID
+	CreatedBy      *uuid.UUID
+	CreatedAt      time.Time
+}
+
+// PriceAtCost represents a product's price at one unit cost
+type PriceAtCost struct {
+	Cost          float64
+	FinalPrice    float64
+	Margin        float64
+	MarginPercent float64
+}
+
+// CostRecomputeReport compares a product's price before and after a cost change
+type CostRecomputeReport struct {
+	ProductID   uuid.UUID
 	SKU         string
-	Name        string
-	Description string
-	BaseCost    float64
-	IsActive    bool
+	CostID      uuid.UUID
+	EffectiveAt time.Time
+	RuleID      *uuid.UUID
+	RuleSource  *RuleSource
+	Before      *PriceAtCost // Nil for a product's first cost
+	After       *PriceAtCost
+	PriceChange *float64
+	Error       string
+}
+
+// Product cost errors
+var (
+	ErrCostExists   = errors.New("product already has a cost effective at this time")
+	ErrCostNotFound = errors.New("product cost not found")
+	ErrInvalidCost  = errors.New("invalid product cost")
+)
+
+// ProductCosts manages effective-dated product costs
+type ProductCosts interface {
+	Timeline(ctx context.Context, productID uuid.UUID) ([]*ProductCost, error)
+	AddCost(ctx context.Context, cost *ProductCost) error
+	// Recompute prices the product before and after a cost change took effect
+	Recompute(ctx context.Context, userID, productID, costID uuid.UUID, quantity int, context map[string]interface{}) (*CostRecomputeReport, error)
 }
 
 // ProductRepository defines operations for product management
@@ -24,19 +116,26 @@ type ProductRepository interface {
 	Create(ctx context.Context, product *Product) error
 	GetByID(ctx context.Context, id uuid.UUID) (*Product, error)
 	GetBySKU(ctx context.Context, userID uuid.UUID, sku string) (*Product, error)
-	GetByUserID(ctx context.Context, userID uuid.UUID) ([]*Product, error)
+	List(ctx context.Context, filter ProductFilter) ([]*Product, error)
+	Count(ctx context.Context, filter ProductFilter) (int, error)
 	Update(ctx context.Context, product *Product) error
 	Delete(ctx context.Context, id uuid.UUID) error
+	AddRuleAssignment(ctx context.Context, assignment *RuleAssignment) error
+	ListRuleAssignments(ctx context.Context, productID uuid.UUID) ([]*RuleAssignment, error)
+	DeleteRuleAssignment(ctx context.Context, productID, id uuid.UUID) error
 }
 
 // ProductsHandler handles product endpoints
 type ProductsHandler struct {
-	repo ProductRepository
+	repo       ProductRepository
+	rules      PricingRuleRepository
+	categories CategoryRepository
+	costs      ProductCosts
 }
 
 // NewProductsHandler creates a new products handler
-func NewProductsHandler(repo ProductRepository) *ProductsHandler {
-	return &ProductsHandler{repo: repo}
+func NewProductsHandler(repo ProductRepository, rules PricingRuleRepository, categories CategoryRepository, costs ProductCosts) *ProductsHandler {
+	return &ProductsHandler{repo: repo, rules: rules, categories: categories, costs: costs}
 }
 
 // Create handles POST /v1/products
@@ -62,15 +161,35 @@ func (h *ProductsHandler) Create(c *gin.Context) {
 		return
 	}
 
+	if req.DefaultRuleID != nil && !checkOwnedRule(c, h.rules, userID, *req.DefaultRuleID, "/default_rule_id") {
+		return
+	}
+
+	var chain []*Category
+	if req.CategoryID != nil {
+		var ok bool
+		if chain, ok = h.categoryChain(c, userID, *req.CategoryID); !ok {
+			return
+		}
+	}
+
+	metadata := req.Metadata
+	if metadata == nil {
+		metadata = map[string]interface{}{}
+	}
+
 	// Create product
 	product := &Product{
-		ID:          uuid.New(),
-		UserID:      userID,
-		SKU:         req.SKU,
-		Name:        req.Name,
-		Description: req.Description,
-		BaseCost:    req.BaseCost,
-		IsActive:    true,
+		ID:            uuid.New(),
+		UserID:        userID,
+		SKU:           req.SKU,
+		Name:          req.Name,
+		Description:   req.Description,
+		BaseCost:      *req.BaseCost,
+		DefaultRuleID: req.DefaultRuleID,
+		CategoryID:    req.CategoryID,
+		Metadata:      metadata,
+		IsActive:      true,
 	}
 
 	if err := h.repo.Create(ctx, product); err != nil {
@@ -78,21 +197,14 @@ func (h *ProductsHandler) Create(c *gin.Context) {
 		return
 	}
 
-	// Return response
-	response := dto.ProductResponse{
-		ID:          product.ID,
-		UserID:      product.UserID,
-		SKU:         product.SKU,
-		Name:        product.Name,
-		Description: product.Description,
-		BaseCost:    product.BaseCost,
-		IsActive:    product.IsActive,
-	}
-
-	Created(c, response)
+	Created(c, productResponse(product, chain))
 }
 
 // List handles GET /v1/products
+// Filters: ?is_active= (default true), ?category=, ?category_id= (with subcategories)
+// and ?metadata[key]=value. Products are listed newest first, a page at a
+// time; pass next_cursor as ?cursor for the next page, and ?include_total=true
+// to count every match.
 func (h *ProductsHandler) List(c *gin.Context) {
 	// Get user ID from context
 	userID := MustGetUserID(c)
@@ -100,181 +212,526 @@ func (h *ProductsHandler) List(c *gin.Context) {
 		return
 	}
 
+	// Bind query parameters
+	var params dto.ProductsQueryParams
+	if err := c.ShouldBindQuery(&params); err != nil {
+		BadRequest(c, err.Error())
+		return
+	}
+
+	page, ok := parsePage(c, params.PageParams)
+	if !ok {
+		return
+	}
+
+	// Set defaults
+	if params.IsActive == nil {
+		active := true
+		params.IsActive = &active
+	}
+
+	filter := ProductFilter{
+		UserID:   userID,
+		IsActive: params.IsActive,
+		Category: params.Category,
+		Metadata: c.QueryMap("metadata"),
+		Limit:    page.fetchLimit(),
+		After:    page.after,
+	}
+	for key := range filter.Metadata {
+		if key == "" {
+			BadRequest(c, "Metadata filters must be given as metadata[key]=value")
+			return
+		}
+	}
+	if params.CategoryID != "" {
+		categoryID, err := uuid.Parse(params.CategoryID)
+		if err != nil {
+			BadRequest(c, "Invalid category ID")
+			return
+		}
+		filter.CategoryID = &categoryID
+	}
+
 	ctx := c.Request.Context()
 
-	// Get all products for user
-	products, err := h.repo.GetByUserID(ctx, userID)
+	products, err := h.repo.List(ctx, filter)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+	fetched := len(products)
+	if fetched > page.limit {
+		products = products[:page.limit]
+	}
+
+	// Get total count if asked for
+	var total *int
+	if page.includeTotal {
+		count, err := h.repo.Count(ctx, filter)
+		if err != nil {
+			HandleError(c, err)
+			return
+		}
+		total = &count
+	}
+
+	// Load the category tree once for every product's inherited attributes
+	categories, err := h.categories.ListByUser(ctx, userID)
 	if err != nil {
 		HandleError(c, err)
 		return
 	}
+	tree := newCategoryTree(categories)
 
 	// Convert to response DTOs
-	response := make([]dto.ProductResponse, len(products))
+	var last Cursor
+	productResponses := make([]dto.ProductResponse, len(products))
 	for i, product := range products {
-		response[i] = dto.ProductResponse{
-			ID:          product.ID,
-			UserID:      product.UserID,
-			SKU:         product.SKU,
-			Name:        product.Name,
-			Description: product.Description,
-			BaseCost:    product.BaseCost,
-			IsActive:    product.IsActive,
+		last = Cursor{CreatedAt: product.CreatedAt, ID: product.ID}
+		var chain []*Category
+		if product.CategoryID != nil {
+			chain = tree.chain(*product.CategoryID)
 		}
+		productResponses[i] = productResponse(product, chain)
 	}
 
-	Success(c, response)
+	Success(c, page.response(productResponses, fetched, last, total))
 }
 
 // Get handles GET /v1/products/:id
 func (h *ProductsHandler) Get(c *gin.Context) {
-	// Get user ID from context
-	userID := MustGetUserID(c)
-	if userID == uuid.Nil {
+	product, ok := h.ownedProduct(c)
+	if !ok {
 		return
 	}
 
-	// Validate product ID
-	productID, err := ValidateUUID(c, "id")
-	if err != nil {
-		BadRequest(c, "Invalid product ID")
+	var chain []*Category
+	if product.CategoryID != nil {
+		var err error
+		if chain, err = h.categories.Ancestors(c.Request.Context(), *product.CategoryID); err != nil {
+			HandleError(c, err)
+			return
+		}
+	}
+
+	Success(c, productResponse(product, chain))
+}
+
+// Update handles PUT /v1/products/:id
+func (h *ProductsHandler) Update(c *gin.Context) {
+	product, ok := h.ownedProduct(c)
+	if !ok {
 		return
 	}
 
-	ctx := c.Request.Context()
+	// Bind request
+	var req dto.UpdateProductRequest
+	if !BindJSON(c, &req) {
+		return
+	}
 
-	// Get product
-	product, err := h.repo.GetByID(ctx, productID)
-	if err != nil {
-		NotFound(c, "Product not found")
+	// Update fields
+	if req.Name != nil {
+		product.Name = *req.Name
+	}
+	if req.Description != nil {
+		product.Description = *req.Description
+	}
+	if req.BaseCost != nil {
+		product.BaseCost = *req.BaseCost
+	}
+	if req.DefaultRuleID.Set {
+		if req.DefaultRuleID.Value != nil && !checkOwnedRule(c, h.rules, product.UserID, *req.DefaultRuleID.Value, "/default_rule_id") {
+			return
+		}
+		product.DefaultRuleID = req.DefaultRuleID.Value
+	}
+	if req.CategoryID.Set {
+		// Moving a product changes the rule its next calculation inherits
+		product.CategoryID = req.CategoryID.Value
+	}
+
+	var chain []*Category
+	if product.CategoryID != nil {
+		var ok bool
+		if chain, ok = h.categoryChain(c, product.UserID, *product.CategoryID); !ok {
+			return
+		}
+	}
+	if req.Metadata != nil {
+		product.Metadata = req.Metadata
+	}
+	if req.IsActive != nil {
+		product.IsActive = *req.IsActive
+	}
+
+	// Save updates
+	if err := h.repo.Update(c.Request.Context(), product); err != nil {
+		HandleError(c, err)
 		return
 	}
 
-	// Verify ownership
-	if product.UserID != userID {
-		Forbidden(c, "Access denied")
+	Success(c, productResponse(product, chain))
+}
+
+// Delete handles DELETE /v1/products/:id
+func (h *ProductsHandler) Delete(c *gin.Context) {
+	product, ok := h.ownedProduct(c)
+	if !ok {
 		return
 	}
 
-	// Return response
-	response := dto.ProductResponse{
-		ID:          product.ID,
-		UserID:      product.UserID,
-		SKU:         product.SKU,
-		Name:        product.Name,
-		Description: product.Description,
-		BaseCost:    product.BaseCost,
-		IsActive:    product.IsActive,
+	// Delete product
+	if err := h.repo.Delete(c.Request.Context(), product.ID); err != nil {
+		HandleError(c, err)
+		return
 	}
 
-	Success(c, response)
+	NoContent(c)
 }
 
-// Update handles PUT /v1/products/:id
-func (h *ProductsHandler) Update(c *gin.Context) {
-	// Get user ID from context
-	userID := MustGetUserID(c)
-	if userID == uuid.Nil {
+// ListRuleSchedule handles GET /v1/products/:id/rule-schedule
+func (h *ProductsHandler) ListRuleSchedule(c *gin.Context) {
+	product, ok := h.ownedProduct(c)
+	if !ok {
 		return
 	}
 
-	// Validate product ID
-	productID, err := ValidateUUID(c, "id")
+	assignments, err := h.repo.ListRuleAssignments(c.Request.Context(), product.ID)
 	if err != nil {
-		BadRequest(c, "Invalid product ID")
+		HandleError(c, err)
+		return
+	}
+
+	response := make([]dto.RuleAssignmentResponse, len(assignments))
+	for i, assignment := range assignments {
+		response[i] = ruleAssignmentResponse(assignment)
+	}
+
+	Success(c, response)
+}
+
+// AddRuleSchedule handles POST /v1/products/:id/rule-schedule
+// Windows for the same product may not overlap.
+func (h *ProductsHandler) AddRuleSchedule(c *gin.Context) {
+	product, ok := h.ownedProduct(c)
+	if !ok {
 		return
 	}
 
 	// Bind request
-	var req dto.UpdateProductRequest
+	var req dto.CreateRuleAssignmentRequest
 	if !BindJSON(c, &req) {
 		return
 	}
 
+	if !checkSchedule(c, req.EffectiveFrom, req.EffectiveTo) {
+		return
+	}
+
 	ctx := c.Request.Context()
 
-	// Get existing product
-	product, err := h.repo.GetByID(ctx, productID)
-	if err != nil {
-		NotFound(c, "Product not found")
+	// The scheduled rule must belong to the same user
+	rule, err := h.rules.GetByID(ctx, req.RuleID)
+	if err != nil || rule.UserID != product.UserID {
+		BadRequestWithDetails(c, "Invalid rule schedule", map[string]string{
+			"/rule_id": "pricing rule not found",
+		})
 		return
 	}
 
-	// Verify ownership
-	if product.UserID != userID {
-		Forbidden(c, "Access denied")
+	assignment := &RuleAssignment{
+		ProductID:     product.ID,
+		RuleID:        rule.ID,
+		EffectiveFrom: req.EffectiveFrom,
+		EffectiveTo:   req.EffectiveTo,
+	}
+
+	if err := h.repo.AddRuleAssignment(ctx, assignment); err != nil {
+		if errors.Is(err, ErrScheduleOverlap) {
+			Conflict(c, "Schedule overlaps an existing rule assignment for this product")
+			return
+		}
+		HandleError(c, err)
 		return
 	}
 
-	// Update fields
-	if req.Name != nil {
-		product.Name = *req.Name
+	Created(c, ruleAssignmentResponse(assignment))
+}
+
+// DeleteRuleSchedule handles DELETE /v1/products/:id/rule-schedule/:assignment_id
+func (h *ProductsHandler) DeleteRuleSchedule(c *gin.Context) {
+	product, ok := h.ownedProduct(c)
+	if !ok {
+		return
 	}
-	if req.Description != nil {
-		product.Description = *req.Description
+
+	assignmentID, err := ValidateUUID(c, "assignment_id")
+	if err != nil {
+		BadRequest(c, "Invalid assignment ID")
+		return
 	}
-	if req.BaseCost != nil {
-		product.BaseCost = *req.BaseCost
+
+	if err := h.repo.DeleteRuleAssignment(c.Request.Context(), product.ID, assignmentID); err != nil {
+		if errors.Is(err, ErrAssignmentNotFound) {
+			NotFound(c, "Rule assignment not found")
+			return
+		}
+		HandleError(c, err)
+		return
 	}
-	if req.IsActive != nil {
-		product.IsActive = *req.IsActive
+
+	NoContent(c)
+}
+
+// ListCosts handles GET /v1/products/:id/costs
+// Returns the product's cost timeline, earliest first.
+func (h *ProductsHandler) ListCosts(c *gin.Context) {
+	product, ok := h.ownedProduct(c)
+	if !ok {
+		return
 	}
 
-	// Save updates
-	if err := h.repo.Update(ctx, product); err != nil {
+	timeline, err := h.costs.Timeline(c.Request.Context(), product.ID)
+	if err != nil {
 		HandleError(c, err)
 		return
 	}
 
-	// Return response
-	response := dto.ProductResponse{
-		ID:          product.ID,
-		UserID:      product.UserID,
-		SKU:         product.SKU,
-		Name:        product.Name,
-		Description: product.Description,
-		BaseCost:    product.BaseCost,
-		IsActive:    product.IsActive,
+	response := make([]dto.ProductCostResponse, len(timeline))
+	for i, cost := range timeline {
+		response[i] = productCostResponse(cost)
 	}
 
 	Success(c, response)
 }
 
-// Delete handles DELETE /v1/products/:id
-func (h *ProductsHandler) Delete(c *gin.Context) {
+// AddCost handles POST /v1/products/:id/costs
+// The cost applies from effective_from until the next entry. With recompute,
+// the response reports how the change moves the product's price.
+func (h *ProductsHandler) AddCost(c *gin.Context) {
+	product, ok := h.ownedProduct(c)
+	if !ok {
+		return
+	}
+
+	// Bind request
+	var req dto.CreateProductCostRequest
+	if !BindJSON(c, &req) {
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	cost := &ProductCost{
+		ProductID: product.ID,
+		Cost:      *req.Cost,
+		Source:    req.Source,
+		Note:      req.Note,
+		CreatedBy: GetAPIKeyID(c),
+	}
+	if req.EffectiveFrom != nil {
+		cost.EffectiveFrom = req.EffectiveFrom.UTC()
+	}
+
+	if err := h.costs.AddCost(ctx, cost); err != nil {
+		switch {
+		case errors.Is(err, ErrCostExists):
+			Conflict(c, "Product already has a cost effective at this time")
+		case errors.Is(err, ErrInvalidCost):
+			BadRequest(c, err.Error())
+		default:
+			HandleError(c, err)
+		}
+		return
+	}
+
+	response := productCostResponse(cost)
+	if req.Recompute != nil {
+		report, err := h.costs.Recompute(ctx, product.UserID, product.ID, cost.ID, req.Recompute.Quantity, req.Recompute.Context)
+		if err != nil {
+			HandleError(c, err)
+			return
+		}
+		recompute := costRecomputeResponse(report)
+		response.Recompute = &recompute
+	}
+
+	Created(c, response)
+}
+
+// RecomputeCost handles POST /v1/products/:id/costs/:cost_id/recompute
+// Reports the product's price just before and after the cost took effect.
+func (h *ProductsHandler) RecomputeCost(c *gin.Context) {
+	product, ok := h.ownedProduct(c)
+	if !ok {
+		return
+	}
+
+	costID, err := ValidateUUID(c, "cost_id")
+	if err != nil {
+		BadRequest(c, "Invalid cost ID")
+		return
+	}
+
+	// The body is optional
+	var req dto.RecomputePriceRequest
+	if c.Request.ContentLength != 0 && !BindJSON(c, &req) {
+		return
+	}
+
+	report, err := h.costs.Recompute(c.Request.Context(), product.UserID, product.ID, costID, req.Quantity, req.Context)
+	if err != nil {
+		if errors.Is(err, ErrCostNotFound) {
+			NotFound(c, "Product cost not found")
+			return
+		}
+		HandleError(c, err)
+		return
+	}
+
+	Success(c, costRecomputeResponse(report))
+}
+
+// ownedProduct loads the product named by the :id parameter and verifies the
+// caller owns it. Returns false if a response was written.
+func (h *ProductsHandler) ownedProduct(c *gin.Context) (*Product, bool) {
 	// Get user ID from context
 	userID := MustGetUserID(c)
 	if userID == uuid.Nil {
-		return
+		return nil, false
 	}
 
 	// Validate product ID
 	productID, err := ValidateUUID(c, "id")
 	if err != nil {
 		BadRequest(c, "Invalid product ID")
-		return
+		return nil, false
 	}
 
-	ctx := c.Request.Context()
-
-	// Get product to verify ownership
-	product, err := h.repo.GetByID(ctx, productID)
+	product, err := h.repo.GetByID(c.Request.Context(), productID)
 	if err != nil {
 		NotFound(c, "Product not found")
-		return
+		return nil, false
 	}
 
 	// Verify ownership
 	if product.UserID != userID {
 		Forbidden(c, "Access denied")
-		return
+		return nil, false
 	}
 
-	// Delete product
-	if err := h.repo.Delete(ctx, productID); err != nil {
-		HandleError(c, err)
-		return
+	return product, true
+}
+
+// categoryChain loads a product's category and its ancestors, verifying the
+// user owns it. Returns false if a response was written.
+func (h *ProductsHandler) categoryChain(c *gin.Context, userID, categoryID uuid.UUID) ([]*Category, bool) {
+	chain, err := h.categories.Ancestors(c.Request.Context(), categoryID)
+	if err != nil || chain[0].UserID != userID {
+		BadRequestWithDetails(c, "Invalid category", map[string]string{
+			"/category_id": "category not found",
+		})
+		return nil, false
 	}
+	return chain, true
+}
 
-	NoContent(c)
+// checkOwnedRule verifies a rule referenced by the request field at pointer
+// belongs to the user. Returns false if a response was written.
+func checkOwnedRule(c *gin.Context, rules PricingRuleRepository, userID, ruleID uuid.UUID, pointer string) bool {
+	rule, err := rules.GetByID(c.Request.Context(), ruleID)
+	if err != nil || rule.UserID != userID {
+		BadRequestWithDetails(c, "Invalid default rule", map[string]string{
+			pointer: "pricing rule not found",
+		})
+		return false
+	}
+	return true
+}
+
+// productResponse converts a product to its response DTO; chain is its
+// category and ancestors, nearest first
+func productResponse(product *Product, chain []*Category) dto.ProductResponse {
+	metadata := product.Metadata
+	if metadata == nil {
+		metadata = map[string]interface{}{}
+	}
+	return dto.ProductResponse{
+		ID:            product.ID,
+		UserID:        product.UserID,
+		SKU:           product.SKU,
+		Name:          product.Name,
+		Description:   product.Description,
+		BaseCost:      product.BaseCost,
+		DefaultRuleID: product.DefaultRuleID,
+		CategoryID:    product.CategoryID,
+		Metadata:      metadata,
+		Attributes:    effectiveAttributes(chain, metadata),
+		IsActive:      product.IsActive,
+		CreatedAt:     product.CreatedAt,
+		UpdatedAt:     product.UpdatedAt,
+	}
+}
+
+// ruleAssignmentResponse converts a rule assignment to its response DTO
+func ruleAssignmentResponse(assignment *RuleAssignment) dto.RuleAssignmentResponse {
+	return dto.RuleAssignmentResponse{
+		ID:             assignment.ID,
+		ProductID:      assignment.ProductID,
+		RuleID:         assignment.RuleID,
+		EffectiveFrom:  assignment.EffectiveFrom,
+		EffectiveTo:    assignment.EffectiveTo,
+		ScheduleStatus: assignment.ScheduleStatus,
+		CreatedAt:      assignment.CreatedAt,
+	}
+}
+
+// productCostResponse converts a product cost to its response DTO
+func productCostResponse(cost *ProductCost) dto.ProductCostResponse {
+	return dto.ProductCostResponse{
+		ID:             cost.ID,
+		ProductID:      cost.ProductID,
+		Cost:           cost.Cost,
+		EffectiveFrom:  cost.EffectiveFrom,
+		EffectiveTo:    cost.EffectiveTo,
+		Status:         cost.Status,
+		Source:         cost.Source,
+		Note:           cost.Note,
+		Change:         cost.Change,
+		ChangePercent:  cost.ChangePercent,
+		PreviousCostID: cost.PreviousCostID,
+		CreatedBy:      cost.CreatedBy,
+		CreatedAt:      cost.CreatedAt,
+	}
+}
+
+// costRecomputeResponse converts a recompute report to its response DTO
+func costRecomputeResponse(report *CostRecomputeReport) dto.CostRecomputeResponse {
+	return dto.CostRecomputeResponse{
+		ProductID:   report.ProductID,
+		SKU:         report.SKU,
+		CostID:      report.CostID,
+		EffectiveAt: report.EffectiveAt,
+		RuleID:      report.RuleID,
+		RuleSource:  ruleSourceResponse(report.RuleSource),
+		Before:      priceAtCostResponse(report.Before),
+		After:       priceAtCostResponse(report.After),
+		PriceChange: report.PriceChange,
+		Error:       report.Error,
+	}
+}
+
+// priceAtCostResponse converts a price at cost to its response DTO
+func priceAtCostResponse(price *PriceAtCost) *dto.PriceAtCostResponse {
+	if price == nil {
+		return nil
+	}
+	return &dto.PriceAtCostResponse{
+		Cost:          price.Cost,
+		FinalPrice:    price.FinalPrice,
+		Margin:        price.Margin,
+		MarginPercent: price.MarginPercent,
+	}
 }
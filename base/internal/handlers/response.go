@@ -129,6 +129,14 @@ func Created(c *gin.Context, data interface{}) {
 	})
 }
 
+// Accepted sends a 202 Accepted response for work that continues in the background
+func Accepted(c *gin.Context, data interface{}) {
+	c.JSON(http.StatusAccepted, SuccessResponse{
+		Success: true,
+		Data:    data,
+	})
+}
+
 // NoContent sends a 204 No Content response
 func NoContent(c *gin.Context) {
 	c.Status(http.StatusNoContent)
@@ -171,3 +179,23 @@ func MustGetUserID(c *gin.Context) uuid.UUID {
 
 	return userID
 }
+
+// GetRequestID returns the ID the request is logged under
+func GetRequestID(c *gin.Context) string {
+	return c.GetString("request_id")
+}
+
+// GetAPIKeyID returns the ID of the API key that authenticated the request, if any
+func GetAPIKeyID(c *gin.Context) *uuid.UUID {
+	value, exists := c.Get("api_key_id")
+	if !exists {
+		return nil
+	}
+
+	keyID, ok := value.(uuid.UUID)
+	if !ok || keyID == uuid.Nil {
+		return nil
+	}
+
+	return &keyID
+}
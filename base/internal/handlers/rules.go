@@ -2,9 +2,16 @@ package handlers
 
 import (
 	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/saintparish4/harmonia/internal/csvsafe"
 	"github.com/saintparish4/harmonia/internal/dto"
 )
 
@@ -12,32 +19,185 @@ import (
 type PricingRule struct {
 	ID           uuid.UUID
 	UserID       uuid.UUID
+	ExternalKey  string
 	Name         string
+	Description  string
 	StrategyType string
 	Config       map[string]interface{}
 	IsActive     bool
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+
+	// Published content is served to calculations; nil until the first publish
+	PublishedAt *time.Time
+
+	// Window the rule applies in; ScheduleStatus is relative to now
+	EffectiveFrom  *time.Time
+	EffectiveTo    *time.Time
+	ScheduleStatus string
+
+	// Current revision, its author and the note it was published with. Set
+	// UpdatedBy and UpdatedByKeyID to the caller when writing a revision.
+	Revision       int
+	RevisionID     *uuid.UUID
+	UpdatedBy      *uuid.UUID
+	UpdatedByKeyID *uuid.UUID
+	ChangeNote     string
+}
+
+// RuleRevision represents an immutable snapshot of a pricing rule
+type RuleRevision struct {
+	ID            uuid.UUID
+	RuleID        uuid.UUID
+	Revision      int
+	Name          string
+	Description   string
+	StrategyType  string
+	Config        map[string]interface{}
+	IsActive      bool
+	EffectiveFrom *time.Time
+	EffectiveTo   *time.Time
+	AuthorID      *uuid.UUID
+	AuthorKeyID   *uuid.UUID
+	ChangeNote    string
+	CreatedAt     time.Time
+}
+
+// RuleDraft represents an unpublished edit of a pricing rule
+type RuleDraft struct {
+	ID                uuid.UUID
+	RuleID            uuid.UUID
+	BaseRevision      int
+	Name              string
+	Description       string
+	StrategyType      string
+	Config            map[string]interface{}
+	IsActive          bool
+	EffectiveFrom     *time.Time
+	EffectiveTo       *time.Time
+	AuthorID          *uuid.UUID
+	AuthorKeyID       *uuid.UUID
+	ChangeNote        string
+	Status            string
+	ApprovedBy        *uuid.UUID
+	ApprovedByKeyID   *uuid.UUID
+	ApprovedAt        *time.Time
+	PublishedRevision int
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+}
+
+// Draft workflow errors returned by repositories and publishers
+var (
+	ErrDraftNotFound    = errors.New("no pending draft")
+	ErrApprovalRequired = errors.New("draft must be approved by an approver key other than its author's")
+	// ErrDeleteNeedsApproval is returned when a published rule must be
+	// deactivated through a draft instead of deleted
+	ErrDeleteNeedsApproval = errors.New("published rule must be deactivated through an approved draft")
+)
+
+// JSONChange represents one difference between two rule revisions
+type JSONChange struct {
+	Op   string
+	Path string
+	From interface{}
+	To   interface{}
+}
+
+// RuleFilter selects a user's active pricing rules
+type RuleFilter struct {
+	UserID   uuid.UUID
+	Schedule string // active, upcoming or expired; empty for any
+	Limit    int
+	After    *Cursor
 }
 
 // PricingRuleRepository defines operations for pricing rule management
 type PricingRuleRepository interface {
 	Create(ctx context.Context, rule *PricingRule) error
 	GetByID(ctx context.Context, id uuid.UUID) (*PricingRule, error)
-	GetByUserID(ctx context.Context, userID uuid.UUID) ([]*PricingRule, error)
+	// List retrieves active rules newest first, continuing after filter.After
+	List(ctx context.Context, filter RuleFilter) ([]*PricingRule, error)
+	// Count returns the number of rules matching the filter, ignoring Limit and After
+	Count(ctx context.Context, filter RuleFilter) (int, error)
 	Update(ctx context.Context, rule *PricingRule) error
 	Delete(ctx context.Context, id uuid.UUID) error
+	ListRevisions(ctx context.Context, ruleID uuid.UUID) ([]*RuleRevision, error)
+	GetRevision(ctx context.Context, ruleID uuid.UUID, revision int) (*RuleRevision, error)
+	SaveDraft(ctx context.Context, draft *RuleDraft) error
+	GetDraft(ctx context.Context, ruleID uuid.UUID) (*RuleDraft, error)
+	ListDrafts(ctx context.Context, userID uuid.UUID) ([]*RuleDraft, error)
+	DiscardDraft(ctx context.Context, ruleID uuid.UUID) error
+}
+
+// RulePublisher publishes drafts under the account's approval policy
+type RulePublisher interface {
+	// Publish makes the pending draft live, recording the approver. A non-nil
+	// draftID must still be the pending draft.
+	Publish(ctx context.Context, ruleID uuid.UUID, draftID *uuid.UUID, approverID uuid.UUID, approverKeyID *uuid.UUID) (*PricingRule, error)
+	// CheckDelete returns ErrDeleteNeedsApproval if the rule may only be taken
+	// offline through an approved draft
+	CheckDelete(ctx context.Context, rule *PricingRule) error
+}
+
+// FieldError describes a single invalid value in a rule request
+type FieldError struct {
+	Path    string // JSON pointer relative to the request body, e.g. /config/time_windows/0/multiplier
+	Message string
+}
+
+// SimulationAxis varies one input field across a list of values
+type SimulationAxis struct {
+	Field  string
+	Values []interface{}
+}
+
+// SimulationCell is the result of one point in a simulation grid
+type SimulationCell struct {
+	Index      []int
+	Inputs     map[string]interface{}
+	FinalPrice float64
+	Currency   string
+	Breakdown  interface{}
+	Error      string
+}
+
+// SimulationResult is the price matrix produced by a rule simulation
+type SimulationResult struct {
+	Shape []int
+	Cells []SimulationCell
+}
+
+// ConfigValidator checks a strategy config before it is used
+type ConfigValidator interface {
+	// ValidateConfig returns nil when the config is valid for the strategy
+	ValidateConfig(strategyType string, config map[string]interface{}) []FieldError
+}
+
+// RuleEngine validates and simulates pricing rules
+type RuleEngine interface {
+	ConfigValidator
+	// Simulate prices the rule over the Cartesian product of the axes without logging
+	Simulate(ctx context.Context, rule *PricingRule, inputs map[string]interface{}, axes []SimulationAxis) (*SimulationResult, error)
+	// DiffRevisions returns the structural changes from one revision to another
+	DiffRevisions(from, to *RuleRevision) []JSONChange
 }
 
 // RulesHandler handles pricing rule endpoints
+// Writes are saved as drafts; only an approved draft changes the published rule.
 type RulesHandler struct {
-	repo PricingRuleRepository
+	repo      PricingRuleRepository
+	engine    RuleEngine
+	publisher RulePublisher
 }
 
 // NewRulesHandler creates a new rules handler
-func NewRulesHandler(repo PricingRuleRepository) *RulesHandler {
-	return &RulesHandler{repo: repo}
+func NewRulesHandler(repo PricingRuleRepository, engine RuleEngine, publisher RulePublisher) *RulesHandler {
+	return &RulesHandler{repo: repo, engine: engine, publisher: publisher}
 }
 
 // Create handles POST /v1/pricing/rules
+// The rule starts unpublished with its content in a pending draft.
 func (h *RulesHandler) Create(c *gin.Context) {
 	// Get user ID from context
 	userID := MustGetUserID(c)
@@ -51,16 +211,11 @@ func (h *RulesHandler) Create(c *gin.Context) {
 		return
 	}
 
-	// Validate strategy type
-	validStrategies := map[string]bool{
-		"cost_plus":  true,
-		"geographic": true,
-		"time_based": true,
-		"rule_based": true,
+	// Validate strategy type, config and schedule
+	if !checkConfig(c, h.engine, req.StrategyType, req.Config) {
+		return
 	}
-
-	if !validStrategies[req.StrategyType] {
-		BadRequest(c, "Invalid strategy type")
+	if !checkSchedule(c, req.EffectiveFrom, req.EffectiveTo) {
 		return
 	}
 
@@ -68,33 +223,45 @@ func (h *RulesHandler) Create(c *gin.Context) {
 
 	// Create pricing rule
 	rule := &PricingRule{
-		ID:           uuid.New(),
-		UserID:       userID,
-		Name:         req.Name,
-		StrategyType: req.StrategyType,
-		Config:       req.Config,
-		IsActive:     req.IsActive,
+		ID:            uuid.New(),
+		UserID:        userID,
+		ExternalKey:   req.ExternalKey,
+		Name:          req.Name,
+		StrategyType:  req.StrategyType,
+		Config:        req.Config,
+		IsActive:      req.IsActive,
+		EffectiveFrom: req.EffectiveFrom,
+		EffectiveTo:   req.EffectiveTo,
+
+		UpdatedBy:      &userID,
+		UpdatedByKeyID: GetAPIKeyID(c),
 	}
 
 	if err := h.repo.Create(ctx, rule); err != nil {
+		if errors.Is(err, ErrDuplicateExternalKey) {
+			Conflict(c, "Another rule already uses this external_key")
+			return
+		}
 		HandleError(c, err)
 		return
 	}
 
-	// Return response
-	response := dto.PricingRuleResponse{
-		ID:           rule.ID,
-		UserID:       rule.UserID,
-		Name:         rule.Name,
-		StrategyType: rule.StrategyType,
-		Config:       rule.Config,
-		IsActive:     rule.IsActive,
+	// Stage the content for approval
+	draft := draftFromRule(rule, userID, GetAPIKeyID(c), req.ChangeNote)
+	if err := h.repo.SaveDraft(ctx, draft); err != nil {
+		HandleError(c, err)
+		return
 	}
 
+	response := ruleResponse(rule)
+	response.Draft = h.draftResponse(rule, draft)
 	Created(c, response)
 }
 
 // List handles GET /v1/pricing/rules
+// ?schedule=active|upcoming|expired limits the listing to rules in that state.
+// Rules are listed newest first, a page at a time; pass next_cursor as
+// ?cursor for the next page, and ?include_total=true to count every match.
 func (h *RulesHandler) List(c *gin.Context) {
 	// Get user ID from context
 	userID := MustGetUserID(c)
@@ -102,29 +269,62 @@ func (h *RulesHandler) List(c *gin.Context) {
 		return
 	}
 
+	// Bind query parameters
+	var params dto.RulesQueryParams
+	if err := c.ShouldBindQuery(&params); err != nil {
+		BadRequest(c, err.Error())
+		return
+	}
+
+	schedule := params.Schedule
+	if schedule != "" && schedule != "active" && schedule != "upcoming" && schedule != "expired" {
+		BadRequest(c, "Invalid schedule. Must be one of: active, upcoming, expired")
+		return
+	}
+
+	page, ok := parsePage(c, params.PageParams)
+	if !ok {
+		return
+	}
+
 	ctx := c.Request.Context()
+	filter := RuleFilter{
+		UserID:   userID,
+		Schedule: schedule,
+		Limit:    page.fetchLimit(),
+		After:    page.after,
+	}
 
-	// Get all rules for user
-	rules, err := h.repo.GetByUserID(ctx, userID)
+	rules, err := h.repo.List(ctx, filter)
 	if err != nil {
 		HandleError(c, err)
 		return
 	}
+	fetched := len(rules)
+	if fetched > page.limit {
+		rules = rules[:page.limit]
+	}
+
+	// Get total count if asked for
+	var total *int
+	if page.includeTotal {
+		count, err := h.repo.Count(ctx, filter)
+		if err != nil {
+			HandleError(c, err)
+			return
+		}
+		total = &count
+	}
 
 	// Convert to response DTOs
+	var last Cursor
 	response := make([]dto.PricingRuleResponse, len(rules))
 	for i, rule := range rules {
-		response[i] = dto.PricingRuleResponse{
-			ID:           rule.ID,
-			UserID:       rule.UserID,
-			Name:         rule.Name,
-			StrategyType: rule.StrategyType,
-			Config:       rule.Config,
-			IsActive:     rule.IsActive,
-		}
+		last = Cursor{CreatedAt: rule.CreatedAt, ID: rule.ID}
+		response[i] = ruleResponse(rule)
 	}
 
-	Success(c, response)
+	Success(c, page.response(response, fetched, last, total))
 }
 
 // Get handles GET /v1/pricing/rules/:id
@@ -157,20 +357,21 @@ func (h *RulesHandler) Get(c *gin.Context) {
 		return
 	}
 
-	// Return response
-	response := dto.PricingRuleResponse{
-		ID:           rule.ID,
-		UserID:       rule.UserID,
-		Name:         rule.Name,
-		StrategyType: rule.StrategyType,
-		Config:       rule.Config,
-		IsActive:     rule.IsActive,
+	draft, ok := h.pendingDraft(c, rule)
+	if !ok {
+		return
+	}
+
+	response := ruleResponse(rule)
+	if draft != nil {
+		response.Draft = h.draftResponse(rule, draft)
 	}
 
 	Success(c, response)
 }
 
 // Update handles PUT /v1/pricing/rules/:id
+// Changes apply to the pending draft, starting one from the published rule if needed.
 func (h *RulesHandler) Update(c *gin.Context) {
 	// Get user ID from context
 	userID := MustGetUserID(c)
@@ -206,40 +407,61 @@ func (h *RulesHandler) Update(c *gin.Context) {
 		return
 	}
 
+	// Start from the pending draft, or from the published rule
+	draft, ok := h.pendingDraft(c, rule)
+	if !ok {
+		return
+	}
+	edited := *rule
+	if draft != nil {
+		applyDraft(&edited, draft)
+	}
+
 	// Update fields
 	if req.Name != nil {
-		rule.Name = *req.Name
+		edited.Name = *req.Name
 	}
 	if req.StrategyType != nil {
-		rule.StrategyType = *req.StrategyType
+		edited.StrategyType = *req.StrategyType
 	}
 	if req.Config != nil {
-		rule.Config = req.Config
+		edited.Config = req.Config
 	}
 	if req.IsActive != nil {
-		rule.IsActive = *req.IsActive
+		edited.IsActive = *req.IsActive
+	}
+	if req.EffectiveFrom.Set {
+		edited.EffectiveFrom = req.EffectiveFrom.Value
+	}
+	if req.EffectiveTo.Set {
+		edited.EffectiveTo = req.EffectiveTo.Value
 	}
 
-	// Save updates
-	if err := h.repo.Update(ctx, rule); err != nil {
-		HandleError(c, err)
+	// Validate the resulting strategy type and config
+	if req.StrategyType != nil || req.Config != nil {
+		if !checkConfig(c, h.engine, edited.StrategyType, edited.Config) {
+			return
+		}
+	}
+	if !checkSchedule(c, edited.EffectiveFrom, edited.EffectiveTo) {
 		return
 	}
 
-	// Return response
-	response := dto.PricingRuleResponse{
-		ID:           rule.ID,
-		UserID:       rule.UserID,
-		Name:         rule.Name,
-		StrategyType: rule.StrategyType,
-		Config:       rule.Config,
-		IsActive:     rule.IsActive,
+	// Save the draft; the published rule is unchanged until approval
+	draft = draftFromRule(&edited, userID, GetAPIKeyID(c), req.ChangeNote)
+	if err := h.repo.SaveDraft(ctx, draft); err != nil {
+		HandleError(c, err)
+		return
 	}
 
+	response := ruleResponse(rule)
+	response.Draft = h.draftResponse(rule, draft)
 	Success(c, response)
 }
 
 // Delete handles DELETE /v1/pricing/rules/:id
+// Published rules of accounts that require approval are refused with 409;
+// they are deactivated through an approved draft instead.
 func (h *RulesHandler) Delete(c *gin.Context) {
 	// Get user ID from context
 	userID := MustGetUserID(c)
@@ -269,6 +491,16 @@ func (h *RulesHandler) Delete(c *gin.Context) {
 		return
 	}
 
+	// Taking a published rule offline needs the same approval as editing it
+	if err := h.publisher.CheckDelete(ctx, rule); err != nil {
+		if errors.Is(err, ErrDeleteNeedsApproval) {
+			Conflict(c, "This account requires rule changes to be approved; deactivate the rule with a draft setting is_active to false and have it approved")
+			return
+		}
+		HandleError(c, err)
+		return
+	}
+
 	// Delete rule
 	if err := h.repo.Delete(ctx, ruleID); err != nil {
 		HandleError(c, err)
@@ -277,3 +509,625 @@ func (h *RulesHandler) Delete(c *gin.Context) {
 
 	NoContent(c)
 }
+
+// Validate handles POST /v1/pricing/rules/validate
+func (h *RulesHandler) Validate(c *gin.Context) {
+	// Bind request
+	var req dto.ValidatePricingRuleRequest
+	if !BindJSON(c, &req) {
+		return
+	}
+
+	if !checkConfig(c, h.engine, req.StrategyType, req.Config) {
+		return
+	}
+
+	Success(c, dto.ValidatePricingRuleResponse{Valid: true})
+}
+
+// ListRevisions handles GET /v1/pricing/rules/:id/revisions
+func (h *RulesHandler) ListRevisions(c *gin.Context) {
+	rule, ok := h.ownedRule(c)
+	if !ok {
+		return
+	}
+
+	revisions, err := h.repo.ListRevisions(c.Request.Context(), rule.ID)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	response := make([]dto.RuleRevisionResponse, len(revisions))
+	for i, revision := range revisions {
+		response[i] = revisionResponse(revision)
+	}
+
+	Success(c, response)
+}
+
+// GetRevision handles GET /v1/pricing/rules/:id/revisions/:revision
+func (h *RulesHandler) GetRevision(c *gin.Context) {
+	rule, ok := h.ownedRule(c)
+	if !ok {
+		return
+	}
+
+	number, err := strconv.Atoi(c.Param("revision"))
+	if err != nil || number < 1 {
+		BadRequest(c, "Invalid revision number")
+		return
+	}
+
+	revision, err := h.repo.GetRevision(c.Request.Context(), rule.ID, number)
+	if err != nil {
+		NotFound(c, "Rule revision not found")
+		return
+	}
+
+	Success(c, revisionResponse(revision))
+}
+
+// Diff handles GET /v1/pricing/rules/:id/diff?from=1&to=2
+// "to" defaults to the current revision and "from" to the one before it.
+func (h *RulesHandler) Diff(c *gin.Context) {
+	rule, ok := h.ownedRule(c)
+	if !ok {
+		return
+	}
+
+	to := rule.Revision
+	if param := c.Query("to"); param != "" {
+		n, err := strconv.Atoi(param)
+		if err != nil || n < 1 {
+			BadRequest(c, "Invalid to revision")
+			return
+		}
+		to = n
+	}
+
+	from := to - 1
+	if param := c.Query("from"); param != "" {
+		n, err := strconv.Atoi(param)
+		if err != nil || n < 1 {
+			BadRequest(c, "Invalid from revision")
+			return
+		}
+		from = n
+	}
+	if from < 1 {
+		BadRequest(c, "Rule has no earlier revision to compare with")
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	fromRevision, err := h.repo.GetRevision(ctx, rule.ID, from)
+	if err != nil {
+		NotFound(c, "Rule revision not found")
+		return
+	}
+	toRevision, err := h.repo.GetRevision(ctx, rule.ID, to)
+	if err != nil {
+		NotFound(c, "Rule revision not found")
+		return
+	}
+
+	changes := h.engine.DiffRevisions(fromRevision, toRevision)
+	changeResponses := make([]dto.JSONChangeResponse, len(changes))
+	for i, change := range changes {
+		changeResponses[i] = dto.JSONChangeResponse{
+			Op:   change.Op,
+			Path: change.Path,
+			From: change.From,
+			To:   change.To,
+		}
+	}
+
+	response := dto.RuleDiffResponse{
+		RuleID:  rule.ID,
+		From:    from,
+		To:      to,
+		Changes: changeResponses,
+	}
+
+	Success(c, response)
+}
+
+// Rollback handles POST /v1/pricing/rules/:id/rollback
+// The old revision's content becomes the pending draft and is published as a
+// new revision once approved; history is never rewritten.
+func (h *RulesHandler) Rollback(c *gin.Context) {
+	rule, ok := h.ownedRule(c)
+	if !ok {
+		return
+	}
+
+	// Bind request
+	var req dto.RollbackPricingRuleRequest
+	if !BindJSON(c, &req) {
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	target, err := h.repo.GetRevision(ctx, rule.ID, req.Revision)
+	if err != nil {
+		NotFound(c, "Rule revision not found")
+		return
+	}
+
+	// The target config must still be valid for the current engine
+	if !checkConfig(c, h.engine, target.StrategyType, target.Config) {
+		return
+	}
+
+	restored := *rule
+	restored.Name = target.Name
+	restored.Description = target.Description
+	restored.StrategyType = target.StrategyType
+	restored.Config = target.Config
+	restored.IsActive = target.IsActive
+	restored.EffectiveFrom = target.EffectiveFrom
+	restored.EffectiveTo = target.EffectiveTo
+
+	note := fmt.Sprintf("Rollback to revision %d", target.Revision)
+	if req.ChangeNote != "" {
+		note += ": " + req.ChangeNote
+	}
+
+	// The caller authors the rollback, not the rule owner
+	draft := draftFromRule(&restored, MustGetUserID(c), GetAPIKeyID(c), note)
+	if err := h.repo.SaveDraft(ctx, draft); err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	response := ruleResponse(rule)
+	response.Draft = h.draftResponse(rule, draft)
+	Success(c, response)
+}
+
+// ListDrafts handles GET /v1/pricing/rules/drafts
+func (h *RulesHandler) ListDrafts(c *gin.Context) {
+	// Get user ID from context
+	userID := MustGetUserID(c)
+	if userID == uuid.Nil {
+		return
+	}
+
+	drafts, err := h.repo.ListDrafts(c.Request.Context(), userID)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	response := make([]*dto.RuleDraftResponse, len(drafts))
+	for i, draft := range drafts {
+		response[i] = h.draftResponse(nil, draft)
+	}
+
+	Success(c, response)
+}
+
+// GetDraft handles GET /v1/pricing/rules/:id/draft
+// The response lists the draft's changes against the published rule.
+func (h *RulesHandler) GetDraft(c *gin.Context) {
+	rule, ok := h.ownedRule(c)
+	if !ok {
+		return
+	}
+
+	draft, ok := h.pendingDraft(c, rule)
+	if !ok {
+		return
+	}
+	if draft == nil {
+		NotFound(c, "Rule has no pending draft")
+		return
+	}
+
+	Success(c, h.draftResponse(rule, draft))
+}
+
+// DiscardDraft handles DELETE /v1/pricing/rules/:id/draft
+func (h *RulesHandler) DiscardDraft(c *gin.Context) {
+	rule, ok := h.ownedRule(c)
+	if !ok {
+		return
+	}
+
+	if err := h.repo.DiscardDraft(c.Request.Context(), rule.ID); err != nil {
+		if errors.Is(err, ErrDraftNotFound) {
+			NotFound(c, "Rule has no pending draft")
+			return
+		}
+		HandleError(c, err)
+		return
+	}
+
+	NoContent(c)
+}
+
+// ApproveDraft handles POST /v1/pricing/rules/:id/draft/approve
+// The draft is published as the rule's next revision, recording the caller as
+// its approver. When the account requires approval, the caller's API key must
+// be an approver key, granted by an administrator, other than the draft's author.
+func (h *RulesHandler) ApproveDraft(c *gin.Context) {
+	rule, ok := h.ownedRule(c)
+	if !ok {
+		return
+	}
+	approverID := MustGetUserID(c)
+	approverKeyID := GetAPIKeyID(c)
+
+	// The body is optional
+	var req dto.ApproveRuleDraftRequest
+	if c.Request.ContentLength > 0 && !BindJSON(c, &req) {
+		return
+	}
+
+	draft, ok := h.pendingDraft(c, rule)
+	if !ok {
+		return
+	}
+	if draft == nil {
+		NotFound(c, "Rule has no pending draft")
+		return
+	}
+
+	// The draft config must still be valid for the current engine
+	if !checkConfig(c, h.engine, draft.StrategyType, draft.Config) {
+		return
+	}
+
+	published, err := h.publisher.Publish(c.Request.Context(), rule.ID, req.DraftID, approverID, approverKeyID)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrApprovalRequired):
+			Forbidden(c, "This account requires drafts to be approved with an approver key other than the one that authored them")
+		case errors.Is(err, ErrDraftNotFound):
+			Conflict(c, "The pending draft has changed since it was reviewed")
+		default:
+			HandleError(c, err)
+		}
+		return
+	}
+
+	response := ruleResponse(published)
+	response.Approval = &dto.RuleApprovalResponse{
+		ApprovedBy:      approverID,
+		ApprovedByKeyID: approverKeyID,
+	}
+	Success(c, response)
+}
+
+// pendingDraft loads the rule's pending draft, or nil if it has none. Returns
+// false if a response was written.
+func (h *RulesHandler) pendingDraft(c *gin.Context, rule *PricingRule) (*RuleDraft, bool) {
+	draft, err := h.repo.GetDraft(c.Request.Context(), rule.ID)
+	if errors.Is(err, ErrDraftNotFound) {
+		return nil, true
+	}
+	if err != nil {
+		HandleError(c, err)
+		return nil, false
+	}
+	return draft, true
+}
+
+// ownedRule loads the rule named by the :id parameter and verifies the caller
+// owns it. Returns false if a response was written.
+func (h *RulesHandler) ownedRule(c *gin.Context) (*PricingRule, bool) {
+	// Get user ID from context
+	userID := MustGetUserID(c)
+	if userID == uuid.Nil {
+		return nil, false
+	}
+
+	// Validate rule ID
+	ruleID, err := ValidateUUID(c, "id")
+	if err != nil {
+		BadRequest(c, "Invalid rule ID")
+		return nil, false
+	}
+
+	rule, err := h.repo.GetByID(c.Request.Context(), ruleID)
+	if err != nil {
+		NotFound(c, "Pricing rule not found")
+		return nil, false
+	}
+
+	// Verify ownership
+	if rule.UserID != userID {
+		Forbidden(c, "Access denied")
+		return nil, false
+	}
+
+	return rule, true
+}
+
+// Simulate handles POST /v1/pricing/rules/:id/simulate
+func (h *RulesHandler) Simulate(c *gin.Context) {
+	// Get user ID from context
+	userID := MustGetUserID(c)
+	if userID == uuid.Nil {
+		return
+	}
+
+	// Validate rule ID
+	ruleID, err := ValidateUUID(c, "id")
+	if err != nil {
+		BadRequest(c, "Invalid rule ID")
+		return
+	}
+
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "csv" {
+		BadRequest(c, "Invalid format. Must be one of: json, csv")
+		return
+	}
+
+	// Bind request
+	var req dto.SimulateRuleRequest
+	if !BindJSON(c, &req) {
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	// Get rule
+	rule, err := h.repo.GetByID(ctx, ruleID)
+	if err != nil {
+		NotFound(c, "Pricing rule not found")
+		return
+	}
+
+	// Verify ownership
+	if rule.UserID != userID {
+		Forbidden(c, "Access denied")
+		return
+	}
+
+	axes := make([]SimulationAxis, len(req.Axes))
+	for i, axis := range req.Axes {
+		axes[i] = SimulationAxis{Field: axis.Field, Values: axis.Values}
+	}
+
+	result, err := h.engine.Simulate(ctx, rule, req.Inputs, axes)
+	if err != nil {
+		BadRequest(c, err.Error())
+		return
+	}
+
+	if format == "csv" {
+		writeSimulationCSV(c, rule, req.Axes, result)
+		return
+	}
+
+	cells := make([]dto.SimulationCellResponse, len(result.Cells))
+	for i, cell := range result.Cells {
+		cells[i] = dto.SimulationCellResponse{
+			Index:      cell.Index,
+			Inputs:     cell.Inputs,
+			FinalPrice: cell.FinalPrice,
+			Currency:   cell.Currency,
+			Breakdown:  cell.Breakdown,
+			Error:      cell.Error,
+		}
+	}
+
+	// Return response
+	response := dto.SimulateRuleResponse{
+		RuleID:       rule.ID,
+		StrategyType: rule.StrategyType,
+		Axes:         req.Axes,
+		Shape:        result.Shape,
+		CellCount:    len(cells),
+		Cells:        cells,
+	}
+
+	Success(c, response)
+}
+
+// writeSimulationCSV streams a simulation as CSV, one row per cell
+func writeSimulationCSV(c *gin.Context, rule *PricingRule, axes []dto.SimulationAxis, result *SimulationResult) {
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"simulation-%s.csv\"", rule.ID))
+	c.Status(http.StatusOK)
+
+	w := csv.NewWriter(c.Writer)
+
+	header := make([]string, 0, len(axes)+3)
+	for _, axis := range axes {
+		header = append(header, csvsafe.Cell(axis.Field))
+	}
+	header = append(header, "final_price", "currency", "error")
+	_ = w.Write(header)
+
+	for _, cell := range result.Cells {
+		row := make([]string, 0, len(header))
+		for _, axis := range axes {
+			row = append(row, csvsafe.Cell(fmt.Sprint(cell.Inputs[axis.Field])))
+		}
+		price := ""
+		if cell.Error == "" {
+			price = strconv.FormatFloat(cell.FinalPrice, 'f', 2, 64)
+		}
+		row = append(row, price, csvsafe.Cell(cell.Currency), csvsafe.Cell(cell.Error))
+		_ = w.Write(row)
+	}
+
+	w.Flush()
+}
+
+// checkConfig validates a strategy config, writing a 400 with one detail per
+// JSON pointer path if it is invalid. Returns false if a response was written.
+func checkConfig(c *gin.Context, validator ConfigValidator, strategyType string, config map[string]interface{}) bool {
+	fieldErrors := validator.ValidateConfig(strategyType, config)
+	if len(fieldErrors) == 0 {
+		return true
+	}
+
+	details := make(map[string]string, len(fieldErrors))
+	for _, fe := range fieldErrors {
+		if existing, ok := details[fe.Path]; ok {
+			details[fe.Path] = existing + "; " + fe.Message
+			continue
+		}
+		details[fe.Path] = fe.Message
+	}
+
+	BadRequestWithDetails(c, "Invalid pricing rule configuration", details)
+	return false
+}
+
+// checkSchedule rejects an effective window that ends before it starts,
+// writing a 400. Returns false if a response was written.
+func checkSchedule(c *gin.Context, from, to *time.Time) bool {
+	if from == nil || to == nil || from.Before(*to) {
+		return true
+	}
+
+	BadRequestWithDetails(c, "Invalid schedule", map[string]string{
+		"/effective_to": "must be after effective_from",
+	})
+	return false
+}
+
+// draftFromRule captures edited rule content as a draft by the given author
+func draftFromRule(rule *PricingRule, authorID uuid.UUID, authorKeyID *uuid.UUID, note string) *RuleDraft {
+	return &RuleDraft{
+		RuleID:        rule.ID,
+		BaseRevision:  rule.Revision,
+		Name:          rule.Name,
+		Description:   rule.Description,
+		StrategyType:  rule.StrategyType,
+		Config:        rule.Config,
+		IsActive:      rule.IsActive,
+		EffectiveFrom: rule.EffectiveFrom,
+		EffectiveTo:   rule.EffectiveTo,
+		AuthorID:      &authorID,
+		AuthorKeyID:   authorKeyID,
+		ChangeNote:    note,
+	}
+}
+
+// applyDraft overlays a draft's content on a rule
+func applyDraft(rule *PricingRule, draft *RuleDraft) {
+	rule.Name = draft.Name
+	rule.Description = draft.Description
+	rule.StrategyType = draft.StrategyType
+	rule.Config = draft.Config
+	rule.IsActive = draft.IsActive
+	rule.EffectiveFrom = draft.EffectiveFrom
+	rule.EffectiveTo = draft.EffectiveTo
+}
+
+// draftResponse converts a draft to its response DTO. Given the published
+// rule, the response also lists the draft's changes against it.
+func (h *RulesHandler) draftResponse(rule *PricingRule, draft *RuleDraft) *dto.RuleDraftResponse {
+	response := &dto.RuleDraftResponse{
+		ID:                draft.ID,
+		RuleID:            draft.RuleID,
+		BaseRevision:      draft.BaseRevision,
+		Name:              draft.Name,
+		Description:       draft.Description,
+		StrategyType:      draft.StrategyType,
+		Config:            draft.Config,
+		IsActive:          draft.IsActive,
+		EffectiveFrom:     draft.EffectiveFrom,
+		EffectiveTo:       draft.EffectiveTo,
+		AuthorID:          draft.AuthorID,
+		AuthorKeyID:       draft.AuthorKeyID,
+		ChangeNote:        draft.ChangeNote,
+		Status:            draft.Status,
+		ApprovedBy:        draft.ApprovedBy,
+		ApprovedByKeyID:   draft.ApprovedByKeyID,
+		ApprovedAt:        draft.ApprovedAt,
+		PublishedRevision: draft.PublishedRevision,
+		CreatedAt:         draft.CreatedAt,
+		UpdatedAt:         draft.UpdatedAt,
+	}
+
+	// A never-published rule has nothing to compare against
+	if rule == nil || rule.PublishedAt == nil {
+		return response
+	}
+
+	published := &RuleRevision{
+		Name:          rule.Name,
+		Description:   rule.Description,
+		StrategyType:  rule.StrategyType,
+		Config:        rule.Config,
+		IsActive:      rule.IsActive,
+		EffectiveFrom: rule.EffectiveFrom,
+		EffectiveTo:   rule.EffectiveTo,
+	}
+	proposed := &RuleRevision{
+		Name:          draft.Name,
+		Description:   draft.Description,
+		StrategyType:  draft.StrategyType,
+		Config:        draft.Config,
+		IsActive:      draft.IsActive,
+		EffectiveFrom: draft.EffectiveFrom,
+		EffectiveTo:   draft.EffectiveTo,
+	}
+
+	changes := h.engine.DiffRevisions(published, proposed)
+	response.Changes = make([]dto.JSONChangeResponse, len(changes))
+	for i, change := range changes {
+		response.Changes[i] = dto.JSONChangeResponse{
+			Op:   change.Op,
+			Path: change.Path,
+			From: change.From,
+			To:   change.To,
+		}
+	}
+
+	return response
+}
+
+// ruleResponse converts a pricing rule to its response DTO
+func ruleResponse(rule *PricingRule) dto.PricingRuleResponse {
+	return dto.PricingRuleResponse{
+		ID:             rule.ID,
+		UserID:         rule.UserID,
+		ExternalKey:    rule.ExternalKey,
+		Name:           rule.Name,
+		Description:    rule.Description,
+		StrategyType:   rule.StrategyType,
+		Config:         rule.Config,
+		IsActive:       rule.IsActive,
+		Published:      rule.PublishedAt != nil,
+		PublishedAt:    rule.PublishedAt,
+		EffectiveFrom:  rule.EffectiveFrom,
+		EffectiveTo:    rule.EffectiveTo,
+		ScheduleStatus: rule.ScheduleStatus,
+		Revision:       rule.Revision,
+		RevisionID:     rule.RevisionID,
+		ChangeNote:     rule.ChangeNote,
+		CreatedAt:      rule.CreatedAt,
+		UpdatedAt:      rule.UpdatedAt,
+	}
+}
+
+// revisionResponse converts a rule revision to its response DTO
+func revisionResponse(revision *RuleRevision) dto.RuleRevisionResponse {
+	return dto.RuleRevisionResponse{
+		ID:            revision.ID,
+		RuleID:        revision.RuleID,
+		Revision:      revision.Revision,
+		Name:          revision.Name,
+		Description:   revision.Description,
+		StrategyType:  revision.StrategyType,
+		Config:        revision.Config,
+		IsActive:      revision.IsActive,
+		EffectiveFrom: revision.EffectiveFrom,
+		EffectiveTo:   revision.EffectiveTo,
+		AuthorID:      revision.AuthorID,
+		AuthorKeyID:   revision.AuthorKeyID,
+		ChangeNote:    revision.ChangeNote,
+		CreatedAt:     revision.CreatedAt,
+	}
+}
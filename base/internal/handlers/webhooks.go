@@ -0,0 +1,433 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/saintparish4/harmonia/internal/dto"
+)
+
+// WebhookEndpoint represents a URL that receives a user's events
+type WebhookEndpoint struct {
+	ID          uuid.UUID
+	UserID      uuid.UUID
+	URL         string
+	Description string
+	Secret      string
+	EventTypes  []string
+	IsActive    bool
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// WebhookDelivery represents one event sent to one endpoint
+type WebhookDelivery struct {
+	ID             uuid.UUID
+	EndpointID     uuid.UUID
+	EventID        uuid.UUID
+	EventType      string
+	Payload        map[string]interface{}
+	Status         string
+	Attempts       int
+	NextAttemptAt  *time.Time
+	LastAttemptAt  *time.Time
+	ResponseStatus *int
+	LastError      string
+	ReplayOf       *uuid.UUID
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+// WebhookAttempt represents one request sent for a delivery
+type WebhookAttempt struct {
+	Attempt        int
+	AttemptedAt    time.Time
+	DurationMs     int
+	ResponseStatus *int
+	ResponseBody   string
+	Error          string
+}
+
+// WebhookDeliveryFilter selects deliveries of an endpoint
+type WebhookDeliveryFilter struct {
+	EndpointID uuid.UUID
+	Status     string
+	EventType  string
+	Limit      int
+	Offset     int
+}
+
+// Webhook errors
+var (
+	ErrInvalidWebhook = errors.New("invalid webhook endpoint")
+)
+
+// Webhooks manages webhook endpoints and their delivery log
+type Webhooks interface {
+	// CreateEndpoint stores an endpoint and sets its signing secret
+	CreateEndpoint(ctx context.Context, endpoint *WebhookEndpoint) error
+	GetEndpoint(ctx context.Context, id uuid.UUID) (*WebhookEndpoint, error)
+	ListEndpoints(ctx context.Context, userID uuid.UUID) ([]*WebhookEndpoint, error)
+	UpdateEndpoint(ctx context.Context, endpoint *WebhookEndpoint) error
+	// RotateSecret replaces an endpoint's signing secret
+	RotateSecret(ctx context.Context, endpoint *WebhookEndpoint) error
+	DeleteEndpoint(ctx context.Context, id uuid.UUID) error
+	// Deliveries returns a page of deliveries and the total matching the filter
+	Deliveries(ctx context.Context, filter WebhookDeliveryFilter) ([]*WebhookDelivery, int, error)
+	// Delivery returns a delivery and its attempts
+	Delivery(ctx context.Context, id uuid.UUID) (*WebhookDelivery, []*WebhookAttempt, error)
+	// Replay queues a delivery's event to be sent again
+	Replay(ctx context.Context, deliveryID uuid.UUID) (*WebhookDelivery, error)
+}
+
+// WebhooksHandler handles webhook endpoints
+type WebhooksHandler struct {
+	webhooks Webhooks
+}
+
+// NewWebhooksHandler creates a new webhooks handler
+func NewWebhooksHandler(webhooks Webhooks) *WebhooksHandler {
+	return &WebhooksHandler{webhooks: webhooks}
+}
+
+// List handles GET /v1/webhooks
+func (h *WebhooksHandler) List(c *gin.Context) {
+	// Get user ID from context
+	userID := MustGetUserID(c)
+	if userID == uuid.Nil {
+		return
+	}
+
+	endpoints, err := h.webhooks.ListEndpoints(c.Request.Context(), userID)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	response := make([]dto.WebhookEndpointResponse, len(endpoints))
+	for i, endpoint := range endpoints {
+		response[i] = webhookEndpointResponse(endpoint, false)
+	}
+
+	Success(c, response)
+}
+
+// Create handles POST /v1/webhooks
+// The signing secret is only returned in this response and when rotated.
+func (h *WebhooksHandler) Create(c *gin.Context) {
+	// Get user ID from context
+	userID := MustGetUserID(c)
+	if userID == uuid.Nil {
+		return
+	}
+
+	// Bind request
+	var req dto.CreateWebhookRequest
+	if !BindJSON(c, &req) {
+		return
+	}
+
+	isActive := true
+	if req.IsActive != nil {
+		isActive = *req.IsActive
+	}
+
+	endpoint := &WebhookEndpoint{
+		ID:          uuid.New(),
+		UserID:      userID,
+		URL:         req.URL,
+		Description: req.Description,
+		EventTypes:  req.EventTypes,
+		IsActive:    isActive,
+	}
+
+	if err := h.webhooks.CreateEndpoint(c.Request.Context(), endpoint); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.Header("Location", "/v1/webhooks/"+endpoint.ID.String())
+	Created(c, webhookEndpointResponse(endpoint, true))
+}
+
+// Get handles GET /v1/webhooks/:id
+func (h *WebhooksHandler) Get(c *gin.Context) {
+	endpoint, ok := h.ownedWebhook(c)
+	if !ok {
+		return
+	}
+
+	Success(c, webhookEndpointResponse(endpoint, false))
+}
+
+// Update handles PUT /v1/webhooks/:id
+func (h *WebhooksHandler) Update(c *gin.Context) {
+	endpoint, ok := h.ownedWebhook(c)
+	if !ok {
+		return
+	}
+
+	// Bind request
+	var req dto.UpdateWebhookRequest
+	if !BindJSON(c, &req) {
+		return
+	}
+
+	// Update fields
+	if req.URL != nil {
+		endpoint.URL = *req.URL
+	}
+	if req.Description != nil {
+		endpoint.Description = *req.Description
+	}
+	if req.EventTypes != nil {
+		endpoint.EventTypes = req.EventTypes
+	}
+	if req.IsActive != nil {
+		endpoint.IsActive = *req.IsActive
+	}
+
+	// Save updates
+	if err := h.webhooks.UpdateEndpoint(c.Request.Context(), endpoint); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	Success(c, webhookEndpointResponse(endpoint, false))
+}
+
+// Delete handles DELETE /v1/webhooks/:id
+func (h *WebhooksHandler) Delete(c *gin.Context) {
+	endpoint, ok := h.ownedWebhook(c)
+	if !ok {
+		return
+	}
+
+	if err := h.webhooks.DeleteEndpoint(c.Request.Context(), endpoint.ID); err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	NoContent(c)
+}
+
+// RotateSecret handles POST /v1/webhooks/:id/rotate-secret
+// Requests are signed with the new secret from the next attempt on.
+func (h *WebhooksHandler) RotateSecret(c *gin.Context) {
+	endpoint, ok := h.ownedWebhook(c)
+	if !ok {
+		return
+	}
+
+	if err := h.webhooks.RotateSecret(c.Request.Context(), endpoint); err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	Success(c, webhookEndpointResponse(endpoint, true))
+}
+
+// Deliveries handles GET /v1/webhooks/:id/deliveries
+// Lists the endpoint's deliveries, newest first, filtered by ?status= and
+// ?event_type=, with limit/offset pagination.
+func (h *WebhooksHandler) Deliveries(c *gin.Context) {
+	endpoint, ok := h.ownedWebhook(c)
+	if !ok {
+		return
+	}
+
+	// Bind query parameters
+	var params dto.WebhookDeliveriesQueryParams
+	if err := c.ShouldBindQuery(&params); err != nil {
+		BadRequest(c, err.Error())
+		return
+	}
+
+	// Set defaults
+	if params.Limit == 0 {
+		params.Limit = 20
+	}
+
+	deliveries, total, err := h.webhooks.Deliveries(c.Request.Context(), WebhookDeliveryFilter{
+		EndpointID: endpoint.ID,
+		Status:     params.Status,
+		EventType:  params.EventType,
+		Limit:      params.Limit,
+		Offset:     params.Offset,
+	})
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	deliveryResponses := make([]dto.WebhookDeliveryResponse, len(deliveries))
+	for i, delivery := range deliveries {
+		deliveryResponses[i] = webhookDeliveryResponse(delivery, nil)
+	}
+
+	Success(c, dto.PaginatedResponse{
+		Data:    deliveryResponses,
+		Total:   total,
+		Limit:   params.Limit,
+		Offset:  params.Offset,
+		HasMore: params.Offset+params.Limit < total,
+	})
+}
+
+// GetDelivery handles GET /v1/webhooks/:id/deliveries/:delivery_id
+// Includes every attempt with its response status and body.
+func (h *WebhooksHandler) GetDelivery(c *gin.Context) {
+	delivery, attempts, ok := h.ownedDelivery(c)
+	if !ok {
+		return
+	}
+
+	Success(c, webhookDeliveryResponse(delivery, attempts))
+}
+
+// Replay handles POST /v1/webhooks/:id/deliveries/:delivery_id/replay
+// Queues a new delivery of the same event, which keeps its event ID so
+// receivers can discard duplicates.
+func (h *WebhooksHandler) Replay(c *gin.Context) {
+	delivery, _, ok := h.ownedDelivery(c)
+	if !ok {
+		return
+	}
+
+	replay, err := h.webhooks.Replay(c.Request.Context(), delivery.ID)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	Accepted(c, webhookDeliveryResponse(replay, nil))
+}
+
+// ownedWebhook loads the endpoint named by the :id parameter and verifies the
+// caller owns it. Returns false if a response was written.
+func (h *WebhooksHandler) ownedWebhook(c *gin.Context) (*WebhookEndpoint, bool) {
+	// Get user ID from context
+	userID := MustGetUserID(c)
+	if userID == uuid.Nil {
+		return nil, false
+	}
+
+	// Validate webhook ID
+	endpointID, err := ValidateUUID(c, "id")
+	if err != nil {
+		BadRequest(c, "Invalid webhook ID")
+		return nil, false
+	}
+
+	endpoint, err := h.webhooks.GetEndpoint(c.Request.Context(), endpointID)
+	if err != nil {
+		NotFound(c, "Webhook not found")
+		return nil, false
+	}
+
+	// Verify ownership
+	if endpoint.UserID != userID {
+		Forbidden(c, "Access denied")
+		return nil, false
+	}
+
+	return endpoint, true
+}
+
+// ownedDelivery loads the delivery named by the :delivery_id parameter and
+// verifies it belongs to an endpoint the caller owns. Returns false if a
+// response was written.
+func (h *WebhooksHandler) ownedDelivery(c *gin.Context) (*WebhookDelivery, []*WebhookAttempt, bool) {
+	endpoint, ok := h.ownedWebhook(c)
+	if !ok {
+		return nil, nil, false
+	}
+
+	// Validate delivery ID
+	deliveryID, err := ValidateUUID(c, "delivery_id")
+	if err != nil {
+		BadRequest(c, "Invalid delivery ID")
+		return nil, nil, false
+	}
+
+	delivery, attempts, err := h.webhooks.Delivery(c.Request.Context(), deliveryID)
+	if err != nil || delivery.EndpointID != endpoint.ID {
+		NotFound(c, "Delivery not found")
+		return nil, nil, false
+	}
+
+	return delivery, attempts, true
+}
+
+// handleError writes the response for a webhook error
+func (h *WebhooksHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrInvalidWebhook):
+		BadRequest(c, err.Error())
+	default:
+		HandleError(c, err)
+	}
+}
+
+// webhookEndpointResponse converts an endpoint to its response DTO, with its
+// secret if withSecret is set
+func webhookEndpointResponse(endpoint *WebhookEndpoint, withSecret bool) dto.WebhookEndpointResponse {
+	eventTypes := endpoint.EventTypes
+	if eventTypes == nil {
+		eventTypes = []string{}
+	}
+
+	response := dto.WebhookEndpointResponse{
+		ID:          endpoint.ID,
+		URL:         endpoint.URL,
+		Description: endpoint.Description,
+		EventTypes:  eventTypes,
+		IsActive:    endpoint.IsActive,
+		CreatedAt:   endpoint.CreatedAt,
+		UpdatedAt:   endpoint.UpdatedAt,
+	}
+	if withSecret {
+		response.Secret = endpoint.Secret
+	}
+
+	return response
+}
+
+// webhookDeliveryResponse converts a delivery and its attempts to its response DTO
+func webhookDeliveryResponse(delivery *WebhookDelivery, attempts []*WebhookAttempt) dto.WebhookDeliveryResponse {
+	var attemptLog []dto.WebhookAttemptResponse
+	if attempts != nil {
+		attemptLog = make([]dto.WebhookAttemptResponse, len(attempts))
+		for i, attempt := range attempts {
+			attemptLog[i] = dto.WebhookAttemptResponse{
+				Attempt:        attempt.Attempt,
+				AttemptedAt:    attempt.AttemptedAt,
+				DurationMs:     attempt.DurationMs,
+				ResponseStatus: attempt.ResponseStatus,
+				ResponseBody:   attempt.ResponseBody,
+				Error:          attempt.Error,
+			}
+		}
+	}
+
+	return dto.WebhookDeliveryResponse{
+		ID:             delivery.ID,
+		EndpointID:     delivery.EndpointID,
+		EventID:        delivery.EventID,
+		EventType:      delivery.EventType,
+		Payload:        delivery.Payload,
+		Status:         delivery.Status,
+		Attempts:       delivery.Attempts,
+		NextAttemptAt:  delivery.NextAttemptAt,
+		LastAttemptAt:  delivery.LastAttemptAt,
+		ResponseStatus: delivery.ResponseStatus,
+		LastError:      delivery.LastError,
+		ReplayOf:       delivery.ReplayOf,
+		AttemptLog:     attemptLog,
+		CreatedAt:      delivery.CreatedAt,
+		UpdatedAt:      delivery.UpdatedAt,
+	}
+}
@@ -0,0 +1,26 @@
+package metrics
+
+import "database/sql"
+
+// RegisterDBStats registers gauges and counters for a connection pool,
+// read from stats on every write
+func (r *Registry) RegisterDBStats(stats func() sql.DBStats) {
+	r.NewGaugeFunc("harmonia_db_max_open_connections", "Maximum open connections to the database.",
+		func() float64 { return float64(stats().MaxOpenConnections) })
+	r.NewGaugeFunc("harmonia_db_open_connections", "Open connections to the database, in use and idle.",
+		func() float64 { return float64(stats().OpenConnections) })
+	r.NewGaugeFunc("harmonia_db_in_use_connections", "Database connections currently in use.",
+		func() float64 { return float64(stats().InUse) })
+	r.NewGaugeFunc("harmonia_db_idle_connections", "Idle database connections.",
+		func() float64 { return float64(stats().Idle) })
+	r.NewCounterFunc("harmonia_db_wait_count_total", "Database connections waited for.",
+		func() float64 { return float64(stats().WaitCount) })
+	r.NewCounterFunc("harmonia_db_wait_duration_seconds_total", "Time spent waiting for database connections.",
+		func() float64 { return stats().WaitDuration.Seconds() })
+	r.NewCounterFunc("harmonia_db_max_idle_closed_total", "Database connections closed due to the idle connection limit.",
+		func() float64 { return float64(stats().MaxIdleClosed) })
+	r.NewCounterFunc("harmonia_db_max_idle_time_closed_total", "Database connections closed due to the idle time limit.",
+		func() float64 { return float64(stats().MaxIdleTimeClosed) })
+	r.NewCounterFunc("harmonia_db_max_lifetime_closed_total", "Database connections closed due to the connection lifetime limit.",
+		func() float64 { return float64(stats().MaxLifetimeClosed) })
+}
@@ -0,0 +1,48 @@
+package metrics
+
+// Default is the registry served on /metrics
+var Default = NewRegistry()
+
+// HTTP metrics, recorded by middleware.Metrics
+var (
+	HTTPRequests = Default.NewCounter(
+		"harmonia_http_requests_total",
+		"HTTP requests by method, route and status.",
+		"method", "route", "status")
+
+	HTTPRequestDuration = Default.NewHistogram(
+		"harmonia_http_request_duration_seconds",
+		"HTTP request latency by method, route and status.",
+		DefaultBuckets, "method", "route", "status")
+)
+
+// Pricing engine metrics, recorded for every calculation including backtests,
+// simulations and replays
+var (
+	Calculations = Default.NewCounter(
+		"harmonia_engine_calculations_total",
+		"Pricing engine calculations by strategy.",
+		"strategy")
+
+	CalculationErrors = Default.NewCounter(
+		"harmonia_engine_calculation_errors_total",
+		"Pricing engine calculations that failed, by strategy.",
+		"strategy")
+
+	CalculationDuration = Default.NewHistogram(
+		"harmonia_engine_calculation_duration_seconds",
+		"Pricing engine calculation latency by strategy.",
+		DefaultBuckets, "strategy")
+)
+
+// Access control metrics
+var (
+	RateLimitRejections = Default.NewCounter(
+		"harmonia_rate_limit_rejections_total",
+		"Requests rejected by the rate limiter.")
+
+	AuthFailures = Default.NewCounter(
+		"harmonia_auth_failures_total",
+		"Rejected authentications by reason.",
+		"reason")
+)
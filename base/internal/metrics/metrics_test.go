@@ -0,0 +1,56 @@
+package metrics
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestRegistryWriteText(t *testing.T) {
+	registry := NewRegistry()
+	requests := registry.NewCounter("test_requests_total", "Requests by route.", "route", "status")
+	rejections := registry.NewCounter("test_rejections_total", "Rejections.")
+	latency := registry.NewHistogram("test_latency_seconds", "Latency.", []float64{0.1, 1}, "route")
+	registry.NewGaugeFunc("test_queue_depth", "Queue depth.", func() float64 { return 3 })
+
+	requests.Inc("/v1/logs/:id", "200")
+	requests.Inc("/v1/logs/:id", "200")
+	requests.Inc(`/a"b\c`, "500")
+	requests.Inc("missing status") // wrong label count is ignored
+	latency.Observe(0.05, "/v1/logs")
+	latency.Observe(0.1, "/v1/logs")
+	latency.Observe(2, "/v1/logs")
+
+	var buf bytes.Buffer
+	if err := registry.WriteText(&buf); err != nil {
+		t.Fatalf("WriteText failed: %v", err)
+	}
+
+	expected := `# HELP test_requests_total Requests by route.
+# TYPE test_requests_total counter
+test_requests_total{route="/a\"b\\c",status="500"} 1
+test_requests_total{route="/v1/logs/:id",status="200"} 2
+# HELP test_rejections_total Rejections.
+# TYPE test_rejections_total counter
+test_rejections_total 0
+# HELP test_latency_seconds Latency.
+# TYPE test_latency_seconds histogram
+test_latency_seconds_bucket{route="/v1/logs",le="0.1"} 2
+test_latency_seconds_bucket{route="/v1/logs",le="1"} 2
+test_latency_seconds_bucket{route="/v1/logs",le="+Inf"} 3
+test_latency_seconds_sum{route="/v1/logs"} 2.15
+test_latency_seconds_count{route="/v1/logs"} 3
+# HELP test_queue_depth Queue depth.
+# TYPE test_queue_depth gauge
+test_queue_depth 3
+`
+	if buf.String() != expected {
+		t.Errorf("unexpected output:\n%s\nexpected:\n%s", buf.String(), expected)
+	}
+
+	rejections.Add(-1)
+	buf.Reset()
+	_ = registry.WriteText(&buf)
+	if !bytes.Contains(buf.Bytes(), []byte("test_rejections_total 0\n")) {
+		t.Errorf("expected a negative amount to be ignored, got:\n%s", buf.String())
+	}
+}
@@ -2,15 +2,17 @@ package middleware
 
 import (
 	"context"
+	"crypto/subtle"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/saintparish4/harmonia/internal/metrics"
 )
 
 // APIKeyRepository defines the interface for API key validation
 type APIKeyRepository interface {
-	ValidateKey(ctx context.Context, keyHash string) (userID uuid.UUID, isValid bool, err error)
+	ValidateKey(ctx context.Context, keyHash string) (userID, keyID uuid.UUID, isValid bool, err error)
 }
 
 // AuthMiddleware handles API key authentication
@@ -39,6 +41,7 @@ func (a *AuthMiddleware) Authenticate() gin.HandlerFunc {
 		}
 
 		if apiKey == "" {
+			metrics.AuthFailures.Inc("missing_api_key")
 			c.JSON(401, gin.H{
 				"error":   "Unauthorized",
 				"message": "API key is required",
@@ -50,6 +53,7 @@ func (a *AuthMiddleware) Authenticate() gin.HandlerFunc {
 
 		// Validate API key format (basic check)
 		if !strings.HasPrefix(apiKey, "hm_live_") && !strings.HasPrefix(apiKey, "hm_test_") {
+			metrics.AuthFailures.Inc("invalid_api_key_format")
 			c.JSON(401, gin.H{
 				"error":   "Unauthorized",
 				"message": "Invalid API key format",
@@ -61,8 +65,9 @@ func (a *AuthMiddleware) Authenticate() gin.HandlerFunc {
 
 		// Validate API key with repository
 		ctx := c.Request.Context()
-		userID, isValid, err := a.repo.ValidateKey(ctx, apiKey)
+		userID, keyID, isValid, err := a.repo.ValidateKey(ctx, apiKey)
 		if err != nil {
+			metrics.AuthFailures.Inc("validation_error")
 			c.JSON(500, gin.H{
 				"error":   "Internal Server Error",
 				"message": "Failed to validate API key",
@@ -73,6 +78,7 @@ func (a *AuthMiddleware) Authenticate() gin.HandlerFunc {
 		}
 
 		if !isValid {
+			metrics.AuthFailures.Inc("invalid_api_key")
 			c.JSON(401, gin.H{
 				"error":   "Unauthorized",
 				"message": "Invalid or revoked API key",
@@ -82,8 +88,9 @@ func (a *AuthMiddleware) Authenticate() gin.HandlerFunc {
 			return
 		}
 
-		// Set user ID in context for downstream handlers
+		// Set user and key IDs in context for downstream handlers
 		c.Set("user_id", userID)
+		c.Set("api_key_id", keyID)
 		c.Set("api_key", apiKey)
 
 		c.Next()
@@ -104,9 +111,10 @@ func (a *AuthMiddleware) OptionalAuth() gin.HandlerFunc {
 
 		if apiKey != "" && (strings.HasPrefix(apiKey, "hm_live_") || strings.HasPrefix(apiKey, "hm_test_")) {
 			ctx := c.Request.Context()
-			userID, isValid, err := a.repo.ValidateKey(ctx, apiKey)
+			userID, keyID, isValid, err := a.repo.ValidateKey(ctx, apiKey)
 			if err == nil && isValid {
 				c.Set("user_id", userID)
+				c.Set("api_key_id", keyID)
 				c.Set("api_key", apiKey)
 			}
 		}
@@ -114,3 +122,33 @@ func (a *AuthMiddleware) OptionalAuth() gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// AdminAuth allows requests carrying the configured admin key in X-Admin-Key.
+// Every request is rejected when no admin key is configured.
+func AdminAuth(adminKey string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if adminKey == "" {
+			c.JSON(403, gin.H{
+				"error":   "Forbidden",
+				"message": "Admin API is disabled",
+				"code":    "ADMIN_DISABLED",
+			})
+			c.Abort()
+			return
+		}
+
+		provided := c.GetHeader("X-Admin-Key")
+		if subtle.ConstantTimeCompare([]byte(provided), []byte(adminKey)) != 1 {
+			metrics.AuthFailures.Inc("invalid_admin_key")
+			c.JSON(401, gin.H{
+				"error":   "Unauthorized",
+				"message": "Invalid admin key",
+				"code":    "INVALID_ADMIN_KEY",
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
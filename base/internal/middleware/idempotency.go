@@ -0,0 +1,292 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const (
+	// IdempotencyKeyHeader carries the client's key for a mutating request
+	IdempotencyKeyHeader = "Idempotency-Key"
+
+	// IdempotentReplayedHeader marks a response replayed from an earlier request
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+
+	// maxIdempotencyKeyLength matches the idempotency_keys column
+	maxIdempotencyKeyLength = 255
+
+	// idempotencyLockTimeout is how long an in-flight request holds its key
+	// before it is considered abandoned and a retry may run it again
+	idempotencyLockTimeout = 5 * time.Minute
+
+	// maxIdempotentResponseSize bounds the stored response body; larger
+	// responses are not stored and their retries run again
+	maxIdempotentResponseSize = 1 << 20
+)
+
+// idempotentHeaders are the response headers replayed with a stored response
+var idempotentHeaders = []string{"Content-Type", "Location"}
+
+// IdempotencyRecord is a request made with an Idempotency-Key and its response
+type IdempotencyRecord struct {
+	UserID      uuid.UUID
+	Key         string
+	Fingerprint string
+	Token       uuid.UUID // Identifies the reservation
+	Status      int
+	Headers     map[string]string
+	Body        []byte
+	CreatedAt   time.Time
+	CompletedAt *time.Time
+	ExpiresAt   time.Time
+}
+
+// IdempotencyStore defines the interface for storing idempotent responses
+type IdempotencyStore interface {
+	// Reserve stores an in-flight record unless the key is already held by an
+	// unexpired record. In-flight records created before staleBefore are
+	// taken over. Returns the existing record and false if the key is held.
+	Reserve(ctx context.Context, record *IdempotencyRecord, now, staleBefore time.Time) (*IdempotencyRecord, bool, error)
+	// Complete and Release act only while record.Token still holds the key,
+	// so a request whose key was taken over cannot touch the new reservation
+	Complete(ctx context.Context, record *IdempotencyRecord) error
+	Release(ctx context.Context, record *IdempotencyRecord) error
+}
+
+// Idempotency replays the stored response when a mutating request is retried
+// with the same Idempotency-Key
+type Idempotency struct {
+	store       IdempotencyStore
+	ttl         time.Duration
+	maxBodySize int64
+	now         func() time.Time
+}
+
+// NewIdempotency creates an idempotency middleware that keeps responses for
+// ttl. Request bodies are read into memory to fingerprint them, so bodies
+// over maxBodySize bytes are rejected with 413.
+func NewIdempotency(store IdempotencyStore, ttl time.Duration, maxBodySize int64) *Idempotency {
+	return &Idempotency{
+		store:       store,
+		ttl:         ttl,
+		maxBodySize: maxBodySize,
+		now:         time.Now,
+	}
+}
+
+// Middleware returns a Gin middleware function. It must run after
+// authentication, since keys are scoped to the user.
+//
+// The first request with a key runs and its response is stored, unless it
+// fails with a 5xx status. Retries with the same method, path and body get
+// the stored response; a reused key with a different request is rejected,
+// as is a retry while the first request is still in flight.
+func (i *Idempotency) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" || !isMutatingMethod(c.Request.Method) {
+			c.Next()
+			return
+		}
+
+		if len(key) > maxIdempotencyKeyLength {
+			c.JSON(400, gin.H{
+				"error":   "Bad Request",
+				"message": "Idempotency-Key must be at most 255 characters",
+				"code":    "INVALID_IDEMPOTENCY_KEY",
+			})
+			c.Abort()
+			return
+		}
+
+		value, exists := c.Get("user_id")
+		userID, ok := value.(uuid.UUID)
+		if !exists || !ok {
+			c.Next()
+			return
+		}
+
+		if c.Request.ContentLength > i.maxBodySize {
+			abortBodyTooLarge(c)
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, i.maxBodySize))
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			abortBodyTooLarge(c)
+			return
+		}
+		if err != nil {
+			c.JSON(400, gin.H{
+				"error":   "Bad Request",
+				"message": "Failed to read request body",
+				"code":    "INVALID_REQUEST",
+			})
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		now := i.now()
+		record := &IdempotencyRecord{
+			UserID:      userID,
+			Key:         key,
+			Fingerprint: idempotencyFingerprint(c.Request, body),
+			Token:       uuid.New(),
+			CreatedAt:   now,
+			ExpiresAt:   now.Add(i.ttl),
+		}
+
+		ctx := c.Request.Context()
+		existing, reserved, err := i.store.Reserve(ctx, record, now, now.Add(-idempotencyLockTimeout))
+		if err != nil {
+			c.JSON(500, gin.H{
+				"error":   "Internal Server Error",
+				"message": "Failed to check idempotency key",
+				"code":    "IDEMPOTENCY_ERROR",
+			})
+			c.Abort()
+			return
+		}
+		if !reserved {
+			replayIdempotent(c, existing, record.Fingerprint)
+			return
+		}
+
+		// The key is released unless a response is stored, including when a
+		// handler panics, so the client can retry
+		stored := false
+		defer func() {
+			if stored {
+				return
+			}
+			if err := i.store.Release(context.WithoutCancel(ctx), record); err != nil {
+				log.Printf("idempotency: failed to release key %q: %v", key, err)
+			}
+		}()
+
+		writer := &recordingWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+
+		c.Next()
+
+		status := writer.Status()
+		if status >= 500 || writer.overflow {
+			return
+		}
+
+		completedAt := i.now()
+		record.Status = status
+		record.Headers = make(map[string]string, len(idempotentHeaders))
+		for _, header := range idempotentHeaders {
+			if value := writer.Header().Get(header); value != "" {
+				record.Headers[header] = value
+			}
+		}
+		record.Body = writer.body.Bytes()
+		record.CompletedAt = &completedAt
+
+		// Keep the key held even if storing fails: running the request again
+		// is what the client is protecting against
+		stored = true
+		if err := i.store.Complete(context.WithoutCancel(ctx), record); err != nil {
+			log.Printf("idempotency: failed to store response for key %q: %v", key, err)
+		}
+	}
+}
+
+// replayIdempotent answers a request whose key is already held
+func replayIdempotent(c *gin.Context, existing *IdempotencyRecord, fingerprint string) {
+	switch {
+	case existing.Fingerprint != fingerprint:
+		c.JSON(422, gin.H{
+			"error":   "Unprocessable Entity",
+			"message": "Idempotency-Key was already used for a different request",
+			"code":    "IDEMPOTENCY_KEY_REUSED",
+		})
+	case existing.CompletedAt == nil:
+		c.Header("Retry-After", "1")
+		c.JSON(409, gin.H{
+			"error":   "Conflict",
+			"message": "A request with this Idempotency-Key is still in progress",
+			"code":    "IDEMPOTENCY_KEY_IN_PROGRESS",
+		})
+	default:
+		for header, value := range existing.Headers {
+			c.Header(header, value)
+		}
+		c.Header(IdempotentReplayedHeader, "true")
+		c.Data(existing.Status, existing.Headers["Content-Type"], existing.Body)
+	}
+	c.Abort()
+}
+
+// idempotencyFingerprint identifies a request by its method, path, query and body
+func idempotencyFingerprint(r *http.Request, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(r.Method))
+	hash.Write([]byte(" "))
+	hash.Write([]byte(r.URL.RequestURI()))
+	hash.Write([]byte("\n"))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// abortBodyTooLarge rejects a request whose body is over the size limit
+func abortBodyTooLarge(c *gin.Context) {
+	c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+		"error":   "Request Entity Too Large",
+		"message": "Request body is too large",
+		"code":    "REQUEST_TOO_LARGE",
+	})
+	c.Abort()
+}
+
+// isMutatingMethod reports whether requests with the method change state
+func isMutatingMethod(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// recordingWriter keeps a copy of the response body as it is written
+type recordingWriter struct {
+	gin.ResponseWriter
+	body     bytes.Buffer
+	overflow bool
+}
+
+func (w *recordingWriter) Write(data []byte) (int, error) {
+	w.record(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *recordingWriter) WriteString(s string) (int, error) {
+	w.record([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *recordingWriter) record(data []byte) {
+	if w.overflow {
+		return
+	}
+	if w.body.Len()+len(data) > maxIdempotentResponseSize {
+		w.overflow = true
+		w.body.Reset()
+		return
+	}
+	w.body.Write(data)
+}
@@ -0,0 +1,322 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// memoryIdempotencyStore holds records in memory with the store's reservation rules
+type memoryIdempotencyStore struct {
+	mu      sync.Mutex
+	records map[string]*IdempotencyRecord
+}
+
+func newMemoryIdempotencyStore() *memoryIdempotencyStore {
+	return &memoryIdempotencyStore{records: make(map[string]*IdempotencyRecord)}
+}
+
+func (s *memoryIdempotencyStore) Reserve(ctx context.Context, record *IdempotencyRecord, now, staleBefore time.Time) (*IdempotencyRecord, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := record.UserID.String() + "/" + record.Key
+	existing, ok := s.records[id]
+	if ok && existing.ExpiresAt.After(now) && (existing.CompletedAt != nil || !existing.CreatedAt.Before(staleBefore)) {
+		copied := *existing
+		return &copied, false, nil
+	}
+
+	copied := *record
+	s.records[id] = &copied
+	return nil, true, nil
+}
+
+func (s *memoryIdempotencyStore) Complete(ctx context.Context, record *IdempotencyRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := record.UserID.String() + "/" + record.Key
+	if held, ok := s.records[id]; ok && held.Token == record.Token && held.CompletedAt == nil {
+		copied := *record
+		s.records[id] = &copied
+	}
+	return nil
+}
+
+func (s *memoryIdempotencyStore) Release(ctx context.Context, record *IdempotencyRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := record.UserID.String() + "/" + record.Key
+	if held, ok := s.records[id]; ok && held.Token == record.Token && held.CompletedAt == nil {
+		delete(s.records, id)
+	}
+	return nil
+}
+
+// idempotentRouter serves POST /items as userID, counting handler runs
+func idempotentRouter(store IdempotencyStore, userID uuid.UUID, handler gin.HandlerFunc) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("user_id", userID)
+	})
+	router.Use(NewIdempotency(store, 24*time.Hour, 1<<20).Middleware())
+	router.POST("/items", handler)
+	router.GET("/items", handler)
+	return router
+}
+
+func idempotentRequest(router *gin.Engine, method, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, "/items", strings.NewReader(body))
+	if key != "" {
+		req.Header.Set(IdempotencyKeyHeader, key)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestIdempotencyReplaysResponse(t *testing.T) {
+	var runs atomic.Int32
+	router := idempotentRouter(newMemoryIdempotencyStore(), uuid.New(), func(c *gin.Context) {
+		n := runs.Add(1)
+		c.Header("Location", "/items/1")
+		c.JSON(http.StatusCreated, gin.H{"run": n})
+	})
+
+	first := idempotentRequest(router, http.MethodPost, "key-1", `{"sku":"A"}`)
+	retry := idempotentRequest(router, http.MethodPost, "key-1", `{"sku":"A"}`)
+
+	if runs.Load() != 1 {
+		t.Fatalf("expected the handler to run once, ran %d times", runs.Load())
+	}
+	if retry.Code != http.StatusCreated || retry.Body.String() != first.Body.String() {
+		t.Errorf("expected replay of %d %s, got %d %s", first.Code, first.Body, retry.Code, retry.Body)
+	}
+	if retry.Header().Get(IdempotentReplayedHeader) != "true" {
+		t.Error("expected the replay to be marked")
+	}
+	if retry.Header().Get("Location") != "/items/1" || !strings.HasPrefix(retry.Header().Get("Content-Type"), "application/json") {
+		t.Errorf("expected stored headers to be replayed, got %v", retry.Header())
+	}
+
+	// A different key runs the request again
+	idempotentRequest(router, http.MethodPost, "key-2", `{"sku":"A"}`)
+	if runs.Load() != 2 {
+		t.Errorf("expected a new key to run the handler, ran %d times", runs.Load())
+	}
+}
+
+func TestIdempotencyRejectsReusedKey(t *testing.T) {
+	router := idempotentRouter(newMemoryIdempotencyStore(), uuid.New(), func(c *gin.Context) {
+		c.JSON(http.StatusCreated, gin.H{})
+	})
+
+	idempotentRequest(router, http.MethodPost, "key-1", `{"sku":"A"}`)
+	w := idempotentRequest(router, http.MethodPost, "key-1", `{"sku":"B"}`)
+
+	if w.Code != http.StatusUnprocessableEntity || !strings.Contains(w.Body.String(), "IDEMPOTENCY_KEY_REUSED") {
+		t.Errorf("expected 422 IDEMPOTENCY_KEY_REUSED, got %d %s", w.Code, w.Body)
+	}
+}
+
+func TestIdempotencyReleasesServerErrors(t *testing.T) {
+	var runs atomic.Int32
+	router := idempotentRouter(newMemoryIdempotencyStore(), uuid.New(), func(c *gin.Context) {
+		if runs.Add(1) == 1 {
+			c.JSON(http.StatusInternalServerError, gin.H{})
+			return
+		}
+		c.JSON(http.StatusCreated, gin.H{})
+	})
+
+	idempotentRequest(router, http.MethodPost, "key-1", `{}`)
+	w := idempotentRequest(router, http.MethodPost, "key-1", `{}`)
+
+	if runs.Load() != 2 || w.Code != http.StatusCreated {
+		t.Errorf("expected the retry to run again and succeed, ran %d times with %d", runs.Load(), w.Code)
+	}
+}
+
+func TestIdempotencyRejectsLargeBodies(t *testing.T) {
+	var runs atomic.Int32
+	store := newMemoryIdempotencyStore()
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("user_id", uuid.New())
+	})
+	router.Use(NewIdempotency(store, 24*time.Hour, 16).Middleware())
+	router.POST("/items", func(c *gin.Context) {
+		runs.Add(1)
+		c.JSON(http.StatusCreated, gin.H{})
+	})
+
+	body := `{"sku":"a-very-long-sku"}`
+	tests := []struct {
+		name          string
+		contentLength int64
+	}{
+		{"declared length", int64(len(body))},
+		{"unknown length", -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/items", strings.NewReader(body))
+			req.ContentLength = tt.contentLength
+			req.Header.Set(IdempotencyKeyHeader, "key-1")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != http.StatusRequestEntityTooLarge {
+				t.Errorf("expected 413, got %d", w.Code)
+			}
+		})
+	}
+
+	if runs.Load() != 0 {
+		t.Errorf("expected the handler not to run, ran %d times", runs.Load())
+	}
+	if len(store.records) != 0 {
+		t.Errorf("expected no key to be reserved, got %d", len(store.records))
+	}
+
+	// Bodies within the limit still run
+	if w := idempotentRequest(router, http.MethodPost, "key-2", `{"sku":"A"}`); w.Code != http.StatusCreated {
+		t.Errorf("expected a small body to succeed, got %d", w.Code)
+	}
+}
+
+func TestIdempotencyStaleTakeover(t *testing.T) {
+	tests := []struct {
+		name        string
+		firstStatus int
+	}{
+		{"completed", http.StatusCreated},
+		{"released", http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newMemoryIdempotencyStore()
+			clock := time.Now()
+			idempotency := NewIdempotency(store, 24*time.Hour, 1<<20)
+			idempotency.now = func() time.Time { return clock }
+
+			gin.SetMode(gin.TestMode)
+			router := gin.New()
+			userID := uuid.New()
+			router.Use(func(c *gin.Context) {
+				c.Set("user_id", userID)
+			})
+			router.Use(idempotency.Middleware())
+
+			// The first request outlives its lock, a retry takes the key over, and
+			// the first finishes while the retry is still running
+			var runs atomic.Int32
+			retryStarted, firstDone, retryDone := make(chan struct{}), make(chan struct{}), make(chan struct{})
+			router.POST("/items", func(c *gin.Context) {
+				if runs.Add(1) == 1 {
+					clock = clock.Add(idempotencyLockTimeout + time.Second)
+					go func() {
+						defer close(retryDone)
+						idempotentRequest(router, http.MethodPost, "key-1", `{"sku":"A"}`)
+					}()
+					<-retryStarted
+					c.JSON(tt.firstStatus, gin.H{"run": "first"})
+					return
+				}
+				close(retryStarted)
+				<-firstDone
+				c.JSON(http.StatusCreated, gin.H{"run": "retry"})
+			})
+
+			idempotentRequest(router, http.MethodPost, "key-1", `{"sku":"A"}`)
+			close(firstDone)
+			<-retryDone
+
+			// The stale request neither overwrites nor releases the retry's response
+			replay := idempotentRequest(router, http.MethodPost, "key-1", `{"sku":"A"}`)
+			if runs.Load() != 2 {
+				t.Errorf("expected the handler to run twice, ran %d times", runs.Load())
+			}
+			if replay.Header().Get(IdempotentReplayedHeader) != "true" || !strings.Contains(replay.Body.String(), "retry") {
+				t.Errorf("expected the retry's response to be replayed, got %d %s", replay.Code, replay.Body)
+			}
+		})
+	}
+}
+
+func TestIdempotencyIgnoresSafeRequests(t *testing.T) {
+	var runs atomic.Int32
+	router := idempotentRouter(newMemoryIdempotencyStore(), uuid.New(), func(c *gin.Context) {
+		runs.Add(1)
+		c.JSON(http.StatusOK, gin.H{})
+	})
+
+	idempotentRequest(router, http.MethodGet, "key-1", "")
+	idempotentRequest(router, http.MethodGet, "key-1", "")
+	idempotentRequest(router, http.MethodPost, "", `{}`)
+	idempotentRequest(router, http.MethodPost, "", `{}`)
+
+	if runs.Load() != 4 {
+		t.Errorf("expected every request to run, ran %d times", runs.Load())
+	}
+}
+
+func TestIdempotencyConcurrentDuplicates(t *testing.T) {
+	var runs atomic.Int32
+	release := make(chan struct{})
+	router := idempotentRouter(newMemoryIdempotencyStore(), uuid.New(), func(c *gin.Context) {
+		runs.Add(1)
+		<-release
+		c.JSON(http.StatusCreated, gin.H{})
+	})
+
+	first := make(chan *httptest.ResponseRecorder)
+	go func() {
+		first <- idempotentRequest(router, http.MethodPost, "key-1", `{}`)
+	}()
+	for runs.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// Duplicates arriving while the first request runs are turned away
+	var wg sync.WaitGroup
+	codes := make([]int, 5)
+	for i := range codes {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			codes[i] = idempotentRequest(router, http.MethodPost, "key-1", `{}`).Code
+		}(i)
+	}
+	wg.Wait()
+	for _, code := range codes {
+		if code != http.StatusConflict {
+			t.Errorf("expected 409 while in flight, got %d", code)
+		}
+	}
+
+	close(release)
+	if w := <-first; w.Code != http.StatusCreated {
+		t.Errorf("expected the first request to succeed, got %d", w.Code)
+	}
+
+	w := idempotentRequest(router, http.MethodPost, "key-1", `{}`)
+	if runs.Load() != 1 || w.Code != http.StatusCreated || w.Header().Get(IdempotentReplayedHeader) != "true" {
+		t.Errorf("expected a replay after completion, ran %d times with %d", runs.Load(), w.Code)
+	}
+}
@@ -20,13 +20,14 @@ func RequestLogger() gin.HandlerFunc {
 
 		// Log request details
 		log.Printf(
-			"[%s] %s %s | Status: %d | Duration: %v | IP: %s",
+			"[%s] %s %s | Status: %d | Duration: %v | IP: %s | Request: %s",
 			c.Request.Method,
 			c.Request.URL.Path,
 			c.Request.Proto,
 			c.Writer.Status(),
 			duration,
 			c.ClientIP(),
+			c.GetString("request_id"),
 		)
 	}
 }
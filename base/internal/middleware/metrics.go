@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/saintparish4/harmonia/internal/metrics"
+)
+
+// Metrics records the count and latency of HTTP requests. Requests are
+// labelled by route pattern, such as /v1/logs/:id, so IDs don't multiply
+// the series; unmatched paths share the route "unmatched".
+func Metrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		startTime := time.Now()
+
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		status := strconv.Itoa(c.Writer.Status())
+
+		metrics.HTTPRequests.Inc(c.Request.Method, route, status)
+		metrics.HTTPRequestDuration.Observe(time.Since(startTime).Seconds(), c.Request.Method, route, status)
+	}
+}
+
+// MetricsAuth allows requests carrying the configured metrics token as a
+// bearer token. Every request is rejected when no token is configured.
+func MetricsAuth(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token == "" {
+			c.JSON(403, gin.H{
+				"error":   "Forbidden",
+				"message": "Metrics are disabled",
+				"code":    "METRICS_DISABLED",
+			})
+			c.Abort()
+			return
+		}
+
+		provided := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+			metrics.AuthFailures.Inc("invalid_metrics_token")
+			c.JSON(401, gin.H{
+				"error":   "Unauthorized",
+				"message": "Invalid metrics token",
+				"code":    "INVALID_METRICS_TOKEN",
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
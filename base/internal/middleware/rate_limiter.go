@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/saintparish4/harmonia/internal/metrics"
 )
 
 // RateLimiter implements token bucket algorithm for rate limiting
@@ -53,6 +54,7 @@ func (rl *RateLimiter) Middleware() gin.HandlerFunc {
 		}
 
 		if !rl.allow(identifier) {
+			metrics.RateLimitRejections.Inc()
 			c.JSON(429, gin.H{
 				"error":   "Too Many Requests",
 				"message": "Rate limit exceeded. Please try again later.",
@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func TestRequestID(t *testing.T) {
+	tests := []struct {
+		name     string
+		header   string
+		wantSame bool
+	}{
+		{name: "client id is kept", header: "req-123_abc.4:5", wantSame: true},
+		{name: "missing id is generated"},
+		{name: "unsafe id is replaced", header: "req 1\nInjected: yes"},
+		{name: "long id is replaced", header: strings.Repeat("a", maxRequestIDLength+1)},
+	}
+
+	gin.SetMode(gin.TestMode)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var seen string
+			router := gin.New()
+			router.Use(RequestID())
+			router.GET("/", func(c *gin.Context) {
+				seen = c.GetString("request_id")
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				req.Header.Set(RequestIDHeader, tt.header)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			got := w.Header().Get(RequestIDHeader)
+			if got != seen {
+				t.Errorf("expected the response to echo %q, got %q", seen, got)
+			}
+			if tt.wantSame {
+				if got != tt.header {
+					t.Errorf("expected %q to be kept, got %q", tt.header, got)
+				}
+				return
+			}
+			if _, err := uuid.Parse(got); err != nil {
+				t.Errorf("expected a generated UUID, got %q", got)
+			}
+		})
+	}
+}
@@ -57,19 +57,16 @@ func (r *APIKeyRepo) Create(ctx context.Context, key *domain.APIKey) error {
 	return nil
 }
 
-// GetByHash retrieves an API key by its hash
-func (r *APIKeyRepo) GetByHash(ctx context.Context, hash string) (*domain.APIKey, error) {
-	query := `
-		SELECT id, user_id, key_hash, key_prefix, name, last_used_at, 
-		       is_active, created_at, revoked_at
-		FROM api_keys
-		WHERE key_hash = $1 AND is_active = true
-	`
+// apiKeyColumns selects a full API key
+const apiKeyColumns = `id, user_id, key_hash, key_prefix, name, last_used_at,
+		       is_active, can_approve, created_at, revoked_at`
 
+// scanAPIKey scans a row selected with apiKeyColumns
+func scanAPIKey(row rowScanner) (*domain.APIKey, error) {
 	key := &domain.APIKey{}
 	var lastUsedAt, revokedAt sql.NullTime
 
-	err := r.db.QueryRowContext(ctx, query, hash).Scan(
+	err := row.Scan(
 		&key.ID,
 		&key.UserID,
 		&key.KeyHash,
@@ -77,15 +74,12 @@ func (r *APIKeyRepo) GetByHash(ctx context.Context, hash string) (*domain.APIKey
 		&key.Name,
 		&lastUsedAt,
 		&key.IsActive,
+		&key.CanApprove,
 		&key.CreatedAt,
 		&revokedAt,
 	)
-
-	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("API key not found or inactive")
-	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to get API key: %w", err)
+		return nil, err
 	}
 
 	// Handle nullable timestamps
@@ -99,17 +93,63 @@ func (r *APIKeyRepo) GetByHash(ctx context.Context, hash string) (*domain.APIKey
 	return key, nil
 }
 
+// GetByHash retrieves an API key by its hash
+func (r *APIKeyRepo) GetByHash(ctx context.Context, hash string) (*domain.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE key_hash = $1 AND is_active = true`
+
+	key, err := scanAPIKey(r.db.QueryRowContext(ctx, query, hash))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("API key not found or inactive")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get API key: %w", err)
+	}
+
+	return key, nil
+}
+
+// GetByID retrieves an API key by ID, including revoked keys
+func (r *APIKeyRepo) GetByID(ctx context.Context, id uuid.UUID) (*domain.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE id = $1`
+
+	key, err := scanAPIKey(r.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w: %s", domain.ErrAPIKeyNotFound, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get API key: %w", err)
+	}
+
+	return key, nil
+}
+
 // GetByUserID retrieves all API keys for a user
 func (r *APIKeyRepo) GetByUserID(ctx context.Context, userID uuid.UUID) ([]*domain.APIKey, error) {
-	query := `
-		SELECT id, user_id, key_hash, key_prefix, name, last_used_at, 
-		       is_active, created_at, revoked_at
-		FROM api_keys
-		WHERE user_id = $1
-		ORDER BY created_at DESC
-	`
+	return r.List(ctx, domain.APIKeyFilter{UserID: userID})
+}
+
+// List retrieves a user's API keys newest first, continuing after filter.After
+func (r *APIKeyRepo) List(ctx context.Context, filter domain.APIKeyFilter) ([]*domain.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE user_id = $1`
+	args := []interface{}{filter.UserID}
+	argCount := 1
+
+	after, afterArgs := cursorCondition(filter.After, "", argCount)
+	query += after
+	args = append(args, afterArgs...)
+	argCount += len(afterArgs)
+
+	// Add ordering
+	query += " ORDER BY " + keysetOrder
+
+	// Add pagination
+	if filter.Limit > 0 {
+		argCount++
+		query += fmt.Sprintf(" LIMIT $%d", argCount)
+		args = append(args, filter.Limit)
+	}
 
-	rows, err := r.db.QueryContext(ctx, query, userID)
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query API keys: %w", err)
 	}
@@ -118,31 +158,11 @@ func (r *APIKeyRepo) GetByUserID(ctx context.Context, userID uuid.UUID) ([]*doma
 	var keys []*domain.APIKey
 
 	for rows.Next() {
-		key := &domain.APIKey{}
-		var lastUsedAt, revokedAt sql.NullTime
-
-		err := rows.Scan(
-			&key.ID,
-			&key.UserID,
-			&key.KeyHash,
-			&key.KeyPrefix,
-			&key.Name,
-			&lastUsedAt,
-			&key.IsActive,
-			&key.CreatedAt,
-			&revokedAt,
-		)
+		key, err := scanAPIKey(rows)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan API key: %w", err)
 		}
 
-		if lastUsedAt.Valid {
-			key.LastUsedAt = &lastUsedAt.Time
-		}
-		if revokedAt.Valid {
-			key.RevokedAt = &revokedAt.Time
-		}
-
 		keys = append(keys, key)
 	}
 
@@ -153,6 +173,17 @@ func (r *APIKeyRepo) GetByUserID(ctx context.Context, userID uuid.UUID) ([]*doma
 	return keys, nil
 }
 
+// Count returns the number of a user's API keys
+func (r *APIKeyRepo) Count(ctx context.Context, filter domain.APIKeyFilter) (int, error) {
+	var count int
+	err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM api_keys WHERE user_id = $1", filter.UserID).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count API keys: %w", err)
+	}
+
+	return count, nil
+}
+
 // UpdateLastUsed updates the last_used_at timestamp for an API key
 func (r *APIKeyRepo) UpdateLastUsed(ctx context.Context, id uuid.UUID) error {
 	query := `
@@ -205,6 +236,31 @@ func (r *APIKeyRepo) Revoke(ctx context.Context, id uuid.UUID) error {
 	return nil
 }
 
+// SetCanApprove grants or withdraws an active key's right to approve drafts
+func (r *APIKeyRepo) SetCanApprove(ctx context.Context, id uuid.UUID, canApprove bool) error {
+	query := `
+		UPDATE api_keys
+		SET can_approve = $1
+		WHERE id = $2 AND is_active = true
+	`
+
+	result, err := r.db.ExecContext(ctx, query, canApprove, id)
+	if err != nil {
+		return fmt.Errorf("failed to update API key approval: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("%w: API key %s is missing or revoked", domain.ErrAPIKeyNotFound, id)
+	}
+
+	return nil
+}
+
 // Delete permanently deletes an API key
 func (r *APIKeyRepo) Delete(ctx context.Context, id uuid.UUID) error {
 	query := `DELETE FROM api_keys WHERE id = $1`
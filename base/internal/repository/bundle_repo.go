@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+	"github.com/saintparish4/harmonia/internal/domain"
+)
+
+// BundleRepo implements domain.BundleRepository
+type BundleRepo struct {
+	db *sql.DB
+}
+
+// NewBundleRepository creates a new bundle repository
+func NewBundleRepository(db *sql.DB) domain.BundleRepository {
+	return &BundleRepo{db: db}
+}
+
+// ApplyImport writes an import plan in one transaction. Imported rule content
+// becomes each rule's pending draft, and is published too when plan.Publish is set.
+func (r *BundleRepo) ApplyImport(ctx context.Context, plan *domain.ImportPlan) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, planned := range plan.Rules {
+		if err := applyPlannedRule(ctx, tx, plan, planned); err != nil {
+			return err
+		}
+	}
+
+	for _, planned := range plan.Products {
+		if err := applyPlannedProduct(ctx, tx, planned); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit import: %w", err)
+	}
+
+	return nil
+}
+
+// applyPlannedRule creates or updates one rule through a draft
+func applyPlannedRule(ctx context.Context, tx *sql.Tx, plan *domain.ImportPlan, planned *domain.PlannedRule) error {
+	rule := planned.Rule
+
+	switch planned.Action {
+	case domain.ImportActionCreate:
+		// New rules start unpublished; their content goes through a draft like any other edit
+		unpublished := *rule
+		unpublished.PublishedAt = nil
+		if err := insertPricingRule(ctx, tx, &unpublished); err != nil {
+			return err
+		}
+	case domain.ImportActionUpdate:
+		_, err := tx.ExecContext(
+			ctx,
+			"UPDATE pricing_rules SET external_key = $1 WHERE id = $2 AND deleted_at IS NULL",
+			nullableString(rule.ExternalKey),
+			rule.ID,
+		)
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Constraint == "idx_pricing_rules_external_key" {
+			return fmt.Errorf("%w: %s", domain.ErrDuplicateExternalKey, rule.ExternalKey)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to update pricing rule key: %w", err)
+		}
+	default:
+		return nil
+	}
+
+	draft := &domain.RuleDraft{
+		RuleID:        rule.ID,
+		BaseRevision:  rule.Revision,
+		Name:          rule.Name,
+		Description:   rule.Description,
+		StrategyType:  rule.StrategyType,
+		Config:        rule.Config,
+		IsActive:      rule.IsActive,
+		EffectiveFrom: rule.EffectiveFrom,
+		EffectiveTo:   rule.EffectiveTo,
+		AuthorID:      &plan.Approval.UserID,
+		AuthorKeyID:   plan.Approval.KeyID,
+		ChangeNote:    rule.ChangeNote,
+	}
+	if err := saveRuleDraft(ctx, tx, draft); err != nil {
+		return err
+	}
+
+	if !plan.Publish {
+		return nil
+	}
+	_, err := publishRuleDraft(ctx, tx, draft.ID, plan.Approval)
+	return err
+}
+
+// applyPlannedProduct creates or updates one product and replaces its schedule
+func applyPlannedProduct(ctx context.Context, tx *sql.Tx, planned *domain.PlannedProduct) error {
+	product := planned.Product
+
+	switch planned.Action {
+	case domain.ImportActionCreate:
+		if err := insertProduct(ctx, tx, product, domain.CostSourceImport); err != nil {
+			return err
+		}
+	case domain.ImportActionUpdate:
+		if err := updateProduct(ctx, tx, product, domain.CostSourceImport); err != nil {
+			return err
+		}
+		_, err := tx.ExecContext(ctx, "DELETE FROM product_rule_assignments WHERE product_id = $1", product.ID)
+		if err != nil {
+			return fmt.Errorf("failed to clear rule assignments: %w", err)
+		}
+	default:
+		return nil
+	}
+
+	for _, assignment := range planned.Schedule {
+		assignment.ProductID = product.ID
+		if err := insertRuleAssignment(ctx, tx, assignment); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,241 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/saintparish4/harmonia/internal/domain"
+)
+
+const (
+	// calculationLogPartitionPrefix starts the name of every monthly partition,
+	// followed by yYYYYmMM
+	calculationLogPartitionPrefix = "calculation_logs_"
+
+	// calculationLogDeleteBatch bounds the logs deleted per statement, so
+	// retention never holds long locks
+	calculationLogDeleteBatch = 10000
+)
+
+// CalculationLogPartitionRepo implements domain.CalculationLogPartitionRepository
+type CalculationLogPartitionRepo struct {
+	db *sql.DB
+}
+
+// NewCalculationLogPartitionRepository creates a new calculation log partition repository
+func NewCalculationLogPartitionRepository(db *sql.DB) domain.CalculationLogPartitionRepository {
+	return &CalculationLogPartitionRepo{db: db}
+}
+
+// CreatePartition creates the partition holding month, returning false if it
+// already exists
+func (r *CalculationLogPartitionRepo) CreatePartition(ctx context.Context, month time.Time) (bool, error) {
+	var created bool
+	err := r.db.QueryRowContext(
+		ctx,
+		"SELECT create_calculation_log_partition($1)",
+		month.Format("2006-01-02"),
+	).Scan(&created)
+
+	// Another instance created it first
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "42P07" {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to create calculation log partition: %w", err)
+	}
+
+	return created, nil
+}
+
+// ListPartitions lists the attached monthly partitions, oldest first.
+// Partitions not named by create_calculation_log_partition are left out.
+func (r *CalculationLogPartitionRepo) ListPartitions(ctx context.Context) ([]*domain.CalculationLogPartition, error) {
+	query := `
+		SELECT c.relname
+		FROM pg_inherits i
+		JOIN pg_class c ON c.oid = i.inhrelid
+		WHERE i.inhparent = 'calculation_logs'::regclass
+	`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list calculation log partitions: %w", err)
+	}
+	defer rows.Close()
+
+	var partitions []*domain.CalculationLogPartition
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("failed to scan calculation log partition: %w", err)
+		}
+		if partition, ok := parseCalculationLogPartition(name); ok {
+			partitions = append(partitions, partition)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list calculation log partitions: %w", err)
+	}
+
+	sort.Slice(partitions, func(i, j int) bool {
+		return partitions[i].From.Before(partitions[j].From)
+	})
+
+	return partitions, nil
+}
+
+// ExportPartition calls fn with every log in a partition, oldest first
+func (r *CalculationLogPartitionRepo) ExportPartition(ctx context.Context, name string, fn func(*domain.CalculationLog) error) error {
+	if _, ok := parseCalculationLogPartition(name); !ok {
+		return fmt.Errorf("not a calculation log partition: %s", name)
+	}
+
+	query := fmt.Sprintf(
+		"SELECT %s FROM %s ORDER BY created_at, id",
+		calculationLogColumns,
+		pq.QuoteIdentifier(name),
+	)
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return fmt.Errorf("failed to export calculation log partition: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		log, err := scanCalculationLog(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(log); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to export calculation log partition: %w", err)
+	}
+
+	return nil
+}
+
+// DropPartition deletes a partition and its logs
+func (r *CalculationLogPartitionRepo) DropPartition(ctx context.Context, name string) error {
+	if _, ok := parseCalculationLogPartition(name); !ok {
+		return fmt.Errorf("not a calculation log partition: %s", name)
+	}
+
+	if _, err := r.db.ExecContext(ctx, "DROP TABLE IF EXISTS "+pq.QuoteIdentifier(name)); err != nil {
+		return fmt.Errorf("failed to drop calculation log partition: %w", err)
+	}
+
+	return nil
+}
+
+// DetachPartition removes a partition from the calculation logs, keeping it
+// as a standalone table
+func (r *CalculationLogPartitionRepo) DetachPartition(ctx context.Context, name string) error {
+	if _, ok := parseCalculationLogPartition(name); !ok {
+		return fmt.Errorf("not a calculation log partition: %s", name)
+	}
+
+	if _, err := r.db.ExecContext(ctx, "ALTER TABLE calculation_logs DETACH PARTITION "+pq.QuoteIdentifier(name)); err != nil {
+		return fmt.Errorf("failed to detach calculation log partition: %w", err)
+	}
+
+	return nil
+}
+
+// AccountRetention returns the retention in days of the accounts that set
+// their own
+func (r *CalculationLogPartitionRepo) AccountRetention(ctx context.Context) (map[uuid.UUID]int, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT id, log_retention_days FROM users WHERE log_retention_days IS NOT NULL")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list log retention: %w", err)
+	}
+	defer rows.Close()
+
+	retention := make(map[uuid.UUID]int)
+	for rows.Next() {
+		var userID uuid.UUID
+		var days int
+		if err := rows.Scan(&userID, &days); err != nil {
+			return nil, fmt.Errorf("failed to scan log retention: %w", err)
+		}
+		retention[userID] = days
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list log retention: %w", err)
+	}
+
+	return retention, nil
+}
+
+// DeleteUserLogsBefore deletes an account's logs created before a time
+func (r *CalculationLogPartitionRepo) DeleteUserLogsBefore(ctx context.Context, userID uuid.UUID, before time.Time) (int64, error) {
+	return r.deleteLogs(ctx, "user_id = $1 AND created_at < $2", userID, before)
+}
+
+// DeleteLogsBefore deletes logs created before a time, except those of the
+// given accounts
+func (r *CalculationLogPartitionRepo) DeleteLogsBefore(ctx context.Context, before time.Time, except []uuid.UUID) (int64, error) {
+	return r.deleteLogs(ctx, "created_at < $1 AND user_id <> ALL($2::uuid[])", before, pq.Array(uuidStrings(except)))
+}
+
+// deleteLogs deletes the logs matching a condition in batches
+func (r *CalculationLogPartitionRepo) deleteLogs(ctx context.Context, condition string, args ...interface{}) (int64, error) {
+	query := fmt.Sprintf(`
+		DELETE FROM calculation_logs
+		WHERE (id, created_at) IN (
+			SELECT id, created_at FROM calculation_logs WHERE %s LIMIT %d
+		)
+	`, condition, calculationLogDeleteBatch)
+
+	var deleted int64
+	for {
+		result, err := r.db.ExecContext(ctx, query, args...)
+		if err != nil {
+			return deleted, fmt.Errorf("failed to delete calculation logs: %w", err)
+		}
+		n, err := result.RowsAffected()
+		if err != nil {
+			return deleted, fmt.Errorf("failed to get rows affected: %w", err)
+		}
+		deleted += n
+		if n < calculationLogDeleteBatch {
+			return deleted, nil
+		}
+	}
+}
+
+// parseCalculationLogPartition reads the month from a partition name such as
+// calculation_logs_y2026m03
+func parseCalculationLogPartition(name string) (*domain.CalculationLogPartition, bool) {
+	suffix, ok := strings.CutPrefix(name, calculationLogPartitionPrefix)
+	if !ok {
+		return nil, false
+	}
+
+	var year, month int
+	if n, err := fmt.Sscanf(suffix, "y%4dm%2d", &year, &month); err != nil || n != 2 || month < 1 || month > 12 {
+		return nil, false
+	}
+	if suffix != fmt.Sprintf("y%04dm%02d", year, month) {
+		return nil, false
+	}
+
+	from := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC)
+	return &domain.CalculationLogPartition{
+		Name: name,
+		From: from,
+		To:   from.AddDate(0, 1, 0),
+	}, true
+}
@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCalculationLogPartition(t *testing.T) {
+	tests := []struct {
+		name     string
+		wantOK   bool
+		wantFrom time.Time
+	}{
+		{name: "calculation_logs_y2026m03", wantOK: true, wantFrom: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{name: "calculation_logs_y2025m12", wantOK: true, wantFrom: time.Date(2025, 12, 1, 0, 0, 0, 0, time.UTC)},
+		{name: "calculation_logs_y2026m13"},
+		{name: "calculation_logs_y2026m3"},
+		{name: "calculation_logs_y2026m03_old"},
+		{name: "calculation_logs_archive"},
+		{name: "price_book_entries"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			partition, ok := parseCalculationLogPartition(tt.name)
+			if ok != tt.wantOK {
+				t.Fatalf("expected ok %v, got %v", tt.wantOK, ok)
+			}
+			if !ok {
+				return
+			}
+			if !partition.From.Equal(tt.wantFrom) || !partition.To.Equal(tt.wantFrom.AddDate(0, 1, 0)) {
+				t.Errorf("expected %v to %v, got %v to %v", tt.wantFrom, tt.wantFrom.AddDate(0, 1, 0), partition.From, partition.To)
+			}
+		})
+	}
+}
@@ -3,10 +3,14 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"github.com/saintparish4/harmonia/internal/domain"
 )
 
@@ -24,9 +28,9 @@ func NewCalculationLogRepository(db *sql.DB) domain.CalculationLogRepository {
 func (r *CalculationLogRepo) Create(ctx context.Context, log *domain.CalculationLog) error {
 	query := `
 		INSERT INTO calculation_logs (
-			id, user_id, api_key_id, rule_id, strategy_type, 
-			input_data, output_data, execution_time_ms, created_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+			id, user_id, api_key_id, rule_id, rule_revision_id, rule_revision, strategy_type, 
+			input_data, output_data, execution_time_ms, request_id, client_ip, engine_version, error, created_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
 	`
 
 	// Generate ID if not provided
@@ -50,10 +54,16 @@ func (r *CalculationLogRepo) Create(ctx context.Context, log *domain.Calculation
 		log.UserID,
 		log.APIKeyID,
 		log.RuleID,
+		log.RuleRevisionID,
+		nullableInt(log.RuleRevision),
 		log.StrategyType,
 		inputData,
 		outputData,
 		log.ExecutionTimeMs,
+		nullableString(log.RequestID),
+		nullableString(log.ClientIP),
+		nullableString(log.EngineVersion),
+		nullableString(log.Error),
 		log.CreatedAt,
 	)
 
@@ -64,31 +74,140 @@ func (r *CalculationLogRepo) Create(ctx context.Context, log *domain.Calculation
 	return nil
 }
 
+// calculationLogInsertColumns are the columns written by CreateBatch
+var calculationLogInsertColumns = []string{
+	"id", "user_id", "api_key_id", "rule_id", "rule_revision_id", "rule_revision", "strategy_type",
+	"input_data", "output_data", "execution_time_ms", "request_id", "client_ip", "engine_version", "error", "created_at",
+}
+
+// CreateBatch writes log entries with COPY in one transaction. COPY fails as
+// a whole, so when an entry violates a constraint the entries are inserted
+// one at a time and the violating ones skipped.
+func (r *CalculationLogRepo) CreateBatch(ctx context.Context, logs []*domain.CalculationLog) (int, error) {
+	if len(logs) == 0 {
+		return 0, nil
+	}
+
+	encoded := make([]*domain.CalculationLog, 0, len(logs))
+	rows := make([][]interface{}, 0, len(logs))
+	for _, log := range logs {
+		if log.ID == uuid.Nil {
+			log.ID = uuid.New()
+		}
+		if log.CreatedAt.IsZero() {
+			log.CreatedAt = time.Now()
+		}
+
+		row, err := calculationLogRow(log)
+		if err != nil {
+			// Unencodable entries can never be written
+			continue
+		}
+		encoded = append(encoded, log)
+		rows = append(rows, row)
+	}
+
+	err := r.copyLogs(ctx, rows)
+	if err == nil {
+		return len(rows), nil
+	}
+	if !isConstraintViolation(err) {
+		return 0, err
+	}
+
+	written := 0
+	for _, log := range encoded {
+		err := r.Create(ctx, log)
+		if isConstraintViolation(err) {
+			continue
+		}
+		if err != nil {
+			return written, err
+		}
+		written++
+	}
+
+	return written, nil
+}
+
+// copyLogs copies encoded log rows into calculation_logs
+func (r *CalculationLogRepo) copyLogs(ctx context.Context, rows [][]interface{}) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, pq.CopyIn("calculation_logs", calculationLogInsertColumns...))
+	if err != nil {
+		return fmt.Errorf("failed to prepare calculation logs: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, row := range rows {
+		if _, err := stmt.ExecContext(ctx, row...); err != nil {
+			return fmt.Errorf("failed to copy calculation log: %w", err)
+		}
+	}
+
+	if _, err := stmt.ExecContext(ctx); err != nil {
+		return fmt.Errorf("failed to write calculation logs: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit calculation logs: %w", err)
+	}
+
+	return nil
+}
+
+// calculationLogRow encodes a log in calculationLogInsertColumns order. JSON
+// is passed as text, since COPY would encode bytes as bytea.
+func calculationLogRow(log *domain.CalculationLog) ([]interface{}, error) {
+	inputData, err := json.Marshal(FromMap(log.InputData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode input data: %w", err)
+	}
+	outputData, err := json.Marshal(FromMap(log.OutputData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode output data: %w", err)
+	}
+
+	return []interface{}{
+		log.ID,
+		log.UserID,
+		log.APIKeyID,
+		log.RuleID,
+		log.RuleRevisionID,
+		nullableInt(log.RuleRevision),
+		log.StrategyType,
+		string(inputData),
+		string(outputData),
+		log.ExecutionTimeMs,
+		nullableString(log.RequestID),
+		nullableString(log.ClientIP),
+		nullableString(log.EngineVersion),
+		nullableString(log.Error),
+		log.CreatedAt,
+	}, nil
+}
+
+// isConstraintViolation reports whether err is an integrity constraint
+// violation, which retrying cannot fix
+func isConstraintViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code.Class() == "23"
+}
+
 // GetByID retrieves a calculation log by ID
 func (r *CalculationLogRepo) GetByID(ctx context.Context, id uuid.UUID) (*domain.CalculationLog, error) {
 	query := `
-		SELECT id, user_id, api_key_id, rule_id, strategy_type, 
-		       input_data, output_data, execution_time_ms, created_at
+		SELECT ` + calculationLogColumns + `
 		FROM calculation_logs
 		WHERE id = $1
 	`
 
-	log := &domain.CalculationLog{}
-	var inputData, outputData JSONB
-	var apiKeyID, ruleID sql.NullString
-
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&log.ID,
-		&log.UserID,
-		&apiKeyID,
-		&ruleID,
-		&log.StrategyType,
-		&inputData,
-		&outputData,
-		&log.ExecutionTimeMs,
-		&log.CreatedAt,
-	)
-
+	log, err := scanCalculationLog(r.db.QueryRowContext(ctx, query, id))
 	if err == sql.ErrNoRows {
 		return nil, fmt.Errorf("calculation log not found: %s", id)
 	}
@@ -96,33 +215,13 @@ func (r *CalculationLogRepo) GetByID(ctx context.Context, id uuid.UUID) (*domain
 		return nil, fmt.Errorf("failed to get calculation log: %w", err)
 	}
 
-	// Convert JSONB to maps
-	log.InputData = inputData.ToMap()
-	log.OutputData = outputData.ToMap()
-
-	// Handle nullable UUIDs
-	if apiKeyID.Valid {
-		keyID, err := uuid.Parse(apiKeyID.String)
-		if err == nil {
-			log.APIKeyID = &keyID
-		}
-	}
-
-	if ruleID.Valid {
-		rID, err := uuid.Parse(ruleID.String)
-		if err == nil {
-			log.RuleID = &rID
-		}
-	}
-
 	return log, nil
 }
 
 // GetByUserID retrieves calculation logs for a user with pagination
 func (r *CalculationLogRepo) GetByUserID(ctx context.Context, userID uuid.UUID, limit, offset int) ([]*domain.CalculationLog, error) {
 	query := `
-		SELECT id, user_id, api_key_id, rule_id, strategy_type, 
-		       input_data, output_data, execution_time_ms, created_at
+		SELECT ` + calculationLogColumns + `
 		FROM calculation_logs
 		WHERE user_id = $1
 		ORDER BY created_at DESC
@@ -138,42 +237,69 @@ func (r *CalculationLogRepo) GetByUserID(ctx context.Context, userID uuid.UUID,
 	var logs []*domain.CalculationLog
 
 	for rows.Next() {
-		log := &domain.CalculationLog{}
-		var inputData, outputData JSONB
-		var apiKeyID, ruleID sql.NullString
-
-		err := rows.Scan(
-			&log.ID,
-			&log.UserID,
-			&apiKeyID,
-			&ruleID,
-			&log.StrategyType,
-			&inputData,
-			&outputData,
-			&log.ExecutionTimeMs,
-			&log.CreatedAt,
-		)
+		log, err := scanCalculationLog(rows)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan calculation log: %w", err)
 		}
+		logs = append(logs, log)
+	}
 
-		log.InputData = inputData.ToMap()
-		log.OutputData = outputData.ToMap()
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating calculation logs: %w", err)
+	}
 
-		if apiKeyID.Valid {
-			keyID, err := uuid.Parse(apiKeyID.String)
-			if err == nil {
-				log.APIKeyID = &keyID
-			}
-		}
+	return logs, nil
+}
 
-		if ruleID.Valid {
-			rID, err := uuid.Parse(ruleID.String)
-			if err == nil {
-				log.RuleID = &rID
-			}
-		}
+// List retrieves calculation logs with filters
+func (r *CalculationLogRepo) List(ctx context.Context, filter domain.CalculationLogFilter) ([]*domain.CalculationLog, error) {
+	query := `
+		SELECT ` + calculationLogColumns + `
+		FROM calculation_logs
+		WHERE user_id = $1
+	`
 
+	where, args, err := calculationLogFilterClause(filter)
+	if err != nil {
+		return nil, err
+	}
+	query += where
+	argCount := len(args)
+
+	after, afterArgs := cursorCondition(filter.After, "", argCount)
+	query += after
+	args = append(args, afterArgs...)
+	argCount += len(afterArgs)
+
+	// Add ordering
+	query += " ORDER BY " + keysetOrder
+
+	// Add pagination
+	if filter.Limit > 0 {
+		argCount++
+		query += fmt.Sprintf(" LIMIT $%d", argCount)
+		args = append(args, filter.Limit)
+	}
+
+	if filter.Offset > 0 {
+		argCount++
+		query += fmt.Sprintf(" OFFSET $%d", argCount)
+		args = append(args, filter.Offset)
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list calculation logs: %w", err)
+	}
+	defer rows.Close()
+
+	var logs []*domain.CalculationLog
+
+	for rows.Next() {
+		log, err := scanCalculationLog(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan calculation log: %w", err)
+		}
 		logs = append(logs, log)
 	}
 
@@ -184,18 +310,172 @@ func (r *CalculationLogRepo) GetByUserID(ctx context.Context, userID uuid.UUID,
 	return logs, nil
 }
 
-// List retrieves calculation logs with filters
-func (r *CalculationLogRepo) List(ctx context.Context, filter domain.CalculationLogFilter) ([]*domain.CalculationLog, error) {
+// Count returns the number of calculation logs matching the filters
+func (r *CalculationLogRepo) Count(ctx context.Context, filter domain.CalculationLogFilter) (int, error) {
 	query := `
-		SELECT id, user_id, api_key_id, rule_id, strategy_type, 
-		       input_data, output_data, execution_time_ms, created_at
+		SELECT COUNT(*)
 		FROM calculation_logs
 		WHERE user_id = $1
 	`
 
+	where, args, err := calculationLogFilterClause(filter)
+	if err != nil {
+		return 0, err
+	}
+	query += where
+
+	var count int
+	if err := r.db.QueryRowContext(ctx, query, args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count calculation logs: %w", err)
+	}
+
+	return count, nil
+}
+
+// calculationLogExportFetchSize is the number of logs fetched from the export
+// cursor at a time
+const calculationLogExportFetchSize = 1000
+
+// Export calls fn with every calculation log matching the filters, oldest
+// first. The logs are fetched from a cursor in a read-only transaction, so
+// only one fetch is held in memory however many logs match.
+func (r *CalculationLogRepo) Export(ctx context.Context, filter domain.CalculationLogFilter, fn func(*domain.CalculationLog) error) error {
+	where, args, err := calculationLogFilterClause(filter)
+	if err != nil {
+		return err
+	}
+	query := `
+		DECLARE calculation_log_export NO SCROLL CURSOR FOR
+		SELECT ` + calculationLogColumns + `
+		FROM calculation_logs
+		WHERE user_id = $1` + where + `
+		ORDER BY created_at, id
+	`
+
+	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("failed to export calculation logs: %w", err)
+	}
+
+	for {
+		fetched, err := fetchCalculationLogs(ctx, tx, fn)
+		if err != nil {
+			return err
+		}
+		if fetched < calculationLogExportFetchSize {
+			break
+		}
+	}
+
+	// Committing closes the cursor
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// fetchCalculationLogs calls fn with the next logs of the export cursor and
+// returns how many were fetched
+func fetchCalculationLogs(ctx context.Context, tx *sql.Tx, fn func(*domain.CalculationLog) error) (int, error) {
+	query := fmt.Sprintf("FETCH %d FROM calculation_log_export", calculationLogExportFetchSize)
+	rows, err := tx.QueryContext(ctx, query)
+	if err != nil {
+		return 0, fmt.Errorf("failed to export calculation logs: %w", err)
+	}
+	defer rows.Close()
+
+	fetched := 0
+	for rows.Next() {
+		log, err := scanCalculationLog(rows)
+		if err != nil {
+			return fetched, fmt.Errorf("failed to scan calculation log: %w", err)
+		}
+		fetched++
+		if err := fn(log); err != nil {
+			return fetched, err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fetched, fmt.Errorf("error iterating calculation logs: %w", err)
+	}
+
+	return fetched, nil
+}
+
+// calculationLogColumns selects a full calculation log
+const calculationLogColumns = `id, user_id, api_key_id, rule_id, rule_revision_id, rule_revision, strategy_type, 
+		       input_data, output_data, execution_time_ms, request_id, client_ip, engine_version, error, created_at`
+
+// scanCalculationLog scans a row selected with calculationLogColumns
+func scanCalculationLog(row rowScanner) (*domain.CalculationLog, error) {
+	log := &domain.CalculationLog{}
+	var inputData, outputData JSONB
+	var apiKeyID, ruleID, revisionID uuid.NullUUID
+	var ruleRevision sql.NullInt64
+	var executionTime sql.NullFloat64
+	var requestID, clientIP, engineVersion, errorMessage sql.NullString
+
+	err := row.Scan(
+		&log.ID,
+		&log.UserID,
+		&apiKeyID,
+		&ruleID,
+		&revisionID,
+		&ruleRevision,
+		&log.StrategyType,
+		&inputData,
+		&outputData,
+		&executionTime,
+		&requestID,
+		&clientIP,
+		&engineVersion,
+		&errorMessage,
+		&log.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// Convert JSONB to maps
+	log.InputData = inputData.ToMap()
+	log.OutputData = outputData.ToMap()
+	log.RuleRevision = int(ruleRevision.Int64)
+	log.ExecutionTimeMs = executionTime.Float64
+	log.RequestID = requestID.String
+	log.ClientIP = clientIP.String
+	log.EngineVersion = engineVersion.String
+	log.Error = errorMessage.String
+
+	// Handle nullable UUIDs
+	if apiKeyID.Valid {
+		log.APIKeyID = &apiKeyID.UUID
+	}
+	if ruleID.Valid {
+		log.RuleID = &ruleID.UUID
+	}
+	if revisionID.Valid {
+		log.RuleRevisionID = &revisionID.UUID
+	}
+
+	return log, nil
+}
+
+// calculationLogFilterClause builds the AND conditions following "WHERE user_id = $1"
+func calculationLogFilterClause(filter domain.CalculationLogFilter) (string, []interface{}, error) {
+	query := ""
 	args := []interface{}{filter.UserID}
 	argCount := 1
 
+	if !filter.IncludeFailed {
+		query += " AND error IS NULL"
+	}
+
 	// Add API key filter
 	if filter.APIKeyID != nil {
 		argCount++
@@ -210,6 +490,13 @@ func (r *CalculationLogRepo) List(ctx context.Context, filter domain.Calculation
 		args = append(args, *filter.RuleID)
 	}
 
+	// Add rule revision filter
+	if filter.RevisionID != nil {
+		argCount++
+		query += fmt.Sprintf(" AND rule_revision_id = $%d", argCount)
+		args = append(args, *filter.RevisionID)
+	}
+
 	// Add strategy type filter
 	if filter.StrategyType != "" {
 		argCount++
@@ -230,75 +517,77 @@ func (r *CalculationLogRepo) List(ctx context.Context, filter domain.Calculation
 		args = append(args, filter.To)
 	}
 
-	// Add ordering
-	query += " ORDER BY created_at DESC"
-
-	// Add pagination
-	if filter.Limit > 0 {
-		argCount++
-		query += fmt.Sprintf(" LIMIT $%d", argCount)
-		args = append(args, filter.Limit)
-	}
-
-	if filter.Offset > 0 {
+	// Add input and output filters
+	for _, dataFilter := range filter.Data {
 		argCount++
-		query += fmt.Sprintf(" OFFSET $%d", argCount)
-		args = append(args, filter.Offset)
-	}
-
-	rows, err := r.db.QueryContext(ctx, query, args...)
-	if err != nil {
-		return nil, fmt.Errorf("failed to list calculation logs: %w", err)
+		condition, arg, err := logDataCondition(dataFilter, argCount)
+		if err != nil {
+			return "", nil, err
+		}
+		query += " AND " + condition
+		args = append(args, arg)
 	}
-	defer rows.Close()
-
-	var logs []*domain.CalculationLog
 
-	for rows.Next() {
-		log := &domain.CalculationLog{}
-		var inputData, outputData JSONB
-		var apiKeyID, ruleID sql.NullString
+	return query, args, nil
+}
 
-		err := rows.Scan(
-			&log.ID,
-			&log.UserID,
-			&apiKeyID,
-			&ruleID,
-			&log.StrategyType,
-			&inputData,
-			&outputData,
-			&log.ExecutionTimeMs,
-			&log.CreatedAt,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan calculation log: %w", err)
-		}
+// logDataRangeOperators are the JSON path comparisons of the range operators
+var logDataRangeOperators = map[string]string{
+	domain.LogFilterGt:  ">",
+	domain.LogFilterGte: ">=",
+	domain.LogFilterLt:  "<",
+	domain.LogFilterLte: "<=",
+}
 
-		log.InputData = inputData.ToMap()
-		log.OutputData = outputData.ToMap()
+// logDataCondition translates an input or output filter into a condition on
+// its parameter, using the operators the GIN indexes on input_data and
+// output_data serve: containment for eq and contains, JSON path existence
+// and predicates for the others
+func logDataCondition(filter domain.LogDataFilter, arg int) (string, interface{}, error) {
+	if err := filter.Validate(); err != nil {
+		return "", nil, err
+	}
 
-		if apiKeyID.Valid {
-			keyID, err := uuid.Parse(apiKeyID.String)
-			if err == nil {
-				log.APIKeyID = &keyID
+	switch filter.Op {
+	case domain.LogFilterEq, domain.LogFilterContains:
+		value := filter.Value
+		if _, ok := value.(map[string]interface{}); !ok && filter.Op == domain.LogFilterContains {
+			// Elements are contained in arrays as arrays
+			if _, ok := value.([]interface{}); !ok {
+				value = []interface{}{value}
 			}
 		}
-
-		if ruleID.Valid {
-			rID, err := uuid.Parse(ruleID.String)
-			if err == nil {
-				log.RuleID = &rID
-			}
+		for i := len(filter.Path) - 1; i >= 0; i-- {
+			value = map[string]interface{}{filter.Path[i]: value}
+		}
+		document, err := json.Marshal(value)
+		if err != nil {
+			return "", nil, fmt.Errorf("%w: %v", domain.ErrLogFilterInvalid, err)
 		}
+		return fmt.Sprintf("%s @> $%d::jsonb", filter.Column, arg), string(document), nil
 
-		logs = append(logs, log)
-	}
+	case domain.LogFilterExists:
+		return fmt.Sprintf("%s @? $%d::jsonpath", filter.Column, arg), logDataPath(filter.Path), nil
 
-	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating calculation logs: %w", err)
+	default:
+		predicate := fmt.Sprintf("%s %s %s",
+			logDataPath(filter.Path),
+			logDataRangeOperators[filter.Op],
+			strconv.FormatFloat(filter.Value.(float64), 'f', -1, 64),
+		)
+		return fmt.Sprintf("%s @@ $%d::jsonpath", filter.Column, arg), predicate, nil
 	}
+}
 
-	return logs, nil
+// logDataPath builds a JSON path following object keys, such as
+// $."context"."location"
+func logDataPath(keys []string) string {
+	path := "$"
+	for _, key := range keys {
+		quoted, _ := json.Marshal(key)
+		path += "." + string(quoted)
+	}
+	return path
 }
 
 // GetStats retrieves calculation statistics for a user
@@ -377,3 +666,122 @@ func (r *CalculationLogRepo) GetStats(ctx context.Context, userID uuid.UUID, fro
 
 	return stats, nil
 }
+
+// analyticsGroupExpressions maps each analytics grouping to the expression
+// grouped on
+var analyticsGroupExpressions = map[string]string{
+	domain.AnalyticsGroupStrategy:   "NULLIF(strategy_type, '')",
+	domain.AnalyticsGroupRule:       "rule_id::text",
+	domain.AnalyticsGroupAPIKey:     "api_key_id::text",
+	domain.AnalyticsGroupLocation:   "input_data->'context'->>'location'",
+	domain.AnalyticsGroupProductSKU: "input_data->>'product_sku'",
+}
+
+// Analytics aggregates an account's calculations per time bucket and group.
+// Prices are read from successful calculations only.
+func (r *CalculationLogRepo) Analytics(ctx context.Context, query domain.AnalyticsQuery) ([]*domain.AnalyticsPoint, error) {
+	sqlQuery, err := analyticsQuery(query)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.QueryContext(ctx, sqlQuery, query.UserID, query.From, query.To)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query analytics: %w", err)
+	}
+	defer rows.Close()
+
+	var points []*domain.AnalyticsPoint
+	for rows.Next() {
+		point := &domain.AnalyticsPoint{}
+		var group sql.NullString
+		var avgPrice, minPrice, maxPrice, p50Price, p95Price, avgExecutionTime sql.NullFloat64
+
+		err := rows.Scan(
+			&point.BucketStart,
+			&group,
+			&point.Count,
+			&point.Errors,
+			&avgPrice,
+			&minPrice,
+			&maxPrice,
+			&p50Price,
+			&p95Price,
+			&avgExecutionTime,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan analytics: %w", err)
+		}
+
+		if group.Valid {
+			point.Group = &group.String
+		}
+		point.AvgPrice = floatOrNil(avgPrice)
+		point.MinPrice = floatOrNil(minPrice)
+		point.MaxPrice = floatOrNil(maxPrice)
+		point.P50Price = floatOrNil(p50Price)
+		point.P95Price = floatOrNil(p95Price)
+		point.AvgExecutionTimeMs = avgExecutionTime.Float64
+		points = append(points, point)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating analytics: %w", err)
+	}
+
+	return points, nil
+}
+
+// analyticsQuery builds the aggregate query for an analytics query, taking
+// the user ID, from and to as $1 to $3
+func analyticsQuery(query domain.AnalyticsQuery) (string, error) {
+	switch query.Bucket {
+	case domain.AnalyticsBucketHour, domain.AnalyticsBucketDay, domain.AnalyticsBucketWeek:
+	default:
+		return "", fmt.Errorf("unsupported analytics bucket: %q", query.Bucket)
+	}
+
+	group := "NULL::text"
+	if query.GroupBy != "" {
+		expression, ok := analyticsGroupExpressions[query.GroupBy]
+		if !ok {
+			return "", fmt.Errorf("unsupported analytics grouping: %q", query.GroupBy)
+		}
+		group = expression
+	}
+
+	return fmt.Sprintf(`
+		SELECT
+			bucket,
+			grp,
+			COUNT(*),
+			COUNT(*) FILTER (WHERE failed),
+			AVG(price)::float8,
+			MIN(price)::float8,
+			MAX(price)::float8,
+			PERCENTILE_CONT(0.5) WITHIN GROUP (ORDER BY price),
+			PERCENTILE_CONT(0.95) WITHIN GROUP (ORDER BY price),
+			AVG(execution_time_ms)::float8
+		FROM (
+			SELECT
+				DATE_TRUNC('%s', created_at) AS bucket,
+				%s AS grp,
+				error IS NOT NULL AS failed,
+				CASE WHEN error IS NULL THEN (output_data->>'final_price')::numeric END AS price,
+				execution_time_ms
+			FROM calculation_logs
+			WHERE user_id = $1 AND created_at >= $2 AND created_at < $3
+		) logs
+		GROUP BY bucket, grp
+		ORDER BY bucket, grp NULLS FIRST
+	`, query.Bucket, group), nil
+}
+
+// floatOrNil converts a scanned nullable number to a pointer
+func floatOrNil(n sql.NullFloat64) *float64 {
+	if !n.Valid {
+		return nil
+	}
+	v := n.Float64
+	return &v
+}
@@ -0,0 +1,220 @@
+package repository
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/saintparish4/harmonia/internal/domain"
+)
+
+func TestCalculationLogRow(t *testing.T) {
+	entry := &domain.CalculationLog{
+		ID:              uuid.New(),
+		UserID:          uuid.New(),
+		StrategyType:    "cost_plus",
+		InputData:       map[string]interface{}{"base_price": 10.0},
+		OutputData:      map[string]interface{}{"final_price": 12.5},
+		ExecutionTimeMs: 0.25,
+		RequestID:       "req-1",
+	}
+
+	row, err := calculationLogRow(entry)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(row) != len(calculationLogInsertColumns) {
+		t.Fatalf("expected %d values, got %d", len(calculationLogInsertColumns), len(row))
+	}
+
+	values := make(map[string]interface{}, len(row))
+	for i, column := range calculationLogInsertColumns {
+		values[column] = row[i]
+	}
+
+	// COPY sends JSON as text; bytes would be written as bytea
+	if values["input_data"] != `{"base_price":10}` || values["output_data"] != `{"final_price":12.5}` {
+		t.Errorf("expected JSON text, got %#v and %#v", values["input_data"], values["output_data"])
+	}
+	if values["rule_revision"] != nil || values["client_ip"] != nil {
+		t.Errorf("expected unset values to be NULL, got %#v and %#v", values["rule_revision"], values["client_ip"])
+	}
+	if values["request_id"] != "req-1" {
+		t.Errorf("expected request ID req-1, got %#v", values["request_id"])
+	}
+}
+
+func TestAnalyticsQuery(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    domain.AnalyticsQuery
+		wantErr  bool
+		contains []string
+	}{
+		{
+			name:     "ungrouped by day",
+			query:    domain.AnalyticsQuery{Bucket: domain.AnalyticsBucketDay},
+			contains: []string{"DATE_TRUNC('day', created_at)", "NULL::text AS grp"},
+		},
+		{
+			name:     "by location per hour",
+			query:    domain.AnalyticsQuery{Bucket: domain.AnalyticsBucketHour, GroupBy: domain.AnalyticsGroupLocation},
+			contains: []string{"DATE_TRUNC('hour', created_at)", "input_data->'context'->>'location' AS grp"},
+		},
+		{
+			name:     "by rule per week",
+			query:    domain.AnalyticsQuery{Bucket: domain.AnalyticsBucketWeek, GroupBy: domain.AnalyticsGroupRule},
+			contains: []string{"DATE_TRUNC('week', created_at)", "rule_id::text AS grp"},
+		},
+		{
+			name:    "unknown bucket",
+			query:   domain.AnalyticsQuery{Bucket: "minute"},
+			wantErr: true,
+		},
+		{
+			name:    "unknown grouping",
+			query:   domain.AnalyticsQuery{Bucket: domain.AnalyticsBucketDay, GroupBy: "user_id); DROP TABLE users; --"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, err := analyticsQuery(tt.query)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected an error, got query %s", query)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for _, want := range tt.contains {
+				if !strings.Contains(query, want) {
+					t.Errorf("expected query to contain %q, got %s", want, query)
+				}
+			}
+		})
+	}
+}
+
+func TestCalculationLogFilterClauseFailed(t *testing.T) {
+	userID := uuid.New()
+
+	query, _, err := calculationLogFilterClause(domain.CalculationLogFilter{UserID: userID})
+	if err != nil {
+		t.Fatalf("calculationLogFilterClause failed: %v", err)
+	}
+	if !strings.Contains(query, "error IS NULL") {
+		t.Errorf("expected failed calculations to be left out, got %q", query)
+	}
+
+	query, _, err = calculationLogFilterClause(domain.CalculationLogFilter{UserID: userID, IncludeFailed: true})
+	if err != nil {
+		t.Fatalf("calculationLogFilterClause failed: %v", err)
+	}
+	if strings.Contains(query, "error") {
+		t.Errorf("expected failed calculations to be included, got %q", query)
+	}
+}
+
+func TestLogDataCondition(t *testing.T) {
+	tests := []struct {
+		name      string
+		filter    domain.LogDataFilter
+		condition string
+		arg       interface{}
+	}{
+		{
+			name:      "eq",
+			filter:    domain.LogDataFilter{Column: domain.LogColumnInputData, Path: []string{"context", "location"}, Op: domain.LogFilterEq, Value: "US-CA"},
+			condition: "input_data @> $3::jsonb",
+			arg:       `{"context":{"location":"US-CA"}}`,
+		},
+		{
+			name:      "contains element",
+			filter:    domain.LogDataFilter{Column: domain.LogColumnInputData, Path: []string{"tags"}, Op: domain.LogFilterContains, Value: "vip"},
+			condition: "input_data @> $3::jsonb",
+			arg:       `{"tags":["vip"]}`,
+		},
+		{
+			name:      "contains object",
+			filter:    domain.LogDataFilter{Column: domain.LogColumnOutputData, Path: []string{"metadata"}, Op: domain.LogFilterContains, Value: map[string]interface{}{"tier": "gold"}},
+			condition: "output_data @> $3::jsonb",
+			arg:       `{"metadata":{"tier":"gold"}}`,
+		},
+		{
+			name:      "exists",
+			filter:    domain.LogDataFilter{Column: domain.LogColumnInputData, Path: []string{"customer", "id"}, Op: domain.LogFilterExists},
+			condition: "input_data @? $3::jsonpath",
+			arg:       `$."customer"."id"`,
+		},
+		{
+			name:      "range",
+			filter:    domain.LogDataFilter{Column: domain.LogColumnOutputData, Path: []string{"final_price"}, Op: domain.LogFilterGt, Value: 500.0},
+			condition: "output_data @@ $3::jsonpath",
+			arg:       `$."final_price" > 500`,
+		},
+		{
+			name:      "quoted keys",
+			filter:    domain.LogDataFilter{Column: domain.LogColumnOutputData, Path: []string{`say "hi"`}, Op: domain.LogFilterLte, Value: 0.5},
+			condition: "output_data @@ $3::jsonpath",
+			arg:       `$."say \"hi\"" <= 0.5`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			condition, arg, err := logDataCondition(tt.filter, 3)
+			if err != nil {
+				t.Fatalf("logDataCondition failed: %v", err)
+			}
+			if condition != tt.condition {
+				t.Errorf("expected condition %q, got %q", tt.condition, condition)
+			}
+			if arg != tt.arg {
+				t.Errorf("expected argument %v, got %v", tt.arg, arg)
+			}
+		})
+	}
+}
+
+func TestLogDataConditionInvalid(t *testing.T) {
+	filters := []domain.LogDataFilter{
+		{Column: "strategy_type", Path: []string{"a"}, Op: domain.LogFilterExists},
+		{Column: domain.LogColumnInputData, Op: domain.LogFilterExists},
+		{Column: domain.LogColumnInputData, Path: []string{"a", ""}, Op: domain.LogFilterExists},
+		{Column: domain.LogColumnInputData, Path: []string{"a"}, Op: "like", Value: "x"},
+		{Column: domain.LogColumnInputData, Path: []string{"a"}, Op: domain.LogFilterGte, Value: "500"},
+		{Column: domain.LogColumnInputData, Path: []string{"a"}, Op: domain.LogFilterEq, Value: []interface{}{"x"}},
+	}
+
+	for _, filter := range filters {
+		if _, _, err := logDataCondition(filter, 2); !errors.Is(err, domain.ErrLogFilterInvalid) {
+			t.Errorf("expected ErrLogFilterInvalid for %+v, got %v", filter, err)
+		}
+	}
+}
+
+func TestCalculationLogFilterClauseData(t *testing.T) {
+	query, args, err := calculationLogFilterClause(domain.CalculationLogFilter{
+		UserID:       uuid.New(),
+		StrategyType: "cost_plus",
+		Data: []domain.LogDataFilter{
+			{Column: domain.LogColumnInputData, Path: []string{"region"}, Op: domain.LogFilterEq, Value: "US-CA"},
+			{Column: domain.LogColumnOutputData, Path: []string{"final_price"}, Op: domain.LogFilterGt, Value: 500.0},
+		},
+	})
+	if err != nil {
+		t.Fatalf("calculationLogFilterClause failed: %v", err)
+	}
+
+	if !strings.Contains(query, "AND input_data @> $3::jsonb AND output_data @@ $4::jsonpath") {
+		t.Errorf("expected the data filters after the strategy filter, got %q", query)
+	}
+	if len(args) != 4 {
+		t.Errorf("expected 4 arguments, got %d", len(args))
+	}
+}
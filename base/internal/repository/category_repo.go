@@ -0,0 +1,310 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/saintparish4/harmonia/internal/domain"
+)
+
+// CategoryRepo implements domain.CategoryRepository
+type CategoryRepo struct {
+	db *sql.DB
+}
+
+// NewCategoryRepository creates a new category repository
+func NewCategoryRepository(db *sql.DB) domain.CategoryRepository {
+	return &CategoryRepo{db: db}
+}
+
+// categoryColumns selects every category field, in scanCategory order
+const categoryColumns = `id, user_id, parent_id, name, description,
+		       default_rule_id, attributes, created_at, updated_at`
+
+// Create creates a new category
+func (r *CategoryRepo) Create(ctx context.Context, category *domain.Category) error {
+	query := `
+		INSERT INTO product_categories (
+			id, user_id, parent_id, name, description,
+			default_rule_id, attributes, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`
+
+	// Generate ID if not provided
+	if category.ID == uuid.Nil {
+		category.ID = uuid.New()
+	}
+
+	// Set timestamps
+	now := time.Now()
+	category.CreatedAt = now
+	category.UpdatedAt = now
+
+	_, err := r.db.ExecContext(
+		ctx,
+		query,
+		category.ID,
+		category.UserID,
+		category.ParentID,
+		category.Name,
+		nullableString(category.Description),
+		category.DefaultRuleID,
+		FromMap(category.Attributes),
+		category.CreatedAt,
+		category.UpdatedAt,
+	)
+
+	if isCategoryNameConflict(err) {
+		return fmt.Errorf("%w: %s", domain.ErrCategoryExists, category.Name)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create category: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID retrieves a category by ID
+func (r *CategoryRepo) GetByID(ctx context.Context, id uuid.UUID) (*domain.Category, error) {
+	query := `
+		SELECT ` + categoryColumns + `
+		FROM product_categories
+		WHERE id = $1
+	`
+
+	category, err := scanCategory(r.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("category not found: %s", id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get category: %w", err)
+	}
+
+	return category, nil
+}
+
+// ListByUser retrieves all of a user's categories, ordered by name
+func (r *CategoryRepo) ListByUser(ctx context.Context, userID uuid.UUID) ([]*domain.Category, error) {
+	query := `
+		SELECT ` + categoryColumns + `
+		FROM product_categories
+		WHERE user_id = $1
+		ORDER BY name, id
+	`
+
+	return queryCategories(ctx, r.db, query, userID)
+}
+
+// Update updates a category. Moves are checked against the current tree so
+// a category never ends up below itself.
+func (r *CategoryRepo) Update(ctx context.Context, category *domain.Category) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if category.ParentID != nil {
+		// Serialize moves within the account so concurrent moves cannot form a cycle
+		_, err := tx.ExecContext(ctx, "SELECT id FROM users WHERE id = $1 FOR UPDATE", category.UserID)
+		if err != nil {
+			return fmt.Errorf("failed to lock account: %w", err)
+		}
+
+		ancestors, err := queryAncestors(ctx, tx, *category.ParentID)
+		if err != nil {
+			return err
+		}
+		for _, ancestor := range ancestors {
+			if ancestor.ID == category.ID {
+				return domain.ErrCategoryCycle
+			}
+		}
+	}
+
+	query := `
+		UPDATE product_categories
+		SET parent_id = $1, name = $2, description = $3,
+		    default_rule_id = $4, attributes = $5, updated_at = $6
+		WHERE id = $7
+	`
+
+	category.UpdatedAt = time.Now()
+
+	result, err := tx.ExecContext(
+		ctx,
+		query,
+		category.ParentID,
+		category.Name,
+		nullableString(category.Description),
+		category.DefaultRuleID,
+		FromMap(category.Attributes),
+		category.UpdatedAt,
+		category.ID,
+	)
+
+	if isCategoryNameConflict(err) {
+		return fmt.Errorf("%w: %s", domain.ErrCategoryExists, category.Name)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update category: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("category not found: %s", category.ID)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit category: %w", err)
+	}
+
+	return nil
+}
+
+// Delete deletes an empty category. Inactive products in it lose their category.
+func (r *CategoryRepo) Delete(ctx context.Context, id uuid.UUID) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var inUse bool
+	err = tx.QueryRowContext(
+		ctx,
+		`SELECT EXISTS (SELECT 1 FROM product_categories WHERE parent_id = $1)
+		     OR EXISTS (SELECT 1 FROM products WHERE category_id = $1 AND is_active = true)`,
+		id,
+	).Scan(&inUse)
+	if err != nil {
+		return fmt.Errorf("failed to check category contents: %w", err)
+	}
+	if inUse {
+		return domain.ErrCategoryNotEmpty
+	}
+
+	result, err := tx.ExecContext(ctx, "DELETE FROM product_categories WHERE id = $1", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete category: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("category not found: %s", id)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit category deletion: %w", err)
+	}
+
+	return nil
+}
+
+// Ancestors retrieves a category followed by its ancestors, nearest first
+func (r *CategoryRepo) Ancestors(ctx context.Context, id uuid.UUID) ([]*domain.Category, error) {
+	ancestors, err := queryAncestors(ctx, r.db, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(ancestors) == 0 {
+		return nil, fmt.Errorf("category not found: %s", id)
+	}
+	return ancestors, nil
+}
+
+// queryAncestors walks parent links from a category up to the root
+func queryAncestors(ctx context.Context, q queryer, id uuid.UUID) ([]*domain.Category, error) {
+	query := `
+		WITH RECURSIVE chain AS (
+			SELECT ` + categoryColumns + `, 0 AS depth
+			FROM product_categories
+			WHERE id = $1
+			UNION ALL
+			SELECT c.id, c.user_id, c.parent_id, c.name, c.description,
+			       c.default_rule_id, c.attributes, c.created_at, c.updated_at, chain.depth + 1
+			FROM product_categories c
+			JOIN chain ON c.id = chain.parent_id
+		)
+		SELECT ` + categoryColumns + `
+		FROM chain
+		ORDER BY depth
+	`
+
+	return queryCategories(ctx, q, query, id)
+}
+
+// queryCategories runs a query selecting categoryColumns
+func queryCategories(ctx context.Context, q queryer, query string, args ...interface{}) ([]*domain.Category, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query categories: %w", err)
+	}
+	defer rows.Close()
+
+	var categories []*domain.Category
+
+	for rows.Next() {
+		category, err := scanCategory(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan category: %w", err)
+		}
+
+		categories = append(categories, category)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating categories: %w", err)
+	}
+
+	return categories, nil
+}
+
+// scanCategory scans a row selected with categoryColumns
+func scanCategory(row rowScanner) (*domain.Category, error) {
+	category := &domain.Category{}
+	var attributes JSONB
+	var description sql.NullString
+	var parentID, defaultRuleID uuid.NullUUID
+
+	err := row.Scan(
+		&category.ID,
+		&category.UserID,
+		&parentID,
+		&category.Name,
+		&description,
+		&defaultRuleID,
+		&attributes,
+		&category.CreatedAt,
+		&category.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	category.ParentID = uuidOrNil(parentID)
+	category.Description = description.String
+	category.DefaultRuleID = uuidOrNil(defaultRuleID)
+	category.Attributes = attributes.ToMap()
+
+	return category, nil
+}
+
+// isCategoryNameConflict reports whether err is a duplicate sibling name
+func isCategoryNameConflict(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Constraint == "idx_product_categories_name"
+}
@@ -0,0 +1,27 @@
+package repository
+
+import (
+	"fmt"
+
+	"github.com/saintparish4/harmonia/internal/domain"
+)
+
+// keysetOrder orders a listing for cursor pagination, newest first; id breaks
+// ties so every item has a distinct position
+const keysetOrder = "created_at DESC, id DESC"
+
+// cursorCondition continues a listing in keysetOrder after the cursor, with
+// its parameters numbered from argCount+1. table qualifies the columns and
+// may be empty.
+func cursorCondition(cursor *domain.Cursor, table string, argCount int) (string, []interface{}) {
+	if cursor == nil {
+		return "", nil
+	}
+
+	prefix := ""
+	if table != "" {
+		prefix = table + "."
+	}
+	condition := fmt.Sprintf(" AND (%screated_at, %sid) < ($%d, $%d)", prefix, prefix, argCount+1, argCount+2)
+	return condition, []interface{}{cursor.CreatedAt, cursor.ID}
+}
@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/saintparish4/harmonia/internal/domain"
+)
+
+func TestCursorCondition(t *testing.T) {
+	cursor := &domain.Cursor{
+		CreatedAt: time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC),
+		ID:        uuid.MustParse("11111111-1111-1111-1111-111111111111"),
+	}
+
+	tests := []struct {
+		name      string
+		cursor    *domain.Cursor
+		table     string
+		argCount  int
+		wantQuery string
+		wantArgs  []interface{}
+	}{
+		{
+			name:      "first page",
+			cursor:    nil,
+			argCount:  1,
+			wantQuery: "",
+			wantArgs:  nil,
+		},
+		{
+			name:      "unqualified columns",
+			cursor:    cursor,
+			argCount:  1,
+			wantQuery: " AND (created_at, id) < ($2, $3)",
+			wantArgs:  []interface{}{cursor.CreatedAt, cursor.ID},
+		},
+		{
+			name:      "qualified columns",
+			cursor:    cursor,
+			table:     "r",
+			argCount:  3,
+			wantQuery: " AND (r.created_at, r.id) < ($4, $5)",
+			wantArgs:  []interface{}{cursor.CreatedAt, cursor.ID},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, args := cursorCondition(tt.cursor, tt.table, tt.argCount)
+			if query != tt.wantQuery {
+				t.Errorf("expected query %q, got %q", tt.wantQuery, query)
+			}
+			if fmt.Sprint(args) != fmt.Sprint(tt.wantArgs) {
+				t.Errorf("expected args %v, got %v", tt.wantArgs, args)
+			}
+		})
+	}
+}
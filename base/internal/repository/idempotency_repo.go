@@ -0,0 +1,176 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/saintparish4/harmonia/internal/domain"
+)
+
+// IdempotencyRepo implements domain.IdempotencyRepository
+type IdempotencyRepo struct {
+	db *sql.DB
+}
+
+// NewIdempotencyRepository creates a new idempotency repository
+func NewIdempotencyRepository(db *sql.DB) domain.IdempotencyRepository {
+	return &IdempotencyRepo{db: db}
+}
+
+// Reserve stores an in-flight record unless the key is already held.
+// Concurrent reservations of one key are serialized by the primary key, so
+// exactly one of them succeeds.
+func (r *IdempotencyRepo) Reserve(ctx context.Context, record *domain.IdempotencyRecord, now, staleBefore time.Time) (*domain.IdempotencyRecord, bool, error) {
+	query := `
+		INSERT INTO idempotency_keys (user_id, idempotency_key, fingerprint, reservation_token, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (user_id, idempotency_key) DO UPDATE
+		SET fingerprint = EXCLUDED.fingerprint,
+			reservation_token = EXCLUDED.reservation_token,
+			response_status = NULL,
+			response_headers = '{}'::jsonb,
+			response_body = NULL,
+			created_at = EXCLUDED.created_at,
+			completed_at = NULL,
+			expires_at = EXCLUDED.expires_at
+		WHERE idempotency_keys.expires_at <= $7
+			OR (idempotency_keys.completed_at IS NULL AND idempotency_keys.created_at < $8)
+		RETURNING user_id
+	`
+
+	// The held record can be released between the insert and the read, so
+	// try again when it is gone
+	for i := 0; i < 3; i++ {
+		var userID uuid.UUID
+		err := r.db.QueryRowContext(
+			ctx,
+			query,
+			record.UserID,
+			record.Key,
+			record.Fingerprint,
+			record.Token,
+			record.CreatedAt,
+			record.ExpiresAt,
+			now,
+			staleBefore,
+		).Scan(&userID)
+		if err == nil {
+			return nil, true, nil
+		}
+		if err != sql.ErrNoRows {
+			return nil, false, fmt.Errorf("failed to reserve idempotency key: %w", err)
+		}
+
+		existing, err := r.get(ctx, record.UserID, record.Key)
+		if err == sql.ErrNoRows {
+			continue
+		}
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to get idempotency key: %w", err)
+		}
+		return existing, false, nil
+	}
+
+	return nil, false, fmt.Errorf("failed to reserve idempotency key: key %q keeps changing", record.Key)
+}
+
+// Complete stores the response of a reserved record. A request whose key was
+// taken over as stale no longer holds the token and stores nothing.
+func (r *IdempotencyRepo) Complete(ctx context.Context, record *domain.IdempotencyRecord) error {
+	headers, err := json.Marshal(record.Headers)
+	if err != nil {
+		return fmt.Errorf("failed to encode response headers: %w", err)
+	}
+
+	query := `
+		UPDATE idempotency_keys
+		SET response_status = $1, response_headers = $2, response_body = $3, completed_at = $4
+		WHERE user_id = $5 AND idempotency_key = $6 AND reservation_token = $7 AND completed_at IS NULL
+	`
+
+	_, err = r.db.ExecContext(
+		ctx,
+		query,
+		record.Status,
+		headers,
+		record.Body,
+		record.CompletedAt,
+		record.UserID,
+		record.Key,
+		record.Token,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to complete idempotency key: %w", err)
+	}
+
+	return nil
+}
+
+// Release deletes an in-flight record so the request can be retried, unless
+// the key was taken over by another reservation
+func (r *IdempotencyRepo) Release(ctx context.Context, record *domain.IdempotencyRecord) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		"DELETE FROM idempotency_keys WHERE user_id = $1 AND idempotency_key = $2 AND reservation_token = $3 AND completed_at IS NULL",
+		record.UserID,
+		record.Key,
+		record.Token,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to release idempotency key: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteExpired deletes records that expired before now
+func (r *IdempotencyRepo) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	result, err := r.db.ExecContext(ctx, "DELETE FROM idempotency_keys WHERE expires_at <= $1", now)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired idempotency keys: %w", err)
+	}
+
+	return result.RowsAffected()
+}
+
+// get retrieves a record by user and key
+func (r *IdempotencyRepo) get(ctx context.Context, userID uuid.UUID, key string) (*domain.IdempotencyRecord, error) {
+	query := `
+		SELECT user_id, idempotency_key, fingerprint, response_status, response_headers,
+			response_body, created_at, completed_at, expires_at
+		FROM idempotency_keys
+		WHERE user_id = $1 AND idempotency_key = $2
+	`
+
+	record := &domain.IdempotencyRecord{}
+	var status sql.NullInt64
+	var headers []byte
+	var completedAt sql.NullTime
+
+	err := r.db.QueryRowContext(ctx, query, userID, key).Scan(
+		&record.UserID,
+		&record.Key,
+		&record.Fingerprint,
+		&status,
+		&headers,
+		&record.Body,
+		&record.CreatedAt,
+		&completedAt,
+		&record.ExpiresAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(headers, &record.Headers); err != nil {
+		return nil, fmt.Errorf("failed to decode response headers: %w", err)
+	}
+	record.Status = int(status.Int64)
+	record.CompletedAt = timeOrNil(completedAt)
+
+	return record, nil
+}
@@ -0,0 +1,697 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/saintparish4/harmonia/internal/domain"
+)
+
+// priceBookClaimTimeout is how long a generation may hold a price book
+// before another one can take it over, e.g. after a crash
+const priceBookClaimTimeout = 15 * time.Minute
+
+// PriceBookRepo implements domain.PriceBookRepository
+type PriceBookRepo struct {
+	db *sql.DB
+}
+
+// NewPriceBookRepository creates a new price book repository
+func NewPriceBookRepository(db *sql.DB) domain.PriceBookRepository {
+	return &PriceBookRepo{db: db}
+}
+
+// priceBookColumns selects every price book field, in scanPriceBook order
+const priceBookColumns = `id, user_id, name, description, dimensions, quantity, context,
+		       auto_regenerate, definition_version, status, generation, generated_at, started_at,
+		       rule_revisions, entry_count, error_count, last_error, dependency_hash, created_at, updated_at`
+
+// priceBookEntryColumns selects every entry field, in scanPriceBookEntry order
+var priceBookEntryColumns = []string{
+	"price_book_id", "product_id", "sku", "location", "currency", "channel",
+	"final_price", "price_currency", "base_cost", "strategy_type",
+	"rule_id", "rule_revision_id", "rule_revision", "rule_source", "error", "generated_at",
+}
+
+// Create creates a new price book
+func (r *PriceBookRepo) Create(ctx context.Context, book *domain.PriceBook) error {
+	query := `
+		INSERT INTO price_books (
+			id, user_id, name, description, dimensions, quantity, context,
+			auto_regenerate, status, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+	`
+
+	// Generate ID if not provided
+	if book.ID == uuid.Nil {
+		book.ID = uuid.New()
+	}
+
+	dimensions, err := json.Marshal(book.Dimensions)
+	if err != nil {
+		return fmt.Errorf("failed to encode price book dimensions: %w", err)
+	}
+
+	// Set timestamps
+	now := time.Now()
+	book.CreatedAt = now
+	book.UpdatedAt = now
+	book.Version = 1
+	book.Status = domain.PriceBookPending
+
+	_, err = r.db.ExecContext(
+		ctx,
+		query,
+		book.ID,
+		book.UserID,
+		book.Name,
+		nullableString(book.Description),
+		dimensions,
+		book.Quantity,
+		FromMap(book.Context),
+		book.AutoRegenerate,
+		book.Status,
+		book.CreatedAt,
+		book.UpdatedAt,
+	)
+
+	if isPriceBookNameConflict(err) {
+		return fmt.Errorf("%w: %s", domain.ErrPriceBookExists, book.Name)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create price book: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID retrieves a price book by ID
+func (r *PriceBookRepo) GetByID(ctx context.Context, id uuid.UUID) (*domain.PriceBook, error) {
+	query := `
+		SELECT ` + priceBookColumns + `
+		FROM price_books
+		WHERE id = $1
+	`
+
+	book, err := scanPriceBook(r.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w: %s", domain.ErrPriceBookNotFound, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get price book: %w", err)
+	}
+
+	return book, nil
+}
+
+// ListByUser retrieves all of a user's price books, ordered by name
+func (r *PriceBookRepo) ListByUser(ctx context.Context, userID uuid.UUID) ([]*domain.PriceBook, error) {
+	query := `
+		SELECT ` + priceBookColumns + `
+		FROM price_books
+		WHERE user_id = $1
+		ORDER BY name
+	`
+
+	return r.queryPriceBooks(ctx, query, userID)
+}
+
+// ListAutoRegenerate retrieves every price book that regenerates on changes
+func (r *PriceBookRepo) ListAutoRegenerate(ctx context.Context) ([]*domain.PriceBook, error) {
+	query := `
+		SELECT ` + priceBookColumns + `
+		FROM price_books
+		WHERE auto_regenerate = true
+		ORDER BY generated_at NULLS FIRST
+	`
+
+	return r.queryPriceBooks(ctx, query)
+}
+
+// Update updates a price book's definition. The book becomes pending, and a
+// generation already running for the old definition leaves it pending.
+func (r *PriceBookRepo) Update(ctx context.Context, book *domain.PriceBook) error {
+	query := `
+		UPDATE price_books
+		SET name = $1, description = $2, dimensions = $3, quantity = $4, context = $5,
+		    auto_regenerate = $6, definition_version = definition_version + 1,
+		    status = CASE WHEN status = 'generating' THEN status ELSE 'pending' END
+		WHERE id = $7
+		RETURNING definition_version, status, updated_at
+	`
+
+	dimensions, err := json.Marshal(book.Dimensions)
+	if err != nil {
+		return fmt.Errorf("failed to encode price book dimensions: %w", err)
+	}
+
+	err = r.db.QueryRowContext(
+		ctx,
+		query,
+		book.Name,
+		nullableString(book.Description),
+		dimensions,
+		book.Quantity,
+		FromMap(book.Context),
+		book.AutoRegenerate,
+		book.ID,
+	).Scan(&book.Version, &book.Status, &book.UpdatedAt)
+
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("%w: %s", domain.ErrPriceBookNotFound, book.ID)
+	}
+	if isPriceBookNameConflict(err) {
+		return fmt.Errorf("%w: %s", domain.ErrPriceBookExists, book.Name)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update price book: %w", err)
+	}
+
+	return nil
+}
+
+// Delete deletes a price book and its entries
+func (r *PriceBookRepo) Delete(ctx context.Context, id uuid.UUID) error {
+	result, err := r.db.ExecContext(ctx, "DELETE FROM price_books WHERE id = $1", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete price book: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("%w: %s", domain.ErrPriceBookNotFound, id)
+	}
+
+	return nil
+}
+
+// Claim marks a price book as generating, taking over claims older than
+// priceBookClaimTimeout. The start time comes from the database clock, which
+// also stamps updated_at on the rows Changes compares it with.
+func (r *PriceBookRepo) Claim(ctx context.Context, id uuid.UUID) (time.Time, error) {
+	query := `
+		UPDATE price_books
+		SET status = 'generating', started_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND (status <> 'generating' OR started_at < CURRENT_TIMESTAMP - make_interval(secs => $2))
+		RETURNING started_at
+	`
+
+	var startedAt time.Time
+	err := r.db.QueryRowContext(ctx, query, id, priceBookClaimTimeout.Seconds()).Scan(&startedAt)
+	if err == sql.ErrNoRows {
+		if _, err := r.GetByID(ctx, id); err != nil {
+			return time.Time{}, err
+		}
+		return time.Time{}, domain.ErrPriceBookBusy
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to claim price book: %w", err)
+	}
+
+	return startedAt, nil
+}
+
+// Complete replaces the generated entries in one transaction and refreshes
+// the book's counts and rule revisions from the stored entries, queuing its
+// price_book.regenerated event
+func (r *PriceBookRepo) Complete(ctx context.Context, generation *domain.PriceBookGeneration) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if generation.Full {
+		_, err = tx.ExecContext(ctx, "DELETE FROM price_book_entries WHERE price_book_id = $1", generation.PriceBookID)
+	} else {
+		_, err = tx.ExecContext(
+			ctx,
+			"DELETE FROM price_book_entries WHERE price_book_id = $1 AND product_id = ANY($2::uuid[])",
+			generation.PriceBookID,
+			pq.Array(uuidStrings(generation.ProductIDs)),
+		)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to clear price book entries: %w", err)
+	}
+
+	if err := copyPriceBookEntries(ctx, tx, generation.Entries); err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE price_books
+		SET status = CASE WHEN definition_version = $2 THEN 'ready' ELSE 'pending' END,
+		    generation = generation + 1, generated_at = $3, dependency_hash = $4, last_error = NULL,
+		    entry_count = (SELECT COUNT(*) FROM price_book_entries WHERE price_book_id = $1),
+		    error_count = (SELECT COUNT(*) FROM price_book_entries WHERE price_book_id = $1 AND error IS NOT NULL),
+		    rule_revisions = COALESCE((
+		        SELECT jsonb_agg(jsonb_build_object(
+		            'rule_id', rule_id, 'revision_id', rule_revision_id, 'revision', rule_revision, 'entries', entries
+		        ) ORDER BY rule_id, rule_revision)
+		        FROM (
+		            SELECT rule_id, rule_revision_id, COALESCE(rule_revision, 0) AS rule_revision, COUNT(*) AS entries
+		            FROM price_book_entries
+		            WHERE price_book_id = $1 AND rule_id IS NOT NULL
+		            GROUP BY rule_id, rule_revision_id, rule_revision
+		        ) revisions
+		    ), '[]'::jsonb)
+		WHERE id = $1
+		RETURNING user_id, name, generation, generated_at, entry_count, error_count
+	`
+
+	book := &domain.PriceBook{ID: generation.PriceBookID}
+	var generatedAt time.Time
+	err = tx.QueryRowContext(ctx, query, generation.PriceBookID, generation.Version, generation.StartedAt, generation.DependencyHash).Scan(
+		&book.UserID,
+		&book.Name,
+		&book.Generation,
+		&generatedAt,
+		&book.EntryCount,
+		&book.ErrorCount,
+	)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("%w: %s", domain.ErrPriceBookNotFound, generation.PriceBookID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to complete price book: %w", err)
+	}
+	book.GeneratedAt = &generatedAt
+
+	if err := enqueueEvent(ctx, tx, domain.PriceBookRegeneratedEvent(book, generation.Full)); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit price book: %w", err)
+	}
+
+	return nil
+}
+
+// copyPriceBookEntries bulk loads entries with COPY, which a full generation
+// of a large catalog needs to finish in reasonable time
+func copyPriceBookEntries(ctx context.Context, tx *sql.Tx, entries []*domain.PriceBookEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	stmt, err := tx.PrepareContext(ctx, pq.CopyIn("price_book_entries", priceBookEntryColumns...))
+	if err != nil {
+		return fmt.Errorf("failed to prepare price book entries: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, entry := range entries {
+		_, err := stmt.ExecContext(
+			ctx,
+			entry.PriceBookID,
+			entry.ProductID,
+			entry.SKU,
+			entry.Location,
+			entry.Currency,
+			entry.Channel,
+			entry.FinalPrice,
+			nullableString(entry.PriceCurrency),
+			entry.BaseCost,
+			nullableString(entry.StrategyType),
+			entry.RuleID,
+			entry.RuleRevisionID,
+			nullableInt(entry.RuleRevision),
+			nullableString(entry.RuleSource),
+			nullableString(entry.Error),
+			entry.GeneratedAt,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to copy price book entry %s: %w", entry.SKU, err)
+		}
+	}
+
+	if _, err := stmt.ExecContext(ctx); err != nil {
+		return fmt.Errorf("failed to write price book entries: %w", err)
+	}
+
+	return nil
+}
+
+// Fail records why a generation failed; the previous entries stay in place
+func (r *PriceBookRepo) Fail(ctx context.Context, id uuid.UUID, message, dependencyHash string) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		"UPDATE price_books SET status = 'failed', last_error = $2, dependency_hash = $3 WHERE id = $1",
+		id,
+		message,
+		dependencyHash,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record price book failure: %w", err)
+	}
+	return nil
+}
+
+// DependencyHash fingerprints what rule resolution reads besides the product
+// itself. Deleted assignments and categories change the fingerprint even
+// though they leave no row behind.
+func (r *PriceBookRepo) DependencyHash(ctx context.Context, userID uuid.UUID) (string, error) {
+	query := `
+		SELECT md5(concat_ws('|',
+			COALESCE((SELECT string_agg(id::text || '@' || updated_at::text, ',' ORDER BY id)
+			          FROM pricing_rules WHERE user_id = $1), ''),
+			COALESCE((SELECT string_agg(a.id::text, ',' ORDER BY a.id)
+			          FROM product_rule_assignments a JOIN products p ON p.id = a.product_id
+			          WHERE p.user_id = $1), ''),
+			COALESCE((SELECT string_agg(id::text || '@' || updated_at::text, ',' ORDER BY id)
+			          FROM product_categories WHERE user_id = $1), ''),
+			COALESCE((SELECT default_rule_id::text FROM users WHERE id = $1), '')
+		))
+	`
+
+	var hash string
+	if err := r.db.QueryRowContext(ctx, query, userID).Scan(&hash); err != nil {
+		return "", fmt.Errorf("failed to fingerprint pricing rules: %w", err)
+	}
+	return hash, nil
+}
+
+// Changes reports what changed since the given time. Rule and assignment
+// windows that opened or closed before now need a full regeneration; product
+// edits and costs that took effect only need their products repriced.
+func (r *PriceBookRepo) Changes(ctx context.Context, userID uuid.UUID, since, now time.Time) (*domain.PriceBookChanges, error) {
+	windowQuery := `
+		SELECT EXISTS (
+			SELECT 1 FROM pricing_rules
+			WHERE user_id = $1 AND deleted_at IS NULL
+			  AND ((effective_from > $2 AND effective_from <= $3) OR (effective_to > $2 AND effective_to <= $3))
+		) OR EXISTS (
+			SELECT 1 FROM product_rule_assignments a JOIN products p ON p.id = a.product_id
+			WHERE p.user_id = $1
+			  AND ((a.effective_from > $2 AND a.effective_from <= $3) OR (a.effective_to > $2 AND a.effective_to <= $3))
+		)
+	`
+
+	changes := &domain.PriceBookChanges{}
+	if err := r.db.QueryRowContext(ctx, windowQuery, userID, since, now).Scan(&changes.Full); err != nil {
+		return nil, fmt.Errorf("failed to check rule windows: %w", err)
+	}
+	if changes.Full {
+		return changes, nil
+	}
+
+	productQuery := `
+		SELECT id FROM products
+		WHERE user_id = $1 AND updated_at > $2
+		UNION
+		SELECT c.product_id FROM product_costs c JOIN products p ON p.id = c.product_id
+		WHERE p.user_id = $1
+		  AND (c.created_at > $2 OR (c.effective_from > $2 AND c.effective_from <= $3))
+	`
+
+	rows, err := r.db.QueryContext(ctx, productQuery, userID, since, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query changed products: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan changed product: %w", err)
+		}
+		changes.ProductIDs = append(changes.ProductIDs, id)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating changed products: %w", err)
+	}
+
+	return changes, nil
+}
+
+// ListEntries retrieves entries in primary key order
+func (r *PriceBookRepo) ListEntries(ctx context.Context, filter domain.PriceBookEntryFilter) ([]*domain.PriceBookEntry, error) {
+	where, args := priceBookEntryFilterClause(filter)
+	query := `
+		SELECT ` + strings.Join(priceBookEntryColumns, ", ") + `
+		FROM price_book_entries
+		WHERE ` + where + `
+		ORDER BY sku, location, currency, channel
+	`
+
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+	if filter.Offset > 0 {
+		args = append(args, filter.Offset)
+		query += fmt.Sprintf(" OFFSET $%d", len(args))
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list price book entries: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []*domain.PriceBookEntry
+
+	for rows.Next() {
+		entry, err := scanPriceBookEntry(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan price book entry: %w", err)
+		}
+
+		entries = append(entries, entry)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating price book entries: %w", err)
+	}
+
+	return entries, nil
+}
+
+// CountEntries returns the number of entries matching the filter
+func (r *PriceBookRepo) CountEntries(ctx context.Context, filter domain.PriceBookEntryFilter) (int, error) {
+	where, args := priceBookEntryFilterClause(filter)
+	query := "SELECT COUNT(*) FROM price_book_entries WHERE " + where
+
+	var count int
+	if err := r.db.QueryRowContext(ctx, query, args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count price book entries: %w", err)
+	}
+	return count, nil
+}
+
+// EachEntry streams every entry of a price book from a single query, so an
+// export never mixes two generations
+func (r *PriceBookRepo) EachEntry(ctx context.Context, priceBookID uuid.UUID, fn func(*domain.PriceBookEntry) error) error {
+	query := `
+		SELECT ` + strings.Join(priceBookEntryColumns, ", ") + `
+		FROM price_book_entries
+		WHERE price_book_id = $1
+		ORDER BY sku, location, currency, channel
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, priceBookID)
+	if err != nil {
+		return fmt.Errorf("failed to read price book entries: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		entry, err := scanPriceBookEntry(rows)
+		if err != nil {
+			return fmt.Errorf("failed to scan price book entry: %w", err)
+		}
+		if err := fn(entry); err != nil {
+			return err
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("error iterating price book entries: %w", err)
+	}
+
+	return nil
+}
+
+// priceBookEntryFilterClause builds the WHERE clause shared by ListEntries and CountEntries
+func priceBookEntryFilterClause(filter domain.PriceBookEntryFilter) (string, []interface{}) {
+	conditions := []string{"price_book_id = $1"}
+	args := []interface{}{filter.PriceBookID}
+
+	add := func(column string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf("%s = $%d", column, len(args)))
+	}
+
+	if filter.SKU != "" {
+		add("sku", filter.SKU)
+	}
+	if filter.Location != nil {
+		add("location", *filter.Location)
+	}
+	if filter.Currency != nil {
+		add("currency", *filter.Currency)
+	}
+	if filter.Channel != nil {
+		add("channel", *filter.Channel)
+	}
+
+	return strings.Join(conditions, " AND "), args
+}
+
+// queryPriceBooks runs a query selecting priceBookColumns
+func (r *PriceBookRepo) queryPriceBooks(ctx context.Context, query string, args ...interface{}) ([]*domain.PriceBook, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query price books: %w", err)
+	}
+	defer rows.Close()
+
+	var books []*domain.PriceBook
+
+	for rows.Next() {
+		book, err := scanPriceBook(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan price book: %w", err)
+		}
+
+		books = append(books, book)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating price books: %w", err)
+	}
+
+	return books, nil
+}
+
+// scanPriceBook scans a row selected with priceBookColumns
+func scanPriceBook(row rowScanner) (*domain.PriceBook, error) {
+	book := &domain.PriceBook{}
+	var inputs JSONB
+	var dimensions, revisions []byte
+	var description, lastError, dependencyHash sql.NullString
+	var generatedAt, startedAt sql.NullTime
+
+	err := row.Scan(
+		&book.ID,
+		&book.UserID,
+		&book.Name,
+		&description,
+		&dimensions,
+		&book.Quantity,
+		&inputs,
+		&book.AutoRegenerate,
+		&book.Version,
+		&book.Status,
+		&book.Generation,
+		&generatedAt,
+		&startedAt,
+		&revisions,
+		&book.EntryCount,
+		&book.ErrorCount,
+		&lastError,
+		&dependencyHash,
+		&book.CreatedAt,
+		&book.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(dimensions, &book.Dimensions); err != nil {
+		return nil, fmt.Errorf("failed to decode price book dimensions: %w", err)
+	}
+	if err := json.Unmarshal(revisions, &book.RuleRevisions); err != nil {
+		return nil, fmt.Errorf("failed to decode price book revisions: %w", err)
+	}
+
+	book.Description = description.String
+	book.Context = inputs.ToMap()
+	book.GeneratedAt = timeOrNil(generatedAt)
+	book.StartedAt = timeOrNil(startedAt)
+	book.LastError = lastError.String
+	book.DependencyHash = dependencyHash.String
+
+	return book, nil
+}
+
+// scanPriceBookEntry scans a row selected with priceBookEntryColumns
+func scanPriceBookEntry(row rowScanner) (*domain.PriceBookEntry, error) {
+	entry := &domain.PriceBookEntry{}
+	var finalPrice sql.NullFloat64
+	var priceCurrency, strategyType, ruleSource, entryError sql.NullString
+	var ruleID, revisionID uuid.NullUUID
+	var revision sql.NullInt64
+
+	err := row.Scan(
+		&entry.PriceBookID,
+		&entry.ProductID,
+		&entry.SKU,
+		&entry.Location,
+		&entry.Currency,
+		&entry.Channel,
+		&finalPrice,
+		&priceCurrency,
+		&entry.BaseCost,
+		&strategyType,
+		&ruleID,
+		&revisionID,
+		&revision,
+		&ruleSource,
+		&entryError,
+		&entry.GeneratedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if finalPrice.Valid {
+		entry.FinalPrice = &finalPrice.Float64
+	}
+	entry.PriceCurrency = priceCurrency.String
+	entry.StrategyType = strategyType.String
+	entry.RuleID = uuidOrNil(ruleID)
+	entry.RuleRevisionID = uuidOrNil(revisionID)
+	entry.RuleRevision = int(revision.Int64)
+	entry.RuleSource = ruleSource.String
+	entry.Error = entryError.String
+
+	return entry, nil
+}
+
+// nullableInt converts zero to NULL
+func nullableInt(n int) interface{} {
+	if n == 0 {
+		return nil
+	}
+	return n
+}
+
+// uuidStrings formats IDs for a uuid[] parameter
+func uuidStrings(ids []uuid.UUID) []string {
+	out := make([]string, len(ids))
+	for i, id := range ids {
+		out[i] = id.String()
+	}
+	return out
+}
+
+// isPriceBookNameConflict reports whether err is a duplicate price book name
+func isPriceBookNameConflict(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Constraint == "uq_price_books_name"
+}
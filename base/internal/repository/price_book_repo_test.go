@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/saintparish4/harmonia/internal/domain"
+)
+
+func TestPriceBookEntryFilterClause(t *testing.T) {
+	bookID := uuid.New()
+	location := "US"
+	channel := ""
+
+	tests := []struct {
+		name      string
+		filter    domain.PriceBookEntryFilter
+		wantQuery string
+		wantArgs  []interface{}
+	}{
+		{
+			name:      "book only",
+			filter:    domain.PriceBookEntryFilter{PriceBookID: bookID},
+			wantQuery: "price_book_id = $1",
+			wantArgs:  []interface{}{bookID},
+		},
+		{
+			name:      "sku and location",
+			filter:    domain.PriceBookEntryFilter{PriceBookID: bookID, SKU: "WIDGET-1", Location: &location},
+			wantQuery: "price_book_id = $1 AND sku = $2 AND location = $3",
+			wantArgs:  []interface{}{bookID, "WIDGET-1", "US"},
+		},
+		{
+			name:      "empty value selects entries without the dimension",
+			filter:    domain.PriceBookEntryFilter{PriceBookID: bookID, Channel: &channel},
+			wantQuery: "price_book_id = $1 AND channel = $2",
+			wantArgs:  []interface{}{bookID, ""},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, args := priceBookEntryFilterClause(tt.filter)
+			if query != tt.wantQuery {
+				t.Errorf("expected query %q, got %q", tt.wantQuery, query)
+			}
+			if fmt.Sprint(args) != fmt.Sprint(tt.wantArgs) {
+				t.Errorf("expected args %v, got %v", tt.wantArgs, args)
+			}
+		})
+	}
+}
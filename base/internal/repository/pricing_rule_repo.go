@@ -3,10 +3,12 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"github.com/saintparish4/harmonia/internal/domain"
 )
 
@@ -20,12 +22,34 @@ func NewPricingRuleRepository(db *sql.DB) domain.PricingRuleRepository {
 	return &PricingRuleRepo{db: db}
 }
 
-// Create creates a new pricing rule
+// Create creates a new pricing rule. A rule with PublishedAt set is recorded
+// as revision 1; otherwise it stays unpublished at revision 0 until a draft is published.
 func (r *PricingRuleRepo) Create(ctx context.Context, rule *domain.PricingRule) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := insertPricingRule(ctx, tx, rule); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit pricing rule: %w", err)
+	}
+
+	return nil
+}
+
+// insertPricingRule inserts a rule, recording revision 1 if it is created
+// published, and queues its rule.created event
+func insertPricingRule(ctx context.Context, tx *sql.Tx, rule *domain.PricingRule) error {
 	query := `
 		INSERT INTO pricing_rules (
-			id, user_id, name, description, strategy_type, config, is_active, created_at, updated_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+			id, user_id, name, description, strategy_type, config, is_active, created_at, updated_at, current_revision,
+			effective_from, effective_to, published_at, external_key
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
 	`
 
 	// Generate ID if not provided
@@ -38,10 +62,16 @@ func (r *PricingRuleRepo) Create(ctx context.Context, rule *domain.PricingRule)
 	rule.CreatedAt = now
 	rule.UpdatedAt = now
 
+	rule.Revision = 0
+	if rule.PublishedAt != nil {
+		rule.Revision = 1
+		rule.PublishedAt = &now
+	}
+
 	// Convert config to JSONB
 	config := FromMap(rule.Config)
 
-	_, err := r.db.ExecContext(
+	_, err := tx.ExecContext(
 		ctx,
 		query,
 		rule.ID,
@@ -53,41 +83,40 @@ func (r *PricingRuleRepo) Create(ctx context.Context, rule *domain.PricingRule)
 		rule.IsActive,
 		rule.CreatedAt,
 		rule.UpdatedAt,
+		rule.Revision,
+		nullableTime(rule.EffectiveFrom),
+		nullableTime(rule.EffectiveTo),
+		nullableTime(rule.PublishedAt),
+		nullableString(rule.ExternalKey),
 	)
 
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Constraint == "idx_pricing_rules_external_key" {
+		return fmt.Errorf("%w: %s", domain.ErrDuplicateExternalKey, rule.ExternalKey)
+	}
 	if err != nil {
 		return fmt.Errorf("failed to create pricing rule: %w", err)
 	}
 
-	return nil
+	if rule.PublishedAt != nil {
+		if err := insertRuleRevision(ctx, tx, rule); err != nil {
+			return err
+		}
+	}
+
+	return enqueueEvent(ctx, tx, domain.RuleEvent(domain.EventRuleCreated, rule))
 }
 
 // GetByID retrieves a pricing rule by ID
 func (r *PricingRuleRepo) GetByID(ctx context.Context, id uuid.UUID) (*domain.PricingRule, error) {
 	query := `
-		SELECT id, user_id, name, description, strategy_type, config, 
-		       is_active, created_at, updated_at, deleted_at
-		FROM pricing_rules
-		WHERE id = $1 AND deleted_at IS NULL
+		SELECT ` + pricingRuleColumns + `
+		FROM pricing_rules r
+		` + currentRevisionJoin + `
+		WHERE r.id = $1 AND r.deleted_at IS NULL
 	`
 
-	rule := &domain.PricingRule{}
-	var config JSONB
-	var deletedAt sql.NullTime
-
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&rule.ID,
-		&rule.UserID,
-		&rule.Name,
-		&rule.Description,
-		&rule.StrategyType,
-		&config,
-		&rule.IsActive,
-		&rule.CreatedAt,
-		&rule.UpdatedAt,
-		&deletedAt,
-	)
-
+	rule, err := scanPricingRule(r.db.QueryRowContext(ctx, query, id))
 	if err == sql.ErrNoRows {
 		return nil, fmt.Errorf("pricing rule not found: %s", id)
 	}
@@ -95,24 +124,17 @@ func (r *PricingRuleRepo) GetByID(ctx context.Context, id uuid.UUID) (*domain.Pr
 		return nil, fmt.Errorf("failed to get pricing rule: %w", err)
 	}
 
-	// Convert JSONB to map
-	rule.Config = config.ToMap()
-
-	if deletedAt.Valid {
-		rule.DeletedAt = &deletedAt.Time
-	}
-
 	return rule, nil
 }
 
 // GetByUserID retrieves all active pricing rules for a user
 func (r *PricingRuleRepo) GetByUserID(ctx context.Context, userID uuid.UUID) ([]*domain.PricingRule, error) {
 	query := `
-		SELECT id, user_id, name, description, strategy_type, config, 
-		       is_active, created_at, updated_at
-		FROM pricing_rules
-		WHERE user_id = $1 AND is_active = true AND deleted_at IS NULL
-		ORDER BY created_at DESC
+		SELECT ` + pricingRuleColumns + `
+		FROM pricing_rules r
+		` + currentRevisionJoin + `
+		WHERE r.user_id = $1 AND r.is_active = true AND r.deleted_at IS NULL
+		ORDER BY r.created_at DESC
 	`
 
 	rows, err := r.db.QueryContext(ctx, query, userID)
@@ -124,25 +146,10 @@ func (r *PricingRuleRepo) GetByUserID(ctx context.Context, userID uuid.UUID) ([]
 	var rules []*domain.PricingRule
 
 	for rows.Next() {
-		rule := &domain.PricingRule{}
-		var config JSONB
-
-		err := rows.Scan(
-			&rule.ID,
-			&rule.UserID,
-			&rule.Name,
-			&rule.Description,
-			&rule.StrategyType,
-			&config,
-			&rule.IsActive,
-			&rule.CreatedAt,
-			&rule.UpdatedAt,
-		)
+		rule, err := scanPricingRule(rows)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan pricing rule: %w", err)
 		}
-
-		rule.Config = config.ToMap()
 		rules = append(rules, rule)
 	}
 
@@ -153,67 +160,61 @@ func (r *PricingRuleRepo) GetByUserID(ctx context.Context, userID uuid.UUID) ([]
 	return rules, nil
 }
 
-// Update updates an existing pricing rule
+// Update publishes new content for an existing pricing rule, recording the change as a new revision
 func (r *PricingRuleRepo) Update(ctx context.Context, rule *domain.PricingRule) error {
-	query := `
-		UPDATE pricing_rules
-		SET name = $1, description = $2, strategy_type = $3, config = $4, 
-		    is_active = $5, updated_at = $6
-		WHERE id = $7 AND deleted_at IS NULL
-	`
-
-	rule.UpdatedAt = time.Now()
-	config := FromMap(rule.Config)
-
-	result, err := r.db.ExecContext(
-		ctx,
-		query,
-		rule.Name,
-		rule.Description,
-		rule.StrategyType,
-		config,
-		rule.IsActive,
-		rule.UpdatedAt,
-		rule.ID,
-	)
-
+	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
-		return fmt.Errorf("failed to update pricing rule: %w", err)
+		return fmt.Errorf("failed to start transaction: %w", err)
 	}
+	defer tx.Rollback()
 
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
+	if err := publishRule(ctx, tx, rule); err != nil {
+		return err
 	}
 
-	if rowsAffected == 0 {
-		return fmt.Errorf("pricing rule not found: %s", rule.ID)
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit pricing rule update: %w", err)
 	}
 
 	return nil
 }
 
-// Delete soft deletes a pricing rule
+// Delete soft deletes a pricing rule and queues its rule.deleted event
 func (r *PricingRuleRepo) Delete(ctx context.Context, id uuid.UUID) error {
 	query := `
 		UPDATE pricing_rules
 		SET deleted_at = $1, updated_at = $1
 		WHERE id = $2 AND deleted_at IS NULL
+		RETURNING user_id, name, strategy_type, current_revision, is_active
 	`
 
-	now := time.Now()
-	result, err := r.db.ExecContext(ctx, query, now, id)
+	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
-		return fmt.Errorf("failed to delete pricing rule: %w", err)
+		return fmt.Errorf("failed to start transaction: %w", err)
 	}
+	defer tx.Rollback()
 
-	rowsAffected, err := result.RowsAffected()
+	rule := &domain.PricingRule{ID: id}
+	err = tx.QueryRowContext(ctx, query, time.Now(), id).Scan(
+		&rule.UserID,
+		&rule.Name,
+		&rule.StrategyType,
+		&rule.Revision,
+		&rule.IsActive,
+	)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("pricing rule not found: %s", id)
+	}
 	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
+		return fmt.Errorf("failed to delete pricing rule: %w", err)
 	}
 
-	if rowsAffected == 0 {
-		return fmt.Errorf("pricing rule not found: %s", id)
+	if err := enqueueEvent(ctx, tx, domain.RuleEvent(domain.EventRuleDeleted, rule)); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit pricing rule deletion: %w", err)
 	}
 
 	return nil
@@ -222,31 +223,23 @@ func (r *PricingRuleRepo) Delete(ctx context.Context, id uuid.UUID) error {
 // List retrieves pricing rules with optional filters
 func (r *PricingRuleRepo) List(ctx context.Context, filter domain.PricingRuleFilter) ([]*domain.PricingRule, error) {
 	query := `
-		SELECT id, user_id, name, description, strategy_type, config, 
-		       is_active, created_at, updated_at
-		FROM pricing_rules
-		WHERE user_id = $1 AND deleted_at IS NULL
+		SELECT ` + pricingRuleColumns + `
+		FROM pricing_rules r
+		` + currentRevisionJoin + `
+		WHERE r.user_id = $1 AND r.deleted_at IS NULL
 	`
 
-	args := []interface{}{filter.UserID}
-	argCount := 1
+	where, args := pricingRuleFilterClause(filter)
+	query += where
+	argCount := len(args)
 
-	// Add strategy type filter if provided
-	if filter.StrategyType != "" {
-		argCount++
-		query += fmt.Sprintf(" AND strategy_type = $%d", argCount)
-		args = append(args, filter.StrategyType)
-	}
-
-	// Add is_active filter if provided
-	if filter.IsActive != nil {
-		argCount++
-		query += fmt.Sprintf(" AND is_active = $%d", argCount)
-		args = append(args, *filter.IsActive)
-	}
+	after, afterArgs := cursorCondition(filter.After, "r", argCount)
+	query += after
+	args = append(args, afterArgs...)
+	argCount += len(afterArgs)
 
 	// Add ordering
-	query += " ORDER BY created_at DESC"
+	query += " ORDER BY r.created_at DESC, r.id DESC"
 
 	// Add pagination
 	if filter.Limit > 0 {
@@ -270,25 +263,10 @@ func (r *PricingRuleRepo) List(ctx context.Context, filter domain.PricingRuleFil
 	var rules []*domain.PricingRule
 
 	for rows.Next() {
-		rule := &domain.PricingRule{}
-		var config JSONB
-
-		err := rows.Scan(
-			&rule.ID,
-			&rule.UserID,
-			&rule.Name,
-			&rule.Description,
-			&rule.StrategyType,
-			&config,
-			&rule.IsActive,
-			&rule.CreatedAt,
-			&rule.UpdatedAt,
-		)
+		rule, err := scanPricingRule(rows)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan pricing rule: %w", err)
 		}
-
-		rule.Config = config.ToMap()
 		rules = append(rules, rule)
 	}
 
@@ -298,3 +276,637 @@ func (r *PricingRuleRepo) List(ctx context.Context, filter domain.PricingRuleFil
 
 	return rules, nil
 }
+
+// Count returns the number of pricing rules matching the filters
+func (r *PricingRuleRepo) Count(ctx context.Context, filter domain.PricingRuleFilter) (int, error) {
+	query := `
+		SELECT COUNT(*)
+		FROM pricing_rules r
+		WHERE r.user_id = $1 AND r.deleted_at IS NULL
+	`
+
+	where, args := pricingRuleFilterClause(filter)
+	query += where
+
+	var count int
+	if err := r.db.QueryRowContext(ctx, query, args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count pricing rules: %w", err)
+	}
+
+	return count, nil
+}
+
+// pricingRuleScheduleConditions select rules by the status of their
+// effective window now, matching EffectiveWindow.Status
+var pricingRuleScheduleConditions = map[string]string{
+	domain.ScheduleStatusActive: " AND (r.effective_from IS NULL OR r.effective_from <= NOW())" +
+		" AND (r.effective_to IS NULL OR r.effective_to > NOW())",
+	domain.ScheduleStatusUpcoming: " AND r.effective_from > NOW()",
+	domain.ScheduleStatusExpired: " AND (r.effective_from IS NULL OR r.effective_from <= NOW())" +
+		" AND r.effective_to <= NOW()",
+}
+
+// pricingRuleFilterClause builds the AND conditions following
+// "WHERE r.user_id = $1 AND r.deleted_at IS NULL"
+func pricingRuleFilterClause(filter domain.PricingRuleFilter) (string, []interface{}) {
+	query := ""
+	args := []interface{}{filter.UserID}
+	argCount := 1
+
+	// Add strategy type filter if provided
+	if filter.StrategyType != "" {
+		argCount++
+		query += fmt.Sprintf(" AND r.strategy_type = $%d", argCount)
+		args = append(args, filter.StrategyType)
+	}
+
+	// Add is_active filter if provided
+	if filter.IsActive != nil {
+		argCount++
+		query += fmt.Sprintf(" AND r.is_active = $%d", argCount)
+		args = append(args, *filter.IsActive)
+	}
+
+	// Add schedule filter if provided
+	query += pricingRuleScheduleConditions[filter.Schedule]
+
+	return query, args
+}
+
+// ListRevisions retrieves every revision of a rule, newest first
+func (r *PricingRuleRepo) ListRevisions(ctx context.Context, ruleID uuid.UUID) ([]*domain.PricingRuleRevision, error) {
+	query := `
+		SELECT ` + ruleRevisionColumns + `
+		FROM pricing_rule_revisions
+		WHERE rule_id = $1
+		ORDER BY revision DESC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, ruleID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query rule revisions: %w", err)
+	}
+	defer rows.Close()
+
+	var revisions []*domain.PricingRuleRevision
+
+	for rows.Next() {
+		revision, err := scanRuleRevision(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan rule revision: %w", err)
+		}
+		revisions = append(revisions, revision)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rule revisions: %w", err)
+	}
+
+	return revisions, nil
+}
+
+// GetRevision retrieves a single revision of a rule
+func (r *PricingRuleRepo) GetRevision(ctx context.Context, ruleID uuid.UUID, revision int) (*domain.PricingRuleRevision, error) {
+	query := `
+		SELECT ` + ruleRevisionColumns + `
+		FROM pricing_rule_revisions
+		WHERE rule_id = $1 AND revision = $2
+	`
+
+	rev, err := scanRuleRevision(r.db.QueryRowContext(ctx, query, ruleID, revision))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("rule revision not found: %s@%d", ruleID, revision)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rule revision: %w", err)
+	}
+
+	return rev, nil
+}
+
+// pricingRuleColumns selects a rule ("r") and its current revision ("rv")
+const pricingRuleColumns = `r.id, r.user_id, r.name, r.description, r.strategy_type, r.config,
+		       r.is_active, r.created_at, r.updated_at, r.deleted_at,
+		       r.effective_from, r.effective_to, r.published_at, r.external_key,
+		       r.current_revision, rv.id, rv.author_id, rv.author_key_id, rv.change_note`
+
+// currentRevisionJoin attaches the revision a rule currently serves
+const currentRevisionJoin = `LEFT JOIN pricing_rule_revisions rv
+		       ON rv.rule_id = r.id AND rv.revision = r.current_revision`
+
+// ruleRevisionColumns selects a full rule revision
+const ruleRevisionColumns = `id, rule_id, revision, name, description, strategy_type, config,
+		       is_active, effective_from, effective_to, author_id, author_key_id, change_note, created_at`
+
+// rowScanner is satisfied by *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanPricingRule scans a row selected with pricingRuleColumns
+func scanPricingRule(row rowScanner) (*domain.PricingRule, error) {
+	rule := &domain.PricingRule{}
+	var config JSONB
+	var description, externalKey, changeNote sql.NullString
+	var deletedAt, effectiveFrom, effectiveTo, publishedAt sql.NullTime
+	var revisionID, authorID, authorKeyID uuid.NullUUID
+
+	err := row.Scan(
+		&rule.ID,
+		&rule.UserID,
+		&rule.Name,
+		&description,
+		&rule.StrategyType,
+		&config,
+		&rule.IsActive,
+		&rule.CreatedAt,
+		&rule.UpdatedAt,
+		&deletedAt,
+		&effectiveFrom,
+		&effectiveTo,
+		&publishedAt,
+		&externalKey,
+		&rule.Revision,
+		&revisionID,
+		&authorID,
+		&authorKeyID,
+		&changeNote,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	rule.Config = config.ToMap()
+	rule.Description = description.String
+	rule.ChangeNote = changeNote.String
+
+	if deletedAt.Valid {
+		rule.DeletedAt = &deletedAt.Time
+	}
+	rule.EffectiveFrom = timeOrNil(effectiveFrom)
+	rule.EffectiveTo = timeOrNil(effectiveTo)
+	rule.PublishedAt = timeOrNil(publishedAt)
+	rule.ExternalKey = externalKey.String
+	if revisionID.Valid {
+		rule.RevisionID = &revisionID.UUID
+	}
+	if authorID.Valid {
+		rule.UpdatedBy = &authorID.UUID
+	}
+	if authorKeyID.Valid {
+		rule.UpdatedByKeyID = &authorKeyID.UUID
+	}
+
+	return rule, nil
+}
+
+// scanRuleRevision scans a row selected with ruleRevisionColumns
+func scanRuleRevision(row rowScanner) (*domain.PricingRuleRevision, error) {
+	revision := &domain.PricingRuleRevision{}
+	var config JSONB
+	var description, changeNote sql.NullString
+	var effectiveFrom, effectiveTo sql.NullTime
+	var authorID, authorKeyID uuid.NullUUID
+
+	err := row.Scan(
+		&revision.ID,
+		&revision.RuleID,
+		&revision.Revision,
+		&revision.Name,
+		&description,
+		&revision.StrategyType,
+		&config,
+		&revision.IsActive,
+		&effectiveFrom,
+		&effectiveTo,
+		&authorID,
+		&authorKeyID,
+		&changeNote,
+		&revision.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	revision.Config = config.ToMap()
+	revision.Description = description.String
+	revision.ChangeNote = changeNote.String
+	revision.EffectiveFrom = timeOrNil(effectiveFrom)
+	revision.EffectiveTo = timeOrNil(effectiveTo)
+
+	if authorID.Valid {
+		revision.AuthorID = &authorID.UUID
+	}
+	if authorKeyID.Valid {
+		revision.AuthorKeyID = &authorKeyID.UUID
+	}
+
+	return revision, nil
+}
+
+// publishRule writes the rule's content as its next revision. The rule row is
+// locked so concurrent publishes get consecutive revision numbers. Queues the
+// rule.updated event.
+func publishRule(ctx context.Context, tx *sql.Tx, rule *domain.PricingRule) error {
+	query := `
+		UPDATE pricing_rules
+		SET name = $1, description = $2, strategy_type = $3, config = $4, 
+		    is_active = $5, updated_at = $6, current_revision = $7,
+		    effective_from = $8, effective_to = $9, published_at = COALESCE(published_at, $6)
+		WHERE id = $10 AND deleted_at IS NULL
+	`
+
+	var current int
+	err := tx.QueryRowContext(
+		ctx,
+		"SELECT current_revision, user_id FROM pricing_rules WHERE id = $1 AND deleted_at IS NULL FOR UPDATE",
+		rule.ID,
+	).Scan(&current, &rule.UserID)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("pricing rule not found: %s", rule.ID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to lock pricing rule: %w", err)
+	}
+
+	rule.Revision = current + 1
+	rule.UpdatedAt = time.Now()
+	if rule.PublishedAt == nil {
+		rule.PublishedAt = &rule.UpdatedAt
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		query,
+		rule.Name,
+		rule.Description,
+		rule.StrategyType,
+		FromMap(rule.Config),
+		rule.IsActive,
+		rule.UpdatedAt,
+		rule.Revision,
+		nullableTime(rule.EffectiveFrom),
+		nullableTime(rule.EffectiveTo),
+		rule.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update pricing rule: %w", err)
+	}
+
+	if err := insertRuleRevision(ctx, tx, rule); err != nil {
+		return err
+	}
+
+	return enqueueEvent(ctx, tx, domain.RuleEvent(domain.EventRuleUpdated, rule))
+}
+
+// insertRuleRevision records the rule's current state as revision rule.Revision
+func insertRuleRevision(ctx context.Context, tx *sql.Tx, rule *domain.PricingRule) error {
+	query := `
+		INSERT INTO pricing_rule_revisions (
+			id, rule_id, revision, name, description, strategy_type, config,
+			is_active, effective_from, effective_to, author_id, author_key_id, change_note, created_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+	`
+
+	// Changes are attributed to the rule owner unless an author is given;
+	// the key is recorded only when the author's key is known
+	author := rule.UpdatedBy
+	if author == nil {
+		owner := rule.UserID
+		author = &owner
+	}
+
+	revisionID := uuid.New()
+	_, err := tx.ExecContext(
+		ctx,
+		query,
+		revisionID,
+		rule.ID,
+		rule.Revision,
+		rule.Name,
+		rule.Description,
+		rule.StrategyType,
+		FromMap(rule.Config),
+		rule.IsActive,
+		nullableTime(rule.EffectiveFrom),
+		nullableTime(rule.EffectiveTo),
+		*author,
+		rule.UpdatedByKeyID,
+		rule.ChangeNote,
+		rule.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record rule revision: %w", err)
+	}
+
+	rule.RevisionID = &revisionID
+	rule.UpdatedBy = author
+	return nil
+}
+
+// nullableTime stores an optional time in UTC, since columns are TIMESTAMP without zone
+func nullableTime(t *time.Time) interface{} {
+	if t == nil {
+		return nil
+	}
+	return t.UTC()
+}
+
+// nullableString stores an empty string as NULL
+func nullableString(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+// timeOrNil converts a scanned nullable time back to a pointer
+func timeOrNil(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
+
+// SaveDraft creates the pending draft of a rule, or replaces its content if one exists.
+// Every save gets a new ID, so an approval can only publish the content it was given for.
+func (r *PricingRuleRepo) SaveDraft(ctx context.Context, draft *domain.RuleDraft) error {
+	return saveRuleDraft(ctx, r.db, draft)
+}
+
+// saveRuleDraft upserts the pending draft of a rule
+func saveRuleDraft(ctx context.Context, ex execer, draft *domain.RuleDraft) error {
+	query := `
+		INSERT INTO pricing_rule_drafts (
+			id, rule_id, base_revision, name, description, strategy_type, config, is_active,
+			effective_from, effective_to, author_id, author_key_id, change_note, status, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $15)
+		ON CONFLICT (rule_id) WHERE status = 'pending' DO UPDATE
+		SET id = EXCLUDED.id, base_revision = EXCLUDED.base_revision, name = EXCLUDED.name, description = EXCLUDED.description,
+		    strategy_type = EXCLUDED.strategy_type, config = EXCLUDED.config, is_active = EXCLUDED.is_active,
+		    effective_from = EXCLUDED.effective_from, effective_to = EXCLUDED.effective_to,
+		    author_id = EXCLUDED.author_id, author_key_id = EXCLUDED.author_key_id,
+		    change_note = EXCLUDED.change_note, updated_at = EXCLUDED.updated_at
+		RETURNING id, created_at
+	`
+
+	draft.ID = uuid.New()
+	draft.Status = domain.DraftStatusPending
+	draft.UpdatedAt = time.Now()
+
+	err := ex.QueryRowContext(
+		ctx,
+		query,
+		draft.ID,
+		draft.RuleID,
+		draft.BaseRevision,
+		draft.Name,
+		draft.Description,
+		draft.StrategyType,
+		FromMap(draft.Config),
+		draft.IsActive,
+		nullableTime(draft.EffectiveFrom),
+		nullableTime(draft.EffectiveTo),
+		draft.AuthorID,
+		draft.AuthorKeyID,
+		draft.ChangeNote,
+		draft.Status,
+		draft.UpdatedAt,
+	).Scan(&draft.ID, &draft.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save rule draft: %w", err)
+	}
+
+	return nil
+}
+
+// GetDraft retrieves the pending draft of a rule
+func (r *PricingRuleRepo) GetDraft(ctx context.Context, ruleID uuid.UUID) (*domain.RuleDraft, error) {
+	query := `
+		SELECT ` + ruleDraftColumns + `
+		FROM pricing_rule_drafts
+		WHERE rule_id = $1 AND status = 'pending'
+	`
+
+	draft, err := scanRuleDraft(r.db.QueryRowContext(ctx, query, ruleID))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w for rule %s", domain.ErrDraftNotFound, ruleID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rule draft: %w", err)
+	}
+
+	return draft, nil
+}
+
+// ListDrafts retrieves a user's pending drafts, most recently edited first
+func (r *PricingRuleRepo) ListDrafts(ctx context.Context, userID uuid.UUID) ([]*domain.RuleDraft, error) {
+	query := `
+		SELECT ` + qualifiedRuleDraftColumns + `
+		FROM pricing_rule_drafts d
+		JOIN pricing_rules r ON r.id = d.rule_id
+		WHERE r.user_id = $1 AND r.deleted_at IS NULL AND d.status = 'pending'
+		ORDER BY d.updated_at DESC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query rule drafts: %w", err)
+	}
+	defer rows.Close()
+
+	var drafts []*domain.RuleDraft
+
+	for rows.Next() {
+		draft, err := scanRuleDraft(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan rule draft: %w", err)
+		}
+		drafts = append(drafts, draft)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rule drafts: %w", err)
+	}
+
+	return drafts, nil
+}
+
+// DiscardDraft abandons the pending draft of a rule
+func (r *PricingRuleRepo) DiscardDraft(ctx context.Context, ruleID uuid.UUID) error {
+	query := `
+		UPDATE pricing_rule_drafts
+		SET status = 'discarded', updated_at = $1
+		WHERE rule_id = $2 AND status = 'pending'
+	`
+
+	result, err := r.db.ExecContext(ctx, query, time.Now(), ruleID)
+	if err != nil {
+		return fmt.Errorf("failed to discard rule draft: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("%w for rule %s", domain.ErrDraftNotFound, ruleID)
+	}
+
+	return nil
+}
+
+// PublishDraft applies a pending draft as the rule's next revision and marks it published
+func (r *PricingRuleRepo) PublishDraft(ctx context.Context, draftID uuid.UUID, approval domain.DraftApproval) (*domain.PricingRule, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	ruleID, err := publishRuleDraft(ctx, tx, draftID, approval)
+	if err != nil {
+		return nil, err
+	}
+
+	// Read back the published rule with its new revision
+	published, err := scanPricingRule(tx.QueryRowContext(
+		ctx,
+		"SELECT "+pricingRuleColumns+" FROM pricing_rules r "+currentRevisionJoin+" WHERE r.id = $1",
+		ruleID,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get published pricing rule: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit draft publish: %w", err)
+	}
+
+	return published, nil
+}
+
+// publishRuleDraft publishes a pending draft within tx and records its approval,
+// returning the ID of the published rule
+func publishRuleDraft(ctx context.Context, tx *sql.Tx, draftID uuid.UUID, approval domain.DraftApproval) (uuid.UUID, error) {
+	draft, err := scanRuleDraft(tx.QueryRowContext(
+		ctx,
+		"SELECT "+ruleDraftColumns+" FROM pricing_rule_drafts WHERE id = $1 AND status = 'pending' FOR UPDATE",
+		draftID,
+	))
+	if err == sql.ErrNoRows {
+		return uuid.Nil, fmt.Errorf("%w: %s", domain.ErrDraftNotFound, draftID)
+	}
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("failed to lock rule draft: %w", err)
+	}
+
+	rule := &domain.PricingRule{
+		ID:             draft.RuleID,
+		Name:           draft.Name,
+		Description:    draft.Description,
+		StrategyType:   draft.StrategyType,
+		Config:         draft.Config,
+		IsActive:       draft.IsActive,
+		EffectiveFrom:  draft.EffectiveFrom,
+		EffectiveTo:    draft.EffectiveTo,
+		UpdatedBy:      draft.AuthorID,
+		UpdatedByKeyID: draft.AuthorKeyID,
+		ChangeNote:     draft.ChangeNote,
+	}
+	if rule.UpdatedBy == nil {
+		rule.UpdatedBy = &approval.UserID
+		rule.UpdatedByKeyID = approval.KeyID
+	}
+	if err := publishRule(ctx, tx, rule); err != nil {
+		return uuid.Nil, err
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		`UPDATE pricing_rule_drafts
+		 SET status = 'published', approved_by = $1, approved_by_key_id = $2, approved_at = $3,
+		     published_revision = $4, updated_at = $3
+		 WHERE id = $5`,
+		approval.UserID,
+		approval.KeyID,
+		rule.UpdatedAt,
+		rule.Revision,
+		draft.ID,
+	)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("failed to record draft approval: %w", err)
+	}
+
+	return rule.ID, nil
+}
+
+// ruleDraftColumns selects a full rule draft
+const ruleDraftColumns = `id, rule_id, base_revision, name, description, strategy_type, config, is_active,
+		       effective_from, effective_to, author_id, author_key_id, change_note, status,
+		       approved_by, approved_by_key_id, approved_at, published_revision, created_at, updated_at`
+
+// qualifiedRuleDraftColumns is ruleDraftColumns for queries joining drafts ("d") to other tables
+const qualifiedRuleDraftColumns = `d.id, d.rule_id, d.base_revision, d.name, d.description, d.strategy_type, d.config, d.is_active,
+		       d.effective_from, d.effective_to, d.author_id, d.author_key_id, d.change_note, d.status,
+		       d.approved_by, d.approved_by_key_id, d.approved_at, d.published_revision, d.created_at, d.updated_at`
+
+// scanRuleDraft scans a row selected with ruleDraftColumns
+func scanRuleDraft(row rowScanner) (*domain.RuleDraft, error) {
+	draft := &domain.RuleDraft{}
+	var config JSONB
+	var description, changeNote sql.NullString
+	var effectiveFrom, effectiveTo, approvedAt sql.NullTime
+	var authorID, authorKeyID, approvedBy, approvedByKeyID uuid.NullUUID
+	var publishedRevision sql.NullInt64
+
+	err := row.Scan(
+		&draft.ID,
+		&draft.RuleID,
+		&draft.BaseRevision,
+		&draft.Name,
+		&description,
+		&draft.StrategyType,
+		&config,
+		&draft.IsActive,
+		&effectiveFrom,
+		&effectiveTo,
+		&authorID,
+		&authorKeyID,
+		&changeNote,
+		&draft.Status,
+		&approvedBy,
+		&approvedByKeyID,
+		&approvedAt,
+		&publishedRevision,
+		&draft.CreatedAt,
+		&draft.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	draft.Config = config.ToMap()
+	draft.Description = description.String
+	draft.ChangeNote = changeNote.String
+	draft.EffectiveFrom = timeOrNil(effectiveFrom)
+	draft.EffectiveTo = timeOrNil(effectiveTo)
+	draft.ApprovedAt = timeOrNil(approvedAt)
+	draft.PublishedRevision = int(publishedRevision.Int64)
+	draft.AuthorID = uuidOrNil(authorID)
+	draft.AuthorKeyID = uuidOrNil(authorKeyID)
+	draft.ApprovedBy = uuidOrNil(approvedBy)
+	draft.ApprovedByKeyID = uuidOrNil(approvedByKeyID)
+
+	return draft, nil
+}
+
+// uuidOrNil converts a scanned nullable UUID back to a pointer
+func uuidOrNil(id uuid.NullUUID) *uuid.UUID {
+	if !id.Valid {
+		return nil
+	}
+	return &id.UUID
+}
@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"fmt"
 	"testing"
 
 	"github.com/google/uuid"
@@ -294,6 +295,118 @@ func TestPricingRuleRepository_List(t *testing.T) {
 	}
 }
 
+func TestPricingRuleRepository_DeleteRevisionAuthors(t *testing.T) {
+	// Covers migrations 021 and 022 against a database with a published draft
+	t.Skip("Requires database connection - run with integration tag")
+
+	ctx := context.Background()
+	repo := NewPricingRuleRepository(DB)
+	users := NewUserRepository(DB)
+	keys := NewAPIKeyRepository(DB)
+
+	owner := &domain.User{Email: fmt.Sprintf("owner-%s@example.com", uuid.New())}
+	author := &domain.User{Email: fmt.Sprintf("author-%s@example.com", uuid.New())}
+	for _, user := range []*domain.User{owner, author} {
+		if err := users.Create(ctx, user); err != nil {
+			t.Fatalf("failed to create user: %v", err)
+		}
+	}
+	key := &domain.APIKey{UserID: author.ID, KeyHash: uuid.NewString(), KeyPrefix: "hm_test_", Name: "author", IsActive: true}
+	if err := keys.Create(ctx, key); err != nil {
+		t.Fatalf("failed to create key: %v", err)
+	}
+
+	rule := &domain.PricingRule{
+		UserID:       owner.ID,
+		Name:         "Authored Rule",
+		StrategyType: domain.StrategyTypeCostPlus,
+		Config:       map[string]interface{}{"markup_value": 10.0},
+		IsActive:     true,
+	}
+	if err := repo.Create(ctx, rule); err != nil {
+		t.Fatalf("failed to create rule: %v", err)
+	}
+
+	draft := &domain.RuleDraft{
+		RuleID:       rule.ID,
+		BaseRevision: 1,
+		Name:         rule.Name,
+		StrategyType: rule.StrategyType,
+		Config:       map[string]interface{}{"markup_value": 20.0},
+		IsActive:     true,
+		AuthorID:     &author.ID,
+		AuthorKeyID:  &key.ID,
+	}
+	if err := repo.SaveDraft(ctx, draft); err != nil {
+		t.Fatalf("failed to save draft: %v", err)
+	}
+	if _, err := repo.PublishDraft(ctx, draft.ID, domain.DraftApproval{UserID: author.ID, KeyID: &key.ID}); err != nil {
+		t.Fatalf("failed to publish draft: %v", err)
+	}
+
+	// Deleting the key and user clears their authorship instead of failing
+	if err := keys.Delete(ctx, key.ID); err != nil {
+		t.Fatalf("failed to delete revision author key: %v", err)
+	}
+	if err := users.Delete(ctx, author.ID); err != nil {
+		t.Fatalf("failed to delete revision author: %v", err)
+	}
+
+	revision, err := repo.GetRevision(ctx, rule.ID, 2)
+	if err != nil {
+		t.Fatalf("failed to get revision: %v", err)
+	}
+	if revision.AuthorID != nil || revision.AuthorKeyID != nil {
+		t.Errorf("expected authorship to be cleared, got user %v and key %v", revision.AuthorID, revision.AuthorKeyID)
+	}
+	if revision.Config["markup_value"] != 20.0 {
+		t.Errorf("expected the revision to be unchanged, got %v", revision.Config)
+	}
+}
+
+func TestPricingRuleFilterClause(t *testing.T) {
+	userID := uuid.New()
+	active := true
+
+	tests := []struct {
+		name      string
+		filter    domain.PricingRuleFilter
+		wantQuery string
+		wantArgs  []interface{}
+	}{
+		{
+			name:      "user only",
+			filter:    domain.PricingRuleFilter{UserID: userID},
+			wantQuery: "",
+			wantArgs:  []interface{}{userID},
+		},
+		{
+			name:      "active rules in effect now",
+			filter:    domain.PricingRuleFilter{UserID: userID, IsActive: &active, Schedule: domain.ScheduleStatusActive},
+			wantQuery: " AND r.is_active = $2" + pricingRuleScheduleConditions[domain.ScheduleStatusActive],
+			wantArgs:  []interface{}{userID, true},
+		},
+		{
+			name:      "upcoming rules of a strategy",
+			filter:    domain.PricingRuleFilter{UserID: userID, StrategyType: domain.StrategyTypeCostPlus, Schedule: domain.ScheduleStatusUpcoming},
+			wantQuery: " AND r.strategy_type = $2 AND r.effective_from > NOW()",
+			wantArgs:  []interface{}{userID, domain.StrategyTypeCostPlus},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, args := pricingRuleFilterClause(tt.filter)
+			if query != tt.wantQuery {
+				t.Errorf("expected query %q, got %q", tt.wantQuery, query)
+			}
+			if fmt.Sprint(args) != fmt.Sprint(tt.wantArgs) {
+				t.Errorf("expected args %v, got %v", tt.wantArgs, args)
+			}
+		})
+	}
+}
+
 // Mock test for JSONB conversion
 func TestJSONB_Conversion(t *testing.T) {
 	tests := []struct {
@@ -3,10 +3,14 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"sort"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"github.com/saintparish4/harmonia/internal/domain"
 )
 
@@ -20,13 +24,33 @@ func NewProductRepository(db *sql.DB) domain.ProductRepository {
 	return &ProductRepo{db: db}
 }
 
-// Create creates a new product
+// Create creates a new product and starts its cost history
 func (r *ProductRepo) Create(ctx context.Context, product *domain.Product) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := insertProduct(ctx, tx, product, domain.CostSourceManual); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit product: %w", err)
+	}
+
+	return nil
+}
+
+// insertProduct inserts a product, generating its ID if needed, and records
+// its base cost as the first cost history entry
+func insertProduct(ctx context.Context, ex execer, product *domain.Product, costSource string) error {
 	query := `
 		INSERT INTO products (
 			id, user_id, sku, name, description, base_cost, 
-			default_rule_id, metadata, is_active, created_at, updated_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+			default_rule_id, category_id, metadata, is_active, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
 	`
 
 	// Generate ID if not provided
@@ -42,7 +66,7 @@ func (r *ProductRepo) Create(ctx context.Context, product *domain.Product) error
 	// Convert metadata to JSONB
 	metadata := FromMap(product.Metadata)
 
-	_, err := r.db.ExecContext(
+	_, err := ex.ExecContext(
 		ctx,
 		query,
 		product.ID,
@@ -52,6 +76,7 @@ func (r *ProductRepo) Create(ctx context.Context, product *domain.Product) error
 		product.Description,
 		product.BaseCost,
 		product.DefaultRuleID,
+		product.CategoryID,
 		metadata,
 		product.IsActive,
 		product.CreatedAt,
@@ -62,108 +87,51 @@ func (r *ProductRepo) Create(ctx context.Context, product *domain.Product) error
 		return fmt.Errorf("failed to create product: %w", err)
 	}
 
-	return nil
+	return recordCostChange(ctx, ex, product.ID, product.BaseCost, product.CreatedAt, costSource)
 }
 
 // GetByID retrieves a product by ID
 func (r *ProductRepo) GetByID(ctx context.Context, id uuid.UUID) (*domain.Product, error) {
 	query := `
-		SELECT id, user_id, sku, name, description, base_cost, 
-		       default_rule_id, metadata, is_active, created_at, updated_at
+		SELECT ` + productColumns + `
 		FROM products
 		WHERE id = $1
 	`
 
-	product := &domain.Product{}
-	var metadata JSONB
-	var defaultRuleID sql.NullString
-
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&product.ID,
-		&product.UserID,
-		&product.SKU,
-		&product.Name,
-		&product.Description,
-		&product.BaseCost,
-		&defaultRuleID,
-		&metadata,
-		&product.IsActive,
-		&product.CreatedAt,
-		&product.UpdatedAt,
-	)
-
+	product, err := scanProduct(r.db.QueryRowContext(ctx, query, id))
 	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("product not found: %s", id)
+		return nil, fmt.Errorf("%w: %s", domain.ErrProductNotFound, id)
 	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to get product: %w", err)
 	}
 
-	// Convert metadata
-	product.Metadata = metadata.ToMap()
-
-	// Handle nullable default_rule_id
-	if defaultRuleID.Valid {
-		ruleID, err := uuid.Parse(defaultRuleID.String)
-		if err == nil {
-			product.DefaultRuleID = &ruleID
-		}
-	}
-
 	return product, nil
 }
 
 // GetBySKU retrieves a product by SKU for a specific user
 func (r *ProductRepo) GetBySKU(ctx context.Context, userID uuid.UUID, sku string) (*domain.Product, error) {
 	query := `
-		SELECT id, user_id, sku, name, description, base_cost, 
-		       default_rule_id, metadata, is_active, created_at, updated_at
+		SELECT ` + productColumns + `
 		FROM products
 		WHERE user_id = $1 AND sku = $2
 	`
 
-	product := &domain.Product{}
-	var metadata JSONB
-	var defaultRuleID sql.NullString
-
-	err := r.db.QueryRowContext(ctx, query, userID, sku).Scan(
-		&product.ID,
-		&product.UserID,
-		&product.SKU,
-		&product.Name,
-		&product.Description,
-		&product.BaseCost,
-		&defaultRuleID,
-		&metadata,
-		&product.IsActive,
-		&product.CreatedAt,
-		&product.UpdatedAt,
-	)
-
+	product, err := scanProduct(r.db.QueryRowContext(ctx, query, userID, sku))
 	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("product not found: %s", sku)
+		return nil, fmt.Errorf("%w: %s", domain.ErrProductNotFound, sku)
 	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to get product: %w", err)
 	}
 
-	product.Metadata = metadata.ToMap()
-
-	if defaultRuleID.Valid {
-		ruleID, err := uuid.Parse(defaultRuleID.String)
-		if err == nil {
-			product.DefaultRuleID = &ruleID
-		}
-	}
-
 	return product, nil
 }
 
 // GetByUserID retrieves all active products for a user
 func (r *ProductRepo) GetByUserID(ctx context.Context, userID uuid.UUID) ([]*domain.Product, error) {
 	query := `
-		SELECT id, user_id, sku, name, description, base_cost, 
-		       default_rule_id, metadata, is_active, created_at, updated_at
+		SELECT ` + productColumns + `
 		FROM products
 		WHERE user_id = $1 AND is_active = true
 		ORDER BY created_at DESC
@@ -178,36 +146,11 @@ func (r *ProductRepo) GetByUserID(ctx context.Context, userID uuid.UUID) ([]*dom
 	var products []*domain.Product
 
 	for rows.Next() {
-		product := &domain.Product{}
-		var metadata JSONB
-		var defaultRuleID sql.NullString
-
-		err := rows.Scan(
-			&product.ID,
-			&product.UserID,
-			&product.SKU,
-			&product.Name,
-			&product.Description,
-			&product.BaseCost,
-			&defaultRuleID,
-			&metadata,
-			&product.IsActive,
-			&product.CreatedAt,
-			&product.UpdatedAt,
-		)
+		product, err := scanProduct(rows)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan product: %w", err)
 		}
 
-		product.Metadata = metadata.ToMap()
-
-		if defaultRuleID.Valid {
-			ruleID, err := uuid.Parse(defaultRuleID.String)
-			if err == nil {
-				product.DefaultRuleID = &ruleID
-			}
-		}
-
 		products = append(products, product)
 	}
 
@@ -218,19 +161,45 @@ func (r *ProductRepo) GetByUserID(ctx context.Context, userID uuid.UUID) ([]*dom
 	return products, nil
 }
 
-// Update updates an existing product
+// Update updates an existing product; a new base cost is added to its cost history
 func (r *ProductRepo) Update(ctx context.Context, product *domain.Product) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := updateProduct(ctx, tx, product, domain.CostSourceManual); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit product: %w", err)
+	}
+
+	return nil
+}
+
+// updateProduct writes every field of an existing product, recording a
+// changed base cost as effective from now
+func updateProduct(ctx context.Context, ex execer, product *domain.Product, costSource string) error {
+	// The old base cost is read in the same statement: history is only
+	// recorded when the caller changed it, so edits that carry a stale cost
+	// forward do not undo a cost entry that has since taken effect
 	query := `
+		WITH old AS (SELECT base_cost FROM products WHERE id = $10)
 		UPDATE products
 		SET sku = $1, name = $2, description = $3, base_cost = $4, 
-		    default_rule_id = $5, metadata = $6, is_active = $7, updated_at = $8
-		WHERE id = $9
+		    default_rule_id = $5, category_id = $6, metadata = $7, is_active = $8, updated_at = $9
+		WHERE id = $10
+		RETURNING (SELECT base_cost FROM old)
 	`
 
 	product.UpdatedAt = time.Now()
 	metadata := FromMap(product.Metadata)
 
-	result, err := r.db.ExecContext(
+	var oldCost sql.NullFloat64
+	err := ex.QueryRowContext(
 		ctx,
 		query,
 		product.SKU,
@@ -238,26 +207,25 @@ func (r *ProductRepo) Update(ctx context.Context, product *domain.Product) error
 		product.Description,
 		product.BaseCost,
 		product.DefaultRuleID,
+		product.CategoryID,
 		metadata,
 		product.IsActive,
 		product.UpdatedAt,
 		product.ID,
-	)
+	).Scan(&oldCost)
 
-	if err != nil {
-		return fmt.Errorf("failed to update product: %w", err)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("product not found: %s", product.ID)
 	}
-
-	rowsAffected, err := result.RowsAffected()
 	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
+		return fmt.Errorf("failed to update product: %w", err)
 	}
 
-	if rowsAffected == 0 {
-		return fmt.Errorf("product not found: %s", product.ID)
+	if oldCost.Valid && oldCost.Float64 == product.BaseCost {
+		return nil
 	}
 
-	return nil
+	return recordCostChange(ctx, ex, product.ID, product.BaseCost, product.UpdatedAt, costSource)
 }
 
 // Delete soft deletes a product
@@ -289,24 +257,22 @@ func (r *ProductRepo) Delete(ctx context.Context, id uuid.UUID) error {
 // List retrieves products with optional filters
 func (r *ProductRepo) List(ctx context.Context, filter domain.ProductFilter) ([]*domain.Product, error) {
 	query := `
-		SELECT id, user_id, sku, name, description, base_cost, 
-		       default_rule_id, metadata, is_active, created_at, updated_at
+		SELECT ` + productColumns + `
 		FROM products
 		WHERE user_id = $1
 	`
 
-	args := []interface{}{filter.UserID}
-	argCount := 1
+	where, args := productFilterClause(filter)
+	query += where
+	argCount := len(args)
 
-	// Add is_active filter if provided
-	if filter.IsActive != nil {
-		argCount++
-		query += fmt.Sprintf(" AND is_active = $%d", argCount)
-		args = append(args, *filter.IsActive)
-	}
+	after, afterArgs := cursorCondition(filter.After, "", argCount)
+	query += after
+	args = append(args, afterArgs...)
+	argCount += len(afterArgs)
 
 	// Add ordering
-	query += " ORDER BY created_at DESC"
+	query += " ORDER BY " + keysetOrder
 
 	// Add pagination
 	if filter.Limit > 0 {
@@ -330,36 +296,129 @@ func (r *ProductRepo) List(ctx context.Context, filter domain.ProductFilter) ([]
 	var products []*domain.Product
 
 	for rows.Next() {
-		product := &domain.Product{}
-		var metadata JSONB
-		var defaultRuleID sql.NullString
-
-		err := rows.Scan(
-			&product.ID,
-			&product.UserID,
-			&product.SKU,
-			&product.Name,
-			&product.Description,
-			&product.BaseCost,
-			&defaultRuleID,
-			&metadata,
-			&product.IsActive,
-			&product.CreatedAt,
-			&product.UpdatedAt,
-		)
+		product, err := scanProduct(rows)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan product: %w", err)
 		}
 
-		product.Metadata = metadata.ToMap()
+		products = append(products, product)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating products: %w", err)
+	}
+
+	return products, nil
+}
+
+// Count returns the number of products matching the filters
+func (r *ProductRepo) Count(ctx context.Context, filter domain.ProductFilter) (int, error) {
+	query := `
+		SELECT COUNT(*)
+		FROM products
+		WHERE user_id = $1
+	`
+
+	where, args := productFilterClause(filter)
+	query += where
+
+	var count int
+	if err := r.db.QueryRowContext(ctx, query, args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count products: %w", err)
+	}
+
+	return count, nil
+}
+
+// productFilterClause builds the conditions after "WHERE user_id = $1" and their args.
+// Metadata filters use JSONB containment so they are served by idx_products_metadata.
+func productFilterClause(filter domain.ProductFilter) (string, []interface{}) {
+	query := ""
+	args := []interface{}{filter.UserID}
+	argCount := 1
+
+	// Add is_active filter if provided
+	if filter.IsActive != nil {
+		argCount++
+		query += fmt.Sprintf(" AND is_active = $%d", argCount)
+		args = append(args, *filter.IsActive)
+	}
+
+	// Add category filter
+	if filter.Category != "" {
+		argCount++
+		query += fmt.Sprintf(" AND metadata @> $%d::jsonb", argCount)
+		args = append(args, containment("category", filter.Category))
+	}
 
-		if defaultRuleID.Valid {
-			ruleID, err := uuid.Parse(defaultRuleID.String)
-			if err == nil {
-				product.DefaultRuleID = &ruleID
+	// Add category tree filter, covering every subcategory
+	if filter.CategoryID != nil {
+		argCount++
+		query += fmt.Sprintf(" AND category_id IN (WITH RECURSIVE subtree AS ("+
+			"SELECT id FROM product_categories WHERE id = $%d "+
+			"UNION ALL SELECT c.id FROM product_categories c JOIN subtree ON c.parent_id = subtree.id"+
+			") SELECT id FROM subtree)", argCount)
+		args = append(args, *filter.CategoryID)
+	}
+
+	// Add metadata filters in key order so queries are stable
+	keys := make([]string, 0, len(filter.Metadata))
+	for key := range filter.Metadata {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value := filter.Metadata[key]
+		argCount++
+		condition := fmt.Sprintf("metadata @> $%d::jsonb", argCount)
+		args = append(args, containment(key, value))
+
+		// A value spelling a number or boolean also matches that JSON value
+		var scalar interface{}
+		if err := json.Unmarshal([]byte(value), &scalar); err == nil {
+			switch scalar.(type) {
+			case float64, bool:
+				argCount++
+				condition = fmt.Sprintf("(%s OR metadata @> $%d::jsonb)", condition, argCount)
+				args = append(args, containment(key, scalar))
 			}
 		}
 
+		query += " AND " + condition
+	}
+
+	return query, args
+}
+
+// containment returns a JSONB document {key: value} for the @> operator
+func containment(key string, value interface{}) string {
+	doc, _ := json.Marshal(map[string]interface{}{key: value})
+	return string(doc)
+}
+
+// GetBySKUs retrieves a user's products with any of the given SKUs
+func (r *ProductRepo) GetBySKUs(ctx context.Context, userID uuid.UUID, skus []string) ([]*domain.Product, error) {
+	query := `
+		SELECT ` + productColumns + `
+		FROM products
+		WHERE user_id = $1 AND sku = ANY($2)
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userID, pq.Array(skus))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query products: %w", err)
+	}
+	defer rows.Close()
+
+	var products []*domain.Product
+
+	for rows.Next() {
+		product, err := scanProduct(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan product: %w", err)
+		}
+
 		products = append(products, product)
 	}
 
@@ -369,3 +428,451 @@ func (r *ProductRepo) List(ctx context.Context, filter domain.ProductFilter) ([]
 
 	return products, nil
 }
+
+// UpsertBatch creates or updates products by (user_id, sku) in one transaction.
+// Every field but the category is written; IDs and timestamps are set from the
+// stored rows, and changed costs are added to the cost history as imports.
+func (r *ProductRepo) UpsertBatch(ctx context.Context, products []*domain.Product) error {
+	query := `
+		INSERT INTO products (
+			id, user_id, sku, name, description, base_cost, 
+			default_rule_id, metadata, is_active, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $10)
+		ON CONFLICT (user_id, sku) DO UPDATE
+		SET name = EXCLUDED.name, description = EXCLUDED.description, base_cost = EXCLUDED.base_cost,
+		    default_rule_id = EXCLUDED.default_rule_id, metadata = EXCLUDED.metadata,
+		    is_active = EXCLUDED.is_active, updated_at = EXCLUDED.updated_at
+		RETURNING id, created_at, updated_at,
+		          (SELECT base_cost FROM products WHERE user_id = $2 AND sku = $3)
+	`
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, query)
+	if err != nil {
+		return fmt.Errorf("failed to prepare product upsert: %w", err)
+	}
+	defer stmt.Close()
+
+	now := time.Now()
+	for _, product := range products {
+		var oldCost sql.NullFloat64
+		if product.ID == uuid.Nil {
+			product.ID = uuid.New()
+		}
+
+		err := stmt.QueryRowContext(
+			ctx,
+			product.ID,
+			product.UserID,
+			product.SKU,
+			product.Name,
+			product.Description,
+			product.BaseCost,
+			product.DefaultRuleID,
+			FromMap(product.Metadata),
+			product.IsActive,
+			now,
+		).Scan(&product.ID, &product.CreatedAt, &product.UpdatedAt, &oldCost)
+		if err != nil {
+			return fmt.Errorf("failed to upsert product %s: %w", product.SKU, err)
+		}
+
+		// The sub-select sees the row as it was before the upsert
+		if oldCost.Valid && oldCost.Float64 == product.BaseCost {
+			continue
+		}
+		if err := recordCostChange(ctx, tx, product.ID, product.BaseCost, now, domain.CostSourceImport); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit products: %w", err)
+	}
+
+	return nil
+}
+
+// productColumns selects every product field, in scanProduct order
+const productColumns = `id, user_id, sku, name, description, base_cost,
+		       default_rule_id, category_id, metadata, is_active, created_at, updated_at`
+
+// scanProduct scans a row selected with productColumns
+func scanProduct(row rowScanner) (*domain.Product, error) {
+	product := &domain.Product{}
+	var metadata JSONB
+	var defaultRuleID, categoryID uuid.NullUUID
+
+	err := row.Scan(
+		&product.ID,
+		&product.UserID,
+		&product.SKU,
+		&product.Name,
+		&product.Description,
+		&product.BaseCost,
+		&defaultRuleID,
+		&categoryID,
+		&metadata,
+		&product.IsActive,
+		&product.CreatedAt,
+		&product.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	product.Metadata = metadata.ToMap()
+	product.DefaultRuleID = uuidOrNil(defaultRuleID)
+	product.CategoryID = uuidOrNil(categoryID)
+
+	return product, nil
+}
+
+// AddCost records a cost history entry and refreshes the product's base cost
+// to the cost in effect now
+func (r *ProductRepo) AddCost(ctx context.Context, cost *domain.ProductCost) error {
+	if cost.ID == uuid.Nil {
+		cost.ID = uuid.New()
+	}
+	cost.CreatedAt = time.Now()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(
+		ctx,
+		`INSERT INTO product_costs (id, product_id, cost, effective_from, source, note, created_by, created_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		cost.ID,
+		cost.ProductID,
+		cost.Cost,
+		cost.EffectiveFrom,
+		cost.Source,
+		nullableString(cost.Note),
+		cost.CreatedBy,
+		cost.CreatedAt,
+	)
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Constraint == "uq_product_costs_effective" {
+		return fmt.Errorf("%w: %s", domain.ErrCostExists, cost.EffectiveFrom.Format(time.RFC3339))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create product cost: %w", err)
+	}
+
+	// Backdated and current entries change the cost in effect now; future ones do not yet
+	_, err = tx.ExecContext(
+		ctx,
+		`UPDATE products SET base_cost = current.cost
+		 FROM (
+			SELECT cost FROM product_costs
+			WHERE product_id = $1 AND effective_from <= $2
+			ORDER BY effective_from DESC LIMIT 1
+		 ) current
+		 WHERE products.id = $1`,
+		cost.ProductID,
+		cost.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to refresh product base cost: %w", err)
+	}
+
+	var userID uuid.UUID
+	var sku string
+	err = tx.QueryRowContext(ctx, "SELECT user_id, sku FROM products WHERE id = $1", cost.ProductID).Scan(&userID, &sku)
+	if err != nil {
+		return fmt.Errorf("failed to get product: %w", err)
+	}
+	if err := enqueueEvent(ctx, tx, domain.CostChangedEvent(userID, sku, cost)); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit product cost: %w", err)
+	}
+
+	return nil
+}
+
+// ListCosts retrieves a product's cost history, earliest first
+func (r *ProductRepo) ListCosts(ctx context.Context, productID uuid.UUID) ([]*domain.ProductCost, error) {
+	query := `
+		SELECT ` + productCostColumns + `
+		FROM product_costs
+		WHERE product_id = $1
+		ORDER BY effective_from ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, productID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query product costs: %w", err)
+	}
+	defer rows.Close()
+
+	var costs []*domain.ProductCost
+
+	for rows.Next() {
+		cost, err := scanProductCost(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan product cost: %w", err)
+		}
+
+		costs = append(costs, cost)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating product costs: %w", err)
+	}
+
+	return costs, nil
+}
+
+// CostAt retrieves the latest cost that took effect at or before a point in time
+func (r *ProductRepo) CostAt(ctx context.Context, productID uuid.UUID, at time.Time) (*domain.ProductCost, error) {
+	query := `
+		SELECT ` + productCostColumns + `
+		FROM product_costs
+		WHERE product_id = $1 AND effective_from <= $2
+		ORDER BY effective_from DESC
+		LIMIT 1
+	`
+
+	cost, err := scanProductCost(r.db.QueryRowContext(ctx, query, productID, at))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w: %s at %s", domain.ErrNoCostInEffect, productID, at.UTC().Format(time.RFC3339))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product cost: %w", err)
+	}
+
+	return cost, nil
+}
+
+// recordCostChange appends a cost history entry at the given time unless the
+// cost already in effect then is the same, queuing its product.cost_changed event
+func recordCostChange(ctx context.Context, ex execer, productID uuid.UUID, cost float64, at time.Time, source string) error {
+	recorded := &domain.ProductCost{ProductID: productID, Cost: cost, EffectiveFrom: at, Source: source}
+	var userID uuid.UUID
+	var sku string
+
+	err := ex.QueryRowContext(
+		ctx,
+		`WITH recorded AS (
+			INSERT INTO product_costs (id, product_id, cost, effective_from, source, created_at)
+			SELECT $1, $2, $3, $4, $5, $4
+			WHERE (
+				SELECT cost FROM product_costs
+				WHERE product_id = $2 AND effective_from <= $4
+				ORDER BY effective_from DESC LIMIT 1
+			) IS DISTINCT FROM $3::numeric
+			ON CONFLICT (product_id, effective_from) DO UPDATE
+			SET cost = EXCLUDED.cost, source = EXCLUDED.source
+			RETURNING id
+		 )
+		 SELECT recorded.id, p.user_id, p.sku FROM recorded, products p WHERE p.id = $2`,
+		uuid.New(),
+		productID,
+		cost,
+		at,
+		source,
+	).Scan(&recorded.ID, &userID, &sku)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to record product cost: %w", err)
+	}
+
+	return enqueueEvent(ctx, ex, domain.CostChangedEvent(userID, sku, recorded))
+}
+
+// productCostColumns selects every cost history field, in scanProductCost order
+const productCostColumns = `id, product_id, cost, effective_from, source, note, created_by, created_at`
+
+// scanProductCost scans a row selected with productCostColumns
+func scanProductCost(row rowScanner) (*domain.ProductCost, error) {
+	cost := &domain.ProductCost{}
+	var note sql.NullString
+	var createdBy uuid.NullUUID
+
+	err := row.Scan(
+		&cost.ID,
+		&cost.ProductID,
+		&cost.Cost,
+		&cost.EffectiveFrom,
+		&cost.Source,
+		&note,
+		&createdBy,
+		&cost.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	cost.Note = note.String
+	cost.CreatedBy = uuidOrNil(createdBy)
+
+	return cost, nil
+}
+
+// AddRuleAssignment schedules a default rule for a product, rejecting overlapping windows
+func (r *ProductRepo) AddRuleAssignment(ctx context.Context, assignment *domain.ProductRuleAssignment) error {
+	if err := assignment.Window().Validate(); err != nil {
+		return err
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Lock the product so concurrent assignments are checked against each other
+	var productID uuid.UUID
+	err = tx.QueryRowContext(ctx, "SELECT id FROM products WHERE id = $1 FOR UPDATE", assignment.ProductID).Scan(&productID)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("product not found: %s", assignment.ProductID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to lock product: %w", err)
+	}
+
+	existing, err := queryRuleAssignments(ctx, tx, assignment.ProductID)
+	if err != nil {
+		return err
+	}
+	for _, other := range existing {
+		if other.Window().Overlaps(assignment.Window()) {
+			return fmt.Errorf("%w: %s", domain.ErrScheduleOverlap, other.ID)
+		}
+	}
+
+	if err := insertRuleAssignment(ctx, tx, assignment); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit rule assignment: %w", err)
+	}
+
+	return nil
+}
+
+// ListRuleAssignments retrieves a product's scheduled rules, earliest first
+func (r *ProductRepo) ListRuleAssignments(ctx context.Context, productID uuid.UUID) ([]*domain.ProductRuleAssignment, error) {
+	return queryRuleAssignments(ctx, r.db, productID)
+}
+
+// DeleteRuleAssignment removes a scheduled rule from a product
+func (r *ProductRepo) DeleteRuleAssignment(ctx context.Context, productID, id uuid.UUID) error {
+	result, err := r.db.ExecContext(
+		ctx,
+		"DELETE FROM product_rule_assignments WHERE id = $1 AND product_id = $2",
+		id,
+		productID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to delete rule assignment: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("%w: %s", domain.ErrAssignmentNotFound, id)
+	}
+
+	return nil
+}
+
+// insertRuleAssignment inserts an assignment without checking for overlaps
+func insertRuleAssignment(ctx context.Context, ex execer, assignment *domain.ProductRuleAssignment) error {
+	if assignment.ID == uuid.Nil {
+		assignment.ID = uuid.New()
+	}
+	assignment.CreatedAt = time.Now()
+
+	_, err := ex.ExecContext(
+		ctx,
+		`INSERT INTO product_rule_assignments (id, product_id, rule_id, effective_from, effective_to, created_at)
+		 VALUES ($1, $2, $3, $4, $5, $6)`,
+		assignment.ID,
+		assignment.ProductID,
+		assignment.RuleID,
+		nullableTime(assignment.EffectiveFrom),
+		nullableTime(assignment.EffectiveTo),
+		assignment.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create rule assignment: %w", err)
+	}
+
+	return nil
+}
+
+// queryer is satisfied by *sql.DB and *sql.Tx
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// execer is satisfied by *sql.DB and *sql.Tx
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// queryRuleAssignments lists a product's assignments; unbounded starts sort first
+func queryRuleAssignments(ctx context.Context, q queryer, productID uuid.UUID) ([]*domain.ProductRuleAssignment, error) {
+	query := `
+		SELECT id, product_id, rule_id, effective_from, effective_to, created_at
+		FROM product_rule_assignments
+		WHERE product_id = $1
+		ORDER BY effective_from ASC NULLS FIRST, created_at ASC
+	`
+
+	rows, err := q.QueryContext(ctx, query, productID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query rule assignments: %w", err)
+	}
+	defer rows.Close()
+
+	var assignments []*domain.ProductRuleAssignment
+
+	for rows.Next() {
+		assignment := &domain.ProductRuleAssignment{}
+		var effectiveFrom, effectiveTo sql.NullTime
+
+		err := rows.Scan(
+			&assignment.ID,
+			&assignment.ProductID,
+			&assignment.RuleID,
+			&effectiveFrom,
+			&effectiveTo,
+			&assignment.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan rule assignment: %w", err)
+		}
+
+		assignment.EffectiveFrom = timeOrNil(effectiveFrom)
+		assignment.EffectiveTo = timeOrNil(effectiveTo)
+		assignments = append(assignments, assignment)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rule assignments: %w", err)
+	}
+
+	return assignments, nil
+}
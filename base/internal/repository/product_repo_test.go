@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/saintparish4/harmonia/internal/domain"
+)
+
+func TestProductFilterClause(t *testing.T) {
+	userID := uuid.New()
+	active := true
+	categoryID := uuid.New()
+
+	tests := []struct {
+		name      string
+		filter    domain.ProductFilter
+		wantQuery string
+		wantArgs  []interface{}
+	}{
+		{
+			name:     "user only",
+			filter:   domain.ProductFilter{UserID: userID},
+			wantArgs: []interface{}{userID},
+		},
+		{
+			name:      "active and category",
+			filter:    domain.ProductFilter{UserID: userID, IsActive: &active, Category: "widgets"},
+			wantQuery: " AND is_active = $2 AND metadata @> $3::jsonb",
+			wantArgs:  []interface{}{userID, true, `{"category":"widgets"}`},
+		},
+		{
+			name:      "category subtree",
+			filter:    domain.ProductFilter{UserID: userID, CategoryID: &categoryID},
+			wantQuery: " AND category_id IN (WITH RECURSIVE subtree AS (SELECT id FROM product_categories WHERE id = $2 UNION ALL SELECT c.id FROM product_categories c JOIN subtree ON c.parent_id = subtree.id) SELECT id FROM subtree)",
+			wantArgs:  []interface{}{userID, categoryID},
+		},
+		{
+			name:      "string metadata value",
+			filter:    domain.ProductFilter{UserID: userID, Metadata: map[string]string{"tier": "premium"}},
+			wantQuery: " AND metadata @> $2::jsonb",
+			wantArgs:  []interface{}{userID, `{"tier":"premium"}`},
+		},
+		{
+			name:      "numeric and boolean values also match JSON scalars, in key order",
+			filter:    domain.ProductFilter{UserID: userID, Metadata: map[string]string{"weight_kg": "1.5", "fragile": "true"}},
+			wantQuery: " AND (metadata @> $2::jsonb OR metadata @> $3::jsonb) AND (metadata @> $4::jsonb OR metadata @> $5::jsonb)",
+			wantArgs: []interface{}{userID,
+				`{"fragile":"true"}`, `{"fragile":true}`,
+				`{"weight_kg":"1.5"}`, `{"weight_kg":1.5}`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, args := productFilterClause(tt.filter)
+			if query != tt.wantQuery {
+				t.Errorf("expected query %q, got %q", tt.wantQuery, query)
+			}
+			if fmt.Sprint(args) != fmt.Sprint(tt.wantArgs) {
+				t.Errorf("expected args %v, got %v", tt.wantArgs, args)
+			}
+		})
+	}
+}
@@ -23,8 +23,8 @@ func NewUserRepository(db *sql.DB) domain.UserRepository {
 // Create creates a new user
 func (r *UserRepo) Create(ctx context.Context, user *domain.User) error {
 	query := `
-		INSERT INTO users (id, email, created_at, updated_at)
-		VALUES ($1, $2, $3, $4)
+		INSERT INTO users (id, email, created_at, updated_at, require_rule_approval, default_rule_id, log_retention_days)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
 	`
 
 	// Generate ID if not provided
@@ -44,6 +44,9 @@ func (r *UserRepo) Create(ctx context.Context, user *domain.User) error {
 		user.Email,
 		user.CreatedAt,
 		user.UpdatedAt,
+		user.RequireRuleApproval,
+		user.DefaultRuleID,
+		user.LogRetentionDays,
 	)
 
 	if err != nil {
@@ -56,18 +59,23 @@ func (r *UserRepo) Create(ctx context.Context, user *domain.User) error {
 // GetByID retrieves a user by ID
 func (r *UserRepo) GetByID(ctx context.Context, id uuid.UUID) (*domain.User, error) {
 	query := `
-		SELECT id, email, created_at, updated_at
+		SELECT id, email, created_at, updated_at, require_rule_approval, default_rule_id, log_retention_days
 		FROM users
 		WHERE id = $1
 	`
 
 	user := &domain.User{}
+	var defaultRuleID uuid.NullUUID
+	var logRetentionDays sql.NullInt64
 
 	err := r.db.QueryRowContext(ctx, query, id).Scan(
 		&user.ID,
 		&user.Email,
 		&user.CreatedAt,
 		&user.UpdatedAt,
+		&user.RequireRuleApproval,
+		&defaultRuleID,
+		&logRetentionDays,
 	)
 
 	if err == sql.ErrNoRows {
@@ -77,24 +85,32 @@ func (r *UserRepo) GetByID(ctx context.Context, id uuid.UUID) (*domain.User, err
 		return nil, fmt.Errorf("failed to get user: %w", err)
 	}
 
+	user.DefaultRuleID = uuidOrNil(defaultRuleID)
+	user.LogRetentionDays = intOrNil(logRetentionDays)
+
 	return user, nil
 }
 
 // GetByEmail retrieves a user by email
 func (r *UserRepo) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
 	query := `
-		SELECT id, email, created_at, updated_at
+		SELECT id, email, created_at, updated_at, require_rule_approval, default_rule_id, log_retention_days
 		FROM users
 		WHERE email = $1
 	`
 
 	user := &domain.User{}
+	var defaultRuleID uuid.NullUUID
+	var logRetentionDays sql.NullInt64
 
 	err := r.db.QueryRowContext(ctx, query, email).Scan(
 		&user.ID,
 		&user.Email,
 		&user.CreatedAt,
 		&user.UpdatedAt,
+		&user.RequireRuleApproval,
+		&defaultRuleID,
+		&logRetentionDays,
 	)
 
 	if err == sql.ErrNoRows {
@@ -104,6 +120,9 @@ func (r *UserRepo) GetByEmail(ctx context.Context, email string) (*domain.User,
 		return nil, fmt.Errorf("failed to get user: %w", err)
 	}
 
+	user.DefaultRuleID = uuidOrNil(defaultRuleID)
+	user.LogRetentionDays = intOrNil(logRetentionDays)
+
 	return user, nil
 }
 
@@ -111,8 +130,8 @@ func (r *UserRepo) GetByEmail(ctx context.Context, email string) (*domain.User,
 func (r *UserRepo) Update(ctx context.Context, user *domain.User) error {
 	query := `
 		UPDATE users
-		SET email = $1, updated_at = $2
-		WHERE id = $3
+		SET email = $1, require_rule_approval = $2, default_rule_id = $3, log_retention_days = $4, updated_at = $5
+		WHERE id = $6
 	`
 
 	user.UpdatedAt = time.Now()
@@ -121,6 +140,9 @@ func (r *UserRepo) Update(ctx context.Context, user *domain.User) error {
 		ctx,
 		query,
 		user.Email,
+		user.RequireRuleApproval,
+		user.DefaultRuleID,
+		user.LogRetentionDays,
 		user.UpdatedAt,
 		user.ID,
 	)
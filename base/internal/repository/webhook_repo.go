@@ -0,0 +1,590 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/saintparish4/harmonia/internal/domain"
+)
+
+// WebhookRepo implements domain.WebhookRepository
+type WebhookRepo struct {
+	db *sql.DB
+}
+
+// NewWebhookRepository creates a new webhook repository
+func NewWebhookRepository(db *sql.DB) domain.WebhookRepository {
+	return &WebhookRepo{db: db}
+}
+
+// webhookEndpointColumns selects every endpoint field, in scanWebhookEndpoint order
+const webhookEndpointColumns = `id, user_id, url, description, secret, event_types, is_active, created_at, updated_at`
+
+// webhookDeliveryColumns selects a delivery joined with its event as ev, in scanWebhookDelivery order
+const webhookDeliveryColumns = `d.id, d.endpoint_id, d.status, d.attempts, d.next_attempt_at, d.last_attempt_at,
+	d.response_status, d.last_error, d.replay_of, d.created_at, d.updated_at,
+	ev.id, ev.user_id, ev.event_type, ev.payload, ev.created_at, ev.dispatched_at`
+
+// CreateEndpoint creates a webhook endpoint
+func (r *WebhookRepo) CreateEndpoint(ctx context.Context, endpoint *domain.WebhookEndpoint) error {
+	query := `
+		INSERT INTO webhook_endpoints (
+			id, user_id, url, description, secret, event_types, is_active, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`
+
+	// Generate ID if not provided
+	if endpoint.ID == uuid.Nil {
+		endpoint.ID = uuid.New()
+	}
+
+	// Set timestamps
+	now := time.Now()
+	endpoint.CreatedAt = now
+	endpoint.UpdatedAt = now
+
+	_, err := r.db.ExecContext(
+		ctx,
+		query,
+		endpoint.ID,
+		endpoint.UserID,
+		endpoint.URL,
+		nullableString(endpoint.Description),
+		endpoint.Secret,
+		pq.Array(eventTypesOrEmpty(endpoint.EventTypes)),
+		endpoint.IsActive,
+		endpoint.CreatedAt,
+		endpoint.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create webhook endpoint: %w", err)
+	}
+
+	return nil
+}
+
+// GetEndpoint retrieves a webhook endpoint by ID
+func (r *WebhookRepo) GetEndpoint(ctx context.Context, id uuid.UUID) (*domain.WebhookEndpoint, error) {
+	query := `SELECT ` + webhookEndpointColumns + ` FROM webhook_endpoints WHERE id = $1`
+
+	endpoint, err := scanWebhookEndpoint(r.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w: %s", domain.ErrWebhookNotFound, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook endpoint: %w", err)
+	}
+
+	return endpoint, nil
+}
+
+// ListEndpoints retrieves a user's webhook endpoints, oldest first
+func (r *WebhookRepo) ListEndpoints(ctx context.Context, userID uuid.UUID) ([]*domain.WebhookEndpoint, error) {
+	query := `
+		SELECT ` + webhookEndpointColumns + `
+		FROM webhook_endpoints
+		WHERE user_id = $1
+		ORDER BY created_at
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query webhook endpoints: %w", err)
+	}
+	defer rows.Close()
+
+	var endpoints []*domain.WebhookEndpoint
+
+	for rows.Next() {
+		endpoint, err := scanWebhookEndpoint(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook endpoint: %w", err)
+		}
+		endpoints = append(endpoints, endpoint)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating webhook endpoints: %w", err)
+	}
+
+	return endpoints, nil
+}
+
+// UpdateEndpoint updates a webhook endpoint
+func (r *WebhookRepo) UpdateEndpoint(ctx context.Context, endpoint *domain.WebhookEndpoint) error {
+	query := `
+		UPDATE webhook_endpoints
+		SET url = $1, description = $2, secret = $3, event_types = $4, is_active = $5, updated_at = $6
+		WHERE id = $7
+	`
+
+	endpoint.UpdatedAt = time.Now()
+
+	result, err := r.db.ExecContext(
+		ctx,
+		query,
+		endpoint.URL,
+		nullableString(endpoint.Description),
+		endpoint.Secret,
+		pq.Array(eventTypesOrEmpty(endpoint.EventTypes)),
+		endpoint.IsActive,
+		endpoint.UpdatedAt,
+		endpoint.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update webhook endpoint: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("%w: %s", domain.ErrWebhookNotFound, endpoint.ID)
+	}
+
+	return nil
+}
+
+// DeleteEndpoint deletes a webhook endpoint and its deliveries
+func (r *WebhookRepo) DeleteEndpoint(ctx context.Context, id uuid.UUID) error {
+	result, err := r.db.ExecContext(ctx, "DELETE FROM webhook_endpoints WHERE id = $1", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook endpoint: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("%w: %s", domain.ErrWebhookNotFound, id)
+	}
+
+	return nil
+}
+
+// DispatchEvents fans undispatched events out to the active endpoints
+// subscribed to them, oldest events first. Locked events are skipped, so
+// several instances can dispatch at once.
+func (r *WebhookRepo) DispatchEvents(ctx context.Context, limit int, now time.Time) (int, error) {
+	query := `
+		WITH batch AS (
+			SELECT id, user_id, event_type
+			FROM webhook_events
+			WHERE dispatched_at IS NULL
+			ORDER BY created_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		), fanned AS (
+			INSERT INTO webhook_deliveries (endpoint_id, event_id, status, next_attempt_at, created_at, updated_at)
+			SELECT e.id, b.id, 'pending', $2, $2, $2
+			FROM batch b
+			JOIN webhook_endpoints e ON e.user_id = b.user_id AND e.is_active
+			WHERE cardinality(e.event_types) = 0 OR b.event_type = ANY(e.event_types)
+		)
+		UPDATE webhook_events SET dispatched_at = $2
+		WHERE id IN (SELECT id FROM batch)
+	`
+
+	result, err := r.db.ExecContext(ctx, query, limit, now)
+	if err != nil {
+		return 0, fmt.Errorf("failed to dispatch webhook events: %w", err)
+	}
+
+	dispatched, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return int(dispatched), nil
+}
+
+// ClaimDeliveries leases due deliveries to active endpoints. A worker that
+// crashes mid-send leaves the lease to expire, and the delivery is retried.
+func (r *WebhookRepo) ClaimDeliveries(ctx context.Context, limit int, now, leaseUntil time.Time) ([]*domain.WebhookDelivery, error) {
+	query := `
+		WITH due AS (
+			SELECT d.id
+			FROM webhook_deliveries d
+			JOIN webhook_endpoints e ON e.id = d.endpoint_id AND e.is_active
+			WHERE d.status = 'pending' AND d.next_attempt_at <= $2
+			ORDER BY d.next_attempt_at
+			LIMIT $1
+			FOR UPDATE OF d SKIP LOCKED
+		), claimed AS (
+			UPDATE webhook_deliveries SET next_attempt_at = $3
+			WHERE id IN (SELECT id FROM due)
+			RETURNING *
+		)
+		SELECT ` + webhookDeliveryColumns + `, e.url, e.secret
+		FROM claimed d
+		JOIN webhook_events ev ON ev.id = d.event_id
+		JOIN webhook_endpoints e ON e.id = d.endpoint_id
+		ORDER BY ev.created_at
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, limit, now, leaseUntil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var deliveries []*domain.WebhookDelivery
+
+	for rows.Next() {
+		var url, secret string
+		delivery, err := scanWebhookDelivery(rows, &url, &secret)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook delivery: %w", err)
+		}
+		delivery.URL = url
+		delivery.Secret = secret
+		deliveries = append(deliveries, delivery)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating webhook deliveries: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+// RecordAttempt stores an attempt and the delivery's resulting state in one transaction
+func (r *WebhookRepo) RecordAttempt(ctx context.Context, delivery *domain.WebhookDelivery, attempt *domain.WebhookAttempt) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if attempt.ID == uuid.Nil {
+		attempt.ID = uuid.New()
+	}
+	attempt.DeliveryID = delivery.ID
+
+	_, err = tx.ExecContext(
+		ctx,
+		`INSERT INTO webhook_delivery_attempts (
+			id, delivery_id, attempt, attempted_at, duration_ms, response_status, response_body, error
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		attempt.ID,
+		attempt.DeliveryID,
+		attempt.Attempt,
+		attempt.AttemptedAt,
+		attempt.DurationMs,
+		attempt.ResponseStatus,
+		nullableString(attempt.ResponseBody),
+		nullableString(attempt.Error),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record webhook attempt: %w", err)
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		`UPDATE webhook_deliveries
+		 SET status = $1, attempts = $2, next_attempt_at = $3, last_attempt_at = $4,
+		     response_status = $5, last_error = $6
+		 WHERE id = $7`,
+		delivery.Status,
+		delivery.Attempts,
+		nullableTime(delivery.NextAttemptAt),
+		nullableTime(delivery.LastAttemptAt),
+		delivery.ResponseStatus,
+		nullableString(delivery.LastError),
+		delivery.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update webhook delivery: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit webhook attempt: %w", err)
+	}
+
+	return nil
+}
+
+// GetDelivery retrieves a delivery with its event
+func (r *WebhookRepo) GetDelivery(ctx context.Context, id uuid.UUID) (*domain.WebhookDelivery, error) {
+	query := `
+		SELECT ` + webhookDeliveryColumns + `
+		FROM webhook_deliveries d
+		JOIN webhook_events ev ON ev.id = d.event_id
+		WHERE d.id = $1
+	`
+
+	delivery, err := scanWebhookDelivery(r.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w: %s", domain.ErrWebhookDeliveryNotFound, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook delivery: %w", err)
+	}
+
+	return delivery, nil
+}
+
+// ListDeliveries retrieves an endpoint's deliveries with their events, newest first
+func (r *WebhookRepo) ListDeliveries(ctx context.Context, filter domain.WebhookDeliveryFilter) ([]*domain.WebhookDelivery, error) {
+	where, args := webhookDeliveryFilterClause(filter)
+	args = append(args, filter.Limit, filter.Offset)
+
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM webhook_deliveries d
+		JOIN webhook_events ev ON ev.id = d.event_id
+		WHERE %s
+		ORDER BY d.created_at DESC, d.id
+		LIMIT $%d OFFSET $%d
+	`, webhookDeliveryColumns, where, len(args)-1, len(args))
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var deliveries []*domain.WebhookDelivery
+
+	for rows.Next() {
+		delivery, err := scanWebhookDelivery(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook delivery: %w", err)
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating webhook deliveries: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+// CountDeliveries returns the number of deliveries matching the filter
+func (r *WebhookRepo) CountDeliveries(ctx context.Context, filter domain.WebhookDeliveryFilter) (int, error) {
+	where, args := webhookDeliveryFilterClause(filter)
+
+	query := `
+		SELECT COUNT(*)
+		FROM webhook_deliveries d
+		JOIN webhook_events ev ON ev.id = d.event_id
+		WHERE ` + where
+
+	var count int
+	if err := r.db.QueryRowContext(ctx, query, args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count webhook deliveries: %w", err)
+	}
+
+	return count, nil
+}
+
+// ListAttempts retrieves a delivery's attempts, earliest first
+func (r *WebhookRepo) ListAttempts(ctx context.Context, deliveryID uuid.UUID) ([]*domain.WebhookAttempt, error) {
+	query := `
+		SELECT id, delivery_id, attempt, attempted_at, duration_ms, response_status, response_body, error
+		FROM webhook_delivery_attempts
+		WHERE delivery_id = $1
+		ORDER BY attempt
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, deliveryID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query webhook attempts: %w", err)
+	}
+	defer rows.Close()
+
+	var attempts []*domain.WebhookAttempt
+
+	for rows.Next() {
+		attempt := &domain.WebhookAttempt{}
+		var status sql.NullInt64
+		var body, attemptError sql.NullString
+
+		err := rows.Scan(
+			&attempt.ID,
+			&attempt.DeliveryID,
+			&attempt.Attempt,
+			&attempt.AttemptedAt,
+			&attempt.DurationMs,
+			&status,
+			&body,
+			&attemptError,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook attempt: %w", err)
+		}
+
+		attempt.ResponseStatus = intOrNil(status)
+		attempt.ResponseBody = body.String
+		attempt.Error = attemptError.String
+		attempts = append(attempts, attempt)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating webhook attempts: %w", err)
+	}
+
+	return attempts, nil
+}
+
+// Replay creates a pending delivery of the same event to the same endpoint,
+// due immediately and linked to the delivery it replays
+func (r *WebhookRepo) Replay(ctx context.Context, deliveryID uuid.UUID, now time.Time) (*domain.WebhookDelivery, error) {
+	query := `
+		WITH replayed AS (
+			INSERT INTO webhook_deliveries (endpoint_id, event_id, status, next_attempt_at, replay_of, created_at, updated_at)
+			SELECT endpoint_id, event_id, 'pending', $2, id, $2, $2
+			FROM webhook_deliveries
+			WHERE id = $1
+			RETURNING *
+		)
+		SELECT ` + webhookDeliveryColumns + `
+		FROM replayed d
+		JOIN webhook_events ev ON ev.id = d.event_id
+	`
+
+	delivery, err := scanWebhookDelivery(r.db.QueryRowContext(ctx, query, deliveryID, now))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w: %s", domain.ErrWebhookDeliveryNotFound, deliveryID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to replay webhook delivery: %w", err)
+	}
+
+	return delivery, nil
+}
+
+// enqueueEvent writes an event to the outbox within the caller's transaction,
+// so the event exists exactly when the change it describes commits
+func enqueueEvent(ctx context.Context, ex execer, event *domain.WebhookEvent) error {
+	_, err := ex.ExecContext(
+		ctx,
+		`INSERT INTO webhook_events (id, user_id, event_type, payload, created_at)
+		 VALUES ($1, $2, $3, $4, $5)`,
+		event.ID,
+		event.UserID,
+		event.Type,
+		FromMap(event.Payload),
+		event.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to enqueue %s event: %w", event.Type, err)
+	}
+
+	return nil
+}
+
+// webhookDeliveryFilterClause builds the WHERE conditions of a delivery filter
+func webhookDeliveryFilterClause(filter domain.WebhookDeliveryFilter) (string, []interface{}) {
+	conditions := []string{"d.endpoint_id = $1"}
+	args := []interface{}{filter.EndpointID}
+
+	if filter.Status != "" {
+		args = append(args, filter.Status)
+		conditions = append(conditions, fmt.Sprintf("d.status = $%d", len(args)))
+	}
+	if filter.EventType != "" {
+		args = append(args, filter.EventType)
+		conditions = append(conditions, fmt.Sprintf("ev.event_type = $%d", len(args)))
+	}
+
+	return strings.Join(conditions, " AND "), args
+}
+
+// scanWebhookEndpoint scans a row selected with webhookEndpointColumns
+func scanWebhookEndpoint(row rowScanner) (*domain.WebhookEndpoint, error) {
+	endpoint := &domain.WebhookEndpoint{}
+	var description sql.NullString
+	var eventTypes pq.StringArray
+
+	err := row.Scan(
+		&endpoint.ID,
+		&endpoint.UserID,
+		&endpoint.URL,
+		&description,
+		&endpoint.Secret,
+		&eventTypes,
+		&endpoint.IsActive,
+		&endpoint.CreatedAt,
+		&endpoint.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint.Description = description.String
+	endpoint.EventTypes = []string(eventTypes)
+
+	return endpoint, nil
+}
+
+// scanWebhookDelivery scans a row selected with webhookDeliveryColumns,
+// followed by any extra columns
+func scanWebhookDelivery(row rowScanner, extra ...interface{}) (*domain.WebhookDelivery, error) {
+	delivery := &domain.WebhookDelivery{Event: &domain.WebhookEvent{}}
+	var nextAttemptAt, lastAttemptAt, dispatchedAt sql.NullTime
+	var responseStatus sql.NullInt64
+	var lastError sql.NullString
+	var replayOf uuid.NullUUID
+	var payload JSONB
+
+	dest := []interface{}{
+		&delivery.ID,
+		&delivery.EndpointID,
+		&delivery.Status,
+		&delivery.Attempts,
+		&nextAttemptAt,
+		&lastAttemptAt,
+		&responseStatus,
+		&lastError,
+		&replayOf,
+		&delivery.CreatedAt,
+		&delivery.UpdatedAt,
+		&delivery.Event.ID,
+		&delivery.Event.UserID,
+		&delivery.Event.Type,
+		&payload,
+		&delivery.Event.CreatedAt,
+		&dispatchedAt,
+	}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return nil, err
+	}
+
+	delivery.NextAttemptAt = timeOrNil(nextAttemptAt)
+	delivery.LastAttemptAt = timeOrNil(lastAttemptAt)
+	delivery.ResponseStatus = intOrNil(responseStatus)
+	delivery.LastError = lastError.String
+	delivery.ReplayOf = uuidOrNil(replayOf)
+	delivery.Event.Payload = payload.ToMap()
+	delivery.Event.DispatchedAt = timeOrNil(dispatchedAt)
+
+	return delivery, nil
+}
+
+// intOrNil converts a nullable integer column to a pointer
+func intOrNil(n sql.NullInt64) *int {
+	if !n.Valid {
+		return nil
+	}
+	v := int(n.Int64)
+	return &v
+}
+
+// eventTypesOrEmpty stores an empty subscription as '{}' rather than NULL
+func eventTypesOrEmpty(eventTypes []string) []string {
+	if eventTypes == nil {
+		return []string{}
+	}
+	return eventTypes
+}
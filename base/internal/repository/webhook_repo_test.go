@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/saintparish4/harmonia/internal/domain"
+)
+
+func TestWebhookDeliveryFilterClause(t *testing.T) {
+	endpointID := uuid.New()
+
+	tests := []struct {
+		name      string
+		filter    domain.WebhookDeliveryFilter
+		wantQuery string
+		wantArgs  []interface{}
+	}{
+		{
+			name:      "endpoint only",
+			filter:    domain.WebhookDeliveryFilter{EndpointID: endpointID},
+			wantQuery: "d.endpoint_id = $1",
+			wantArgs:  []interface{}{endpointID},
+		},
+		{
+			name:      "status and event type",
+			filter:    domain.WebhookDeliveryFilter{EndpointID: endpointID, Status: "failed", EventType: "rule.created"},
+			wantQuery: "d.endpoint_id = $1 AND d.status = $2 AND ev.event_type = $3",
+			wantArgs:  []interface{}{endpointID, "failed", "rule.created"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, args := webhookDeliveryFilterClause(tt.filter)
+			if query != tt.wantQuery {
+				t.Errorf("expected query %q, got %q", tt.wantQuery, query)
+			}
+			if fmt.Sprint(args) != fmt.Sprint(tt.wantArgs) {
+				t.Errorf("expected args %v, got %v", tt.wantArgs, args)
+			}
+		})
+	}
+}
@@ -0,0 +1,477 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/saintparish4/harmonia/internal/domain"
+)
+
+const (
+	// backtestBatchSize is the number of logs fetched per query
+	backtestBatchSize = 500
+
+	// backtestSyncLimit is the largest log count replayed inline; larger ranges run in the background
+	backtestSyncLimit = 1000
+
+	// backtestTopChanges is the number of largest increases/decreases kept in a report
+	backtestTopChanges = 10
+
+	// backtestMaxErrors is the number of error samples kept in a report
+	backtestMaxErrors = 20
+
+	// backtestRetention is how long finished jobs are kept in memory
+	backtestRetention = time.Hour
+
+	// backtestMaxRunning is the number of background jobs replayed at once
+	backtestMaxRunning = 4
+)
+
+// BacktestService replays calculation logs through the engine with a proposed config.
+// Jobs are kept in memory, so they do not survive a restart.
+type BacktestService struct {
+	engine *PricingEngine
+	logs   domain.CalculationLogRepository
+
+	mu   sync.Mutex
+	jobs map[uuid.UUID]*backtestJob
+
+	// Background jobs run on ctx, which Stop cancels, and hold a slot while running
+	ctx     context.Context
+	stop    context.CancelFunc
+	slots   chan struct{}
+	running sync.WaitGroup
+}
+
+// backtestJob guards a job's state and notifies watchers when it changes
+type backtestJob struct {
+	mu      sync.Mutex
+	job     domain.BacktestJob
+	changed chan struct{}
+}
+
+// NewBacktestService creates a new backtest service
+func NewBacktestService(engine *PricingEngine, logs domain.CalculationLogRepository) *BacktestService {
+	ctx, stop := context.WithCancel(context.Background())
+	return &BacktestService{
+		engine: engine,
+		logs:   logs,
+		jobs:   make(map[uuid.UUID]*backtestJob),
+		ctx:    ctx,
+		stop:   stop,
+		slots:  make(chan struct{}, backtestMaxRunning),
+	}
+}
+
+// Start validates a backtest and runs it. Small ranges are replayed before
+// returning; larger ones return a pending job that continues in the background.
+// Returns ErrBacktestBusy if backtestMaxRunning background jobs are running.
+func (s *BacktestService) Start(ctx context.Context, req domain.BacktestRequest) (*domain.BacktestJob, error) {
+	if err := s.engine.ValidateConfig(req.StrategyType, req.Config); err != nil {
+		return nil, err
+	}
+	if req.To.IsZero() {
+		req.To = time.Now()
+	}
+	if !req.From.IsZero() && req.From.After(req.To) {
+		return nil, fmt.Errorf("%w: from must be before to", domain.ErrBacktestInvalid)
+	}
+
+	filter := backtestFilter(req)
+	total, err := s.logs.Count(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count calculation logs: %w", err)
+	}
+
+	background := total > backtestSyncLimit
+	if background {
+		select {
+		case s.slots <- struct{}{}:
+		default:
+			return nil, domain.ErrBacktestBusy
+		}
+	}
+
+	job := &backtestJob{
+		job: domain.BacktestJob{
+			ID:        uuid.New(),
+			Request:   req,
+			Status:    domain.BacktestStatusPending,
+			Progress:  domain.BacktestProgress{Total: total},
+			CreatedAt: time.Now(),
+		},
+		changed: make(chan struct{}),
+	}
+	s.register(job)
+
+	if !background {
+		s.run(ctx, job)
+	} else {
+		// The request context ends with the response, so background jobs run
+		// on the service's context until Stop
+		s.running.Add(1)
+		go func() {
+			defer s.running.Done()
+			defer func() { <-s.slots }()
+			s.run(s.ctx, job)
+		}()
+	}
+
+	snapshot, _ := job.snapshot()
+	return &snapshot, nil
+}
+
+// Stop cancels background jobs, which finish as failed, and waits for them
+func (s *BacktestService) Stop() {
+	s.stop()
+	s.running.Wait()
+}
+
+// Get returns a backtest job owned by the user
+func (s *BacktestService) Get(userID, id uuid.UUID) (*domain.BacktestJob, error) {
+	job, err := s.lookup(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	snapshot, _ := job.snapshot()
+	return &snapshot, nil
+}
+
+// Watch streams snapshots of a job as it progresses. The channel receives the
+// current state immediately and is closed once the job finishes or ctx ends.
+func (s *BacktestService) Watch(ctx context.Context, userID, id uuid.UUID) (<-chan domain.BacktestJob, error) {
+	job, err := s.lookup(userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	updates := make(chan domain.BacktestJob)
+	go func() {
+		defer close(updates)
+		for {
+			snapshot, changed := job.snapshot()
+			select {
+			case updates <- snapshot:
+			case <-ctx.Done():
+				return
+			}
+			if snapshot.Status.Done() {
+				return
+			}
+			select {
+			case <-changed:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return updates, nil
+}
+
+// register stores a job and drops finished jobs past their retention
+func (s *BacktestService) register(job *backtestJob) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cutoff := time.Now().Add(-backtestRetention)
+	for id, existing := range s.jobs {
+		snapshot, _ := existing.snapshot()
+		if snapshot.CompletedAt != nil && snapshot.CompletedAt.Before(cutoff) {
+			delete(s.jobs, id)
+		}
+	}
+
+	s.jobs[job.job.ID] = job
+}
+
+// lookup finds a job, hiding jobs that belong to other users
+func (s *BacktestService) lookup(userID, id uuid.UUID) (*backtestJob, error) {
+	s.mu.Lock()
+	job, exists := s.jobs[id]
+	s.mu.Unlock()
+
+	if !exists || job.job.Request.UserID != userID {
+		return nil, fmt.Errorf("%w: %s", domain.ErrBacktestNotFound, id)
+	}
+	return job, nil
+}
+
+// run replays every matching log in batches, publishing progress after each
+func (s *BacktestService) run(ctx context.Context, job *backtestJob) {
+	started := time.Now()
+	job.update(func(j *domain.BacktestJob) {
+		j.Status = domain.BacktestStatusRunning
+		j.StartedAt = &started
+	})
+
+	req := job.job.Request
+	acc := newBacktestAccumulator()
+	filter := backtestFilter(req)
+	filter.Limit = backtestBatchSize
+
+	var runErr error
+	processed := 0
+	for {
+		logs, err := s.logs.List(ctx, filter)
+		if err != nil {
+			runErr = fmt.Errorf("failed to list calculation logs: %w", err)
+			break
+		}
+
+		for _, log := range logs {
+			s.replay(acc, req, log)
+		}
+
+		processed += len(logs)
+		job.update(func(j *domain.BacktestJob) {
+			j.Progress.Processed = processed
+			if processed > j.Progress.Total {
+				j.Progress.Total = processed
+			}
+		})
+
+		if len(logs) < backtestBatchSize {
+			break
+		}
+		last := logs[len(logs)-1]
+		filter.After = &domain.Cursor{CreatedAt: last.CreatedAt, ID: last.ID}
+	}
+
+	completed := time.Now()
+	job.update(func(j *domain.BacktestJob) {
+		j.CompletedAt = &completed
+		if runErr != nil {
+			j.Status = domain.BacktestStatusFailed
+			j.Error = runErr.Error()
+			return
+		}
+		j.Status = domain.BacktestStatusCompleted
+		j.Report = acc.report()
+	})
+}
+
+// replay recalculates a single log with the proposed config
+func (s *BacktestService) replay(acc *backtestAccumulator, req domain.BacktestRequest, log *domain.CalculationLog) {
+	oldPrice, hasPrice := PriceFromLog(log.OutputData)
+	inputs, hasInputs := InputsFromLog(log.InputData)
+	if !hasPrice || !hasInputs {
+		acc.skip()
+		return
+	}
+
+	pricingReq := &domain.PricingRequest{
+		Strategy:    req.StrategyType,
+		RuleID:      req.RuleID,
+		Inputs:      inputs,
+		RequestedAt: RequestedAtFromLog(log),
+	}
+
+	response, err := s.engine.Calculate(pricingReq, req.Config)
+	if err != nil {
+		acc.addError(log, err)
+		return
+	}
+	acc.add(log, oldPrice, response.FinalPrice)
+}
+
+// backtestFilter selects the logs a backtest replays
+func backtestFilter(req domain.BacktestRequest) domain.CalculationLogFilter {
+	filter := domain.CalculationLogFilter{
+		UserID: req.UserID,
+		RuleID: req.RuleID,
+		From:   req.From,
+		To:     req.To,
+	}
+	if req.RuleID == nil {
+		filter.StrategyType = req.StrategyType
+	}
+	return filter
+}
+
+// snapshot returns a copy of the job and a channel closed on the next change
+func (j *backtestJob) snapshot() (domain.BacktestJob, <-chan struct{}) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.job, j.changed
+}
+
+// update applies fn to the job and wakes watchers
+func (j *backtestJob) update(fn func(*domain.BacktestJob)) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	fn(&j.job)
+	close(j.changed)
+	j.changed = make(chan struct{})
+}
+
+// backtestBucketEdges are the percent-change boundaries of the report distribution
+var backtestBucketEdges = []float64{-20, -5, 0, 5, 20}
+
+// backtestAccumulator aggregates replay results into a report
+type backtestAccumulator struct {
+	summary      domain.BacktestReport
+	unchanged    int // Index of the unchanged bucket in the distribution
+	sumChange    float64
+	sumPercent   float64
+	percentCount int
+}
+
+func newBacktestAccumulator() *backtestAccumulator {
+	acc := &backtestAccumulator{}
+	acc.summary.Distribution, acc.unchanged = newBacktestBuckets()
+	acc.summary.LargestIncreases = []domain.BacktestChange{}
+	acc.summary.LargestDecreases = []domain.BacktestChange{}
+	acc.summary.Errors = []domain.BacktestError{}
+	return acc
+}
+
+// newBacktestBuckets builds the distribution from backtestBucketEdges, with a
+// separate bucket for unchanged prices at zero. Returns the buckets and the
+// index of the unchanged bucket.
+func newBacktestBuckets() ([]domain.BacktestBucket, int) {
+	buckets := make([]domain.BacktestBucket, 0, len(backtestBucketEdges)+2)
+	unchanged := -1
+
+	var lower *float64
+	for i := range backtestBucketEdges {
+		upper := &backtestBucketEdges[i]
+		if lower != nil && *lower == 0 {
+			unchanged = len(buckets)
+			buckets = append(buckets, domain.BacktestBucket{Label: "unchanged", Min: lower, Max: lower})
+		}
+		buckets = append(buckets, domain.BacktestBucket{Label: bucketLabel(lower, upper), Min: lower, Max: upper})
+		lower = upper
+	}
+	buckets = append(buckets, domain.BacktestBucket{Label: bucketLabel(lower, nil), Min: lower})
+
+	return buckets, unchanged
+}
+
+func bucketLabel(lower, upper *float64) string {
+	switch {
+	case lower == nil:
+		return fmt.Sprintf("< %g%%", *upper)
+	case upper == nil:
+		return fmt.Sprintf(">= %g%%", *lower)
+	default:
+		return fmt.Sprintf("%g%% to %g%%", *lower, *upper)
+	}
+}
+
+func (a *backtestAccumulator) skip() {
+	a.summary.Skipped++
+}
+
+func (a *backtestAccumulator) addError(log *domain.CalculationLog, err error) {
+	a.summary.Replayed++
+	a.summary.NewErrors++
+	if len(a.summary.Errors) < backtestMaxErrors {
+		a.summary.Errors = append(a.summary.Errors, domain.BacktestError{
+			LogID:     log.ID,
+			Error:     err.Error(),
+			CreatedAt: log.CreatedAt,
+		})
+	}
+}
+
+func (a *backtestAccumulator) add(log *domain.CalculationLog, oldPrice, newPrice float64) {
+	r := &a.summary
+	r.Replayed++
+	r.OldRevenue += oldPrice
+	r.NewRevenue += newPrice
+
+	change := math.Round((newPrice-oldPrice)*100) / 100
+	a.sumChange += change
+
+	var percent *float64
+	if oldPrice != 0 {
+		p := math.Round(change/oldPrice*10000) / 100
+		percent = &p
+		a.sumPercent += p
+		a.percentCount++
+	}
+
+	a.bucket(oldPrice, change)
+
+	if change == 0 {
+		r.Unchanged++
+		return
+	}
+
+	r.Changed++
+	entry := domain.BacktestChange{
+		LogID:         log.ID,
+		OldPrice:      oldPrice,
+		NewPrice:      newPrice,
+		Change:        change,
+		ChangePercent: percent,
+		CreatedAt:     log.CreatedAt,
+	}
+	if change > 0 {
+		r.Increased++
+		r.LargestIncreases = insertTopChange(r.LargestIncreases, entry, func(a, b float64) bool { return a > b })
+	} else {
+		r.Decreased++
+		r.LargestDecreases = insertTopChange(r.LargestDecreases, entry, func(a, b float64) bool { return a < b })
+	}
+}
+
+// bucket counts a change in the distribution; changes from a zero price use the sign only
+func (a *backtestAccumulator) bucket(oldPrice, change float64) {
+	buckets := a.summary.Distribution
+	if change == 0 {
+		buckets[a.unchanged].Count++
+		return
+	}
+
+	value := math.Copysign(math.Inf(1), change)
+	if oldPrice != 0 {
+		value = change / oldPrice * 100
+	}
+	for i := range buckets {
+		b := &buckets[i]
+		if i == a.unchanged {
+			continue
+		}
+		if (b.Min == nil || value >= *b.Min) && (b.Max == nil || value < *b.Max) {
+			b.Count++
+			return
+		}
+	}
+}
+
+// insertTopChange keeps the backtestTopChanges entries ranked first by less
+func insertTopChange(list []domain.BacktestChange, entry domain.BacktestChange, less func(a, b float64) bool) []domain.BacktestChange {
+	idx := sort.Search(len(list), func(i int) bool { return less(entry.Change, list[i].Change) })
+	if idx >= backtestTopChanges {
+		return list
+	}
+	list = append(list, domain.BacktestChange{})
+	copy(list[idx+1:], list[idx:])
+	list[idx] = entry
+	if len(list) > backtestTopChanges {
+		list = list[:backtestTopChanges]
+	}
+	return list
+}
+
+// report finalises the aggregated statistics
+func (a *backtestAccumulator) report() *domain.BacktestReport {
+	r := a.summary
+	priced := r.Replayed - r.NewErrors
+	if priced > 0 {
+		r.MeanChange = math.Round(a.sumChange/float64(priced)*100) / 100
+	}
+	if a.percentCount > 0 {
+		r.MeanChangePercent = math.Round(a.sumPercent/float64(a.percentCount)*100) / 100
+	}
+	r.OldRevenue = domain.RoundToTwoDecimals(r.OldRevenue)
+	r.NewRevenue = domain.RoundToTwoDecimals(r.NewRevenue)
+	return &r
+}
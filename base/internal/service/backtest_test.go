@@ -0,0 +1,252 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/saintparish4/harmonia/internal/domain"
+)
+
+// fakeLogRepo serves calculation logs from memory for backtests
+type fakeLogRepo struct {
+	domain.CalculationLogRepository
+	logs []*domain.CalculationLog
+}
+
+func (r *fakeLogRepo) matching(filter domain.CalculationLogFilter) []*domain.CalculationLog {
+	var out []*domain.CalculationLog
+	for _, log := range r.logs {
+		if log.UserID != filter.UserID {
+			continue
+		}
+		if filter.StrategyType != "" && log.StrategyType != filter.StrategyType {
+			continue
+		}
+		out = append(out, log)
+	}
+	return out
+}
+
+// List serves logs in the order given, continuing after filter.After
+func (r *fakeLogRepo) List(ctx context.Context, filter domain.CalculationLogFilter) ([]*domain.CalculationLog, error) {
+	logs := r.matching(filter)
+	if filter.After != nil {
+		for i, log := range logs {
+			if log.ID == filter.After.ID {
+				logs = logs[i+1:]
+				break
+			}
+		}
+	}
+	if filter.Limit > 0 && filter.Limit < len(logs) {
+		logs = logs[:filter.Limit]
+	}
+	return logs, nil
+}
+
+func (r *fakeLogRepo) Count(ctx context.Context, filter domain.CalculationLogFilter) (int, error) {
+	return len(r.matching(filter)), nil
+}
+
+func costPlusLog(userID uuid.UUID, baseCost, finalPrice float64) *domain.CalculationLog {
+	return &domain.CalculationLog{
+		ID:           uuid.New(),
+		UserID:       userID,
+		StrategyType: domain.StrategyTypeCostPlus,
+		InputData: map[string]interface{}{
+			"strategy_type": domain.StrategyTypeCostPlus,
+			"base_price":    baseCost,
+			"quantity":      1.0,
+			"context":       map[string]interface{}{},
+		},
+		OutputData: map[string]interface{}{"final_price": finalPrice},
+		CreatedAt:  time.Now().Add(-time.Hour),
+	}
+}
+
+func TestBacktestService_Start(t *testing.T) {
+	userID := uuid.New()
+	repo := &fakeLogRepo{logs: []*domain.CalculationLog{
+		costPlusLog(userID, 100, 120),     // 20% markup in the log, 25% proposed
+		costPlusLog(userID, 10, 12.5),     // already at the new price
+		costPlusLog(userID, 40, 60),       // 50% markup in the log
+		costPlusLog(uuid.New(), 100, 120), // another user's log
+		{ID: uuid.New(), UserID: userID, StrategyType: domain.StrategyTypeCostPlus}, // nothing to replay
+	}}
+
+	service := NewBacktestService(NewPricingEngine(), repo)
+	job, err := service.Start(context.Background(), domain.BacktestRequest{
+		UserID:       userID,
+		StrategyType: domain.StrategyTypeCostPlus,
+		Config:       map[string]interface{}{"markup_value": 25.0},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if job.Status != domain.BacktestStatusCompleted {
+		t.Fatalf("expected small backtest to complete inline, got %s", job.Status)
+	}
+	if job.Progress.Processed != 4 || job.Progress.Total != 4 {
+		t.Errorf("expected 4/4 processed, got %+v", job.Progress)
+	}
+
+	report := job.Report
+	if report.Replayed != 3 || report.Skipped != 1 {
+		t.Errorf("expected 3 replayed and 1 skipped, got %d and %d", report.Replayed, report.Skipped)
+	}
+	if report.Unchanged != 1 || report.Increased != 1 || report.Decreased != 1 {
+		t.Errorf("expected 1 unchanged, 1 increased, 1 decreased, got %+v", report)
+	}
+	if len(report.LargestIncreases) != 1 || report.LargestIncreases[0].Change != 5 {
+		t.Errorf("expected largest increase of 5, got %+v", report.LargestIncreases)
+	}
+	if len(report.LargestDecreases) != 1 || report.LargestDecreases[0].Change != -10 {
+		t.Errorf("expected largest decrease of -10, got %+v", report.LargestDecreases)
+	}
+
+	counts := make(map[string]int)
+	for _, bucket := range report.Distribution {
+		counts[bucket.Label] = bucket.Count
+	}
+	if counts["unchanged"] != 1 || counts["-20% to -5%"] != 1 || counts[">= 20%"] != 0 || counts["0% to 5%"] != 1 {
+		t.Errorf("unexpected distribution %v", counts)
+	}
+
+	// Jobs are only visible to their owner
+	if _, err := service.Get(uuid.New(), job.ID); !errors.Is(err, domain.ErrBacktestNotFound) {
+		t.Errorf("expected ErrBacktestNotFound for another user, got %v", err)
+	}
+}
+
+func TestBacktestService_Start_InvalidConfig(t *testing.T) {
+	service := NewBacktestService(NewPricingEngine(), &fakeLogRepo{})
+
+	_, err := service.Start(context.Background(), domain.BacktestRequest{
+		UserID:       uuid.New(),
+		StrategyType: domain.StrategyTypeCostPlus,
+		Config:       map[string]interface{}{"markup_value": -5.0},
+	})
+	if !errors.Is(err, domain.ErrConfigurationInvalid) {
+		t.Errorf("expected ErrConfigurationInvalid, got %v", err)
+	}
+}
+
+func TestBacktestService_Watch(t *testing.T) {
+	userID := uuid.New()
+	repo := &fakeLogRepo{logs: []*domain.CalculationLog{costPlusLog(userID, 100, 120)}}
+	service := NewBacktestService(NewPricingEngine(), repo)
+
+	job, err := service.Start(context.Background(), domain.BacktestRequest{
+		UserID:       userID,
+		StrategyType: domain.StrategyTypeCostPlus,
+		Config:       map[string]interface{}{"markup_value": 20.0},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	updates, err := service.Watch(context.Background(), userID, job.ID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var last domain.BacktestJob
+	for update := range updates {
+		last = update
+	}
+	if last.Status != domain.BacktestStatusCompleted || last.Report.Unchanged != 1 {
+		t.Errorf("expected final completed snapshot, got %+v", last)
+	}
+}
+
+func TestBacktestService_Start_Background(t *testing.T) {
+	userID := uuid.New()
+	repo := &fakeLogRepo{}
+	for i := 0; i < backtestSyncLimit+backtestBatchSize/2; i++ {
+		log := costPlusLog(userID, 100, 120)
+		log.CreatedAt = log.CreatedAt.Add(-time.Duration(i) * time.Second)
+		repo.logs = append(repo.logs, log)
+	}
+	service := NewBacktestService(NewPricingEngine(), repo)
+	defer service.Stop()
+
+	job, err := service.Start(context.Background(), domain.BacktestRequest{
+		UserID:       userID,
+		StrategyType: domain.StrategyTypeCostPlus,
+		Config:       map[string]interface{}{"markup_value": 20.0},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if job.Status.Done() {
+		t.Fatalf("expected a large backtest to continue in the background, got %s", job.Status)
+	}
+
+	updates, err := service.Watch(context.Background(), userID, job.ID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var last domain.BacktestJob
+	for update := range updates {
+		last = update
+	}
+
+	// Every batch continues after the last log of the one before
+	want := len(repo.logs)
+	if last.Status != domain.BacktestStatusCompleted || last.Progress.Processed != want || last.Report.Replayed != want {
+		t.Errorf("expected all %d logs replayed once, got %s with %+v", want, last.Status, last.Progress)
+	}
+}
+
+// blockingLogRepo reports a large range and blocks listing it until ctx ends
+type blockingLogRepo struct {
+	fakeLogRepo
+}
+
+func (r *blockingLogRepo) Count(ctx context.Context, filter domain.CalculationLogFilter) (int, error) {
+	return backtestSyncLimit + 1, nil
+}
+
+func (r *blockingLogRepo) List(ctx context.Context, filter domain.CalculationLogFilter) ([]*domain.CalculationLog, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestBacktestService_Start_Busy(t *testing.T) {
+	userID := uuid.New()
+	service := NewBacktestService(NewPricingEngine(), &blockingLogRepo{})
+	req := domain.BacktestRequest{
+		UserID:       userID,
+		StrategyType: domain.StrategyTypeCostPlus,
+		Config:       map[string]interface{}{"markup_value": 20.0},
+	}
+
+	var jobs []*domain.BacktestJob
+	for i := 0; i < backtestMaxRunning; i++ {
+		job, err := service.Start(context.Background(), req)
+		if err != nil {
+			t.Fatalf("unexpected error starting job %d: %v", i, err)
+		}
+		jobs = append(jobs, job)
+	}
+
+	if _, err := service.Start(context.Background(), req); !errors.Is(err, domain.ErrBacktestBusy) {
+		t.Errorf("expected ErrBacktestBusy once %d jobs are running, got %v", backtestMaxRunning, err)
+	}
+
+	// Stopping the service cancels the running jobs
+	service.Stop()
+	for _, job := range jobs {
+		got, err := service.Get(userID, job.ID)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got.Status != domain.BacktestStatusFailed {
+			t.Errorf("expected a stopped job to fail, got %s", got.Status)
+		}
+	}
+}
@@ -0,0 +1,493 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/saintparish4/harmonia/internal/domain"
+)
+
+// BundleService exports a user's rules as a portable bundle and imports bundles
+type BundleService struct {
+	engine   *PricingEngine
+	rules    domain.PricingRuleRepository
+	products domain.ProductRepository
+	users    domain.UserRepository
+	bundles  domain.BundleRepository
+}
+
+// NewBundleService creates a new bundle service
+func NewBundleService(engine *PricingEngine, rules domain.PricingRuleRepository, products domain.ProductRepository, users domain.UserRepository, bundles domain.BundleRepository) *BundleService {
+	return &BundleService{
+		engine:   engine,
+		rules:    rules,
+		products: products,
+		users:    users,
+		bundles:  bundles,
+	}
+}
+
+// Export returns the user's published rules and, if requested, their products.
+// Rules are keyed by external key, or by ID when they have none; product links
+// to rules outside the bundle are left out.
+func (s *BundleService) Export(ctx context.Context, userID uuid.UUID, includeProducts bool) (*domain.Bundle, error) {
+	rules, err := s.rules.List(ctx, domain.PricingRuleFilter{UserID: userID})
+	if err != nil {
+		return nil, err
+	}
+
+	bundle := &domain.Bundle{
+		Version:    domain.BundleVersion,
+		ExportedAt: time.Now().UTC(),
+		Rules:      []domain.BundleRule{},
+	}
+
+	keys := make(map[uuid.UUID]string, len(rules))
+	for _, rule := range rules {
+		if rule.PublishedAt == nil {
+			continue
+		}
+		key := ruleKey(rule)
+		keys[rule.ID] = key
+		bundle.Rules = append(bundle.Rules, domain.BundleRule{
+			Key:           key,
+			Name:          rule.Name,
+			Description:   rule.Description,
+			StrategyType:  rule.StrategyType,
+			Config:        rule.Config,
+			IsActive:      rule.IsActive,
+			EffectiveFrom: rule.EffectiveFrom,
+			EffectiveTo:   rule.EffectiveTo,
+		})
+	}
+
+	if !includeProducts {
+		return bundle, nil
+	}
+
+	products, err := s.products.List(ctx, domain.ProductFilter{UserID: userID})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, product := range products {
+		entry := domain.BundleProduct{
+			SKU:         product.SKU,
+			Name:        product.Name,
+			Description: product.Description,
+			BaseCost:    product.BaseCost,
+			Metadata:    product.Metadata,
+			IsActive:    product.IsActive,
+		}
+		if product.DefaultRuleID != nil {
+			entry.DefaultRule = keys[*product.DefaultRuleID]
+		}
+
+		assignments, err := s.products.ListRuleAssignments(ctx, product.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, assignment := range assignments {
+			key, ok := keys[assignment.RuleID]
+			if !ok {
+				continue
+			}
+			entry.Schedule = append(entry.Schedule, domain.BundleAssignment{
+				Rule:          key,
+				EffectiveFrom: assignment.EffectiveFrom,
+				EffectiveTo:   assignment.EffectiveTo,
+			})
+		}
+
+		bundle.Products = append(bundle.Products, entry)
+	}
+
+	return bundle, nil
+}
+
+// Import validates a bundle against the user's account and, unless it is a dry
+// run or anything conflicts or fails validation, applies it in one transaction.
+// Publishing directly is refused when the account requires draft approval.
+func (s *BundleService) Import(ctx context.Context, req domain.ImportRequest) (*domain.ImportReport, error) {
+	opts := req.Options
+	if opts.MatchBy == "" {
+		opts.MatchBy = domain.BundleMatchKey
+	}
+	if opts.Mode == "" {
+		opts.Mode = domain.ImportModeCreate
+	}
+	if opts.MatchBy != domain.BundleMatchKey && opts.MatchBy != domain.BundleMatchName {
+		return nil, fmt.Errorf("%w: match_by must be one of: key, name", domain.ErrInvalidImportOptions)
+	}
+	if opts.Mode != domain.ImportModeCreate && opts.Mode != domain.ImportModeUpsert {
+		return nil, fmt.Errorf("%w: mode must be one of: create, upsert", domain.ErrInvalidImportOptions)
+	}
+	if req.Bundle == nil || req.Bundle.Version != domain.BundleVersion {
+		return nil, fmt.Errorf("%w: expected version %d", domain.ErrUnsupportedBundleVersion, domain.BundleVersion)
+	}
+
+	if opts.Publish {
+		account, err := s.users.GetByID(ctx, req.Approval.UserID)
+		if err != nil {
+			return nil, err
+		}
+		if account.RequireRuleApproval {
+			return nil, domain.ErrSelfApprovalForbidden
+		}
+	}
+
+	planner := &importPlanner{
+		service: s,
+		opts:    opts,
+		report: &domain.ImportReport{
+			DryRun:    opts.DryRun,
+			Items:     []domain.ImportItem{},
+			Conflicts: []domain.ImportConflict{},
+			Errors:    []domain.FieldError{},
+			Summary:   map[string]int{},
+		},
+		plan: &domain.ImportPlan{
+			UserID:   req.Approval.UserID,
+			Approval: req.Approval,
+			Publish:  opts.Publish,
+		},
+		ruleIDs: map[string]uuid.UUID{},
+	}
+
+	if err := planner.planRules(ctx, req.Bundle.Rules); err != nil {
+		return nil, err
+	}
+	if err := planner.planProducts(ctx, req.Bundle.Products); err != nil {
+		return nil, err
+	}
+
+	report := planner.report
+	if report.Rejected() || opts.DryRun {
+		return report, nil
+	}
+
+	if err := s.bundles.ApplyImport(ctx, planner.plan); err != nil {
+		return nil, fmt.Errorf("failed to apply import: %w", err)
+	}
+	report.Applied = true
+	report.Published = opts.Publish
+
+	return report, nil
+}
+
+// importPlanner matches bundle entries to the account and records the outcome
+type importPlanner struct {
+	service *BundleService
+	opts    domain.ImportOptions
+	report  *domain.ImportReport
+	plan    *domain.ImportPlan
+
+	// ruleIDs maps bundle rule keys to the rule each one will be written to
+	ruleIDs map[string]uuid.UUID
+}
+
+func (p *importPlanner) planRules(ctx context.Context, entries []domain.BundleRule) error {
+	existing, err := p.service.rules.List(ctx, domain.PricingRuleFilter{UserID: p.plan.UserID})
+	if err != nil {
+		return err
+	}
+
+	for i, entry := range entries {
+		path := domain.JSONPointer("rules", i)
+
+		// Key is how products refer to the rule; match_by=name falls back to the name
+		key := entry.Key
+		if key == "" && p.opts.MatchBy == domain.BundleMatchName {
+			key = entry.Name
+		}
+		if key == "" {
+			p.invalid(path+"/key", "is required")
+			continue
+		}
+		if entry.Name == "" {
+			p.invalid(path+"/name", "is required")
+		}
+		if _, dup := p.ruleIDs[key]; dup {
+			p.conflict(path, domain.ImportConflictDuplicate, fmt.Sprintf("rule key %q appears more than once in the bundle", key), nil)
+			continue
+		}
+		// Known to products even if the rule turns out to be invalid; set once planned
+		p.ruleIDs[key] = uuid.Nil
+
+		config, err := normalizeJSON(entry.Config)
+		if err != nil {
+			p.invalid(path+"/config", err.Error())
+			continue
+		}
+		if !p.validateRule(path, entry.StrategyType, config) {
+			continue
+		}
+		window := domain.EffectiveWindow{From: entry.EffectiveFrom, To: entry.EffectiveTo}
+		if err := window.Validate(); err != nil {
+			p.invalid(path+"/effective_to", "must be after effective_from")
+			continue
+		}
+
+		rule := &domain.PricingRule{
+			UserID:        p.plan.UserID,
+			Name:          entry.Name,
+			Description:   entry.Description,
+			StrategyType:  entry.StrategyType,
+			Config:        config,
+			IsActive:      entry.IsActive,
+			EffectiveFrom: entry.EffectiveFrom,
+			EffectiveTo:   entry.EffectiveTo,
+			ExternalKey:   entry.Key,
+			ChangeNote:    p.opts.ChangeNote,
+		}
+
+		matches := matchRules(existing, entry, p.opts.MatchBy)
+		if len(matches) > 1 {
+			p.conflict(path, domain.ImportConflictAmbiguous, fmt.Sprintf("%d existing rules are named %q", len(matches), entry.Name), nil)
+			continue
+		}
+
+		action := domain.ImportActionCreate
+		if len(matches) == 1 {
+			current := matches[0]
+			if p.opts.Mode == domain.ImportModeCreate {
+				p.conflict(path, domain.ImportConflictExists, fmt.Sprintf("rule %q already exists", key), &current.ID)
+				continue
+			}
+			if _, err := p.service.rules.GetDraft(ctx, current.ID); err == nil {
+				p.conflict(path, domain.ImportConflictPendingDraft, "rule has a pending draft that the import would replace", &current.ID)
+				continue
+			} else if !errors.Is(err, domain.ErrDraftNotFound) {
+				return err
+			}
+
+			rule.ID = current.ID
+			rule.Revision = current.Revision
+			// Keep the existing key when the bundle refers to the rule by ID
+			if rule.ExternalKey == current.ID.String() || rule.ExternalKey == "" {
+				rule.ExternalKey = current.ExternalKey
+			}
+
+			action = domain.ImportActionUpdate
+			if current.PublishedAt != nil && sameRuleContent(current, rule) {
+				action = domain.ImportActionUnchanged
+			}
+		} else {
+			rule.ID = uuid.New()
+		}
+
+		p.ruleIDs[key] = rule.ID
+		p.plan.Rules = append(p.plan.Rules, &domain.PlannedRule{Action: action, Rule: rule})
+		p.item(path, "rule", key, action, rule.ID)
+	}
+
+	return nil
+}
+
+func (p *importPlanner) planProducts(ctx context.Context, entries []domain.BundleProduct) error {
+	seen := make(map[string]bool, len(entries))
+	for i, entry := range entries {
+		path := domain.JSONPointer("products", i)
+
+		if entry.SKU == "" {
+			p.invalid(path+"/sku", "is required")
+			continue
+		}
+		if entry.Name == "" {
+			p.invalid(path+"/name", "is required")
+		}
+		if entry.BaseCost < 0 {
+			p.invalid(path+"/base_cost", "must not be negative")
+		}
+		if seen[entry.SKU] {
+			p.conflict(path, domain.ImportConflictDuplicate, fmt.Sprintf("SKU %q appears more than once in the bundle", entry.SKU), nil)
+			continue
+		}
+		seen[entry.SKU] = true
+
+		metadata, err := normalizeJSON(entry.Metadata)
+		if err != nil {
+			p.invalid(path+"/metadata", err.Error())
+			continue
+		}
+
+		product := &domain.Product{
+			UserID:      p.plan.UserID,
+			SKU:         entry.SKU,
+			Name:        entry.Name,
+			Description: entry.Description,
+			BaseCost:    entry.BaseCost,
+			Metadata:    metadata,
+			IsActive:    entry.IsActive,
+		}
+		if entry.DefaultRule != "" {
+			ruleID, ok := p.ruleIDs[entry.DefaultRule]
+			if !ok {
+				p.invalid(path+"/default_rule", fmt.Sprintf("unknown rule %q", entry.DefaultRule))
+				continue
+			}
+			product.DefaultRuleID = &ruleID
+		}
+
+		schedule, ok := p.planSchedule(path, entry.Schedule)
+		if !ok {
+			continue
+		}
+
+		action := domain.ImportActionCreate
+		if current, err := p.service.products.GetBySKU(ctx, p.plan.UserID, entry.SKU); err == nil {
+			if p.opts.Mode == domain.ImportModeCreate {
+				p.conflict(path, domain.ImportConflictExists, fmt.Sprintf("product %q already exists", entry.SKU), &current.ID)
+				continue
+			}
+			product.ID = current.ID
+			// Bundles do not carry categories; keep the product's own
+			product.CategoryID = current.CategoryID
+			action = domain.ImportActionUpdate
+		} else {
+			product.ID = uuid.New()
+		}
+
+		p.plan.Products = append(p.plan.Products, &domain.PlannedProduct{Action: action, Product: product, Schedule: schedule})
+		p.item(path, "product", entry.SKU, action, product.ID)
+	}
+
+	return nil
+}
+
+// planSchedule resolves a product's scheduled rules, rejecting overlaps
+func (p *importPlanner) planSchedule(path string, entries []domain.BundleAssignment) ([]*domain.ProductRuleAssignment, bool) {
+	ok := true
+	schedule := make([]*domain.ProductRuleAssignment, 0, len(entries))
+	for j, entry := range entries {
+		entryPath := path + domain.JSONPointer("schedule", j)
+
+		ruleID, found := p.ruleIDs[entry.Rule]
+		if !found {
+			p.invalid(entryPath+"/rule", fmt.Sprintf("unknown rule %q", entry.Rule))
+			ok = false
+			continue
+		}
+
+		assignment := &domain.ProductRuleAssignment{
+			RuleID:        ruleID,
+			EffectiveFrom: entry.EffectiveFrom,
+			EffectiveTo:   entry.EffectiveTo,
+		}
+		if err := assignment.Window().Validate(); err != nil {
+			p.invalid(entryPath+"/effective_to", "must be after effective_from")
+			ok = false
+			continue
+		}
+		for k, other := range schedule {
+			if other.Window().Overlaps(assignment.Window()) {
+				p.invalid(entryPath, fmt.Sprintf("overlaps schedule entry %d", k))
+				ok = false
+			}
+		}
+		schedule = append(schedule, assignment)
+	}
+	return schedule, ok
+}
+
+// validateRule checks a rule's config with the engine, reporting errors under path
+func (p *importPlanner) validateRule(path, strategyType string, config map[string]interface{}) bool {
+	err := p.service.engine.ValidateConfig(strategyType, config)
+	if err == nil {
+		return true
+	}
+
+	var ve *domain.ValidationError
+	if !errors.As(err, &ve) {
+		p.invalid(path+"/strategy_type", err.Error())
+		return false
+	}
+	for _, fe := range ve.Errors {
+		p.invalid(path+"/config"+fe.Path, fe.Message)
+	}
+	return false
+}
+
+func (p *importPlanner) item(path, kind, key, action string, id uuid.UUID) {
+	p.report.Items = append(p.report.Items, domain.ImportItem{Path: path, Kind: kind, Key: key, Action: action, ID: &id})
+	p.report.Summary[kind+"s_"+action]++
+}
+
+func (p *importPlanner) conflict(path, kind, message string, existingID *uuid.UUID) {
+	p.report.Conflicts = append(p.report.Conflicts, domain.ImportConflict{Path: path, Kind: kind, Message: message, ExistingID: existingID})
+	p.report.Summary["conflicts"]++
+}
+
+func (p *importPlanner) invalid(path, message string) {
+	p.report.Errors = append(p.report.Errors, domain.FieldError{Path: path, Message: message})
+	p.report.Summary["errors"]++
+}
+
+// ruleKey identifies a rule in exported bundles
+func ruleKey(rule *domain.PricingRule) string {
+	if rule.ExternalKey != "" {
+		return rule.ExternalKey
+	}
+	return rule.ID.String()
+}
+
+// matchRules finds the existing rules a bundle entry refers to
+func matchRules(existing []*domain.PricingRule, entry domain.BundleRule, matchBy string) []*domain.PricingRule {
+	var matches []*domain.PricingRule
+	for _, rule := range existing {
+		switch matchBy {
+		case domain.BundleMatchName:
+			if rule.Name == entry.Name {
+				matches = append(matches, rule)
+			}
+		default:
+			if entry.Key != "" && (rule.ExternalKey == entry.Key || rule.ID.String() == entry.Key) {
+				matches = append(matches, rule)
+			}
+		}
+	}
+	return matches
+}
+
+// sameRuleContent reports whether importing rule would leave current unchanged
+func sameRuleContent(current, rule *domain.PricingRule) bool {
+	if current.ExternalKey != rule.ExternalKey {
+		return false
+	}
+	return len(DiffJSON(ruleDocument(current), ruleDocument(rule))) == 0
+}
+
+// ruleDocument returns a rule's revised fields as a JSON document
+func ruleDocument(rule *domain.PricingRule) map[string]interface{} {
+	revision := &domain.PricingRuleRevision{
+		Name:          rule.Name,
+		Description:   rule.Description,
+		StrategyType:  rule.StrategyType,
+		Config:        rule.Config,
+		IsActive:      rule.IsActive,
+		EffectiveFrom: rule.EffectiveFrom,
+		EffectiveTo:   rule.EffectiveTo,
+	}
+	return revision.Document()
+}
+
+// normalizeJSON round-trips a decoded document through JSON so values have the
+// types strategies expect; bundles decoded from YAML hold integers, not float64s
+func normalizeJSON(doc map[string]interface{}) (map[string]interface{}, error) {
+	if doc == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("is not representable as JSON: %w", err)
+	}
+	var normalized map[string]interface{}
+	if err := json.Unmarshal(data, &normalized); err != nil {
+		return nil, err
+	}
+	return normalized, nil
+}
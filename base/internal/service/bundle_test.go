@@ -0,0 +1,356 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/saintparish4/harmonia/internal/domain"
+)
+
+// fakeRuleListRepo lists rules and pending drafts from memory
+type fakeRuleListRepo struct {
+	domain.PricingRuleRepository
+	rules  []*domain.PricingRule
+	drafts map[uuid.UUID]bool
+}
+
+func (r *fakeRuleListRepo) List(ctx context.Context, filter domain.PricingRuleFilter) ([]*domain.PricingRule, error) {
+	return r.rules, nil
+}
+
+func (r *fakeRuleListRepo) GetDraft(ctx context.Context, ruleID uuid.UUID) (*domain.RuleDraft, error) {
+	if !r.drafts[ruleID] {
+		return nil, fmt.Errorf("%w for rule %s", domain.ErrDraftNotFound, ruleID)
+	}
+	return &domain.RuleDraft{RuleID: ruleID}, nil
+}
+
+// fakeBundleRepo records applied import plans
+type fakeBundleRepo struct {
+	applied []*domain.ImportPlan
+}
+
+func (r *fakeBundleRepo) ApplyImport(ctx context.Context, plan *domain.ImportPlan) error {
+	r.applied = append(r.applied, plan)
+	return nil
+}
+
+func TestBundleService_Import(t *testing.T) {
+	userID := uuid.New()
+	published := *at(1)
+
+	standard := &domain.PricingRule{
+		ID:           uuid.New(),
+		UserID:       userID,
+		Name:         "Standard",
+		StrategyType: "cost_plus",
+		Config:       map[string]interface{}{"markup_type": "percentage", "markup_value": 25.0},
+		IsActive:     true,
+		ExternalKey:  "std",
+		PublishedAt:  &published,
+	}
+	drafted := &domain.PricingRule{ID: uuid.New(), UserID: userID, Name: "Drafted", ExternalKey: "drafted", PublishedAt: &published}
+	twinA := &domain.PricingRule{ID: uuid.New(), UserID: userID, Name: "Twin", PublishedAt: &published}
+	twinB := &domain.PricingRule{ID: uuid.New(), UserID: userID, Name: "Twin", PublishedAt: &published}
+	widget := &domain.Product{ID: uuid.New(), UserID: userID, SKU: "W-1", Name: "Widget"}
+
+	costPlus := func(key, name string, markup interface{}) domain.BundleRule {
+		return domain.BundleRule{
+			Key:          key,
+			Name:         name,
+			StrategyType: "cost_plus",
+			Config:       map[string]interface{}{"markup_type": "percentage", "markup_value": markup},
+			IsActive:     true,
+		}
+	}
+
+	tests := []struct {
+		name            string
+		rules           []domain.BundleRule
+		products        []domain.BundleProduct
+		opts            domain.ImportOptions
+		requireApproval bool
+		wantErr         error
+		wantActions     []string
+		wantConflicts   []string
+		wantErrorPaths  []string
+		wantApplied     bool
+	}{
+		{
+			name:        "new rule is created",
+			rules:       []domain.BundleRule{costPlus("premium", "Premium", 40)},
+			wantActions: []string{domain.ImportActionCreate},
+			wantApplied: true,
+		},
+		{
+			name:          "existing key conflicts in create mode",
+			rules:         []domain.BundleRule{costPlus("std", "Standard", 30)},
+			wantConflicts: []string{domain.ImportConflictExists},
+		},
+		{
+			name:        "existing key is updated in upsert mode",
+			rules:       []domain.BundleRule{costPlus("std", "Standard", 30)},
+			opts:        domain.ImportOptions{Mode: domain.ImportModeUpsert},
+			wantActions: []string{domain.ImportActionUpdate},
+			wantApplied: true,
+		},
+		{
+			name:        "rule ID matches as key",
+			rules:       []domain.BundleRule{costPlus(standard.ID.String(), "Standard", 30)},
+			opts:        domain.ImportOptions{Mode: domain.ImportModeUpsert},
+			wantActions: []string{domain.ImportActionUpdate},
+			wantApplied: true,
+		},
+		{
+			name:        "identical content is unchanged",
+			rules:       []domain.BundleRule{costPlus("std", "Standard", 25)},
+			opts:        domain.ImportOptions{Mode: domain.ImportModeUpsert},
+			wantActions: []string{domain.ImportActionUnchanged},
+			wantApplied: true,
+		},
+		{
+			name:          "pending draft conflicts",
+			rules:         []domain.BundleRule{costPlus("drafted", "Drafted", 30)},
+			opts:          domain.ImportOptions{Mode: domain.ImportModeUpsert},
+			wantConflicts: []string{domain.ImportConflictPendingDraft},
+		},
+		{
+			name:          "ambiguous name",
+			rules:         []domain.BundleRule{costPlus("", "Twin", 30)},
+			opts:          domain.ImportOptions{MatchBy: domain.BundleMatchName, Mode: domain.ImportModeUpsert},
+			wantConflicts: []string{domain.ImportConflictAmbiguous},
+		},
+		{
+			name:          "duplicate key in bundle",
+			rules:         []domain.BundleRule{costPlus("premium", "Premium", 40), costPlus("premium", "Premium 2", 45)},
+			wantActions:   []string{domain.ImportActionCreate},
+			wantConflicts: []string{domain.ImportConflictDuplicate},
+		},
+		{
+			name:           "invalid config is reported by path",
+			rules:          []domain.BundleRule{costPlus("std", "Standard", 25), costPlus("bad", "Bad", -5)},
+			opts:           domain.ImportOptions{Mode: domain.ImportModeUpsert},
+			wantActions:    []string{domain.ImportActionUnchanged},
+			wantErrorPaths: []string{"/rules/1/config/markup_value"},
+		},
+		{
+			name:        "YAML integers are normalized",
+			rules:       []domain.BundleRule{costPlus("premium", "Premium", uint64(40))},
+			wantActions: []string{domain.ImportActionCreate},
+			wantApplied: true,
+		},
+		{
+			name:  "product links resolve to bundle rules",
+			rules: []domain.BundleRule{costPlus("premium", "Premium", 40)},
+			products: []domain.BundleProduct{{
+				SKU: "G-1", Name: "Gadget", BaseCost: 10, DefaultRule: "premium",
+				Schedule: []domain.BundleAssignment{{Rule: "premium", EffectiveTo: at(10)}},
+			}},
+			wantActions: []string{domain.ImportActionCreate, domain.ImportActionCreate},
+			wantApplied: true,
+		},
+		{
+			name:          "existing product conflicts in create mode",
+			products:      []domain.BundleProduct{{SKU: "W-1", Name: "Widget"}},
+			wantConflicts: []string{domain.ImportConflictExists},
+		},
+		{
+			name:           "unknown default rule",
+			products:       []domain.BundleProduct{{SKU: "G-1", Name: "Gadget", DefaultRule: "missing"}},
+			wantErrorPaths: []string{"/products/0/default_rule"},
+		},
+		{
+			name:  "overlapping schedule",
+			rules: []domain.BundleRule{costPlus("premium", "Premium", 40)},
+			products: []domain.BundleProduct{{
+				SKU: "G-1", Name: "Gadget",
+				Schedule: []domain.BundleAssignment{{Rule: "premium", EffectiveTo: at(10)}, {Rule: "premium", EffectiveFrom: at(5)}},
+			}},
+			wantActions:    []string{domain.ImportActionCreate},
+			wantErrorPaths: []string{"/products/0/schedule/1"},
+		},
+		{
+			name:        "dry run applies nothing",
+			rules:       []domain.BundleRule{costPlus("premium", "Premium", 40)},
+			opts:        domain.ImportOptions{DryRun: true},
+			wantActions: []string{domain.ImportActionCreate},
+		},
+		{
+			name:        "publish without approval policy",
+			rules:       []domain.BundleRule{costPlus("premium", "Premium", 40)},
+			opts:        domain.ImportOptions{Publish: true},
+			wantActions: []string{domain.ImportActionCreate},
+			wantApplied: true,
+		},
+		{
+			name:            "publish refused under approval policy",
+			rules:           []domain.BundleRule{costPlus("premium", "Premium", 40)},
+			opts:            domain.ImportOptions{Publish: true},
+			requireApproval: true,
+			wantErr:         domain.ErrSelfApprovalForbidden,
+		},
+		{
+			name:    "invalid mode",
+			opts:    domain.ImportOptions{Mode: "replace"},
+			wantErr: domain.ErrInvalidImportOptions,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules := &fakeRuleListRepo{
+				rules:  []*domain.PricingRule{standard, drafted, twinA, twinB},
+				drafts: map[uuid.UUID]bool{drafted.ID: true},
+			}
+			products := &fakeProductRepo{products: []*domain.Product{widget}}
+			users := &fakeUserRepo{users: map[uuid.UUID]*domain.User{
+				userID: {ID: userID, RequireRuleApproval: tt.requireApproval},
+			}}
+			bundles := &fakeBundleRepo{}
+
+			service := NewBundleService(NewPricingEngine(), rules, products, users, bundles)
+			report, err := service.Import(context.Background(), domain.ImportRequest{
+				Bundle:   &domain.Bundle{Version: domain.BundleVersion, Rules: tt.rules, Products: tt.products},
+				Options:  tt.opts,
+				Approval: domain.DraftApproval{UserID: userID},
+			})
+
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("expected %v, got %v", tt.wantErr, err)
+				}
+				if len(bundles.applied) != 0 {
+					t.Errorf("expected nothing applied")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var actions []string
+			for _, item := range report.Items {
+				actions = append(actions, item.Action)
+			}
+			if fmt.Sprint(actions) != fmt.Sprint(tt.wantActions) {
+				t.Errorf("expected actions %v, got %v", tt.wantActions, actions)
+			}
+
+			var conflicts []string
+			for _, conflict := range report.Conflicts {
+				conflicts = append(conflicts, conflict.Kind)
+			}
+			if fmt.Sprint(conflicts) != fmt.Sprint(tt.wantConflicts) {
+				t.Errorf("expected conflicts %v, got %v", tt.wantConflicts, conflicts)
+			}
+
+			var paths []string
+			for _, fe := range report.Errors {
+				paths = append(paths, fe.Path)
+			}
+			if fmt.Sprint(paths) != fmt.Sprint(tt.wantErrorPaths) {
+				t.Errorf("expected error paths %v, got %v", tt.wantErrorPaths, paths)
+			}
+
+			if report.Applied != tt.wantApplied || (len(bundles.applied) == 1) != tt.wantApplied {
+				t.Errorf("expected applied=%v, got report %v with %d plans", tt.wantApplied, report.Applied, len(bundles.applied))
+			}
+		})
+	}
+
+	// Unsupported bundle versions are refused before anything is planned
+	service := NewBundleService(NewPricingEngine(), &fakeRuleListRepo{}, &fakeProductRepo{}, &fakeUserRepo{}, &fakeBundleRepo{})
+	_, err := service.Import(context.Background(), domain.ImportRequest{Bundle: &domain.Bundle{Version: 2}})
+	if !errors.Is(err, domain.ErrUnsupportedBundleVersion) {
+		t.Errorf("expected ErrUnsupportedBundleVersion, got %v", err)
+	}
+}
+
+func TestBundleService_Import_KeepsCategory(t *testing.T) {
+	userID := uuid.New()
+	categoryID := uuid.New()
+	widget := &domain.Product{ID: uuid.New(), UserID: userID, SKU: "W-1", Name: "Widget", CategoryID: &categoryID}
+
+	bundles := &fakeBundleRepo{}
+	service := NewBundleService(NewPricingEngine(), &fakeRuleListRepo{}, &fakeProductRepo{products: []*domain.Product{widget}}, &fakeUserRepo{}, bundles)
+	_, err := service.Import(context.Background(), domain.ImportRequest{
+		Bundle: &domain.Bundle{Version: domain.BundleVersion, Products: []domain.BundleProduct{
+			{SKU: "W-1", Name: "Widget v2", IsActive: true},
+		}},
+		Options:  domain.ImportOptions{Mode: domain.ImportModeUpsert},
+		Approval: domain.DraftApproval{UserID: userID},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(bundles.applied) != 1 || len(bundles.applied[0].Products) != 1 {
+		t.Fatalf("expected one planned product, got %+v", bundles.applied)
+	}
+
+	planned := bundles.applied[0].Products[0]
+	if planned.Action != domain.ImportActionUpdate {
+		t.Errorf("expected an update, got %s", planned.Action)
+	}
+	if planned.Product.CategoryID == nil || *planned.Product.CategoryID != categoryID {
+		t.Errorf("expected the product to keep category %s, got %v", categoryID, planned.Product.CategoryID)
+	}
+}
+
+func TestBundleService_ExportRoundTrip(t *testing.T) {
+	userID := uuid.New()
+	published := *at(1)
+
+	keyed := &domain.PricingRule{ID: uuid.New(), UserID: userID, Name: "Keyed", StrategyType: "cost_plus",
+		Config: map[string]interface{}{"markup_type": "percentage", "markup_value": 25.0}, ExternalKey: "keyed", PublishedAt: &published}
+	unkeyed := &domain.PricingRule{ID: uuid.New(), UserID: userID, Name: "Unkeyed", StrategyType: "cost_plus",
+		Config: map[string]interface{}{"markup_type": "percentage", "markup_value": 10.0}, PublishedAt: &published}
+	unpublished := &domain.PricingRule{ID: uuid.New(), UserID: userID, Name: "Unpublished", StrategyType: "cost_plus"}
+	product := &domain.Product{ID: uuid.New(), UserID: userID, SKU: "W-1", Name: "Widget", DefaultRuleID: &unkeyed.ID}
+
+	rules := &fakeRuleListRepo{rules: []*domain.PricingRule{keyed, unkeyed, unpublished}}
+	products := &fakeProductListRepo{fakeProductRepo: fakeProductRepo{
+		products:    []*domain.Product{product},
+		assignments: []*domain.ProductRuleAssignment{{ProductID: product.ID, RuleID: keyed.ID, EffectiveTo: at(10)}},
+	}}
+	bundles := &fakeBundleRepo{}
+	service := NewBundleService(NewPricingEngine(), rules, products, &fakeUserRepo{}, bundles)
+
+	bundle, err := service.Export(context.Background(), userID, true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(bundle.Rules) != 2 || bundle.Rules[0].Key != "keyed" || bundle.Rules[1].Key != unkeyed.ID.String() {
+		t.Fatalf("expected published rules keyed by external key or ID, got %+v", bundle.Rules)
+	}
+	if len(bundle.Products) != 1 || bundle.Products[0].DefaultRule != unkeyed.ID.String() || len(bundle.Products[0].Schedule) != 1 {
+		t.Fatalf("expected product with rule links, got %+v", bundle.Products)
+	}
+
+	// Re-importing an unmodified export into the same account changes nothing
+	report, err := service.Import(context.Background(), domain.ImportRequest{
+		Bundle:   bundle,
+		Options:  domain.ImportOptions{Mode: domain.ImportModeUpsert, DryRun: true},
+		Approval: domain.DraftApproval{UserID: userID},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if report.Rejected() {
+		t.Fatalf("expected import to be accepted, got %+v %+v", report.Conflicts, report.Errors)
+	}
+	if report.Summary["rules_unchanged"] != 2 || report.Summary["products_update"] != 1 {
+		t.Errorf("expected 2 unchanged rules and 1 updated product, got %v", report.Summary)
+	}
+}
+
+// fakeProductListRepo adds listing to fakeProductRepo
+type fakeProductListRepo struct {
+	fakeProductRepo
+}
+
+func (r *fakeProductListRepo) List(ctx context.Context, filter domain.ProductFilter) ([]*domain.Product, error) {
+	return r.products, nil
+}
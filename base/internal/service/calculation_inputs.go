@@ -0,0 +1,82 @@
+package service
+
+import (
+	"time"
+
+	"github.com/saintparish4/harmonia/internal/domain"
+)
+
+// Helpers that turn API requests and logged calculations into engine inputs
+
+// BuildInputs merges the request context with the top-level base price and
+// quantity. Context values win, so callers can override either field.
+func BuildInputs(context map[string]interface{}, basePrice float64, quantity int) map[string]interface{} {
+	inputs := make(map[string]interface{}, len(context)+3)
+
+	// Copy context fields
+	for k, v := range context {
+		inputs[k] = v
+	}
+
+	// Add base_price and quantity if not already in context
+	// The cost_plus strategy expects "base_cost" in inputs
+	if _, exists := inputs["base_cost"]; !exists && basePrice > 0 {
+		inputs["base_cost"] = basePrice
+	}
+	if _, exists := inputs["base_price"]; !exists && basePrice > 0 {
+		inputs["base_price"] = basePrice
+	}
+	if _, exists := inputs["quantity"]; !exists && quantity > 0 {
+		inputs["quantity"] = quantity
+	}
+
+	return inputs
+}
+
+// ApplyProductCost prices with a product's cost in effect unless the request
+// context sets base_cost itself
+func ApplyProductCost(inputs, context map[string]interface{}, cost float64) {
+	if _, exists := context["base_cost"]; !exists {
+		inputs["base_cost"] = cost
+	}
+}
+
+// InputsFromLog rebuilds the engine inputs of a logged calculation.
+// Returns false if the log does not hold enough data to replay it.
+func InputsFromLog(inputData map[string]interface{}) (map[string]interface{}, bool) {
+	if inputData == nil {
+		return nil, false
+	}
+
+	context, _ := inputData["context"].(map[string]interface{})
+	basePrice, _ := convertToFloat(inputData["base_price"])
+	quantity, _ := convertToFloat(inputData["quantity"])
+
+	inputs := BuildInputs(context, basePrice, int(quantity))
+	if cost, ok := convertToFloat(inputData["product_cost"]); ok {
+		ApplyProductCost(inputs, context, cost)
+	}
+	if len(inputs) == 0 {
+		return nil, false
+	}
+	return inputs, true
+}
+
+// PriceFromLog extracts the final price recorded in a calculation log's output
+func PriceFromLog(outputData map[string]interface{}) (float64, bool) {
+	if outputData == nil {
+		return 0, false
+	}
+	return convertToFloat(outputData["final_price"])
+}
+
+// RequestedAtFromLog returns the time a logged calculation priced at: the
+// explicit requested_at when one was given, otherwise when it was logged
+func RequestedAtFromLog(log *domain.CalculationLog) time.Time {
+	if raw, ok := log.InputData["requested_at"].(string); ok {
+		if t, err := time.Parse(time.RFC3339, raw); err == nil {
+			return t
+		}
+	}
+	return log.CreatedAt
+}
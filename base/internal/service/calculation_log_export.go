@@ -0,0 +1,296 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/saintparish4/harmonia/internal/csvsafe"
+	"github.com/saintparish4/harmonia/internal/domain"
+)
+
+// maxLogExportColumns limits the columns of one calculation log export
+const maxLogExportColumns = 100
+
+// CalculationLogExportService streams calculation logs as CSV or NDJSON files
+type CalculationLogExportService struct {
+	logs domain.CalculationLogRepository
+}
+
+// NewCalculationLogExportService creates a calculation log export service
+func NewCalculationLogExportService(logs domain.CalculationLogRepository) *CalculationLogExportService {
+	return &CalculationLogExportService{logs: logs}
+}
+
+// Export writes every log matching filter to w, oldest first, with one CSV
+// row or NDJSON line per log holding the given columns. Without columns,
+// domain.LogColumns are written. Nothing is written when the format or a
+// column is invalid.
+func (s *CalculationLogExportService) Export(ctx context.Context, filter domain.CalculationLogFilter, format string, columns []string, w io.Writer) error {
+	if len(columns) == 0 {
+		columns = domain.LogColumns
+	}
+	fields, err := parseLogColumns(columns)
+	if err != nil {
+		return err
+	}
+
+	var encoder logEncoder
+	switch format {
+	case domain.LogExportCSV:
+		encoder = &csvLogEncoder{writer: csv.NewWriter(w), fields: fields}
+	case domain.LogExportNDJSON:
+		encoder = &ndjsonLogEncoder{buffer: bufio.NewWriter(w), fields: fields}
+	default:
+		return fmt.Errorf("%w: unsupported format %s", domain.ErrLogExportInvalid, format)
+	}
+
+	if err := s.logs.Export(ctx, filter, encoder.encode); err != nil {
+		return err
+	}
+	return encoder.flush()
+}
+
+// logField is an exported column and how its value is read from a log
+type logField struct {
+	name  string
+	value func(log *domain.CalculationLog) interface{}
+}
+
+// logFieldValues read the value of each fixed column. Values a log does not
+// have are nil, so they export as empty cells or null.
+var logFieldValues = map[string]func(log *domain.CalculationLog) interface{}{
+	domain.LogColumnID:        func(log *domain.CalculationLog) interface{} { return log.ID.String() },
+	domain.LogColumnCreatedAt: func(log *domain.CalculationLog) interface{} { return log.CreatedAt.UTC() },
+	domain.LogColumnUserID:    func(log *domain.CalculationLog) interface{} { return log.UserID.String() },
+	domain.LogColumnAPIKeyID: func(log *domain.CalculationLog) interface{} {
+		if log.APIKeyID == nil {
+			return nil
+		}
+		return log.APIKeyID.String()
+	},
+	domain.LogColumnRuleID: func(log *domain.CalculationLog) interface{} {
+		if log.RuleID == nil {
+			return nil
+		}
+		return log.RuleID.String()
+	},
+	domain.LogColumnRuleRevisionID: func(log *domain.CalculationLog) interface{} {
+		if log.RuleRevisionID == nil {
+			return nil
+		}
+		return log.RuleRevisionID.String()
+	},
+	domain.LogColumnRuleRevision: func(log *domain.CalculationLog) interface{} {
+		if log.RuleRevision == 0 {
+			return nil
+		}
+		return log.RuleRevision
+	},
+	domain.LogColumnStrategyType:  func(log *domain.CalculationLog) interface{} { return optionalLogString(log.StrategyType) },
+	domain.LogColumnExecutionTime: func(log *domain.CalculationLog) interface{} { return log.ExecutionTimeMs },
+	domain.LogColumnRequestID:     func(log *domain.CalculationLog) interface{} { return optionalLogString(log.RequestID) },
+	domain.LogColumnClientIP:      func(log *domain.CalculationLog) interface{} { return optionalLogString(log.ClientIP) },
+	domain.LogColumnEngineVersion: func(log *domain.CalculationLog) interface{} { return optionalLogString(log.EngineVersion) },
+	domain.LogColumnError:         func(log *domain.CalculationLog) interface{} { return optionalLogString(log.Error) },
+	domain.LogColumnInputData:     func(log *domain.CalculationLog) interface{} { return log.InputData },
+	domain.LogColumnOutputData:    func(log *domain.CalculationLog) interface{} { return log.OutputData },
+}
+
+// parseLogColumns resolves the selected columns. Columns named input.<path>
+// or output.<path> read one value of the input or output, following object
+// keys and array indexes separated by dots.
+func parseLogColumns(columns []string) ([]logField, error) {
+	if len(columns) > maxLogExportColumns {
+		return nil, fmt.Errorf("%w: at most %d columns can be exported", domain.ErrLogExportInvalid, maxLogExportColumns)
+	}
+
+	fields := make([]logField, 0, len(columns))
+	seen := make(map[string]bool, len(columns))
+	for _, column := range columns {
+		if seen[column] {
+			return nil, fmt.Errorf("%w: duplicate column %q", domain.ErrLogExportInvalid, column)
+		}
+		seen[column] = true
+
+		if value, ok := logFieldValues[column]; ok {
+			fields = append(fields, logField{name: column, value: value})
+			continue
+		}
+
+		var data func(log *domain.CalculationLog) map[string]interface{}
+		path, ok := strings.CutPrefix(column, domain.LogInputPrefix)
+		if ok {
+			data = func(log *domain.CalculationLog) map[string]interface{} { return log.InputData }
+		} else if path, ok = strings.CutPrefix(column, domain.LogOutputPrefix); ok {
+			data = func(log *domain.CalculationLog) map[string]interface{} { return log.OutputData }
+		} else {
+			return nil, fmt.Errorf("%w: unknown column %q", domain.ErrLogExportInvalid, column)
+		}
+
+		keys := strings.Split(path, ".")
+		for _, key := range keys {
+			if key == "" {
+				return nil, fmt.Errorf("%w: invalid path in column %q", domain.ErrLogExportInvalid, column)
+			}
+		}
+		fields = append(fields, logField{
+			name: column,
+			value: func(log *domain.CalculationLog) interface{} {
+				return logValueAt(data(log), keys)
+			},
+		})
+	}
+
+	return fields, nil
+}
+
+// logValueAt follows keys through nested objects and arrays, returning nil
+// when the path does not exist
+func logValueAt(data map[string]interface{}, keys []string) interface{} {
+	var value interface{} = data
+	for _, key := range keys {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			value = v[key]
+		case []interface{}:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(v) {
+				return nil
+			}
+			value = v[i]
+		default:
+			return nil
+		}
+	}
+	return value
+}
+
+// optionalLogString returns nil for an empty string
+func optionalLogString(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+// logEncoder writes calculation logs in an export format
+type logEncoder interface {
+	encode(log *domain.CalculationLog) error
+	flush() error
+}
+
+// csvLogEncoder writes a header row, then one row per log. Objects and arrays
+// are written as JSON.
+type csvLogEncoder struct {
+	writer      *csv.Writer
+	fields      []logField
+	wroteHeader bool
+	record      []string
+}
+
+func (e *csvLogEncoder) writeHeader() error {
+	if e.wroteHeader {
+		return nil
+	}
+	header := make([]string, len(e.fields))
+	for i, field := range e.fields {
+		header[i] = field.name
+	}
+	e.wroteHeader = true
+	return e.writer.Write(header)
+}
+
+func (e *csvLogEncoder) encode(log *domain.CalculationLog) error {
+	if err := e.writeHeader(); err != nil {
+		return err
+	}
+
+	if e.record == nil {
+		e.record = make([]string, len(e.fields))
+	}
+	for i, field := range e.fields {
+		cell, err := csvLogCell(field.value(log))
+		if err != nil {
+			return fmt.Errorf("failed to encode %s of %s: %w", field.name, log.ID, err)
+		}
+		e.record[i] = cell
+	}
+	return e.writer.Write(e.record)
+}
+
+func (e *csvLogEncoder) flush() error {
+	// An empty export still gets a header
+	if err := e.writeHeader(); err != nil {
+		return err
+	}
+	e.writer.Flush()
+	return e.writer.Error()
+}
+
+// csvLogCell formats a value for a CSV cell. Strings that spreadsheets would
+// run as formulas are quoted.
+func csvLogCell(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return csvsafe.Cell(v), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case int:
+		return strconv.Itoa(v), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case time.Time:
+		return v.Format(time.RFC3339Nano), nil
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return "", err
+		}
+		return string(data), nil
+	}
+}
+
+// ndjsonLogEncoder writes one JSON object per log, with keys in column order
+type ndjsonLogEncoder struct {
+	buffer *bufio.Writer
+	fields []logField
+	line   []byte
+}
+
+func (e *ndjsonLogEncoder) encode(log *domain.CalculationLog) error {
+	e.line = append(e.line[:0], '{')
+	for i, field := range e.fields {
+		if i > 0 {
+			e.line = append(e.line, ',')
+		}
+		name, err := json.Marshal(field.name)
+		if err != nil {
+			return err
+		}
+		e.line = append(e.line, name...)
+		e.line = append(e.line, ':')
+
+		value, err := json.Marshal(field.value(log))
+		if err != nil {
+			return fmt.Errorf("failed to encode %s of %s: %w", field.name, log.ID, err)
+		}
+		e.line = append(e.line, value...)
+	}
+	e.line = append(e.line, '}', '\n')
+
+	_, err := e.buffer.Write(e.line)
+	return err
+}
+
+func (e *ndjsonLogEncoder) flush() error {
+	return e.buffer.Flush()
+}
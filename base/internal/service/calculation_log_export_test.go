@@ -0,0 +1,164 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/saintparish4/harmonia/internal/domain"
+)
+
+// fakeExportLogRepo exports logs from memory
+type fakeExportLogRepo struct {
+	domain.CalculationLogRepository
+	logs   []*domain.CalculationLog
+	filter domain.CalculationLogFilter
+	calls  int
+}
+
+func (r *fakeExportLogRepo) Export(ctx context.Context, filter domain.CalculationLogFilter, fn func(*domain.CalculationLog) error) error {
+	r.filter = filter
+	r.calls++
+	for _, log := range r.logs {
+		if err := fn(log); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func exportTestLogs() []*domain.CalculationLog {
+	ruleID := uuid.MustParse("22222222-2222-2222-2222-222222222222")
+	return []*domain.CalculationLog{
+		{
+			ID:              uuid.MustParse("11111111-1111-1111-1111-111111111111"),
+			RuleID:          &ruleID,
+			StrategyType:    "cost_plus",
+			InputData:       map[string]interface{}{"product_id": "sku-1", "context": map[string]interface{}{"location": "US"}},
+			OutputData:      map[string]interface{}{"final_price": 12.5, "breakdown": []interface{}{map[string]interface{}{"amount": 10.0}}},
+			ExecutionTimeMs: 0.25,
+			CreatedAt:       time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC),
+		},
+		{
+			ID:         uuid.MustParse("33333333-3333-3333-3333-333333333333"),
+			InputData:  map[string]interface{}{"product_id": "sku-2, large"},
+			OutputData: map[string]interface{}{},
+			Error:      "invalid pricing strategy",
+			CreatedAt:  time.Date(2026, 3, 1, 13, 0, 0, 0, time.UTC),
+		},
+	}
+}
+
+func TestCalculationLogExportCSV(t *testing.T) {
+	repo := &fakeExportLogRepo{logs: exportTestLogs()}
+	exporter := NewCalculationLogExportService(repo)
+
+	filter := domain.CalculationLogFilter{UserID: uuid.New(), IncludeFailed: true}
+	columns := []string{"id", "rule_id", "error", "input.product_id", "input.context.location", "output.final_price", "output.breakdown.0.amount", "output.breakdown.1.amount"}
+
+	var buf bytes.Buffer
+	if err := exporter.Export(context.Background(), filter, domain.LogExportCSV, columns, &buf); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	if repo.filter.UserID != filter.UserID || !repo.filter.IncludeFailed {
+		t.Errorf("expected the filter to be passed through, got %+v", repo.filter)
+	}
+
+	expected := "id,rule_id,error,input.product_id,input.context.location,output.final_price,output.breakdown.0.amount,output.breakdown.1.amount\n" +
+		"11111111-1111-1111-1111-111111111111,22222222-2222-2222-2222-222222222222,,sku-1,US,12.5,10,\n" +
+		"33333333-3333-3333-3333-333333333333,,invalid pricing strategy,\"sku-2, large\",,,,\n"
+	if buf.String() != expected {
+		t.Errorf("unexpected CSV:\n%s\nexpected:\n%s", buf.String(), expected)
+	}
+}
+
+func TestCalculationLogExportCSVFormulas(t *testing.T) {
+	repo := &fakeExportLogRepo{logs: []*domain.CalculationLog{{
+		ID:        uuid.MustParse("11111111-1111-1111-1111-111111111111"),
+		InputData: map[string]interface{}{"product_id": "=HYPERLINK(\"http://x\")", "context": map[string]interface{}{"location": "@US", "discount": "-5", "note": "+1+cmd"}},
+		Error:     "-bad input",
+	}}}
+	exporter := NewCalculationLogExportService(repo)
+
+	columns := []string{"error", "input.product_id", "input.context.location", "input.context.discount", "input.context.note"}
+
+	var buf bytes.Buffer
+	if err := exporter.Export(context.Background(), domain.CalculationLogFilter{}, domain.LogExportCSV, columns, &buf); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+
+	// Strings that would run as formulas are quoted, numbers are kept
+	expected := "error,input.product_id,input.context.location,input.context.discount,input.context.note\n" +
+		"'-bad input,\"'=HYPERLINK(\"\"http://x\"\")\",'@US,-5,'+1+cmd\n"
+	if buf.String() != expected {
+		t.Errorf("unexpected CSV:\n%s\nexpected:\n%s", buf.String(), expected)
+	}
+}
+
+func TestCalculationLogExportNDJSON(t *testing.T) {
+	repo := &fakeExportLogRepo{logs: exportTestLogs()[:1]}
+	exporter := NewCalculationLogExportService(repo)
+
+	columns := []string{"id", "created_at", "strategy_type", "request_id", "execution_time_ms", "input.context", "output_data"}
+
+	var buf bytes.Buffer
+	if err := exporter.Export(context.Background(), domain.CalculationLogFilter{}, domain.LogExportNDJSON, columns, &buf); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+
+	expected := `{"id":"11111111-1111-1111-1111-111111111111","created_at":"2026-03-01T12:00:00Z","strategy_type":"cost_plus",` +
+		`"request_id":null,"execution_time_ms":0.25,"input.context":{"location":"US"},` +
+		`"output_data":{"breakdown":[{"amount":10}],"final_price":12.5}}` + "\n"
+	if buf.String() != expected {
+		t.Errorf("unexpected NDJSON:\n%s\nexpected:\n%s", buf.String(), expected)
+	}
+}
+
+func TestCalculationLogExportDefaultColumns(t *testing.T) {
+	exporter := NewCalculationLogExportService(&fakeExportLogRepo{})
+
+	var buf bytes.Buffer
+	if err := exporter.Export(context.Background(), domain.CalculationLogFilter{}, domain.LogExportCSV, nil, &buf); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+
+	// An empty export still gets a header
+	expected := "id,created_at,user_id,api_key_id,rule_id,rule_revision_id,rule_revision,strategy_type,execution_time_ms," +
+		"request_id,client_ip,engine_version,error,input_data,output_data\n"
+	if buf.String() != expected {
+		t.Errorf("unexpected CSV:\n%s\nexpected:\n%s", buf.String(), expected)
+	}
+}
+
+func TestCalculationLogExportInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  string
+		columns []string
+	}{
+		{"unknown format", "xml", nil},
+		{"unknown column", domain.LogExportCSV, []string{"id", "price"}},
+		{"duplicate column", domain.LogExportCSV, []string{"id", "id"}},
+		{"empty path", domain.LogExportCSV, []string{"input."}},
+		{"empty path segment", domain.LogExportNDJSON, []string{"output.breakdown..amount"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeExportLogRepo{logs: exportTestLogs()}
+			exporter := NewCalculationLogExportService(repo)
+
+			var buf bytes.Buffer
+			err := exporter.Export(context.Background(), domain.CalculationLogFilter{}, tt.format, tt.columns, &buf)
+			if !errors.Is(err, domain.ErrLogExportInvalid) {
+				t.Errorf("expected ErrLogExportInvalid, got %v", err)
+			}
+			if repo.calls != 0 || buf.Len() != 0 {
+				t.Errorf("expected nothing to be exported, got %d calls and %q", repo.calls, buf.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,375 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/saintparish4/harmonia/internal/domain"
+)
+
+const (
+	// calculationLogWriteTimeout bounds a single batch write
+	calculationLogWriteTimeout = 30 * time.Second
+
+	// maxSpilledLogSize bounds one line of the spill file
+	maxSpilledLogSize = 16 << 20
+)
+
+// CalculationLogWriterConfig configures a CalculationLogWriter
+type CalculationLogWriterConfig struct {
+	QueueSize      int
+	BatchSize      int
+	FlushInterval  time.Duration
+	EnqueueTimeout time.Duration // how long Enqueue waits for room before dropping a log
+	SpillPath      string        // file for batches that could not be written; empty disables
+}
+
+// CalculationLogStats counts what happened to the logs given to the writer
+type CalculationLogStats struct {
+	Queued  int   // waiting to be written
+	Written int64 // stored, including logs replayed from the spill file
+	Dropped int64 // turned away because the queue was full
+	Spilled int64 // appended to the spill file while the database was unavailable
+	Failed  int64 // rejected by the database or lost because they could not be spilled
+}
+
+// CalculationLogWriter writes calculation logs in batches from a bounded
+// queue, so requests never wait on the database. When the queue is full,
+// logs are dropped and counted; when a batch cannot be written it is spilled
+// to disk, if configured, and replayed once writes succeed again.
+type CalculationLogWriter struct {
+	repo   domain.CalculationLogRepository
+	config CalculationLogWriterConfig
+	queue  chan *domain.CalculationLog
+	done   chan struct{}
+
+	// mu is held for writing while closing, so Enqueue never sends on a
+	// closed queue
+	mu     sync.RWMutex
+	closed bool
+
+	written atomic.Int64
+	dropped atomic.Int64
+	spilled atomic.Int64
+	failed  atomic.Int64
+
+	// Only touched by the write loop
+	healthy       bool
+	spillPending  bool
+	reportedDrops int64
+}
+
+// NewCalculationLogWriter creates a calculation log writer; call Start to
+// begin writing
+func NewCalculationLogWriter(repo domain.CalculationLogRepository, config CalculationLogWriterConfig) *CalculationLogWriter {
+	if config.QueueSize <= 0 {
+		config.QueueSize = 1
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = 1
+	}
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = time.Second
+	}
+
+	return &CalculationLogWriter{
+		repo:    repo,
+		config:  config,
+		queue:   make(chan *domain.CalculationLog, config.QueueSize),
+		done:    make(chan struct{}),
+		healthy: true,
+	}
+}
+
+// Start runs the write loop until Close. Logs spilled by an earlier run are
+// replayed once the database accepts writes.
+func (w *CalculationLogWriter) Start() {
+	if w.config.SpillPath != "" {
+		w.spillPending = fileExists(w.config.SpillPath) || fileExists(w.replayPath())
+	}
+	go w.run()
+}
+
+// Enqueue queues a log to be written. When the queue stays full for the
+// enqueue timeout the log is dropped and ErrCalculationLogQueueFull returned.
+func (w *CalculationLogWriter) Enqueue(entry *domain.CalculationLog) error {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	if w.closed {
+		w.dropped.Add(1)
+		return domain.ErrCalculationLogQueueFull
+	}
+
+	select {
+	case w.queue <- entry:
+		return nil
+	default:
+	}
+
+	if w.config.EnqueueTimeout > 0 {
+		timer := time.NewTimer(w.config.EnqueueTimeout)
+		defer timer.Stop()
+
+		select {
+		case w.queue <- entry:
+			return nil
+		case <-timer.C:
+		}
+	}
+
+	w.dropped.Add(1)
+	return domain.ErrCalculationLogQueueFull
+}
+
+// Close stops accepting logs and waits until the queued ones are written or
+// spilled, or ctx is done
+func (w *CalculationLogWriter) Close(ctx context.Context) error {
+	w.mu.Lock()
+	if !w.closed {
+		w.closed = true
+		close(w.queue)
+	}
+	w.mu.Unlock()
+
+	select {
+	case <-w.done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("%d calculation logs not written: %w", len(w.queue), ctx.Err())
+	}
+}
+
+// Stats returns the writer's counters
+func (w *CalculationLogWriter) Stats() CalculationLogStats {
+	return CalculationLogStats{
+		Queued:  len(w.queue),
+		Written: w.written.Load(),
+		Dropped: w.dropped.Load(),
+		Spilled: w.spilled.Load(),
+		Failed:  w.failed.Load(),
+	}
+}
+
+// run collects queued logs into batches, writing a batch when it is full or
+// the flush interval passes
+func (w *CalculationLogWriter) run() {
+	defer close(w.done)
+
+	ticker := time.NewTicker(w.config.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]*domain.CalculationLog, 0, w.config.BatchSize)
+	for {
+		select {
+		case entry, ok := <-w.queue:
+			if !ok {
+				w.flush(batch)
+				return
+			}
+			batch = append(batch, entry)
+			if len(batch) >= w.config.BatchSize {
+				w.flush(batch)
+				batch = make([]*domain.CalculationLog, 0, w.config.BatchSize)
+			}
+		case <-ticker.C:
+			flushed := len(batch) > 0
+			if flushed {
+				w.flush(batch)
+				batch = make([]*domain.CalculationLog, 0, w.config.BatchSize)
+			}
+			// After a failed flush the replay waits for a quiet tick, where
+			// it doubles as the check that the database is back
+			if w.healthy || !flushed {
+				w.replaySpill()
+			}
+			w.reportDrops()
+		}
+	}
+}
+
+// flush writes a batch, spilling it when the write fails
+func (w *CalculationLogWriter) flush(batch []*domain.CalculationLog) {
+	if len(batch) == 0 {
+		return
+	}
+
+	written, err := w.write(batch)
+	w.written.Add(int64(written))
+	if err == nil {
+		w.failed.Add(int64(len(batch) - written))
+		w.healthy = true
+		return
+	}
+
+	// The whole batch is spilled; entries written before the failure are
+	// skipped by their IDs when it is replayed
+	log.Printf("calculation logs: failed to write %d logs: %v", len(batch), err)
+	w.healthy = false
+	w.spill(batch)
+}
+
+// write stores a batch with a timeout of its own, so shutdown can still
+// flush after the request contexts are gone
+func (w *CalculationLogWriter) write(batch []*domain.CalculationLog) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), calculationLogWriteTimeout)
+	defer cancel()
+	return w.repo.CreateBatch(ctx, batch)
+}
+
+// spill appends logs to the spill file as JSON lines
+func (w *CalculationLogWriter) spill(entries []*domain.CalculationLog) {
+	if w.config.SpillPath == "" {
+		w.failed.Add(int64(len(entries)))
+		return
+	}
+
+	if err := appendSpill(w.config.SpillPath, entries); err != nil {
+		log.Printf("calculation logs: failed to spill %d logs: %v", len(entries), err)
+		w.failed.Add(int64(len(entries)))
+		return
+	}
+
+	w.spilled.Add(int64(len(entries)))
+	w.spillPending = true
+}
+
+// replaySpill writes spilled logs back to the database. The spill file is
+// renamed first, so logs spilled meanwhile start a new file; a replay that
+// fails is retried from the start, and logs already written are skipped by
+// their IDs.
+func (w *CalculationLogWriter) replaySpill() {
+	if !w.spillPending {
+		return
+	}
+
+	replayPath := w.replayPath()
+	if !fileExists(replayPath) {
+		if err := os.Rename(w.config.SpillPath, replayPath); err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				w.spillPending = false
+			} else {
+				log.Printf("calculation logs: failed to replay spill file: %v", err)
+			}
+			return
+		}
+	}
+
+	if err := w.replayFile(replayPath); err != nil {
+		log.Printf("calculation logs: failed to replay spill file: %v", err)
+		w.healthy = false
+		return
+	}
+	w.healthy = true
+
+	if err := os.Remove(replayPath); err != nil {
+		log.Printf("calculation logs: failed to remove replayed spill file: %v", err)
+		return
+	}
+	w.spillPending = fileExists(w.config.SpillPath)
+}
+
+// replayFile writes the logs in a spill file in batches
+func (w *CalculationLogWriter) replayFile(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64<<10), maxSpilledLogSize)
+
+	replayed := 0
+	batch := make([]*domain.CalculationLog, 0, w.config.BatchSize)
+	writeBatch := func() error {
+		written, err := w.write(batch)
+		w.written.Add(int64(written))
+		if err != nil {
+			return err
+		}
+		replayed += written
+		batch = batch[:0]
+		return nil
+	}
+
+	for scanner.Scan() {
+		entry := &domain.CalculationLog{}
+		if err := json.Unmarshal(scanner.Bytes(), entry); err != nil {
+			// A partly written line from a crash
+			w.failed.Add(1)
+			continue
+		}
+		batch = append(batch, entry)
+		if len(batch) >= w.config.BatchSize {
+			if err := writeBatch(); err != nil {
+				return err
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	if len(batch) > 0 {
+		if err := writeBatch(); err != nil {
+			return err
+		}
+	}
+
+	log.Printf("calculation logs: replayed %d spilled logs", replayed)
+	return nil
+}
+
+// reportDrops logs how many logs were dropped since the last report
+func (w *CalculationLogWriter) reportDrops() {
+	dropped := w.dropped.Load()
+	if dropped > w.reportedDrops {
+		log.Printf("calculation logs: dropped %d logs because the queue was full", dropped-w.reportedDrops)
+		w.reportedDrops = dropped
+	}
+}
+
+// replayPath is where the spill file is moved while it is replayed
+func (w *CalculationLogWriter) replayPath() string {
+	return w.config.SpillPath + ".replay"
+}
+
+// appendSpill appends logs to a file as JSON lines
+func appendSpill(path string, entries []*domain.CalculationLog) error {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+
+	writer := bufio.NewWriter(file)
+	encoder := json.NewEncoder(writer)
+	for _, entry := range entries {
+		if err := encoder.Encode(entry); err != nil {
+			file.Close()
+			return err
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// fileExists reports whether a file exists at path
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
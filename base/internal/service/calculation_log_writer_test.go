@@ -0,0 +1,197 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/saintparish4/harmonia/internal/domain"
+)
+
+// fakeCalculationLogRepo stores batches in memory, failing while down is set
+type fakeCalculationLogRepo struct {
+	domain.CalculationLogRepository
+	mu      sync.Mutex
+	down    bool
+	block   chan struct{}
+	batches [][]*domain.CalculationLog
+	stored  map[uuid.UUID]bool
+}
+
+func (r *fakeCalculationLogRepo) CreateBatch(ctx context.Context, logs []*domain.CalculationLog) (int, error) {
+	if r.block != nil {
+		<-r.block
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.down {
+		return 0, errors.New("connection refused")
+	}
+	if r.stored == nil {
+		r.stored = make(map[uuid.UUID]bool)
+	}
+
+	written := 0
+	for _, entry := range logs {
+		if !r.stored[entry.ID] {
+			r.stored[entry.ID] = true
+			written++
+		}
+	}
+	r.batches = append(r.batches, logs)
+	return written, nil
+}
+
+func (r *fakeCalculationLogRepo) setDown(down bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.down = down
+}
+
+func (r *fakeCalculationLogRepo) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.stored)
+}
+
+func newTestCalculationLog() *domain.CalculationLog {
+	return &domain.CalculationLog{
+		ID:           uuid.New(),
+		UserID:       uuid.New(),
+		StrategyType: "cost_plus",
+		InputData:    map[string]interface{}{"base_price": 10.0},
+		OutputData:   map[string]interface{}{"final_price": 12.0},
+		CreatedAt:    time.Now(),
+	}
+}
+
+func closeWriter(t *testing.T, writer *CalculationLogWriter) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := writer.Close(ctx); err != nil {
+		t.Fatalf("failed to close writer: %v", err)
+	}
+}
+
+func TestCalculationLogWriterBatches(t *testing.T) {
+	repo := &fakeCalculationLogRepo{}
+	writer := NewCalculationLogWriter(repo, CalculationLogWriterConfig{
+		QueueSize:     100,
+		BatchSize:     4,
+		FlushInterval: time.Hour,
+	})
+	writer.Start()
+
+	for i := 0; i < 10; i++ {
+		if err := writer.Enqueue(newTestCalculationLog()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	closeWriter(t, writer)
+
+	// Full batches are written as they fill; Close flushes the rest
+	sizes := make([]int, len(repo.batches))
+	for i, batch := range repo.batches {
+		sizes[i] = len(batch)
+	}
+	if len(sizes) != 3 || sizes[0] != 4 || sizes[1] != 4 || sizes[2] != 2 {
+		t.Errorf("expected batches of 4, 4 and 2, got %v", sizes)
+	}
+	if stats := writer.Stats(); stats.Written != 10 || stats.Dropped != 0 {
+		t.Errorf("expected 10 written, got %+v", stats)
+	}
+}
+
+func TestCalculationLogWriterDropsWhenFull(t *testing.T) {
+	repo := &fakeCalculationLogRepo{block: make(chan struct{})}
+	writer := NewCalculationLogWriter(repo, CalculationLogWriterConfig{
+		QueueSize:      2,
+		BatchSize:      1,
+		FlushInterval:  time.Hour,
+		EnqueueTimeout: time.Millisecond,
+	})
+	writer.Start()
+
+	// The first log holds the loop in CreateBatch; two more fill the queue
+	dropped := 0
+	for i := 0; i < 6; i++ {
+		if err := writer.Enqueue(newTestCalculationLog()); errors.Is(err, domain.ErrCalculationLogQueueFull) {
+			dropped++
+		}
+	}
+	close(repo.block)
+	closeWriter(t, writer)
+
+	stats := writer.Stats()
+	if dropped == 0 || stats.Dropped != int64(dropped) {
+		t.Errorf("expected the dropped logs to be counted, dropped %d with %+v", dropped, stats)
+	}
+	if stats.Written+stats.Dropped != 6 {
+		t.Errorf("expected every log to be written or dropped, got %+v", stats)
+	}
+
+	if err := writer.Enqueue(newTestCalculationLog()); !errors.Is(err, domain.ErrCalculationLogQueueFull) {
+		t.Errorf("expected a closed writer to drop logs, got %v", err)
+	}
+}
+
+func TestCalculationLogWriterSpillsAndReplays(t *testing.T) {
+	spillPath := filepath.Join(t.TempDir(), "calculation_logs.ndjson")
+	repo := &fakeCalculationLogRepo{down: true}
+	config := CalculationLogWriterConfig{
+		QueueSize:     100,
+		BatchSize:     10,
+		FlushInterval: time.Hour,
+		SpillPath:     spillPath,
+	}
+
+	writer := NewCalculationLogWriter(repo, config)
+	writer.Start()
+	logs := make([]*domain.CalculationLog, 5)
+	for i := range logs {
+		logs[i] = newTestCalculationLog()
+		_ = writer.Enqueue(logs[i])
+	}
+	closeWriter(t, writer)
+
+	if stats := writer.Stats(); stats.Spilled != 5 || stats.Written != 0 {
+		t.Fatalf("expected 5 spilled logs, got %+v", stats)
+	}
+	if _, err := os.Stat(spillPath); err != nil {
+		t.Fatalf("expected a spill file: %v", err)
+	}
+
+	// The next writer replays the spill file once the database is back
+	repo.setDown(false)
+	config.FlushInterval = 10 * time.Millisecond
+	writer = NewCalculationLogWriter(repo, config)
+	writer.Start()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for repo.count() < len(logs) && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	closeWriter(t, writer)
+
+	if repo.count() != len(logs) {
+		t.Fatalf("expected %d replayed logs, got %d", len(logs), repo.count())
+	}
+	for _, entry := range logs {
+		if !repo.stored[entry.ID] {
+			t.Errorf("expected log %s to be replayed", entry.ID)
+		}
+	}
+	for _, path := range []string{spillPath, spillPath + ".replay"} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("expected %s to be removed, got %v", path, err)
+		}
+	}
+}
@@ -0,0 +1,185 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/saintparish4/harmonia/internal/domain"
+)
+
+// CalculationReplayService re-runs logged calculations, so a disputed price
+// can be reproduced and any drift since explained
+type CalculationReplayService struct {
+	engine *PricingEngine
+	rules  domain.PricingRuleRepository
+}
+
+// NewCalculationReplayService creates a new calculation replay service
+func NewCalculationReplayService(engine *PricingEngine, rules domain.PricingRuleRepository) *CalculationReplayService {
+	return &CalculationReplayService{engine: engine, rules: rules}
+}
+
+// Replay re-runs a logged calculation with the rule revision and evaluation
+// time it originally used, and compares the result with the logged output.
+// The caller must own the log.
+func (s *CalculationReplayService) Replay(ctx context.Context, log *domain.CalculationLog) (*domain.CalculationReplay, error) {
+	inputs, ok := InputsFromLog(log.InputData)
+	if !ok {
+		return nil, fmt.Errorf("%w: the log holds no input", domain.ErrReplayUnsupported)
+	}
+
+	replay := &domain.CalculationReplay{
+		LogID:                 log.ID,
+		StrategyType:          log.StrategyType,
+		RequestedAt:           RequestedAtFromLog(log),
+		OriginalEngineVersion: log.EngineVersion,
+		EngineVersion:         EngineVersion,
+		OriginalError:         log.Error,
+		Reasons:               []string{},
+		Changes:               []domain.JSONChange{},
+	}
+	if log.Error == "" {
+		if price, ok := PriceFromLog(log.OutputData); ok {
+			replay.OriginalPrice = &price
+		}
+	}
+
+	// Inline requests carry their config in the inputs; saved rules supply their own
+	config := inputs
+	ruleChanged := false
+	if log.RuleID != nil {
+		revision, changed, err := s.replayRevision(ctx, log)
+		if err != nil {
+			return nil, err
+		}
+		replay.RuleID = log.RuleID
+		replay.RuleRevision = revision.Revision
+		replay.StrategyType = revision.StrategyType
+		config = revision.Config
+		ruleChanged = changed
+	} else if _, requested := log.InputData["rule_id"]; requested {
+		// Failed calculations only record the rule they asked for, not the revision
+		return nil, fmt.Errorf("%w: the rule revision of the calculation was not recorded", domain.ErrReplayUnsupported)
+	}
+	if replay.StrategyType == "" {
+		return nil, fmt.Errorf("%w: the log holds no strategy", domain.ErrReplayUnsupported)
+	}
+
+	productSKU, _ := log.InputData["product_sku"].(string)
+	pricingReq := &domain.PricingRequest{
+		Strategy:    replay.StrategyType,
+		RuleID:      log.RuleID,
+		ProductSKU:  productSKU,
+		Inputs:      inputs,
+		RequestedAt: replay.RequestedAt,
+	}
+
+	response, calcErr := s.engine.Calculate(pricingReq, config)
+	replay.ReplayedAt = time.Now()
+
+	switch {
+	case calcErr != nil:
+		replay.ReplayedError = calcErr.Error()
+		replay.Drift = replay.ReplayedError != replay.OriginalError
+	case log.Error != "":
+		replay.Drift = true
+	default:
+		price := response.FinalPrice
+		replay.ReplayedPrice = &price
+
+		output, err := replayOutput(response)
+		if err != nil {
+			return nil, err
+		}
+		replay.Changes = DiffJSON(comparableOutput(log.OutputData), output)
+		replay.Drift = len(replay.Changes) > 0
+	}
+
+	if replay.Drift {
+		replay.Reasons = driftReasons(log, ruleChanged)
+	}
+	return replay, nil
+}
+
+// replayRevision returns the rule revision a log was priced with. Logs from
+// before revisions were recorded replay the current rule; changed reports
+// whether it was edited after the calculation.
+func (s *CalculationReplayService) replayRevision(ctx context.Context, log *domain.CalculationLog) (*domain.PricingRuleRevision, bool, error) {
+	if log.RuleRevision > 0 {
+		revision, err := s.rules.GetRevision(ctx, *log.RuleID, log.RuleRevision)
+		if err != nil {
+			return nil, false, fmt.Errorf("%w: %v", domain.ErrReplayUnsupported, err)
+		}
+		return revision, false, nil
+	}
+
+	rule, err := s.rules.GetByID(ctx, *log.RuleID)
+	if err != nil {
+		return nil, false, fmt.Errorf("%w: %v", domain.ErrReplayUnsupported, err)
+	}
+	revision := &domain.PricingRuleRevision{
+		RuleID:       rule.ID,
+		Revision:     rule.Revision,
+		StrategyType: rule.StrategyType,
+		Config:       rule.Config,
+	}
+	return revision, rule.UpdatedAt.After(log.CreatedAt), nil
+}
+
+// driftReasons lists what changed between a calculation and its replay
+func driftReasons(log *domain.CalculationLog, ruleChanged bool) []string {
+	reasons := []string{}
+	if ruleChanged {
+		reasons = append(reasons, domain.ReplayDriftRuleChanged)
+	}
+	if log.EngineVersion != "" && log.EngineVersion != EngineVersion {
+		reasons = append(reasons, domain.ReplayDriftEngineChanged)
+	}
+	if len(reasons) == 0 {
+		reasons = append(reasons, domain.ReplayDriftUnexplained)
+	}
+	return reasons
+}
+
+// replayOutput decodes a replayed calculation into the JSON shape of a
+// logged output, without its calculation time
+func replayOutput(response *domain.PricingResponse) (map[string]interface{}, error) {
+	encoded, err := json.Marshal(map[string]interface{}{
+		"final_price": response.FinalPrice,
+		"breakdown": map[string]interface{}{
+			"strategy":  response.Strategy,
+			"breakdown": response.Breakdown,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode replayed output: %w", err)
+	}
+
+	var output map[string]interface{}
+	if err := json.Unmarshal(encoded, &output); err != nil {
+		return nil, fmt.Errorf("failed to decode replayed output: %w", err)
+	}
+	return output, nil
+}
+
+// comparableOutput copies a logged output without its calculation time,
+// which differs on every run
+func comparableOutput(output map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(output))
+	for key, value := range output {
+		out[key] = value
+	}
+
+	if breakdown, ok := output["breakdown"].(map[string]interface{}); ok {
+		trimmed := make(map[string]interface{}, len(breakdown))
+		for key, value := range breakdown {
+			if key != "calculated_at" {
+				trimmed[key] = value
+			}
+		}
+		out["breakdown"] = trimmed
+	}
+	return out
+}
@@ -0,0 +1,251 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/saintparish4/harmonia/internal/domain"
+)
+
+// fakeReplayRuleRepo serves a rule and its revisions from memory
+type fakeReplayRuleRepo struct {
+	domain.PricingRuleRepository
+	rule      *domain.PricingRule
+	revisions map[int]*domain.PricingRuleRevision
+}
+
+func (r *fakeReplayRuleRepo) GetByID(ctx context.Context, id uuid.UUID) (*domain.PricingRule, error) {
+	if r.rule == nil || r.rule.ID != id {
+		return nil, errors.New("pricing rule not found")
+	}
+	return r.rule, nil
+}
+
+func (r *fakeReplayRuleRepo) GetRevision(ctx context.Context, ruleID uuid.UUID, revision int) (*domain.PricingRuleRevision, error) {
+	rev, exists := r.revisions[revision]
+	if !exists || rev.RuleID != ruleID {
+		return nil, errors.New("rule revision not found")
+	}
+	return rev, nil
+}
+
+// replayTestLog logs a cost plus calculation of base_cost 100 priced at price
+func replayTestLog(ruleID *uuid.UUID, revision int, price float64) *domain.CalculationLog {
+	return &domain.CalculationLog{
+		ID:            uuid.New(),
+		UserID:        uuid.New(),
+		RuleID:        ruleID,
+		RuleRevision:  revision,
+		StrategyType:  domain.StrategyTypeCostPlus,
+		EngineVersion: EngineVersion,
+		InputData:     map[string]interface{}{"base_price": 100.0, "quantity": 1.0, "context": map[string]interface{}{}, "strategy_type": "cost_plus"},
+		OutputData:    replayTestOutput(price),
+		CreatedAt:     time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC),
+	}
+}
+
+// replayTestOutput is the logged output of a 25% cost plus markup on a base cost of 100
+func replayTestOutput(price float64) map[string]interface{} {
+	return map[string]interface{}{
+		"final_price": price,
+		"breakdown": map[string]interface{}{
+			"strategy":      "cost_plus",
+			"calculated_at": "2026-03-01T12:00:00Z",
+			"breakdown": map[string]interface{}{
+				"base_price": 100.0,
+				"adjustments": []interface{}{
+					map[string]interface{}{"type": "markup", "description": "percentage markup", "amount": 25.0, "applied": 25.0},
+				},
+				"details": map[string]interface{}{
+					"base_cost": 100.0, "markup_type": "percentage", "markup_value": 25.0, "markup_amount": 25.0,
+					"subtotal": 125.0, "tax_rate": 0.0, "tax_amount": 0.0, "final_price": 125.0,
+				},
+			},
+		},
+	}
+}
+
+func replayTestRules(ruleID uuid.UUID) *fakeReplayRuleRepo {
+	return &fakeReplayRuleRepo{
+		rule: &domain.PricingRule{
+			ID:           ruleID,
+			StrategyType: domain.StrategyTypeCostPlus,
+			Config:       map[string]interface{}{"markup_type": "percentage", "markup_value": 40.0},
+			Revision:     2,
+			UpdatedAt:    time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC),
+		},
+		revisions: map[int]*domain.PricingRuleRevision{
+			1: {
+				RuleID:       ruleID,
+				Revision:     1,
+				StrategyType: domain.StrategyTypeCostPlus,
+				Config:       map[string]interface{}{"markup_type": "percentage", "markup_value": 25.0},
+			},
+		},
+	}
+}
+
+func TestCalculationReplayMatches(t *testing.T) {
+	ruleID := uuid.New()
+	replayer := NewCalculationReplayService(NewPricingEngine(), replayTestRules(ruleID))
+
+	// Revision 1 priced the log; the current revision 2 would not
+	replay, err := replayer.Replay(context.Background(), replayTestLog(&ruleID, 1, 125))
+	if err != nil {
+		t.Fatalf("Replay failed: %v", err)
+	}
+
+	if replay.Drift {
+		t.Errorf("expected no drift, got changes %+v and reasons %v", replay.Changes, replay.Reasons)
+	}
+	if replay.RuleRevision != 1 {
+		t.Errorf("expected revision 1 to be replayed, got %d", replay.RuleRevision)
+	}
+	if replay.ReplayedPrice == nil || *replay.ReplayedPrice != 125 {
+		t.Errorf("expected a replayed price of 125, got %v", replay.ReplayedPrice)
+	}
+	if !replay.RequestedAt.Equal(time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)) {
+		t.Errorf("expected to replay at the log time, got %v", replay.RequestedAt)
+	}
+}
+
+func TestCalculationReplayDrift(t *testing.T) {
+	ruleID := uuid.New()
+
+	tests := []struct {
+		name        string
+		log         *domain.CalculationLog
+		wantPrice   float64
+		wantReasons []string
+	}{
+		{
+			name: "engine changed",
+			log: func() *domain.CalculationLog {
+				log := replayTestLog(&ruleID, 1, 126)
+				log.EngineVersion = "0.9.0"
+				return log
+			}(),
+			wantPrice:   125,
+			wantReasons: []string{domain.ReplayDriftEngineChanged},
+		},
+		{
+			name:        "unrecorded revision of a rule changed since",
+			log:         replayTestLog(&ruleID, 0, 125),
+			wantPrice:   140,
+			wantReasons: []string{domain.ReplayDriftRuleChanged},
+		},
+		{
+			name:        "nothing changed",
+			log:         replayTestLog(&ruleID, 1, 126),
+			wantPrice:   125,
+			wantReasons: []string{domain.ReplayDriftUnexplained},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			replayer := NewCalculationReplayService(NewPricingEngine(), replayTestRules(ruleID))
+
+			replay, err := replayer.Replay(context.Background(), tt.log)
+			if err != nil {
+				t.Fatalf("Replay failed: %v", err)
+			}
+
+			if !replay.Drift {
+				t.Fatal("expected drift")
+			}
+			if replay.ReplayedPrice == nil || *replay.ReplayedPrice != tt.wantPrice {
+				t.Errorf("expected a replayed price of %v, got %v", tt.wantPrice, replay.ReplayedPrice)
+			}
+			if len(replay.Reasons) != len(tt.wantReasons) || replay.Reasons[0] != tt.wantReasons[0] {
+				t.Errorf("expected reasons %v, got %v", tt.wantReasons, replay.Reasons)
+			}
+
+			found := false
+			for _, change := range replay.Changes {
+				if change.Path == "/final_price" {
+					found = true
+				}
+			}
+			if !found {
+				t.Errorf("expected a /final_price change, got %+v", replay.Changes)
+			}
+		})
+	}
+}
+
+func TestCalculationReplayInlineConfig(t *testing.T) {
+	replayer := NewCalculationReplayService(NewPricingEngine(), &fakeReplayRuleRepo{})
+
+	log := replayTestLog(nil, 0, 125)
+	log.InputData["context"] = map[string]interface{}{"markup_type": "percentage", "markup_value": 25.0}
+
+	replay, err := replayer.Replay(context.Background(), log)
+	if err != nil {
+		t.Fatalf("Replay failed: %v", err)
+	}
+	if replay.Drift {
+		t.Errorf("expected no drift, got changes %+v", replay.Changes)
+	}
+}
+
+func TestCalculationReplayFailedLog(t *testing.T) {
+	replayer := NewCalculationReplayService(NewPricingEngine(), &fakeReplayRuleRepo{})
+
+	// The markup was missing, and still is
+	log := replayTestLog(nil, 0, 0)
+	log.OutputData = map[string]interface{}{}
+	log.InputData["context"] = map[string]interface{}{"markup_type": "percentage"}
+
+	replay, err := replayer.Replay(context.Background(), log)
+	if err != nil {
+		t.Fatalf("Replay failed: %v", err)
+	}
+	if replay.ReplayedError == "" {
+		t.Fatal("expected the replay to fail")
+	}
+
+	log.Error = replay.ReplayedError
+	replay, err = replayer.Replay(context.Background(), log)
+	if err != nil {
+		t.Fatalf("Replay failed: %v", err)
+	}
+	if replay.Drift {
+		t.Errorf("expected no drift when the calculation fails the same way, got reasons %v", replay.Reasons)
+	}
+}
+
+func TestCalculationReplayUnsupported(t *testing.T) {
+	missingRule := uuid.New()
+
+	tests := []struct {
+		name string
+		log  *domain.CalculationLog
+	}{
+		{"no input", &domain.CalculationLog{ID: uuid.New(), StrategyType: domain.StrategyTypeCostPlus}},
+		{"missing revision", replayTestLog(&missingRule, 3, 125)},
+		{
+			name: "failed with an unrecorded rule",
+			log: func() *domain.CalculationLog {
+				log := replayTestLog(nil, 0, 0)
+				log.InputData["rule_id"] = missingRule.String()
+				log.Error = "pricing rule not found"
+				return log
+			}(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			replayer := NewCalculationReplayService(NewPricingEngine(), replayTestRules(uuid.New()))
+
+			_, err := replayer.Replay(context.Background(), tt.log)
+			if !errors.Is(err, domain.ErrReplayUnsupported) {
+				t.Errorf("expected ErrReplayUnsupported, got %v", err)
+			}
+		})
+	}
+}
@@ -14,24 +14,58 @@ func (s *CostPlusStrategy) Name() string {
 	return domain.StrategyTypeCostPlus
 }
 
+// Schema describes the cost plus config and inputs
+func (s *CostPlusStrategy) Schema() domain.StrategySchema {
+	return domain.StrategySchema{
+		Type:        domain.StrategyTypeCostPlus,
+		Name:        "Cost-Plus Pricing",
+		Description: "Adds a fixed markup or percentage to the base cost",
+		ConfigSchema: rootSchema(objectSchema(withBounds(map[string]interface{}{
+			"markup_type":  enumSchema("How markup_value is applied (default: percentage)", "percentage", "fixed"),
+			"markup_value": numberSchema("Markup percentage or fixed amount", nonNegative),
+			"tax_rate": map[string]interface{}{
+				"type":        "number",
+				"description": "Tax rate applied to the subtotal as a fraction (e.g., 0.08)",
+				"minimum":     0,
+				"maximum":     1,
+			},
+			"currency": currencySchema(),
+		}))),
+		InputSchema: rootSchema(objectSchema(map[string]interface{}{
+			"base_cost":    numberSchema("Cost of the item before markup", nonNegative),
+			"markup_type":  enumSchema("Overrides the configured markup_type", "percentage", "fixed"),
+			"markup_value": numberSchema("Overrides the configured markup_value (required if not configured)", nonNegative),
+			"tax_rate":     numberSchema("Overrides the configured tax_rate", nonNegative),
+			"currency":     currencySchema(),
+		}, "base_cost")),
+	}
+}
+
 // Validate checks if the config is valid for cost plus pricing
 func (s *CostPlusStrategy) Validate(config map[string]interface{}) error {
 	// No required config fields - all inputs come from request
 	// Optional config: markup_type, markup_value, tax_rate, min_price, max_price
+	ve := &domain.ValidationError{}
 
-	// If markup values are in config, validate them
-	if markupValue, ok := domain.GetFloat64(config, "markup_value"); ok {
-		if markupValue < 0 {
-			return fmt.Errorf("markup_value cannot be negative")
+	if raw, exists := config["markup_type"]; exists {
+		if markupType, ok := raw.(string); !ok || (markupType != "percentage" && markupType != "fixed") {
+			ve.Add(domain.JSONPointer("markup_type"), "must be one of: percentage, fixed")
 		}
 	}
 
-	if taxRate, ok := domain.GetFloat64(config, "tax_rate"); ok {
-		if taxRate < 0 || taxRate > 1 {
-			return fmt.Errorf("tax_rate must be between 0 and 1")
+	// If markup values are in config, validate them
+	validateOptionalNumber(ve, config, "markup_value", 0)
+
+	if raw, exists := config["tax_rate"]; exists {
+		if taxRate, ok := convertToFloat(raw); !ok || taxRate < 0 || taxRate > 1 {
+			ve.Add(domain.JSONPointer("tax_rate"), "must be a number between 0 and 1")
 		}
 	}
-	return nil
+
+	validateOptionalString(ve, config, "currency")
+	validateBounds(ve, config)
+
+	return ve.Err()
 }
 
 // Calculate computes the cost-plus price
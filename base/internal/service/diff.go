@@ -0,0 +1,91 @@
+package service
+
+import (
+	"reflect"
+	"sort"
+
+	"github.com/saintparish4/harmonia/internal/domain"
+)
+
+// DiffJSON returns the structural differences between two decoded JSON
+// documents. Objects are compared key by key and arrays index by index;
+// numbers compare equal regardless of their Go type.
+func DiffJSON(from, to interface{}) []domain.JSONChange {
+	changes := []domain.JSONChange{}
+	diffValue(&changes, "", from, to)
+	return changes
+}
+
+func diffValue(changes *[]domain.JSONChange, path string, from, to interface{}) {
+	fromMap, fromIsMap := from.(map[string]interface{})
+	toMap, toIsMap := to.(map[string]interface{})
+	if fromIsMap && toIsMap {
+		diffObject(changes, path, fromMap, toMap)
+		return
+	}
+
+	fromList, fromIsList := from.([]interface{})
+	toList, toIsList := to.([]interface{})
+	if fromIsList && toIsList {
+		diffArray(changes, path, fromList, toList)
+		return
+	}
+
+	if !jsonEqual(from, to) {
+		*changes = append(*changes, domain.JSONChange{Op: "replace", Path: path, From: from, To: to})
+	}
+}
+
+func diffObject(changes *[]domain.JSONChange, path string, from, to map[string]interface{}) {
+	keys := make([]string, 0, len(from)+len(to))
+	for key := range from {
+		keys = append(keys, key)
+	}
+	for key := range to {
+		if _, seen := from[key]; !seen {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		child := path + domain.JSONPointer(key)
+		fromValue, inFrom := from[key]
+		toValue, inTo := to[key]
+		switch {
+		case !inFrom:
+			*changes = append(*changes, domain.JSONChange{Op: "add", Path: child, To: toValue})
+		case !inTo:
+			*changes = append(*changes, domain.JSONChange{Op: "remove", Path: child, From: fromValue})
+		default:
+			diffValue(changes, child, fromValue, toValue)
+		}
+	}
+}
+
+func diffArray(changes *[]domain.JSONChange, path string, from, to []interface{}) {
+	shared := len(from)
+	if len(to) < shared {
+		shared = len(to)
+	}
+	for i := 0; i < shared; i++ {
+		diffValue(changes, path+domain.JSONPointer(i), from[i], to[i])
+	}
+	for i := shared; i < len(to); i++ {
+		*changes = append(*changes, domain.JSONChange{Op: "add", Path: path + domain.JSONPointer(i), To: to[i]})
+	}
+	// Removals are listed from the end so the paths stay valid when applied in order
+	for i := len(from) - 1; i >= shared; i-- {
+		*changes = append(*changes, domain.JSONChange{Op: "remove", Path: path + domain.JSONPointer(i), From: from[i]})
+	}
+}
+
+// jsonEqual compares leaf values, treating all numeric types as numbers
+func jsonEqual(a, b interface{}) bool {
+	aNum, aIsNum := convertToFloat(a)
+	bNum, bIsNum := convertToFloat(b)
+	if aIsNum || bIsNum {
+		return aIsNum && bIsNum && aNum == bNum
+	}
+	return reflect.DeepEqual(a, b)
+}
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/saintparish4/harmonia/internal/domain"
+)
+
+func TestDiffJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		from interface{}
+		to   interface{}
+		want []domain.JSONChange
+	}{
+		{
+			name: "identical documents",
+			from: map[string]interface{}{"markup": 1.5, "tiers": []interface{}{1.0, 2.0}},
+			to:   map[string]interface{}{"markup": 1.5, "tiers": []interface{}{1.0, 2.0}},
+			want: []domain.JSONChange{},
+		},
+		{
+			name: "numbers compare across types",
+			from: map[string]interface{}{"quantity": 10},
+			to:   map[string]interface{}{"quantity": 10.0},
+			want: []domain.JSONChange{},
+		},
+		{
+			name: "added, removed and replaced keys",
+			from: map[string]interface{}{"a": 1.0, "b": "x"},
+			to:   map[string]interface{}{"b": "y", "c": true},
+			want: []domain.JSONChange{
+				{Op: "remove", Path: "/a", From: 1.0},
+				{Op: "replace", Path: "/b", From: "x", To: "y"},
+				{Op: "add", Path: "/c", To: true},
+			},
+		},
+		{
+			name: "nested objects and escaped keys",
+			from: map[string]interface{}{"config": map[string]interface{}{"a/b": 1.0}},
+			to:   map[string]interface{}{"config": map[string]interface{}{"a/b": 2.0}},
+			want: []domain.JSONChange{
+				{Op: "replace", Path: "/config/a~1b", From: 1.0, To: 2.0},
+			},
+		},
+		{
+			name: "arrays grow and shrink by index",
+			from: map[string]interface{}{"list": []interface{}{"a", "b", "c"}},
+			to:   map[string]interface{}{"list": []interface{}{"a", "x"}},
+			want: []domain.JSONChange{
+				{Op: "replace", Path: "/list/1", From: "b", To: "x"},
+				{Op: "remove", Path: "/list/2", From: "c"},
+			},
+		},
+		{
+			name: "type change replaces the whole value",
+			from: map[string]interface{}{"v": map[string]interface{}{"k": 1.0}},
+			to:   map[string]interface{}{"v": "flat"},
+			want: []domain.JSONChange{
+				{Op: "replace", Path: "/v", From: map[string]interface{}{"k": 1.0}, To: "flat"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := DiffJSON(tt.from, tt.to)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DiffJSON() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,896 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Expression evaluator limits
+const (
+	maxExpressionLength = 2048  // maximum characters in a single expression
+	maxExpressionDepth  = 64    // maximum nesting depth of the parse tree
+	defaultMaxSteps     = 10000 // default evaluation step budget per calculation
+	maxStepsLimit       = 100000
+)
+
+// Expression evaluation errors
+var (
+	ErrExpressionSyntax   = errors.New("expression syntax error")
+	ErrExpressionType     = errors.New("expression type error")
+	ErrExpressionEval     = errors.New("expression evaluation error")
+	ErrExpressionTooLarge = errors.New("expression exceeds size limits")
+	ErrStepLimitExceeded  = errors.New("expression step limit exceeded")
+)
+
+// exprType is the static type of an expression
+type exprType int
+
+const (
+	typeNumber exprType = iota
+	typeBool
+	typeString
+)
+
+// String returns the type name used in config and error messages
+func (t exprType) String() string {
+	switch t {
+	case typeNumber:
+		return "number"
+	case typeBool:
+		return "bool"
+	case typeString:
+		return "string"
+	}
+	return "unknown"
+}
+
+// parseExprType converts a config type name into an exprType
+func parseExprType(name string) (exprType, bool) {
+	switch strings.ToLower(name) {
+	case "number", "float", "int":
+		return typeNumber, true
+	case "bool", "boolean":
+		return typeBool, true
+	case "string":
+		return typeString, true
+	}
+	return 0, false
+}
+
+// evalBudget tracks the number of evaluation steps taken
+type evalBudget struct {
+	steps int
+	max   int
+}
+
+// step consumes one evaluation step
+func (b *evalBudget) step() error {
+	b.steps++
+	if b.steps > b.max {
+		return fmt.Errorf("%w: more than %d steps", ErrStepLimitExceeded, b.max)
+	}
+	return nil
+}
+
+// exprNode is a node in a parsed expression tree
+type exprNode interface {
+	// check returns the static type of the node given identifier types
+	check(types map[string]exprType) (exprType, error)
+
+	// eval computes the node value given identifier values
+	eval(vars map[string]interface{}, budget *evalBudget) (interface{}, error)
+
+	// idents appends every identifier referenced by the node
+	idents(out map[string]bool)
+}
+
+// expression is a parsed, reusable expression
+type expression struct {
+	source string
+	root   exprNode
+}
+
+// parseExpression parses an expression string into an expression tree
+func parseExpression(source string) (*expression, error) {
+	if strings.TrimSpace(source) == "" {
+		return nil, fmt.Errorf("%w: expression is empty", ErrExpressionSyntax)
+	}
+	if len(source) > maxExpressionLength {
+		return nil, fmt.Errorf("%w: longer than %d characters", ErrExpressionTooLarge, maxExpressionLength)
+	}
+
+	tokens, err := tokenize(source)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &exprParser{tokens: tokens}
+	root, err := p.parseTernary()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, fmt.Errorf("%w: unexpected %q at position %d", ErrExpressionSyntax, tok.text, tok.pos)
+	}
+
+	return &expression{source: source, root: root}, nil
+}
+
+// Check type-checks the expression against the given identifier types
+func (e *expression) Check(types map[string]exprType) (exprType, error) {
+	return e.root.check(types)
+}
+
+// Eval evaluates the expression against the given identifier values
+func (e *expression) Eval(vars map[string]interface{}, budget *evalBudget) (interface{}, error) {
+	return e.root.eval(vars, budget)
+}
+
+// Identifiers returns every identifier referenced by the expression
+func (e *expression) Identifiers() map[string]bool {
+	out := make(map[string]bool)
+	e.root.idents(out)
+	return out
+}
+
+// --- Lexer ---
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokNumber
+	tokString
+	tokIdent
+	tokOperator
+	tokLParen
+	tokRParen
+	tokComma
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	num  float64
+	pos  int
+}
+
+// twoCharOperators lists operators that span two characters
+var twoCharOperators = map[string]bool{
+	"<=": true, ">=": true, "==": true, "!=": true, "&&": true, "||": true,
+}
+
+// tokenize splits an expression into tokens
+func tokenize(source string) ([]token, error) {
+	var tokens []token
+	i := 0
+
+	for i < len(source) {
+		ch := source[i]
+
+		switch {
+		case ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r':
+			i++
+
+		case isDigit(ch) || (ch == '.' && i+1 < len(source) && isDigit(source[i+1])):
+			start := i
+			for i < len(source) && (isDigit(source[i]) || source[i] == '.') {
+				i++
+			}
+			// Optional exponent (e.g., 1e-3)
+			if i < len(source) && (source[i] == 'e' || source[i] == 'E') {
+				j := i + 1
+				if j < len(source) && (source[j] == '+' || source[j] == '-') {
+					j++
+				}
+				if j < len(source) && isDigit(source[j]) {
+					i = j
+					for i < len(source) && isDigit(source[i]) {
+						i++
+					}
+				}
+			}
+			text := source[start:i]
+			num, err := strconv.ParseFloat(text, 64)
+			if err != nil {
+				return nil, fmt.Errorf("%w: invalid number %q at position %d", ErrExpressionSyntax, text, start)
+			}
+			tokens = append(tokens, token{kind: tokNumber, text: text, num: num, pos: start})
+
+		case isIdentStart(ch):
+			start := i
+			for i < len(source) && isIdentPart(source[i]) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokIdent, text: source[start:i], pos: start})
+
+		case ch == '"' || ch == '\'':
+			start := i
+			quote := ch
+			i++
+			var sb strings.Builder
+			closed := false
+			for i < len(source) {
+				if source[i] == '\\' && i+1 < len(source) {
+					sb.WriteByte(source[i+1])
+					i += 2
+					continue
+				}
+				if source[i] == quote {
+					closed = true
+					i++
+					break
+				}
+				sb.WriteByte(source[i])
+				i++
+			}
+			if !closed {
+				return nil, fmt.Errorf("%w: unterminated string at position %d", ErrExpressionSyntax, start)
+			}
+			tokens = append(tokens, token{kind: tokString, text: sb.String(), pos: start})
+
+		case ch == '(':
+			tokens = append(tokens, token{kind: tokLParen, text: "(", pos: i})
+			i++
+
+		case ch == ')':
+			tokens = append(tokens, token{kind: tokRParen, text: ")", pos: i})
+			i++
+
+		case ch == ',':
+			tokens = append(tokens, token{kind: tokComma, text: ",", pos: i})
+			i++
+
+		default:
+			if i+1 < len(source) && twoCharOperators[source[i:i+2]] {
+				tokens = append(tokens, token{kind: tokOperator, text: source[i : i+2], pos: i})
+				i += 2
+				continue
+			}
+			if strings.IndexByte("+-*/%<>!?:", ch) >= 0 {
+				tokens = append(tokens, token{kind: tokOperator, text: string(ch), pos: i})
+				i++
+				continue
+			}
+			return nil, fmt.Errorf("%w: unexpected character %q at position %d", ErrExpressionSyntax, ch, i)
+		}
+	}
+
+	tokens = append(tokens, token{kind: tokEOF, text: "end of expression", pos: len(source)})
+	return tokens, nil
+}
+
+func isDigit(ch byte) bool {
+	return ch >= '0' && ch <= '9'
+}
+
+func isIdentStart(ch byte) bool {
+	return ch == '_' || (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z')
+}
+
+func isIdentPart(ch byte) bool {
+	return isIdentStart(ch) || isDigit(ch)
+}
+
+// isValidIdentifier checks if a name can be referenced from an expression
+func isValidIdentifier(name string) bool {
+	if name == "" || !isIdentStart(name[0]) {
+		return false
+	}
+	for i := 1; i < len(name); i++ {
+		if !isIdentPart(name[i]) {
+			return false
+		}
+	}
+	return name != "true" && name != "false" && exprFunctions[name] == nil
+}
+
+// --- Parser ---
+
+// exprParser is a recursive-descent parser over a token stream
+type exprParser struct {
+	tokens []token
+	pos    int
+	depth  int
+}
+
+func (p *exprParser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *exprParser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *exprParser) isOperator(ops ...string) bool {
+	tok := p.peek()
+	if tok.kind != tokOperator {
+		return false
+	}
+	for _, op := range ops {
+		if tok.text == op {
+			return true
+		}
+	}
+	return false
+}
+
+// enter guards against deeply nested expressions
+func (p *exprParser) enter() error {
+	p.depth++
+	if p.depth > maxExpressionDepth {
+		return fmt.Errorf("%w: nested deeper than %d levels", ErrExpressionTooLarge, maxExpressionDepth)
+	}
+	return nil
+}
+
+func (p *exprParser) leave() {
+	p.depth--
+}
+
+// parseTernary parses: or ( "?" ternary ":" ternary )?
+func (p *exprParser) parseTernary() (exprNode, error) {
+	if err := p.enter(); err != nil {
+		return nil, err
+	}
+	defer p.leave()
+
+	cond, err := p.parseBinary(0)
+	if err != nil {
+		return nil, err
+	}
+	if !p.isOperator("?") {
+		return cond, nil
+	}
+	p.next()
+
+	then, err := p.parseTernary()
+	if err != nil {
+		return nil, err
+	}
+	if !p.isOperator(":") {
+		tok := p.peek()
+		return nil, fmt.Errorf("%w: expected ':' at position %d", ErrExpressionSyntax, tok.pos)
+	}
+	p.next()
+
+	otherwise, err := p.parseTernary()
+	if err != nil {
+		return nil, err
+	}
+	return &condNode{cond: cond, then: then, otherwise: otherwise}, nil
+}
+
+// binaryPrecedence lists binary operators from lowest to highest precedence
+var binaryPrecedence = [][]string{
+	{"||"},
+	{"&&"},
+	{"==", "!="},
+	{"<", "<=", ">", ">="},
+	{"+", "-"},
+	{"*", "/", "%"},
+}
+
+// parseBinary parses left-associative binary operators at the given level
+func (p *exprParser) parseBinary(level int) (exprNode, error) {
+	if level >= len(binaryPrecedence) {
+		return p.parseUnary()
+	}
+
+	left, err := p.parseBinary(level + 1)
+	if err != nil {
+		return nil, err
+	}
+
+	for p.isOperator(binaryPrecedence[level]...) {
+		op := p.next()
+		right, err := p.parseBinary(level + 1)
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryNode{op: op.text, left: left, right: right, pos: op.pos}
+	}
+
+	return left, nil
+}
+
+// parseUnary parses: ("-" | "!") unary | primary
+func (p *exprParser) parseUnary() (exprNode, error) {
+	if p.isOperator("-", "!") {
+		if err := p.enter(); err != nil {
+			return nil, err
+		}
+		defer p.leave()
+
+		op := p.next()
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &unaryNode{op: op.text, operand: operand, pos: op.pos}, nil
+	}
+	return p.parsePrimary()
+}
+
+// parsePrimary parses literals, identifiers, function calls and groups
+func (p *exprParser) parsePrimary() (exprNode, error) {
+	tok := p.next()
+
+	switch tok.kind {
+	case tokNumber:
+		return &literalNode{value: tok.num, typ: typeNumber}, nil
+
+	case tokString:
+		return &literalNode{value: tok.text, typ: typeString}, nil
+
+	case tokIdent:
+		switch tok.text {
+		case "true":
+			return &literalNode{value: true, typ: typeBool}, nil
+		case "false":
+			return &literalNode{value: false, typ: typeBool}, nil
+		}
+		if p.peek().kind == tokLParen {
+			return p.parseCall(tok)
+		}
+		return &identNode{name: tok.text, pos: tok.pos}, nil
+
+	case tokLParen:
+		inner, err := p.parseTernary()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokRParen {
+			return nil, fmt.Errorf("%w: expected ')' at position %d", ErrExpressionSyntax, closing.pos)
+		}
+		return inner, nil
+	}
+
+	return nil, fmt.Errorf("%w: unexpected %q at position %d", ErrExpressionSyntax, tok.text, tok.pos)
+}
+
+// parseCall parses a function call after its name
+func (p *exprParser) parseCall(name token) (exprNode, error) {
+	fn, ok := exprFunctions[name.text]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown function %q at position %d", ErrExpressionSyntax, name.text, name.pos)
+	}
+	p.next() // consume "("
+
+	var args []exprNode
+	if p.peek().kind != tokRParen {
+		for {
+			arg, err := p.parseTernary()
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg)
+
+			if p.peek().kind != tokComma {
+				break
+			}
+			p.next()
+		}
+	}
+
+	if closing := p.next(); closing.kind != tokRParen {
+		return nil, fmt.Errorf("%w: expected ')' at position %d", ErrExpressionSyntax, closing.pos)
+	}
+
+	if len(args) < fn.minArgs || (fn.maxArgs >= 0 && len(args) > fn.maxArgs) {
+		return nil, fmt.Errorf("%w: %s() called with %d arguments at position %d", ErrExpressionSyntax, name.text, len(args), name.pos)
+	}
+
+	return &callNode{name: name.text, fn: fn, args: args, pos: name.pos}, nil
+}
+
+// --- Nodes ---
+
+// literalNode is a constant value
+type literalNode struct {
+	value interface{}
+	typ   exprType
+}
+
+func (n *literalNode) check(map[string]exprType) (exprType, error) {
+	return n.typ, nil
+}
+
+func (n *literalNode) eval(_ map[string]interface{}, budget *evalBudget) (interface{}, error) {
+	if err := budget.step(); err != nil {
+		return nil, err
+	}
+	return n.value, nil
+}
+
+func (n *literalNode) idents(map[string]bool) {}
+
+// identNode references an input or intermediate variable
+type identNode struct {
+	name string
+	pos  int
+}
+
+func (n *identNode) check(types map[string]exprType) (exprType, error) {
+	typ, ok := types[n.name]
+	if !ok {
+		return 0, fmt.Errorf("%w: unknown identifier %q at position %d", ErrExpressionType, n.name, n.pos)
+	}
+	return typ, nil
+}
+
+func (n *identNode) eval(vars map[string]interface{}, budget *evalBudget) (interface{}, error) {
+	if err := budget.step(); err != nil {
+		return nil, err
+	}
+	value, ok := vars[n.name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s is not defined", ErrExpressionEval, n.name)
+	}
+	return value, nil
+}
+
+func (n *identNode) idents(out map[string]bool) {
+	out[n.name] = true
+}
+
+// unaryNode applies negation or logical not
+type unaryNode struct {
+	op      string
+	operand exprNode
+	pos     int
+}
+
+func (n *unaryNode) check(types map[string]exprType) (exprType, error) {
+	typ, err := n.operand.check(types)
+	if err != nil {
+		return 0, err
+	}
+
+	want := typeNumber
+	if n.op == "!" {
+		want = typeBool
+	}
+	if typ != want {
+		return 0, fmt.Errorf("%w: operator %s expects %s, got %s at position %d", ErrExpressionType, n.op, want, typ, n.pos)
+	}
+	return want, nil
+}
+
+func (n *unaryNode) eval(vars map[string]interface{}, budget *evalBudget) (interface{}, error) {
+	if err := budget.step(); err != nil {
+		return nil, err
+	}
+	value, err := n.operand.eval(vars, budget)
+	if err != nil {
+		return nil, err
+	}
+
+	if n.op == "!" {
+		b, _ := value.(bool)
+		return !b, nil
+	}
+	f, _ := value.(float64)
+	return -f, nil
+}
+
+func (n *unaryNode) idents(out map[string]bool) {
+	n.operand.idents(out)
+}
+
+// binaryNode applies an arithmetic, comparison or logical operator
+type binaryNode struct {
+	op    string
+	left  exprNode
+	right exprNode
+	pos   int
+}
+
+func (n *binaryNode) check(types map[string]exprType) (exprType, error) {
+	left, err := n.left.check(types)
+	if err != nil {
+		return 0, err
+	}
+	right, err := n.right.check(types)
+	if err != nil {
+		return 0, err
+	}
+
+	switch n.op {
+	case "+", "-", "*", "/", "%":
+		if left != typeNumber || right != typeNumber {
+			return 0, fmt.Errorf("%w: operator %s expects numbers, got %s and %s at position %d", ErrExpressionType, n.op, left, right, n.pos)
+		}
+		return typeNumber, nil
+
+	case "<", "<=", ">", ">=":
+		if left != typeNumber || right != typeNumber {
+			return 0, fmt.Errorf("%w: operator %s expects numbers, got %s and %s at position %d", ErrExpressionType, n.op, left, right, n.pos)
+		}
+		return typeBool, nil
+
+	case "==", "!=":
+		if left != right {
+			return 0, fmt.Errorf("%w: cannot compare %s with %s at position %d", ErrExpressionType, left, right, n.pos)
+		}
+		return typeBool, nil
+
+	case "&&", "||":
+		if left != typeBool || right != typeBool {
+			return 0, fmt.Errorf("%w: operator %s expects bools, got %s and %s at position %d", ErrExpressionType, n.op, left, right, n.pos)
+		}
+		return typeBool, nil
+	}
+
+	return 0, fmt.Errorf("%w: unknown operator %s at position %d", ErrExpressionSyntax, n.op, n.pos)
+}
+
+func (n *binaryNode) eval(vars map[string]interface{}, budget *evalBudget) (interface{}, error) {
+	if err := budget.step(); err != nil {
+		return nil, err
+	}
+
+	left, err := n.left.eval(vars, budget)
+	if err != nil {
+		return nil, err
+	}
+
+	// Short-circuit logical operators
+	if n.op == "&&" || n.op == "||" {
+		l, _ := left.(bool)
+		if n.op == "&&" && !l {
+			return false, nil
+		}
+		if n.op == "||" && l {
+			return true, nil
+		}
+		right, err := n.right.eval(vars, budget)
+		if err != nil {
+			return nil, err
+		}
+		r, _ := right.(bool)
+		return r, nil
+	}
+
+	right, err := n.right.eval(vars, budget)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case "==":
+		return left == right, nil
+	case "!=":
+		return left != right, nil
+	}
+
+	l, _ := left.(float64)
+	r, _ := right.(float64)
+
+	switch n.op {
+	case "+":
+		return l + r, nil
+	case "-":
+		return l - r, nil
+	case "*":
+		return l * r, nil
+	case "/":
+		if r == 0 {
+			return nil, fmt.Errorf("%w: division by zero at position %d", ErrExpressionEval, n.pos)
+		}
+		return l / r, nil
+	case "%":
+		if r == 0 {
+			return nil, fmt.Errorf("%w: modulo by zero at position %d", ErrExpressionEval, n.pos)
+		}
+		return math.Mod(l, r), nil
+	case "<":
+		return l < r, nil
+	case "<=":
+		return l <= r, nil
+	case ">":
+		return l > r, nil
+	case ">=":
+		return l >= r, nil
+	}
+
+	return nil, fmt.Errorf("%w: unknown operator %s", ErrExpressionEval, n.op)
+}
+
+func (n *binaryNode) idents(out map[string]bool) {
+	n.left.idents(out)
+	n.right.idents(out)
+}
+
+// condNode is a ternary conditional (cond ? then : otherwise)
+type condNode struct {
+	cond      exprNode
+	then      exprNode
+	otherwise exprNode
+}
+
+func (n *condNode) check(types map[string]exprType) (exprType, error) {
+	return checkConditional(types, n.cond, n.then, n.otherwise, "?:")
+}
+
+func (n *condNode) eval(vars map[string]interface{}, budget *evalBudget) (interface{}, error) {
+	if err := budget.step(); err != nil {
+		return nil, err
+	}
+	return evalConditional(vars, budget, n.cond, n.then, n.otherwise)
+}
+
+func (n *condNode) idents(out map[string]bool) {
+	n.cond.idents(out)
+	n.then.idents(out)
+	n.otherwise.idents(out)
+}
+
+// checkConditional type-checks a conditional with matching branch types
+func checkConditional(types map[string]exprType, cond, then, otherwise exprNode, name string) (exprType, error) {
+	condType, err := cond.check(types)
+	if err != nil {
+		return 0, err
+	}
+	if condType != typeBool {
+		return 0, fmt.Errorf("%w: %s condition must be bool, got %s", ErrExpressionType, name, condType)
+	}
+
+	thenType, err := then.check(types)
+	if err != nil {
+		return 0, err
+	}
+	otherType, err := otherwise.check(types)
+	if err != nil {
+		return 0, err
+	}
+	if thenType != otherType {
+		return 0, fmt.Errorf("%w: %s branches must have the same type, got %s and %s", ErrExpressionType, name, thenType, otherType)
+	}
+	return thenType, nil
+}
+
+// evalConditional evaluates only the selected branch of a conditional
+func evalConditional(vars map[string]interface{}, budget *evalBudget, cond, then, otherwise exprNode) (interface{}, error) {
+	value, err := cond.eval(vars, budget)
+	if err != nil {
+		return nil, err
+	}
+	if b, _ := value.(bool); b {
+		return then.eval(vars, budget)
+	}
+	return otherwise.eval(vars, budget)
+}
+
+// --- Functions ---
+
+// exprFunction describes a built-in function
+type exprFunction struct {
+	minArgs int
+	maxArgs int // -1 for variadic
+	call    func(args []float64) (float64, error)
+}
+
+// exprFunctions lists the built-in numeric functions available to expressions.
+// The "if" function is handled specially so only the selected branch is evaluated.
+var exprFunctions = map[string]*exprFunction{
+	"min": {minArgs: 1, maxArgs: -1, call: func(args []float64) (float64, error) {
+		result := args[0]
+		for _, a := range args[1:] {
+			result = math.Min(result, a)
+		}
+		return result, nil
+	}},
+	"max": {minArgs: 1, maxArgs: -1, call: func(args []float64) (float64, error) {
+		result := args[0]
+		for _, a := range args[1:] {
+			result = math.Max(result, a)
+		}
+		return result, nil
+	}},
+	"clamp": {minArgs: 3, maxArgs: 3, call: func(args []float64) (float64, error) {
+		if args[1] > args[2] {
+			return 0, fmt.Errorf("clamp() lower bound %g exceeds upper bound %g", args[1], args[2])
+		}
+		return math.Min(math.Max(args[0], args[1]), args[2]), nil
+	}},
+	"abs": {minArgs: 1, maxArgs: 1, call: func(args []float64) (float64, error) {
+		return math.Abs(args[0]), nil
+	}},
+	"floor": {minArgs: 1, maxArgs: 1, call: func(args []float64) (float64, error) {
+		return math.Floor(args[0]), nil
+	}},
+	"ceil": {minArgs: 1, maxArgs: 1, call: func(args []float64) (float64, error) {
+		return math.Ceil(args[0]), nil
+	}},
+	"round": {minArgs: 1, maxArgs: 2, call: func(args []float64) (float64, error) {
+		places := 0.0
+		if len(args) == 2 {
+			places = args[1]
+		}
+		if places < 0 || places > 10 {
+			return 0, fmt.Errorf("round() places must be between 0 and 10")
+		}
+		scale := math.Pow(10, math.Floor(places))
+		return math.Round(args[0]*scale) / scale, nil
+	}},
+	"sqrt": {minArgs: 1, maxArgs: 1, call: func(args []float64) (float64, error) {
+		if args[0] < 0 {
+			return 0, fmt.Errorf("sqrt() of negative number")
+		}
+		return math.Sqrt(args[0]), nil
+	}},
+	"pow": {minArgs: 2, maxArgs: 2, call: func(args []float64) (float64, error) {
+		return math.Pow(args[0], args[1]), nil
+	}},
+	"log": {minArgs: 1, maxArgs: 1, call: func(args []float64) (float64, error) {
+		if args[0] <= 0 {
+			return 0, fmt.Errorf("log() of non-positive number")
+		}
+		return math.Log(args[0]), nil
+	}},
+	"if": {minArgs: 3, maxArgs: 3},
+}
+
+// callNode invokes a built-in function
+type callNode struct {
+	name string
+	fn   *exprFunction
+	args []exprNode
+	pos  int
+}
+
+func (n *callNode) check(types map[string]exprType) (exprType, error) {
+	if n.name == "if" {
+		return checkConditional(types, n.args[0], n.args[1], n.args[2], "if()")
+	}
+
+	for i, arg := range n.args {
+		typ, err := arg.check(types)
+		if err != nil {
+			return 0, err
+		}
+		if typ != typeNumber {
+			return 0, fmt.Errorf("%w: %s() argument %d must be number, got %s at position %d", ErrExpressionType, n.name, i+1, typ, n.pos)
+		}
+	}
+	return typeNumber, nil
+}
+
+func (n *callNode) eval(vars map[string]interface{}, budget *evalBudget) (interface{}, error) {
+	if err := budget.step(); err != nil {
+		return nil, err
+	}
+
+	if n.name == "if" {
+		return evalConditional(vars, budget, n.args[0], n.args[1], n.args[2])
+	}
+
+	args := make([]float64, len(n.args))
+	for i, arg := range n.args {
+		value, err := arg.eval(vars, budget)
+		if err != nil {
+			return nil, err
+		}
+		args[i], _ = value.(float64)
+	}
+
+	result, err := n.fn.call(args)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v at position %d", ErrExpressionEval, err, n.pos)
+	}
+	return result, nil
+}
+
+func (n *callNode) idents(out map[string]bool) {
+	for _, arg := range n.args {
+		arg.idents(out)
+	}
+}
@@ -0,0 +1,94 @@
+package service
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestExpression_Eval(t *testing.T) {
+	vars := map[string]interface{}{
+		"x":    4.0,
+		"zero": 0.0,
+		"tier": "gold",
+		"vip":  true,
+	}
+	types := map[string]exprType{
+		"x":    typeNumber,
+		"zero": typeNumber,
+		"tier": typeString,
+		"vip":  typeBool,
+	}
+
+	tests := []struct {
+		name    string
+		source  string
+		want    interface{}
+		wantErr error
+	}{
+		{name: "precedence", source: "1 + 2 * 3", want: 7.0},
+		{name: "parentheses", source: "(1 + 2) * 3", want: 9.0},
+		{name: "unary minus", source: "-x + 10", want: 6.0},
+		{name: "modulo", source: "10 % 4", want: 2.0},
+		{name: "scientific notation", source: "1.5e2", want: 150.0},
+		{name: "comparison", source: "x >= 4 && x < 5", want: true},
+		{name: "string equality", source: `tier == "gold"`, want: true},
+		{name: "not", source: "!vip", want: false},
+		{name: "nested ternary", source: "x > 10 ? 1 : x > 2 ? 2 : 3", want: 2.0},
+		{name: "functions", source: "pow(2, 3) + sqrt(16) + abs(-1) + floor(1.7) + ceil(1.2)", want: 16.0},
+		{name: "short circuit skips division", source: "zero != 0 && 1 / zero > 1", want: false},
+		{name: "if skips unselected branch", source: "if(zero == 0, 0, 1 / zero)", want: 0.0},
+		{name: "division by zero", source: "x / zero", wantErr: ErrExpressionEval},
+		{name: "sqrt of negative", source: "sqrt(-1)", wantErr: ErrExpressionEval},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := parseExpression(tt.source)
+			if err != nil {
+				t.Fatalf("unexpected parse error: %v", err)
+			}
+			if _, err := expr.Check(types); err != nil {
+				t.Fatalf("unexpected type error: %v", err)
+			}
+
+			got, err := expr.Eval(vars, &evalBudget{max: defaultMaxSteps})
+
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("expected error '%v', got '%v'", tt.wantErr, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestParseExpression_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+	}{
+		{name: "empty", source: "   "},
+		{name: "trailing operator", source: "1 +"},
+		{name: "unbalanced parentheses", source: "(1 + 2"},
+		{name: "unterminated string", source: `"abc`},
+		{name: "invalid character", source: "1 $ 2"},
+		{name: "missing ternary colon", source: "true ? 1"},
+		{name: "wrong arity", source: "clamp(1, 2)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := parseExpression(tt.source); err == nil {
+				t.Errorf("expected error parsing %q, got nil", tt.source)
+			}
+		})
+	}
+}
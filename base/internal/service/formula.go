@@ -0,0 +1,423 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/saintparish4/harmonia/internal/domain"
+)
+
+// FormulaStrategy implements custom pricing via sandboxed arithmetic expressions
+type FormulaStrategy struct{}
+
+// Name returns the strategy identifier
+func (s *FormulaStrategy) Name() string {
+	return domain.StrategyTypeFormula
+}
+
+// Schema describes the formula config and inputs
+func (s *FormulaStrategy) Schema() domain.StrategySchema {
+	typeName := enumSchema("Input type", "number", "string", "bool")
+
+	return domain.StrategySchema{
+		Type:        domain.StrategyTypeFormula,
+		Name:        "Custom Formula Pricing",
+		Description: "Evaluates named intermediate expressions and a final price expression over the inputs",
+		ConfigSchema: rootSchema(objectSchema(withBounds(map[string]interface{}{
+			"price": stringSchema("Expression that evaluates to the final price, e.g. base_cost * (1 + markup/100)"),
+			"variables": map[string]interface{}{
+				"type":        "array",
+				"description": "Intermediate expressions evaluated in order; each may reference inputs and earlier variables",
+				"items": objectSchema(map[string]interface{}{
+					"name": map[string]interface{}{
+						"type":    "string",
+						"pattern": "^[A-Za-z_][A-Za-z0-9_]*$",
+					},
+					"expression": stringSchema("Expression evaluated to produce the variable"),
+				}, "name", "expression"),
+			},
+			"inputs": map[string]interface{}{
+				"type":        "object",
+				"description": "Declared input types and defaults; undeclared identifiers are numeric inputs",
+				"additionalProperties": map[string]interface{}{
+					"oneOf": []interface{}{
+						typeName,
+						objectSchema(map[string]interface{}{
+							"type":    typeName,
+							"default": map[string]interface{}{"type": []string{"number", "string", "boolean"}},
+						}, "type"),
+					},
+				},
+			},
+			"max_steps": map[string]interface{}{
+				"type":        "integer",
+				"description": "Evaluation step budget per calculation",
+				"minimum":     1,
+				"maximum":     maxStepsLimit,
+				"default":     defaultMaxSteps,
+			},
+			"currency": currencySchema(),
+		}), "price")),
+		InputSchema: rootSchema(map[string]interface{}{
+			"type":                 "object",
+			"description":          "Values for the identifiers referenced by the configured expressions",
+			"properties":           map[string]interface{}{"currency": currencySchema()},
+			"additionalProperties": true,
+		}),
+	}
+}
+
+// formulaInput describes a declared input and its optional default
+type formulaInput struct {
+	Name       string
+	Type       exprType
+	Default    interface{}
+	HasDefault bool
+}
+
+// formulaVariable is a named intermediate expression
+type formulaVariable struct {
+	Name string
+	Expr *expression
+}
+
+// compiledFormula is a parsed and type-checked formula config
+type compiledFormula struct {
+	Inputs    []formulaInput
+	Variables []formulaVariable
+	Price     *expression
+	MaxSteps  int
+}
+
+// Validate checks if the configuration is valid for formula pricing
+func (s *FormulaStrategy) Validate(config map[string]interface{}) error {
+	_, err := compileFormula(config)
+	return err
+}
+
+// Calculate evaluates the formula against the request inputs
+func (s *FormulaStrategy) Calculate(req *domain.PricingRequest, config map[string]interface{}) (*domain.PricingResponse, error) {
+	formula, err := compileFormula(config)
+	if err != nil {
+		return nil, err
+	}
+
+	// Resolve inputs from the request
+	vars := make(map[string]interface{}, len(formula.Inputs)+len(formula.Variables))
+	inputsUsed := make(map[string]interface{}, len(formula.Inputs))
+	for _, input := range formula.Inputs {
+		value, err := resolveFormulaInput(req.Inputs, input)
+		if err != nil {
+			return nil, err
+		}
+		vars[input.Name] = value
+		inputsUsed[input.Name] = value
+	}
+
+	budget := &evalBudget{max: formula.MaxSteps}
+
+	// Evaluate intermediate variables in order
+	intermediates := make([]map[string]interface{}, 0, len(formula.Variables))
+	for _, variable := range formula.Variables {
+		value, err := variable.Expr.Eval(vars, budget)
+		if err != nil {
+			return nil, fmt.Errorf("variable %s: %w", variable.Name, err)
+		}
+		if f, ok := value.(float64); ok && (math.IsNaN(f) || math.IsInf(f, 0)) {
+			return nil, fmt.Errorf("%w: variable %s is not a finite number", ErrExpressionEval, variable.Name)
+		}
+		vars[variable.Name] = value
+		intermediates = append(intermediates, map[string]interface{}{
+			"name":       variable.Name,
+			"expression": variable.Expr.source,
+			"value":      value,
+		})
+	}
+
+	// Evaluate final price
+	value, err := formula.Price.Eval(vars, budget)
+	if err != nil {
+		return nil, fmt.Errorf("price: %w", err)
+	}
+	formulaPrice, _ := value.(float64)
+	if math.IsNaN(formulaPrice) || math.IsInf(formulaPrice, 0) {
+		return nil, fmt.Errorf("%w: price is not a finite number", ErrExpressionEval)
+	}
+
+	// Apply min/max bounds
+	minPrice, _ := domain.GetFloat64(config, "min_price")
+	maxPrice, _ := domain.GetFloat64(config, "max_price")
+	finalPrice := domain.ApplyBounds(formulaPrice, minPrice, maxPrice)
+
+	adjustments := []domain.PriceAdjustment{}
+	if finalPrice != formulaPrice {
+		adjustments = append(adjustments, domain.PriceAdjustment{
+			Type:        "bound",
+			Description: "Price bounded by min_price/max_price",
+			Amount:      finalPrice,
+			Applied:     finalPrice - formulaPrice,
+		})
+	}
+
+	// Build response
+	response := &domain.PricingResponse{
+		FinalPrice: finalPrice,
+		Currency:   getCurrency(req, config),
+		Breakdown: domain.PriceBreakdown{
+			Adjustments: adjustments,
+			Details: map[string]interface{}{
+				"inputs":           inputsUsed,
+				"intermediates":    intermediates,
+				"price_expression": formula.Price.source,
+				"formula_price":    formulaPrice,
+				"steps":            budget.steps,
+				"final_price":      domain.RoundToTwoDecimals(finalPrice),
+			},
+		},
+	}
+
+	return response, nil
+}
+
+// compileFormula parses and type-checks a formula config
+func compileFormula(config map[string]interface{}) (*compiledFormula, error) {
+	priceSource, ok := domain.GetString(config, "price")
+	if !ok || priceSource == "" {
+		return nil, domain.NewValidationError(domain.JSONPointer("price"), "price expression is required")
+	}
+
+	formula := &compiledFormula{MaxSteps: defaultMaxSteps}
+
+	if maxSteps, ok := domain.GetFloat64(config, "max_steps"); ok {
+		if maxSteps < 1 || maxSteps > maxStepsLimit {
+			return nil, domain.NewValidationError(domain.JSONPointer("max_steps"), "must be between 1 and %d", maxStepsLimit)
+		}
+		formula.MaxSteps = int(maxSteps)
+	}
+
+	bounds := &domain.ValidationError{}
+	validateBounds(bounds, config)
+	validateOptionalString(bounds, config, "currency")
+	if bounds.HasErrors() {
+		return nil, bounds
+	}
+
+	// Declared inputs
+	types := make(map[string]exprType)
+	declared, err := parseFormulaInputs(config)
+	if err != nil {
+		return nil, err
+	}
+	for _, input := range declared {
+		types[input.Name] = input.Type
+	}
+	formula.Inputs = declared
+
+	// Parse intermediate variables
+	variables, err := parseFormulaVariables(config, types)
+	if err != nil {
+		return nil, err
+	}
+
+	variableIndex := make(map[string]int, len(variables))
+	for i, variable := range variables {
+		variableIndex[variable.Name] = i
+	}
+
+	// Undeclared identifiers are implicit numeric inputs, unless they name a variable
+	addImplicit := func(expr *expression, position int, path string) *domain.ValidationError {
+		names := make([]string, 0)
+		for name := range expr.Identifiers() {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			if idx, isVariable := variableIndex[name]; isVariable {
+				if idx >= position {
+					return domain.NewValidationError(path, "variable %s is referenced before it is defined", name)
+				}
+				continue
+			}
+			if _, known := types[name]; !known {
+				types[name] = typeNumber
+				formula.Inputs = append(formula.Inputs, formulaInput{Name: name, Type: typeNumber})
+			}
+		}
+		return nil
+	}
+
+	// Type-check variables in order, each may reference inputs and earlier variables
+	for i, variable := range variables {
+		path := domain.JSONPointer("variables", i, "expression")
+		if err := addImplicit(variable.Expr, i, path); err != nil {
+			return nil, err
+		}
+		typ, err := variable.Expr.Check(types)
+		if err != nil {
+			return nil, domain.NewValidationError(path, "%v", err)
+		}
+		types[variable.Name] = typ
+	}
+	formula.Variables = variables
+
+	// Parse and type-check the final price expression
+	pricePath := domain.JSONPointer("price")
+	price, err := parseExpression(priceSource)
+	if err != nil {
+		return nil, domain.NewValidationError(pricePath, "%v", err)
+	}
+	if err := addImplicit(price, len(variables), pricePath); err != nil {
+		return nil, err
+	}
+	typ, err := price.Check(types)
+	if err != nil {
+		return nil, domain.NewValidationError(pricePath, "%v", err)
+	}
+	if typ != typeNumber {
+		return nil, domain.NewValidationError(pricePath, "%v: expression must be number, got %s", ErrExpressionType, typ)
+	}
+	formula.Price = price
+
+	return formula, nil
+}
+
+// parseFormulaInputs reads declared inputs: {"name": "type"} or {"name": {"type": ..., "default": ...}}
+func parseFormulaInputs(config map[string]interface{}) ([]formulaInput, error) {
+	raw, exists := config["inputs"]
+	if !exists {
+		return nil, nil
+	}
+	inputsMap, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, domain.NewValidationError(domain.JSONPointer("inputs"), "must be an object")
+	}
+
+	names := make([]string, 0, len(inputsMap))
+	for name := range inputsMap {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	inputs := make([]formulaInput, 0, len(names))
+	for _, name := range names {
+		if !isValidIdentifier(name) {
+			return nil, domain.NewValidationError(domain.JSONPointer("inputs", name), "is not a valid identifier")
+		}
+
+		input := formulaInput{Name: name}
+		var typeName string
+
+		switch v := inputsMap[name].(type) {
+		case string:
+			typeName = v
+		case map[string]interface{}:
+			typeName, _ = domain.GetString(v, "type")
+			if def, ok := v["default"]; ok {
+				input.Default = def
+				input.HasDefault = true
+			}
+		default:
+			return nil, domain.NewValidationError(domain.JSONPointer("inputs", name), "must be a type name or an object")
+		}
+
+		typ, ok := parseExprType(typeName)
+		if !ok {
+			return nil, domain.NewValidationError(domain.JSONPointer("inputs", name), "invalid type %q (use number, string or bool)", typeName)
+		}
+		input.Type = typ
+
+		if input.HasDefault {
+			value, ok := coerceFormulaValue(input.Default, typ)
+			if !ok {
+				return nil, domain.NewValidationError(domain.JSONPointer("inputs", name, "default"), "must be a %s", typ)
+			}
+			input.Default = value
+		}
+
+		inputs = append(inputs, input)
+	}
+
+	return inputs, nil
+}
+
+// parseFormulaVariables reads the ordered intermediate variables
+func parseFormulaVariables(config map[string]interface{}, inputs map[string]exprType) ([]formulaVariable, error) {
+	raw, exists := config["variables"]
+	if !exists {
+		return nil, nil
+	}
+	list, ok := raw.([]interface{})
+	if !ok {
+		return nil, domain.NewValidationError(domain.JSONPointer("variables"), "must be an array")
+	}
+
+	seen := make(map[string]bool, len(list))
+	variables := make([]formulaVariable, 0, len(list))
+
+	for i, item := range list {
+		itemMap, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, domain.NewValidationError(domain.JSONPointer("variables", i), "must be an object")
+		}
+
+		name, _ := domain.GetString(itemMap, "name")
+		if !isValidIdentifier(name) {
+			return nil, domain.NewValidationError(domain.JSONPointer("variables", i, "name"), "%q is not a valid identifier", name)
+		}
+		if _, isInput := inputs[name]; isInput || seen[name] {
+			return nil, domain.NewValidationError(domain.JSONPointer("variables", i, "name"), "%q is already defined", name)
+		}
+		seen[name] = true
+
+		source, ok := domain.GetString(itemMap, "expression")
+		if !ok {
+			return nil, domain.NewValidationError(domain.JSONPointer("variables", i, "expression"), "is required")
+		}
+		expr, err := parseExpression(source)
+		if err != nil {
+			return nil, domain.NewValidationError(domain.JSONPointer("variables", i, "expression"), "%v", err)
+		}
+
+		variables = append(variables, formulaVariable{Name: name, Expr: expr})
+	}
+
+	return variables, nil
+}
+
+// resolveFormulaInput extracts and type-checks an input value from the request
+func resolveFormulaInput(inputs map[string]interface{}, input formulaInput) (interface{}, error) {
+	raw, exists := inputs[input.Name]
+	if !exists {
+		if input.HasDefault {
+			return input.Default, nil
+		}
+		return nil, fmt.Errorf("%w: %s is required", domain.ErrMissingRequiredField, input.Name)
+	}
+
+	value, ok := coerceFormulaValue(raw, input.Type)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s must be a %s", domain.ErrInvalidFieldValue, input.Name, input.Type)
+	}
+	return value, nil
+}
+
+// coerceFormulaValue converts a JSON value into the expression representation of a type
+func coerceFormulaValue(value interface{}, typ exprType) (interface{}, bool) {
+	switch typ {
+	case typeNumber:
+		f, ok := convertToFloat(value)
+		if !ok || math.IsNaN(f) || math.IsInf(f, 0) {
+			return nil, false
+		}
+		return f, true
+	case typeBool:
+		b, ok := value.(bool)
+		return b, ok
+	case typeString:
+		str, ok := value.(string)
+		return str, ok
+	}
+	return nil, false
+}
@@ -0,0 +1,349 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/saintparish4/harmonia/internal/domain"
+)
+
+func TestFormulaStrategy_Calculate(t *testing.T) {
+	strategy := &FormulaStrategy{}
+
+	tests := []struct {
+		name        string
+		request     *domain.PricingRequest
+		config      map[string]interface{}
+		wantPrice   float64
+		wantErr     bool
+		errContains string
+	}{
+		{
+			name: "markup plus weight surcharge",
+			request: &domain.PricingRequest{
+				Strategy: domain.StrategyTypeFormula,
+				Inputs: map[string]interface{}{
+					"base_cost": 100.0,
+					"markup":    20.0,
+					"weight_kg": 4.0,
+				},
+			},
+			config: map[string]interface{}{
+				"price": "base_cost * (1 + markup/100) + max(0, weight_kg - 2) * 3.5",
+			},
+			wantPrice: 127.0, // 120 + 2 * 3.5
+			wantErr:   false,
+		},
+		{
+			name: "intermediate variables",
+			request: &domain.PricingRequest{
+				Strategy: domain.StrategyTypeFormula,
+				Inputs: map[string]interface{}{
+					"base_cost": 80.0,
+				},
+			},
+			config: map[string]interface{}{
+				"variables": []interface{}{
+					map[string]interface{}{"name": "markup_amount", "expression": "base_cost * 0.25"},
+					map[string]interface{}{"name": "subtotal", "expression": "base_cost + markup_amount"},
+				},
+				"price": "round(subtotal, 2)",
+			},
+			wantPrice: 100.0,
+			wantErr:   false,
+		},
+		{
+			name: "string input with default and conditional",
+			request: &domain.PricingRequest{
+				Strategy: domain.StrategyTypeFormula,
+				Inputs: map[string]interface{}{
+					"base_price": 100.0,
+					"tier":       "premium",
+				},
+			},
+			config: map[string]interface{}{
+				"inputs": map[string]interface{}{
+					"tier":     "string",
+					"quantity": map[string]interface{}{"type": "number", "default": 1.0},
+				},
+				"price": `(tier == "premium" ? base_price * 0.9 : base_price) * quantity`,
+			},
+			wantPrice: 90.0,
+			wantErr:   false,
+		},
+		{
+			name: "if function and clamp",
+			request: &domain.PricingRequest{
+				Strategy: domain.StrategyTypeFormula,
+				Inputs: map[string]interface{}{
+					"base_price": 500.0,
+				},
+			},
+			config: map[string]interface{}{
+				"price": "clamp(if(base_price > 100, base_price * 2, base_price), 0, 750)",
+			},
+			wantPrice: 750.0,
+			wantErr:   false,
+		},
+		{
+			name: "apply max price",
+			request: &domain.PricingRequest{
+				Strategy: domain.StrategyTypeFormula,
+				Inputs: map[string]interface{}{
+					"base_price": 100.0,
+				},
+			},
+			config: map[string]interface{}{
+				"price":     "base_price * 3",
+				"max_price": 200.0,
+			},
+			wantPrice: 200.0,
+			wantErr:   false,
+		},
+		{
+			name: "missing input",
+			request: &domain.PricingRequest{
+				Strategy: domain.StrategyTypeFormula,
+				Inputs:   map[string]interface{}{},
+			},
+			config: map[string]interface{}{
+				"price": "base_cost * 2",
+			},
+			wantErr:     true,
+			errContains: "base_cost",
+		},
+		{
+			name: "wrong input type",
+			request: &domain.PricingRequest{
+				Strategy: domain.StrategyTypeFormula,
+				Inputs: map[string]interface{}{
+					"base_cost": "lots",
+				},
+			},
+			config: map[string]interface{}{
+				"price": "base_cost * 2",
+			},
+			wantErr:     true,
+			errContains: "base_cost",
+		},
+		{
+			name: "division by zero",
+			request: &domain.PricingRequest{
+				Strategy: domain.StrategyTypeFormula,
+				Inputs: map[string]interface{}{
+					"base_cost": 10.0,
+					"units":     0.0,
+				},
+			},
+			config: map[string]interface{}{
+				"price": "base_cost / units",
+			},
+			wantErr:     true,
+			errContains: "division by zero",
+		},
+		{
+			name: "step limit exceeded",
+			request: &domain.PricingRequest{
+				Strategy: domain.StrategyTypeFormula,
+				Inputs: map[string]interface{}{
+					"a": 1.0,
+				},
+			},
+			config: map[string]interface{}{
+				"price":     "a + a + a + a + a + a",
+				"max_steps": 5.0,
+			},
+			wantErr:     true,
+			errContains: "step limit",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response, err := strategy.Calculate(tt.request, tt.config)
+
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected error containing '%s', got nil", tt.errContains)
+				} else if !strings.Contains(err.Error(), tt.errContains) {
+					t.Errorf("expected error containing '%s', got '%v'", tt.errContains, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+				return
+			}
+
+			if response.FinalPrice != tt.wantPrice {
+				t.Errorf("expected price %.2f, got %.2f", tt.wantPrice, response.FinalPrice)
+			}
+
+			if _, ok := response.Breakdown.Details["intermediates"]; !ok {
+				t.Error("breakdown should list intermediates")
+			}
+		})
+	}
+}
+
+func TestFormulaStrategy_Intermediates(t *testing.T) {
+	strategy := &FormulaStrategy{}
+
+	request := &domain.PricingRequest{
+		Strategy: domain.StrategyTypeFormula,
+		Inputs: map[string]interface{}{
+			"base_cost": 50.0,
+		},
+	}
+	config := map[string]interface{}{
+		"variables": []interface{}{
+			map[string]interface{}{"name": "doubled", "expression": "base_cost * 2"},
+			map[string]interface{}{"name": "with_fee", "expression": "doubled + 5"},
+		},
+		"price": "with_fee",
+	}
+
+	response, err := strategy.Calculate(request, config)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	intermediates, ok := response.Breakdown.Details["intermediates"].([]map[string]interface{})
+	if !ok {
+		t.Fatal("intermediates should be a list")
+	}
+	if len(intermediates) != 2 {
+		t.Fatalf("expected 2 intermediates, got %d", len(intermediates))
+	}
+	if intermediates[0]["name"] != "doubled" || intermediates[0]["value"] != 100.0 {
+		t.Errorf("unexpected first intermediate: %v", intermediates[0])
+	}
+	if intermediates[1]["name"] != "with_fee" || intermediates[1]["value"] != 105.0 {
+		t.Errorf("unexpected second intermediate: %v", intermediates[1])
+	}
+}
+
+func TestFormulaStrategy_Validate(t *testing.T) {
+	strategy := &FormulaStrategy{}
+
+	tests := []struct {
+		name    string
+		config  map[string]interface{}
+		wantErr error
+	}{
+		{
+			name: "valid formula",
+			config: map[string]interface{}{
+				"price": "base_cost * 1.2",
+			},
+		},
+		{
+			name:    "missing price",
+			config:  map[string]interface{}{},
+			wantErr: domain.ErrConfigurationInvalid,
+		},
+		{
+			name: "syntax error",
+			config: map[string]interface{}{
+				"price": "base_cost * (1 + 2",
+			},
+			wantErr: ErrExpressionSyntax,
+		},
+		{
+			name: "unknown function",
+			config: map[string]interface{}{
+				"price": "exec(1)",
+			},
+			wantErr: ErrExpressionSyntax,
+		},
+		{
+			name: "bool price",
+			config: map[string]interface{}{
+				"price": "base_cost > 10",
+			},
+			wantErr: ErrExpressionType,
+		},
+		{
+			name: "string arithmetic",
+			config: map[string]interface{}{
+				"inputs": map[string]interface{}{"tier": "string"},
+				"price":  "tier * 2",
+			},
+			wantErr: ErrExpressionType,
+		},
+		{
+			name: "mismatched conditional branches",
+			config: map[string]interface{}{
+				"price": `base_cost > 1 ? 1 : "one"`,
+			},
+			wantErr: ErrExpressionType,
+		},
+		{
+			name: "variable referenced before definition",
+			config: map[string]interface{}{
+				"variables": []interface{}{
+					map[string]interface{}{"name": "a", "expression": "b + 1"},
+					map[string]interface{}{"name": "b", "expression": "2"},
+				},
+				"price": "a",
+			},
+			wantErr: errors.New("referenced before it is defined"),
+		},
+		{
+			name: "duplicate variable",
+			config: map[string]interface{}{
+				"variables": []interface{}{
+					map[string]interface{}{"name": "a", "expression": "1"},
+					map[string]interface{}{"name": "a", "expression": "2"},
+				},
+				"price": "a",
+			},
+			wantErr: errors.New("already defined"),
+		},
+		{
+			name: "invalid input type",
+			config: map[string]interface{}{
+				"inputs": map[string]interface{}{"tier": "date"},
+				"price":  "1",
+			},
+			wantErr: errors.New("invalid type"),
+		},
+		{
+			name: "max_steps out of range",
+			config: map[string]interface{}{
+				"price":     "1",
+				"max_steps": 0.0,
+			},
+			wantErr: errors.New("max_steps"),
+		},
+		{
+			name: "expression too deep",
+			config: map[string]interface{}{
+				"price": strings.Repeat("(", 100) + "1" + strings.Repeat(")", 100),
+			},
+			wantErr: ErrExpressionTooLarge,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := strategy.Validate(tt.config)
+
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+
+			if err == nil {
+				t.Fatalf("expected error '%v', got nil", tt.wantErr)
+			}
+			if !errors.Is(err, tt.wantErr) && !strings.Contains(err.Error(), tt.wantErr.Error()) {
+				t.Errorf("expected error '%v', got '%v'", tt.wantErr, err)
+			}
+		})
+	}
+}
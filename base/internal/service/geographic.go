@@ -2,6 +2,7 @@ package service
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/saintparish4/harmonia/internal/domain"
@@ -15,34 +16,76 @@ func (s *GeographicStrategy) Name() string {
 	return domain.StrategyTypeGeographic
 }
 
+// Schema describes the geographic config and inputs
+func (s *GeographicStrategy) Schema() domain.StrategySchema {
+	return domain.StrategySchema{
+		Type:        domain.StrategyTypeGeographic,
+		Name:        "Geographic Pricing",
+		Description: "Applies regional multipliers based on location",
+		ConfigSchema: rootSchema(objectSchema(withBounds(map[string]interface{}{
+			"regional_multipliers": map[string]interface{}{
+				"type":          "object",
+				"description":   "Multiplier per location code (e.g., US-CA), country code (e.g., US) or \"default\"",
+				"minProperties": 1,
+				"additionalProperties": map[string]interface{}{
+					"type":             "number",
+					"exclusiveMinimum": 0,
+				},
+			},
+			"currency_map": map[string]interface{}{
+				"type":                 "object",
+				"description":          "Currency per location or country code",
+				"additionalProperties": map[string]interface{}{"type": "string"},
+			},
+			"default_currency": stringSchema("Currency used when no currency_map entry matches (default: USD)"),
+		}), "regional_multipliers")),
+		InputSchema: rootSchema(objectSchema(map[string]interface{}{
+			"base_price": numberSchema("Price before the regional multiplier", nonNegative),
+			"location":   stringSchema("Location code such as US-CA or US"),
+		}, "base_price", "location")),
+	}
+}
+
 // Validate checks if the configuration is valid for geographic pricing
 func (s *GeographicStrategy) Validate(config map[string]interface{}) error {
+	ve := &domain.ValidationError{}
+
 	// regional_multipliers is required in config
 	multipliers, ok := domain.GetMap(config, "regional_multipliers")
 	if !ok || len(multipliers) == 0 {
-		return fmt.Errorf("%w: regional_multipliers map is required", domain.ErrConfigurationInvalid)
+		ve.Add(domain.JSONPointer("regional_multipliers"), "a non-empty map of region multipliers is required")
 	}
 
 	// Validate all multipliers are positive numbers
-	for region, multiplier := range multipliers {
-		var mult float64
-		switch v := multiplier.(type) {
-		case float64:
-			mult = v
-		case float32:
-			mult = float64(v)
-		case int:
-			mult = float64(v)
-		default:
-			return fmt.Errorf("regional_multipliers[%s] must be a number", region)
+	for _, region := range sortedKeys(multipliers) {
+		path := domain.JSONPointer("regional_multipliers", region)
+		mult, ok := convertToFloat(multipliers[region])
+		if !ok {
+			ve.Add(path, "must be a number")
+			continue
 		}
-
 		if mult <= 0 {
-			return fmt.Errorf("regional_multipliers[%s] must be positive", region)
+			ve.Add(path, "must be positive")
 		}
 	}
 
-	return nil
+	// Validate currency_map values are strings
+	if raw, exists := config["currency_map"]; exists {
+		currencyMap, ok := raw.(map[string]interface{})
+		if !ok {
+			ve.Add(domain.JSONPointer("currency_map"), "must be an object")
+		}
+		for _, region := range sortedKeys(currencyMap) {
+			if _, ok := currencyMap[region].(string); !ok {
+				ve.Add(domain.JSONPointer("currency_map", region), "must be a string")
+			}
+		}
+	}
+
+	validateOptionalString(ve, config, "default_currency")
+	validateBounds(ve, config)
+
+	return ve.Err()
 }
 
 // Calculate computes the geographic price based on location
@@ -190,6 +233,16 @@ func getCurrencyForRegion(location string, config map[string]interface{}) string
 	return "USD"
 }
 
+// sortedKeys returns the keys of a map in sorted order
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 // getAvailableRegions returns a list of configured regions
 func getAvailableRegions(multipliers map[string]interface{}) []string {
 	regions := make([]string, 0, len(multipliers))
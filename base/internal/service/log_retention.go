@@ -0,0 +1,269 @@
+package service
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/saintparish4/harmonia/internal/domain"
+)
+
+// LogRetentionConfig configures calculation log maintenance
+type LogRetentionConfig struct {
+	PartitionsAhead int    // future monthly partitions kept ready
+	RetentionDays   int    // for accounts without their own; 0 keeps logs forever
+	Mode            string // domain.LogRetentionDrop or domain.LogRetentionDetach
+	ArchiveDir      string // expired partitions are archived here first; empty disables
+}
+
+// LogMaintenance reports what a maintenance pass did
+type LogMaintenance struct {
+	Created  []string // partitions created
+	Expired  []string // partitions dropped or detached
+	Archived []string // archive files written
+	Deleted  int64    // logs deleted for accounts keeping them less than the longest retention
+}
+
+// LogRetentionService keeps monthly partitions ready for the calculation
+// logs and applies each account's retention.
+//
+// A partition is expired once it ends before the longest retention of any
+// account; it is archived, if configured, then dropped or detached. Accounts
+// with a shorter retention have their logs deleted from the partitions that
+// are still kept.
+type LogRetentionService struct {
+	repo   domain.CalculationLogPartitionRepository
+	config LogRetentionConfig
+	now    func() time.Time
+
+	// running tracks the maintenance loop so shutdown can wait for it
+	running sync.WaitGroup
+}
+
+// NewLogRetentionService creates a calculation log retention service
+func NewLogRetentionService(repo domain.CalculationLogPartitionRepository, config LogRetentionConfig) *LogRetentionService {
+	if config.Mode == "" {
+		config.Mode = domain.LogRetentionDrop
+	}
+
+	return &LogRetentionService{
+		repo:   repo,
+		config: config,
+		now:    time.Now,
+	}
+}
+
+// Run maintains the calculation logs now and then every interval until ctx
+// is cancelled
+func (s *LogRetentionService) Run(ctx context.Context, interval time.Duration) {
+	s.running.Add(1)
+	defer s.running.Done()
+
+	s.maintain(ctx)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.maintain(ctx)
+		}
+	}
+}
+
+// Wait blocks until Run has returned
+func (s *LogRetentionService) Wait() {
+	s.running.Wait()
+}
+
+// maintain runs a maintenance pass, logging what it did
+func (s *LogRetentionService) maintain(ctx context.Context) {
+	report, err := s.Maintain(ctx)
+	if err != nil {
+		log.Printf("log retention: %v", err)
+	}
+	if len(report.Created) > 0 {
+		log.Printf("log retention: created partitions %v", report.Created)
+	}
+	if len(report.Expired) > 0 {
+		log.Printf("log retention: %s partitions %v, archived to %v", s.config.Mode, report.Expired, report.Archived)
+	}
+	if report.Deleted > 0 {
+		log.Printf("log retention: deleted %d expired logs", report.Deleted)
+	}
+}
+
+// Maintain creates the partitions for this month and the months ahead, then
+// applies retention. The report covers the work done before any error.
+func (s *LogRetentionService) Maintain(ctx context.Context) (*LogMaintenance, error) {
+	report := &LogMaintenance{}
+	now := s.now().UTC()
+
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i <= s.config.PartitionsAhead; i++ {
+		partitionMonth := month.AddDate(0, i, 0)
+		created, err := s.repo.CreatePartition(ctx, partitionMonth)
+		if err != nil {
+			return report, err
+		}
+		if created {
+			report.Created = append(report.Created, partitionMonth.Format("2006-01"))
+		}
+	}
+
+	accounts, err := s.repo.AccountRetention(ctx)
+	if err != nil {
+		return report, err
+	}
+
+	longest := longestRetention(s.config.RetentionDays, accounts)
+	if longest > 0 {
+		if err := s.expirePartitions(ctx, now.AddDate(0, 0, -longest), report); err != nil {
+			return report, err
+		}
+	}
+
+	// Logs of accounts keeping them less than the longest retention are
+	// still in kept partitions
+	for userID, days := range accounts {
+		if longest > 0 && days >= longest {
+			continue
+		}
+		deleted, err := s.repo.DeleteUserLogsBefore(ctx, userID, now.AddDate(0, 0, -days))
+		report.Deleted += deleted
+		if err != nil {
+			return report, err
+		}
+	}
+
+	if s.config.RetentionDays > 0 && s.config.RetentionDays < longest {
+		except := make([]uuid.UUID, 0, len(accounts))
+		for userID := range accounts {
+			except = append(except, userID)
+		}
+		deleted, err := s.repo.DeleteLogsBefore(ctx, now.AddDate(0, 0, -s.config.RetentionDays), except)
+		report.Deleted += deleted
+		if err != nil {
+			return report, err
+		}
+	}
+
+	return report, nil
+}
+
+// expirePartitions archives, then drops or detaches, the partitions that end
+// before cutoff
+func (s *LogRetentionService) expirePartitions(ctx context.Context, cutoff time.Time, report *LogMaintenance) error {
+	partitions, err := s.repo.ListPartitions(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, partition := range partitions {
+		if partition.To.After(cutoff) {
+			// Partitions are oldest first
+			break
+		}
+
+		if s.config.ArchiveDir != "" {
+			path, err := s.archive(ctx, partition)
+			if err != nil {
+				return fmt.Errorf("failed to archive %s: %w", partition.Name, err)
+			}
+			report.Archived = append(report.Archived, path)
+		}
+
+		if s.config.Mode == domain.LogRetentionDetach {
+			err = s.repo.DetachPartition(ctx, partition.Name)
+		} else {
+			err = s.repo.DropPartition(ctx, partition.Name)
+		}
+		if err != nil {
+			return err
+		}
+		report.Expired = append(report.Expired, partition.Name)
+	}
+
+	return nil
+}
+
+// archive writes a partition's logs to a gzip-compressed NDJSON file named
+// after the partition. The file only appears once it is complete.
+func (s *LogRetentionService) archive(ctx context.Context, partition *domain.CalculationLogPartition) (string, error) {
+	if err := os.MkdirAll(s.config.ArchiveDir, 0o750); err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(s.config.ArchiveDir, partition.Name+".ndjson.gz")
+	tmpPath := path + ".tmp"
+
+	file, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmpPath)
+
+	if err := writeLogArchive(ctx, s.repo, partition.Name, file); err != nil {
+		file.Close()
+		return "", err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return "", err
+	}
+	if err := file.Close(); err != nil {
+		return "", err
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// writeLogArchive writes a partition's logs to w as gzip-compressed NDJSON
+func writeLogArchive(ctx context.Context, repo domain.CalculationLogPartitionRepository, name string, w io.Writer) error {
+	buffered := bufio.NewWriter(w)
+	compressed := gzip.NewWriter(buffered)
+	encoder := json.NewEncoder(compressed)
+
+	err := repo.ExportPartition(ctx, name, func(entry *domain.CalculationLog) error {
+		return encoder.Encode(entry)
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := compressed.Close(); err != nil {
+		return err
+	}
+	return buffered.Flush()
+}
+
+// longestRetention is the longest retention of any account in days, or 0
+// when some account keeps its logs forever
+func longestRetention(defaultDays int, accounts map[uuid.UUID]int) int {
+	if defaultDays <= 0 {
+		return 0
+	}
+
+	longest := defaultDays
+	for _, days := range accounts {
+		if days > longest {
+			longest = days
+		}
+	}
+	return longest
+}
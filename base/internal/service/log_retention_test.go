@@ -0,0 +1,268 @@
+package service
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/saintparish4/harmonia/internal/domain"
+)
+
+// fakePartitionRepo holds monthly partitions and records retention calls
+type fakePartitionRepo struct {
+	domain.CalculationLogPartitionRepository
+	partitions map[string]*domain.CalculationLogPartition
+	logs       map[string][]*domain.CalculationLog
+	accounts   map[uuid.UUID]int
+	dropped    []string
+	detached   []string
+	userCuts   map[uuid.UUID]time.Time
+	otherCut   *time.Time
+	except     []uuid.UUID
+}
+
+func newFakePartitionRepo(months ...string) *fakePartitionRepo {
+	repo := &fakePartitionRepo{
+		partitions: make(map[string]*domain.CalculationLogPartition),
+		logs:       make(map[string][]*domain.CalculationLog),
+		userCuts:   make(map[uuid.UUID]time.Time),
+	}
+	for _, month := range months {
+		from, _ := time.Parse("2006-01", month)
+		repo.add(from)
+	}
+	return repo
+}
+
+func (r *fakePartitionRepo) add(month time.Time) bool {
+	name := "calculation_logs_" + month.Format("y2006m01")
+	if _, ok := r.partitions[name]; ok {
+		return false
+	}
+	r.partitions[name] = &domain.CalculationLogPartition{Name: name, From: month, To: month.AddDate(0, 1, 0)}
+	return true
+}
+
+func (r *fakePartitionRepo) names() []string {
+	names := make([]string, 0, len(r.partitions))
+	for name := range r.partitions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (r *fakePartitionRepo) CreatePartition(ctx context.Context, month time.Time) (bool, error) {
+	return r.add(month), nil
+}
+
+func (r *fakePartitionRepo) ListPartitions(ctx context.Context) ([]*domain.CalculationLogPartition, error) {
+	var partitions []*domain.CalculationLogPartition
+	for _, name := range r.names() {
+		partitions = append(partitions, r.partitions[name])
+	}
+	return partitions, nil
+}
+
+func (r *fakePartitionRepo) ExportPartition(ctx context.Context, name string, fn func(*domain.CalculationLog) error) error {
+	for _, entry := range r.logs[name] {
+		if err := fn(entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *fakePartitionRepo) DropPartition(ctx context.Context, name string) error {
+	delete(r.partitions, name)
+	r.dropped = append(r.dropped, name)
+	return nil
+}
+
+func (r *fakePartitionRepo) DetachPartition(ctx context.Context, name string) error {
+	delete(r.partitions, name)
+	r.detached = append(r.detached, name)
+	return nil
+}
+
+func (r *fakePartitionRepo) AccountRetention(ctx context.Context) (map[uuid.UUID]int, error) {
+	return r.accounts, nil
+}
+
+func (r *fakePartitionRepo) DeleteUserLogsBefore(ctx context.Context, userID uuid.UUID, before time.Time) (int64, error) {
+	r.userCuts[userID] = before
+	return 1, nil
+}
+
+func (r *fakePartitionRepo) DeleteLogsBefore(ctx context.Context, before time.Time, except []uuid.UUID) (int64, error) {
+	r.otherCut = &before
+	r.except = except
+	return 2, nil
+}
+
+func TestLogRetentionCreatesPartitionsAhead(t *testing.T) {
+	repo := newFakePartitionRepo("2026-03")
+	svc := NewLogRetentionService(repo, LogRetentionConfig{PartitionsAhead: 2})
+	svc.now = func() time.Time { return time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC) }
+
+	report, err := svc.Maintain(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []string{"calculation_logs_y2026m03", "calculation_logs_y2026m04", "calculation_logs_y2026m05"}
+	if got := repo.names(); len(got) != len(want) || got[0] != want[0] || got[2] != want[2] {
+		t.Errorf("expected partitions %v, got %v", want, got)
+	}
+	if len(report.Created) != 2 || report.Created[0] != "2026-04" {
+		t.Errorf("expected 2026-04 and 2026-05 to be created, got %v", report.Created)
+	}
+
+	// Without a default retention nothing expires
+	if len(repo.dropped) != 0 || repo.otherCut != nil {
+		t.Errorf("expected logs to be kept forever, dropped %v", repo.dropped)
+	}
+}
+
+func TestLogRetentionExpiresPartitions(t *testing.T) {
+	now := time.Date(2026, 6, 10, 0, 0, 0, 0, time.UTC)
+	shortAccount := uuid.New()
+	longAccount := uuid.New()
+
+	tests := []struct {
+		name        string
+		accounts    map[uuid.UUID]int
+		mode        string
+		wantExpired []string
+		wantUser    map[uuid.UUID]time.Time
+		wantOther   *time.Time
+	}{
+		{
+			name:        "default retention",
+			wantExpired: []string{"calculation_logs_y2026m01", "calculation_logs_y2026m02"},
+		},
+		{
+			name:        "detach",
+			mode:        domain.LogRetentionDetach,
+			wantExpired: []string{"calculation_logs_y2026m01", "calculation_logs_y2026m02"},
+		},
+		{
+			name:        "shorter account retention trims its logs",
+			accounts:    map[uuid.UUID]int{shortAccount: 30},
+			wantExpired: []string{"calculation_logs_y2026m01", "calculation_logs_y2026m02"},
+			wantUser:    map[uuid.UUID]time.Time{shortAccount: now.AddDate(0, 0, -30)},
+		},
+		{
+			name:        "longer account retention keeps partitions",
+			accounts:    map[uuid.UUID]int{shortAccount: 30, longAccount: 120},
+			wantExpired: []string{"calculation_logs_y2026m01"},
+			wantUser:    map[uuid.UUID]time.Time{shortAccount: now.AddDate(0, 0, -30)},
+			wantOther:   timePtr(now.AddDate(0, 0, -90)),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakePartitionRepo("2026-01", "2026-02", "2026-03", "2026-04", "2026-05", "2026-06")
+			repo.accounts = tt.accounts
+			svc := NewLogRetentionService(repo, LogRetentionConfig{RetentionDays: 90, Mode: tt.mode})
+			svc.now = func() time.Time { return now }
+
+			report, err := svc.Maintain(context.Background())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			got := repo.dropped
+			if tt.mode == domain.LogRetentionDetach {
+				got = repo.detached
+				if len(repo.dropped) != 0 {
+					t.Errorf("expected nothing dropped, got %v", repo.dropped)
+				}
+			}
+			if len(got) != len(tt.wantExpired) || len(report.Expired) != len(tt.wantExpired) {
+				t.Fatalf("expected %v to expire, got %v", tt.wantExpired, got)
+			}
+			for i := range got {
+				if got[i] != tt.wantExpired[i] {
+					t.Errorf("expected %v to expire, got %v", tt.wantExpired, got)
+				}
+			}
+
+			if len(repo.userCuts) != len(tt.wantUser) {
+				t.Errorf("expected account deletes %v, got %v", tt.wantUser, repo.userCuts)
+			}
+			for userID, want := range tt.wantUser {
+				if !repo.userCuts[userID].Equal(want) {
+					t.Errorf("expected logs before %v deleted, got %v", want, repo.userCuts[userID])
+				}
+			}
+			if !equalTimePtr(repo.otherCut, tt.wantOther) {
+				t.Errorf("expected other accounts' logs before %v deleted, got %v", tt.wantOther, repo.otherCut)
+			}
+			if tt.wantOther != nil && len(repo.except) != len(tt.accounts) {
+				t.Errorf("expected accounts with their own retention to be excluded, got %v", repo.except)
+			}
+		})
+	}
+}
+
+func TestLogRetentionArchivesBeforeDropping(t *testing.T) {
+	dir := t.TempDir()
+	repo := newFakePartitionRepo("2026-01", "2026-05")
+	entries := []*domain.CalculationLog{
+		{ID: uuid.New(), UserID: uuid.New(), StrategyType: "cost_plus", CreatedAt: time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC)},
+		{ID: uuid.New(), UserID: uuid.New(), StrategyType: "geographic", CreatedAt: time.Date(2026, 1, 6, 0, 0, 0, 0, time.UTC)},
+	}
+	repo.logs["calculation_logs_y2026m01"] = entries
+
+	svc := NewLogRetentionService(repo, LogRetentionConfig{RetentionDays: 30, ArchiveDir: dir})
+	svc.now = func() time.Time { return time.Date(2026, 5, 10, 0, 0, 0, 0, time.UTC) }
+
+	report, err := svc.Maintain(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	path := filepath.Join(dir, "calculation_logs_y2026m01.ndjson.gz")
+	if len(report.Archived) != 1 || report.Archived[0] != path {
+		t.Fatalf("expected archive %s, got %v", path, report.Archived)
+	}
+	if len(repo.dropped) != 1 || repo.dropped[0] != "calculation_logs_y2026m01" {
+		t.Errorf("expected the archived partition to be dropped, got %v", repo.dropped)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("failed to open archive: %v", err)
+	}
+	defer file.Close()
+	reader, err := gzip.NewReader(file)
+	if err != nil {
+		t.Fatalf("failed to read archive: %v", err)
+	}
+
+	var archived []*domain.CalculationLog
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		entry := &domain.CalculationLog{}
+		if err := json.Unmarshal(scanner.Bytes(), entry); err != nil {
+			t.Fatalf("failed to decode archived log: %v", err)
+		}
+		archived = append(archived, entry)
+	}
+	if len(archived) != len(entries) || archived[0].ID != entries[0].ID || archived[1].ID != entries[1].ID {
+		t.Errorf("expected the partition's logs in order, got %+v", archived)
+	}
+
+	if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+		t.Errorf("expected no temporary file, got %v", err)
+	}
+}
@@ -0,0 +1,552 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/saintparish4/harmonia/internal/csvsafe"
+	"github.com/saintparish4/harmonia/internal/domain"
+)
+
+// PriceBookService materializes the prices of every active product across a
+// price book's dimensions and keeps them current as rules and products change
+type PriceBookService struct {
+	engine     *PricingEngine
+	resolver   *RuleResolver
+	products   domain.ProductRepository
+	books      domain.PriceBookRepository
+	maxEntries int
+
+	// running tracks the refresh loop and background generations so shutdown can wait
+	running sync.WaitGroup
+}
+
+// NewPriceBookService creates a price book service; maxEntries caps the
+// products times cells of a single book
+func NewPriceBookService(engine *PricingEngine, resolver *RuleResolver, products domain.ProductRepository, books domain.PriceBookRepository, maxEntries int) *PriceBookService {
+	return &PriceBookService{
+		engine:     engine,
+		resolver:   resolver,
+		products:   products,
+		books:      books,
+		maxEntries: maxEntries,
+	}
+}
+
+// Create stores a price book and generates it in the background
+func (s *PriceBookService) Create(ctx context.Context, book *domain.PriceBook) error {
+	if err := s.validate(book); err != nil {
+		return err
+	}
+	if err := s.books.Create(ctx, book); err != nil {
+		return err
+	}
+	s.start(book.ID)
+	return nil
+}
+
+// Get retrieves a price book
+func (s *PriceBookService) Get(ctx context.Context, id uuid.UUID) (*domain.PriceBook, error) {
+	return s.books.GetByID(ctx, id)
+}
+
+// List retrieves a user's price books
+func (s *PriceBookService) List(ctx context.Context, userID uuid.UUID) ([]*domain.PriceBook, error) {
+	return s.books.ListByUser(ctx, userID)
+}
+
+// Update changes a price book's definition and regenerates it in the background
+func (s *PriceBookService) Update(ctx context.Context, book *domain.PriceBook) error {
+	if err := s.validate(book); err != nil {
+		return err
+	}
+	if err := s.books.Update(ctx, book); err != nil {
+		return err
+	}
+	s.start(book.ID)
+	return nil
+}
+
+// Delete deletes a price book and its entries
+func (s *PriceBookService) Delete(ctx context.Context, id uuid.UUID) error {
+	return s.books.Delete(ctx, id)
+}
+
+// Regenerate claims a price book and fully regenerates it in the background.
+// Returns ErrPriceBookBusy if a generation is already running.
+func (s *PriceBookService) Regenerate(ctx context.Context, id uuid.UUID) (*domain.PriceBook, error) {
+	startedAt, err := s.books.Claim(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	s.running.Add(1)
+	go func() {
+		defer s.running.Done()
+		s.generateClaimed(context.Background(), id, startedAt, true, time.Time{})
+	}()
+
+	return s.books.GetByID(ctx, id)
+}
+
+// Entries retrieves a page of a price book's entries and the total matching the filter
+func (s *PriceBookService) Entries(ctx context.Context, filter domain.PriceBookEntryFilter) ([]*domain.PriceBookEntry, int, error) {
+	entries, err := s.books.ListEntries(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	total, err := s.books.CountEntries(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	return entries, total, nil
+}
+
+// Export writes a price book's entries to w. JSON exports wrap the entries
+// with the book, so the generation time and rule revisions travel with them.
+func (s *PriceBookService) Export(ctx context.Context, book *domain.PriceBook, format string, w io.Writer) error {
+	var encoder priceBookEncoder
+	switch format {
+	case domain.PriceBookFormatCSV:
+		encoder = &csvPriceBookEncoder{writer: csv.NewWriter(w)}
+	case domain.PriceBookFormatJSON:
+		encoder = &jsonPriceBookEncoder{buffer: bufio.NewWriter(w)}
+	default:
+		return fmt.Errorf("unsupported price book format: %s", format)
+	}
+
+	if err := encoder.begin(book); err != nil {
+		return err
+	}
+	if err := s.books.EachEntry(ctx, book.ID, encoder.encode); err != nil {
+		return err
+	}
+	return encoder.end()
+}
+
+// Run regenerates price books whose rules or products changed, checking every
+// interval until ctx is cancelled
+func (s *PriceBookService) Run(ctx context.Context, interval time.Duration) {
+	s.running.Add(1)
+	defer s.running.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.Refresh(ctx)
+		}
+	}
+}
+
+// Wait blocks until the refresh loop and background generations have finished
+func (s *PriceBookService) Wait() {
+	s.running.Wait()
+}
+
+// Refresh brings every auto-regenerating price book up to date. New and
+// redefined books are generated in full, as are books whose rule
+// configuration changed; otherwise only changed products are repriced.
+// Failed books are retried once something changes after the failure.
+func (s *PriceBookService) Refresh(ctx context.Context) {
+	books, err := s.books.ListAutoRegenerate(ctx)
+	if err != nil {
+		log.Printf("price books: failed to list: %v", err)
+		return
+	}
+
+	for _, book := range books {
+		if ctx.Err() != nil {
+			return
+		}
+		if err := s.refresh(ctx, book); err != nil {
+			log.Printf("price books: failed to refresh %s: %v", book.ID, err)
+		}
+	}
+}
+
+// refresh regenerates one book if anything it depends on changed
+func (s *PriceBookService) refresh(ctx context.Context, book *domain.PriceBook) error {
+	full := book.Status == domain.PriceBookPending || book.Status == domain.PriceBookGenerating
+	since := book.GeneratedAt
+	if book.Status == domain.PriceBookFailed {
+		since = book.StartedAt
+	}
+
+	if !full {
+		if since == nil {
+			full = true
+		} else {
+			hash, err := s.books.DependencyHash(ctx, book.UserID)
+			if err != nil {
+				return err
+			}
+			full = hash != book.DependencyHash
+		}
+	}
+
+	if !full {
+		changes, err := s.books.Changes(ctx, book.UserID, *since, time.Now())
+		if err != nil {
+			return err
+		}
+		if !changes.Full && len(changes.ProductIDs) == 0 {
+			return nil
+		}
+	}
+
+	startedAt, err := s.books.Claim(ctx, book.ID)
+	if errors.Is(err, domain.ErrPriceBookBusy) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var changedSince time.Time
+	if since != nil {
+		changedSince = *since
+	}
+	s.generateClaimed(ctx, book.ID, startedAt, full, changedSince)
+	return nil
+}
+
+// start claims and generates a book in the background, leaving it to the
+// next refresh if another generation holds it
+func (s *PriceBookService) start(id uuid.UUID) {
+	s.running.Add(1)
+	go func() {
+		defer s.running.Done()
+
+		ctx := context.Background()
+		startedAt, err := s.books.Claim(ctx, id)
+		if err != nil {
+			if !errors.Is(err, domain.ErrPriceBookBusy) {
+				log.Printf("price books: failed to claim %s: %v", id, err)
+			}
+			return
+		}
+		s.generateClaimed(ctx, id, startedAt, true, time.Time{})
+	}()
+}
+
+// generateClaimed generates a claimed book and stores the result. An
+// incremental generation reprices the products changed since changedSince,
+// or everything if the changes need it.
+func (s *PriceBookService) generateClaimed(ctx context.Context, id uuid.UUID, startedAt time.Time, full bool, changedSince time.Time) {
+	book, err := s.books.GetByID(ctx, id)
+	if err != nil {
+		log.Printf("price books: failed to load %s: %v", id, err)
+		return
+	}
+
+	// Fingerprint before reading rules, so a change made while generating
+	// is picked up by the next refresh
+	hash, err := s.books.DependencyHash(ctx, book.UserID)
+	if err != nil {
+		s.fail(ctx, book, err, "")
+		return
+	}
+
+	var productIDs []uuid.UUID
+	if !full {
+		changes, err := s.books.Changes(ctx, book.UserID, changedSince, startedAt)
+		if err != nil {
+			s.fail(ctx, book, err, hash)
+			return
+		}
+		full = changes.Full
+		productIDs = changes.ProductIDs
+	}
+
+	generation, err := s.Generate(ctx, book, full, productIDs, startedAt)
+	if err != nil {
+		s.fail(ctx, book, err, hash)
+		return
+	}
+	generation.DependencyHash = hash
+
+	if err := s.books.Complete(ctx, generation); err != nil {
+		s.fail(ctx, book, err, hash)
+	}
+}
+
+// fail records a failed generation
+func (s *PriceBookService) fail(ctx context.Context, book *domain.PriceBook, cause error, hash string) {
+	if err := s.books.Fail(ctx, book.ID, cause.Error(), hash); err != nil {
+		log.Printf("price books: failed to record failure of %s: %v (%v)", book.ID, err, cause)
+	}
+}
+
+// Generate prices a book's products at every cell as of the given time. A
+// full generation covers every active product; otherwise only productIDs are
+// repriced, and those no longer active are left without entries. Products
+// without a rule in effect, and calculations that fail, are stored as entries
+// with an error so a storefront can tell them from missing products.
+func (s *PriceBookService) Generate(ctx context.Context, book *domain.PriceBook, full bool, productIDs []uuid.UUID, at time.Time) (*domain.PriceBookGeneration, error) {
+	generation := &domain.PriceBookGeneration{
+		PriceBookID: book.ID,
+		Version:     book.Version,
+		Full:        full,
+		ProductIDs:  productIDs,
+		StartedAt:   at,
+	}
+
+	var products []*domain.Product
+	if full {
+		var err error
+		if products, err = s.products.GetByUserID(ctx, book.UserID); err != nil {
+			return nil, err
+		}
+	} else {
+		for _, id := range productIDs {
+			product, err := s.products.GetByID(ctx, id)
+			if err != nil || product.UserID != book.UserID || !product.IsActive {
+				continue
+			}
+			products = append(products, product)
+		}
+	}
+
+	cells := book.Dimensions.Cells()
+	if full && len(products)*len(cells) > s.maxEntries {
+		return nil, fmt.Errorf("%w: %d products at %d cells is %d entries, limit %d",
+			domain.ErrPriceBookTooLarge, len(products), len(cells), len(products)*len(cells), s.maxEntries)
+	}
+
+	for _, product := range products {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		entries, err := s.priceProduct(ctx, book, product, cells, at)
+		if err != nil {
+			return nil, err
+		}
+		generation.Entries = append(generation.Entries, entries...)
+	}
+
+	return generation, nil
+}
+
+// priceProduct prices one product at every cell. The rule and cost are
+// resolved once, since neither depends on the cell.
+func (s *PriceBookService) priceProduct(ctx context.Context, book *domain.PriceBook, product *domain.Product, cells []domain.PriceBookCell, at time.Time) ([]*domain.PriceBookEntry, error) {
+	cost := product.BaseCost
+	if current, err := s.products.CostAt(ctx, product.ID, at); err == nil {
+		cost = current.Cost
+	}
+
+	resolved, err := s.resolver.Resolve(ctx, book.UserID, nil, product.SKU, at)
+	if err != nil && !errors.Is(err, domain.ErrNoRuleInEffect) {
+		return nil, err
+	}
+
+	entries := make([]*domain.PriceBookEntry, len(cells))
+	for i, cell := range cells {
+		entry := &domain.PriceBookEntry{
+			PriceBookID:   book.ID,
+			ProductID:     product.ID,
+			SKU:           product.SKU,
+			PriceBookCell: cell,
+			BaseCost:      cost,
+			GeneratedAt:   at,
+		}
+		entries[i] = entry
+
+		if resolved == nil {
+			entry.Error = err.Error()
+			continue
+		}
+
+		rule := resolved.Rule
+		entry.StrategyType = rule.StrategyType
+		entry.RuleID = &rule.ID
+		entry.RuleRevisionID = rule.RevisionID
+		entry.RuleRevision = rule.Revision
+		entry.RuleSource = resolved.Source.Level
+
+		inputs := BuildInputs(book.Context, cost, book.Quantity)
+		ApplyProductCost(inputs, book.Context, cost)
+		if cell.Location != "" {
+			inputs["location"] = cell.Location
+		}
+		if cell.Currency != "" {
+			inputs["currency"] = cell.Currency
+		}
+		if cell.Channel != "" {
+			inputs["channel"] = cell.Channel
+		}
+
+		response, calcErr := s.engine.Calculate(&domain.PricingRequest{
+			Strategy:    rule.StrategyType,
+			ProductSKU:  product.SKU,
+			Inputs:      inputs,
+			RuleID:      &rule.ID,
+			RequestedAt: at,
+		}, rule.Config)
+		if calcErr != nil {
+			entry.Error = calcErr.Error()
+			continue
+		}
+
+		price := response.FinalPrice
+		entry.FinalPrice = &price
+		entry.PriceCurrency = response.Currency
+		if entry.PriceCurrency == "" {
+			entry.PriceCurrency = cell.Currency
+		}
+	}
+
+	return entries, nil
+}
+
+// validate applies defaults and checks a book's definition
+func (s *PriceBookService) validate(book *domain.PriceBook) error {
+	if book.Quantity == 0 {
+		book.Quantity = 1
+	}
+	if book.Quantity < 0 {
+		return fmt.Errorf("%w: quantity must be positive", domain.ErrInvalidPriceBook)
+	}
+
+	dimensions := []struct {
+		name   string
+		values []string
+	}{
+		{"locations", book.Dimensions.Locations},
+		{"currencies", book.Dimensions.Currencies},
+		{"channels", book.Dimensions.Channels},
+	}
+	for _, dimension := range dimensions {
+		seen := make(map[string]bool, len(dimension.values))
+		for _, value := range dimension.values {
+			if value == "" {
+				return fmt.Errorf("%w: %s cannot contain empty values", domain.ErrInvalidPriceBook, dimension.name)
+			}
+			if seen[value] {
+				return fmt.Errorf("%w: %s contains %s twice", domain.ErrInvalidPriceBook, dimension.name, value)
+			}
+			seen[value] = true
+		}
+	}
+
+	if cells := len(book.Dimensions.Cells()); cells > s.maxEntries {
+		return fmt.Errorf("%w: %d cells exceed the limit of %d entries", domain.ErrPriceBookTooLarge, cells, s.maxEntries)
+	}
+	return nil
+}
+
+// priceBookEncoder writes price book entries in an export format
+type priceBookEncoder interface {
+	begin(book *domain.PriceBook) error
+	encode(entry *domain.PriceBookEntry) error
+	end() error
+}
+
+// csvPriceBookEncoder writes a header row, then one row per entry
+type csvPriceBookEncoder struct {
+	writer *csv.Writer
+	rows   int
+}
+
+func (e *csvPriceBookEncoder) begin(book *domain.PriceBook) error {
+	return e.writer.Write(domain.PriceBookColumns)
+}
+
+func (e *csvPriceBookEncoder) encode(entry *domain.PriceBookEntry) error {
+	finalPrice, ruleID, revision := "", "", ""
+	if entry.FinalPrice != nil {
+		finalPrice = strconv.FormatFloat(*entry.FinalPrice, 'f', 2, 64)
+	}
+	if entry.RuleID != nil {
+		ruleID = entry.RuleID.String()
+	}
+	if entry.RuleRevision > 0 {
+		revision = strconv.Itoa(entry.RuleRevision)
+	}
+
+	err := e.writer.Write([]string{
+		csvsafe.Cell(entry.SKU),
+		csvsafe.Cell(entry.Location),
+		csvsafe.Cell(entry.Currency),
+		csvsafe.Cell(entry.Channel),
+		finalPrice,
+		csvsafe.Cell(entry.PriceCurrency),
+		strconv.FormatFloat(entry.BaseCost, 'f', -1, 64),
+		csvsafe.Cell(entry.StrategyType),
+		ruleID,
+		revision,
+		csvsafe.Cell(entry.RuleSource),
+		csvsafe.Cell(entry.Error),
+	})
+	if err != nil {
+		return err
+	}
+
+	// Flush in chunks so large books stream instead of buffering
+	e.rows++
+	if e.rows%productExportPageSize == 0 {
+		e.writer.Flush()
+		return e.writer.Error()
+	}
+	return nil
+}
+
+func (e *csvPriceBookEncoder) end() error {
+	e.writer.Flush()
+	return e.writer.Error()
+}
+
+// jsonPriceBookEncoder writes {"price_book": {...}, "entries": [...]} one entry at a time
+type jsonPriceBookEncoder struct {
+	buffer *bufio.Writer
+	rows   int
+}
+
+func (e *jsonPriceBookEncoder) begin(book *domain.PriceBook) error {
+	header, err := json.Marshal(book)
+	if err != nil {
+		return fmt.Errorf("failed to encode price book: %w", err)
+	}
+	if _, err := fmt.Fprintf(e.buffer, `{"price_book":%s,"entries":[`, header); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (e *jsonPriceBookEncoder) encode(entry *domain.PriceBookEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode price book entry %s: %w", entry.SKU, err)
+	}
+	if e.rows > 0 {
+		if err := e.buffer.WriteByte(','); err != nil {
+			return err
+		}
+	}
+	e.rows++
+	_, err = e.buffer.Write(line)
+	return err
+}
+
+func (e *jsonPriceBookEncoder) end() error {
+	if _, err := e.buffer.WriteString("]}\n"); err != nil {
+		return err
+	}
+	return e.buffer.Flush()
+}
@@ -0,0 +1,487 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/saintparish4/harmonia/internal/domain"
+)
+
+// fakePriceBookProducts adds active product listing and costs in effect to fakeCostRepo
+type fakePriceBookProducts struct {
+	*fakeCostRepo
+}
+
+func (r *fakePriceBookProducts) GetByUserID(ctx context.Context, userID uuid.UUID) ([]*domain.Product, error) {
+	var out []*domain.Product
+	for _, product := range r.products {
+		if product.UserID == userID && product.IsActive {
+			out = append(out, product)
+		}
+	}
+	return out, nil
+}
+
+func (r *fakePriceBookProducts) CostAt(ctx context.Context, productID uuid.UUID, at time.Time) (*domain.ProductCost, error) {
+	var current *domain.ProductCost
+	for _, cost := range r.costs {
+		if cost.ProductID == productID && !cost.EffectiveFrom.After(at) {
+			if current == nil || cost.EffectiveFrom.After(current.EffectiveFrom) {
+				current = cost
+			}
+		}
+	}
+	if current == nil {
+		return nil, domain.ErrNoCostInEffect
+	}
+	return current, nil
+}
+
+// fakePriceBookRepo serves price books from memory and records generations
+type fakePriceBookRepo struct {
+	domain.PriceBookRepository
+	books     map[uuid.UUID]*domain.PriceBook
+	hash      string
+	changes   domain.PriceBookChanges
+	busy      bool
+	claims    int
+	completed []*domain.PriceBookGeneration
+	failures  []string
+	entries   []*domain.PriceBookEntry
+}
+
+func (r *fakePriceBookRepo) GetByID(ctx context.Context, id uuid.UUID) (*domain.PriceBook, error) {
+	book, ok := r.books[id]
+	if !ok {
+		return nil, domain.ErrPriceBookNotFound
+	}
+	return book, nil
+}
+
+func (r *fakePriceBookRepo) ListAutoRegenerate(ctx context.Context) ([]*domain.PriceBook, error) {
+	var out []*domain.PriceBook
+	for _, book := range r.books {
+		if book.AutoRegenerate {
+			out = append(out, book)
+		}
+	}
+	return out, nil
+}
+
+func (r *fakePriceBookRepo) Claim(ctx context.Context, id uuid.UUID) (time.Time, error) {
+	if r.busy {
+		return time.Time{}, domain.ErrPriceBookBusy
+	}
+	r.claims++
+	return *at(20), nil
+}
+
+func (r *fakePriceBookRepo) Complete(ctx context.Context, generation *domain.PriceBookGeneration) error {
+	r.completed = append(r.completed, generation)
+	return nil
+}
+
+func (r *fakePriceBookRepo) Fail(ctx context.Context, id uuid.UUID, message, dependencyHash string) error {
+	r.failures = append(r.failures, message)
+	return nil
+}
+
+func (r *fakePriceBookRepo) DependencyHash(ctx context.Context, userID uuid.UUID) (string, error) {
+	return r.hash, nil
+}
+
+func (r *fakePriceBookRepo) Changes(ctx context.Context, userID uuid.UUID, since, now time.Time) (*domain.PriceBookChanges, error) {
+	changes := r.changes
+	return &changes, nil
+}
+
+func (r *fakePriceBookRepo) EachEntry(ctx context.Context, priceBookID uuid.UUID, fn func(*domain.PriceBookEntry) error) error {
+	for _, entry := range r.entries {
+		if err := fn(entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// priceBookFixture is an account with a geographic rule, a product priced by
+// it, one without a rule, an inactive one and another account's product
+type priceBookFixture struct {
+	userID                              uuid.UUID
+	rule                                *domain.PricingRule
+	priced, unpriced, inactive, foreign *domain.Product
+	products                            *fakePriceBookProducts
+	service                             *PriceBookService
+}
+
+func newPriceBookFixture(maxEntries int) *priceBookFixture {
+	userID := uuid.New()
+	published := *at(1)
+
+	rule := &domain.PricingRule{
+		ID:           uuid.New(),
+		UserID:       userID,
+		StrategyType: domain.StrategyTypeGeographic,
+		Config: map[string]interface{}{
+			"regional_multipliers": map[string]interface{}{"US": 1.0, "DE": 1.2},
+			"currency_map":         map[string]interface{}{"US": "USD", "DE": "EUR"},
+		},
+		IsActive:    true,
+		PublishedAt: &published,
+		Revision:    3,
+	}
+	rules := &fakeRuleRepo{rules: map[uuid.UUID]*domain.PricingRule{rule.ID: rule}}
+
+	priced := &domain.Product{ID: uuid.New(), UserID: userID, SKU: "WIDGET-1", BaseCost: 80, DefaultRuleID: &rule.ID, IsActive: true}
+	unpriced := &domain.Product{ID: uuid.New(), UserID: userID, SKU: "WIDGET-2", BaseCost: 10, IsActive: true}
+	inactive := &domain.Product{ID: uuid.New(), UserID: userID, SKU: "WIDGET-3", BaseCost: 10, DefaultRuleID: &rule.ID}
+	foreign := &domain.Product{ID: uuid.New(), UserID: uuid.New(), SKU: "WIDGET-1", BaseCost: 10, DefaultRuleID: &rule.ID, IsActive: true}
+
+	products := &fakePriceBookProducts{&fakeCostRepo{
+		fakeProductRepo: &fakeProductRepo{products: []*domain.Product{priced, unpriced, inactive, foreign}},
+		costs: []*domain.ProductCost{
+			newCost(priced.ID, 100, 10),
+			newCost(priced.ID, 200, 25), // not in effect yet
+		},
+	}}
+	users := &fakeUserRepo{users: map[uuid.UUID]*domain.User{userID: {ID: userID}}}
+	resolver := NewRuleResolver(rules, products, &fakeCategoryRepo{}, users)
+
+	return &priceBookFixture{
+		userID:   userID,
+		rule:     rule,
+		priced:   priced,
+		unpriced: unpriced,
+		inactive: inactive,
+		foreign:  foreign,
+		products: products,
+		service:  NewPriceBookService(NewPricingEngine(), resolver, products, nil, maxEntries),
+	}
+}
+
+func TestPriceBookDimensions_Cells(t *testing.T) {
+	tests := []struct {
+		name       string
+		dimensions domain.PriceBookDimensions
+		want       []domain.PriceBookCell
+	}{
+		{
+			name: "no dimensions",
+			want: []domain.PriceBookCell{{}},
+		},
+		{
+			name:       "single dimension",
+			dimensions: domain.PriceBookDimensions{Currencies: []string{"USD", "EUR"}},
+			want:       []domain.PriceBookCell{{Currency: "USD"}, {Currency: "EUR"}},
+		},
+		{
+			name: "locations vary slowest",
+			dimensions: domain.PriceBookDimensions{
+				Locations: []string{"US", "DE"},
+				Channels:  []string{"web", "pos"},
+			},
+			want: []domain.PriceBookCell{
+				{Location: "US", Channel: "web"},
+				{Location: "US", Channel: "pos"},
+				{Location: "DE", Channel: "web"},
+				{Location: "DE", Channel: "pos"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.dimensions.Cells(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestPriceBookService_Generate(t *testing.T) {
+	f := newPriceBookFixture(100)
+	book := &domain.PriceBook{
+		ID:         uuid.New(),
+		UserID:     f.userID,
+		Dimensions: domain.PriceBookDimensions{Locations: []string{"US", "DE"}},
+		Quantity:   1,
+		Version:    2,
+	}
+	ctx := context.Background()
+
+	t.Run("full generation prices active products at every cell", func(t *testing.T) {
+		generation, err := f.service.Generate(ctx, book, true, nil, *at(20))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !generation.Full || generation.Version != 2 || !generation.StartedAt.Equal(*at(20)) {
+			t.Errorf("unexpected generation header: %+v", generation)
+		}
+		if len(generation.Entries) != 4 {
+			t.Fatalf("expected 4 entries, got %d", len(generation.Entries))
+		}
+
+		us, de := generation.Entries[0], generation.Entries[1]
+		if us.SKU != "WIDGET-1" || us.Location != "US" || de.Location != "DE" {
+			t.Fatalf("unexpected entry order: %+v, %+v", us, de)
+		}
+		// The cost in effect on the 20th, not the product's stored base cost
+		if us.BaseCost != 100 || !equalFloatPtr(us.FinalPrice, floatPtr(100)) || us.PriceCurrency != "USD" {
+			t.Errorf("unexpected US entry: %+v", us)
+		}
+		if !equalFloatPtr(de.FinalPrice, floatPtr(120)) || de.PriceCurrency != "EUR" {
+			t.Errorf("unexpected DE entry: %+v", de)
+		}
+		if us.RuleID == nil || *us.RuleID != f.rule.ID || us.RuleRevision != 3 || us.RuleSource != domain.RuleSourceProduct {
+			t.Errorf("expected rule provenance, got %+v", us)
+		}
+
+		for _, entry := range generation.Entries[2:] {
+			if entry.SKU != "WIDGET-2" || entry.FinalPrice != nil || entry.Error == "" {
+				t.Errorf("expected an unpriced entry with an error, got %+v", entry)
+			}
+			if entry.BaseCost != 10 {
+				t.Errorf("expected the stored base cost without a cost history, got %v", entry.BaseCost)
+			}
+		}
+	})
+
+	t.Run("incremental generation skips inactive and foreign products", func(t *testing.T) {
+		ids := []uuid.UUID{f.priced.ID, f.inactive.ID, f.foreign.ID, uuid.New()}
+		generation, err := f.service.Generate(ctx, book, false, ids, *at(20))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if generation.Full || !reflect.DeepEqual(generation.ProductIDs, ids) {
+			t.Errorf("expected the changed products to be replaced, got %+v", generation)
+		}
+		if len(generation.Entries) != 2 {
+			t.Fatalf("expected 2 entries, got %d", len(generation.Entries))
+		}
+		for _, entry := range generation.Entries {
+			if entry.ProductID != f.priced.ID {
+				t.Errorf("unexpected entry for %s", entry.SKU)
+			}
+		}
+	})
+
+	t.Run("strategy errors are stored on the entry", func(t *testing.T) {
+		noLocation := &domain.PriceBook{ID: uuid.New(), UserID: f.userID, Quantity: 1}
+		generation, err := f.service.Generate(ctx, noLocation, false, []uuid.UUID{f.priced.ID}, *at(20))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		entry := generation.Entries[0]
+		if entry.FinalPrice != nil || !strings.Contains(entry.Error, "location is required") || entry.RuleID == nil {
+			t.Errorf("expected a calculation error with the rule, got %+v", entry)
+		}
+	})
+
+	t.Run("full generation over the entry limit", func(t *testing.T) {
+		small := newPriceBookFixture(3)
+		large := *book
+		large.UserID = small.userID
+		_, err := small.service.Generate(ctx, &large, true, nil, *at(20))
+		if !errors.Is(err, domain.ErrPriceBookTooLarge) {
+			t.Errorf("expected ErrPriceBookTooLarge, got %v", err)
+		}
+	})
+}
+
+func TestPriceBookService_Validate(t *testing.T) {
+	service := NewPriceBookService(nil, nil, nil, nil, 4)
+
+	tests := []struct {
+		name       string
+		dimensions domain.PriceBookDimensions
+		quantity   int
+		wantErr    error
+	}{
+		{name: "defaults", dimensions: domain.PriceBookDimensions{Locations: []string{"US", "DE"}}},
+		{name: "negative quantity", quantity: -1, wantErr: domain.ErrInvalidPriceBook},
+		{name: "empty value", dimensions: domain.PriceBookDimensions{Channels: []string{""}}, wantErr: domain.ErrInvalidPriceBook},
+		{name: "duplicate value", dimensions: domain.PriceBookDimensions{Currencies: []string{"USD", "USD"}}, wantErr: domain.ErrInvalidPriceBook},
+		{
+			name: "too many cells",
+			dimensions: domain.PriceBookDimensions{
+				Locations:  []string{"US", "DE", "FR"},
+				Currencies: []string{"USD", "EUR"},
+			},
+			wantErr: domain.ErrPriceBookTooLarge,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			book := &domain.PriceBook{Dimensions: tt.dimensions, Quantity: tt.quantity}
+			err := service.validate(book)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("expected %v, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if book.Quantity != 1 {
+				t.Errorf("expected quantity to default to 1, got %d", book.Quantity)
+			}
+		})
+	}
+}
+
+func TestPriceBookService_Refresh(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      string
+		hash        string
+		changes     domain.PriceBookChanges
+		changed     bool // a product changed since the last generation
+		busy        bool
+		wantClaims  int
+		wantFull    bool
+		wantEntries int
+	}{
+		{name: "pending book is generated in full", status: domain.PriceBookPending, hash: "h1", wantClaims: 1, wantFull: true, wantEntries: 2},
+		{name: "unchanged book is skipped", status: domain.PriceBookReady, hash: "h1"},
+		{name: "rule configuration changed", status: domain.PriceBookReady, hash: "h2", wantClaims: 1, wantFull: true, wantEntries: 2},
+		{name: "rule window opened", status: domain.PriceBookReady, hash: "h1", changes: domain.PriceBookChanges{Full: true}, wantClaims: 1, wantFull: true, wantEntries: 2},
+		{name: "product changed", status: domain.PriceBookReady, hash: "h1", changed: true, wantClaims: 1, wantEntries: 1},
+		{name: "failed book retried after a change", status: domain.PriceBookFailed, hash: "h1", changed: true, wantClaims: 1, wantEntries: 1},
+		{name: "generation already running", status: domain.PriceBookPending, hash: "h1", busy: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newPriceBookFixture(100)
+			book := &domain.PriceBook{
+				ID:             uuid.New(),
+				UserID:         f.userID,
+				Quantity:       1,
+				Context:        map[string]interface{}{"location": "US"},
+				AutoRegenerate: true,
+				Status:         tt.status,
+				GeneratedAt:    at(15),
+				StartedAt:      at(16),
+				DependencyHash: "h1",
+			}
+			changes := tt.changes
+			if tt.changed {
+				changes.ProductIDs = []uuid.UUID{f.unpriced.ID}
+			}
+			repo := &fakePriceBookRepo{
+				books:   map[uuid.UUID]*domain.PriceBook{book.ID: book},
+				hash:    tt.hash,
+				changes: changes,
+				busy:    tt.busy,
+			}
+			f.service.books = repo
+
+			f.service.Refresh(context.Background())
+
+			if repo.claims != tt.wantClaims {
+				t.Fatalf("expected %d claims, got %d", tt.wantClaims, repo.claims)
+			}
+			if len(repo.failures) > 0 {
+				t.Fatalf("unexpected failures: %v", repo.failures)
+			}
+			if tt.wantClaims == 0 {
+				if len(repo.completed) != 0 {
+					t.Errorf("expected no generation, got %d", len(repo.completed))
+				}
+				return
+			}
+
+			generation := repo.completed[0]
+			if generation.Full != tt.wantFull || len(generation.Entries) != tt.wantEntries {
+				t.Errorf("expected full=%v with %d entries, got full=%v with %d", tt.wantFull, tt.wantEntries, generation.Full, len(generation.Entries))
+			}
+			if generation.DependencyHash != tt.hash {
+				t.Errorf("expected the fingerprint %s to be stored, got %s", tt.hash, generation.DependencyHash)
+			}
+		})
+	}
+}
+
+func TestPriceBookService_Export(t *testing.T) {
+	generatedAt := *at(20)
+	ruleID := uuid.New()
+	book := &domain.PriceBook{ID: uuid.New(), Name: "Retail", Generation: 4, GeneratedAt: &generatedAt}
+	repo := &fakePriceBookRepo{entries: []*domain.PriceBookEntry{
+		{
+			SKU:           "WIDGET-1",
+			PriceBookCell: domain.PriceBookCell{Location: "DE", Currency: "EUR"},
+			FinalPrice:    floatPtr(120),
+			PriceCurrency: "EUR",
+			BaseCost:      100,
+			StrategyType:  domain.StrategyTypeGeographic,
+			RuleID:        &ruleID,
+			RuleRevision:  3,
+			RuleSource:    domain.RuleSourceProduct,
+		},
+		{SKU: "WIDGET-2", BaseCost: 10.5, Error: "no pricing rule in effect"},
+	}}
+	service := NewPriceBookService(nil, nil, nil, repo, 100)
+
+	t.Run("csv", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := service.Export(context.Background(), book, domain.PriceBookFormatCSV, &buf); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		want := strings.Join(domain.PriceBookColumns, ",") + "\n" +
+			"WIDGET-1,DE,EUR,,120.00,EUR,100,geographic," + ruleID.String() + ",3,product,\n" +
+			"WIDGET-2,,,,,,10.5,,,,,no pricing rule in effect\n"
+		if buf.String() != want {
+			t.Errorf("unexpected csv:\n%s\nwant:\n%s", buf.String(), want)
+		}
+	})
+
+	t.Run("csv formulas", func(t *testing.T) {
+		formulas := NewPriceBookService(nil, nil, nil, &fakePriceBookRepo{entries: []*domain.PriceBookEntry{
+			{SKU: "=HYPERLINK(\"http://x\")", PriceBookCell: domain.PriceBookCell{Location: "@DE", Channel: "+web"}, BaseCost: -1, Error: "-bad"},
+		}}, 100)
+		var buf bytes.Buffer
+		if err := formulas.Export(context.Background(), book, domain.PriceBookFormatCSV, &buf); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		want := strings.Join(domain.PriceBookColumns, ",") + "\n" +
+			"\"'=HYPERLINK(\"\"http://x\"\")\",'@DE,,'+web,,,-1,,,,,'-bad\n"
+		if buf.String() != want {
+			t.Errorf("unexpected csv:\n%s\nwant:\n%s", buf.String(), want)
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := service.Export(context.Background(), book, domain.PriceBookFormatJSON, &buf); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		var doc struct {
+			PriceBook domain.PriceBook         `json:"price_book"`
+			Entries   []*domain.PriceBookEntry `json:"entries"`
+		}
+		if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+			t.Fatalf("invalid json: %v\n%s", err, buf.String())
+		}
+		if doc.PriceBook.Generation != 4 || !doc.PriceBook.GeneratedAt.Equal(generatedAt) {
+			t.Errorf("expected the book header, got %+v", doc.PriceBook)
+		}
+		if len(doc.Entries) != 2 || doc.Entries[0].Location != "DE" || !equalFloatPtr(doc.Entries[0].FinalPrice, floatPtr(120)) {
+			t.Errorf("unexpected entries: %+v", doc.Entries)
+		}
+	})
+
+	t.Run("unknown format", func(t *testing.T) {
+		if err := service.Export(context.Background(), book, "xml", &bytes.Buffer{}); err == nil {
+			t.Error("expected an error")
+		}
+	})
+}
@@ -1,12 +1,19 @@
 package service
 
 import (
+	"errors"
 	"fmt"
+	"sort"
 	"time"
 
 	"github.com/saintparish4/harmonia/internal/domain"
+	"github.com/saintparish4/harmonia/internal/metrics"
 )
 
+// EngineVersion identifies the pricing math in calculation logs. Bump it
+// whenever a strategy changes how it prices the same inputs.
+const EngineVersion = "1.0.0"
+
 // PricingEngine coordinates all pricing strategies
 type PricingEngine struct {
 	strategies map[string]domain.PricingStrategy
@@ -23,6 +30,7 @@ func NewPricingEngine() *PricingEngine {
 	engine.RegisterStrategy(&GeographicStrategy{})
 	engine.RegisterStrategy(&TimeBasedStrategy{})
 	engine.RegisterStrategy(&RuleBasedStrategy{})
+	engine.RegisterStrategy(&FormulaStrategy{})
 
 	return engine
 }
@@ -34,6 +42,25 @@ func (e *PricingEngine) RegisterStrategy(strategy domain.PricingStrategy) {
 
 // Calculate processes a pricing request and returns the calculated price
 func (e *PricingEngine) Calculate(req *domain.PricingRequest, config map[string]interface{}) (*domain.PricingResponse, error) {
+	started := time.Now()
+	response, err := e.calculate(req, config)
+
+	// Unregistered strategies share a label, so requests can't add series
+	strategy := req.Strategy
+	if _, exists := e.strategies[strategy]; !exists {
+		strategy = "unknown"
+	}
+	metrics.Calculations.Inc(strategy)
+	if err != nil {
+		metrics.CalculationErrors.Inc(strategy)
+	}
+	metrics.CalculationDuration.Observe(time.Since(started).Seconds(), strategy)
+
+	return response, err
+}
+
+// calculate prices a request with its strategy
+func (e *PricingEngine) calculate(req *domain.PricingRequest, config map[string]interface{}) (*domain.PricingResponse, error) {
 	// Validate strategy exists
 	if err := domain.ValidateStrategy(req.Strategy); err != nil {
 		return nil, err
@@ -95,11 +122,47 @@ func (e *PricingEngine) ListStrategies() []string {
 	return strategies
 }
 
-// ValidateConfig validates a configuration for a specific strategy
+// ListSchemas returns the schema of every registered strategy, sorted by type
+func (e *PricingEngine) ListSchemas() []domain.StrategySchema {
+	schemas := make([]domain.StrategySchema, 0, len(e.strategies))
+	for _, strategy := range e.strategies {
+		schemas = append(schemas, strategy.Schema())
+	}
+	sort.Slice(schemas, func(i, j int) bool {
+		return schemas[i].Type < schemas[j].Type
+	})
+	return schemas
+}
+
+// GetSchema returns the schema of a registered strategy
+func (e *PricingEngine) GetSchema(name string) (*domain.StrategySchema, error) {
+	strategy, err := e.GetStrategy(name)
+	if err != nil {
+		return nil, err
+	}
+	schema := strategy.Schema()
+	return &schema, nil
+}
+
+// ValidateConfig validates a configuration for a specific strategy.
+// Configuration problems are returned as a *domain.ValidationError whose
+// paths are JSON pointers relative to the config; an unknown strategy
+// returns domain.ErrInvalidStrategy.
 func (e *PricingEngine) ValidateConfig(strategyName string, config map[string]interface{}) error {
 	strategy, err := e.GetStrategy(strategyName)
 	if err != nil {
-		return err
+		return fmt.Errorf("%w: %s", domain.ErrInvalidStrategy, strategyName)
+	}
+
+	err = strategy.Validate(config)
+	if err == nil {
+		return nil
+	}
+
+	var ve *domain.ValidationError
+	if errors.As(err, &ve) {
+		return ve
 	}
-	return strategy.Validate(config)
+	// Strategies that report a plain error get a single document-level entry
+	return domain.NewValidationError("", "%v", err)
 }
@@ -1,6 +1,7 @@
 package service
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/saintparish4/harmonia/internal/domain"
@@ -130,8 +131,8 @@ func TestPricingEngine_ListStrategies(t *testing.T) {
 
 	strategies := engine.ListStrategies()
 
-	if len(strategies) != 4 {
-		t.Errorf("expected 4 strategies, got %d", len(strategies))
+	if len(strategies) != 5 {
+		t.Errorf("expected 5 strategies, got %d", len(strategies))
 	}
 
 	// Check all expected strategies are present
@@ -140,6 +141,7 @@ func TestPricingEngine_ListStrategies(t *testing.T) {
 		domain.StrategyTypeGeographic: false,
 		domain.StrategyTypeTimeBased:  false,
 		domain.StrategyTypeRuleBased:  false,
+		domain.StrategyTypeFormula:    false,
 	}
 
 	for _, strategy := range strategies {
@@ -264,4 +266,140 @@ func TestPricingEngine_ValidateConfig(t *testing.T) {
 			}
 		})
 	}
-}
\ No newline at end of file
+}
+func TestPricingEngine_ListSchemas(t *testing.T) {
+	engine := NewPricingEngine()
+
+	schemas := engine.ListSchemas()
+
+	if len(schemas) != len(engine.ListStrategies()) {
+		t.Fatalf("expected a schema per strategy, got %d", len(schemas))
+	}
+
+	for i, schema := range schemas {
+		if i > 0 && schemas[i-1].Type >= schema.Type {
+			t.Errorf("schemas should be sorted by type, got %s before %s", schemas[i-1].Type, schema.Type)
+		}
+
+		strategy, err := engine.GetStrategy(schema.Type)
+		if err != nil {
+			t.Errorf("schema type %s is not a registered strategy", schema.Type)
+			continue
+		}
+		if strategy.Name() != schema.Type {
+			t.Errorf("expected schema type %s, got %s", strategy.Name(), schema.Type)
+		}
+
+		if schema.Name == "" || schema.Description == "" {
+			t.Errorf("schema %s should have a name and description", schema.Type)
+		}
+		if schema.ConfigSchema["type"] != "object" {
+			t.Errorf("schema %s config should be an object schema", schema.Type)
+		}
+		if schema.InputSchema["type"] != "object" {
+			t.Errorf("schema %s inputs should be an object schema", schema.Type)
+		}
+	}
+}
+
+func TestPricingEngine_GetSchema(t *testing.T) {
+	engine := NewPricingEngine()
+
+	schema, err := engine.GetSchema(domain.StrategyTypeGeographic)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The geographic strategy reads "location", not "region_code"
+	required, _ := schema.InputSchema["required"].([]string)
+	found := false
+	for _, field := range required {
+		if field == "location" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected geographic inputs to require location, got %v", required)
+	}
+
+	if _, err := engine.GetSchema("invalid"); err == nil {
+		t.Error("expected error for unknown strategy, got nil")
+	}
+}
+
+func TestPricingEngine_ValidateConfig_FieldErrors(t *testing.T) {
+	engine := NewPricingEngine()
+
+	tests := []struct {
+		name         string
+		strategyName string
+		config       map[string]interface{}
+		wantPaths    []string
+	}{
+		{
+			name:         "empty time windows",
+			strategyName: domain.StrategyTypeTimeBased,
+			config: map[string]interface{}{
+				"time_windows": []interface{}{},
+			},
+			wantPaths: []string{"/time_windows"},
+		},
+		{
+			name:         "negative multiplier and bad time",
+			strategyName: domain.StrategyTypeTimeBased,
+			config: map[string]interface{}{
+				"time_windows": []interface{}{
+					map[string]interface{}{"multiplier": -1.0, "start_time": "25:00"},
+				},
+			},
+			wantPaths: []string{"/time_windows/0/multiplier", "/time_windows/0/start_time"},
+		},
+		{
+			name:         "region name is escaped",
+			strategyName: domain.StrategyTypeGeographic,
+			config: map[string]interface{}{
+				"regional_multipliers": map[string]interface{}{"US/CA": -2.0},
+			},
+			wantPaths: []string{"/regional_multipliers/US~1CA"},
+		},
+		{
+			name:         "formula variable expression",
+			strategyName: domain.StrategyTypeFormula,
+			config: map[string]interface{}{
+				"price": "base",
+				"variables": []interface{}{
+					map[string]interface{}{"name": "base", "expression": "1 +"},
+				},
+			},
+			wantPaths: []string{"/variables/0/expression"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := engine.ValidateConfig(tt.strategyName, tt.config)
+
+			var ve *domain.ValidationError
+			if !errors.As(err, &ve) {
+				t.Fatalf("expected *domain.ValidationError, got %v", err)
+			}
+			if !errors.Is(err, domain.ErrConfigurationInvalid) {
+				t.Errorf("expected error to wrap ErrConfigurationInvalid")
+			}
+
+			paths := make(map[string]bool, len(ve.Errors))
+			for _, fe := range ve.Errors {
+				paths[fe.Path] = true
+			}
+			for _, want := range tt.wantPaths {
+				if !paths[want] {
+					t.Errorf("expected error at %s, got %v", want, ve.Errors)
+				}
+			}
+		})
+	}
+
+	if err := engine.ValidateConfig("invalid", nil); !errors.Is(err, domain.ErrInvalidStrategy) {
+		t.Errorf("expected ErrInvalidStrategy for unknown strategy, got %v", err)
+	}
+}
@@ -0,0 +1,987 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/saintparish4/harmonia/internal/domain"
+)
+
+const (
+	// productImportBatchSize is the number of rows looked up and upserted together
+	productImportBatchSize = 500
+
+	// productImportMaxErrors is the number of row errors kept in a job's result
+	productImportMaxErrors = 1000
+
+	// productImportRetention is how long finished jobs are kept in memory
+	productImportRetention = time.Hour
+
+	// productImportMaxRunning is the number of imports run at once
+	productImportMaxRunning = 2
+
+	// productMaxFileSize limits the size of an uploaded product file
+	productMaxFileSize = 50 << 20 // 50 MB
+
+	// productExportPageSize is the number of products fetched per query when exporting
+	productExportPageSize = 500
+
+	// productMaxLineSize limits a single NDJSON line
+	productMaxLineSize = 1 << 20 // 1 MB
+
+	// Column limits from the products table
+	productSKUMaxLength  = 100
+	productNameMaxLength = 255
+	productMaxBaseCost   = 1e8 // DECIMAL(10,2)
+)
+
+// utf8BOM is written at the start of CSV files by some spreadsheet tools
+var utf8BOM = []byte("\xef\xbb\xbf")
+
+// ProductCatalogService imports product files in the background and exports
+// the catalog in the same formats. Import jobs are kept in memory, so they do
+// not survive a restart.
+type ProductCatalogService struct {
+	products domain.ProductRepository
+	rules    domain.PricingRuleRepository
+
+	mu   sync.Mutex
+	jobs map[uuid.UUID]*productImportJob
+
+	// Imports run on ctx, which Stop cancels, and hold a slot while running
+	ctx     context.Context
+	stop    context.CancelFunc
+	slots   chan struct{}
+	running sync.WaitGroup
+}
+
+// productImportJob guards a job's state while it runs
+type productImportJob struct {
+	mu  sync.Mutex
+	job domain.ProductImportJob
+}
+
+// NewProductCatalogService creates a new product catalog service
+func NewProductCatalogService(products domain.ProductRepository, rules domain.PricingRuleRepository) *ProductCatalogService {
+	ctx, stop := context.WithCancel(context.Background())
+	return &ProductCatalogService{
+		products: products,
+		rules:    rules,
+		jobs:     make(map[uuid.UUID]*productImportJob),
+		ctx:      ctx,
+		stop:     stop,
+		slots:    make(chan struct{}, productImportMaxRunning),
+	}
+}
+
+// StartImport checks a product file's format and columns, then upserts its
+// rows by SKU in the background. The file is copied to a temporary file rather
+// than held in memory. Rows that fail validation are skipped and reported by
+// line; the returned job can be polled with GetImport. Returns
+// ErrProductImportBusy if productImportMaxRunning imports are running.
+func (s *ProductCatalogService) StartImport(ctx context.Context, req domain.ProductImportRequest) (*domain.ProductImportJob, error) {
+	for source, target := range req.Mapping {
+		if target != domain.ProductColumnIgnored && !isProductColumn(target) {
+			return nil, fmt.Errorf("%w: column %q is mapped to unknown column %q", domain.ErrProductImportInvalid, source, target)
+		}
+	}
+
+	select {
+	case s.slots <- struct{}{}:
+	default:
+		return nil, domain.ErrProductImportBusy
+	}
+
+	file, err := spoolProductFile(req.Data)
+	if err != nil {
+		<-s.slots
+		return nil, err
+	}
+
+	reader, err := newProductReader(req.Format, file, req.Mapping)
+	if err != nil {
+		file.Close()
+		<-s.slots
+		return nil, err
+	}
+
+	job := &productImportJob{
+		job: domain.ProductImportJob{
+			ID:        uuid.New(),
+			UserID:    req.UserID,
+			Format:    req.Format,
+			Status:    domain.ProductImportPending,
+			Result:    domain.ProductImportResult{Errors: []domain.ProductRowError{}},
+			CreatedAt: time.Now(),
+		},
+	}
+	s.register(job)
+
+	// The request context ends with the response, so the job runs on the
+	// service's context until Stop
+	s.running.Add(1)
+	go func() {
+		defer s.running.Done()
+		defer func() { <-s.slots }()
+		defer file.Close()
+		s.run(s.ctx, job, reader)
+	}()
+
+	snapshot := job.snapshot()
+	return &snapshot, nil
+}
+
+// Stop cancels running imports, which finish as failed, and waits for them
+func (s *ProductCatalogService) Stop() {
+	s.stop()
+	s.running.Wait()
+}
+
+// GetImport returns a product import job owned by the user
+func (s *ProductCatalogService) GetImport(userID, id uuid.UUID) (*domain.ProductImportJob, error) {
+	s.mu.Lock()
+	job, exists := s.jobs[id]
+	s.mu.Unlock()
+
+	if !exists || job.job.UserID != userID {
+		return nil, fmt.Errorf("%w: %s", domain.ErrProductImportNotFound, id)
+	}
+	snapshot := job.snapshot()
+	return &snapshot, nil
+}
+
+// Export writes the user's products to w in the import format, one page at a
+// time. Default rules are written as their external key, or ID when they have none.
+func (s *ProductCatalogService) Export(ctx context.Context, userID uuid.UUID, format string, w io.Writer) error {
+	var encoder productEncoder
+	switch format {
+	case domain.ProductFormatCSV:
+		encoder = newCSVProductEncoder(w)
+	case domain.ProductFormatNDJSON:
+		encoder = newNDJSONProductEncoder(w)
+	default:
+		return fmt.Errorf("unsupported product format: %s", format)
+	}
+
+	rules, err := s.rules.List(ctx, domain.PricingRuleFilter{UserID: userID})
+	if err != nil {
+		return err
+	}
+	keys := make(map[uuid.UUID]string, len(rules))
+	for _, rule := range rules {
+		keys[rule.ID] = ruleKey(rule)
+	}
+
+	filter := domain.ProductFilter{UserID: userID, Limit: productExportPageSize}
+	for {
+		products, err := s.products.List(ctx, filter)
+		if err != nil {
+			return err
+		}
+
+		for _, product := range products {
+			defaultRule := ""
+			if product.DefaultRuleID != nil {
+				defaultRule = keys[*product.DefaultRuleID]
+			}
+			if err := encoder.encode(product, defaultRule); err != nil {
+				return err
+			}
+		}
+		if err := encoder.flush(); err != nil {
+			return err
+		}
+
+		if len(products) < productExportPageSize {
+			return nil
+		}
+		last := products[len(products)-1]
+		filter.After = &domain.Cursor{CreatedAt: last.CreatedAt, ID: last.ID}
+	}
+}
+
+// register stores a job and drops finished jobs past their retention
+func (s *ProductCatalogService) register(job *productImportJob) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cutoff := time.Now().Add(-productImportRetention)
+	for id, existing := range s.jobs {
+		snapshot := existing.snapshot()
+		if snapshot.CompletedAt != nil && snapshot.CompletedAt.Before(cutoff) {
+			delete(s.jobs, id)
+		}
+	}
+
+	s.jobs[job.job.ID] = job
+}
+
+// run parses every row, then upserts valid rows in batches, publishing progress after each
+func (s *ProductCatalogService) run(ctx context.Context, job *productImportJob, reader productReader) {
+	started := time.Now()
+	job.update(func(j *domain.ProductImportJob) {
+		j.Status = domain.ProductImportRunning
+		j.StartedAt = &started
+	})
+
+	runErr := s.importRows(ctx, job, reader)
+
+	completed := time.Now()
+	job.update(func(j *domain.ProductImportJob) {
+		j.CompletedAt = &completed
+		if runErr != nil {
+			j.Status = domain.ProductImportFailed
+			j.Error = runErr.Error()
+			return
+		}
+		j.Status = domain.ProductImportCompleted
+	})
+}
+
+func (s *ProductCatalogService) importRows(ctx context.Context, job *productImportJob, reader productReader) error {
+	userID := job.job.UserID
+
+	// Rows are parsed up front so the total is known and duplicate SKUs are caught
+	var rows []*productRow
+	firstLine := make(map[string]int)
+	for {
+		record, err := reader.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read file: %w", err)
+		}
+
+		row, rowErrs := parseProductRow(record)
+		if row != nil {
+			if line, dup := firstLine[row.sku]; dup {
+				rowErrs = append(rowErrs, domain.ProductRowError{
+					Line:    record.line,
+					Field:   domain.ProductColumnSKU,
+					Message: fmt.Sprintf("duplicate SKU; first seen on line %d", line),
+				})
+			} else {
+				firstLine[row.sku] = record.line
+			}
+		}
+		if len(rowErrs) > 0 {
+			job.update(func(j *domain.ProductImportJob) { addRowErrors(&j.Result, rowErrs) })
+			continue
+		}
+		rows = append(rows, row)
+	}
+
+	failed := job.snapshot().Result.Failed
+	job.update(func(j *domain.ProductImportJob) {
+		j.Progress.Total = len(rows) + failed
+		j.Progress.Processed = failed
+	})
+
+	rules, err := s.rules.List(ctx, domain.PricingRuleFilter{UserID: userID})
+	if err != nil {
+		return fmt.Errorf("failed to list pricing rules: %w", err)
+	}
+	ruleIDs := make(map[string]uuid.UUID, 2*len(rules))
+	for _, rule := range rules {
+		ruleIDs[rule.ID.String()] = rule.ID
+		if rule.ExternalKey != "" {
+			ruleIDs[rule.ExternalKey] = rule.ID
+		}
+	}
+
+	for start := 0; start < len(rows); start += productImportBatchSize {
+		end := start + productImportBatchSize
+		if end > len(rows) {
+			end = len(rows)
+		}
+		if err := s.importBatch(ctx, job, rows[start:end], ruleIDs); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// importBatch merges a batch of rows into the existing products and upserts them together
+func (s *ProductCatalogService) importBatch(ctx context.Context, job *productImportJob, rows []*productRow, ruleIDs map[string]uuid.UUID) error {
+	userID := job.job.UserID
+
+	skus := make([]string, len(rows))
+	for i, row := range rows {
+		skus[i] = row.sku
+	}
+	existing, err := s.products.GetBySKUs(ctx, userID, skus)
+	if err != nil {
+		return fmt.Errorf("failed to look up products: %w", err)
+	}
+	bySKU := make(map[string]*domain.Product, len(existing))
+	for _, product := range existing {
+		bySKU[product.SKU] = product
+	}
+
+	var products []*domain.Product
+	var rowErrs []domain.ProductRowError
+	created, updated := 0, 0
+	for _, row := range rows {
+		product, isNew := bySKU[row.sku], false
+		if product == nil {
+			isNew = true
+			product = &domain.Product{UserID: userID, SKU: row.sku, IsActive: true}
+		}
+
+		if errs := row.apply(product, isNew, ruleIDs); len(errs) > 0 {
+			rowErrs = append(rowErrs, errs...)
+			continue
+		}
+
+		products = append(products, product)
+		if isNew {
+			created++
+		} else {
+			updated++
+		}
+	}
+
+	if len(products) > 0 {
+		if err := s.products.UpsertBatch(ctx, products); err != nil {
+			return fmt.Errorf("failed to save products: %w", err)
+		}
+	}
+
+	job.update(func(j *domain.ProductImportJob) {
+		j.Result.Created += created
+		j.Result.Updated += updated
+		addRowErrors(&j.Result, rowErrs)
+		j.Progress.Processed += len(rows)
+	})
+
+	return nil
+}
+
+// addRowErrors records the errors of failed rows, keeping the first productImportMaxErrors
+func addRowErrors(result *domain.ProductImportResult, errs []domain.ProductRowError) {
+	line := 0
+	for _, rowErr := range errs {
+		if rowErr.Line != line {
+			result.Failed++
+			line = rowErr.Line
+		}
+		if len(result.Errors) >= productImportMaxErrors {
+			result.ErrorsTruncated = true
+			continue
+		}
+		result.Errors = append(result.Errors, rowErr)
+	}
+}
+
+// snapshot returns a copy of the job
+func (j *productImportJob) snapshot() domain.ProductImportJob {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	snapshot := j.job
+	snapshot.Result.Errors = append([]domain.ProductRowError(nil), j.job.Result.Errors...)
+	return snapshot
+}
+
+// update applies fn to the job
+func (j *productImportJob) update(fn func(*domain.ProductImportJob)) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	fn(&j.job)
+}
+
+// isProductColumn reports whether name is a product column or a metadata key column
+func isProductColumn(name string) bool {
+	for _, column := range domain.ProductColumns {
+		if name == column {
+			return true
+		}
+	}
+	return strings.HasPrefix(name, domain.ProductMetadataPrefix) && len(name) > len(domain.ProductMetadataPrefix)
+}
+
+// mapProductColumn returns the product column a source column is read into;
+// empty when the column is ignored
+func mapProductColumn(name string, mapping map[string]string) (string, error) {
+	if target, ok := mapping[name]; ok {
+		name = target
+	}
+	if name == domain.ProductColumnIgnored {
+		return "", nil
+	}
+	if !isProductColumn(name) {
+		return "", fmt.Errorf("unknown column %q", name)
+	}
+	return name, nil
+}
+
+// productRecord is one row of a product file, keyed by product column
+type productRecord struct {
+	line   int
+	fields map[string]interface{} // strings for CSV, decoded JSON values for NDJSON
+	errs   []domain.ProductRowError
+}
+
+// productReader reads the rows of a product file, returning io.EOF at the end
+type productReader interface {
+	next() (*productRecord, error)
+}
+
+// spooledFile is an uploaded product file copied to disk; closing it removes it
+type spooledFile struct {
+	*os.File
+}
+
+// spoolProductFile copies an upload to a temporary file, rejecting files over
+// productMaxFileSize, and returns it positioned at the start
+func spoolProductFile(data io.Reader) (*spooledFile, error) {
+	temp, err := os.CreateTemp("", "product-import-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to store product file: %w", err)
+	}
+	file := &spooledFile{File: temp}
+
+	n, err := io.Copy(file, io.LimitReader(data, productMaxFileSize+1))
+	if err == nil && n > productMaxFileSize {
+		err = fmt.Errorf("%w: file exceeds the %d MB limit", domain.ErrProductImportInvalid, productMaxFileSize>>20)
+	}
+	if err == nil {
+		_, err = file.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		file.Close()
+		if errors.Is(err, domain.ErrProductImportInvalid) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to store product file: %w", err)
+	}
+	return file, nil
+}
+
+// Close closes and removes the temporary file
+func (f *spooledFile) Close() error {
+	err := f.File.Close()
+	if removeErr := os.Remove(f.Name()); err == nil {
+		err = removeErr
+	}
+	return err
+}
+
+// newProductReader reads a product file, rejecting CSV headers with unknown columns
+func newProductReader(format string, data io.Reader, mapping map[string]string) (productReader, error) {
+	switch format {
+	case domain.ProductFormatCSV:
+		return newCSVProductReader(data, mapping)
+	case domain.ProductFormatNDJSON:
+		scanner := bufio.NewScanner(data)
+		scanner.Buffer(make([]byte, 0, 64*1024), productMaxLineSize)
+		return &ndjsonProductReader{scanner: scanner, mapping: mapping}, nil
+	default:
+		return nil, fmt.Errorf("%w: format must be one of: csv, ndjson", domain.ErrProductImportInvalid)
+	}
+}
+
+// csvProductReader reads CSV files with a header row
+type csvProductReader struct {
+	reader  *csv.Reader
+	columns []string // product column of each source column; empty when ignored
+}
+
+func newCSVProductReader(data io.Reader, mapping map[string]string) (*csvProductReader, error) {
+	buffered := bufio.NewReader(data)
+	if prefix, _ := buffered.Peek(len(utf8BOM)); bytes.Equal(prefix, utf8BOM) {
+		_, _ = buffered.Discard(len(utf8BOM))
+	}
+	reader := csv.NewReader(buffered)
+	reader.FieldsPerRecord = -1 // checked per row so the error has a line number
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, fmt.Errorf("%w: file is empty", domain.ErrProductImportInvalid)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrProductImportInvalid, err)
+	}
+
+	columns := make([]string, len(header))
+	sources := make(map[string]string, len(header))
+	for i, name := range header {
+		name = strings.TrimSpace(name)
+		column, err := mapProductColumn(name, mapping)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v; map it to a product column or to %q to ignore it", domain.ErrProductImportInvalid, err, domain.ProductColumnIgnored)
+		}
+		if column == "" {
+			continue
+		}
+		if other, dup := sources[column]; dup {
+			return nil, fmt.Errorf("%w: columns %q and %q both map to %s", domain.ErrProductImportInvalid, other, name, column)
+		}
+		sources[column] = name
+		columns[i] = column
+	}
+	if _, ok := sources[domain.ProductColumnSKU]; !ok {
+		return nil, fmt.Errorf("%w: a %s column is required", domain.ErrProductImportInvalid, domain.ProductColumnSKU)
+	}
+
+	return &csvProductReader{reader: reader, columns: columns}, nil
+}
+
+func (r *csvProductReader) next() (*productRecord, error) {
+	values, err := r.reader.Read()
+	if err == io.EOF {
+		return nil, io.EOF
+	}
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return &productRecord{
+			line: parseErr.StartLine,
+			errs: []domain.ProductRowError{{Line: parseErr.StartLine, Message: parseErr.Err.Error()}},
+		}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	line, _ := r.reader.FieldPos(0)
+	record := &productRecord{line: line, fields: make(map[string]interface{}, len(values))}
+	if len(values) != len(r.columns) {
+		record.errs = append(record.errs, domain.ProductRowError{
+			Line:    line,
+			Message: fmt.Sprintf("has %d fields, expected %d", len(values), len(r.columns)),
+		})
+		return record, nil
+	}
+	for i, value := range values {
+		if r.columns[i] != "" {
+			record.fields[r.columns[i]] = value
+		}
+	}
+	return record, nil
+}
+
+// ndjsonProductReader reads one JSON object per line; blank lines are skipped
+type ndjsonProductReader struct {
+	scanner *bufio.Scanner
+	mapping map[string]string
+	line    int
+}
+
+func (r *ndjsonProductReader) next() (*productRecord, error) {
+	for r.scanner.Scan() {
+		r.line++
+		text := bytes.TrimSpace(r.scanner.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+
+		record := &productRecord{line: r.line, fields: map[string]interface{}{}}
+		var object map[string]interface{}
+		if err := json.Unmarshal(text, &object); err != nil || object == nil {
+			record.errs = append(record.errs, domain.ProductRowError{Line: r.line, Message: "must be a JSON object"})
+			return record, nil
+		}
+		keys := make([]string, 0, len(object))
+		for key := range object {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			column, err := mapProductColumn(key, r.mapping)
+			if err != nil {
+				record.errs = append(record.errs, domain.ProductRowError{Line: r.line, Field: key, Message: err.Error()})
+				continue
+			}
+			if column == "" {
+				continue
+			}
+			if _, dup := record.fields[column]; dup {
+				record.errs = append(record.errs, domain.ProductRowError{Line: r.line, Field: key, Message: "maps to " + column + ", which is already set"})
+				continue
+			}
+			record.fields[column] = object[key]
+		}
+		return record, nil
+	}
+	if err := r.scanner.Err(); err != nil {
+		return nil, fmt.Errorf("line %d: %w", r.line+1, err)
+	}
+	return nil, io.EOF
+}
+
+// productRow is a validated row; nil fields leave the product's value unchanged
+type productRow struct {
+	line        int
+	sku         string
+	name        *string
+	description *string
+	baseCost    *float64
+	defaultRule *string // empty clears the default rule
+	isActive    *bool
+	metadata    map[string]interface{} // replaces the metadata when metadataSet
+	metadataSet bool
+	// metadataKeys sets single keys; nil values remove the key
+	metadataKeys map[string]interface{}
+}
+
+// parseProductRow validates a record's values. Empty cells leave name,
+// base_cost and is_active unchanged, and clear the other columns.
+func parseProductRow(record *productRecord) (*productRow, []domain.ProductRowError) {
+	errs := record.errs
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	row := &productRow{line: record.line}
+	invalid := func(field, message string) {
+		errs = append(errs, domain.ProductRowError{Line: record.line, Field: field, Message: message})
+	}
+
+	for _, column := range fieldOrder(record.fields) {
+		value := record.fields[column]
+		switch column {
+		case domain.ProductColumnSKU:
+			sku, ok := stringValue(value)
+			sku = strings.TrimSpace(sku)
+			switch {
+			case !ok:
+				invalid(column, "must be a string")
+			case sku == "":
+				invalid(column, "is required")
+			case len(sku) > productSKUMaxLength:
+				invalid(column, fmt.Sprintf("must be at most %d characters", productSKUMaxLength))
+			default:
+				row.sku = sku
+			}
+		case domain.ProductColumnName:
+			name, ok := stringValue(value)
+			switch {
+			case !ok:
+				invalid(column, "must be a string")
+			case len(name) > productNameMaxLength:
+				invalid(column, fmt.Sprintf("must be at most %d characters", productNameMaxLength))
+			case name != "":
+				row.name = &name
+			}
+		case domain.ProductColumnDescription:
+			description, ok := stringValue(value)
+			if !ok {
+				invalid(column, "must be a string")
+				continue
+			}
+			row.description = &description
+		case domain.ProductColumnBaseCost:
+			cost, set, err := floatValue(value)
+			switch {
+			case err != nil:
+				invalid(column, err.Error())
+			case !set:
+			case cost < 0:
+				invalid(column, "must not be negative")
+			case cost >= productMaxBaseCost:
+				invalid(column, fmt.Sprintf("must be less than %.0f", productMaxBaseCost))
+			default:
+				row.baseCost = &cost
+			}
+		case domain.ProductColumnDefaultRule:
+			key, ok := stringValue(value)
+			if !ok {
+				invalid(column, "must be a string")
+				continue
+			}
+			key = strings.TrimSpace(key)
+			row.defaultRule = &key
+		case domain.ProductColumnIsActive:
+			active, set, err := boolValue(value)
+			if err != nil {
+				invalid(column, err.Error())
+				continue
+			}
+			if set {
+				row.isActive = &active
+			}
+		case domain.ProductColumnMetadata:
+			metadata, err := metadataValue(value)
+			if err != nil {
+				invalid(column, err.Error())
+				continue
+			}
+			row.metadata = metadata
+			row.metadataSet = true
+		default:
+			key := strings.TrimPrefix(column, domain.ProductMetadataPrefix)
+			if row.metadataKeys == nil {
+				row.metadataKeys = map[string]interface{}{}
+			}
+			if s, ok := value.(string); ok && s == "" {
+				value = nil
+			}
+			row.metadataKeys[key] = value
+		}
+	}
+
+	if _, ok := record.fields[domain.ProductColumnSKU]; !ok {
+		invalid(domain.ProductColumnSKU, "is required")
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return row, nil
+}
+
+// fieldOrder returns a record's columns in export order, then metadata keys by
+// name, so row errors are reported in a stable order
+func fieldOrder(fields map[string]interface{}) []string {
+	columns := make([]string, 0, len(fields))
+	for _, column := range domain.ProductColumns {
+		if _, ok := fields[column]; ok {
+			columns = append(columns, column)
+		}
+	}
+	var keys []string
+	for column := range fields {
+		if strings.HasPrefix(column, domain.ProductMetadataPrefix) {
+			keys = append(keys, column)
+		}
+	}
+	sort.Strings(keys)
+	return append(columns, keys...)
+}
+
+// apply writes the row's values over product, resolving the default rule by key or ID
+func (r *productRow) apply(product *domain.Product, isNew bool, ruleIDs map[string]uuid.UUID) []domain.ProductRowError {
+	if isNew && r.name == nil {
+		return []domain.ProductRowError{{Line: r.line, Field: domain.ProductColumnName, Message: "is required for new products"}}
+	}
+
+	if r.defaultRule != nil {
+		if *r.defaultRule == "" {
+			product.DefaultRuleID = nil
+		} else {
+			ruleID, ok := ruleIDs[*r.defaultRule]
+			if !ok {
+				return []domain.ProductRowError{{Line: r.line, Field: domain.ProductColumnDefaultRule, Message: fmt.Sprintf("unknown rule %q", *r.defaultRule)}}
+			}
+			product.DefaultRuleID = &ruleID
+		}
+	}
+
+	if r.name != nil {
+		product.Name = *r.name
+	}
+	if r.description != nil {
+		product.Description = *r.description
+	}
+	if r.baseCost != nil {
+		product.BaseCost = *r.baseCost
+	}
+	if r.isActive != nil {
+		product.IsActive = *r.isActive
+	}
+
+	metadata := make(map[string]interface{}, len(product.Metadata)+len(r.metadataKeys))
+	if r.metadataSet {
+		for key, value := range r.metadata {
+			metadata[key] = value
+		}
+	} else {
+		for key, value := range product.Metadata {
+			metadata[key] = value
+		}
+	}
+	for key, value := range r.metadataKeys {
+		if value == nil {
+			delete(metadata, key)
+			continue
+		}
+		metadata[key] = value
+	}
+	product.Metadata = metadata
+
+	return nil
+}
+
+// stringValue accepts strings and JSON null, which is treated as empty
+func stringValue(value interface{}) (string, bool) {
+	if value == nil {
+		return "", true
+	}
+	s, ok := value.(string)
+	return s, ok
+}
+
+// floatValue accepts numbers and numeric strings; empty values are not set
+func floatValue(value interface{}) (float64, bool, error) {
+	switch v := value.(type) {
+	case nil:
+		return 0, false, nil
+	case float64:
+		return v, true, nil
+	case string:
+		v = strings.TrimSpace(v)
+		if v == "" {
+			return 0, false, nil
+		}
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return 0, false, errors.New("must be a number")
+		}
+		return f, true, nil
+	default:
+		return 0, false, errors.New("must be a number")
+	}
+}
+
+// boolValue accepts booleans and strconv.ParseBool strings; empty values are not set
+func boolValue(value interface{}) (bool, bool, error) {
+	switch v := value.(type) {
+	case nil:
+		return false, false, nil
+	case bool:
+		return v, true, nil
+	case string:
+		v = strings.TrimSpace(v)
+		if v == "" {
+			return false, false, nil
+		}
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return false, false, errors.New("must be true or false")
+		}
+		return b, true, nil
+	default:
+		return false, false, errors.New("must be true or false")
+	}
+}
+
+// metadataValue accepts a JSON object, or a string holding one; empty values clear the metadata
+func metadataValue(value interface{}) (map[string]interface{}, error) {
+	switch v := value.(type) {
+	case nil:
+		return map[string]interface{}{}, nil
+	case map[string]interface{}:
+		return v, nil
+	case string:
+		if strings.TrimSpace(v) == "" {
+			return map[string]interface{}{}, nil
+		}
+		var metadata map[string]interface{}
+		if err := json.Unmarshal([]byte(v), &metadata); err != nil || metadata == nil {
+			return nil, errors.New("must be a JSON object")
+		}
+		return metadata, nil
+	default:
+		return nil, errors.New("must be a JSON object")
+	}
+}
+
+// productEncoder writes products in an export format
+type productEncoder interface {
+	encode(product *domain.Product, defaultRule string) error
+	flush() error
+}
+
+// csvProductEncoder writes a header row, then one row per product with metadata as JSON
+type csvProductEncoder struct {
+	writer      *csv.Writer
+	wroteHeader bool
+}
+
+func newCSVProductEncoder(w io.Writer) *csvProductEncoder {
+	return &csvProductEncoder{writer: csv.NewWriter(w)}
+}
+
+func (e *csvProductEncoder) encode(product *domain.Product, defaultRule string) error {
+	if !e.wroteHeader {
+		if err := e.writer.Write(domain.ProductColumns); err != nil {
+			return err
+		}
+		e.wroteHeader = true
+	}
+
+	metadata, err := json.Marshal(productMetadata(product))
+	if err != nil {
+		return fmt.Errorf("failed to encode metadata of %s: %w", product.SKU, err)
+	}
+	return e.writer.Write([]string{
+		product.SKU,
+		product.Name,
+		product.Description,
+		strconv.FormatFloat(product.BaseCost, 'f', -1, 64),
+		defaultRule,
+		strconv.FormatBool(product.IsActive),
+		string(metadata),
+	})
+}
+
+func (e *csvProductEncoder) flush() error {
+	// An empty catalog still gets a header
+	if !e.wroteHeader {
+		if err := e.writer.Write(domain.ProductColumns); err != nil {
+			return err
+		}
+		e.wroteHeader = true
+	}
+	e.writer.Flush()
+	return e.writer.Error()
+}
+
+// productFileLine is an NDJSON product line, with keys in column order
+type productFileLine struct {
+	SKU         string                 `json:"sku"`
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	BaseCost    float64                `json:"base_cost"`
+	DefaultRule string                 `json:"default_rule"`
+	IsActive    bool                   `json:"is_active"`
+	Metadata    map[string]interface{} `json:"metadata"`
+}
+
+// ndjsonProductEncoder writes one JSON object per product
+type ndjsonProductEncoder struct {
+	buffer  *bufio.Writer
+	encoder *json.Encoder
+}
+
+func newNDJSONProductEncoder(w io.Writer) *ndjsonProductEncoder {
+	buffer := bufio.NewWriter(w)
+	return &ndjsonProductEncoder{buffer: buffer, encoder: json.NewEncoder(buffer)}
+}
+
+func (e *ndjsonProductEncoder) encode(product *domain.Product, defaultRule string) error {
+	return e.encoder.Encode(productFileLine{
+		SKU:         product.SKU,
+		Name:        product.Name,
+		Description: product.Description,
+		BaseCost:    product.BaseCost,
+		DefaultRule: defaultRule,
+		IsActive:    product.IsActive,
+		Metadata:    productMetadata(product),
+	})
+}
+
+func (e *ndjsonProductEncoder) flush() error {
+	return e.buffer.Flush()
+}
+
+// productMetadata returns a product's metadata, empty rather than nil
+func productMetadata(product *domain.Product) map[string]interface{} {
+	if product.Metadata == nil {
+		return map[string]interface{}{}
+	}
+	return product.Metadata
+}
@@ -0,0 +1,373 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/saintparish4/harmonia/internal/domain"
+)
+
+// fakeCatalogRepo stores products in memory, keyed by SKU
+type fakeCatalogRepo struct {
+	domain.ProductRepository
+	products []*domain.Product
+	batches  int
+}
+
+func (r *fakeCatalogRepo) GetBySKUs(ctx context.Context, userID uuid.UUID, skus []string) ([]*domain.Product, error) {
+	var out []*domain.Product
+	for _, product := range r.products {
+		for _, sku := range skus {
+			if product.UserID == userID && product.SKU == sku {
+				copied := *product
+				out = append(out, &copied)
+			}
+		}
+	}
+	return out, nil
+}
+
+func (r *fakeCatalogRepo) UpsertBatch(ctx context.Context, products []*domain.Product) error {
+	r.batches++
+	for _, product := range products {
+		copied := *product
+		if existing := r.find(product.SKU); existing != nil {
+			*existing = copied
+			continue
+		}
+		copied.ID = uuid.New()
+		r.products = append(r.products, &copied)
+	}
+	return nil
+}
+
+// List serves products in the order stored, continuing after filter.After
+func (r *fakeCatalogRepo) List(ctx context.Context, filter domain.ProductFilter) ([]*domain.Product, error) {
+	products := r.products
+	if filter.After != nil {
+		for i, product := range products {
+			if product.ID == filter.After.ID {
+				products = products[i+1:]
+				break
+			}
+		}
+	}
+	if filter.Limit > 0 && filter.Limit < len(products) {
+		products = products[:filter.Limit]
+	}
+	return products, nil
+}
+
+func (r *fakeCatalogRepo) find(sku string) *domain.Product {
+	for _, product := range r.products {
+		if product.SKU == sku {
+			return product
+		}
+	}
+	return nil
+}
+
+// waitForImport polls a job until it finishes
+func waitForImport(t *testing.T, s *ProductCatalogService, userID, id uuid.UUID) *domain.ProductImportJob {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		job, err := s.GetImport(userID, id)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if job.Status.Done() {
+			return job
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("import %s did not finish", id)
+	return nil
+}
+
+func TestProductCatalogService_Import(t *testing.T) {
+	userID := uuid.New()
+	rule := &domain.PricingRule{ID: uuid.New(), UserID: userID, Name: "Standard", ExternalKey: "std"}
+	other := &domain.PricingRule{ID: uuid.New(), UserID: userID, Name: "Other"}
+
+	file := "\xef\xbb\xbfItem Code,Title,Cost,default_rule,metadata.color,Notes\n" +
+		"W-1,Widget,10.50,std,red,ignored\n" +
+		"W-2,,12,,blue,\n" + // existing product keeps its name
+		"W-3,Gizmo,-1,,,\n" +
+		"W-4,Gadget,abc,missing,,\n" +
+		"W-1,Widget again,11,,,\n" +
+		"W-5,\"Multi\nline\",5," + other.ID.String() + ",,\n" +
+		"W-6,Short\n" +
+		"W-7,,3,,,\n" // new product without a name
+
+	repo := &fakeCatalogRepo{products: []*domain.Product{
+		{ID: uuid.New(), UserID: userID, SKU: "W-2", Name: "Existing", BaseCost: 1, DefaultRuleID: &rule.ID, IsActive: true,
+			Metadata: map[string]interface{}{"color": "green", "size": "L"}},
+	}}
+	rules := &fakeRuleListRepo{rules: []*domain.PricingRule{rule, other}}
+	catalog := NewProductCatalogService(repo, rules)
+
+	job, err := catalog.StartImport(context.Background(), domain.ProductImportRequest{
+		UserID:  userID,
+		Format:  domain.ProductFormatCSV,
+		Mapping: map[string]string{"Item Code": "sku", "Title": "name", "Cost": "base_cost", "Notes": "-"},
+		Data:    strings.NewReader(file),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	job = waitForImport(t, catalog, userID, job.ID)
+	if job.Status != domain.ProductImportCompleted {
+		t.Fatalf("expected completed, got %s (%s)", job.Status, job.Error)
+	}
+
+	result := job.Result
+	if result.Created != 2 || result.Updated != 1 || result.Failed != 5 {
+		t.Errorf("expected 2 created, 1 updated, 5 failed, got %+v", result)
+	}
+	if job.Progress.Processed != 8 || job.Progress.Total != 8 {
+		t.Errorf("expected 8 of 8 rows processed, got %+v", job.Progress)
+	}
+
+	var got []string
+	for _, rowErr := range result.Errors {
+		got = append(got, fmt.Sprintf("%d:%s", rowErr.Line, rowErr.Field))
+	}
+	want := []string{"4:base_cost", "5:base_cost", "6:sku", "9:", "10:name"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("expected errors %v, got %v (%+v)", want, got, result.Errors)
+	}
+
+	widget := repo.find("W-1")
+	if widget == nil || widget.Name != "Widget" || widget.BaseCost != 10.5 || widget.DefaultRuleID == nil || *widget.DefaultRuleID != rule.ID || widget.Metadata["color"] != "red" || !widget.IsActive {
+		t.Errorf("unexpected W-1: %+v", widget)
+	}
+	existing := repo.find("W-2")
+	if existing.Name != "Existing" || existing.BaseCost != 12 || existing.DefaultRuleID != nil {
+		t.Errorf("expected W-2 updated with its name kept and rule cleared, got %+v", existing)
+	}
+	if existing.Metadata["color"] != "blue" || existing.Metadata["size"] != "L" {
+		t.Errorf("expected W-2 metadata merged, got %v", existing.Metadata)
+	}
+	multi := repo.find("W-5")
+	if multi == nil || multi.Name != "Multi\nline" || *multi.DefaultRuleID != other.ID {
+		t.Errorf("unexpected W-5: %+v", multi)
+	}
+	if repo.find("W-3") != nil || repo.find("W-4") != nil || repo.find("W-7") != nil {
+		t.Errorf("expected invalid rows to be skipped")
+	}
+
+	// Other users cannot see the job
+	if _, err := catalog.GetImport(uuid.New(), job.ID); !errors.Is(err, domain.ErrProductImportNotFound) {
+		t.Errorf("expected ErrProductImportNotFound, got %v", err)
+	}
+}
+
+func TestProductCatalogService_ImportNDJSON(t *testing.T) {
+	userID := uuid.New()
+	repo := &fakeCatalogRepo{}
+	catalog := NewProductCatalogService(repo, &fakeRuleListRepo{})
+
+	file := `{"code": "N-1", "name": "One", "base_cost": 4.25, "metadata": {"tier": "gold"}, "is_active": false}
+
+{"code": "N-2", "name": "Two", "base_cost": "7"}
+not json
+{"code": "N-3", "name": "Three", "colour": "red"}
+{"code": 4, "name": "Four"}
+`
+	job, err := catalog.StartImport(context.Background(), domain.ProductImportRequest{
+		UserID:  userID,
+		Format:  domain.ProductFormatNDJSON,
+		Mapping: map[string]string{"code": "sku"},
+		Data:    strings.NewReader(file),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	job = waitForImport(t, catalog, userID, job.ID)
+	if job.Result.Created != 2 || job.Result.Failed != 3 {
+		t.Errorf("expected 2 created and 3 failed, got %+v", job.Result)
+	}
+
+	var got []string
+	for _, rowErr := range job.Result.Errors {
+		got = append(got, fmt.Sprintf("%d:%s", rowErr.Line, rowErr.Field))
+	}
+	want := []string{"4:", "5:colour", "6:sku"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("expected errors %v, got %v", want, got)
+	}
+
+	one := repo.find("N-1")
+	if one == nil || one.BaseCost != 4.25 || one.IsActive || one.Metadata["tier"] != "gold" {
+		t.Errorf("unexpected N-1: %+v", one)
+	}
+}
+
+func TestProductCatalogService_ImportRejected(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  string
+		mapping map[string]string
+		data    string
+	}{
+		{name: "unsupported format", format: "xlsx", data: "sku\n"},
+		{name: "empty file", format: domain.ProductFormatCSV, data: ""},
+		{name: "missing sku column", format: domain.ProductFormatCSV, data: "name,base_cost\n"},
+		{name: "unknown column", format: domain.ProductFormatCSV, data: "sku,colour\n"},
+		{name: "two columns for one field", format: domain.ProductFormatCSV, mapping: map[string]string{"code": "sku"}, data: "sku,code\n"},
+		{name: "mapped to unknown column", format: domain.ProductFormatNDJSON, mapping: map[string]string{"code": "identifier"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			catalog := NewProductCatalogService(&fakeCatalogRepo{}, &fakeRuleListRepo{})
+			_, err := catalog.StartImport(context.Background(), domain.ProductImportRequest{
+				UserID:  uuid.New(),
+				Format:  tt.format,
+				Mapping: tt.mapping,
+				Data:    strings.NewReader(tt.data),
+			})
+			if !errors.Is(err, domain.ErrProductImportInvalid) {
+				t.Errorf("expected ErrProductImportInvalid, got %v", err)
+			}
+		})
+	}
+}
+
+func TestProductCatalogService_ExportRoundTrip(t *testing.T) {
+	userID := uuid.New()
+	keyed := &domain.PricingRule{ID: uuid.New(), UserID: userID, ExternalKey: "std"}
+	unkeyed := &domain.PricingRule{ID: uuid.New(), UserID: userID}
+
+	var products []*domain.Product
+	for i := 0; i < productExportPageSize+3; i++ {
+		product := &domain.Product{
+			ID:          uuid.New(),
+			UserID:      userID,
+			SKU:         fmt.Sprintf("SKU-%04d", i),
+			Name:        fmt.Sprintf("Product, \"%d\"", i),
+			BaseCost:    float64(i) + 0.25,
+			IsActive:    i%2 == 0,
+			Metadata:    map[string]interface{}{"rank": float64(i)},
+			Description: "line one\nline two",
+		}
+		switch i % 3 {
+		case 1:
+			product.DefaultRuleID = &keyed.ID
+		case 2:
+			product.DefaultRuleID = &unkeyed.ID
+		}
+		products = append(products, product)
+	}
+
+	for _, format := range []string{domain.ProductFormatCSV, domain.ProductFormatNDJSON} {
+		t.Run(format, func(t *testing.T) {
+			repo := &fakeCatalogRepo{products: products}
+			catalog := NewProductCatalogService(repo, &fakeRuleListRepo{rules: []*domain.PricingRule{keyed, unkeyed}})
+
+			var file bytes.Buffer
+			if err := catalog.Export(context.Background(), userID, format, &file); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if format == domain.ProductFormatCSV && !strings.HasPrefix(file.String(), strings.Join(domain.ProductColumns, ",")+"\n") {
+				t.Errorf("expected a header row, got %q", file.String()[:80])
+			}
+
+			// Importing the export into an empty catalog recreates it
+			restored := &fakeCatalogRepo{}
+			catalog = NewProductCatalogService(restored, &fakeRuleListRepo{rules: []*domain.PricingRule{keyed, unkeyed}})
+			job, err := catalog.StartImport(context.Background(), domain.ProductImportRequest{UserID: userID, Format: format, Data: &file})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			job = waitForImport(t, catalog, userID, job.ID)
+			if job.Result.Created != len(products) || job.Result.Failed != 0 {
+				t.Fatalf("expected %d created, got %+v", len(products), job.Result)
+			}
+			if restored.batches != 2 {
+				t.Errorf("expected 2 batches, got %d", restored.batches)
+			}
+
+			for _, want := range products {
+				got := restored.find(want.SKU)
+				if got == nil {
+					t.Fatalf("missing %s", want.SKU)
+				}
+				if got.Name != want.Name || got.Description != want.Description || got.BaseCost != want.BaseCost ||
+					got.IsActive != want.IsActive || got.Metadata["rank"] != want.Metadata["rank"] ||
+					fmt.Sprint(got.DefaultRuleID) != fmt.Sprint(want.DefaultRuleID) {
+					t.Fatalf("expected %+v, got %+v", want, got)
+				}
+			}
+		})
+	}
+}
+
+// blockingRuleListRepo blocks listing rules until ctx ends, holding imports open
+type blockingRuleListRepo struct {
+	fakeRuleListRepo
+}
+
+func (r *blockingRuleListRepo) List(ctx context.Context, filter domain.PricingRuleFilter) ([]*domain.PricingRule, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestProductCatalogService_ImportBusy(t *testing.T) {
+	userID := uuid.New()
+	catalog := NewProductCatalogService(&fakeCatalogRepo{}, &blockingRuleListRepo{})
+	start := func() (*domain.ProductImportJob, error) {
+		return catalog.StartImport(context.Background(), domain.ProductImportRequest{
+			UserID: userID,
+			Format: domain.ProductFormatCSV,
+			Data:   strings.NewReader("sku,name\nA,Widget\n"),
+		})
+	}
+
+	var jobs []*domain.ProductImportJob
+	for i := 0; i < productImportMaxRunning; i++ {
+		job, err := start()
+		if err != nil {
+			t.Fatalf("unexpected error starting import %d: %v", i, err)
+		}
+		jobs = append(jobs, job)
+	}
+
+	if _, err := start(); !errors.Is(err, domain.ErrProductImportBusy) {
+		t.Errorf("expected ErrProductImportBusy once %d imports are running, got %v", productImportMaxRunning, err)
+	}
+
+	// Stopping the service cancels the running imports
+	catalog.Stop()
+	for _, job := range jobs {
+		got, err := catalog.GetImport(userID, job.ID)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got.Status != domain.ProductImportFailed {
+			t.Errorf("expected a stopped import to fail, got %s", got.Status)
+		}
+	}
+
+	// Rejected files give their slot back
+	catalog = NewProductCatalogService(&fakeCatalogRepo{}, &fakeRuleListRepo{})
+	for i := 0; i <= productImportMaxRunning; i++ {
+		_, err := catalog.StartImport(context.Background(), domain.ProductImportRequest{
+			UserID: userID,
+			Format: domain.ProductFormatCSV,
+			Data:   strings.NewReader("name\n"),
+		})
+		if !errors.Is(err, domain.ErrProductImportInvalid) {
+			t.Fatalf("expected ErrProductImportInvalid, got %v", err)
+		}
+	}
+}
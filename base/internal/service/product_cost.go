@@ -0,0 +1,162 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/saintparish4/harmonia/internal/domain"
+)
+
+// ProductCostService manages effective-dated product costs and reports how
+// cost changes move prices
+type ProductCostService struct {
+	engine   *PricingEngine
+	resolver *RuleResolver
+	products domain.ProductRepository
+}
+
+// NewProductCostService creates a new product cost service
+func NewProductCostService(engine *PricingEngine, resolver *RuleResolver, products domain.ProductRepository) *ProductCostService {
+	return &ProductCostService{
+		engine:   engine,
+		resolver: resolver,
+		products: products,
+	}
+}
+
+// CostAt returns the cost of a user's product in effect at the given time
+func (s *ProductCostService) CostAt(ctx context.Context, userID uuid.UUID, sku string, at time.Time) (*domain.ProductCost, error) {
+	product, err := s.products.GetBySKU(ctx, userID, sku)
+	if err != nil {
+		return nil, err
+	}
+	return s.products.CostAt(ctx, product.ID, at)
+}
+
+// Timeline returns a product's cost history as consecutive periods, earliest first
+func (s *ProductCostService) Timeline(ctx context.Context, productID uuid.UUID) ([]*domain.CostPeriod, error) {
+	costs, err := s.products.ListCosts(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	return domain.CostTimeline(costs, time.Now()), nil
+}
+
+// AddCost records a cost taking effect at cost.EffectiveFrom, defaulting to
+// now and a manual source
+func (s *ProductCostService) AddCost(ctx context.Context, cost *domain.ProductCost) error {
+	if cost.Cost < 0 {
+		return fmt.Errorf("%w: cost cannot be negative", domain.ErrInvalidCost)
+	}
+	if cost.Source == "" {
+		cost.Source = domain.CostSourceManual
+	}
+	if !isCostSource(cost.Source) {
+		return fmt.Errorf("%w: unknown source %s", domain.ErrInvalidCost, cost.Source)
+	}
+	if cost.EffectiveFrom.IsZero() {
+		cost.EffectiveFrom = time.Now()
+	}
+	return s.products.AddCost(ctx, cost)
+}
+
+// Recompute prices a product just before and after one of its cost changes,
+// with the rule in effect when the change took effect. The request context
+// and quantity fill the remaining inputs; a missing rule or failed
+// calculation is reported rather than returned as an error.
+func (s *ProductCostService) Recompute(ctx context.Context, userID, productID, costID uuid.UUID, quantity int, context map[string]interface{}) (*domain.CostRecomputeReport, error) {
+	product, err := s.products.GetByID(ctx, productID)
+	if err != nil || product.UserID != userID {
+		return nil, fmt.Errorf("product not found: %s", productID)
+	}
+
+	costs, err := s.products.ListCosts(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	index := -1
+	for i, cost := range costs {
+		if cost.ID == costID {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return nil, fmt.Errorf("%w: %s", domain.ErrCostNotFound, costID)
+	}
+
+	cost := costs[index]
+	report := &domain.CostRecomputeReport{
+		ProductID:   product.ID,
+		SKU:         product.SKU,
+		CostID:      cost.ID,
+		EffectiveAt: cost.EffectiveFrom,
+	}
+
+	resolved, err := s.resolver.Resolve(ctx, userID, nil, product.SKU, cost.EffectiveFrom)
+	if err != nil {
+		if !errors.Is(err, domain.ErrNoRuleInEffect) {
+			return nil, err
+		}
+		report.Error = err.Error()
+		return report, nil
+	}
+	report.RuleID = &resolved.Rule.ID
+	report.RuleSource = &resolved.Source
+
+	if quantity <= 0 {
+		quantity = 1
+	}
+
+	report.After, err = s.priceAtCost(product, resolved.Rule, cost, quantity, context)
+	if err != nil {
+		report.Error = err.Error()
+		return report, nil
+	}
+
+	if index > 0 {
+		report.Before, err = s.priceAtCost(product, resolved.Rule, costs[index-1], quantity, context)
+		if err != nil {
+			report.Error = err.Error()
+			return report, nil
+		}
+		change := domain.RoundChange(report.After.FinalPrice - report.Before.FinalPrice)
+		report.PriceChange = &change
+	}
+
+	return report, nil
+}
+
+// priceAtCost prices a product with a rule at the given cost, at the time the
+// cost took effect. The cost replaces any base_cost in the context.
+func (s *ProductCostService) priceAtCost(product *domain.Product, rule *domain.PricingRule, cost *domain.ProductCost, quantity int, context map[string]interface{}) (*domain.PriceAtCost, error) {
+	inputs := BuildInputs(context, cost.Cost, quantity)
+	inputs["base_cost"] = cost.Cost
+
+	response, err := s.engine.Calculate(&domain.PricingRequest{
+		Strategy:    rule.StrategyType,
+		ProductSKU:  product.SKU,
+		Inputs:      inputs,
+		RuleID:      &rule.ID,
+		RequestedAt: cost.EffectiveFrom,
+	}, rule.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	return domain.NewPriceAtCost(cost.Cost, response.FinalPrice), nil
+}
+
+// isCostSource reports whether source is an accepted cost source
+func isCostSource(source string) bool {
+	for _, s := range domain.CostSources {
+		if s == source {
+			return true
+		}
+	}
+	return false
+}
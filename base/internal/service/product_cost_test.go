@@ -0,0 +1,390 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/saintparish4/harmonia/internal/domain"
+)
+
+// fakeCostRepo adds products by ID and their cost history to fakeProductRepo
+type fakeCostRepo struct {
+	*fakeProductRepo
+	costs []*domain.ProductCost
+}
+
+func (r *fakeCostRepo) GetByID(ctx context.Context, id uuid.UUID) (*domain.Product, error) {
+	for _, product := range r.products {
+		if product.ID == id {
+			return product, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", domain.ErrProductNotFound, id)
+}
+
+func (r *fakeCostRepo) ListCosts(ctx context.Context, productID uuid.UUID) ([]*domain.ProductCost, error) {
+	var out []*domain.ProductCost
+	for _, cost := range r.costs {
+		if cost.ProductID == productID {
+			out = append(out, cost)
+		}
+	}
+	return out, nil
+}
+
+func (r *fakeCostRepo) AddCost(ctx context.Context, cost *domain.ProductCost) error {
+	for _, existing := range r.costs {
+		if existing.ProductID == cost.ProductID && existing.EffectiveFrom.Equal(cost.EffectiveFrom) {
+			return domain.ErrCostExists
+		}
+	}
+	cost.ID = uuid.New()
+	r.costs = append(r.costs, cost)
+	return nil
+}
+
+func newCost(productID uuid.UUID, cost float64, day int) *domain.ProductCost {
+	return &domain.ProductCost{
+		ID:            uuid.New(),
+		ProductID:     productID,
+		Cost:          cost,
+		EffectiveFrom: *at(day),
+		Source:        domain.CostSourceManual,
+	}
+}
+
+func TestCostTimeline(t *testing.T) {
+	productID := uuid.New()
+	costs := []*domain.ProductCost{
+		newCost(productID, 40, 1),
+		newCost(productID, 50, 10),
+		newCost(productID, 45, 20),
+	}
+
+	timeline := domain.CostTimeline(costs, *at(15))
+	if len(timeline) != 3 {
+		t.Fatalf("expected 3 periods, got %d", len(timeline))
+	}
+
+	tests := []struct {
+		name         string
+		period       *domain.CostPeriod
+		wantTo       *time.Time
+		wantStatus   string
+		wantChange   *float64
+		wantPercent  *float64
+		wantPrevious *uuid.UUID
+	}{
+		{
+			name:       "first entry has no change",
+			period:     timeline[0],
+			wantTo:     at(10),
+			wantStatus: domain.ScheduleStatusExpired,
+		},
+		{
+			name:         "current entry",
+			period:       timeline[1],
+			wantTo:       at(20),
+			wantStatus:   domain.ScheduleStatusActive,
+			wantChange:   floatPtr(10),
+			wantPercent:  floatPtr(25),
+			wantPrevious: &costs[0].ID,
+		},
+		{
+			name:         "future entry is open-ended",
+			period:       timeline[2],
+			wantStatus:   domain.ScheduleStatusUpcoming,
+			wantChange:   floatPtr(-5),
+			wantPercent:  floatPtr(-10),
+			wantPrevious: &costs[1].ID,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := tt.period
+			if !equalTimePtr(p.EffectiveTo, tt.wantTo) {
+				t.Errorf("effective_to = %v, want %v", p.EffectiveTo, tt.wantTo)
+			}
+			if p.Status != tt.wantStatus {
+				t.Errorf("status = %s, want %s", p.Status, tt.wantStatus)
+			}
+			if !equalFloatPtr(p.Change, tt.wantChange) {
+				t.Errorf("change = %v, want %v", p.Change, tt.wantChange)
+			}
+			if !equalFloatPtr(p.ChangePercent, tt.wantPercent) {
+				t.Errorf("change_percent = %v, want %v", p.ChangePercent, tt.wantPercent)
+			}
+			if (p.PreviousCostID == nil) != (tt.wantPrevious == nil) ||
+				(p.PreviousCostID != nil && *p.PreviousCostID != *tt.wantPrevious) {
+				t.Errorf("previous_cost_id = %v, want %v", p.PreviousCostID, tt.wantPrevious)
+			}
+		})
+	}
+}
+
+func TestProductCostService_AddCost(t *testing.T) {
+	productID := uuid.New()
+
+	tests := []struct {
+		name       string
+		cost       *domain.ProductCost
+		wantErr    error
+		wantSource string
+	}{
+		{
+			name:       "defaults to a manual source",
+			cost:       &domain.ProductCost{ProductID: productID, Cost: 12, EffectiveFrom: *at(1)},
+			wantSource: domain.CostSourceManual,
+		},
+		{
+			name:       "keeps a known source",
+			cost:       &domain.ProductCost{ProductID: productID, Cost: 12, EffectiveFrom: *at(2), Source: domain.CostSourceSupplier},
+			wantSource: domain.CostSourceSupplier,
+		},
+		{
+			name:    "rejects a negative cost",
+			cost:    &domain.ProductCost{ProductID: productID, Cost: -1, EffectiveFrom: *at(3)},
+			wantErr: domain.ErrInvalidCost,
+		},
+		{
+			name:    "rejects an unknown source",
+			cost:    &domain.ProductCost{ProductID: productID, Cost: 12, EffectiveFrom: *at(3), Source: "guess"},
+			wantErr: domain.ErrInvalidCost,
+		},
+		{
+			name:    "rejects a second cost at the same time",
+			cost:    &domain.ProductCost{ProductID: productID, Cost: 15, EffectiveFrom: *at(1)},
+			wantErr: domain.ErrCostExists,
+		},
+	}
+
+	repo := &fakeCostRepo{fakeProductRepo: &fakeProductRepo{}}
+	service := NewProductCostService(NewPricingEngine(), nil, repo)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := service.AddCost(context.Background(), tt.cost)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("expected %v, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.cost.Source != tt.wantSource {
+				t.Errorf("source = %s, want %s", tt.cost.Source, tt.wantSource)
+			}
+		})
+	}
+}
+
+func TestProductCostService_Recompute(t *testing.T) {
+	userID := uuid.New()
+	published := *at(1)
+
+	// 50% markup until the 15th, 100% after
+	early := &domain.PricingRule{
+		ID:           uuid.New(),
+		UserID:       userID,
+		StrategyType: domain.StrategyTypeCostPlus,
+		Config:       map[string]interface{}{"markup_type": "percentage", "markup_value": 50.0},
+		IsActive:     true,
+		PublishedAt:  &published,
+		EffectiveTo:  at(15),
+	}
+	late := &domain.PricingRule{
+		ID:            uuid.New(),
+		UserID:        userID,
+		StrategyType:  domain.StrategyTypeCostPlus,
+		Config:        map[string]interface{}{"markup_type": "percentage", "markup_value": 100.0},
+		IsActive:      true,
+		PublishedAt:   &published,
+		EffectiveFrom: at(15),
+	}
+	rules := &fakeRuleRepo{rules: map[uuid.UUID]*domain.PricingRule{early.ID: early, late.ID: late}}
+
+	product := &domain.Product{ID: uuid.New(), UserID: userID, SKU: "WIDGET-1"}
+	unpriced := &domain.Product{ID: uuid.New(), UserID: userID, SKU: "WIDGET-2"}
+
+	first := newCost(product.ID, 40, 1)
+	raise := newCost(product.ID, 50, 10)
+	later := newCost(product.ID, 60, 20)
+	orphan := newCost(unpriced.ID, 10, 5)
+
+	repo := &fakeCostRepo{
+		fakeProductRepo: &fakeProductRepo{
+			products: []*domain.Product{product, unpriced},
+			assignments: []*domain.ProductRuleAssignment{
+				{ID: uuid.New(), ProductID: product.ID, RuleID: early.ID, EffectiveTo: at(15)},
+				{ID: uuid.New(), ProductID: product.ID, RuleID: late.ID, EffectiveFrom: at(15)},
+			},
+		},
+		costs: []*domain.ProductCost{first, raise, later, orphan},
+	}
+	users := &fakeUserRepo{users: map[uuid.UUID]*domain.User{userID: {ID: userID}}}
+	resolver := NewRuleResolver(rules, repo, &fakeCategoryRepo{}, users)
+	service := NewProductCostService(NewPricingEngine(), resolver, repo)
+
+	tests := []struct {
+		name        string
+		productID   uuid.UUID
+		costID      uuid.UUID
+		wantErr     error
+		wantRule    *uuid.UUID
+		wantBefore  *float64
+		wantAfter   *float64
+		wantChange  *float64
+		wantFailure bool
+	}{
+		{
+			name:      "first cost has no previous price",
+			productID: product.ID,
+			costID:    first.ID,
+			wantRule:  &early.ID,
+			wantAfter: floatPtr(60),
+		},
+		{
+			name:       "cost raise under the early rule",
+			productID:  product.ID,
+			costID:     raise.ID,
+			wantRule:   &early.ID,
+			wantBefore: floatPtr(60),
+			wantAfter:  floatPtr(75),
+			wantChange: floatPtr(15),
+		},
+		{
+			name:       "prices both costs with the rule in effect at the change",
+			productID:  product.ID,
+			costID:     later.ID,
+			wantRule:   &late.ID,
+			wantBefore: floatPtr(100),
+			wantAfter:  floatPtr(120),
+			wantChange: floatPtr(20),
+		},
+		{
+			name:        "no rule in effect is reported",
+			productID:   unpriced.ID,
+			costID:      orphan.ID,
+			wantFailure: true,
+		},
+		{
+			name:      "cost of another product",
+			productID: product.ID,
+			costID:    orphan.ID,
+			wantErr:   domain.ErrCostNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, err := service.Recompute(context.Background(), userID, tt.productID, tt.costID, 0, nil)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("expected %v, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if tt.wantFailure {
+				if report.Error == "" || report.After != nil {
+					t.Errorf("expected a reported failure, got %+v", report)
+				}
+				return
+			}
+			if report.Error != "" {
+				t.Fatalf("unexpected report error: %s", report.Error)
+			}
+
+			if report.RuleID == nil || *report.RuleID != *tt.wantRule {
+				t.Errorf("rule = %v, want %v", report.RuleID, *tt.wantRule)
+			}
+			if got := finalPrice(report.Before); !equalFloatPtr(got, tt.wantBefore) {
+				t.Errorf("before = %v, want %v", got, tt.wantBefore)
+			}
+			if got := finalPrice(report.After); !equalFloatPtr(got, tt.wantAfter) {
+				t.Errorf("after = %v, want %v", got, tt.wantAfter)
+			}
+			if !equalFloatPtr(report.PriceChange, tt.wantChange) {
+				t.Errorf("price change = %v, want %v", report.PriceChange, tt.wantChange)
+			}
+		})
+	}
+}
+
+func TestInputsFromLog_ProductCost(t *testing.T) {
+	tests := []struct {
+		name      string
+		inputData map[string]interface{}
+		want      float64
+	}{
+		{
+			name:      "logged product cost replaces the base price",
+			inputData: map[string]interface{}{"base_price": 10.0, "product_cost": 7.5},
+			want:      7.5,
+		},
+		{
+			name: "context base_cost wins",
+			inputData: map[string]interface{}{
+				"base_price":   10.0,
+				"product_cost": 7.5,
+				"context":      map[string]interface{}{"base_cost": 9.0},
+			},
+			want: 9,
+		},
+		{
+			name:      "without a product cost",
+			inputData: map[string]interface{}{"base_price": 10.0},
+			want:      10,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inputs, ok := InputsFromLog(tt.inputData)
+			if !ok {
+				t.Fatal("expected inputs")
+			}
+			if got, _ := convertToFloat(inputs["base_cost"]); got != tt.want {
+				t.Errorf("base_cost = %v, want %v", got, tt.want)
+			}
+			if got, _ := convertToFloat(inputs["base_price"]); got != 10 {
+				t.Errorf("base_price = %v, want 10", got)
+			}
+		})
+	}
+}
+
+func finalPrice(price *domain.PriceAtCost) *float64 {
+	if price == nil {
+		return nil
+	}
+	return &price.FinalPrice
+}
+
+func floatPtr(v float64) *float64 {
+	return &v
+}
+
+func equalFloatPtr(a, b *float64) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func equalTimePtr(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
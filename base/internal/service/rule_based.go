@@ -15,6 +15,38 @@ func (s *RuleBasedStrategy) Name() string {
 	return domain.StrategyTypeRuleBased
 }
 
+// Schema describes the rule-based config and inputs
+func (s *RuleBasedStrategy) Schema() domain.StrategySchema {
+	return domain.StrategySchema{
+		Type:        domain.StrategyTypeRuleBased,
+		Name:        "Rule-Based Pricing",
+		Description: "Applies custom conditional rules to determine price",
+		ConfigSchema: rootSchema(objectSchema(withBounds(map[string]interface{}{
+			"rules": map[string]interface{}{
+				"type":        "array",
+				"description": "Rules applied in order when their condition matches the inputs",
+				"minItems":    1,
+				"items": objectSchema(map[string]interface{}{
+					"condition": stringSchema("Comparison such as \"quantity > 10\" or \"always\""),
+					"action":    enumSchema("Action applied to the running price", "apply_discount", "apply_markup", "set_multiplier", "add_fixed_amount", "set_price"),
+					"value":     numberSchema("Value used by the action", nil),
+					"priority":  map[string]interface{}{"type": "integer", "description": "Higher priority rules execute first"},
+				}, "condition", "action", "value"),
+			},
+			"currency": currencySchema(),
+		}), "rules")),
+		InputSchema: rootSchema(map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"base_price": numberSchema("Price before rules are applied", nonNegative),
+				"currency":   currencySchema(),
+			},
+			"required":             []string{"base_price"},
+			"additionalProperties": true,
+		}),
+	}
+}
+
 // PricingRule represents a single pricing rule
 type PricingRule struct {
 	Condition string                 `json:"condition"` // e.g., "quantity > 10"
@@ -25,58 +57,63 @@ type PricingRule struct {
 
 // Validate checks if the configuration is valid for rule-based pricing
 func (s *RuleBasedStrategy) Validate(config map[string]interface{}) error {
+	ve := &domain.ValidationError{}
+
 	// rules array is required in config
 	rulesData, ok := config["rules"]
 	if !ok {
-		return fmt.Errorf("%w: rules array is required", domain.ErrConfigurationInvalid)
+		ve.Add(domain.JSONPointer("rules"), "rules array is required")
+		return ve
 	}
-	
+
 	// Convert to slice
 	rules, ok := rulesData.([]interface{})
 	if !ok {
-		return fmt.Errorf("rules must be an array")
+		ve.Add(domain.JSONPointer("rules"), "must be an array")
+		return ve
 	}
-	
+
 	if len(rules) == 0 {
-		return fmt.Errorf("rules array cannot be empty")
+		ve.Add(domain.JSONPointer("rules"), "cannot be empty")
 	}
-	
+
+	validActions := map[string]bool{
+		"apply_discount":   true,
+		"apply_markup":     true,
+		"set_multiplier":   true,
+		"add_fixed_amount": true,
+		"set_price":        true,
+	}
+
 	// Validate each rule
 	for i, rule := range rules {
 		ruleMap, ok := rule.(map[string]interface{})
 		if !ok {
-			return fmt.Errorf("rules[%d] must be an object", i)
+			ve.Add(domain.JSONPointer("rules", i), "must be an object")
+			continue
 		}
-		
+
 		// Validate required fields
 		if _, ok := domain.GetString(ruleMap, "condition"); !ok {
-			return fmt.Errorf("rules[%d] missing required field: condition", i)
+			ve.Add(domain.JSONPointer("rules", i, "condition"), "is required")
 		}
-		
-		if _, ok := domain.GetString(ruleMap, "action"); !ok {
-			return fmt.Errorf("rules[%d] missing required field: action", i)
-		}
-		
+
 		if _, ok := domain.GetFloat64(ruleMap, "value"); !ok {
-			return fmt.Errorf("rules[%d] missing required field: value", i)
+			ve.Add(domain.JSONPointer("rules", i, "value"), "is required and must be a number")
 		}
-		
+
 		// Validate action type
-		action, _ := domain.GetString(ruleMap, "action")
-		validActions := map[string]bool{
-			"apply_discount":   true,
-			"apply_markup":     true,
-			"set_multiplier":   true,
-			"add_fixed_amount": true,
-			"set_price":        true,
-		}
-		
-		if !validActions[action] {
-			return fmt.Errorf("rules[%d] has invalid action: %s", i, action)
+		if action, ok := domain.GetString(ruleMap, "action"); !ok {
+			ve.Add(domain.JSONPointer("rules", i, "action"), "is required")
+		} else if !validActions[action] {
+			ve.Add(domain.JSONPointer("rules", i, "action"), "invalid action: %s", action)
 		}
 	}
-	
-	return nil
+
+	validateOptionalString(ve, config, "currency")
+	validateBounds(ve, config)
+
+	return ve.Err()
 }
 
 // Calculate computes the rule-based price
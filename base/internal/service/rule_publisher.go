@@ -0,0 +1,77 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/saintparish4/harmonia/internal/domain"
+)
+
+// RulePublisher publishes rule drafts under the account's approval policy
+type RulePublisher struct {
+	rules domain.PricingRuleRepository
+	users domain.UserRepository
+	keys  domain.APIKeyRepository
+}
+
+// NewRulePublisher creates a publisher over the rule, user and API key repositories
+func NewRulePublisher(rules domain.PricingRuleRepository, users domain.UserRepository, keys domain.APIKeyRepository) *RulePublisher {
+	return &RulePublisher{
+		rules: rules,
+		users: users,
+		keys:  keys,
+	}
+}
+
+// Publish approves the pending draft of a rule and makes it the published
+// version. If draftID is given it must still be the pending draft, so an
+// approver only ever publishes the content they reviewed.
+func (p *RulePublisher) Publish(ctx context.Context, ruleID uuid.UUID, draftID *uuid.UUID, approval domain.DraftApproval) (*domain.PricingRule, error) {
+	draft, err := p.rules.GetDraft(ctx, ruleID)
+	if err != nil {
+		return nil, err
+	}
+	if draftID != nil && *draftID != draft.ID {
+		return nil, fmt.Errorf("%w: draft %s was replaced by %s", domain.ErrDraftNotFound, *draftID, draft.ID)
+	}
+
+	account, err := p.users.GetByID(ctx, approval.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Approval rights come from the stored key, never from the caller
+	approval.CanApprove = false
+	if account.RequireRuleApproval && approval.KeyID != nil {
+		key, err := p.keys.GetByID(ctx, *approval.KeyID)
+		if err != nil {
+			return nil, err
+		}
+		approval.CanApprove = key.CanApprove && key.IsActive && key.UserID == approval.UserID
+	}
+
+	if err := draft.CheckApproval(approval, account.RequireRuleApproval); err != nil {
+		return nil, err
+	}
+
+	return p.rules.PublishDraft(ctx, draft.ID, approval)
+}
+
+// CheckDelete refuses to delete a published rule while its account requires
+// rule approval; a draft with is_active=false takes it offline instead, so the
+// change is approved like any other. Unpublished rules can always be deleted.
+func (p *RulePublisher) CheckDelete(ctx context.Context, rule *domain.PricingRule) error {
+	if rule.PublishedAt == nil {
+		return nil
+	}
+
+	account, err := p.users.GetByID(ctx, rule.UserID)
+	if err != nil {
+		return err
+	}
+	if account.RequireRuleApproval {
+		return fmt.Errorf("%w: rule %s", domain.ErrDeleteNeedsApproval, rule.ID)
+	}
+	return nil
+}
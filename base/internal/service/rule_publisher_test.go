@@ -0,0 +1,181 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/saintparish4/harmonia/internal/domain"
+)
+
+// fakeDraftRepo holds one pending draft and records what gets published
+type fakeDraftRepo struct {
+	domain.PricingRuleRepository
+	draft     *domain.RuleDraft
+	published []uuid.UUID
+}
+
+func (r *fakeDraftRepo) GetDraft(ctx context.Context, ruleID uuid.UUID) (*domain.RuleDraft, error) {
+	if r.draft == nil || r.draft.RuleID != ruleID {
+		return nil, fmt.Errorf("%w for rule %s", domain.ErrDraftNotFound, ruleID)
+	}
+	return r.draft, nil
+}
+
+func (r *fakeDraftRepo) PublishDraft(ctx context.Context, draftID uuid.UUID, approval domain.DraftApproval) (*domain.PricingRule, error) {
+	r.published = append(r.published, draftID)
+	return &domain.PricingRule{ID: r.draft.RuleID, Name: r.draft.Name}, nil
+}
+
+// fakeUserRepo serves users from memory
+type fakeUserRepo struct {
+	domain.UserRepository
+	users map[uuid.UUID]*domain.User
+}
+
+func (r *fakeUserRepo) GetByID(ctx context.Context, id uuid.UUID) (*domain.User, error) {
+	user, ok := r.users[id]
+	if !ok {
+		return nil, fmt.Errorf("user not found: %s", id)
+	}
+	return user, nil
+}
+
+// fakeAPIKeyRepo serves API keys from memory
+type fakeAPIKeyRepo struct {
+	domain.APIKeyRepository
+	keys map[uuid.UUID]*domain.APIKey
+}
+
+func (r *fakeAPIKeyRepo) GetByID(ctx context.Context, id uuid.UUID) (*domain.APIKey, error) {
+	key, ok := r.keys[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", domain.ErrAPIKeyNotFound, id)
+	}
+	return key, nil
+}
+
+func TestRulePublisher_Publish(t *testing.T) {
+	userID := uuid.New()
+	otherUserID := uuid.New()
+	ruleID := uuid.New()
+	authorKey := uuid.New()
+	reviewerKey := uuid.New()
+	mintedKey := uuid.New()
+	foreignKey := uuid.New()
+	revokedKey := uuid.New()
+	draftID := uuid.New()
+	staleID := uuid.New()
+
+	// The author's own key holds approval rights too, so only the key check stops it
+	keys := &fakeAPIKeyRepo{keys: map[uuid.UUID]*domain.APIKey{
+		authorKey:   {ID: authorKey, UserID: userID, IsActive: true, CanApprove: true},
+		reviewerKey: {ID: reviewerKey, UserID: userID, IsActive: true, CanApprove: true},
+		mintedKey:   {ID: mintedKey, UserID: userID, IsActive: true},
+		foreignKey:  {ID: foreignKey, UserID: otherUserID, IsActive: true, CanApprove: true},
+		revokedKey:  {ID: revokedKey, UserID: userID, CanApprove: true},
+	}}
+
+	tests := []struct {
+		name            string
+		requireApproval bool
+		draftID         *uuid.UUID
+		keyID           *uuid.UUID
+		claimApprover   bool
+		wantErr         error
+	}{
+		{name: "no policy, author publishes", keyID: &authorKey},
+		{name: "no policy, no key", keyID: nil},
+		{name: "no policy, ordinary key publishes", keyID: &mintedKey},
+		{name: "policy, reviewer publishes", requireApproval: true, keyID: &reviewerKey},
+		{name: "policy, author cannot self-approve", requireApproval: true, keyID: &authorKey, wantErr: domain.ErrSelfApprovalForbidden},
+		{name: "policy, newly minted key of the author cannot approve", requireApproval: true, keyID: &mintedKey, wantErr: domain.ErrSelfApprovalForbidden},
+		{name: "policy, approval rights cannot be claimed", requireApproval: true, keyID: &mintedKey, claimApprover: true, wantErr: domain.ErrSelfApprovalForbidden},
+		{name: "policy, another account's approver key", requireApproval: true, keyID: &foreignKey, wantErr: domain.ErrSelfApprovalForbidden},
+		{name: "policy, revoked approver key", requireApproval: true, keyID: &revokedKey, wantErr: domain.ErrSelfApprovalForbidden},
+		{name: "policy, approver without key", requireApproval: true, keyID: nil, wantErr: domain.ErrSelfApprovalForbidden},
+		{name: "reviewed draft still pending", draftID: &draftID, keyID: &reviewerKey},
+		{name: "reviewed draft was replaced", draftID: &staleID, keyID: &reviewerKey, wantErr: domain.ErrDraftNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules := &fakeDraftRepo{draft: &domain.RuleDraft{
+				ID:          draftID,
+				RuleID:      ruleID,
+				Name:        "Standard",
+				AuthorID:    &userID,
+				AuthorKeyID: &authorKey,
+				Status:      domain.DraftStatusPending,
+			}}
+			users := &fakeUserRepo{users: map[uuid.UUID]*domain.User{
+				userID: {ID: userID, RequireRuleApproval: tt.requireApproval},
+			}}
+
+			publisher := NewRulePublisher(rules, users, keys)
+			approval := domain.DraftApproval{UserID: userID, KeyID: tt.keyID, CanApprove: tt.claimApprover}
+			rule, err := publisher.Publish(context.Background(), ruleID, tt.draftID, approval)
+
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("expected %v, got %v", tt.wantErr, err)
+				}
+				if len(rules.published) != 0 {
+					t.Errorf("expected nothing published, got %v", rules.published)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if rule.ID != ruleID {
+				t.Errorf("expected rule %s, got %s", ruleID, rule.ID)
+			}
+			if len(rules.published) != 1 || rules.published[0] != draftID {
+				t.Errorf("expected draft %s published, got %v", draftID, rules.published)
+			}
+		})
+	}
+
+	// A rule without a pending draft has nothing to publish
+	publisher := NewRulePublisher(&fakeDraftRepo{}, &fakeUserRepo{}, &fakeAPIKeyRepo{})
+	if _, err := publisher.Publish(context.Background(), ruleID, nil, domain.DraftApproval{UserID: userID}); !errors.Is(err, domain.ErrDraftNotFound) {
+		t.Errorf("expected ErrDraftNotFound, got %v", err)
+	}
+}
+
+func TestRulePublisher_CheckDelete(t *testing.T) {
+	userID := uuid.New()
+	published := time.Now()
+
+	tests := []struct {
+		name            string
+		requireApproval bool
+		publishedAt     *time.Time
+		wantErr         error
+	}{
+		{name: "published rule without approval policy", publishedAt: &published},
+		{name: "unpublished rule under approval policy", requireApproval: true},
+		{name: "published rule under approval policy", requireApproval: true, publishedAt: &published, wantErr: domain.ErrDeleteNeedsApproval},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users := &fakeUserRepo{users: map[uuid.UUID]*domain.User{
+				userID: {ID: userID, RequireRuleApproval: tt.requireApproval},
+			}}
+			publisher := NewRulePublisher(&fakeDraftRepo{}, users, &fakeAPIKeyRepo{})
+
+			err := publisher.CheckDelete(context.Background(), &domain.PricingRule{ID: uuid.New(), UserID: userID, PublishedAt: tt.publishedAt})
+			if tt.wantErr == nil && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("expected %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/saintparish4/harmonia/internal/domain"
+)
+
+// RuleResolver finds the saved pricing rule in effect for a calculation
+type RuleResolver struct {
+	rules      domain.PricingRuleRepository
+	products   domain.ProductRepository
+	categories domain.CategoryRepository
+	users      domain.UserRepository
+}
+
+// NewRuleResolver creates a resolver over the rule, product, category and user repositories
+func NewRuleResolver(rules domain.PricingRuleRepository, products domain.ProductRepository, categories domain.CategoryRepository, users domain.UserRepository) *RuleResolver {
+	return &RuleResolver{
+		rules:      rules,
+		products:   products,
+		categories: categories,
+		users:      users,
+	}
+}
+
+// ruleCandidate is a rule configured at one level of the resolution chain
+type ruleCandidate struct {
+	ruleID uuid.UUID
+	source domain.RuleSource
+}
+
+// Resolve returns the published rule to price with at the given time and
+// where it came from. An explicit ruleID must be in effect; otherwise the
+// product's scheduled assignment applies, then its default rule, then the
+// default rule of its category and each ancestor, then the account default.
+// Every level is read when resolving, so moving a product or category takes
+// effect on the next calculation. Returns nil when no rule or SKU is given.
+func (r *RuleResolver) Resolve(ctx context.Context, userID uuid.UUID, ruleID *uuid.UUID, sku string, at time.Time) (*domain.ResolvedRule, error) {
+	if ruleID != nil {
+		rule, err := r.ownedRule(ctx, userID, *ruleID)
+		if err != nil {
+			return nil, err
+		}
+		if rule.PublishedAt == nil {
+			return nil, fmt.Errorf("%w: %s", domain.ErrRuleNotPublished, rule.ID)
+		}
+		if !rule.IsActive {
+			return nil, fmt.Errorf("pricing rule is inactive: %s", rule.ID)
+		}
+		if status := rule.Window().Status(at); status != domain.ScheduleStatusActive {
+			return nil, fmt.Errorf("%w: %s is %s at %s", domain.ErrRuleNotInEffect, rule.ID, status, at.UTC().Format(time.RFC3339))
+		}
+		return &domain.ResolvedRule{
+			Rule:   rule,
+			Source: domain.RuleSource{Level: domain.RuleSourceRequest, ID: rule.ID, Name: rule.Name},
+		}, nil
+	}
+
+	if sku == "" {
+		return nil, nil
+	}
+
+	product, err := r.products.GetBySKU(ctx, userID, sku)
+	if err != nil {
+		return nil, fmt.Errorf("product not found: %s", sku)
+	}
+
+	assignments, err := r.products.ListRuleAssignments(ctx, product.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Scheduled assignments take precedence over the product's default rule
+	var candidates []ruleCandidate
+	for _, assignment := range assignments {
+		if assignment.Window().Contains(at) {
+			candidates = append(candidates, ruleCandidate{
+				ruleID: assignment.RuleID,
+				source: domain.RuleSource{Level: domain.RuleSourceSchedule, ID: assignment.ID},
+			})
+			break
+		}
+	}
+	if product.DefaultRuleID != nil {
+		candidates = append(candidates, ruleCandidate{
+			ruleID: *product.DefaultRuleID,
+			source: domain.RuleSource{Level: domain.RuleSourceProduct, ID: product.ID, Name: product.SKU},
+		})
+	}
+	if resolved := r.firstInEffect(ctx, userID, candidates, at); resolved != nil {
+		return resolved, nil
+	}
+
+	// Then the category tree, nearest category first
+	if product.CategoryID != nil {
+		chain, err := r.categories.Ancestors(ctx, *product.CategoryID)
+		if err != nil {
+			return nil, err
+		}
+
+		candidates = candidates[:0]
+		for _, category := range chain {
+			if category.DefaultRuleID != nil {
+				candidates = append(candidates, ruleCandidate{
+					ruleID: *category.DefaultRuleID,
+					source: domain.RuleSource{Level: domain.RuleSourceCategory, ID: category.ID, Name: category.Name},
+				})
+			}
+		}
+		if resolved := r.firstInEffect(ctx, userID, candidates, at); resolved != nil {
+			return resolved, nil
+		}
+	}
+
+	// Finally the account default
+	user, err := r.users.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.DefaultRuleID != nil {
+		candidates = []ruleCandidate{{
+			ruleID: *user.DefaultRuleID,
+			source: domain.RuleSource{Level: domain.RuleSourceAccount, ID: user.ID},
+		}}
+		if resolved := r.firstInEffect(ctx, userID, candidates, at); resolved != nil {
+			return resolved, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w for product %s at %s", domain.ErrNoRuleInEffect, sku, at.UTC().Format(time.RFC3339))
+}
+
+// firstInEffect returns the first candidate whose rule is published, active
+// and in effect at the given time, skipping rules that cannot be loaded
+func (r *RuleResolver) firstInEffect(ctx context.Context, userID uuid.UUID, candidates []ruleCandidate, at time.Time) *domain.ResolvedRule {
+	for _, candidate := range candidates {
+		rule, err := r.ownedRule(ctx, userID, candidate.ruleID)
+		if err != nil {
+			continue
+		}
+		if rule.PublishedAt != nil && rule.IsActive && rule.Window().Contains(at) {
+			return &domain.ResolvedRule{Rule: rule, Source: candidate.source}
+		}
+	}
+	return nil
+}
+
+// ownedRule loads a rule, hiding rules that belong to other users
+func (r *RuleResolver) ownedRule(ctx context.Context, userID, id uuid.UUID) (*domain.PricingRule, error) {
+	rule, err := r.rules.GetByID(ctx, id)
+	if err != nil || rule.UserID != userID {
+		return nil, fmt.Errorf("pricing rule not found: %s", id)
+	}
+	return rule, nil
+}
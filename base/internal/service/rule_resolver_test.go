@@ -0,0 +1,365 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/saintparish4/harmonia/internal/domain"
+)
+
+// fakeRuleRepo serves pricing rules from memory
+type fakeRuleRepo struct {
+	domain.PricingRuleRepository
+	rules map[uuid.UUID]*domain.PricingRule
+}
+
+func (r *fakeRuleRepo) GetByID(ctx context.Context, id uuid.UUID) (*domain.PricingRule, error) {
+	rule, ok := r.rules[id]
+	if !ok {
+		return nil, fmt.Errorf("pricing rule not found: %s", id)
+	}
+	return rule, nil
+}
+
+// fakeProductRepo serves products and their rule assignments from memory
+type fakeProductRepo struct {
+	domain.ProductRepository
+	products    []*domain.Product
+	assignments []*domain.ProductRuleAssignment
+}
+
+func (r *fakeProductRepo) GetBySKU(ctx context.Context, userID uuid.UUID, sku string) (*domain.Product, error) {
+	for _, product := range r.products {
+		if product.UserID == userID && product.SKU == sku {
+			return product, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", domain.ErrProductNotFound, sku)
+}
+
+func (r *fakeProductRepo) ListRuleAssignments(ctx context.Context, productID uuid.UUID) ([]*domain.ProductRuleAssignment, error) {
+	var out []*domain.ProductRuleAssignment
+	for _, assignment := range r.assignments {
+		if assignment.ProductID == productID {
+			out = append(out, assignment)
+		}
+	}
+	return out, nil
+}
+
+// fakeCategoryRepo serves the category tree from memory
+type fakeCategoryRepo struct {
+	domain.CategoryRepository
+	categories map[uuid.UUID]*domain.Category
+}
+
+func (r *fakeCategoryRepo) Ancestors(ctx context.Context, id uuid.UUID) ([]*domain.Category, error) {
+	var chain []*domain.Category
+	for category := r.categories[id]; category != nil; {
+		chain = append(chain, category)
+		if category.ParentID == nil {
+			break
+		}
+		category = r.categories[*category.ParentID]
+	}
+	if len(chain) == 0 {
+		return nil, fmt.Errorf("category not found: %s", id)
+	}
+	return chain, nil
+}
+
+func at(day int) *time.Time {
+	t := time.Date(2026, 3, day, 0, 0, 0, 0, time.UTC)
+	return &t
+}
+
+func TestEffectiveWindow(t *testing.T) {
+	window := domain.EffectiveWindow{From: at(10), To: at(20)}
+
+	tests := []struct {
+		name     string
+		at       time.Time
+		expected string
+	}{
+		{"before start", *at(9), domain.ScheduleStatusUpcoming},
+		{"at start", *at(10), domain.ScheduleStatusActive},
+		{"inside", *at(15), domain.ScheduleStatusActive},
+		{"at end", *at(20), domain.ScheduleStatusExpired},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if status := window.Status(tt.at); status != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, status)
+			}
+		})
+	}
+
+	overlaps := []struct {
+		name     string
+		other    domain.EffectiveWindow
+		expected bool
+	}{
+		{"adjacent before", domain.EffectiveWindow{To: at(10)}, false},
+		{"adjacent after", domain.EffectiveWindow{From: at(20)}, false},
+		{"overlapping start", domain.EffectiveWindow{From: at(5), To: at(11)}, true},
+		{"contained", domain.EffectiveWindow{From: at(12), To: at(13)}, true},
+		{"unbounded", domain.EffectiveWindow{}, true},
+	}
+
+	for _, tt := range overlaps {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := window.Overlaps(tt.other); got != tt.expected {
+				t.Errorf("expected overlap %v, got %v", tt.expected, got)
+			}
+		})
+	}
+
+	if err := (domain.EffectiveWindow{From: at(10), To: at(10)}).Validate(); !errors.Is(err, domain.ErrInvalidSchedule) {
+		t.Errorf("expected ErrInvalidSchedule for an empty window, got %v", err)
+	}
+}
+
+func TestRuleResolver_Resolve(t *testing.T) {
+	userID := uuid.New()
+
+	published := *at(1)
+
+	newRule := func(from, to *time.Time) *domain.PricingRule {
+		return &domain.PricingRule{
+			ID:            uuid.New(),
+			UserID:        userID,
+			StrategyType:  domain.StrategyTypeCostPlus,
+			IsActive:      true,
+			PublishedAt:   &published,
+			EffectiveFrom: from,
+			EffectiveTo:   to,
+		}
+	}
+
+	standard := newRule(nil, nil)
+	sale := newRule(nil, nil)
+	increase := newRule(at(20), nil)
+	inactive := newRule(nil, nil)
+	inactive.IsActive = false
+	foreign := newRule(nil, nil)
+	foreign.UserID = uuid.New()
+	unpublished := newRule(nil, nil)
+	unpublished.PublishedAt = nil
+
+	rules := &fakeRuleRepo{rules: map[uuid.UUID]*domain.PricingRule{}}
+	for _, rule := range []*domain.PricingRule{standard, sale, increase, inactive, foreign, unpublished} {
+		rules.rules[rule.ID] = rule
+	}
+
+	product := &domain.Product{ID: uuid.New(), UserID: userID, SKU: "WIDGET-001", DefaultRuleID: &standard.ID}
+	products := &fakeProductRepo{
+		products: []*domain.Product{product},
+		assignments: []*domain.ProductRuleAssignment{
+			{ID: uuid.New(), ProductID: product.ID, RuleID: sale.ID, EffectiveFrom: at(10), EffectiveTo: at(15)},
+			{ID: uuid.New(), ProductID: product.ID, RuleID: increase.ID, EffectiveFrom: at(15)},
+		},
+	}
+
+	categories := &fakeCategoryRepo{categories: map[uuid.UUID]*domain.Category{}}
+	users := &fakeUserRepo{users: map[uuid.UUID]*domain.User{userID: {ID: userID}}}
+
+	resolver := NewRuleResolver(rules, products, categories, users)
+	ctx := context.Background()
+
+	tests := []struct {
+		name       string
+		ruleID     *uuid.UUID
+		sku        string
+		at         time.Time
+		want       *domain.PricingRule
+		wantSource string
+		wantErr    error
+	}{
+		{name: "explicit rule", ruleID: &standard.ID, at: *at(1), want: standard, wantSource: domain.RuleSourceRequest},
+		{name: "explicit rule before its window", ruleID: &increase.ID, at: *at(19), wantErr: domain.ErrRuleNotInEffect},
+		{name: "explicit rule inside its window", ruleID: &increase.ID, at: *at(25), want: increase, wantSource: domain.RuleSourceRequest},
+		{name: "inactive rule", ruleID: &inactive.ID, at: *at(1), wantErr: errAny},
+		{name: "other user's rule", ruleID: &foreign.ID, at: *at(1), wantErr: errAny},
+		{name: "unpublished rule", ruleID: &unpublished.ID, at: *at(1), wantErr: domain.ErrRuleNotPublished},
+		{name: "product default before schedules", sku: "WIDGET-001", at: *at(5), want: standard, wantSource: domain.RuleSourceProduct},
+		{name: "product sale window", sku: "WIDGET-001", at: *at(12), want: sale, wantSource: domain.RuleSourceSchedule},
+		{name: "scheduled rule not yet in effect falls back", sku: "WIDGET-001", at: *at(17), want: standard, wantSource: domain.RuleSourceProduct},
+		{name: "product increase", sku: "WIDGET-001", at: *at(25), want: increase, wantSource: domain.RuleSourceSchedule},
+		{name: "unknown product", sku: "MISSING", at: *at(1), wantErr: errAny},
+		{name: "no rule requested", at: *at(1)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resolved, err := resolver.Resolve(ctx, userID, tt.ruleID, tt.sku, tt.at)
+			if tt.wantErr != nil {
+				if err == nil {
+					t.Fatalf("expected error, got rule %v", resolved)
+				}
+				if tt.wantErr != errAny && !errors.Is(err, tt.wantErr) {
+					t.Errorf("expected %v, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.want == nil {
+				if resolved != nil {
+					t.Errorf("expected no rule, got %v", resolved)
+				}
+				return
+			}
+			if resolved == nil || resolved.Rule != tt.want {
+				t.Fatalf("expected rule %v, got %v", tt.want, resolved)
+			}
+			if resolved.Source.Level != tt.wantSource {
+				t.Errorf("expected source %s, got %s", tt.wantSource, resolved.Source.Level)
+			}
+		})
+	}
+
+	// No assignment or default rule in effect
+	product.DefaultRuleID = nil
+	if _, err := resolver.Resolve(ctx, userID, nil, "WIDGET-001", *at(5)); !errors.Is(err, domain.ErrNoRuleInEffect) {
+		t.Errorf("expected ErrNoRuleInEffect, got %v", err)
+	}
+}
+
+func TestRuleResolver_CategoryInheritance(t *testing.T) {
+	userID := uuid.New()
+	published := *at(1)
+
+	newRule := func(from, to *time.Time) *domain.PricingRule {
+		return &domain.PricingRule{
+			ID:            uuid.New(),
+			UserID:        userID,
+			StrategyType:  domain.StrategyTypeCostPlus,
+			IsActive:      true,
+			PublishedAt:   &published,
+			EffectiveFrom: from,
+			EffectiveTo:   to,
+		}
+	}
+
+	rootRule := newRule(nil, nil)
+	seasonal := newRule(at(10), at(20))
+	accountRule := newRule(nil, nil)
+
+	rules := &fakeRuleRepo{rules: map[uuid.UUID]*domain.PricingRule{}}
+	for _, rule := range []*domain.PricingRule{rootRule, seasonal, accountRule} {
+		rules.rules[rule.ID] = rule
+	}
+
+	// apparel (rootRule) > shoes (seasonal) > running (no rule); outlet has no rule
+	apparel := &domain.Category{ID: uuid.New(), UserID: userID, Name: "Apparel", DefaultRuleID: &rootRule.ID}
+	shoes := &domain.Category{ID: uuid.New(), UserID: userID, Name: "Shoes", ParentID: &apparel.ID, DefaultRuleID: &seasonal.ID}
+	running := &domain.Category{ID: uuid.New(), UserID: userID, Name: "Running", ParentID: &shoes.ID}
+	outlet := &domain.Category{ID: uuid.New(), UserID: userID, Name: "Outlet"}
+
+	categories := &fakeCategoryRepo{categories: map[uuid.UUID]*domain.Category{}}
+	for _, category := range []*domain.Category{apparel, shoes, running, outlet} {
+		categories.categories[category.ID] = category
+	}
+
+	product := &domain.Product{ID: uuid.New(), UserID: userID, SKU: "SHOE-001", CategoryID: &running.ID}
+	products := &fakeProductRepo{products: []*domain.Product{product}}
+
+	user := &domain.User{ID: userID, DefaultRuleID: &accountRule.ID}
+	users := &fakeUserRepo{users: map[uuid.UUID]*domain.User{userID: user}}
+
+	resolver := NewRuleResolver(rules, products, categories, users)
+	ctx := context.Background()
+
+	tests := []struct {
+		name       string
+		setup      func()
+		at         time.Time
+		want       *domain.PricingRule
+		wantSource string
+		wantFrom   uuid.UUID
+	}{
+		{
+			name:       "nearest category with a rule in effect",
+			at:         *at(15),
+			want:       seasonal,
+			wantSource: domain.RuleSourceCategory,
+			wantFrom:   shoes.ID,
+		},
+		{
+			name:       "ancestor when the nearer rule is out of its window",
+			at:         *at(25),
+			want:       rootRule,
+			wantSource: domain.RuleSourceCategory,
+			wantFrom:   apparel.ID,
+		},
+		{
+			name:       "moving the product changes its rule",
+			setup:      func() { product.CategoryID = &outlet.ID },
+			at:         *at(15),
+			want:       accountRule,
+			wantSource: domain.RuleSourceAccount,
+			wantFrom:   userID,
+		},
+		{
+			name:       "moving a category changes its products' rule",
+			setup:      func() { outlet.ParentID = &apparel.ID },
+			at:         *at(15),
+			want:       rootRule,
+			wantSource: domain.RuleSourceCategory,
+			wantFrom:   apparel.ID,
+		},
+		{
+			name:       "product default wins over its category",
+			setup:      func() { product.DefaultRuleID = &accountRule.ID },
+			at:         *at(15),
+			want:       accountRule,
+			wantSource: domain.RuleSourceProduct,
+			wantFrom:   product.ID,
+		},
+		{
+			name: "uncategorized product falls back to the account",
+			setup: func() {
+				product.DefaultRuleID = nil
+				product.CategoryID = nil
+			},
+			at:         *at(15),
+			want:       accountRule,
+			wantSource: domain.RuleSourceAccount,
+			wantFrom:   userID,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.setup != nil {
+				tt.setup()
+			}
+
+			resolved, err := resolver.Resolve(ctx, userID, nil, "SHOE-001", tt.at)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if resolved.Rule != tt.want {
+				t.Errorf("expected rule %v, got %v", tt.want.ID, resolved.Rule.ID)
+			}
+			if resolved.Source.Level != tt.wantSource || resolved.Source.ID != tt.wantFrom {
+				t.Errorf("expected source %s %s, got %s %s", tt.wantSource, tt.wantFrom, resolved.Source.Level, resolved.Source.ID)
+			}
+		})
+	}
+
+	// Nothing in effect anywhere in the chain
+	user.DefaultRuleID = nil
+	if _, err := resolver.Resolve(ctx, userID, nil, "SHOE-001", *at(15)); !errors.Is(err, domain.ErrNoRuleInEffect) {
+		t.Errorf("expected ErrNoRuleInEffect, got %v", err)
+	}
+}
+
+// errAny matches any non-nil error in table tests
+var errAny = errors.New("any error")
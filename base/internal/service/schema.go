@@ -0,0 +1,68 @@
+package service
+
+// JSON Schema helpers used by strategies to describe their config and inputs
+
+// jsonSchemaDialect is the JSON Schema draft used for strategy schemas
+const jsonSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// objectSchema builds a JSON Schema object with the given properties
+func objectSchema(properties map[string]interface{}, required ...string) map[string]interface{} {
+	schema := map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// rootSchema marks an object schema as a top-level document
+func rootSchema(schema map[string]interface{}) map[string]interface{} {
+	schema["$schema"] = jsonSchemaDialect
+	return schema
+}
+
+// numberSchema builds a number property with an optional inclusive minimum
+func numberSchema(description string, minimum *float64) map[string]interface{} {
+	schema := map[string]interface{}{
+		"type":        "number",
+		"description": description,
+	}
+	if minimum != nil {
+		schema["minimum"] = *minimum
+	}
+	return schema
+}
+
+// stringSchema builds a string property
+func stringSchema(description string) map[string]interface{} {
+	return map[string]interface{}{
+		"type":        "string",
+		"description": description,
+	}
+}
+
+// enumSchema builds a string property restricted to the given values
+func enumSchema(description string, values ...string) map[string]interface{} {
+	return map[string]interface{}{
+		"type":        "string",
+		"description": description,
+		"enum":        values,
+	}
+}
+
+// nonNegative is the shared minimum for prices and costs
+var nonNegative = func() *float64 { zero := 0.0; return &zero }()
+
+// withBounds adds the min_price/max_price config properties shared by strategies
+func withBounds(properties map[string]interface{}) map[string]interface{} {
+	properties["min_price"] = numberSchema("Lower bound applied to the final price", nonNegative)
+	properties["max_price"] = numberSchema("Upper bound applied to the final price", nonNegative)
+	return properties
+}
+
+// currencySchema is the currency property read by getCurrency
+func currencySchema() map[string]interface{} {
+	return stringSchema("ISO 4217 currency code (default: USD)")
+}
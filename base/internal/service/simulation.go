@@ -0,0 +1,140 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/saintparish4/harmonia/internal/domain"
+)
+
+// Simulate runs a strategy over every combination of the request's axis values.
+// The config is validated once; a cell whose inputs are rejected by the
+// strategy records the error instead of failing the whole simulation.
+// Results are never logged.
+func (e *PricingEngine) Simulate(req *domain.SimulationRequest) (*domain.SimulationResult, error) {
+	strategy, err := e.GetStrategy(req.Strategy)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", domain.ErrInvalidStrategy, req.Strategy)
+	}
+
+	if err := strategy.Validate(req.Config); err != nil {
+		return nil, fmt.Errorf("configuration validation failed: %w", err)
+	}
+
+	shape, err := simulationShape(req)
+	if err != nil {
+		return nil, err
+	}
+
+	requestedAt := req.RequestedAt
+	if requestedAt.IsZero() {
+		requestedAt = time.Now()
+	}
+
+	total := 1
+	for _, size := range shape {
+		total *= size
+	}
+
+	result := &domain.SimulationResult{
+		Strategy: req.Strategy,
+		RuleID:   req.RuleID,
+		Axes:     req.Axes,
+		Shape:    shape,
+		Cells:    make([]domain.SimulationCell, 0, total),
+	}
+
+	index := make([]int, len(shape))
+	for n := 0; n < total; n++ {
+		result.Cells = append(result.Cells, e.simulateCell(strategy, req, index, requestedAt))
+
+		// Advance the index, last axis fastest
+		for axis := len(index) - 1; axis >= 0; axis-- {
+			index[axis]++
+			if index[axis] < shape[axis] {
+				break
+			}
+			index[axis] = 0
+		}
+	}
+
+	return result, nil
+}
+
+// simulateCell prices a single point of the grid
+func (e *PricingEngine) simulateCell(strategy domain.PricingStrategy, req *domain.SimulationRequest, index []int, requestedAt time.Time) domain.SimulationCell {
+	inputs := make(map[string]interface{}, len(req.Inputs)+len(req.Axes))
+	for k, v := range req.Inputs {
+		inputs[k] = v
+	}
+	for axis, position := range index {
+		inputs[req.Axes[axis].Field] = req.Axes[axis].Values[position]
+	}
+
+	cell := domain.SimulationCell{
+		Index:  append([]int(nil), index...),
+		Inputs: inputs,
+	}
+
+	pricingReq := &domain.PricingRequest{
+		Strategy:    req.Strategy,
+		RuleID:      req.RuleID,
+		Inputs:      inputs,
+		RequestedAt: requestedAt,
+	}
+
+	response, err := strategy.Calculate(pricingReq, req.Config)
+	if err != nil {
+		cell.Error = err.Error()
+		return cell
+	}
+
+	cell.FinalPrice = domain.RoundToTwoDecimals(response.FinalPrice)
+	if cell.FinalPrice < 0 {
+		cell.Error = domain.ErrNegativePrice.Error()
+		return cell
+	}
+	cell.Currency = response.Currency
+	cell.Breakdown = &response.Breakdown
+
+	return cell
+}
+
+// simulationShape validates the axes and returns the number of values along each
+func simulationShape(req *domain.SimulationRequest) ([]int, error) {
+	if len(req.Axes) == 0 {
+		return nil, fmt.Errorf("%w: at least one axis is required", domain.ErrSimulationInvalid)
+	}
+
+	maxCells := req.MaxCells
+	if maxCells <= 0 {
+		maxCells = domain.DefaultMaxSimulationCells
+	}
+
+	seen := make(map[string]bool, len(req.Axes))
+	shape := make([]int, len(req.Axes))
+	cells := 1
+
+	for i, axis := range req.Axes {
+		if axis.Field == "" {
+			return nil, fmt.Errorf("%w: axes[%d] field is required", domain.ErrSimulationInvalid, i)
+		}
+		if seen[axis.Field] {
+			return nil, fmt.Errorf("%w: axis %s is defined more than once", domain.ErrSimulationInvalid, axis.Field)
+		}
+		seen[axis.Field] = true
+
+		if len(axis.Values) == 0 {
+			return nil, fmt.Errorf("%w: axis %s has no values", domain.ErrSimulationInvalid, axis.Field)
+		}
+
+		// Checked per axis so the product cannot overflow
+		if len(axis.Values) > maxCells/cells {
+			return nil, fmt.Errorf("%w: limit is %d cells", domain.ErrSimulationTooLarge, maxCells)
+		}
+		cells *= len(axis.Values)
+		shape[i] = len(axis.Values)
+	}
+
+	return shape, nil
+}
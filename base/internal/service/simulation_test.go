@@ -0,0 +1,114 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/saintparish4/harmonia/internal/domain"
+)
+
+func TestPricingEngine_Simulate(t *testing.T) {
+	engine := NewPricingEngine()
+
+	req := &domain.SimulationRequest{
+		Strategy: domain.StrategyTypeGeographic,
+		Config: map[string]interface{}{
+			"regional_multipliers": map[string]interface{}{
+				"US": 1.0,
+				"EU": 1.2,
+			},
+		},
+		Axes: []domain.SimulationAxis{
+			{Field: "location", Values: []interface{}{"US", "EU"}},
+			{Field: "base_price", Values: []interface{}{10.0, 20.0, -5.0}},
+		},
+		RequestedAt: time.Date(2026, 1, 5, 12, 0, 0, 0, time.UTC),
+	}
+
+	result, err := engine.Simulate(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(result.Shape) != 2 || result.Shape[0] != 2 || result.Shape[1] != 3 {
+		t.Fatalf("expected shape [2 3], got %v", result.Shape)
+	}
+	if len(result.Cells) != 6 {
+		t.Fatalf("expected 6 cells, got %d", len(result.Cells))
+	}
+
+	// Row-major order: last axis varies fastest
+	cell := result.Cells[4]
+	if cell.Index[0] != 1 || cell.Index[1] != 1 {
+		t.Errorf("expected cell 4 at index [1 1], got %v", cell.Index)
+	}
+	if cell.Inputs["location"] != "EU" || cell.FinalPrice != 24.0 {
+		t.Errorf("expected EU at 20.00 to price 24.00, got %v at %v", cell.Inputs, cell.FinalPrice)
+	}
+
+	// Invalid inputs fail the cell, not the simulation
+	if result.Cells[2].Error == "" {
+		t.Error("expected an error for a negative base_price cell")
+	}
+}
+
+func TestPricingEngine_Simulate_Errors(t *testing.T) {
+	engine := NewPricingEngine()
+	config := map[string]interface{}{"markup_value": 10.0}
+
+	tests := []struct {
+		name    string
+		req     *domain.SimulationRequest
+		wantErr error
+	}{
+		{
+			name:    "no axes",
+			req:     &domain.SimulationRequest{Strategy: domain.StrategyTypeCostPlus, Config: config},
+			wantErr: domain.ErrSimulationInvalid,
+		},
+		{
+			name: "duplicate axis",
+			req: &domain.SimulationRequest{
+				Strategy: domain.StrategyTypeCostPlus,
+				Config:   config,
+				Axes: []domain.SimulationAxis{
+					{Field: "base_cost", Values: []interface{}{1.0}},
+					{Field: "base_cost", Values: []interface{}{2.0}},
+				},
+			},
+			wantErr: domain.ErrSimulationInvalid,
+		},
+		{
+			name: "too many cells",
+			req: &domain.SimulationRequest{
+				Strategy: domain.StrategyTypeCostPlus,
+				Config:   config,
+				MaxCells: 5,
+				Axes: []domain.SimulationAxis{
+					{Field: "base_cost", Values: []interface{}{1.0, 2.0, 3.0}},
+					{Field: "quantity", Values: []interface{}{1.0, 2.0}},
+				},
+			},
+			wantErr: domain.ErrSimulationTooLarge,
+		},
+		{
+			name: "invalid config",
+			req: &domain.SimulationRequest{
+				Strategy: domain.StrategyTypeCostPlus,
+				Config:   map[string]interface{}{"markup_value": -1.0},
+				Axes:     []domain.SimulationAxis{{Field: "base_cost", Values: []interface{}{1.0}}},
+			},
+			wantErr: domain.ErrConfigurationInvalid,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := engine.Simulate(tt.req)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("expected error '%v', got '%v'", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -16,6 +16,53 @@ func (s *TimeBasedStrategy) Name() string {
 	return domain.StrategyTypeTimeBased
 }
 
+// Schema describes the time-based config and inputs
+func (s *TimeBasedStrategy) Schema() domain.StrategySchema {
+	timeOfDay := map[string]interface{}{
+		"type":    "string",
+		"pattern": "^([01][0-9]|2[0-3]):[0-5][0-9]$",
+	}
+
+	return domain.StrategySchema{
+		Type:        domain.StrategyTypeTimeBased,
+		Name:        "Time-Based Surge Pricing",
+		Description: "Adjusts price based on time windows and demand",
+		ConfigSchema: rootSchema(objectSchema(withBounds(map[string]interface{}{
+			"time_windows": map[string]interface{}{
+				"type":        "array",
+				"description": "Windows whose multipliers apply when the request time falls inside them",
+				"minItems":    1,
+				"items": objectSchema(map[string]interface{}{
+					"days": map[string]interface{}{
+						"type":        "array",
+						"description": "Days the window applies to (all days if omitted)",
+						"items": map[string]interface{}{
+							"type": "string",
+							"enum": []string{"monday", "tuesday", "wednesday", "thursday", "friday", "saturday", "sunday"},
+						},
+					},
+					"start_time": timeOfDay,
+					"end_time":   timeOfDay,
+					"multiplier": numberSchema("Price multiplier while the window is active", nil),
+				}, "multiplier"),
+			},
+			"surge_enabled":        map[string]interface{}{"type": "boolean", "description": "Apply demand surge pricing"},
+			"base_surge_threshold": numberSchema("Demand level above which surge applies (default: 1.0)", nonNegative),
+			"currency":             currencySchema(),
+		}), "time_windows")),
+		InputSchema: rootSchema(objectSchema(map[string]interface{}{
+			"base_price": numberSchema("Price before time multipliers", nonNegative),
+			"timestamp": map[string]interface{}{
+				"type":        "string",
+				"format":      "date-time",
+				"description": "RFC 3339 time to price at (default: request time)",
+			},
+			"current_demand": numberSchema("Current demand level used for surge pricing", nonNegative),
+			"currency":       currencySchema(),
+		}, "base_price")),
+	}
+}
+
 // TimeWindow represents a time-based pricing rule
 type TimeWindow struct {
 	Days       []string  `json:"days"`        // e.g., ["monday", "friday"]
@@ -26,49 +73,84 @@ type TimeWindow struct {
 
 // Validate checks if the configuration is valid for time-based pricing
 func (s *TimeBasedStrategy) Validate(config map[string]interface{}) error {
+	ve := &domain.ValidationError{}
+
 	// time_windows is required in config
 	timeWindowsData, ok := config["time_windows"]
 	if !ok {
-		return fmt.Errorf("%w: time_windows array is required", domain.ErrConfigurationInvalid)
+		ve.Add(domain.JSONPointer("time_windows"), "time_windows array is required")
+		return ve
 	}
-	
+
 	// Convert to slice
 	windows, ok := timeWindowsData.([]interface{})
 	if !ok {
-		return fmt.Errorf("time_windows must be an array")
+		ve.Add(domain.JSONPointer("time_windows"), "must be an array")
+		return ve
 	}
-	
+
 	if len(windows) == 0 {
-		return fmt.Errorf("time_windows array cannot be empty")
+		ve.Add(domain.JSONPointer("time_windows"), "cannot be empty")
 	}
-	
+
+	validDays := map[string]bool{
+		"monday": true, "tuesday": true, "wednesday": true, "thursday": true,
+		"friday": true, "saturday": true, "sunday": true,
+	}
+
 	// Validate each time window
 	for i, window := range windows {
 		windowMap, ok := window.(map[string]interface{})
 		if !ok {
-			return fmt.Errorf("time_windows[%d] must be an object", i)
+			ve.Add(domain.JSONPointer("time_windows", i), "must be an object")
+			continue
 		}
-		
+
 		// Validate required fields
-		if _, ok := windowMap["multiplier"]; !ok {
-			return fmt.Errorf("time_windows[%d] missing required field: multiplier", i)
+		if raw, ok := windowMap["multiplier"]; !ok {
+			ve.Add(domain.JSONPointer("time_windows", i, "multiplier"), "is required")
+		} else if mult, ok := convertToFloat(raw); !ok {
+			ve.Add(domain.JSONPointer("time_windows", i, "multiplier"), "must be a number")
+		} else if mult <= 0 {
+			ve.Add(domain.JSONPointer("time_windows", i, "multiplier"), "must be positive")
 		}
-		
-		// Validate time format if provided
-		if startTime, ok := domain.GetString(windowMap, "start_time"); ok {
-			if !isValidTimeFormat(startTime) {
-				return fmt.Errorf("time_windows[%d] has invalid start_time format (use HH:MM)", i)
+
+		// Validate days if provided
+		if daysData, ok := windowMap["days"]; ok {
+			days, ok := daysData.([]interface{})
+			if !ok {
+				ve.Add(domain.JSONPointer("time_windows", i, "days"), "must be an array")
+			}
+			for j, day := range days {
+				dayStr, _ := day.(string)
+				if !validDays[strings.ToLower(dayStr)] {
+					ve.Add(domain.JSONPointer("time_windows", i, "days", j), "must be a day of the week")
+				}
 			}
 		}
-		
-		if endTime, ok := domain.GetString(windowMap, "end_time"); ok {
-			if !isValidTimeFormat(endTime) {
-				return fmt.Errorf("time_windows[%d] has invalid end_time format (use HH:MM)", i)
+
+		// Validate time format if provided
+		for _, key := range []string{"start_time", "end_time"} {
+			raw, ok := windowMap[key]
+			if !ok {
+				continue
+			}
+			if timeStr, ok := raw.(string); !ok || !isValidTimeFormat(timeStr) {
+				ve.Add(domain.JSONPointer("time_windows", i, key), "invalid time format (use HH:MM)")
 			}
 		}
 	}
-	
-	return nil
+
+	if raw, exists := config["surge_enabled"]; exists {
+		if _, ok := raw.(bool); !ok {
+			ve.Add(domain.JSONPointer("surge_enabled"), "must be a boolean")
+		}
+	}
+	validateOptionalNumber(ve, config, "base_surge_threshold", 0)
+	validateOptionalString(ve, config, "currency")
+	validateBounds(ve, config)
+
+	return ve.Err()
 }
 
 // Calculate computes the time-based price
@@ -0,0 +1,48 @@
+package service
+
+import (
+	"github.com/saintparish4/harmonia/internal/domain"
+)
+
+// Shared config validation helpers used by strategies
+
+// validateOptionalNumber checks that an optional config key, if present, is a number >= min
+func validateOptionalNumber(ve *domain.ValidationError, config map[string]interface{}, key string, min float64) {
+	raw, exists := config[key]
+	if !exists {
+		return
+	}
+	value, ok := convertToFloat(raw)
+	if !ok {
+		ve.Add(domain.JSONPointer(key), "must be a number")
+		return
+	}
+	if value < min {
+		if min == 0 {
+			ve.Add(domain.JSONPointer(key), "cannot be negative")
+		} else {
+			ve.Add(domain.JSONPointer(key), "must be at least %g", min)
+		}
+	}
+}
+
+// validateOptionalString checks that an optional config key, if present, is a string
+func validateOptionalString(ve *domain.ValidationError, config map[string]interface{}, key string) {
+	if raw, exists := config[key]; exists {
+		if _, ok := raw.(string); !ok {
+			ve.Add(domain.JSONPointer(key), "must be a string")
+		}
+	}
+}
+
+// validateBounds checks the optional min_price/max_price config shared by strategies
+func validateBounds(ve *domain.ValidationError, config map[string]interface{}) {
+	validateOptionalNumber(ve, config, "min_price", 0)
+	validateOptionalNumber(ve, config, "max_price", 0)
+
+	minPrice, hasMin := domain.GetFloat64(config, "min_price")
+	maxPrice, hasMax := domain.GetFloat64(config, "max_price")
+	if hasMin && hasMax && minPrice > 0 && maxPrice > 0 && minPrice > maxPrice {
+		ve.Add(domain.JSONPointer("min_price"), "cannot be greater than max_price")
+	}
+}
@@ -0,0 +1,498 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/saintparish4/harmonia/internal/domain"
+)
+
+const (
+	// WebhookSecretPrefix starts every webhook signing secret
+	WebhookSecretPrefix = "hm_whsec_"
+
+	// Webhook request headers
+	WebhookSignatureHeader = "X-Harmonia-Signature"
+	WebhookEventHeader     = "X-Harmonia-Event"
+	WebhookDeliveryHeader  = "X-Harmonia-Delivery"
+
+	// webhookBatchSize bounds the events dispatched and deliveries claimed per pass
+	webhookBatchSize = 100
+
+	// webhookWorkers is how many deliveries are sent at once
+	webhookWorkers = 8
+
+	// webhookRetryBase is the delay before the first retry; it doubles per attempt
+	webhookRetryBase = 30 * time.Second
+
+	// webhookRetryMax caps the delay between attempts
+	webhookRetryMax = 6 * time.Hour
+
+	// webhookResponseLimit is how much of a response body is kept in the delivery log
+	webhookResponseLimit = 2 << 10
+)
+
+// errWebhookAddressBlocked is recorded when a delivery would connect to an
+// address webhooks may not reach
+var errWebhookAddressBlocked = errors.New("webhook address is not publicly routable")
+
+// blockedWebhookNetworks are unroutable or internal ranges that net.IP has no
+// predicate for: "this network", shared address space, IETF protocol
+// assignments, benchmarking and NAT64, which can embed any IPv4 address
+var blockedWebhookNetworks = func() []*net.IPNet {
+	cidrs := []string{"0.0.0.0/8", "100.64.0.0/10", "192.0.0.0/24", "198.18.0.0/15", "64:ff9b::/96"}
+	networks := make([]*net.IPNet, len(cidrs))
+	for i, cidr := range cidrs {
+		_, networks[i], _ = net.ParseCIDR(cidr)
+	}
+	return networks
+}()
+
+// WebhookService manages webhook endpoints and delivers the events in the
+// outbox to them, retrying failures with exponential backoff
+type WebhookService struct {
+	repo        domain.WebhookRepository
+	client      *http.Client
+	maxAttempts int
+	now         func() time.Time
+
+	// allowPrivate lets endpoints reach loopback, private and link-local
+	// addresses, for local development only
+	allowPrivate bool
+
+	// lookupIP resolves endpoint hosts when they are registered
+	lookupIP func(ctx context.Context, host string) ([]net.IPAddr, error)
+
+	// running tracks the delivery loop so shutdown can wait for sends in flight
+	running sync.WaitGroup
+}
+
+// NewWebhookService creates a webhook service whose requests time out after
+// timeout. Unless allowPrivate is set, endpoints may only resolve to and
+// connect to publicly routable addresses, so users cannot make the server
+// fetch internal services and read back the response.
+func NewWebhookService(repo domain.WebhookRepository, timeout time.Duration, maxAttempts int, allowPrivate bool) *WebhookService {
+	dialer := &net.Dialer{Timeout: timeout, KeepAlive: 30 * time.Second}
+	if !allowPrivate {
+		// Checked on the address actually dialed, so a name that re-resolves
+		// to an internal address after registration is still refused
+		dialer.Control = refuseBlockedWebhookAddress
+	}
+
+	return &WebhookService{
+		repo: repo,
+		client: &http.Client{
+			Timeout: timeout,
+			Transport: &http.Transport{
+				// No proxy: the address check must see the endpoint itself
+				DialContext:         dialer.DialContext,
+				ForceAttemptHTTP2:   true,
+				MaxIdleConns:        100,
+				IdleConnTimeout:     90 * time.Second,
+				TLSHandshakeTimeout: 10 * time.Second,
+			},
+			// Redirects are not followed; a moved endpoint should be re-registered
+			CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+		},
+		maxAttempts:  maxAttempts,
+		now:          time.Now,
+		allowPrivate: allowPrivate,
+		lookupIP:     net.DefaultResolver.LookupIPAddr,
+	}
+}
+
+// CreateEndpoint validates an endpoint and stores it with a new signing secret
+func (s *WebhookService) CreateEndpoint(ctx context.Context, endpoint *domain.WebhookEndpoint) error {
+	if err := validateWebhookEndpoint(endpoint); err != nil {
+		return err
+	}
+	if err := s.checkEndpointAddress(ctx, endpoint); err != nil {
+		return err
+	}
+
+	secret, err := generateWebhookSecret()
+	if err != nil {
+		return err
+	}
+	endpoint.Secret = secret
+
+	return s.repo.CreateEndpoint(ctx, endpoint)
+}
+
+// GetEndpoint retrieves a webhook endpoint
+func (s *WebhookService) GetEndpoint(ctx context.Context, id uuid.UUID) (*domain.WebhookEndpoint, error) {
+	return s.repo.GetEndpoint(ctx, id)
+}
+
+// ListEndpoints retrieves a user's webhook endpoints
+func (s *WebhookService) ListEndpoints(ctx context.Context, userID uuid.UUID) ([]*domain.WebhookEndpoint, error) {
+	return s.repo.ListEndpoints(ctx, userID)
+}
+
+// UpdateEndpoint validates and stores changes to an endpoint
+func (s *WebhookService) UpdateEndpoint(ctx context.Context, endpoint *domain.WebhookEndpoint) error {
+	if err := validateWebhookEndpoint(endpoint); err != nil {
+		return err
+	}
+	if err := s.checkEndpointAddress(ctx, endpoint); err != nil {
+		return err
+	}
+	return s.repo.UpdateEndpoint(ctx, endpoint)
+}
+
+// RotateSecret replaces an endpoint's signing secret
+func (s *WebhookService) RotateSecret(ctx context.Context, endpoint *domain.WebhookEndpoint) error {
+	secret, err := generateWebhookSecret()
+	if err != nil {
+		return err
+	}
+	endpoint.Secret = secret
+	return s.repo.UpdateEndpoint(ctx, endpoint)
+}
+
+// DeleteEndpoint deletes an endpoint and its delivery log
+func (s *WebhookService) DeleteEndpoint(ctx context.Context, id uuid.UUID) error {
+	return s.repo.DeleteEndpoint(ctx, id)
+}
+
+// Deliveries retrieves a page of an endpoint's deliveries and the total matching the filter
+func (s *WebhookService) Deliveries(ctx context.Context, filter domain.WebhookDeliveryFilter) ([]*domain.WebhookDelivery, int, error) {
+	deliveries, err := s.repo.ListDeliveries(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	total, err := s.repo.CountDeliveries(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	return deliveries, total, nil
+}
+
+// Delivery retrieves a delivery and its attempts
+func (s *WebhookService) Delivery(ctx context.Context, id uuid.UUID) (*domain.WebhookDelivery, []*domain.WebhookAttempt, error) {
+	delivery, err := s.repo.GetDelivery(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	attempts, err := s.repo.ListAttempts(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	return delivery, attempts, nil
+}
+
+// Replay queues the event of a delivery to be sent again to the same endpoint
+func (s *WebhookService) Replay(ctx context.Context, deliveryID uuid.UUID) (*domain.WebhookDelivery, error) {
+	return s.repo.Replay(ctx, deliveryID, s.now())
+}
+
+// Run dispatches outbox events and sends due deliveries every interval until
+// ctx is cancelled
+func (s *WebhookService) Run(ctx context.Context, interval time.Duration) {
+	s.running.Add(1)
+	defer s.running.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.Process(ctx)
+		}
+	}
+}
+
+// Wait blocks until Run has returned
+func (s *WebhookService) Wait() {
+	s.running.Wait()
+}
+
+// Process fans committed events out to their endpoints, then sends every
+// delivery that is due
+func (s *WebhookService) Process(ctx context.Context) {
+	for ctx.Err() == nil {
+		dispatched, err := s.repo.DispatchEvents(ctx, webhookBatchSize, s.now())
+		if err != nil {
+			log.Printf("webhooks: failed to dispatch events: %v", err)
+			break
+		}
+		if dispatched < webhookBatchSize {
+			break
+		}
+	}
+
+	for ctx.Err() == nil {
+		now := s.now()
+		deliveries, err := s.repo.ClaimDeliveries(ctx, webhookBatchSize, now, now.Add(s.lease()))
+		if err != nil {
+			log.Printf("webhooks: failed to claim deliveries: %v", err)
+			return
+		}
+		if len(deliveries) == 0 {
+			return
+		}
+
+		s.sendAll(ctx, deliveries)
+
+		if len(deliveries) < webhookBatchSize {
+			return
+		}
+	}
+}
+
+// sendAll sends claimed deliveries with a bounded number of workers
+func (s *WebhookService) sendAll(ctx context.Context, deliveries []*domain.WebhookDelivery) {
+	queue := make(chan *domain.WebhookDelivery)
+	var wg sync.WaitGroup
+
+	for i := 0; i < webhookWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for delivery := range queue {
+				if err := s.send(ctx, delivery); err != nil {
+					log.Printf("webhooks: failed to record delivery %s: %v", delivery.ID, err)
+				}
+			}
+		}()
+	}
+
+	for _, delivery := range deliveries {
+		queue <- delivery
+	}
+	close(queue)
+	wg.Wait()
+}
+
+// lease is how long a claimed batch may take before another worker takes it over
+func (s *WebhookService) lease() time.Duration {
+	perRequest := s.client.Timeout
+	if perRequest == 0 {
+		perRequest = 30 * time.Second
+	}
+	return perRequest*time.Duration(webhookBatchSize/webhookWorkers+1) + time.Minute
+}
+
+// send makes one attempt at a delivery and records the outcome. A 2xx
+// response succeeds; anything else is retried until maxAttempts.
+func (s *WebhookService) send(ctx context.Context, delivery *domain.WebhookDelivery) error {
+	attemptedAt := s.now()
+	delivery.Attempts++
+	attempt := &domain.WebhookAttempt{
+		DeliveryID:  delivery.ID,
+		Attempt:     delivery.Attempts,
+		AttemptedAt: attemptedAt,
+	}
+
+	status, body, err := s.post(ctx, delivery, attemptedAt)
+	if err != nil && ctx.Err() != nil {
+		// Interrupted by shutdown: the lease expires and the attempt is made again
+		return nil
+	}
+	attempt.DurationMs = int(s.now().Sub(attemptedAt).Milliseconds())
+	attempt.ResponseBody = body
+	if status > 0 {
+		attempt.ResponseStatus = &status
+	}
+
+	switch {
+	case err != nil:
+		attempt.Error = err.Error()
+	case status < 200 || status > 299:
+		attempt.Error = fmt.Sprintf("endpoint responded with status %d", status)
+	}
+
+	delivery.LastAttemptAt = &attemptedAt
+	delivery.ResponseStatus = attempt.ResponseStatus
+	delivery.LastError = attempt.Error
+	delivery.NextAttemptAt = nil
+
+	switch {
+	case attempt.Error == "":
+		delivery.Status = domain.WebhookDeliverySucceeded
+	case delivery.Attempts >= s.maxAttempts:
+		delivery.Status = domain.WebhookDeliveryFailed
+	default:
+		next := attemptedAt.Add(WebhookRetryDelay(delivery.Attempts))
+		delivery.Status = domain.WebhookDeliveryPending
+		delivery.NextAttemptAt = &next
+	}
+
+	// Record even if the run is stopping, so a finished attempt is not repeated
+	return s.repo.RecordAttempt(context.WithoutCancel(ctx), delivery, attempt)
+}
+
+// post sends a delivery's event and returns the response status and the
+// start of its body
+func (s *WebhookService) post(ctx context.Context, delivery *domain.WebhookDelivery, at time.Time) (int, string, error) {
+	body, err := json.Marshal(webhookBody{
+		ID:        delivery.Event.ID,
+		Type:      delivery.Event.Type,
+		CreatedAt: delivery.Event.CreatedAt,
+		Data:      delivery.Event.Payload,
+	})
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to encode event: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, "", err
+	}
+
+	timestamp := at.Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Harmonia-Webhooks/1.0")
+	req.Header.Set(WebhookEventHeader, delivery.Event.Type)
+	req.Header.Set(WebhookDeliveryHeader, delivery.ID.String())
+	req.Header.Set(WebhookSignatureHeader, fmt.Sprintf("t=%d,v1=%s", timestamp, SignWebhook(delivery.Secret, timestamp, body)))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+
+	excerpt, _ := io.ReadAll(io.LimitReader(resp.Body, webhookResponseLimit))
+	return resp.StatusCode, string(excerpt), nil
+}
+
+// webhookBody is the JSON sent for an event. Replays send the same event ID,
+// so receivers can discard duplicates.
+type webhookBody struct {
+	ID        uuid.UUID              `json:"id"`
+	Type      string                 `json:"type"`
+	CreatedAt time.Time              `json:"created_at"`
+	Data      map[string]interface{} `json:"data"`
+}
+
+// SignWebhook returns the hex HMAC-SHA256 of "<timestamp>.<body>" under the
+// endpoint's secret. Receivers recompute it to verify a request and reject
+// stale timestamps to prevent replays.
+func SignWebhook(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// WebhookRetryDelay returns how long to wait after the given failed attempt:
+// 30s, doubling per attempt, capped at 6 hours
+func WebhookRetryDelay(attempt int) time.Duration {
+	delay := webhookRetryBase
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if delay >= webhookRetryMax {
+			return webhookRetryMax
+		}
+	}
+	return delay
+}
+
+// validateWebhookEndpoint checks an endpoint's URL and event types
+func validateWebhookEndpoint(endpoint *domain.WebhookEndpoint) error {
+	parsed, err := url.Parse(endpoint.URL)
+	if err != nil || parsed.Host == "" || (parsed.Scheme != "https" && parsed.Scheme != "http") {
+		return fmt.Errorf("%w: url must be an absolute http or https URL", domain.ErrInvalidWebhook)
+	}
+
+	seen := make(map[string]bool, len(endpoint.EventTypes))
+	for _, eventType := range endpoint.EventTypes {
+		if !domain.IsWebhookEventType(eventType) {
+			return fmt.Errorf("%w: unknown event type %s", domain.ErrInvalidWebhook, eventType)
+		}
+		if seen[eventType] {
+			return fmt.Errorf("%w: event type %s listed twice", domain.ErrInvalidWebhook, eventType)
+		}
+		seen[eventType] = true
+	}
+
+	return nil
+}
+
+// checkEndpointAddress refuses an endpoint whose host is, or resolves to, an
+// address webhooks may not reach. Deliveries check again when connecting.
+func (s *WebhookService) checkEndpointAddress(ctx context.Context, endpoint *domain.WebhookEndpoint) error {
+	if s.allowPrivate {
+		return nil
+	}
+
+	parsed, err := url.Parse(endpoint.URL)
+	if err != nil {
+		return fmt.Errorf("%w: url must be an absolute http or https URL", domain.ErrInvalidWebhook)
+	}
+	host := parsed.Hostname()
+
+	addrs, err := s.lookupIP(ctx, host)
+	if err != nil || len(addrs) == 0 {
+		return fmt.Errorf("%w: host %s does not resolve", domain.ErrInvalidWebhook, host)
+	}
+	for _, addr := range addrs {
+		if !webhookAddressAllowed(addr.IP) {
+			return fmt.Errorf("%w: host %s is not publicly routable", domain.ErrInvalidWebhook, host)
+		}
+	}
+
+	return nil
+}
+
+// webhookAddressAllowed reports whether webhooks may connect to ip. Loopback,
+// private, link-local (including cloud metadata at 169.254.169.254),
+// multicast and unspecified addresses are refused.
+func webhookAddressAllowed(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return false
+	}
+	for _, network := range blockedWebhookNetworks {
+		if network.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// refuseBlockedWebhookAddress is a net.Dialer Control function that refuses
+// connections to addresses webhooks may not reach
+func refuseBlockedWebhookAddress(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || !webhookAddressAllowed(ip) {
+		return fmt.Errorf("%w: %s", errWebhookAddressBlocked, host)
+	}
+	return nil
+}
+
+// generateWebhookSecret creates a random signing secret
+func generateWebhookSecret() (string, error) {
+	randomBytes := make([]byte, 24)
+	if _, err := rand.Read(randomBytes); err != nil {
+		return "", fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+	return WebhookSecretPrefix + hex.EncodeToString(randomBytes), nil
+}
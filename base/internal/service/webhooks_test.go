@@ -0,0 +1,410 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/saintparish4/harmonia/internal/domain"
+)
+
+// fakeWebhookRepo serves claimed deliveries from memory and records attempts
+type fakeWebhookRepo struct {
+	domain.WebhookRepository
+	mu         sync.Mutex
+	dispatched int
+	due        []*domain.WebhookDelivery
+	recorded   []*domain.WebhookDelivery
+	attempts   []*domain.WebhookAttempt
+}
+
+func (r *fakeWebhookRepo) DispatchEvents(ctx context.Context, limit int, now time.Time) (int, error) {
+	r.dispatched++
+	return 0, nil
+}
+
+func (r *fakeWebhookRepo) ClaimDeliveries(ctx context.Context, limit int, now, leaseUntil time.Time) ([]*domain.WebhookDelivery, error) {
+	due := r.due
+	r.due = nil
+	return due, nil
+}
+
+func (r *fakeWebhookRepo) RecordAttempt(ctx context.Context, delivery *domain.WebhookDelivery, attempt *domain.WebhookAttempt) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.recorded = append(r.recorded, delivery)
+	r.attempts = append(r.attempts, attempt)
+	return nil
+}
+
+func TestSignWebhook(t *testing.T) {
+	body := []byte(`{"id":"1"}`)
+	mac := hmac.New(sha256.New, []byte("hm_whsec_test"))
+	mac.Write([]byte(`1767225600.{"id":"1"}`))
+	want := hex.EncodeToString(mac.Sum(nil))
+
+	if got := SignWebhook("hm_whsec_test", 1767225600, body); got != want {
+		t.Errorf("expected signature %s, got %s", want, got)
+	}
+	if SignWebhook("hm_whsec_other", 1767225600, body) == want {
+		t.Error("expected a different secret to change the signature")
+	}
+	if SignWebhook("hm_whsec_test", 1767225601, body) == want {
+		t.Error("expected a different timestamp to change the signature")
+	}
+}
+
+func TestWebhookRetryDelay(t *testing.T) {
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{3, 2 * time.Minute},
+		{6, 16 * time.Minute},
+		{10, 4*time.Hour + 16*time.Minute},
+		{11, 6 * time.Hour},
+		{50, 6 * time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("attempt %d", tt.attempt), func(t *testing.T) {
+			if got := WebhookRetryDelay(tt.attempt); got != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestValidateWebhookEndpoint(t *testing.T) {
+	tests := []struct {
+		name       string
+		url        string
+		eventTypes []string
+		wantErr    bool
+	}{
+		{name: "every event", url: "https://example.com/hooks"},
+		{name: "subscribed events", url: "http://localhost:8080/hooks", eventTypes: []string{domain.EventRuleCreated, domain.EventPriceBookRegenerated}},
+		{name: "relative url", url: "/hooks", wantErr: true},
+		{name: "unsupported scheme", url: "ftp://example.com/hooks", wantErr: true},
+		{name: "unknown event", url: "https://example.com/hooks", eventTypes: []string{"quote.redeemed"}, wantErr: true},
+		{name: "duplicate event", url: "https://example.com/hooks", eventTypes: []string{domain.EventRuleDeleted, domain.EventRuleDeleted}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateWebhookEndpoint(&domain.WebhookEndpoint{URL: tt.url, EventTypes: tt.eventTypes})
+			if tt.wantErr {
+				if !errors.Is(err, domain.ErrInvalidWebhook) {
+					t.Errorf("expected ErrInvalidWebhook, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestWebhookEndpointSubscribes(t *testing.T) {
+	all := &domain.WebhookEndpoint{}
+	if !all.Subscribes(domain.EventProductCostChanged) {
+		t.Error("expected an endpoint without event types to receive every event")
+	}
+
+	rules := &domain.WebhookEndpoint{EventTypes: []string{domain.EventRuleCreated, domain.EventRuleUpdated}}
+	if !rules.Subscribes(domain.EventRuleUpdated) {
+		t.Error("expected a subscribed event to be received")
+	}
+	if rules.Subscribes(domain.EventProductCostChanged) {
+		t.Error("expected an unsubscribed event to be skipped")
+	}
+}
+
+func TestWebhookProcess(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name         string
+		status       int
+		attempts     int // made before this one
+		wantStatus   string
+		wantNext     *time.Time
+		wantErrorHas string
+	}{
+		{
+			name:       "success",
+			status:     http.StatusOK,
+			wantStatus: domain.WebhookDeliverySucceeded,
+		},
+		{
+			name:         "server error is retried with backoff",
+			status:       http.StatusInternalServerError,
+			attempts:     2,
+			wantStatus:   domain.WebhookDeliveryPending,
+			wantNext:     timePtr(now.Add(2 * time.Minute)),
+			wantErrorHas: "status 500",
+		},
+		{
+			name:         "last attempt fails the delivery",
+			status:       http.StatusBadGateway,
+			attempts:     4,
+			wantStatus:   domain.WebhookDeliveryFailed,
+			wantErrorHas: "status 502",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			secret := "hm_whsec_test"
+			var gotBody []byte
+			var gotHeader http.Header
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotBody, _ = io.ReadAll(r.Body)
+				gotHeader = r.Header.Clone()
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte("ok"))
+			}))
+			defer server.Close()
+
+			event := domain.NewWebhookEvent(uuid.New(), domain.EventRuleCreated, map[string]interface{}{"name": "Standard"})
+			delivery := &domain.WebhookDelivery{
+				ID:       uuid.New(),
+				Event:    event,
+				Status:   domain.WebhookDeliveryPending,
+				Attempts: tt.attempts,
+				URL:      server.URL,
+				Secret:   secret,
+			}
+
+			repo := &fakeWebhookRepo{due: []*domain.WebhookDelivery{delivery}}
+			svc := NewWebhookService(repo, time.Second, 5, true)
+			svc.now = func() time.Time { return now }
+
+			svc.Process(context.Background())
+
+			if repo.dispatched != 1 {
+				t.Errorf("expected events to be dispatched once, got %d", repo.dispatched)
+			}
+			if len(repo.attempts) != 1 {
+				t.Fatalf("expected 1 recorded attempt, got %d", len(repo.attempts))
+			}
+
+			attempt := repo.attempts[0]
+			if attempt.Attempt != tt.attempts+1 {
+				t.Errorf("expected attempt %d, got %d", tt.attempts+1, attempt.Attempt)
+			}
+			if attempt.ResponseStatus == nil || *attempt.ResponseStatus != tt.status {
+				t.Errorf("expected response status %d, got %v", tt.status, attempt.ResponseStatus)
+			}
+			if attempt.ResponseBody != "ok" {
+				t.Errorf("expected response body %q, got %q", "ok", attempt.ResponseBody)
+			}
+			if !strings.Contains(attempt.Error, tt.wantErrorHas) || (tt.wantErrorHas == "" && attempt.Error != "") {
+				t.Errorf("expected error containing %q, got %q", tt.wantErrorHas, attempt.Error)
+			}
+
+			recorded := repo.recorded[0]
+			if recorded.Status != tt.wantStatus {
+				t.Errorf("expected status %s, got %s", tt.wantStatus, recorded.Status)
+			}
+			if !equalTimePtr(recorded.NextAttemptAt, tt.wantNext) {
+				t.Errorf("expected next attempt %v, got %v", tt.wantNext, recorded.NextAttemptAt)
+			}
+
+			// The request carries the event and a verifiable signature
+			if gotHeader.Get(WebhookEventHeader) != domain.EventRuleCreated {
+				t.Errorf("expected event header %s, got %s", domain.EventRuleCreated, gotHeader.Get(WebhookEventHeader))
+			}
+			if gotHeader.Get(WebhookDeliveryHeader) != delivery.ID.String() {
+				t.Errorf("expected delivery header %s, got %s", delivery.ID, gotHeader.Get(WebhookDeliveryHeader))
+			}
+			wantSignature := fmt.Sprintf("t=%d,v1=%s", now.Unix(), SignWebhook(secret, now.Unix(), gotBody))
+			if gotHeader.Get(WebhookSignatureHeader) != wantSignature {
+				t.Errorf("expected signature %s, got %s", wantSignature, gotHeader.Get(WebhookSignatureHeader))
+			}
+
+			var body webhookBody
+			if err := json.Unmarshal(gotBody, &body); err != nil {
+				t.Fatalf("failed to decode body: %v", err)
+			}
+			if body.ID != event.ID || body.Type != event.Type || body.Data["name"] != "Standard" {
+				t.Errorf("unexpected body %+v", body)
+			}
+		})
+	}
+}
+
+func TestWebhookProcessUnreachable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	url := server.URL
+	server.Close()
+
+	delivery := &domain.WebhookDelivery{
+		ID:     uuid.New(),
+		Event:  domain.NewWebhookEvent(uuid.New(), domain.EventRuleDeleted, nil),
+		Status: domain.WebhookDeliveryPending,
+		URL:    url,
+		Secret: "hm_whsec_test",
+	}
+
+	repo := &fakeWebhookRepo{due: []*domain.WebhookDelivery{delivery}}
+	svc := NewWebhookService(repo, time.Second, 5, true)
+	svc.Process(context.Background())
+
+	if len(repo.attempts) != 1 {
+		t.Fatalf("expected 1 recorded attempt, got %d", len(repo.attempts))
+	}
+	if repo.attempts[0].ResponseStatus != nil || repo.attempts[0].Error == "" {
+		t.Errorf("expected a connection error without a status, got %+v", repo.attempts[0])
+	}
+	if repo.recorded[0].Status != domain.WebhookDeliveryPending || repo.recorded[0].NextAttemptAt == nil {
+		t.Errorf("expected the delivery to be retried, got %+v", repo.recorded[0])
+	}
+}
+
+func TestWebhookAddressAllowed(t *testing.T) {
+	tests := []struct {
+		ip   string
+		want bool
+	}{
+		{"93.184.216.34", true},
+		{"2606:2800:220:1:248:1893:25c8:1946", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"::ffff:127.0.0.1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"172.31.255.254", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"fd00:ec2::254", false},
+		{"fe80::1", false},
+		{"100.64.0.1", false},
+		{"0.0.0.0", false},
+		{"224.0.0.1", false},
+		{"64:ff9b::a00:1", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ip, func(t *testing.T) {
+			if got := webhookAddressAllowed(net.ParseIP(tt.ip)); got != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestWebhookCreateEndpointRefusesInternalAddresses(t *testing.T) {
+	// Names resolve from this table instead of DNS
+	hosts := map[string][]net.IPAddr{
+		"hooks.example.com":    {{IP: net.ParseIP("93.184.216.34")}},
+		"localhost":            {{IP: net.ParseIP("127.0.0.1")}, {IP: net.ParseIP("::1")}},
+		"internal.example.com": {{IP: net.ParseIP("93.184.216.34")}, {IP: net.ParseIP("10.0.0.7")}},
+	}
+
+	tests := []struct {
+		name    string
+		url     string
+		wantErr bool
+	}{
+		{name: "public host", url: "https://hooks.example.com/harmonia"},
+		{name: "public address", url: "https://93.184.216.34/harmonia"},
+		{name: "loopback", url: "http://127.0.0.1:8080/hooks", wantErr: true},
+		{name: "ipv6 loopback", url: "http://[::1]/hooks", wantErr: true},
+		{name: "localhost", url: "http://localhost:8080/hooks", wantErr: true},
+		{name: "private 10/8", url: "http://10.0.0.5/hooks", wantErr: true},
+		{name: "private 172.16/12", url: "http://172.20.1.1/hooks", wantErr: true},
+		{name: "private 192.168/16", url: "http://192.168.0.10/hooks", wantErr: true},
+		{name: "cloud metadata", url: "http://169.254.169.254/latest/meta-data/", wantErr: true},
+		{name: "name with a private address", url: "https://internal.example.com/hooks", wantErr: true},
+		{name: "unresolvable name", url: "https://missing.example.com/hooks", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := NewWebhookService(&fakeWebhookRepo{}, time.Second, 5, false)
+			svc.lookupIP = func(ctx context.Context, host string) ([]net.IPAddr, error) {
+				if ip := net.ParseIP(host); ip != nil {
+					return []net.IPAddr{{IP: ip}}, nil
+				}
+				addrs, ok := hosts[host]
+				if !ok {
+					return nil, fmt.Errorf("no such host %s", host)
+				}
+				return addrs, nil
+			}
+
+			endpoint := &domain.WebhookEndpoint{URL: tt.url}
+			err := svc.checkEndpointAddress(context.Background(), endpoint)
+			if !tt.wantErr {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, domain.ErrInvalidWebhook) {
+				t.Errorf("expected ErrInvalidWebhook from the address check, got %v", err)
+			}
+			if err := svc.CreateEndpoint(context.Background(), endpoint); !errors.Is(err, domain.ErrInvalidWebhook) {
+				t.Errorf("expected CreateEndpoint to refuse, got %v", err)
+			}
+		})
+	}
+}
+
+func TestWebhookProcessRefusesInternalAddresses(t *testing.T) {
+	// The endpoint passed registration, but now resolves to loopback
+	hit := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hit = true
+		_, _ = w.Write([]byte("internal secret"))
+	}))
+	defer server.Close()
+
+	delivery := &domain.WebhookDelivery{
+		ID:     uuid.New(),
+		Event:  domain.NewWebhookEvent(uuid.New(), domain.EventRuleCreated, nil),
+		Status: domain.WebhookDeliveryPending,
+		URL:    server.URL,
+		Secret: "hm_whsec_test",
+	}
+
+	repo := &fakeWebhookRepo{due: []*domain.WebhookDelivery{delivery}}
+	svc := NewWebhookService(repo, time.Second, 5, false)
+	svc.Process(context.Background())
+
+	if hit {
+		t.Error("expected the loopback endpoint not to be contacted")
+	}
+	if len(repo.attempts) != 1 {
+		t.Fatalf("expected 1 recorded attempt, got %d", len(repo.attempts))
+	}
+	attempt := repo.attempts[0]
+	if attempt.ResponseStatus != nil || attempt.ResponseBody != "" {
+		t.Errorf("expected no response to be recorded, got %+v", attempt)
+	}
+	if !strings.Contains(attempt.Error, errWebhookAddressBlocked.Error()) {
+		t.Errorf("expected a blocked address error, got %q", attempt.Error)
+	}
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}